@@ -0,0 +1,190 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// Algorithm describes an algorithm referenced by a CMS/PKCS structure
+type Algorithm struct {
+	OID  asn1.ObjectIdentifier
+	Name string
+}
+
+// AlgorithmReport lists the algorithms found in a CMS/PKCS structure, grouped by purpose
+type AlgorithmReport struct {
+	Digest            []Algorithm
+	Signature         []Algorithm
+	KeyEncryption     []Algorithm
+	ContentEncryption []Algorithm
+	Weak              []Finding // Deprecated algorithms and weak parameters
+}
+
+// GetAlgorithmName returns a human-readable name of the algorithm OID
+func GetAlgorithmName(oid asn1.ObjectIdentifier) string {
+	if info, ok := LookupOID(oid); ok && info.Category.IsAlgorithm() {
+		return info.Name
+	}
+
+	return describeUnknownOID("Unknown algorithm", oid)
+}
+
+// InspectAlgorithms lists every digest, signature, key encryption and content
+// encryption algorithm referenced by the CMS/PKCS structure, PKCS#12 container or encrypted
+// PKCS#8 key. Weak password-based encryption parameters are found with the PBEThresholds in effect
+func InspectAlgorithms(data []byte) (report *AlgorithmReport, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	report = &AlgorithmReport{}
+
+	contentInfo, err := parseContentInfo(data)
+	if err == nil {
+		report.addContentInfo(contentInfo)
+		report.flagWeakAlgorithms()
+
+		return report, nil
+	}
+
+	// PKCS#12 containers and PKCS#8 keys are not wrapped in ContentInfo
+	if contents, pfxErr := parsePFX(data); pfxErr == nil {
+		report.addPFX(contents)
+		report.flagWeakAlgorithms()
+
+		return report, nil
+	}
+
+	if kind, ok := detectPKCS8(data); ok && kind == KindEncryptedPrivateKey {
+		var key encryptedPrivateKeyInfo
+		if _, keyErr := asn1.Unmarshal(data, &key); keyErr == nil {
+			report.addContentEncryption(key.Algorithm)
+			report.flagWeakAlgorithms()
+
+			return report, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+}
+
+// all returns the OIDs of all algorithms in the report
+func (r *AlgorithmReport) all() []asn1.ObjectIdentifier {
+	var oids []asn1.ObjectIdentifier
+
+	for _, group := range [][]Algorithm{r.Digest, r.Signature, r.KeyEncryption, r.ContentEncryption} {
+		for _, alg := range group {
+			oids = append(oids, alg.OID)
+		}
+	}
+
+	return oids
+}
+
+// appendAlgorithm adds the algorithm to the list unless it is already there
+func appendAlgorithm(list []Algorithm, oid asn1.ObjectIdentifier) []Algorithm {
+	if len(oid) == 0 {
+		return list
+	}
+
+	for _, alg := range list {
+		if alg.OID.Equal(oid) {
+			return list
+		}
+	}
+
+	return append(list, newAlgorithm(oid))
+}
+
+// newAlgorithm describes the algorithm OID
+func newAlgorithm(oid asn1.ObjectIdentifier) Algorithm {
+	return Algorithm{OID: oid, Name: GetAlgorithmName(oid)}
+}
+
+// addContentInfo collects the algorithms of the supported CMS content types
+func (r *AlgorithmReport) addContentInfo(contentInfo ContentInfo) {
+	if sd, err := parseSignedData(contentInfo); err == nil {
+		for _, alg := range sd.DigestAlgorithms {
+			r.Digest = appendAlgorithm(r.Digest, alg.Algorithm)
+		}
+
+		for _, si := range sd.SignerInfos {
+			r.Digest = appendAlgorithm(r.Digest, si.DigestAlgorithm.Algorithm)
+			r.Signature = appendAlgorithm(r.Signature, si.SignatureAlgorithm.Algorithm)
+		}
+
+		for _, cert := range parseCertificates(sd.Certificates) {
+			r.checkCertificate(cert)
+		}
+	}
+
+	if ed, err := parseEnvelopedData(contentInfo); err == nil {
+		for _, ri := range ed.RecipientInfos {
+			if alg, ok := keyEncryptionAlgorithm(ri); ok {
+				r.KeyEncryption = appendAlgorithm(r.KeyEncryption, alg.Algorithm)
+			}
+		}
+
+		r.addContentEncryption(ed.EncryptedContentInfo.ContentEncryptionAlgorithm)
+	}
+
+	if dd, err := parseDigestedData(contentInfo); err == nil {
+		r.Digest = appendAlgorithm(r.Digest, dd.DigestAlgorithm.Algorithm)
+	}
+
+	if ed, err := parseEncryptedData(contentInfo); err == nil {
+		r.addContentEncryption(ed.EncryptedContentInfo.ContentEncryptionAlgorithm)
+	}
+}
+
+// addPFX collects the integrity and privacy algorithms of a PKCS#12 container
+func (r *AlgorithmReport) addPFX(contents *pkcs12Contents) {
+	mac := contents.pfx.MacData
+	if len(mac.Mac.Algorithm.Algorithm) > 0 {
+		r.Digest = appendAlgorithm(r.Digest, mac.Mac.Algorithm.Algorithm)
+		r.checkIterations(mac.Mac.Algorithm.Algorithm, mac.Iterations)
+		r.checkSalt(mac.Mac.Algorithm.Algorithm, mac.MacSalt)
+	}
+
+	for _, info := range contents.encryptedInfos {
+		r.addContentEncryption(info.ContentEncryptionAlgorithm)
+	}
+
+	for _, bag := range contents.bags {
+		if bag.ID.Equal(PKCS12CertBagOID) {
+			r.checkCertBag(bag)
+		}
+
+		if !bag.ID.Equal(PKCS12ShroudedKeyBagOID) {
+			continue
+		}
+
+		var keyInfo encryptedPrivateKeyInfo
+		if _, err := asn1.Unmarshal(bag.Value.Bytes, &keyInfo); err != nil {
+			continue
+		}
+
+		r.addContentEncryption(keyInfo.Algorithm)
+	}
+}
+
+// addContentEncryption adds a content encryption algorithm, unwrapping PBES2 parameters
+func (r *AlgorithmReport) addContentEncryption(alg pkix.AlgorithmIdentifier) {
+	r.ContentEncryption = appendAlgorithm(r.ContentEncryption, alg.Algorithm)
+	r.checkPBEParameters(alg)
+
+	if !alg.Algorithm.Equal(pbes2OID) {
+		return
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return
+	}
+
+	r.ContentEncryption = appendAlgorithm(r.ContentEncryption, params.KeyDerivationFunc.Algorithm)
+	r.ContentEncryption = appendAlgorithm(r.ContentEncryption, params.EncryptionScheme.Algorithm)
+}
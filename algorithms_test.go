@@ -0,0 +1,151 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+// algorithmNamesOf returns the names of the given algorithms
+func algorithmNamesOf(algs []Algorithm) []string {
+	names := make([]string, 0, len(algs))
+	for _, alg := range algs {
+		names = append(names, alg.Name)
+	}
+
+	return names
+}
+
+// equalStrings checks if two string slices have the same elements in the same order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TestInspectAlgorithms tests the InspectAlgorithms function with different CMS types
+func TestInspectAlgorithms(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	pbes2Parameters, err := asn1.Marshal(
+		pbes2Params{
+			KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}},
+			EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: aesOID},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal PBES2 parameters: %v", err)
+	}
+
+	keyInfo := encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  pbes2OID,
+			Parameters: asn1.RawValue{FullBytes: pbes2Parameters},
+		},
+		EncryptedData: []byte{0x01},
+	}
+
+	certs := encryptedContentInfo{
+		ContentType:                PKCS7DataOID,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}},
+	}
+
+	tests := []struct {
+		name                      string
+		data                      []byte
+		expectedDigest            []string
+		expectedSignature         []string
+		expectedKeyEncryption     []string
+		expectedContentEncryption []string
+	}{
+		{
+			name:              "SignedData",
+			data:              createSignedData(t, sha256OID, rsaOID),
+			expectedDigest:    []string{"SHA-256"},
+			expectedSignature: []string{"RSA"},
+		},
+		{
+			name:                      "EnvelopedData",
+			data:                      createEnvelopedData(t, rsaOID, aesOID),
+			expectedKeyEncryption:     []string{"RSA"},
+			expectedContentEncryption: []string{"AES-256-CBC"},
+		},
+		{
+			name:                      "GOST EnvelopedData",
+			data:                      createEnvelopedData(t, GOSTKuznyechikKExp15OID, GOSTKuznyechikCTROID),
+			expectedKeyEncryption:     []string{"Kuznyechik KExp15 key wrap"},
+			expectedContentEncryption: []string{"Kuznyechik CTR-ACPKM"},
+		},
+		{
+			name: "PKCS#12",
+			data: createPFX(
+				t,
+				[]safeBag{createSafeBag(t, PKCS12ShroudedKeyBagOID, keyInfo)},
+				[]encryptedContentInfo{certs},
+			),
+			expectedDigest: []string{"SHA-256"},
+			expectedContentEncryption: []string{
+				"PBE with SHA-1 and 40 bit RC2-CBC",
+				"PBES2",
+				"PBKDF2",
+				"AES-256-CBC",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := InspectAlgorithms(tt.data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				groups := []struct {
+					name     string
+					actual   []Algorithm
+					expected []string
+				}{
+					{"digest", report.Digest, tt.expectedDigest},
+					{"signature", report.Signature, tt.expectedSignature},
+					{"key encryption", report.KeyEncryption, tt.expectedKeyEncryption},
+					{"content encryption", report.ContentEncryption, tt.expectedContentEncryption},
+				}
+
+				for _, group := range groups {
+					if names := algorithmNamesOf(group.actual); !equalStrings(names, group.expected) {
+						t.Errorf("Expected %s algorithms %v, got %v", group.name, group.expected, names)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestInspectAlgorithmsInvalidData tests InspectAlgorithms with invalid ASN.1 data
+func TestInspectAlgorithmsInvalidData(t *testing.T) {
+	if _, err := InspectAlgorithms([]byte{0x01, 0x02, 0x03}); err == nil {
+		t.Error("Expected error for invalid data, got nil")
+	}
+}
+
+// TestGetAlgorithmName tests the GetAlgorithmName function
+func TestGetAlgorithmName(t *testing.T) {
+	if name := GetAlgorithmName(GOSTR34112012512OID); name != "GOST R 34.11-2012 (512 bit)" {
+		t.Errorf("Unexpected name %s", name)
+	}
+
+	if name := GetAlgorithmName(asn1.ObjectIdentifier{1, 2, 3}); name != "Unknown algorithm: 1.2.3" {
+		t.Errorf("Unexpected name %s", name)
+	}
+}
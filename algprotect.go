@@ -0,0 +1,116 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// CMSAlgorithmProtectionAttributeOID identifies the cmsAlgorithmProtect signed attribute (RFC 6211)
+var CMSAlgorithmProtectionAttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 52}
+
+// asn1NULL is the DER encoding of NULL algorithm parameters, equivalent to absent parameters
+var asn1NULL = []byte{0x05, 0x00}
+
+// AlgorithmProtection describes the cmsAlgorithmProtect attribute of a signer
+type AlgorithmProtection struct {
+	Present            bool      // Indicates a cmsAlgorithmProtect signed attribute
+	DigestAlgorithm    Algorithm // Digest algorithm declared by the attribute
+	SignatureAlgorithm Algorithm // Signature algorithm declared by the attribute, if any
+	MACAlgorithm       Algorithm // MAC algorithm declared by the attribute, which is invalid in SignedData
+	Matches            bool      // Indicates that the declared algorithms equal the SignerInfo algorithms
+}
+
+// cmsAlgorithmProtection provides the ASN.1 structure of CMSAlgorithmProtection (RFC 6211, section 2)
+type cmsAlgorithmProtection struct {
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignatureAlgorithm pkix.AlgorithmIdentifier `asn1:"optional,tag:1"`
+	MACAlgorithm       pkix.AlgorithmIdentifier `asn1:"optional,tag:2"`
+}
+
+// AlgorithmProtections reports the cmsAlgorithmProtect attribute of each signer of the SignedData,
+// in the order of the SignerInfos, and whether the declared digest and signature algorithms match
+// the SignerInfo fields. A mismatch indicates that the algorithms were substituted after signing.
+// Signers without the attribute are reported with Present set to false
+func AlgorithmProtections(data []byte) (protections []AlgorithmProtection, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.SignedAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed attributes: %w", err)
+		}
+
+		values := attributeValues(attrs, CMSAlgorithmProtectionAttributeOID)
+		if len(values) == 0 {
+			protections = append(protections, AlgorithmProtection{})
+
+			continue
+		}
+
+		protection, err := checkAlgorithmProtection(values[0].FullBytes, si.DigestAlgorithm, si.SignatureAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse algorithm protection: %w", err)
+		}
+
+		protections = append(protections, protection)
+	}
+
+	return protections, nil
+}
+
+// checkAlgorithmProtection compares the algorithms of a CMSAlgorithmProtection value with the signer algorithms
+func checkAlgorithmProtection(value []byte, digest, signature pkix.AlgorithmIdentifier) (AlgorithmProtection, error) {
+	var attr cmsAlgorithmProtection
+
+	rest, err := asn1.Unmarshal(value, &attr)
+	if err != nil {
+		return AlgorithmProtection{}, err
+	}
+
+	if len(rest) > 0 {
+		return AlgorithmProtection{}, errors.New("trailing data after CMSAlgorithmProtection")
+	}
+
+	protection := AlgorithmProtection{
+		Present:         true,
+		DigestAlgorithm: newAlgorithm(attr.DigestAlgorithm.Algorithm),
+		Matches: sameAlgorithm(attr.DigestAlgorithm, digest) &&
+			len(attr.MACAlgorithm.Algorithm) == 0 && sameAlgorithm(attr.SignatureAlgorithm, signature),
+	}
+
+	if len(attr.SignatureAlgorithm.Algorithm) > 0 {
+		protection.SignatureAlgorithm = newAlgorithm(attr.SignatureAlgorithm.Algorithm)
+	}
+
+	if len(attr.MACAlgorithm.Algorithm) > 0 {
+		protection.MACAlgorithm = newAlgorithm(attr.MACAlgorithm.Algorithm)
+	}
+
+	return protection, nil
+}
+
+// sameAlgorithm compares algorithm identifiers, treating NULL parameters as absent
+func sameAlgorithm(a, b pkix.AlgorithmIdentifier) bool {
+	if !a.Algorithm.Equal(b.Algorithm) {
+		return false
+	}
+
+	paramsA, paramsB := a.Parameters.FullBytes, b.Parameters.FullBytes
+	if bytes.Equal(paramsA, asn1NULL) {
+		paramsA = nil
+	}
+
+	if bytes.Equal(paramsB, asn1NULL) {
+		paramsB = nil
+	}
+
+	return bytes.Equal(paramsA, paramsB)
+}
@@ -0,0 +1,125 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createAlgorithmProtection creates a cmsAlgorithmProtect attribute declaring the algorithms
+func createAlgorithmProtection(t *testing.T, digestOID, signatureOID, macOID asn1.ObjectIdentifier) attribute {
+	t.Helper()
+
+	return createAttribute(
+		t, CMSAlgorithmProtectionAttributeOID, cmsAlgorithmProtection{
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: digestOID},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureOID},
+			MACAlgorithm:       pkix.AlgorithmIdentifier{Algorithm: macOID},
+		},
+	)
+}
+
+// TestAlgorithmProtections tests the comparison of cmsAlgorithmProtect attributes with the signer algorithms
+func TestAlgorithmProtections(t *testing.T) {
+	sha1OID := asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	hmacOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+
+	tests := []struct {
+		name      string
+		attrs     []attribute
+		present   bool
+		matches   bool
+		signature string
+		expectErr bool
+	}{
+		{
+			name:  "No attribute",
+			attrs: []attribute{createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)},
+		},
+		{
+			name:      "Matching algorithms",
+			attrs:     []attribute{createAlgorithmProtection(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, nil)},
+			present:   true,
+			matches:   true,
+			signature: "SHA-256 with RSA",
+		},
+		{
+			name:      "Substituted digest algorithm",
+			attrs:     []attribute{createAlgorithmProtection(t, sha1OID, cmstest.SHA256WithRSAOID, nil)},
+			present:   true,
+			signature: "SHA-256 with RSA",
+		},
+		{
+			name:    "Missing signature algorithm",
+			attrs:   []attribute{createAlgorithmProtection(t, cmstest.SHA256OID, nil, nil)},
+			present: true,
+		},
+		{
+			name:      "MAC algorithm",
+			attrs:     []attribute{createAlgorithmProtection(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, hmacOID)},
+			present:   true,
+			signature: "SHA-256 with RSA",
+		},
+		{
+			name:      "Malformed",
+			attrs:     []attribute{createAttribute(t, CMSAlgorithmProtectionAttributeOID, 42)},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				si := createSignerInfo(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, tt.attrs, nil)
+
+				protections, err := AlgorithmProtections(createSignedDataWithSigners(t, []signerInfo{si}))
+				if (err != nil) != tt.expectErr {
+					t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+				}
+
+				if tt.expectErr {
+					return
+				}
+
+				if len(protections) != 1 {
+					t.Fatalf("Expected 1 signer, got %d", len(protections))
+				}
+
+				protection := protections[0]
+				if protection.Present != tt.present {
+					t.Errorf("Expected present %v, got %v", tt.present, protection.Present)
+				}
+
+				if protection.Matches != tt.matches {
+					t.Errorf("Expected matches %v, got %v", tt.matches, protection.Matches)
+				}
+
+				if protection.SignatureAlgorithm.Name != tt.signature {
+					t.Errorf("Expected signature algorithm %q, got %q", tt.signature, protection.SignatureAlgorithm.Name)
+				}
+			},
+		)
+	}
+
+	if _, err := AlgorithmProtections(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+}
+
+// TestSameAlgorithm tests that NULL parameters are equivalent to absent parameters
+func TestSameAlgorithm(t *testing.T) {
+	withNULL := pkix.AlgorithmIdentifier{Algorithm: cmstest.SHA256OID, Parameters: asn1.RawValue{FullBytes: asn1NULL}}
+	withoutParams := pkix.AlgorithmIdentifier{Algorithm: cmstest.SHA256OID}
+	withParams := pkix.AlgorithmIdentifier{Algorithm: cmstest.SHA256OID, Parameters: asn1.RawValue{FullBytes: []byte{0x02, 0x01, 0x01}}}
+
+	if !sameAlgorithm(withNULL, withoutParams) {
+		t.Error("Expected NULL parameters to equal absent parameters")
+	}
+
+	if sameAlgorithm(withParams, withoutParams) {
+		t.Error("Expected different parameters to differ")
+	}
+}
@@ -0,0 +1,228 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Family groups related kinds of cryptographic formats
+type Family int
+
+// Families of formats recognized by DetectAny
+const (
+	FamilyUnknown  Family = iota
+	FamilyCMS             // PKCS#7/CMS structures and PKCS#12 containers
+	FamilyX509            // X.509 certificates
+	FamilyPKCS8           // PKCS#8 private keys
+	FamilyPKCS10          // PKCS#10 certification requests
+	FamilyKeystore        // Java and BouncyCastle keystores
+	FamilySSH             // OpenSSH and PuTTY keys
+	FamilyPGP             // OpenPGP messages, keys and signatures
+	FamilyJOSE            // JWS and JWE objects
+	FamilyCOSE            // COSE messages
+	FamilyPEM             // PEM blocks with unrecognized contents
+	FamilyCustom          // Formats identified by user-defined rules
+	FamilyCMP             // Certificate Management Protocol messages
+	FamilyXMLDSig         // XML signature documents, including XAdES
+)
+
+// familyNames maps families to human-readable names
+var familyNames = map[Family]string{
+	FamilyUnknown:  "Unknown",
+	FamilyCMS:      "CMS/PKCS",
+	FamilyX509:     "X.509",
+	FamilyPKCS8:    "PKCS#8",
+	FamilyPKCS10:   "PKCS#10",
+	FamilyKeystore: "Keystore",
+	FamilySSH:      "SSH",
+	FamilyPGP:      "OpenPGP",
+	FamilyJOSE:     "JOSE",
+	FamilyCOSE:     "COSE",
+	FamilyPEM:      "PEM",
+	FamilyCustom:   "Custom",
+	FamilyCMP:      "CMP",
+	FamilyXMLDSig:  "XMLDSig",
+}
+
+// String returns a human-readable name of the family
+func (f Family) String() string {
+	if name, ok := familyNames[f]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Family(%d)", int(f))
+}
+
+// Confidence tells how reliable a detection is
+type Confidence int
+
+// Confidence levels of DetectAny results
+const (
+	ConfidenceLow    Confidence = iota // Heuristic match, e.g. encrypted PKCS#12 markers
+	ConfidenceMedium                   // Header match, e.g. binary OpenPGP packet or untagged COSE
+	ConfidenceHigh                     // Full structural match
+)
+
+// String returns a human-readable name of the confidence level
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("Confidence(%d)", int(c))
+	}
+}
+
+// ErrUnknownFormat is returned when the data does not match any supported format,
+// DetectAny wraps it in UnknownFormatError with hints about the data
+var ErrUnknownFormat = errors.New("unknown format")
+
+// AnyResult contains the best match found by DetectAny
+type AnyResult struct {
+	Family        Family
+	Kind          Kind
+	Confidence    Confidence
+	PEMType       string // PEM block type when the data is PEM encoded
+	Rule          string // Name of the first matching user-defined rule of a Detector, if any
+	NeedsPassword bool   // Indicates a passphrase is required to open the data, e.g. PKCS#12 or an encrypted key
+}
+
+// DetectAny tries every format family supported by the package in priority order
+// and returns the best match
+func DetectAny(data []byte) (result AnyResult, err error) {
+	defer recoverPanic(&err)
+
+	result, err = detectAny(data)
+	recordDetection(len(data), result.Kind, result.Confidence < ConfidenceHigh, err)
+
+	return result, err
+}
+
+// detectAny returns the best match for the data without reporting metrics
+func detectAny(data []byte) (AnyResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return AnyResult{}, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	if kind, ok := detectPGPArmor(trimmed); ok {
+		return AnyResult{Family: FamilyPGP, Kind: kind, Confidence: ConfidenceHigh}, nil
+	}
+
+	if bytes.HasPrefix(trimmed, []byte("-----BEGIN ")) {
+		return detectPEM(trimmed)
+	}
+
+	if result, ok := detectBinary(data); ok {
+		return result, nil
+	}
+
+	if key, err := DetectSSHKey(trimmed); err == nil {
+		return AnyResult{Family: FamilySSH, Kind: key.Kind, Confidence: ConfidenceHigh, NeedsPassword: key.Encrypted}, nil
+	}
+
+	if jose, err := DetectJOSE(trimmed); err == nil {
+		return AnyResult{Family: FamilyJOSE, Kind: jose.Kind, Confidence: ConfidenceHigh}, nil
+	}
+
+	if xmlsig, err := DetectXMLSignature(trimmed); err == nil {
+		return AnyResult{Family: FamilyXMLDSig, Kind: xmlsig.Kind, Confidence: ConfidenceHigh}, nil
+	}
+
+	return AnyResult{}, &UnknownFormatError{Hints: Hints(data)}
+}
+
+// detectPEM detects the contents of the first PEM block
+func detectPEM(data []byte) (AnyResult, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return AnyResult{}, &UnknownFormatError{Hints: Hints(data)}
+	}
+
+	return detectPEMBlock(block, data), nil
+}
+
+// detectPEMBlock detects the contents of a PEM block, given the text starting with the block
+func detectPEMBlock(block *pem.Block, data []byte) AnyResult {
+	if block.Type == pemTypeOpenSSHPrivateKey {
+		if key, err := DetectSSHKey(data); err == nil {
+			return AnyResult{Family: FamilySSH, Kind: KindOpenSSHPrivateKey, Confidence: ConfidenceHigh, PEMType: block.Type, NeedsPassword: key.Encrypted}
+		}
+	}
+
+	result, ok := detectBinary(block.Bytes)
+	if !ok {
+		result = AnyResult{Family: FamilyPEM, Kind: KindUnknown, Confidence: ConfidenceLow}
+	}
+
+	result.PEMType = block.Type
+
+	return result
+}
+
+// detectBinary detects binary formats, structural matches first and heuristics last
+func detectBinary(data []byte) (AnyResult, bool) {
+	if contents, err := parsePFX(data); err == nil {
+		return AnyResult{Family: FamilyCMS, Kind: KindPKCS12, Confidence: ConfidenceHigh, NeedsPassword: contents.needsPassword()}, true
+	}
+
+	if contentInfo, err := parseContentInfo(data); err == nil {
+		// Structures with encrypted content are recognized even without a kind, as AuthEnvelopedData
+		if kind := kindOfContentInfo(contentInfo); kind != KindUnknown || isEncryptedContent(contentInfo) {
+			return AnyResult{Family: FamilyCMS, Kind: kind, Confidence: ConfidenceHigh, NeedsPassword: needsPasswordContent(contentInfo)}, true
+		}
+	}
+
+	if kind, ok := detectX509(data); ok {
+		family := FamilyX509
+		if kind == KindCertificateRequest {
+			family = FamilyPKCS10
+		}
+
+		return AnyResult{Family: family, Kind: kind, Confidence: ConfidenceHigh}, true
+	}
+
+	if isCMPMessage(data) {
+		return AnyResult{Family: FamilyCMP, Kind: KindCMPMessage, Confidence: ConfidenceHigh}, true
+	}
+
+	if kind, ok := detectPKCS8(data); ok {
+		return AnyResult{Family: FamilyPKCS8, Kind: kind, Confidence: ConfidenceHigh, NeedsPassword: kind == KindEncryptedPrivateKey}, true
+	}
+
+	if keystore, err := DetectKeystore(data); err == nil {
+		if keystore.Kind == KindEncryptedPKCS12 {
+			return AnyResult{Family: FamilyCMS, Kind: keystore.Kind, Confidence: ConfidenceLow, NeedsPassword: true}, true
+		}
+
+		// BouncyCastle keystores have no magic number
+		confidence := ConfidenceHigh
+		if keystore.Kind == KindBKS {
+			confidence = ConfidenceMedium
+		}
+
+		return AnyResult{Family: FamilyKeystore, Kind: keystore.Kind, Confidence: confidence}, true
+	}
+
+	if cose, err := DetectCOSE(data); err == nil {
+		confidence := ConfidenceMedium
+		if cose.Tagged {
+			confidence = ConfidenceHigh
+		}
+
+		return AnyResult{Family: FamilyCOSE, Kind: cose.Kind, Confidence: confidence}, true
+	}
+
+	if kind, ok := detectPGPPacket(data); ok {
+		return AnyResult{Family: FamilyPGP, Kind: kind, Confidence: ConfidenceMedium}, true
+	}
+
+	return AnyResult{}, false
+}
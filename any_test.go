@@ -0,0 +1,183 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+// TestDetectAny tests detection across all supported format families
+func TestDetectAny(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	cert := createCertificate(t, "Test", key)
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "Test"}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate request: %v", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+
+	encryptedPKCS8, err := asn1.Marshal(
+		encryptedPrivateKeyInfo{
+			Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+			EncryptedData: []byte{0x01, 0x02, 0x03},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal encrypted private key: %v", err)
+	}
+
+	cose, err := hex.DecodeString("d28443a10126a0447465737442abcd")
+	if err != nil {
+		t.Fatalf("Failed to decode COSE message: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected AnyResult
+	}{
+		{
+			name:     "PKCS#7 Signed Data",
+			data:     createTestData(t, PKCS7SignedDataOID),
+			expected: AnyResult{Family: FamilyCMS, Kind: KindSignedData, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "PKCS#12",
+			data:     createPFX(t, []safeBag{createSafeBag(t, PKCS12SecretBagOID, []byte{0x01})}, nil),
+			expected: AnyResult{Family: FamilyCMS, Kind: KindPKCS12, Confidence: ConfidenceHigh, NeedsPassword: true},
+		},
+		{
+			name:     "Encrypted PKCS#12 heuristic",
+			data:     createMockPKCS12Key(t),
+			expected: AnyResult{Family: FamilyCMS, Kind: KindEncryptedPKCS12, Confidence: ConfidenceLow, NeedsPassword: true},
+		},
+		{
+			name:     "Certificate",
+			data:     cert,
+			expected: AnyResult{Family: FamilyX509, Kind: KindCertificate, Confidence: ConfidenceHigh},
+		},
+		{
+			name: "PEM certificate",
+			data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+			expected: AnyResult{
+				Family: FamilyX509, Kind: KindCertificate, Confidence: ConfidenceHigh, PEMType: "CERTIFICATE",
+			},
+		},
+		{
+			name:     "Certificate request",
+			data:     csr,
+			expected: AnyResult{Family: FamilyPKCS10, Kind: KindCertificateRequest, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "PKCS#8 private key",
+			data:     pkcs8,
+			expected: AnyResult{Family: FamilyPKCS8, Kind: KindPrivateKey, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "PKCS#8 encrypted private key",
+			data:     encryptedPKCS8,
+			expected: AnyResult{Family: FamilyPKCS8, Kind: KindEncryptedPrivateKey, Confidence: ConfidenceHigh, NeedsPassword: true},
+		},
+		{
+			name:     "PEM with unknown contents",
+			data:     pem.EncodeToMemory(&pem.Block{Type: "DH PARAMETERS", Bytes: []byte{0x30, 0x00}}),
+			expected: AnyResult{Family: FamilyPEM, Kind: KindUnknown, Confidence: ConfidenceLow, PEMType: "DH PARAMETERS"},
+		},
+		{
+			name:     "JKS",
+			data:     createJavaKeystore(jksMagic, 2, 1),
+			expected: AnyResult{Family: FamilyKeystore, Kind: KindJKS, Confidence: ConfidenceHigh},
+		},
+		{
+			name: "OpenSSH private key",
+			data: createOpenSSHPrivateKey(sshCipherNone),
+			expected: AnyResult{
+				Family: FamilySSH, Kind: KindOpenSSHPrivateKey, Confidence: ConfidenceHigh, PEMType: pemTypeOpenSSHPrivateKey,
+			},
+		},
+		{
+			name: "Encrypted OpenSSH private key",
+			data: createOpenSSHPrivateKey("aes256-ctr"),
+			expected: AnyResult{
+				Family: FamilySSH, Kind: KindOpenSSHPrivateKey, Confidence: ConfidenceHigh, PEMType: pemTypeOpenSSHPrivateKey,
+				NeedsPassword: true,
+			},
+		},
+		{
+			name:     "Armored OpenPGP public key",
+			data:     []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmQENBF...\n-----END PGP PUBLIC KEY BLOCK-----\n"),
+			expected: AnyResult{Family: FamilyPGP, Kind: KindPGPPublicKey, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "Binary OpenPGP public key",
+			data:     []byte{0xc6, 0x05, 0x04, 0x00, 0x00, 0x00, 0x00},
+			expected: AnyResult{Family: FamilyPGP, Kind: KindPGPPublicKey, Confidence: ConfidenceMedium},
+		},
+		{
+			name:     "JWS",
+			data:     []byte(encodeJOSEHeader(`{"alg":"RS256"}`) + ".e30.c2ln"),
+			expected: AnyResult{Family: FamilyJOSE, Kind: KindJWS, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "XAdES",
+			data:     []byte(`<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:Object><QualifyingProperties xmlns="http://uri.etsi.org/01903/v1.3.2#"/></ds:Object></ds:Signature>`),
+			expected: AnyResult{Family: FamilyXMLDSig, Kind: KindXAdES, Confidence: ConfidenceHigh},
+		},
+		{
+			name:     "COSE_Sign1",
+			data:     cose,
+			expected: AnyResult{Family: FamilyCOSE, Kind: KindCOSESign1, Confidence: ConfidenceHigh},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectAny(tt.data)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectAnyUnknown tests rejection of unsupported data
+func TestDetectAnyUnknown(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("plain text"), {0x30, 0x03, 0x02, 0x01, 0x01}} {
+		if _, err := DetectAny(data); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Expected error %v for %x, got %v", ErrUnknownFormat, data, err)
+		}
+	}
+}
+
+// TestFamilyString tests the names of families and confidence levels
+func TestFamilyString(t *testing.T) {
+	if name := FamilyCMS.String(); name != "CMS/PKCS" {
+		t.Errorf("Expected CMS/PKCS, got %s", name)
+	}
+
+	if name := ConfidenceMedium.String(); name != "medium" {
+		t.Errorf("Expected medium, got %s", name)
+	}
+}
@@ -0,0 +1,78 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Payload types reported for SignedData wrapping Apple formats
+const (
+	PayloadAppleConfigurationProfile = "Apple Configuration Profile"
+	PayloadAppleWalletPass           = "Apple Wallet Pass"
+)
+
+var (
+	// Markers of XML and binary property lists
+	xmlPlistMarker    = []byte("<plist")
+	binaryPlistMarker = []byte("bplist00")
+
+	// payloadTypeKey is present in the top-level dictionary of every configuration profile
+	payloadTypeKey = []byte("PayloadType")
+)
+
+// detectPayload returns the type of a recognized payload signed by the SignedData,
+// or an empty string when the content is detached or not recognized
+func detectPayload(contentInfo ContentInfo) string {
+	sd, err := parseSignedData(contentInfo)
+	if err != nil || !sd.EncapContentInfo.EContentType.Equal(PKCS7DataOID) {
+		return ""
+	}
+
+	// Failed CertRep messages carry no content
+	if _, ok := scepMessage(sd); ok {
+		return PayloadSCEP
+	}
+
+	content, ok := encapsulatedContent(sd.EncapContentInfo)
+	if !ok {
+		return ""
+	}
+
+	switch {
+	case isConfigurationProfile(content):
+		return PayloadAppleConfigurationProfile
+	case isWalletPass(content):
+		return PayloadAppleWalletPass
+	}
+
+	return ""
+}
+
+// isConfigurationProfile checks if the content is a property list describing a configuration profile
+func isConfigurationProfile(content []byte) bool {
+	content = scanWindow(bytes.TrimSpace(content))
+
+	isPlist := bytes.HasPrefix(content, binaryPlistMarker) ||
+		(bytes.HasPrefix(content, []byte("<")) && bytes.Contains(content, xmlPlistMarker))
+
+	return isPlist && bytes.Contains(content, payloadTypeKey)
+}
+
+// isWalletPass checks if the content is a pass.json or manifest.json of a Wallet pass
+func isWalletPass(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	if !bytes.HasPrefix(content, []byte("{")) {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return false
+	}
+
+	// pass.json carries the pass type, while manifest.json lists the hash of pass.json
+	_, isPass := fields["passTypeIdentifier"]
+	_, isManifest := fields["pass.json"]
+
+	return isPass || isManifest
+}
@@ -0,0 +1,74 @@
+package cmsdetector
+
+import (
+	"testing"
+)
+
+// TestDetectPayload tests recognition of Apple payloads signed by SignedData
+func TestDetectPayload(t *testing.T) {
+	profile := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadType</key>
+	<string>Configuration</string>
+</dict>
+</plist>`
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "XML configuration profile",
+			data:     createSignedDataWithContent(t, []byte(profile)),
+			expected: PayloadAppleConfigurationProfile,
+		},
+		{
+			name:     "Binary configuration profile",
+			data:     createSignedDataWithContent(t, []byte("bplist00\xd1\x01\x02[PayloadType")),
+			expected: PayloadAppleConfigurationProfile,
+		},
+		{
+			name:     "Wallet pass",
+			data:     createSignedDataWithContent(t, []byte(`{"formatVersion": 1, "passTypeIdentifier": "pass.com.example"}`)),
+			expected: PayloadAppleWalletPass,
+		},
+		{
+			name:     "Wallet pass manifest",
+			data:     createSignedDataWithContent(t, []byte(`{"pass.json": "2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"}`)),
+			expected: PayloadAppleWalletPass,
+		},
+		{
+			name:     "Plist without profile keys",
+			data:     createSignedDataWithContent(t, []byte(`<plist version="1.0"><dict/></plist>`)),
+			expected: "",
+		},
+		{
+			name:     "Unrelated JSON",
+			data:     createSignedDataWithContent(t, []byte(`{"name": "document"}`)),
+			expected: "",
+		},
+		{
+			name:     "Detached content",
+			data:     createTestData(t, PKCS7SignedDataOID),
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if result.Payload != tt.expected {
+					t.Errorf("Expected payload %q, got %q", tt.expected, result.Payload)
+				}
+			},
+		)
+	}
+}
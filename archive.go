@@ -0,0 +1,195 @@
+package cmsdetector
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxArchiveEntries limits the number of members classified by DetectArchive
+const maxArchiveEntries = 1 << 16
+
+// Magic numbers of archive formats
+var (
+	zipLocalFileHeader = []byte("PK\x03\x04")
+	zipEndOfDirectory  = []byte("PK\x05\x06")
+	gzipMagic          = []byte{0x1f, 0x8b}
+	tarMagic           = []byte("ustar")
+)
+
+// tarMagicOffset is the offset of the magic field in the first TAR header block (POSIX.1-1988)
+const tarMagicOffset = 257
+
+// ErrNotArchive is returned for data other than ZIP, TAR and gzip compressed TAR archives
+var ErrNotArchive = errors.New("not a ZIP or TAR archive")
+
+// containerEntry is a regular file read from an archive, e-mail message or PDF document, or the
+// error reading it
+type containerEntry struct {
+	name string
+	size int64
+	data []byte
+	err  error
+}
+
+// DetectArchive opens a ZIP, TAR or gzip compressed TAR archive and classifies every regular file
+// in it like Scanner does, e.g. to find key containers in backups. Members larger than MaxInputSize
+// are reported with ErrInputTooLarge, nested archives are not opened. If a TAR archive is truncated
+// or corrupt, the members read so far are returned with the error
+func DetectArchive(r io.ReaderAt, size int64) (files []ScannedFile, err error) {
+	defer recoverPanic(&err)
+
+	err = walkArchive(
+		r, size, func(entry containerEntry) {
+			if entry.err != nil {
+				files = append(files, ScannedFile{Path: entry.name, Size: entry.size, Err: entry.err})
+
+				return
+			}
+
+			files = append(files, classifyData(entry.name, entry.data))
+		},
+	)
+
+	return files, err
+}
+
+// walkArchive calls visit for every regular file of a ZIP, TAR or gzip compressed TAR archive
+func walkArchive(r io.ReaderAt, size int64, visit func(containerEntry)) error {
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+	n, err := r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, zipLocalFileHeader) || bytes.HasPrefix(header, zipEndOfDirectory):
+		archive, err := zip.NewReader(r, size)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrNotArchive, err)
+		}
+
+		return walkZIP(archive.File, visit)
+	case bytes.HasPrefix(header, gzipMagic):
+		decompressed, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrNotArchive, err)
+		}
+		defer decompressed.Close()
+
+		buffered := bufio.NewReaderSize(decompressed, tarMagicOffset+len(tarMagic))
+		if magic, _ := buffered.Peek(tarMagicOffset + len(tarMagic)); !isTARHeader(magic) {
+			return ErrNotArchive
+		}
+
+		return walkTAR(tar.NewReader(buffered), visit)
+	case isTARHeader(header):
+		return walkTAR(tar.NewReader(io.NewSectionReader(r, 0, size)), visit)
+	}
+
+	return ErrNotArchive
+}
+
+// hasArchiveMagic checks if the data starts like a ZIP, TAR or gzip compressed archive
+func hasArchiveMagic(data []byte) bool {
+	return bytes.HasPrefix(data, zipLocalFileHeader) || bytes.HasPrefix(data, zipEndOfDirectory) ||
+		bytes.HasPrefix(data, gzipMagic) || isTARHeader(data)
+}
+
+// isTARHeader checks if the block starts with a ustar or GNU TAR header
+func isTARHeader(block []byte) bool {
+	return len(block) >= tarMagicOffset+len(tarMagic) && bytes.Equal(block[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic)
+}
+
+// walkZIP calls visit for every regular file of a ZIP archive
+func walkZIP(members []*zip.File, visit func(containerEntry)) error {
+	if len(members) > maxArchiveEntries {
+		return fmt.Errorf("ZIP archive has %d entries, limit %d", len(members), maxArchiveEntries)
+	}
+
+	for _, member := range members {
+		if !member.Mode().IsRegular() {
+			continue
+		}
+
+		entry := containerEntry{name: member.Name, size: int64(member.UncompressedSize64)}
+
+		if exceedsMemberSize(member.UncompressedSize64) {
+			entry.err = ErrInputTooLarge
+		} else {
+			entry.data, entry.err = readZIPMember(member)
+		}
+
+		visit(entry)
+	}
+
+	return nil
+}
+
+// readZIPMember decompresses a file of a ZIP archive
+func readZIPMember(member *zip.File) ([]byte, error) {
+	reader, err := member.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	// The uncompressed size in the directory is not trusted to bound the decompressed data
+	return readAll(newSizeLimitedReader(reader))
+}
+
+// walkTAR calls visit for every regular file of a TAR archive
+func walkTAR(archive *tar.Reader, visit func(containerEntry)) error {
+	for entries := 0; ; entries++ {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read TAR archive: %w", err)
+		}
+
+		if entries == maxArchiveEntries {
+			return fmt.Errorf("TAR archive has more than %d entries", maxArchiveEntries)
+		}
+
+		if !header.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		entry := containerEntry{name: header.Name, size: header.Size}
+
+		// Skipped members are discarded by the next call to Next
+		if exceedsMemberSize(uint64(header.Size)) {
+			entry.err = ErrInputTooLarge
+			visit(entry)
+
+			continue
+		}
+
+		entry.data, err = readAll(archive)
+		if errors.Is(err, ErrInputTooLarge) {
+			entry.err = err
+		} else if err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+
+		visit(entry)
+	}
+}
+
+// exceedsMemberSize checks if an archive member of the size exceeds the maximum input size or allocation
+func exceedsMemberSize(size uint64) bool {
+	limits := CurrentLimits()
+
+	return limits.MaxInputSize > 0 && size > uint64(limits.MaxInputSize) ||
+		limits.MaxAllocation > 0 && size > uint64(limits.MaxAllocation)
+}
@@ -0,0 +1,199 @@
+package cmsdetector
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// archiveMember is a file or directory stored in a test archive
+type archiveMember struct {
+	name string
+	data []byte
+	dir  bool
+}
+
+// createZIP creates a ZIP archive with the members
+func createZIP(t *testing.T, members []archiveMember) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	for _, member := range members {
+		name := member.name
+		if member.dir {
+			name += "/"
+		}
+
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create ZIP entry: %v", err)
+		}
+
+		if _, err := w.Write(member.data); err != nil {
+			t.Fatalf("Failed to write ZIP entry: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close ZIP archive: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// createTAR creates a TAR archive with the members, gzip compressed if requested
+func createTAR(t *testing.T, members []archiveMember, compressed bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+
+	for _, member := range members {
+		header := &tar.Header{Name: member.name, Mode: 0o600, Size: int64(len(member.data)), Typeflag: tar.TypeReg, Format: tar.FormatUSTAR}
+		if member.dir {
+			header = &tar.Header{Name: member.name + "/", Mode: 0o755, Typeflag: tar.TypeDir, Format: tar.FormatUSTAR}
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write TAR header: %v", err)
+		}
+
+		if _, err := writer.Write(member.data); err != nil {
+			t.Fatalf("Failed to write TAR entry: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close TAR archive: %v", err)
+	}
+
+	if !compressed {
+		return buf.Bytes()
+	}
+
+	var gz bytes.Buffer
+	compressor := gzip.NewWriter(&gz)
+
+	if _, err := compressor.Write(buf.Bytes()); err != nil {
+		t.Fatalf("Failed to compress TAR archive: %v", err)
+	}
+
+	if err := compressor.Close(); err != nil {
+		t.Fatalf("Failed to close gzip stream: %v", err)
+	}
+
+	return gz.Bytes()
+}
+
+// TestDetectArchive tests classification of the members of ZIP and TAR archives
+func TestDetectArchive(t *testing.T) {
+	members := []archiveMember{
+		{name: "backup", dir: true},
+		{name: "backup/user.p12", data: cmstest.PFX(t)},
+		{name: "backup/signed.p7s", data: cmstest.SignedData(t)},
+		{name: "backup/notes.txt", data: []byte("Hello, world")},
+	}
+
+	expected := []struct {
+		path    string
+		kind    Kind
+		unknown bool
+	}{
+		{path: "backup/user.p12", kind: KindPKCS12},
+		{path: "backup/signed.p7s", kind: KindSignedData},
+		{path: "backup/notes.txt", unknown: true},
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "ZIP", data: createZIP(t, members)},
+		{name: "TAR", data: createTAR(t, members, false)},
+		{name: "TAR.GZ", data: createTAR(t, members, true)},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				files, err := DetectArchive(bytes.NewReader(tt.data), int64(len(tt.data)))
+				if err != nil {
+					t.Fatalf("DetectArchive returned an error: %v", err)
+				}
+
+				if len(files) != len(expected) {
+					t.Fatalf("Expected %d files, got %d", len(expected), len(files))
+				}
+
+				for i, file := range files {
+					if file.Path != expected[i].path {
+						t.Errorf("Expected path %s, got %s", expected[i].path, file.Path)
+					}
+
+					if unknown := errors.Is(file.Err, ErrUnknownFormat); unknown != expected[i].unknown {
+						t.Errorf("Expected unknown %v for %s, got error %v", expected[i].unknown, file.Path, file.Err)
+					}
+
+					if file.Result.Kind != expected[i].kind {
+						t.Errorf("Expected %v for %s, got %v", expected[i].kind, file.Path, file.Result.Kind)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestDetectArchiveErrors tests rejection of other formats and reporting of oversized and truncated members
+func TestDetectArchiveErrors(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	if _, err := DetectArchive(bytes.NewReader(signed), int64(len(signed))); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("Expected error %v, got %v", ErrNotArchive, err)
+	}
+
+	var compressed bytes.Buffer
+	compressor := gzip.NewWriter(&compressed)
+	_, _ = compressor.Write(signed)
+	_ = compressor.Close()
+
+	if _, err := DetectArchive(bytes.NewReader(compressed.Bytes()), int64(compressed.Len())); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("Expected error %v for gzip compressed CMS, got %v", ErrNotArchive, err)
+	}
+
+	empty := createZIP(t, nil)
+	if files, err := DetectArchive(bytes.NewReader(empty), int64(len(empty))); err != nil || len(files) != 0 {
+		t.Errorf("Expected no files of an empty archive, got %d files and error %v", len(files), err)
+	}
+
+	members := []archiveMember{
+		{name: "large.p12", data: make([]byte, 128)},
+		{name: "small.p7s", data: signed[:32]},
+	}
+
+	withLimits(t, Limits{MaxInputSize: 64})
+
+	for _, data := range [][]byte{createZIP(t, members), createTAR(t, members, false)} {
+		files, err := DetectArchive(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("DetectArchive returned an error: %v", err)
+		}
+
+		if len(files) != 2 || !errors.Is(files[0].Err, ErrInputTooLarge) || errors.Is(files[1].Err, ErrInputTooLarge) {
+			t.Errorf("Expected only the first member to be too large, got %+v", files)
+		}
+	}
+
+	truncated := createTAR(t, []archiveMember{{name: "signed.p7s", data: signed[:32]}, {name: "large.bin", data: make([]byte, 60)}}, false)[:1536+10]
+
+	files, err := DetectArchive(bytes.NewReader(truncated), int64(len(truncated)))
+	if err == nil || len(files) != 1 {
+		t.Errorf("Expected the first member and an error, got %d files and error %v", len(files), err)
+	}
+}
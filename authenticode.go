@@ -0,0 +1,132 @@
+package cmsdetector
+
+import (
+	"debug/pe"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SpcIndirectDataContentOID identifies Authenticode SpcIndirectDataContent (Microsoft Authenticode specification)
+var SpcIndirectDataContentOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 2, 1, 4}
+
+// WIN_CERTIFICATE constants (Microsoft PE format specification, "The Attribute Certificate Table")
+const (
+	WinCertRevision1          = 0x0100
+	WinCertRevision2          = 0x0200
+	WinCertTypeX509           = 0x0001
+	WinCertTypePKCSSignedData = 0x0002
+
+	winCertificateHeaderSize = 8
+	winCertificateAlignment  = 8
+
+	// maxSecurityDirectorySize limits the size of the attribute certificate table read into memory
+	maxSecurityDirectorySize = 16 << 20
+)
+
+// Errors returned by DetectAuthenticode
+var (
+	ErrNotPE       = errors.New("not a PE image")
+	ErrNotSignedPE = errors.New("PE image has no Authenticode signature")
+)
+
+// AuthenticodeSignature describes a signature embedded in the attribute certificate table of a PE image
+type AuthenticodeSignature struct {
+	Offset          int64                 // File offset of the WIN_CERTIFICATE entry
+	Revision        uint16                // WIN_CERTIFICATE revision
+	CertificateType uint16                // WIN_CERTIFICATE type, WinCertTypePKCSSignedData for Authenticode
+	ContentType     asn1.ObjectIdentifier // Signed content type, SpcIndirectDataContentOID for Authenticode
+	Result          DetectionResult       // Detection result of the embedded PKCS#7 structure
+	Data            []byte                // Embedded PKCS#7 SignedData
+}
+
+// IsIndirectData checks if the signature signs Authenticode SpcIndirectDataContent
+func (s AuthenticodeSignature) IsIndirectData() bool {
+	return s.ContentType.Equal(SpcIndirectDataContentOID)
+}
+
+// DetectAuthenticode locates the Security Directory of a Windows PE image and extracts
+// the PKCS#7 SignedData structures stored in its attribute certificate table
+func DetectAuthenticode(r io.ReaderAt, size int64) ([]AuthenticodeSignature, error) {
+	file, err := pe.NewFile(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotPE, err)
+	}
+
+	var (
+		directories [16]pe.DataDirectory
+		count       uint32
+	)
+
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		directories, count = header.DataDirectory, header.NumberOfRvaAndSizes
+	case *pe.OptionalHeader64:
+		directories, count = header.DataDirectory, header.NumberOfRvaAndSizes
+	default:
+		return nil, ErrNotPE
+	}
+
+	if count <= pe.IMAGE_DIRECTORY_ENTRY_SECURITY {
+		return nil, ErrNotSignedPE
+	}
+
+	// Unlike other directories, the security directory address is a file offset
+	security := directories[pe.IMAGE_DIRECTORY_ENTRY_SECURITY]
+	if security.VirtualAddress == 0 || security.Size == 0 {
+		return nil, ErrNotSignedPE
+	}
+
+	if int64(security.VirtualAddress)+int64(security.Size) > size || security.Size > maxSecurityDirectorySize {
+		return nil, fmt.Errorf("security directory out of bounds: offset %d, size %d", security.VirtualAddress, security.Size)
+	}
+
+	table := make([]byte, security.Size)
+	if _, err := r.ReadAt(table, int64(security.VirtualAddress)); err != nil {
+		return nil, fmt.Errorf("failed to read security directory: %w", err)
+	}
+
+	return parseCertificateTable(table, int64(security.VirtualAddress))
+}
+
+// parseCertificateTable parses the WIN_CERTIFICATE entries of the attribute certificate table
+func parseCertificateTable(table []byte, baseOffset int64) ([]AuthenticodeSignature, error) {
+	var signatures []AuthenticodeSignature
+
+	for offset := 0; offset+winCertificateHeaderSize <= len(table); {
+		length := int(binary.LittleEndian.Uint32(table[offset:]))
+		if length < winCertificateHeaderSize || offset+length > len(table) {
+			return nil, fmt.Errorf("invalid WIN_CERTIFICATE length %d at offset %d", length, baseOffset+int64(offset))
+		}
+
+		signature := AuthenticodeSignature{
+			Offset:          baseOffset + int64(offset),
+			Revision:        binary.LittleEndian.Uint16(table[offset+4:]),
+			CertificateType: binary.LittleEndian.Uint16(table[offset+6:]),
+			Data:            table[offset+winCertificateHeaderSize : offset+length],
+		}
+
+		if signature.CertificateType == WinCertTypePKCSSignedData {
+			if result, err := Detect(signature.Data); err == nil {
+				signature.Result = result
+			}
+
+			if sd, err := loadSignedData(signature.Data); err == nil {
+				signature.ContentType = sd.EncapContentInfo.EContentType
+			}
+		}
+
+		signatures = append(signatures, signature)
+
+		// Entries are aligned on 8-byte boundaries
+		offset += (length + winCertificateAlignment - 1) &^ (winCertificateAlignment - 1)
+	}
+
+	if len(signatures) == 0 {
+		return nil, ErrNotSignedPE
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,164 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// peHeaderOffset is the offset of the PE signature in the images created by createPE
+const peHeaderOffset = 0x40
+
+// createPE creates a minimal PE32 image with the given attribute certificate table
+func createPE(t *testing.T, certificateTable []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	dos := make([]byte, peHeaderOffset)
+	copy(dos, "MZ")
+	binary.LittleEndian.PutUint32(dos[0x3c:], peHeaderOffset)
+	buf.Write(dos)
+	buf.WriteString("PE\x00\x00")
+
+	optional := pe.OptionalHeader32{
+		Magic:               0x10b,
+		FileAlignment:       0x200,
+		SectionAlignment:    0x1000,
+		NumberOfRvaAndSizes: 16,
+	}
+
+	fileHeader := pe.FileHeader{
+		Machine:              pe.IMAGE_FILE_MACHINE_I386,
+		SizeOfOptionalHeader: uint16(binary.Size(optional)),
+	}
+
+	if len(certificateTable) > 0 {
+		offset := buf.Len() + binary.Size(fileHeader) + binary.Size(optional)
+		optional.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_SECURITY] = pe.DataDirectory{
+			VirtualAddress: uint32(offset),
+			Size:           uint32(len(certificateTable)),
+		}
+	}
+
+	for _, header := range []interface{}{fileHeader, optional} {
+		if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+			t.Fatalf("Failed to write PE header: %v", err)
+		}
+	}
+
+	buf.Write(certificateTable)
+
+	return buf.Bytes()
+}
+
+// createWinCertificate creates a WIN_CERTIFICATE entry padded to 8 bytes
+func createWinCertificate(certificateType uint16, data []byte) []byte {
+	entry := make([]byte, winCertificateHeaderSize, winCertificateHeaderSize+len(data)+winCertificateAlignment)
+	binary.LittleEndian.PutUint32(entry, uint32(winCertificateHeaderSize+len(data)))
+	binary.LittleEndian.PutUint16(entry[4:], WinCertRevision2)
+	binary.LittleEndian.PutUint16(entry[6:], certificateType)
+	entry = append(entry, data...)
+
+	for len(entry)%winCertificateAlignment != 0 {
+		entry = append(entry, 0)
+	}
+
+	return entry
+}
+
+// TestDetectAuthenticode tests extraction of Authenticode signatures from PE images
+func TestDetectAuthenticode(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	signedData := createSignedData(t, sha256OID, rsaOID)
+	table := append(
+		createWinCertificate(WinCertTypePKCSSignedData, signedData),
+		createWinCertificate(WinCertTypeX509, []byte{0x30, 0x00})...,
+	)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		expectedErr   error
+		expectedTypes []uint16
+	}{
+		{
+			name:          "Signed image",
+			data:          createPE(t, table),
+			expectedTypes: []uint16{WinCertTypePKCSSignedData, WinCertTypeX509},
+		},
+		{
+			name:        "Unsigned image",
+			data:        createPE(t, nil),
+			expectedErr: ErrNotSignedPE,
+		},
+		{
+			name:        "Not a PE image",
+			data:        signedData,
+			expectedErr: ErrNotPE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				signatures, err := DetectAuthenticode(bytes.NewReader(tt.data), int64(len(tt.data)))
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if len(signatures) != len(tt.expectedTypes) {
+					t.Fatalf("Expected %d signatures, got %d", len(tt.expectedTypes), len(signatures))
+				}
+
+				for i, signature := range signatures {
+					if signature.CertificateType != tt.expectedTypes[i] {
+						t.Errorf("Expected certificate type %d, got %d", tt.expectedTypes[i], signature.CertificateType)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestAuthenticodeSignatureResult tests detection of the embedded SignedData
+func TestAuthenticodeSignatureResult(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	signedData := createSignedData(t, sha256OID, rsaOID)
+	data := createPE(t, createWinCertificate(WinCertTypePKCSSignedData, signedData))
+
+	signatures, err := DetectAuthenticode(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	signature := signatures[0]
+	if signature.Result.Type != "PKCS#7 Signed Data" {
+		t.Errorf("Expected type %s, got %s", "PKCS#7 Signed Data", signature.Result.Type)
+	}
+
+	if !bytes.Equal(signature.Data[:len(signedData)], signedData) {
+		t.Error("Expected embedded SignedData to be returned")
+	}
+
+	if signature.IsIndirectData() {
+		t.Error("Expected PKCS#7 Data content not to be reported as SpcIndirectDataContent")
+	}
+}
+
+// TestParseCertificateTableInvalidLength tests rejection of truncated WIN_CERTIFICATE entries
+func TestParseCertificateTableInvalidLength(t *testing.T) {
+	table := createWinCertificate(WinCertTypePKCSSignedData, []byte{0x30, 0x00})
+	binary.LittleEndian.PutUint32(table, 0x1000)
+
+	if _, err := parseCertificateTable(table, 0); err == nil {
+		t.Error("Expected error for WIN_CERTIFICATE length beyond the table")
+	}
+}
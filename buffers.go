@@ -0,0 +1,81 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which scratch buffers are dropped instead of reused,
+// so a single large input does not keep its memory alive
+const maxPooledBufferSize = 1 << 20
+
+// scratchPool holds the buffers of intermediate data reused by decoders, e.g. while reading archive
+// members, stripping whitespace from base64 text or re-encoding BER
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		buffer := make([]byte, 0, 4096)
+
+		return &buffer
+	},
+}
+
+// getScratch returns an empty scratch buffer, to be returned with putScratch
+func getScratch() *[]byte {
+	buffer := scratchPool.Get().(*[]byte)
+	*buffer = (*buffer)[:0]
+
+	return buffer
+}
+
+// putScratch returns the scratch buffer to the pool unless it grew too large
+func putScratch(buffer *[]byte) {
+	if cap(*buffer) > maxPooledBufferSize {
+		return
+	}
+
+	scratchPool.Put(buffer)
+}
+
+// checkAllocation returns ErrInputTooLarge if a buffer of n bytes exceeds the maximum allocation
+func checkAllocation(n int) error {
+	if limit := CurrentLimits().MaxAllocation; limit > 0 && n > limit {
+		return fmt.Errorf("%w: buffer of %d bytes, allocation limit %d", ErrInputTooLarge, n, limit)
+	}
+
+	return nil
+}
+
+// readAll reads until EOF like io.ReadAll, growing a scratch buffer instead of a new slice, and
+// returns a copy of the exact size. Reading stops with ErrInputTooLarge once the data exceeds
+// the maximum allocation
+func readAll(r io.Reader) ([]byte, error) {
+	scratch := getScratch()
+	defer putScratch(scratch)
+
+	limit := CurrentLimits().MaxAllocation
+	b := *scratch
+
+	for {
+		if len(b) == cap(b) {
+			b = append(b, 0)[:len(b)]
+		}
+
+		n, err := r.Read(b[len(b):cap(b)])
+		b = b[:len(b)+n]
+		*scratch = b
+
+		if limit > 0 && len(b) > limit {
+			return nil, checkAllocation(len(b))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return append(make([]byte, 0, len(b)), b...), nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
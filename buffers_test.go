@@ -0,0 +1,94 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+// TestReadAll tests reading through a scratch buffer
+func TestReadAll(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 1000)
+	errRead := errors.New("read failed")
+
+	tests := []struct {
+		name          string
+		reader        io.Reader
+		maxAllocation int
+		expected      []byte
+		expectedErr   error
+	}{
+		{
+			name:     "Empty",
+			reader:   bytes.NewReader(nil),
+			expected: []byte{},
+		},
+		{
+			name:     "Larger than a pooled buffer",
+			reader:   iotest.HalfReader(bytes.NewReader(large)),
+			expected: large,
+		},
+		{
+			name:          "Exactly the maximum allocation",
+			reader:        bytes.NewReader(large),
+			maxAllocation: len(large),
+			expected:      large,
+		},
+		{
+			name:          "Exceeding the maximum allocation",
+			reader:        bytes.NewReader(large),
+			maxAllocation: len(large) - 1,
+			expectedErr:   ErrInputTooLarge,
+		},
+		{
+			name:        "Read error",
+			reader:      io.MultiReader(bytes.NewReader(large), iotest.ErrReader(errRead)),
+			expectedErr: errRead,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withLimits(t, Limits{MaxAllocation: tt.maxAllocation})
+
+				data, err := readAll(tt.reader)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if tt.expectedErr == nil && (!bytes.Equal(data, tt.expected) || cap(data) != len(tt.expected)) {
+					t.Errorf("Expected %d bytes, got %d bytes with capacity %d", len(tt.expected), len(data), cap(data))
+				}
+			},
+		)
+	}
+}
+
+// TestScratchPool tests that returned data does not share memory with pooled buffers
+func TestScratchPool(t *testing.T) {
+	first, err := readAll(bytes.NewReader([]byte("first")))
+	if err != nil {
+		t.Fatalf("readAll returned an error: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := readAll(bytes.NewReader([]byte("other"))); err != nil {
+			t.Fatalf("readAll returned an error: %v", err)
+		}
+	}
+
+	if string(first) != "first" {
+		t.Errorf("Expected %q, got %q", "first", first)
+	}
+
+	buffer := getScratch()
+	*buffer = make([]byte, 0, maxPooledBufferSize+1)
+	putScratch(buffer)
+
+	if buffer := getScratch(); cap(*buffer) > maxPooledBufferSize {
+		t.Errorf("Expected oversized buffers not to be pooled, got capacity %d", cap(*buffer))
+	}
+}
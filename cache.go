@@ -0,0 +1,150 @@
+package cmsdetector
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"sync"
+)
+
+// cacheOperation tells the results of Detect and DetectAny apart in a Cache
+type cacheOperation uint8
+
+// Operations with cached results
+const (
+	cacheDetect cacheOperation = iota
+	cacheDetectAny
+)
+
+// cacheKey identifies the result of an operation of a Detector configuration under the limits in
+// effect for an input
+type cacheKey struct {
+	operation  cacheOperation
+	strictness Strictness
+	rules      *RuleSet
+	limits     Limits
+	sum        [sha256.Size]byte
+}
+
+// cacheEntry holds the result of an operation
+type cacheEntry struct {
+	key       cacheKey
+	detection DetectionResult
+	any       AnyResult
+	err       error
+}
+
+// CacheStats contains the counters of a Cache
+type CacheStats struct {
+	Hits    uint64 // Lookups answered from the cache
+	Misses  uint64 // Lookups of inputs that were classified
+	Entries int    // Number of cached results
+}
+
+// Cache is a least recently used cache of the results of a Detector, keyed by the SHA-256 hash of
+// the input, the strictness and rules of the Detector and the limits in effect, so repeated inputs
+// such as tokens revalidated by a gateway are not parsed again. Every caller gets its own copy of
+// the result. Results depend on the registered OIDs, call Purge after RegisterOID or LoadOIDs. It is
+// safe for concurrent use
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[cacheKey]*list.Element
+	order   *list.List // Elements hold *cacheEntry, the most recently used first
+	hits    uint64
+	misses  uint64
+}
+
+// NewCache returns a cache holding the results of at most size inputs, at least one
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Cache{size: size, entries: make(map[cacheKey]*list.Element), order: list.New()}
+}
+
+// Stats returns the counters of the cache
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// Purge removes all cached results
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[cacheKey]*list.Element)
+	c.order.Init()
+}
+
+// get returns the cached result for the key, marking it as recently used
+func (c *Cache) get(key cacheKey) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+
+		return cacheEntry{}, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(element)
+
+	return element.Value.(*cacheEntry).clone(), true
+}
+
+// add caches the result, evicting the least recently used result if the cache is full. Errors
+// depending on the input size limit are not cached
+func (c *Cache) add(entry cacheEntry) {
+	if errors.Is(entry.err, ErrInputTooLarge) {
+		return
+	}
+
+	// The caller keeps the result it was given
+	entry = entry.clone()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[entry.key]; ok {
+		element.Value = &entry
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(&entry)
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// clone returns a copy of the entry whose result shares no slices with the entry
+func (e cacheEntry) clone() cacheEntry {
+	e.detection.ContentType = append(asn1.ObjectIdentifier(nil), e.detection.ContentType...)
+	e.detection.InnerContentType = append(asn1.ObjectIdentifier(nil), e.detection.InnerContentType...)
+	e.detection.Header = append([]byte(nil), e.detection.Header...)
+
+	return e
+}
+
+// cacheKey returns the key of the operation of the Detector for the data
+func (d *Detector) cacheKey(operation cacheOperation, data []byte) cacheKey {
+	return cacheKey{
+		operation:  operation,
+		strictness: d.strictness,
+		rules:      d.ruleSource,
+		limits:     CurrentLimits(),
+		sum:        sha256.Sum256(data),
+	}
+}
@@ -0,0 +1,195 @@
+package cmsdetector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestDetectorCache tests that repeated inputs are answered from the cache of a Detector
+func TestDetectorCache(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	text := []byte("Hello, world")
+
+	uncached, uncachedErr := (&Detector{}).Detect(signed)
+
+	detector := NewDetector(WithCache(NewCache(8)))
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected CacheStats
+	}{
+		{
+			name: "First Detect",
+			call: func() error {
+				result, err := detector.Detect(signed)
+				if !reflect.DeepEqual(result, uncached) || err != uncachedErr {
+					t.Errorf("Expected %+v, got %+v", uncached, result)
+				}
+
+				return err
+			},
+			expected: CacheStats{Misses: 1, Entries: 1},
+		},
+		{
+			name: "Repeated Detect",
+			call: func() error {
+				result, err := detector.Detect(signed)
+				if !reflect.DeepEqual(result, uncached) {
+					t.Errorf("Expected %+v, got %+v", uncached, result)
+				}
+
+				return err
+			},
+			expected: CacheStats{Hits: 1, Misses: 1, Entries: 1},
+		},
+		{
+			name: "DetectAny of the same input",
+			call: func() error {
+				result, err := detector.DetectAny(signed)
+				if result.Kind != KindSignedData {
+					t.Errorf("Expected %v, got %v", KindSignedData, result.Kind)
+				}
+
+				return err
+			},
+			expected: CacheStats{Hits: 1, Misses: 2, Entries: 2},
+		},
+		{
+			name: "Other strictness",
+			call: func() error {
+				_, err := NewDetector(WithStrictness(StrictnessStrict), WithCache(detector.cache)).Detect(signed)
+
+				return err
+			},
+			expected: CacheStats{Hits: 1, Misses: 3, Entries: 3},
+		},
+		{
+			name: "Repeated unknown format",
+			call: func() error {
+				_, _ = detector.DetectAny(text)
+				_, err := detector.DetectAny(text)
+				if !errors.Is(err, ErrUnknownFormat) {
+					t.Errorf("Expected %v, got %v", ErrUnknownFormat, err)
+				}
+
+				return nil
+			},
+			expected: CacheStats{Hits: 2, Misses: 4, Entries: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if err := tt.call(); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if stats := detector.cache.Stats(); stats != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, stats)
+				}
+			},
+		)
+	}
+
+	detector.cache.Purge()
+
+	if stats := detector.cache.Stats(); stats.Entries != 0 {
+		t.Errorf("Expected no entries after Purge, got %d", stats.Entries)
+	}
+}
+
+// TestCacheEviction tests that the least recently used result is evicted from a full cache
+func TestCacheEviction(t *testing.T) {
+	cache := NewCache(2)
+	detector := &Detector{}
+
+	keys := make([]cacheKey, 3)
+	for i := range keys {
+		keys[i] = detector.cacheKey(cacheDetect, []byte{byte(i)})
+	}
+
+	cache.add(cacheEntry{key: keys[0]})
+	cache.add(cacheEntry{key: keys[1]})
+	cache.get(keys[0])
+	cache.add(cacheEntry{key: keys[2]})
+
+	for i, expected := range []bool{true, false, true} {
+		if _, ok := cache.get(keys[i]); ok != expected {
+			t.Errorf("Expected input %d cached %v, got %v", i, expected, ok)
+		}
+	}
+
+	if stats := cache.Stats(); stats.Entries != 2 {
+		t.Errorf("Expected 2 entries, got %d", stats.Entries)
+	}
+}
+
+// TestCacheInputTooLarge tests that inputs exceeding the size limit bypass the cache
+func TestCacheInputTooLarge(t *testing.T) {
+	withLimits(t, Limits{MaxInputSize: 16})
+
+	detector := NewDetector(WithCache(NewCache(8)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := detector.Detect(make([]byte, 32)); !errors.Is(err, ErrInputTooLarge) {
+			t.Errorf("Expected %v, got %v", ErrInputTooLarge, err)
+		}
+	}
+
+	if stats := detector.cache.Stats(); stats != (CacheStats{}) {
+		t.Errorf("Expected no cache lookups, got %+v", stats)
+	}
+}
+
+// TestCacheResultsOwnedByCaller tests that modifying a result does not change later cache hits
+func TestCacheResultsOwnedByCaller(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	detector := NewDetector(WithCache(NewCache(8)))
+
+	expected, err := (&Detector{}).Detect(signed)
+	if err != nil {
+		t.Fatalf("Detect returned an error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := detector.Detect(signed)
+		if err != nil {
+			t.Fatalf("Detect returned an error: %v", err)
+		}
+
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("Expected %+v, got %+v", expected, result)
+		}
+
+		result.Header[0] = 0xFF
+		result.ContentType[0] = 0
+		result.InnerContentType[0] = 0
+	}
+}
+
+// TestCacheLimits tests that results obtained under other limits are not reused
+func TestCacheLimits(t *testing.T) {
+	withLimits(t, DefaultLimits())
+
+	pfx := cmstest.PFX(t)
+	detector := NewDetector(WithCache(NewCache(8)))
+
+	if result, err := detector.DetectAny(pfx); err != nil || !result.NeedsPassword {
+		t.Fatalf("Expected a PKCS#12 needing a password, got %+v, %v", result, err)
+	}
+
+	limits := DefaultLimits()
+	limits.MaxScanWindow = 1
+	SetLimits(limits)
+
+	_, _ = detector.DetectAny(pfx)
+
+	if stats := detector.cache.Stats(); stats != (CacheStats{Misses: 2, Entries: 2}) {
+		t.Errorf("Expected a miss after SetLimits, got %+v", stats)
+	}
+}
@@ -0,0 +1,100 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// CAdESLevel is a baseline level of ETSI EN 319 122-1, each level includes the requirements of the
+// levels below it
+type CAdESLevel int
+
+// Baseline levels of CAdES signatures
+const (
+	CAdESBaselineB   CAdESLevel = iota // B-B: basic signature with signed attributes
+	CAdESBaselineT                     // B-T: signature time-stamp
+	CAdESBaselineLT                    // B-LT: embedded revocation material
+	CAdESBaselineLTA                   // B-LTA: archive time-stamp
+)
+
+// String returns the name of the level as used by ETSI, e.g. "B-LT"
+func (l CAdESLevel) String() string {
+	switch l {
+	case CAdESBaselineB:
+		return "B-B"
+	case CAdESBaselineT:
+		return "B-T"
+	case CAdESBaselineLT:
+		return "B-LT"
+	case CAdESBaselineLTA:
+		return "B-LTA"
+	default:
+		return fmt.Sprintf("CAdESLevel(%d)", int(l))
+	}
+}
+
+// cadesSignedAttributes lists the signed attributes mandatory for B-B signatures
+var cadesSignedAttributes = []asn1.ObjectIdentifier{
+	ContentTypeAttributeOID,
+	MessageDigestAttributeOID,
+	SigningTimeAttributeOID,
+}
+
+// CheckCAdESBaseline checks SignedData against the requirements of a baseline level of ETSI EN
+// 319 122-1 (eIDAS) and reports which mandatory elements are missing:
+//
+//	B-B    contentType, messageDigest, signingTime and signing certificate signed attributes,
+//	       signer certificate embedded
+//	B-T    signature-time-stamp unsigned attribute
+//	B-LT   revocation material in the crls field or a revocation-values attribute
+//	B-LTA  archive-time-stamp-v3 unsigned attribute
+//
+// Signatures, time-stamps and certificate chains are not verified. Data other than SignedData is
+// rejected with ErrNotSignedData
+func CheckCAdESBaseline(data []byte, level CAdESLevel) (report *ProfileReport, err error) {
+	defer recoverPanic(&err)
+
+	if level < CAdESBaselineB || level > CAdESBaselineLTA {
+		return nil, fmt.Errorf("unknown CAdES baseline level %v", level)
+	}
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report = &ProfileReport{}
+	report.add(-1, ProfileRuleSigner, len(sd.SignerInfos) > 0, "%d signers", len(sd.SignerInfos))
+
+	certs := parseCertificates(sd.Certificates)
+	crls, responses := revocationInfoChoices(sd.CRLs)
+
+	for i, si := range sd.SignerInfos {
+		signed, unsigned, err := signerAttributes(si)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, oid := range cadesSignedAttributes {
+			report.add(i, ProfileRuleAttribute, len(attributeValues(signed, oid)) > 0, "signed attribute %s", GetOIDDescription(oid))
+		}
+
+		report.add(i, ProfileRuleAttribute, hasSigningCertificateAttribute(signed), "signed attribute %s", GetOIDDescription(SigningCertificateV2AttributeOID))
+		report.add(i, ProfileRuleCertificate, hasSignerCertificate(si, certs), "signer certificate embedded")
+
+		if level >= CAdESBaselineT {
+			report.add(i, ProfileRuleTimestamp, len(attributeValues(unsigned, TimeStampTokenAttributeOID)) > 0, "signature time-stamp")
+		}
+
+		if level >= CAdESBaselineLT {
+			hasRevocation := len(crls)+len(responses) > 0 || len(attributeValues(unsigned, RevocationValuesAttributeOID)) > 0
+			report.add(i, ProfileRuleRevocation, hasRevocation, "revocation values")
+		}
+
+		if level >= CAdESBaselineLTA {
+			report.add(i, ProfileRuleArchive, len(attributeValues(unsigned, ArchiveTimestampV3AttributeOID)) > 0, "archive time-stamp v3")
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,188 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createCAdES creates SignedData with a CAdES-BES signer carrying the unsigned attributes, its
+// certificate and the given RevocationInfoChoices in the crls field
+func createCAdES(t *testing.T, unsignedAttrs []attribute, crls ...[]byte) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	signedAttrs := []attribute{
+		createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID),
+		createAttribute(t, MessageDigestAttributeOID, make([]byte, 32)),
+		createAttribute(t, SigningTimeAttributeOID, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)),
+		createAttribute(t, SigningCertificateV2AttributeOID, asn1.RawValue{FullBytes: []byte{0x30, 0x00}}),
+	}
+
+	si := createSignerInfo(t, cmstest.SHA256OID, asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}, signedAttrs, unsignedAttrs)
+	si.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte("signer")}
+
+	cert := createCertificate(t, "signer", key)
+
+	sd := signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{si.DigestAlgorithm},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: PKCS7DataOID},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert},
+		SignerInfos:      []signerInfo{si},
+	}
+
+	if len(crls) > 0 {
+		var set []byte
+		for _, crl := range crls {
+			set = append(set, crl...)
+		}
+
+		sd.CRLs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: set}
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// TestCheckCAdESBaseline tests the checks of SignedData against the CAdES baseline levels
+func TestCheckCAdESBaseline(t *testing.T) {
+	// Placeholders for time-stamp tokens and CRLs
+	token := asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{0x05, 0x00}}
+	crl := []byte{0x30, 0x02, 0x05, 0x00}
+
+	timeStamp := createAttribute(t, TimeStampTokenAttributeOID, token)
+	revocationVals := createAttribute(t, RevocationValuesAttributeOID, revocationValues{CRLVals: []asn1.RawValue{token}})
+	archiveTimeStamp := createAttribute(t, ArchiveTimestampV3AttributeOID, token)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		level    CAdESLevel
+		expected []string
+	}{
+		{
+			name:  "B-B",
+			data:  createCAdES(t, nil),
+			level: CAdESBaselineB,
+		},
+		{
+			name:     "B-B checked for B-T",
+			data:     createCAdES(t, nil),
+			level:    CAdESBaselineT,
+			expected: []string{"FAIL signer 0: signature time-stamp [timestamp]"},
+		},
+		{
+			name:  "B-T",
+			data:  createCAdES(t, []attribute{timeStamp}),
+			level: CAdESBaselineT,
+		},
+		{
+			name:     "B-T checked for B-LT",
+			data:     createCAdES(t, []attribute{timeStamp}),
+			level:    CAdESBaselineLT,
+			expected: []string{"FAIL signer 0: revocation values [revocation]"},
+		},
+		{
+			name:  "B-LT with revocation values",
+			data:  createCAdES(t, []attribute{timeStamp, revocationVals}),
+			level: CAdESBaselineLT,
+		},
+		{
+			name:  "B-LT with CRLs",
+			data:  createCAdES(t, []attribute{timeStamp}, crl),
+			level: CAdESBaselineLT,
+		},
+		{
+			name:     "B-LT checked for B-LTA",
+			data:     createCAdES(t, []attribute{timeStamp, revocationVals}),
+			level:    CAdESBaselineLTA,
+			expected: []string{"FAIL signer 0: archive time-stamp v3 [archive-timestamp]"},
+		},
+		{
+			name:  "B-LTA",
+			data:  createCAdES(t, []attribute{timeStamp, revocationVals, archiveTimeStamp}),
+			level: CAdESBaselineLTA,
+		},
+		{
+			name:  "PKCS#7 signature",
+			data:  createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			level: CAdESBaselineT,
+			expected: []string{
+				"FAIL signer 0: signed attribute " + GetOIDDescription(ContentTypeAttributeOID) + " [attribute]",
+				"FAIL signer 0: signed attribute " + GetOIDDescription(MessageDigestAttributeOID) + " [attribute]",
+				"FAIL signer 0: signed attribute " + GetOIDDescription(SigningTimeAttributeOID) + " [attribute]",
+				"FAIL signer 0: signed attribute " + GetOIDDescription(SigningCertificateV2AttributeOID) + " [attribute]",
+				"FAIL signer 0: signer certificate embedded [certificate]",
+				"FAIL signer 0: signature time-stamp [timestamp]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := CheckCAdESBaseline(tt.data, tt.level)
+				if err != nil {
+					t.Fatalf("CheckCAdESBaseline returned an error: %v", err)
+				}
+
+				var failures []string
+				for _, check := range report.Failures() {
+					failures = append(failures, check.String())
+				}
+
+				if !reflect.DeepEqual(failures, tt.expected) {
+					t.Errorf("Expected failures %q, got %q", tt.expected, failures)
+				}
+
+				if passed := len(tt.expected) == 0; report.Passed() != passed {
+					t.Errorf("Expected passed %v, got %v", passed, report.Passed())
+				}
+			},
+		)
+	}
+
+	if _, err := CheckCAdESBaseline(createTestData(t, PKCS7DataOID), CAdESBaselineB); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected error %v, got %v", ErrNotSignedData, err)
+	}
+
+	if _, err := CheckCAdESBaseline(createCAdES(t, nil), CAdESLevel(4)); err == nil {
+		t.Error("Expected error for unknown level, got nil")
+	}
+}
+
+// TestCAdESLevelString tests the names of the CAdES baseline levels
+func TestCAdESLevelString(t *testing.T) {
+	tests := []struct {
+		level    CAdESLevel
+		expected string
+	}{
+		{level: CAdESBaselineB, expected: "B-B"},
+		{level: CAdESBaselineT, expected: "B-T"},
+		{level: CAdESBaselineLT, expected: "B-LT"},
+		{level: CAdESBaselineLTA, expected: "B-LTA"},
+		{level: CAdESLevel(7), expected: "CAdESLevel(7)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.expected, func(t *testing.T) {
+				if result := tt.level.String(); result != tt.expected {
+					t.Errorf("Expected %q, got %q", tt.expected, result)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,107 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"sort"
+)
+
+// nativeAlgorithms lists the algorithms implemented by Go's standard crypto packages
+var nativeAlgorithms = map[string]bool{
+	"1.2.840.113549.2.5":      true, // MD5
+	"1.3.14.3.2.26":           true, // SHA-1
+	"2.16.840.1.101.3.4.2.4":  true, // SHA-224
+	"2.16.840.1.101.3.4.2.1":  true, // SHA-256
+	"2.16.840.1.101.3.4.2.2":  true, // SHA-384
+	"2.16.840.1.101.3.4.2.3":  true, // SHA-512
+	"2.16.840.1.101.3.4.2.5":  true, // SHA-512/224
+	"2.16.840.1.101.3.4.2.6":  true, // SHA-512/256
+	"1.2.840.113549.1.1.1":    true, // RSA
+	"1.2.840.113549.1.1.4":    true, // MD5 with RSA
+	"1.2.840.113549.1.1.5":    true, // SHA-1 with RSA
+	"1.2.840.113549.1.1.7":    true, // RSAES-OAEP
+	"1.2.840.113549.1.1.10":   true, // RSASSA-PSS
+	"1.2.840.113549.1.1.11":   true, // SHA-256 with RSA
+	"1.2.840.113549.1.1.12":   true, // SHA-384 with RSA
+	"1.2.840.113549.1.1.13":   true, // SHA-512 with RSA
+	"1.2.840.113549.1.1.14":   true, // SHA-224 with RSA
+	"1.2.840.10040.4.1":       true, // DSA
+	"1.2.840.10040.4.3":       true, // DSA with SHA-1
+	"2.16.840.1.101.3.4.3.2":  true, // DSA with SHA-256
+	"1.2.840.10045.2.1":       true, // EC public key
+	"1.2.840.10045.4.1":       true, // ECDSA with SHA-1
+	"1.2.840.10045.4.3.1":     true, // ECDSA with SHA-224
+	"1.2.840.10045.4.3.2":     true, // ECDSA with SHA-256
+	"1.2.840.10045.4.3.3":     true, // ECDSA with SHA-384
+	"1.2.840.10045.4.3.4":     true, // ECDSA with SHA-512
+	"1.3.101.112":             true, // Ed25519
+	"1.3.132.1.11.1":          true, // ECDH with SHA-256 KDF
+	"1.3.132.1.11.2":          true, // ECDH with SHA-384 KDF
+	"1.3.132.1.11.3":          true, // ECDH with SHA-512 KDF
+	"2.16.840.1.101.3.4.1.5":  true, // AES-128 key wrap
+	"2.16.840.1.101.3.4.1.25": true, // AES-192 key wrap
+	"2.16.840.1.101.3.4.1.45": true, // AES-256 key wrap
+	"2.16.840.1.101.3.4.1.6":  true, // AES-128-GCM
+	"2.16.840.1.101.3.4.1.26": true, // AES-192-GCM
+	"2.16.840.1.101.3.4.1.46": true, // AES-256-GCM
+	"1.3.14.3.2.7":            true, // DES-CBC
+	"1.2.840.113549.1.5.12":   true, // PBKDF2
+	"1.2.840.113549.1.5.13":   true, // PBES2
+}
+
+// CapabilityReport states whether the algorithms of a CMS/PKCS structure are available without an
+// external crypto provider
+type CapabilityReport struct {
+	Kind        Kind
+	Native      bool        // Indicates that Go's standard crypto packages implement every algorithm
+	Providers   []string    // External providers implementing the other algorithms, e.g. ProviderKalkanCrypt
+	Unsupported []Algorithm // Algorithms not implemented by the standard crypto packages
+}
+
+// Capabilities reports whether Go's standard crypto packages can verify or decrypt the CMS/PKCS
+// structure, based on the algorithms listed by InspectAlgorithms. Password-based encryption of
+// PKCS#12 containers counts as native when OpenPKCS12 supports it. Algorithms of the Kazakhstan
+// and Russian national arcs are attributed to KalkanCrypt and CryptoPro CSP, other unsupported
+// algorithms leave Providers empty
+func Capabilities(data []byte) (report CapabilityReport, err error) {
+	defer recoverPanic(&err)
+
+	algorithms, err := InspectAlgorithms(data)
+	if err != nil {
+		return CapabilityReport{}, err
+	}
+
+	if result, err := detect(data); err == nil {
+		report.Kind = result.Kind
+	}
+
+	providers := make(map[string]bool)
+
+	for _, oid := range algorithms.all() {
+		if isNativeAlgorithm(oid) {
+			continue
+		}
+
+		report.Unsupported = appendAlgorithm(report.Unsupported, oid)
+
+		if provider := providerOf(oid); provider != "" && !providers[provider] {
+			providers[provider] = true
+			report.Providers = append(report.Providers, provider)
+		}
+	}
+
+	sort.Strings(report.Providers)
+	report.Native = len(report.Unsupported) == 0
+
+	return report, nil
+}
+
+// isNativeAlgorithm checks if the algorithm is implemented by the standard library or the PBE
+// schemes of OpenPKCS12
+func isNativeAlgorithm(oid asn1.ObjectIdentifier) bool {
+	key := oid.String()
+	_, pkcs12PBE := pkcs12PBECiphers[key]
+	_, pbes2Cipher := pbes2Ciphers[key]
+	_, pbkdf2PRF := pbkdf2Hashes[key]
+
+	return nativeAlgorithms[key] || pkcs12PBE || pbes2Cipher || pbkdf2PRF
+}
@@ -0,0 +1,102 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestCapabilities tests the report of algorithms requiring an external crypto provider
+func TestCapabilities(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	kazakhDigestOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 3, 1}
+	kazakhSignatureOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 2}
+	camelliaOID := asn1.ObjectIdentifier{1, 2, 392, 200011, 61, 1, 1, 1, 4}
+
+	pfx, err := os.ReadFile("testdata/pkcs12/empty-rc2.p12")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		kind        Kind
+		native      bool
+		providers   []string
+		unsupported int
+	}{
+		{
+			name:   "RSA signature",
+			data:   createSignedData(t, sha256OID, rsaOID),
+			kind:   KindSignedData,
+			native: true,
+		},
+		{
+			name:   "AES encryption",
+			data:   createEnvelopedData(t, rsaOID, aesOID),
+			kind:   KindEnvelopedData,
+			native: true,
+		},
+		{
+			name:   "PKCS#12 with RC2 and 3DES",
+			data:   pfx,
+			kind:   KindEncryptedPKCS12,
+			native: true,
+		},
+		{
+			name:        "KalkanCrypt signature",
+			data:        createSignedData(t, kazakhDigestOID, kazakhSignatureOID),
+			kind:        KindSignedData,
+			providers:   []string{ProviderKalkanCrypt},
+			unsupported: 2,
+		},
+		{
+			name:        "CryptoPro encryption",
+			data:        createEnvelopedData(t, GOSTKuznyechikKExp15OID, GOSTKuznyechikCTROID),
+			kind:        KindEnvelopedData,
+			providers:   []string{ProviderCryptoPro},
+			unsupported: 2,
+		},
+		{
+			name:        "Camellia encryption",
+			data:        createEnvelopedData(t, rsaOID, camelliaOID),
+			kind:        KindEnvelopedData,
+			unsupported: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := Capabilities(tt.data)
+				if err != nil {
+					t.Fatalf("Capabilities returned an error: %v", err)
+				}
+
+				if report.Kind != tt.kind {
+					t.Errorf("Expected kind %v, got %v", tt.kind, report.Kind)
+				}
+
+				if report.Native != tt.native {
+					t.Errorf("Expected native %v, got %v", tt.native, report.Native)
+				}
+
+				if !reflect.DeepEqual(report.Providers, tt.providers) {
+					t.Errorf("Expected providers %v, got %v", tt.providers, report.Providers)
+				}
+
+				if len(report.Unsupported) != tt.unsupported {
+					t.Errorf("Expected %d unsupported algorithms, got %v", tt.unsupported, report.Unsupported)
+				}
+			},
+		)
+	}
+
+	if _, err := Capabilities([]byte("not a structure")); err == nil {
+		t.Error("Expected an error for unknown data")
+	}
+}
@@ -0,0 +1,37 @@
+//go:build js && wasm
+
+// Command cmsdetect-wasm exposes the classification of the core package to JavaScript, e.g. to
+// validate uploads in the browser before sending them. Built with Go or TinyGo:
+//
+//	GOOS=js GOARCH=wasm go build -o cmsdetect.wasm ./cmd/cmsdetect-wasm
+//	tinygo build -o cmsdetect.wasm -target wasm ./cmd/cmsdetect-wasm
+//
+// it defines the global function cmsdetectKindOf, taking a Uint8Array and returning the name of
+// the kind, e.g. "PKCS#7 Signed Data"
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+func main() {
+	js.Global().Set(
+		"cmsdetectKindOf", js.FuncOf(
+			func(this js.Value, args []js.Value) interface{} {
+				if len(args) != 1 || !args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+					return core.KindUnknown.String()
+				}
+
+				data := make([]byte, args[0].Length())
+				js.CopyBytesToGo(data, args[0])
+
+				return core.KindOf(data).String()
+			},
+		),
+	)
+
+	// The function is called until the page is closed
+	select {}
+}
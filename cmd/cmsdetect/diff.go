@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// runDiff writes the differences between the structures of two files and returns the exit code,
+// exitMismatch if they differ
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 2 || args[0] == "-" && args[1] == "-" {
+		fmt.Fprintln(stderr, "usage: cmsdetect diff file1 file2   (\"-\" reads standard input for one of them)")
+
+		return exitUsage
+	}
+
+	var data [2][]byte
+
+	for i, name := range args {
+		var err error
+		if data[i], err = readInput(name, stdin); err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+			return exitFailed
+		}
+
+		data[i] = decodeBase64Input(data[i])
+	}
+
+	differences, err := cmsdetector.CompareResults(data[0], data[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+		return exitMalformed
+	}
+
+	if len(differences) == 0 {
+		fmt.Fprintln(stdout, "no differences")
+
+		return exitOK
+	}
+
+	for _, d := range differences {
+		fmt.Fprintf(stdout, "%s:\n  - %s: %s\n  + %s: %s\n", d.Property, args[0], orNone(d.A), args[1], orNone(d.B))
+	}
+
+	return exitMismatch
+}
+
+// orNone returns the value, or "(none)" for properties not applying to a structure
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+
+	return value
+}
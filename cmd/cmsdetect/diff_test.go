@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestRunDiff tests the differences written by the diff command and its exit codes
+func TestRunDiff(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	dir := createFiles(
+		t, map[string][]byte{
+			"a.p7s":    signed,
+			"b.p7s":    cmstest.SignedData(t, cmstest.WithDigestAlgorithm(cmstest.SHA384OID), cmstest.WithDetached()),
+			"note.txt": []byte("Hello, world"),
+		},
+	)
+
+	a, b := filepath.Join(dir, "a.p7s"), filepath.Join(dir, "b.p7s")
+
+	tests := []struct {
+		name         string
+		args         []string
+		stdin        []byte
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "Identical",
+			args:         []string{"diff", a, "-"},
+			stdin:        signed,
+			expectedCode: exitOK,
+			expected:     []string{"no differences"},
+		},
+		{
+			name:         "Different",
+			args:         []string{"diff", a, b},
+			expectedCode: exitMismatch,
+			expected: []string{
+				"layers:\n  - " + a + ": DER, encapsulated PKCS#7 Data\n  + " + b + ": DER, encapsulated PKCS#7 Data, detached content\n",
+				"digest algorithms:\n  - " + a + ": SHA-256\n  + " + b + ": SHA-384\n",
+			},
+		},
+		{
+			name:         "Unknown format",
+			args:         []string{"diff", a, filepath.Join(dir, "note.txt")},
+			expectedCode: exitMalformed,
+		},
+		{
+			name:         "Missing file",
+			args:         []string{"diff", a, filepath.Join(dir, "missing")},
+			expectedCode: exitFailed,
+		},
+		{
+			name:         "One file",
+			args:         []string{"diff", a},
+			expectedCode: exitUsage,
+		},
+		{
+			name:         "Standard input twice",
+			args:         []string{"diff", "-", "-"},
+			expectedCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run(tt.args, bytes.NewReader(tt.stdin), &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				for _, expected := range tt.expected {
+					if !strings.Contains(stdout.String(), expected) {
+						t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout.String())
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// runDump writes the annotated hexdump of a file and returns the exit code
+func runDump(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "usage: cmsdetect dump file   (\"-\" reads standard input)")
+
+		return exitUsage
+	}
+
+	data, err := readInput(args[0], stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+		return exitFailed
+	}
+
+	// The DER of PEM and base64 input is dumped, so that offsets match the decoded structure
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	} else {
+		data = decodeBase64Input(data)
+	}
+
+	if err := cmsdetector.WriteDump(stdout, data); err != nil {
+		fmt.Fprintf(stderr, "cmsdetect: %s: %v\n", args[0], err)
+
+		return exitFailed
+	}
+
+	return exitOK
+}
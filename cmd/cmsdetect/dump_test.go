@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestRunDump tests the annotated hexdump of files and standard input
+func TestRunDump(t *testing.T) {
+	encrypted := cmstest.EncryptedData(t)
+
+	dir := createFiles(
+		t, map[string][]byte{
+			"encrypted.p7":  encrypted,
+			"encrypted.pem": pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: encrypted}),
+			"truncated.p7":  encrypted[:len(encrypted)-4],
+		},
+	)
+
+	tests := []struct {
+		name         string
+		args         []string
+		stdin        []byte
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:     "DER",
+			args:     []string{"dump", filepath.Join(dir, "encrypted.p7")},
+			expected: []string{"00000000  30 4f", "(PKCS#7 Encrypted Data)", "encrypted octets begin"},
+		},
+		{
+			name:     "PEM",
+			args:     []string{"dump", filepath.Join(dir, "encrypted.pem")},
+			expected: []string{"00000000  30 4f", "(AES-256-CBC)"},
+		},
+		{
+			name:     "Base64 on standard input",
+			args:     []string{"dump", "-"},
+			stdin:    []byte(base64.StdEncoding.EncodeToString(encrypted)),
+			expected: []string{"00000000  30 4f", "(PKCS#7 Encrypted Data)"},
+		},
+		{
+			name:         "Malformed",
+			args:         []string{"dump", filepath.Join(dir, "truncated.p7")},
+			expectedCode: exitFailed,
+			expected:     []string{"malformed: element of 79 bytes exceeds the enclosing element or input"},
+		},
+		{
+			name:         "Missing file",
+			args:         []string{"dump", filepath.Join(dir, "missing")},
+			expectedCode: exitFailed,
+		},
+		{
+			name:         "No file",
+			args:         []string{"dump"},
+			expectedCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run(tt.args, bytes.NewReader(tt.stdin), &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				for _, expected := range tt.expected {
+					if !strings.Contains(stdout.String(), expected) {
+						t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout.String())
+					}
+				}
+			},
+		)
+	}
+}
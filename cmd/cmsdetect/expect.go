@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// expectNames maps the names accepted by -expect to kinds
+var expectNames = map[string]cmsdetector.Kind{
+	"pkcs7-data":                  cmsdetector.KindData,
+	"pkcs7-signed":                cmsdetector.KindSignedData,
+	"pkcs7-enveloped":             cmsdetector.KindEnvelopedData,
+	"pkcs7-signed-enveloped":      cmsdetector.KindSignedAndEnvelopedData,
+	"pkcs7-digested":              cmsdetector.KindDigestedData,
+	"pkcs7-encrypted":             cmsdetector.KindEncryptedData,
+	"pkcs12":                      cmsdetector.KindPKCS12,
+	"pkcs12-encrypted":            cmsdetector.KindEncryptedPKCS12,
+	"windows-catalog":             cmsdetector.KindWindowsCatalog,
+	"jks":                         cmsdetector.KindJKS,
+	"jceks":                       cmsdetector.KindJCEKS,
+	"bks":                         cmsdetector.KindBKS,
+	"openssh-private-key":         cmsdetector.KindOpenSSHPrivateKey,
+	"ssh-public-key":              cmsdetector.KindSSHPublicKey,
+	"putty-private-key":           cmsdetector.KindPuTTYPrivateKey,
+	"jws":                         cmsdetector.KindJWS,
+	"jwe":                         cmsdetector.KindJWE,
+	"cose-sign1":                  cmsdetector.KindCOSESign1,
+	"cose-sign":                   cmsdetector.KindCOSESign,
+	"cose-encrypt":                cmsdetector.KindCOSEEncrypt,
+	"cose-encrypt0":               cmsdetector.KindCOSEEncrypt0,
+	"cose-mac":                    cmsdetector.KindCOSEMac,
+	"cose-mac0":                   cmsdetector.KindCOSEMac0,
+	"x509-certificate":            cmsdetector.KindCertificate,
+	"pkcs10-request":              cmsdetector.KindCertificateRequest,
+	"pkcs8-private-key":           cmsdetector.KindPrivateKey,
+	"pkcs8-encrypted-private-key": cmsdetector.KindEncryptedPrivateKey,
+	"pgp-message":                 cmsdetector.KindPGPMessage,
+	"pgp-public-key":              cmsdetector.KindPGPPublicKey,
+	"pgp-private-key":             cmsdetector.KindPGPPrivateKey,
+	"pgp-signature":               cmsdetector.KindPGPSignature,
+	"est-certs-only":              cmsdetector.KindESTCertsOnly,
+	"cmp-message":                 cmsdetector.KindCMPMessage,
+	"xml-signature":               cmsdetector.KindXMLSignature,
+	"xades":                       cmsdetector.KindXAdES,
+}
+
+// parseExpectedKind returns the kind of an -expect name, or of a kind name such as
+// "PKCS#7 Signed Data", ignoring case
+func parseExpectedKind(name string) (cmsdetector.Kind, bool) {
+	if kind, ok := expectNames[strings.ToLower(name)]; ok {
+		return kind, true
+	}
+
+	for _, kind := range expectNames {
+		if strings.EqualFold(kind.String(), name) {
+			return kind, true
+		}
+	}
+
+	return cmsdetector.KindUnknown, false
+}
+
+// expectUsage lists the names accepted by -expect
+func expectUsage() string {
+	names := make([]string, 0, len(expectNames))
+	for name := range expectNames {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// exitCode returns the exit code for the scanned files, reporting failures and files not matching
+// the expected kind to stderr. Read errors, including files exceeding the maximal input size, take
+// precedence over parse failures, which take precedence over mismatches. Unknown formats only fail when a kind is expected
+func exitCode(stderr io.Writer, files []cmsdetector.ScannedFile, expect *cmsdetector.Kind) int {
+	code := exitOK
+
+	for _, file := range files {
+		var pathErr *fs.PathError
+
+		switch {
+		case errors.As(file.Err, &pathErr) || errors.Is(file.Err, cmsdetector.ErrInputTooLarge):
+			code = exitFailed
+		case file.Err != nil:
+			if expect == nil && errors.Is(file.Err, cmsdetector.ErrUnknownFormat) {
+				continue
+			}
+
+			if code != exitFailed {
+				code = exitMalformed
+			}
+		case expect != nil && file.Result.Kind != *expect:
+			fmt.Fprintf(stderr, "cmsdetect: %s: expected %s, got %s\n", file.Path, *expect, file.Result.Kind)
+
+			if code == exitOK {
+				code = exitMismatch
+			}
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestExpectNames tests that every kind can be given to -expect
+func TestExpectNames(t *testing.T) {
+	named := make(map[cmsdetector.Kind]bool)
+	for _, kind := range expectNames {
+		named[kind] = true
+	}
+
+	for kind := cmsdetector.KindUnknown + 1; !strings.HasPrefix(kind.String(), "Kind("); kind++ {
+		if !named[kind] {
+			t.Errorf("Expected a name for %v", kind)
+		}
+	}
+}
+
+// TestParseExpectedKind tests the names accepted by -expect
+func TestParseExpectedKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected cmsdetector.Kind
+		ok       bool
+	}{
+		{name: "pkcs7-signed", expected: cmsdetector.KindSignedData, ok: true},
+		{name: "PKCS12", expected: cmsdetector.KindPKCS12, ok: true},
+		{name: "pkcs#7 signed data", expected: cmsdetector.KindSignedData, ok: true},
+		{name: "signed", ok: false},
+		{name: "unknown", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				kind, ok := parseExpectedKind(tt.name)
+				if ok != tt.ok || kind != tt.expected {
+					t.Errorf("Expected %v (%v), got %v (%v)", tt.expected, tt.ok, kind, ok)
+				}
+			},
+		)
+	}
+}
+
+// TestRunExpect tests the exit codes of -expect
+func TestRunExpect(t *testing.T) {
+	dir := createFiles(
+		t, map[string][]byte{
+			"signed.p7s":         cmstest.SignedData(t),
+			"other/signed.p7s":   cmstest.SignedData(t),
+			"other/enveloped.p7": cmstest.EncryptedData(t),
+			"note.txt":           []byte("Hello, world"),
+		},
+	)
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectedCode   int
+		expectedStderr string
+	}{
+		{
+			name:         "Match",
+			args:         []string{"-expect", "pkcs7-signed", filepath.Join(dir, "signed.p7s")},
+			expectedCode: exitOK,
+		},
+		{
+			name:           "Mismatch",
+			args:           []string{"-expect", "pkcs7-signed", filepath.Join(dir, "other")},
+			expectedCode:   exitMismatch,
+			expectedStderr: "enveloped.p7: expected PKCS#7 Signed Data, got PKCS#7 Encrypted Data",
+		},
+		{
+			name:         "Unknown format",
+			args:         []string{"--expect", "pkcs7-signed", filepath.Join(dir, "note.txt")},
+			expectedCode: exitMalformed,
+		},
+		{
+			name:         "Unknown format without -expect",
+			args:         []string{filepath.Join(dir, "note.txt")},
+			expectedCode: exitOK,
+		},
+		{
+			name:         "Missing file",
+			args:         []string{"-expect", "pkcs7-signed", filepath.Join(dir, "missing")},
+			expectedCode: exitFailed,
+		},
+		{
+			name:           "Unknown kind",
+			args:           []string{"-expect", "pkcs7-signd", dir},
+			expectedCode:   exitUsage,
+			expectedStderr: `unknown kind "pkcs7-signd"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run(tt.args, nil, &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				if !strings.Contains(filepath.ToSlash(stderr.String()), tt.expectedStderr) {
+					t.Errorf("Expected stderr to contain %q, got %q", tt.expectedStderr, stderr.String())
+				}
+			},
+		)
+	}
+}
+
+// TestExitCode tests the precedence of read errors, parse failures and mismatches
+func TestExitCode(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	kind := cmsdetector.KindSignedData
+
+	readErr := cmsdetector.ScannedFile{Path: "a", Err: &os.PathError{Op: "read", Path: "a", Err: os.ErrPermission}}
+	parseErr := cmsdetector.ScannedFile{Path: "b", Err: cmsdetector.ErrPanic}
+	tooLarge := cmsdetector.ScannedFile{Path: "e", Err: cmsdetector.ErrInputTooLarge}
+	mismatch := (&cmsdetector.Scanner{}).Classify("c", cmstest.EncryptedData(t))
+	match := (&cmsdetector.Scanner{}).Classify("d", signed)
+
+	tests := []struct {
+		name     string
+		files    []cmsdetector.ScannedFile
+		expected int
+	}{
+		{name: "Match", files: []cmsdetector.ScannedFile{match}, expected: exitOK},
+		{name: "Mismatch", files: []cmsdetector.ScannedFile{match, mismatch}, expected: exitMismatch},
+		{name: "Parse failure", files: []cmsdetector.ScannedFile{mismatch, parseErr}, expected: exitMalformed},
+		{name: "Read error", files: []cmsdetector.ScannedFile{readErr, parseErr, mismatch}, expected: exitFailed},
+		{name: "Parse failure after read error", files: []cmsdetector.ScannedFile{parseErr, readErr}, expected: exitFailed},
+		{name: "Input too large", files: []cmsdetector.ScannedFile{parseErr, tooLarge, mismatch}, expected: exitFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if code := exitCode(&bytes.Buffer{}, tt.files, &kind); code != tt.expected {
+					t.Errorf("Expected %d, got %d", tt.expected, code)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"io"
+	"text/template"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// templateFile is the data of -format templates for a file
+type templateFile struct {
+	Path          string
+	Size          int64
+	Family        cmsdetector.Family
+	Kind          cmsdetector.Kind
+	Confidence    cmsdetector.Confidence
+	PEMType       string
+	Rule          string
+	ContentType   asn1.ObjectIdentifier // Empty for other formats than CMS/PKCS
+	IsEncrypted   bool
+	NeedsPassword bool
+	Weak          []cmsdetector.Finding
+	Error         string // Empty for classified files
+}
+
+// templateFuncs are the functions available to -format templates besides the predefined ones
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+
+		return string(data), err
+	},
+}
+
+// parseFormat parses a -format template
+func parseFormat(format string) (*template.Template, error) {
+	return template.New("format").Funcs(templateFuncs).Parse(format)
+}
+
+// writeTemplate executes the template for every file, each followed by a line break
+func writeTemplate(w io.Writer, tmpl *template.Template, files []cmsdetector.ScannedFile) error {
+	for _, file := range files {
+		data := templateFile{
+			Path:          file.Path,
+			Size:          file.Size,
+			Family:        file.Result.Family,
+			Kind:          file.Result.Kind,
+			Confidence:    file.Result.Confidence,
+			PEMType:       file.Result.PEMType,
+			Rule:          file.Result.Rule,
+			ContentType:   file.ContentType,
+			IsEncrypted:   file.Encrypted,
+			NeedsPassword: file.Result.NeedsPassword,
+			Weak:          file.Weak,
+		}
+
+		if file.Err != nil {
+			data.Error = file.Err.Error()
+		}
+
+		if err := tmpl.Execute(w, data); err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
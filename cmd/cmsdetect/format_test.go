@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestRunFormat tests the output of -format templates
+func TestRunFormat(t *testing.T) {
+	dir := createFiles(
+		t, map[string][]byte{
+			"a/signed.p7s":   cmstest.SignedData(t),
+			"b/encrypted.p7": cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.DESEDE3CBCOID)),
+			"c/note.txt":     []byte("Hello, world"),
+		},
+	)
+
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expected     string
+	}{
+		{
+			name:     "Fields",
+			args:     []string{"-format", "{{.Kind}} {{.ContentType}} {{.IsEncrypted}}", dir},
+			expected: "PKCS#7 Signed Data 1.2.840.113549.1.7.2 false\nPKCS#7 Encrypted Data 1.2.840.113549.1.7.6 true\nUnknown  false\n",
+		},
+		{
+			name:     "Conditionals and ranges",
+			args:     []string{"--format", "{{if .Error}}{{.Error}}{{else}}{{.Family}}{{range .Weak}} [{{.}}]{{end}}{{end}}", dir},
+			expected: "CMS/PKCS\nCMS/PKCS [weak encryption algorithm: Triple-DES-CBC]\nunknown format: this looks like plain text\n",
+		},
+		{
+			name:     "JSON",
+			args:     []string{"-format", "{{json .PEMType}}", filepath.Join(dir, "a")},
+			expected: "\"\"\n",
+		},
+		{
+			name:         "Invalid template",
+			args:         []string{"-format", "{{.Kind", dir},
+			expectedCode: exitUsage,
+		},
+		{
+			name:         "Unknown field",
+			args:         []string{"-format", "{{.Unknown}}", dir},
+			expectedCode: exitFailed,
+		},
+		{
+			name:         "With JSON output",
+			args:         []string{"-format", "{{.Kind}}", "-json", dir},
+			expectedCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run(tt.args, nil, &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				if tt.expectedCode == exitOK && stdout.String() != tt.expected {
+					t.Errorf("Expected %q, got %q", tt.expected, stdout.String())
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,357 @@
+// Command cmsdetect classifies files containing CMS/PKCS structures and other cryptographic
+// formats. Directories are scanned recursively and "-" reads standard input. Results can be
+// written as JSON lines, and findings as SARIF for code scanning and vulnerability dashboards.
+// "cmsdetect dump" writes a hexdump annotated with the ASN.1 structure for debugging malformed
+// containers, "cmsdetect diff" compares the structures of two files, and "cmsdetect serve" runs
+// the detector as an HTTP and gRPC service for services written in other languages.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// Exit codes of the command
+const (
+	exitOK        = 0
+	exitFailed    = 1 // A file could not be read, or exceeds the maximal input size
+	exitUsage     = 2
+	exitMismatch  = 3 // A file is not of the kind given with -expect
+	exitMalformed = 4 // A file could not be parsed, or has an unknown format while a kind is expected
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command with the arguments and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			return runServe(args[1:], stderr)
+		case "dump":
+			return runDump(args[1:], stdin, stdout, stderr)
+		case "diff":
+			return runDiff(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	flags := flag.NewFlagSet("cmsdetect", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	sarif := flags.Bool("sarif", false, "write weak algorithms, unencrypted private keys and unknown formats as SARIF 2.1.0")
+	jsonLines := flags.Bool("json", false, "write a JSON object per file")
+	format := flags.String("format", "", "write every file with the Go template, e.g. '{{.Kind}} {{.ContentType}} {{.IsEncrypted}}'")
+	expectName := flags.String("expect", "", "exit with code 3 unless every file is of the kind, one of:\n"+expectUsage())
+	defaults := cmsdetector.DefaultPBEThresholds()
+	minIterations := flags.Int("min-iterations", defaults.MinIterations, "report password-based key derivations with fewer iterations as weak, 0 to disable")
+	minSalt := flags.Int("min-salt", defaults.MinSaltLength, "report password-based key derivations with shorter salts in bytes as weak, 0 to disable")
+
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: cmsdetect [flags] path...   (\"-\" reads standard input)")
+		fmt.Fprintln(stderr, "       cmsdetect dump file")
+		fmt.Fprintln(stderr, "       cmsdetect diff file1 file2")
+		fmt.Fprintln(stderr, "       cmsdetect serve [flags]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 || countTrue(*sarif, *jsonLines, *format != "") > 1 {
+		flags.Usage()
+
+		return exitUsage
+	}
+
+	cmsdetector.SetPBEThresholds(cmsdetector.PBEThresholds{MinIterations: *minIterations, MinSaltLength: *minSalt})
+
+	var tmpl *template.Template
+
+	if *format != "" {
+		var err error
+		if tmpl, err = parseFormat(*format); err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: invalid -format: %v\n", err)
+
+			return exitUsage
+		}
+	}
+
+	var expect *cmsdetector.Kind
+
+	if *expectName != "" {
+		kind, ok := parseExpectedKind(*expectName)
+		if !ok {
+			fmt.Fprintf(stderr, "cmsdetect: unknown kind %q for -expect\n", *expectName)
+
+			return exitUsage
+		}
+
+		expect = &kind
+	}
+
+	var files []cmsdetector.ScannedFile
+
+	for _, name := range flags.Args() {
+		if name == "-" {
+			file, err := scanStdin(stdin)
+			if err != nil {
+				fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+				return exitFailed
+			}
+
+			files = append(files, file)
+
+			continue
+		}
+
+		scanned, err := scanPath(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+			return exitFailed
+		}
+
+		files = append(files, scanned...)
+	}
+
+	switch {
+	case *sarif:
+		if err := cmsdetector.WriteSARIF(stdout, files); err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+			return exitFailed
+		}
+	case *jsonLines:
+		if err := writeJSONLines(stdout, files); err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+			return exitFailed
+		}
+	case tmpl != nil:
+		if err := writeTemplate(stdout, tmpl, files); err != nil {
+			fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+			return exitFailed
+		}
+	default:
+		writeText(stdout, files)
+	}
+
+	return exitCode(stderr, files, expect)
+}
+
+// countTrue returns the number of true values
+func countTrue(values ...bool) int {
+	n := 0
+
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+
+	return n
+}
+
+// scanPath classifies the file, or every file in the directory tree, reporting paths prefixed with
+// the directory given on the command line
+func scanPath(name string) ([]cmsdetector.ScannedFile, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, root := name, "."
+	if !info.IsDir() {
+		dir, root = filepath.Dir(name), filepath.Base(name)
+	}
+
+	report, err := (&cmsdetector.Scanner{}).Scan(os.DirFS(dir), root)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range report.Files {
+		report.Files[i].Path = path.Join(filepath.ToSlash(dir), report.Files[i].Path)
+	}
+
+	return report.Files, nil
+}
+
+// scanStdin classifies standard input, reported with the path "-". Base64 text, e.g. a signature
+// copied from a JSON document, is decoded first. PEM is handled by DetectAny itself
+func scanStdin(stdin io.Reader) (cmsdetector.ScannedFile, error) {
+	data, err := readLimited(stdin)
+	if err != nil {
+		return cmsdetector.ScannedFile{}, fmt.Errorf("failed to read standard input: %w", err)
+	}
+
+	return (&cmsdetector.Scanner{}).Classify("-", decodeBase64Input(data)), nil
+}
+
+// readInput reads the named file, or standard input for "-"
+func readInput(name string, stdin io.Reader) ([]byte, error) {
+	if name == "-" {
+		return readLimited(stdin)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readLimited(file)
+}
+
+// readLimited reads up to one byte more than MaxInputSize, so that oversized input is rejected
+// by the detector
+func readLimited(r io.Reader) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(r, int64(cmsdetector.CurrentLimits().MaxInputSize)+1))
+}
+
+// decodeBase64Input decodes data that DetectAny reports as base64 text, other data is returned as is
+func decodeBase64Input(data []byte) []byte {
+	var unknown *cmsdetector.UnknownFormatError
+	if _, err := cmsdetector.DetectAny(data); !errors.As(err, &unknown) || len(unknown.Hints) == 0 || unknown.Hints[0].Format != cmsdetector.HintBase64 {
+		return data
+	}
+
+	if decoded, ok := decodeBase64(data); ok {
+		return decoded
+	}
+
+	return data
+}
+
+// decodeBase64 decodes standard or URL-safe base64 text, with or without padding and line breaks
+func decodeBase64(text []byte) ([]byte, bool) {
+	compact := string(bytes.Join(bytes.Fields(text), nil))
+
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(compact); err == nil {
+			return decoded, true
+		}
+	}
+
+	return nil, false
+}
+
+// jsonFile is the JSON representation of a classified file
+type jsonFile struct {
+	Path string `json:"path"`
+	jsonResult
+	Weak      []string           `json:"weak,omitempty"`
+	PDF       []jsonPDFSignature `json:"pdf_signatures,omitempty"`
+	PEMBlocks []jsonPEMBlock     `json:"pem_blocks,omitempty"`
+}
+
+// jsonPDFSignature is the JSON representation of a signature of a PDF document
+type jsonPDFSignature struct {
+	SubFilter string `json:"subfilter"`
+	PAdES     bool   `json:"pades"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+// jsonPEMBlock is the JSON representation of a block of a PEM bundle
+type jsonPEMBlock struct {
+	Index int `json:"index"`
+	jsonResult
+}
+
+// writeJSONLines writes a JSON object per file with its classification or error
+func writeJSONLines(w io.Writer, files []cmsdetector.ScannedFile) error {
+	encoder := json.NewEncoder(w)
+
+	for _, file := range files {
+		line := jsonFile{Path: file.Path}
+
+		if file.Err != nil {
+			line.Error = file.Err.Error()
+		} else {
+			line.jsonResult = newAnyJSONResult(file.Result)
+			line.Encrypted = file.Encrypted
+		}
+
+		for _, finding := range file.Weak {
+			line.Weak = append(line.Weak, finding.String())
+		}
+
+		for _, signature := range file.PDF {
+			line.PDF = append(line.PDF, jsonPDFSignature{SubFilter: signature.SubFilter, PAdES: signature.IsPAdES(), Kind: signature.Result.Type})
+		}
+
+		for _, block := range file.PEMBlocks {
+			line.PEMBlocks = append(line.PEMBlocks, jsonPEMBlock{Index: block.Index, jsonResult: newAnyJSONResult(block.Result)})
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeText writes a line per file with its classification or error, followed by its weak algorithms
+// and the blocks of PEM bundles
+func writeText(w io.Writer, files []cmsdetector.ScannedFile) {
+	for _, file := range files {
+		if file.Err != nil {
+			fmt.Fprintf(w, "%s: %v\n", file.Path, file.Err)
+			writePDFSignatures(w, file.PDF)
+
+			continue
+		}
+
+		result := file.Result
+		description := fmt.Sprintf("%s [%s, %s confidence]", result.Kind, result.Family, result.Confidence)
+
+		if file.Encrypted {
+			description += ", encrypted"
+		}
+
+		if file.Result.NeedsPassword {
+			description += ", needs password"
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", file.Path, description)
+
+		for _, finding := range file.Weak {
+			fmt.Fprintf(w, "  weak: %s\n", finding)
+		}
+
+		for _, block := range file.PEMBlocks {
+			fmt.Fprintf(w, "  pem block %d (%s): %s [%s, %s confidence]\n", block.Index, block.Type, block.Result.Kind, block.Result.Family, block.Result.Confidence)
+		}
+
+		writePDFSignatures(w, file.PDF)
+	}
+}
+
+// writePDFSignatures writes a line per signature of a PDF document with its SubFilter
+func writePDFSignatures(w io.Writer, signatures []cmsdetector.PDFSignature) {
+	for _, signature := range signatures {
+		scheme := "legacy PKCS#7"
+		if signature.IsPAdES() {
+			scheme = "PAdES"
+		}
+
+		fmt.Fprintf(w, "  pdf signature: %s (%s)\n", signature.SubFilter, scheme)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createFiles writes the files to a temporary directory and returns its path
+func createFiles(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	return dir
+}
+
+// TestRun tests the output and exit codes of the command
+func TestRun(t *testing.T) {
+	dir := createFiles(
+		t, map[string][]byte{
+			"signed.p7s":    cmstest.SignedData(t),
+			"keys/weak.p7":  cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.DESEDE3CBCOID)),
+			"keys/note.txt": []byte("Hello, world"),
+			"keys/id.p12":   cmstest.PFX(t),
+			"keys/chain.pem": append(
+				pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cmstest.Certificate(t, "Leaf")}),
+				pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: cmstest.SignedData(t)})...,
+			),
+			"signed.pdf": []byte(
+				"%PDF-1.7\n1 0 obj\n<< /Type /Sig /SubFilter /ETSI.CAdES.detached /ByteRange [0 1 2 3] /Contents <" +
+					hex.EncodeToString(cmstest.SignedData(t)) + "> >>\nendobj\n",
+			),
+		},
+	)
+
+	tests := []struct {
+		name         string
+		args         []string
+		expectedCode int
+		expected     []string
+	}{
+		{
+			name:         "Directory",
+			args:         []string{dir},
+			expectedCode: exitOK,
+			expected: []string{
+				"keys/chain.pem: X.509 Certificate [X.509, high confidence]\n" +
+					"  pem block 0 (CERTIFICATE): X.509 Certificate [X.509, high confidence]\n" +
+					"  pem block 1 (PKCS7): PKCS#7 Signed Data [CMS/PKCS, high confidence]\n",
+				"keys/id.p12: PKCS#12 [CMS/PKCS, high confidence], encrypted, needs password\n",
+				"keys/note.txt: unknown format",
+				"keys/weak.p7: PKCS#7 Encrypted Data [CMS/PKCS, high confidence], encrypted",
+				"  weak: weak encryption algorithm: Triple-DES-CBC\n",
+				"signed.p7s: PKCS#7 Signed Data [CMS/PKCS, high confidence]",
+				"signed.pdf: unknown format: this looks like a PDF document\n  pdf signature: ETSI.CAdES.detached (PAdES)\n",
+			},
+		},
+		{
+			name:         "File",
+			args:         []string{filepath.Join(dir, "signed.p7s")},
+			expectedCode: exitOK,
+			expected:     []string{"signed.p7s: PKCS#7 Signed Data"},
+		},
+		{
+			name:         "SARIF",
+			args:         []string{"-sarif", dir},
+			expectedCode: exitOK,
+			expected:     []string{`"ruleId": "weak-algorithm"`, `"ruleId": "unknown-format"`, "keys/weak.p7"},
+		},
+		{
+			name:         "PBE thresholds",
+			args:         []string{"-min-iterations", "10000", "-min-salt", "16", filepath.Join(dir, "keys", "id.p12")},
+			expectedCode: exitOK,
+			expected:     []string{"  weak: low iteration count: 2048: PBKDF2\n", "  weak: short salt: 8 bytes: PBKDF2\n"},
+		},
+		{
+			name:         "Missing file",
+			args:         []string{filepath.Join(dir, "missing")},
+			expectedCode: exitFailed,
+		},
+		{
+			name:         "No arguments",
+			args:         nil,
+			expectedCode: exitUsage,
+		},
+		{
+			name:         "SARIF and JSON",
+			args:         []string{"-sarif", "-json", dir},
+			expectedCode: exitUsage,
+		},
+		{
+			name:         "Unknown flag",
+			args:         []string{"-unknown", dir},
+			expectedCode: exitUsage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run(tt.args, nil, &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				output := filepath.ToSlash(stdout.String())
+				for _, expected := range tt.expected {
+					if !strings.Contains(output, expected) {
+						t.Errorf("Expected output to contain %q, got:\n%s", expected, output)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestRunSARIFIsJSON tests that the SARIF output is a valid JSON document
+func TestRunSARIFIsJSON(t *testing.T) {
+	dir := createFiles(t, map[string][]byte{"signed.p7s": cmstest.SignedData(t)})
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-sarif", dir}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr.String())
+	}
+
+	var log map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &log); err != nil {
+		t.Errorf("Expected valid JSON, got %v", err)
+	}
+}
+
+// TestRunStdin tests classification of standard input in its raw, PEM and base64 forms
+func TestRunStdin(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	encoded := base64.StdEncoding.EncodeToString(signed)
+
+	tests := []struct {
+		name         string
+		stdin        []byte
+		expectedCode int
+		expected     jsonFile
+	}{
+		{
+			name:     "DER",
+			stdin:    signed,
+			expected: jsonFile{Path: "-", jsonResult: jsonResult{Family: "CMS/PKCS", Kind: "PKCS#7 Signed Data", Confidence: "high"}},
+		},
+		{
+			name:     "PEM",
+			stdin:    pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: signed}),
+			expected: jsonFile{Path: "-", jsonResult: jsonResult{Family: "CMS/PKCS", Kind: "PKCS#7 Signed Data", Confidence: "high", PEMType: "PKCS7"}},
+		},
+		{
+			name:     "Base64 with line breaks",
+			stdin:    []byte(encoded[:40] + "\n" + encoded[40:] + "\n"),
+			expected: jsonFile{Path: "-", jsonResult: jsonResult{Family: "CMS/PKCS", Kind: "PKCS#7 Signed Data", Confidence: "high"}},
+		},
+		{
+			name:  "Weak algorithm",
+			stdin: cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.DESEDE3CBCOID)),
+			expected: jsonFile{
+				Path:       "-",
+				jsonResult: jsonResult{Family: "CMS/PKCS", Kind: "PKCS#7 Encrypted Data", Confidence: "high", Encrypted: true},
+				Weak:       []string{"weak encryption algorithm: Triple-DES-CBC"},
+			},
+		},
+		{
+			name:     "Unknown format",
+			stdin:    []byte("Hello, world"),
+			expected: jsonFile{Path: "-", jsonResult: jsonResult{Error: "unknown format: this looks like plain text"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+
+				if code := run([]string{"--json", "-"}, bytes.NewReader(tt.stdin), &stdout, &stderr); code != tt.expectedCode {
+					t.Fatalf("Expected exit code %d, got %d: %s", tt.expectedCode, code, stderr.String())
+				}
+
+				var result jsonFile
+				if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+					t.Fatalf("Failed to decode output %q: %v", stdout.String(), err)
+				}
+
+				if !reflect.DeepEqual(result, tt.expected) {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestRunJSONLines tests that every file is written as a JSON object on its own line
+func TestRunJSONLines(t *testing.T) {
+	dir := createFiles(
+		t, map[string][]byte{
+			"signed.p7s": cmstest.SignedData(t),
+			"note.txt":   []byte("Hello, world"),
+		},
+	)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-json", dir}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr.String())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), stdout.String())
+	}
+
+	for _, line := range lines {
+		var result jsonFile
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Errorf("Expected valid JSON, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// gRPC status codes (https://grpc.github.io/grpc/core/md_doc_statuscodes.html)
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+)
+
+// grpcDetectPath is the path of the unary Detect method of the cmsdetector.v1.Detector service
+const grpcDetectPath = "/cmsdetector.v1.Detector/Detect"
+
+// jsonResult is the JSON representation of a detection result or error
+type jsonResult struct {
+	Family           string `json:"family,omitempty"`
+	Kind             string `json:"kind,omitempty"`
+	Confidence       string `json:"confidence,omitempty"`
+	PEMType          string `json:"pem_type,omitempty"`
+	Rule             string `json:"rule,omitempty"`
+	ContentType      string `json:"content_type,omitempty"`
+	InnerContentType string `json:"inner_content_type,omitempty"`
+	ContentSize      int64  `json:"content_size,omitempty"`
+	Header           string `json:"header,omitempty"` // Hex encoded DetectionResult.Header
+	Encrypted        bool   `json:"encrypted,omitempty"`
+	NeedsPassword    bool   `json:"needs_password,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// runServe starts the detection service and returns the exit code once it is stopped
+func runServe(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cmsdetect serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	certFile := flags.String("tls-cert", "", "TLS certificate file, required for gRPC over HTTP/2")
+	keyFile := flags.String("tls-key", "", "TLS private key file")
+	maxSize := flags.Int64("max-size", int64(cmsdetector.DefaultLimits().MaxInputSize), "maximum size of a request body in bytes")
+	cacheSize := flags.Int("cache", 0, "number of detection results of repeated request bodies to cache, 0 to disable")
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 0 || (*certFile == "") != (*keyFile == "") {
+		fmt.Fprintln(stderr, "usage: cmsdetect serve [-addr host:port] [-tls-cert file -tls-key file] [-max-size bytes] [-cache entries]")
+
+		return exitUsage
+	}
+
+	var opts []cmsdetector.DetectorOption
+	if *cacheSize > 0 {
+		opts = append(opts, cmsdetector.WithCache(cmsdetector.NewCache(*cacheSize)))
+	}
+
+	detector := cmsdetector.NewDetector(opts...)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newHandler(detector, *maxSize),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if *certFile != "" {
+		err = server.ListenAndServeTLS(*certFile, *keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "cmsdetect: %v\n", err)
+
+		return exitFailed
+	}
+
+	return exitOK
+}
+
+// newHandler returns the handler of the detection service classifying request bodies with the detector:
+//
+//	POST /v1/detect                        DetectAny of the request body, answered with JSON
+//	POST /v1/detect/stream                 DetectPrefix of the leading bytes of a large body
+//	POST /cmsdetector.v1.Detector/Detect   gRPC method of cmsdetector.proto, HTTP/2 only
+func newHandler(detector *cmsdetector.Detector, maxSize int64) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(
+		"/v1/detect", func(w http.ResponseWriter, r *http.Request) {
+			if !requirePost(w, r) {
+				return
+			}
+
+			data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+			if err != nil {
+				writeJSON(w, http.StatusRequestEntityTooLarge, jsonResult{Error: err.Error()})
+
+				return
+			}
+
+			result, err := detector.DetectAny(data)
+			if err != nil {
+				writeJSON(w, detectionErrorStatus(err), jsonResult{Error: err.Error()})
+
+				return
+			}
+
+			writeJSON(w, http.StatusOK, newAnyJSONResult(result))
+		},
+	)
+
+	mux.HandleFunc(
+		"/v1/detect/stream", func(w http.ResponseWriter, r *http.Request) {
+			if !requirePost(w, r) {
+				return
+			}
+
+			if r.ContentLength < 0 {
+				writeJSON(w, http.StatusLengthRequired, jsonResult{Error: "Content-Length is required"})
+
+				return
+			}
+
+			// Only the leading bytes are read, the rest of the body is discarded with the connection
+			prefix := make([]byte, cmsdetector.MaxPrefixSize)
+
+			n, err := io.ReadFull(r.Body, prefix)
+			if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+				writeJSON(w, http.StatusBadRequest, jsonResult{Error: err.Error()})
+
+				return
+			}
+
+			result, err := cmsdetector.DetectPrefix(prefix[:n], r.ContentLength)
+			if err != nil {
+				writeJSON(w, detectionErrorStatus(err), jsonResult{Error: err.Error()})
+
+				return
+			}
+
+			writeJSON(w, http.StatusOK, newDetectionJSONResult(result))
+		},
+	)
+
+	mux.HandleFunc(
+		grpcDetectPath, func(w http.ResponseWriter, r *http.Request) {
+			serveGRPCDetect(w, r, detector, maxSize)
+		},
+	)
+
+	return mux
+}
+
+// requirePost answers requests with other methods than POST with 405 Method Not Allowed
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+
+	w.Header().Set("Allow", http.MethodPost)
+	writeJSON(w, http.StatusMethodNotAllowed, jsonResult{Error: "method not allowed"})
+
+	return false
+}
+
+// detectionErrorStatus returns the HTTP status of a detection error
+func detectionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, cmsdetector.ErrInputTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, cmsdetector.ErrNeedMoreData):
+		return http.StatusBadRequest
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// writeJSON writes the result as JSON with the status code
+func writeJSON(w http.ResponseWriter, status int, result jsonResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// newAnyJSONResult returns the JSON representation of a DetectAny result
+func newAnyJSONResult(result cmsdetector.AnyResult) jsonResult {
+	return jsonResult{
+		Family:        result.Family.String(),
+		Kind:          result.Kind.String(),
+		Confidence:    result.Confidence.String(),
+		PEMType:       result.PEMType,
+		Rule:          result.Rule,
+		NeedsPassword: result.NeedsPassword,
+	}
+}
+
+// newDetectionJSONResult returns the JSON representation of a Detect result
+func newDetectionJSONResult(result cmsdetector.DetectionResult) jsonResult {
+	r := jsonResult{
+		Kind:          result.Kind.String(),
+		ContentSize:   result.ContentSize,
+		Header:        hex.EncodeToString(result.Header),
+		Encrypted:     result.IsEncrypted,
+		NeedsPassword: result.NeedsPassword,
+	}
+	if len(result.ContentType) > 0 {
+		r.ContentType = result.ContentType.String()
+	}
+
+	if len(result.InnerContentType) > 0 {
+		r.InnerContentType = result.InnerContentType.String()
+	}
+
+	return r
+}
+
+// serveGRPCDetect answers a unary gRPC call of the Detect method. The request is a DetectRequest
+// message and the response an AnyResult message of cmsdetector.proto
+func serveGRPCDetect(w http.ResponseWriter, r *http.Request, detector *cmsdetector.Detector, maxSize int64) {
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	message, status, err := readGRPCMessage(r.Body, maxSize)
+	if err != nil {
+		writeGRPCStatus(w, status, err.Error())
+
+		return
+	}
+
+	data, err := parseDetectRequest(message)
+	if err != nil {
+		writeGRPCStatus(w, grpcInvalidArgument, err.Error())
+
+		return
+	}
+
+	result, err := detector.DetectAny(data)
+	if errors.Is(err, cmsdetector.ErrInputTooLarge) {
+		writeGRPCStatus(w, grpcResourceExhausted, err.Error())
+
+		return
+	}
+
+	if err != nil {
+		writeGRPCStatus(w, grpcInvalidArgument, err.Error())
+
+		return
+	}
+
+	response, _ := result.MarshalBinary()
+
+	frame := make([]byte, 5, 5+len(response))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(response)))
+
+	_, _ = w.Write(append(frame, response...))
+	writeGRPCStatus(w, grpcOK, "")
+}
+
+// readGRPCMessage reads a length-prefixed gRPC message, returning the gRPC status of failures
+func readGRPCMessage(r io.Reader, maxSize int64) ([]byte, int, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, grpcInvalidArgument, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	if header[0] != 0 {
+		return nil, grpcUnimplemented, errors.New("compressed messages are not supported")
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[1:]))
+	if length > maxSize {
+		return nil, grpcResourceExhausted, fmt.Errorf("message of %d bytes exceeds %d bytes", length, maxSize)
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, grpcInvalidArgument, fmt.Errorf("failed to read message: %w", err)
+	}
+
+	return message, grpcOK, nil
+}
+
+// parseDetectRequest returns the data field of a DetectRequest message, skipping other fields
+func parseDetectRequest(message []byte) ([]byte, error) {
+	var data []byte
+
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 {
+			return nil, errors.New("malformed DetectRequest")
+		}
+
+		message = message[n:]
+
+		switch key & 7 {
+		case 0:
+			if _, n = binary.Uvarint(message); n <= 0 {
+				return nil, errors.New("malformed DetectRequest")
+			}
+
+			message = message[n:]
+		case 2:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || length > uint64(len(message)-n) {
+				return nil, errors.New("malformed DetectRequest")
+			}
+
+			if key>>3 == 1 {
+				data = message[n : n+int(length)]
+			}
+
+			message = message[n+int(length):]
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d in DetectRequest", key&7)
+		}
+	}
+
+	return data, nil
+}
+
+// writeGRPCStatus sets the gRPC status trailers
+func writeGRPCStatus(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Grpc-Status", strconv.Itoa(status))
+
+	if message != "" {
+		w.Header().Set("Grpc-Message", url.PathEscape(message))
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestServeHTTP tests the JSON endpoints of the detection service
+func TestServeHTTP(t *testing.T) {
+	server := httptest.NewServer(newHandler(cmsdetector.NewDetector(), 1<<20))
+	defer server.Close()
+
+	large := cmstest.SignedData(t, cmstest.WithContent(make([]byte, 2<<20)))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           io.Reader
+		expectedStatus int
+		expected       jsonResult
+	}{
+		{
+			name:           "SignedData",
+			method:         http.MethodPost,
+			path:           "/v1/detect",
+			body:           bytes.NewReader(cmstest.SignedData(t)),
+			expectedStatus: http.StatusOK,
+			expected:       jsonResult{Family: "CMS/PKCS", Kind: "PKCS#7 Signed Data", Confidence: "high"},
+		},
+		{
+			name:           "Unknown format",
+			method:         http.MethodPost,
+			path:           "/v1/detect",
+			body:           bytes.NewReader([]byte("Hello, world")),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expected:       jsonResult{Error: "unknown format: this looks like plain text"},
+		},
+		{
+			name:           "Body too large",
+			method:         http.MethodPost,
+			path:           "/v1/detect",
+			body:           bytes.NewReader(large),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expected:       jsonResult{Error: "http: request body too large"},
+		},
+		{
+			name:           "Method not allowed",
+			method:         http.MethodGet,
+			path:           "/v1/detect",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expected:       jsonResult{Error: "method not allowed"},
+		},
+		{
+			name:           "Stream",
+			method:         http.MethodPost,
+			path:           "/v1/detect/stream",
+			body:           bytes.NewReader(large),
+			expectedStatus: http.StatusOK,
+			expected: jsonResult{
+				Kind:             "PKCS#7 Signed Data",
+				ContentType:      "1.2.840.113549.1.7.2",
+				InnerContentType: "1.2.840.113549.1.7.1",
+				Header:           hex.EncodeToString(large[:16]),
+			},
+		},
+		{
+			name:           "Stream without length",
+			method:         http.MethodPost,
+			path:           "/v1/detect/stream",
+			body:           io.MultiReader(bytes.NewReader(large)),
+			expectedStatus: http.StatusLengthRequired,
+			expected:       jsonResult{Error: "Content-Length is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				req, err := http.NewRequest(tt.method, server.URL+tt.path, tt.body)
+				if err != nil {
+					t.Fatalf("Failed to create request: %v", err)
+				}
+
+				resp, err := server.Client().Do(req)
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				defer resp.Body.Close()
+
+				if resp.StatusCode != tt.expectedStatus {
+					t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+				}
+
+				var result jsonResult
+				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// createGRPCRequest frames a DetectRequest message with the data
+func createGRPCRequest(data []byte) []byte {
+	length := make([]byte, binary.MaxVarintLen64)
+	message := append([]byte{0x0a}, length[:binary.PutUvarint(length, uint64(len(data)))]...)
+	message = append(message, data...)
+
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+
+	return append(frame, message...)
+}
+
+// TestServeGRPC tests the gRPC Detect method over HTTP/2
+func TestServeGRPC(t *testing.T) {
+	server := httptest.NewUnstartedServer(newHandler(cmsdetector.NewDetector(), 1<<20))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		body           []byte
+		expectedStatus string
+		expected       cmsdetector.AnyResult
+	}{
+		{
+			name:           "SignedData",
+			body:           createGRPCRequest(cmstest.SignedData(t)),
+			expectedStatus: "0",
+			expected:       cmsdetector.AnyResult{Family: cmsdetector.FamilyCMS, Kind: cmsdetector.KindSignedData, Confidence: cmsdetector.ConfidenceHigh},
+		},
+		{
+			name:           "Unknown format",
+			body:           createGRPCRequest([]byte("Hello, world")),
+			expectedStatus: "3",
+		},
+		{
+			name:           "Compressed message",
+			body:           append([]byte{1}, createGRPCRequest(nil)[1:]...),
+			expectedStatus: "12",
+		},
+		{
+			name:           "Truncated message",
+			body:           createGRPCRequest(cmstest.SignedData(t))[:10],
+			expectedStatus: "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodPost, server.URL+grpcDetectPath, bytes.NewReader(tt.body))
+				if err != nil {
+					t.Fatalf("Failed to create request: %v", err)
+				}
+
+				req.Header.Set("Content-Type", "application/grpc")
+
+				resp, err := server.Client().Do(req)
+				if err != nil {
+					t.Fatalf("Request failed: %v", err)
+				}
+				defer resp.Body.Close()
+
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					t.Fatalf("Failed to read response: %v", err)
+				}
+
+				if status := resp.Trailer.Get("Grpc-Status"); status != tt.expectedStatus {
+					t.Fatalf("Expected gRPC status %s, got %q (%s)", tt.expectedStatus, status, resp.Trailer.Get("Grpc-Message"))
+				}
+
+				if tt.expectedStatus != "0" {
+					return
+				}
+
+				if len(body) < 5 || int(binary.BigEndian.Uint32(body[1:5])) != len(body)-5 {
+					t.Fatalf("Malformed response frame %x", body)
+				}
+
+				var result cmsdetector.AnyResult
+				if err := result.UnmarshalBinary(body[5:]); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestServeGRPCRequiresHTTP2 tests that gRPC calls over HTTP/1.1 are rejected
+func TestServeGRPCRequiresHTTP2(t *testing.T) {
+	server := httptest.NewServer(newHandler(cmsdetector.NewDetector(), 1<<20))
+	defer server.Close()
+
+	resp, err := server.Client().Post(server.URL+grpcDetectPath, "application/grpc", bytes.NewReader(createGRPCRequest(nil)))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusHTTPVersionNotSupported {
+		t.Errorf("Expected status %d, got %d", http.StatusHTTPVersionNotSupported, resp.StatusCode)
+	}
+}
+
+// TestRunServeUsage tests that invalid arguments of the serve command are rejected
+func TestRunServeUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "Positional argument", args: []string{"serve", "extra"}},
+		{name: "Certificate without key", args: []string{"serve", "-tls-cert", "cert.pem"}},
+		{name: "Unknown flag", args: []string{"serve", "-unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var stdout, stderr bytes.Buffer
+				if code := run(tt.args, nil, &stdout, &stderr); code != exitUsage {
+					t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+				}
+			},
+		)
+	}
+}
+
+// TestServeCache tests that repeated request bodies are answered from the cache of the detector
+func TestServeCache(t *testing.T) {
+	cache := cmsdetector.NewCache(16)
+	detector := cmsdetector.NewDetector(cmsdetector.WithCache(cache))
+
+	server := httptest.NewServer(newHandler(detector, 1<<20))
+	defer server.Close()
+
+	signed := cmstest.SignedData(t)
+
+	for i := 0; i < 3; i++ {
+		resp, err := server.Client().Post(server.URL+"/v1/detect", "application/octet-stream", bytes.NewReader(signed))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	expected := cmsdetector.CacheStats{Hits: 2, Misses: 1, Entries: 1}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
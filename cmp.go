@@ -0,0 +1,49 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+)
+
+// MediaTypePKIXCMP is the media type of CMP messages transferred over HTTP (RFC 6712, section 3.4)
+const MediaTypePKIXCMP = "application/pkixcmp"
+
+// CMP structure limits used to tell a PKIMessage from other DER sequences
+const (
+	cmpMaxVersion     = 3  // cmp2021 (RFC 9480)
+	cmpMaxBodyTag     = 26 // pollRep, the last PKIBody choice
+	generalNameMaxTag = 8  // registeredID, the last GeneralName choice
+)
+
+// pkiMessage provides the ASN.1 structure of a CMP PKIMessage (RFC 4210, section 5.1)
+type pkiMessage struct {
+	Header     pkiHeader
+	Body       asn1.RawValue
+	Protection asn1.BitString `asn1:"optional,explicit,tag:0"`
+	ExtraCerts asn1.RawValue  `asn1:"optional,tag:1"`
+}
+
+// pkiHeader provides the leading fields of PKIHeader (RFC 4210, section 5.1.1)
+type pkiHeader struct {
+	Version   int
+	Sender    asn1.RawValue
+	Recipient asn1.RawValue
+}
+
+// isCMPMessage checks if the data is a DER encoded CMP PKIMessage, as sent to and by CMP servers
+func isCMPMessage(der []byte) bool {
+	var message pkiMessage
+	if rest, err := asn1.Unmarshal(der, &message); err != nil || len(rest) > 0 {
+		return false
+	}
+
+	header := message.Header
+
+	return header.Version >= 1 && header.Version <= cmpMaxVersion &&
+		isGeneralName(header.Sender) && isGeneralName(header.Recipient) &&
+		message.Body.Class == asn1.ClassContextSpecific && message.Body.IsCompound && message.Body.Tag <= cmpMaxBodyTag
+}
+
+// isGeneralName checks if the value is tagged as one of the GeneralName choices
+func isGeneralName(value asn1.RawValue) bool {
+	return value.Class == asn1.ClassContextSpecific && value.Tag <= generalNameMaxTag
+}
@@ -0,0 +1,98 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// createCMPMessage creates a DER encoded CMP PKIMessage with directoryName sender and recipient,
+// a transactionID and an empty body of the given PKIBody choice
+func createCMPMessage(t *testing.T, version, bodyTag int) []byte {
+	t.Helper()
+
+	directoryName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true, Bytes: []byte{0x30, 0x00}}
+
+	message := struct {
+		Header struct {
+			Version       int
+			Sender        asn1.RawValue
+			Recipient     asn1.RawValue
+			TransactionID []byte `asn1:"explicit,tag:4"`
+		}
+		Body       asn1.RawValue
+		Protection asn1.BitString `asn1:"explicit,tag:0"`
+	}{
+		Body:       asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: bodyTag, IsCompound: true, Bytes: []byte{0x30, 0x00}},
+		Protection: asn1.BitString{Bytes: make([]byte, 32), BitLength: 256},
+	}
+
+	message.Header.Version = version
+	message.Header.Sender = directoryName
+	message.Header.Recipient = directoryName
+	message.Header.TransactionID = []byte{0x01, 0x02, 0x03, 0x04}
+
+	data, err := asn1.Marshal(message)
+	if err != nil {
+		t.Fatalf("Failed to marshal PKIMessage: %v", err)
+	}
+
+	return data
+}
+
+// TestDetectCMP tests detection of CMP PKIMessages by DetectAny
+func TestDetectCMP(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected Kind
+	}{
+		{
+			name:     "Initialization request",
+			data:     createCMPMessage(t, 2, 0),
+			expected: KindCMPMessage,
+		},
+		{
+			name:     "cmp2021 poll response",
+			data:     createCMPMessage(t, 3, 26),
+			expected: KindCMPMessage,
+		},
+		{
+			name:     "Unsupported version",
+			data:     createCMPMessage(t, 4, 0),
+			expected: KindUnknown,
+		},
+		{
+			name:     "Unknown body type",
+			data:     createCMPMessage(t, 2, 27),
+			expected: KindUnknown,
+		},
+		{
+			name:     "Trailing data",
+			data:     append(createCMPMessage(t, 2, 0), 0x00),
+			expected: KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectAny(tt.data)
+				if tt.expected == KindUnknown {
+					if err == nil && result.Kind == KindCMPMessage {
+						t.Errorf("Expected no CMP message, got %v", result.Kind)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if result.Family != FamilyCMP || result.Kind != tt.expected || result.Confidence != ConfidenceHigh {
+					t.Errorf("Expected %v %v, got %v %v (%v)", FamilyCMP, tt.expected, result.Family, result.Kind, result.Confidence)
+				}
+			},
+		)
+	}
+}
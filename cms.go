@@ -0,0 +1,444 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// Context-specific tags used by the RecipientInfo CHOICE (RFC 5652, section 6.2)
+const (
+	recipientInfoKeyAgree = 1
+	recipientInfoKEK      = 2
+	recipientInfoPassword = 3
+)
+
+// signedData provides the ASN.1 structure of CMS SignedData (RFC 5652, section 5.1)
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+// encapsulatedContentInfo provides the ASN.1 structure of the signed content
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo provides the ASN.1 structure of CMS SignerInfo (RFC 5652, section 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// issuerAndSerialNumber provides the ASN.1 structure identifying a certificate by issuer and serial number
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// envelopedData provides the ASN.1 structure of CMS EnvelopedData (RFC 5652, section 6.1)
+type envelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// encryptedContentInfo provides the ASN.1 structure of the encrypted content
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// signedAndEnvelopedData provides the ASN.1 structure of PKCS#7 SignedAndEnvelopedData (RFC 2315, section 11)
+type signedAndEnvelopedData struct {
+	Version              int
+	RecipientInfos       []asn1.RawValue            `asn1:"set"`
+	DigestAlgorithms     []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+	Certificates         asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs                 asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos          []asn1.RawValue `asn1:"set"`
+}
+
+// authEnvelopedData provides the ASN.1 structure of CMS AuthEnvelopedData (RFC 5083, section 2.1)
+type authEnvelopedData struct {
+	Version                  int
+	OriginatorInfo           asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos           []asn1.RawValue `asn1:"set"`
+	AuthEncryptedContentInfo encryptedContentInfo
+	AuthAttrs                asn1.RawValue `asn1:"optional,tag:1"`
+	MAC                      []byte
+	UnauthAttrs              asn1.RawValue `asn1:"optional,tag:2"`
+}
+
+// digestedData provides the ASN.1 structure of CMS DigestedData (RFC 5652, section 7)
+type digestedData struct {
+	Version          int
+	DigestAlgorithm  pkix.AlgorithmIdentifier
+	EncapContentInfo encapsulatedContentInfo
+	Digest           []byte
+}
+
+// encryptedData provides the ASN.1 structure of CMS EncryptedData (RFC 5652, section 8)
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// keyTransRecipientInfo provides the ASN.1 structure of KeyTransRecipientInfo
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// keyAgreeRecipientInfo provides the ASN.1 structure of KeyAgreeRecipientInfo
+type keyAgreeRecipientInfo struct {
+	Version                int
+	Originator             asn1.RawValue `asn1:"explicit,tag:0"`
+	UKM                    asn1.RawValue `asn1:"explicit,optional,tag:1"`
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	RecipientEncryptedKeys asn1.RawValue
+}
+
+// kekRecipientInfo provides the ASN.1 structure of KEKRecipientInfo
+type kekRecipientInfo struct {
+	Version                int
+	KEKID                  asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// passwordRecipientInfo provides the ASN.1 structure of PasswordRecipientInfo
+type passwordRecipientInfo struct {
+	Version                int
+	KeyDerivationAlgorithm pkix.AlgorithmIdentifier `asn1:"optional,tag:0"`
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// errUnexpectedContentType is returned when the content is not of the requested type
+var errUnexpectedContentType = errors.New("unexpected content type")
+
+// parseContentInfo unmarshals the outer ContentInfo structure. DER is read by hand, other
+// encodings are left to asn1.Unmarshal, which also reports the errors
+func parseContentInfo(data []byte) (ContentInfo, error) {
+	if contentInfo, ok := readContentInfo(data); ok {
+		return contentInfo, nil
+	}
+
+	var contentInfo ContentInfo
+	if _, err := asn1.Unmarshal(data, &contentInfo); err != nil {
+		return ContentInfo{}, err
+	}
+
+	return contentInfo, nil
+}
+
+// unmarshalContent unmarshals the content of the given ContentInfo if it has the expected type
+func unmarshalContent(contentInfo ContentInfo, contentType asn1.ObjectIdentifier, out interface{}) error {
+	if !contentInfo.ContentType.Equal(contentType) {
+		return errUnexpectedContentType
+	}
+
+	_, err := asn1.Unmarshal(contentInfo.Content.Bytes, out)
+
+	return err
+}
+
+// parseSignedData unmarshals the SignedData content of the given ContentInfo
+func parseSignedData(contentInfo ContentInfo) (*signedData, error) {
+	var sd signedData
+	if err := unmarshalContent(contentInfo, PKCS7SignedDataOID, &sd); err != nil {
+		return nil, err
+	}
+
+	return &sd, nil
+}
+
+// parseEnvelopedData unmarshals the EnvelopedData content of the given ContentInfo
+func parseEnvelopedData(contentInfo ContentInfo) (*envelopedData, error) {
+	var ed envelopedData
+	if err := unmarshalContent(contentInfo, PKCS7EnvelopedDataOID, &ed); err != nil {
+		return nil, err
+	}
+
+	return &ed, nil
+}
+
+// parseSignedAndEnvelopedData unmarshals the SignedAndEnvelopedData content of the given ContentInfo
+func parseSignedAndEnvelopedData(contentInfo ContentInfo) (*signedAndEnvelopedData, error) {
+	var sed signedAndEnvelopedData
+	if err := unmarshalContent(contentInfo, PKCS7SignedAndEnvelopedOID, &sed); err != nil {
+		return nil, err
+	}
+
+	return &sed, nil
+}
+
+// parseAuthEnvelopedData unmarshals the AuthEnvelopedData content of the given ContentInfo
+func parseAuthEnvelopedData(contentInfo ContentInfo) (*authEnvelopedData, error) {
+	var aed authEnvelopedData
+	if err := unmarshalContent(contentInfo, AuthEnvelopedDataOID, &aed); err != nil {
+		return nil, err
+	}
+
+	return &aed, nil
+}
+
+// parseDigestedData unmarshals the DigestedData content of the given ContentInfo
+func parseDigestedData(contentInfo ContentInfo) (*digestedData, error) {
+	var dd digestedData
+	if err := unmarshalContent(contentInfo, PKCS7DigestedDataOID, &dd); err != nil {
+		return nil, err
+	}
+
+	return &dd, nil
+}
+
+// parseEncryptedData unmarshals the EncryptedData content of the given ContentInfo
+func parseEncryptedData(contentInfo ContentInfo) (*encryptedData, error) {
+	var ed encryptedData
+	if err := unmarshalContent(contentInfo, PKCS7EncryptedDataOID, &ed); err != nil {
+		return nil, err
+	}
+
+	return &ed, nil
+}
+
+// encryptedContentType returns the type of the content encrypted by EnvelopedData, AuthEnvelopedData,
+// SignedAndEnvelopedData or EncryptedData
+func encryptedContentType(contentInfo ContentInfo) (asn1.ObjectIdentifier, bool) {
+	eci, ok := encryptedContentOf(contentInfo)
+
+	return eci.ContentType, ok
+}
+
+// encryptedContentOf returns the EncryptedContentInfo of EnvelopedData, AuthEnvelopedData,
+// SignedAndEnvelopedData and EncryptedData
+func encryptedContentOf(contentInfo ContentInfo) (encryptedContentInfo, bool) {
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		if ed, err := parseEnvelopedData(contentInfo); err == nil {
+			return ed.EncryptedContentInfo, true
+		}
+	case contentInfo.ContentType.Equal(AuthEnvelopedDataOID):
+		if aed, err := parseAuthEnvelopedData(contentInfo); err == nil {
+			return aed.AuthEncryptedContentInfo, true
+		}
+	case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
+		if sed, err := parseSignedAndEnvelopedData(contentInfo); err == nil {
+			return sed.EncryptedContentInfo, true
+		}
+	case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
+		if ed, err := parseEncryptedData(contentInfo); err == nil {
+			return ed.EncryptedContentInfo, true
+		}
+	}
+
+	return encryptedContentInfo{}, false
+}
+
+// keyEncryptionAlgorithm returns the key encryption algorithm of a RecipientInfo
+func keyEncryptionAlgorithm(ri asn1.RawValue) (pkix.AlgorithmIdentifier, bool) {
+	// KeyTransRecipientInfo is the only untagged alternative of the CHOICE
+	if ri.Class == asn1.ClassUniversal && ri.Tag == asn1.TagSequence {
+		var ktri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(ri.FullBytes, &ktri); err != nil {
+			return pkix.AlgorithmIdentifier{}, false
+		}
+
+		return ktri.KeyEncryptionAlgorithm, true
+	}
+
+	if ri.Class != asn1.ClassContextSpecific {
+		return pkix.AlgorithmIdentifier{}, false
+	}
+
+	encoded, err := retagSequence(ri)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, false
+	}
+
+	switch ri.Tag {
+	case recipientInfoKeyAgree:
+		var kari keyAgreeRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &kari); err != nil {
+			return pkix.AlgorithmIdentifier{}, false
+		}
+
+		return kari.KeyEncryptionAlgorithm, true
+	case recipientInfoKEK:
+		var kekri kekRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &kekri); err != nil {
+			return pkix.AlgorithmIdentifier{}, false
+		}
+
+		return kekri.KeyEncryptionAlgorithm, true
+	case recipientInfoPassword:
+		var pwri passwordRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &pwri); err != nil {
+			return pkix.AlgorithmIdentifier{}, false
+		}
+
+		return pwri.KeyEncryptionAlgorithm, true
+	default:
+		return pkix.AlgorithmIdentifier{}, false
+	}
+}
+
+// retagSequence re-tags an implicitly tagged SEQUENCE, e.g. a RecipientInfo alternative, so
+// that it can be unmarshalled into a struct
+func retagSequence(raw asn1.RawValue) ([]byte, error) {
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: raw.Bytes})
+}
+
+// hasPasswordRecipient checks if any RecipientInfo is a PasswordRecipientInfo
+func hasPasswordRecipient(recipientInfos []asn1.RawValue) bool {
+	for _, ri := range recipientInfos {
+		if ri.Class == asn1.ClassContextSpecific && ri.Tag == recipientInfoPassword {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseCertificates parses the X.509 certificates of a CertificateSet, skipping other alternatives
+func parseCertificates(raw asn1.RawValue) []*x509.Certificate {
+	var certs []*x509.Certificate
+
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			choice asn1.RawValue
+			err    error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &choice); err != nil {
+			break
+		}
+
+		if choice.Class != asn1.ClassUniversal || choice.Tag != asn1.TagSequence {
+			continue
+		}
+
+		if cert, err := x509.ParseCertificate(choice.FullBytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+
+	return certs
+}
+
+// attribute provides the ASN.1 structure of a CMS Attribute (RFC 5652, section 5.3)
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// parseAttributes parses an implicitly tagged SET OF Attribute
+func parseAttributes(raw asn1.RawValue) ([]attribute, error) {
+	var attrs []attribute
+
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			attr attribute
+			err  error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return nil, err
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	return attrs, nil
+}
+
+// attributeValues returns the encoded values of all attributes of the given type
+func attributeValues(attrs []attribute, attrType asn1.ObjectIdentifier) []asn1.RawValue {
+	var values []asn1.RawValue
+
+	for _, attr := range attrs {
+		if !attr.Type.Equal(attrType) {
+			continue
+		}
+
+		for rest := attr.Values.Bytes; len(rest) > 0; {
+			var (
+				value asn1.RawValue
+				err   error
+			)
+
+			if rest, err = asn1.Unmarshal(rest, &value); err != nil {
+				break
+			}
+
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// matchesCertificate checks if a SignerIdentifier or RecipientIdentifier refers to the certificate
+func matchesCertificate(id asn1.RawValue, cert *x509.Certificate) bool {
+	switch {
+	case id.Class == asn1.ClassUniversal && id.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(id.FullBytes, &ias); err != nil || ias.SerialNumber == nil {
+			return false
+		}
+
+		return bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.SerialNumber.Cmp(cert.SerialNumber) == 0
+	case id.Class == asn1.ClassContextSpecific && id.Tag == 0:
+		// subjectKeyIdentifier is implicitly tagged, so the content is the key identifier itself
+		return len(cert.SubjectKeyId) > 0 && bytes.Equal(id.Bytes, cert.SubjectKeyId)
+	default:
+		return false
+	}
+}
+
+// encapsulatedContent returns the octets of the encapsulated content, if it is attached. The octets
+// are not copied and share the memory of the parsed data
+func encapsulatedContent(eci encapsulatedContentInfo) ([]byte, bool) {
+	if len(eci.EContent.Bytes) == 0 {
+		return nil, false
+	}
+
+	return octetString(eci.EContent.Bytes)
+}
+
+// octetString returns the contents of the primitive OCTET STRING at the start of the data without
+// copying them, unlike unmarshaling into a byte slice
+func octetString(data []byte) ([]byte, bool) {
+	var octets asn1.RawValue
+	if _, err := asn1.Unmarshal(data, &octets); err != nil {
+		return nil, false
+	}
+
+	if octets.Class != asn1.ClassUniversal || octets.Tag != asn1.TagOctetString || octets.IsCompound {
+		return nil, false
+	}
+
+	return octets.Bytes, true
+}
@@ -0,0 +1,306 @@
+package cmsdetector
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// createContentInfo creates ASN.1 encoded ContentInfo structure wrapping the given content
+func createContentInfo(t testing.TB, oid asn1.ObjectIdentifier, content interface{}) []byte {
+	t.Helper()
+
+	inner, err := asn1.Marshal(content)
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	contentInfo := ContentInfo{
+		ContentType: oid,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      inner,
+		},
+	}
+
+	data, err := asn1.Marshal(contentInfo)
+	if err != nil {
+		t.Fatalf("Failed to marshal content info: %v", err)
+	}
+
+	return data
+}
+
+// createSignedData creates ASN.1 encoded SignedData with a single signer using the given algorithms
+func createSignedData(t testing.TB, digestOID, signatureOID asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	return createSignedDataWithCertificates(t, digestOID, signatureOID)
+}
+
+// createSignedDataWithCertificates creates ASN.1 encoded SignedData carrying the given DER certificates
+func createSignedDataWithCertificates(t testing.TB, digestOID, signatureOID asn1.ObjectIdentifier, certs ...[]byte) []byte {
+	t.Helper()
+
+	return createSignedDataWithSigners(t, []signerInfo{createSignerInfo(t, digestOID, signatureOID, nil, nil)}, certs...)
+}
+
+// createSignedDataWithSigners creates ASN.1 encoded SignedData with the given signers and DER certificates
+func createSignedDataWithSigners(t testing.TB, signers []signerInfo, certs ...[]byte) []byte {
+	t.Helper()
+
+	sd := signedData{
+		Version:          1,
+		EncapContentInfo: encapsulatedContentInfo{EContentType: PKCS7DataOID},
+		SignerInfos:      signers,
+	}
+
+	for _, si := range signers {
+		sd.DigestAlgorithms = append(sd.DigestAlgorithms, si.DigestAlgorithm)
+	}
+
+	if len(certs) > 0 {
+		var certSet []byte
+		for _, cert := range certs {
+			certSet = append(certSet, cert...)
+		}
+
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certSet}
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// createSignedDataWithContent creates ASN.1 encoded SignedData encapsulating the given id-data content
+func createSignedDataWithContent(t *testing.T, content []byte) []byte {
+	t.Helper()
+
+	octets, err := asn1.Marshal(content)
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	sd := signedData{
+		Version: 1,
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: PKCS7DataOID,
+			EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets},
+		},
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// createSignerInfo creates a SignerInfo using the given algorithms and attributes
+func createSignerInfo(t testing.TB, digestOID, signatureOID asn1.ObjectIdentifier, signedAttrs, unsignedAttrs []attribute) signerInfo {
+	t.Helper()
+
+	return signerInfo{
+		Version:            3,
+		SID:                asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x01, 0x02}},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: digestOID},
+		SignedAttrs:        createAttributes(t, 0, signedAttrs),
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signatureOID},
+		Signature:          []byte{0xDE, 0xAD, 0xBE, 0xEF},
+		UnsignedAttrs:      createAttributes(t, 1, unsignedAttrs),
+	}
+}
+
+// createAttribute creates an attribute with the ASN.1 encodings of the given values
+func createAttribute(t *testing.T, attrType asn1.ObjectIdentifier, values ...interface{}) attribute {
+	t.Helper()
+
+	var encoded []byte
+
+	for _, value := range values {
+		der, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to marshal attribute value: %v", err)
+		}
+
+		encoded = append(encoded, der...)
+	}
+
+	return attribute{
+		Type:   attrType,
+		Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: encoded},
+	}
+}
+
+// createAttributes creates an implicitly tagged SET OF Attribute, or an empty value for no attributes
+func createAttributes(t testing.TB, tag int, attrs []attribute) asn1.RawValue {
+	t.Helper()
+
+	if len(attrs) == 0 {
+		return asn1.RawValue{}
+	}
+
+	var encoded []byte
+
+	for _, attr := range attrs {
+		der, err := asn1.Marshal(attr)
+		if err != nil {
+			t.Fatalf("Failed to marshal attribute: %v", err)
+		}
+
+		encoded = append(encoded, der...)
+	}
+
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: encoded}
+}
+
+// createEnvelopedData creates ASN.1 encoded EnvelopedData with a single key transport recipient
+func createEnvelopedData(t testing.TB, keyEncryptionOID, contentEncryptionOID asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	ktri, err := asn1.Marshal(
+		keyTransRecipientInfo{
+			Version:                2,
+			RID:                    asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x01, 0x02}},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: keyEncryptionOID},
+			EncryptedKey:           []byte{0xCA, 0xFE},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient info: %v", err)
+	}
+
+	ed := envelopedData{
+		Version:        0,
+		RecipientInfos: []asn1.RawValue{{FullBytes: ktri}},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                PKCS7DataOID,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: contentEncryptionOID},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x00, 0x01}},
+		},
+	}
+
+	return createContentInfo(t, PKCS7EnvelopedDataOID, ed)
+}
+
+// createRecipientInfo creates an implicitly tagged RecipientInfo alternative from the given structure
+func createRecipientInfo(t *testing.T, tag int, ri interface{}) asn1.RawValue {
+	t.Helper()
+
+	encoded, err := asn1.Marshal(ri)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient info: %v", err)
+	}
+
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(encoded, &seq); err != nil {
+		t.Fatalf("Failed to unmarshal recipient info: %v", err)
+	}
+
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: seq.Bytes}
+}
+
+// TestKeyEncryptionAlgorithm tests extraction of the key encryption algorithm from RecipientInfo alternatives
+func TestKeyEncryptionAlgorithm(t *testing.T) {
+	aesWrapOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 45}
+	id := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x01}}
+
+	kari := keyAgreeRecipientInfo{
+		Version:                3,
+		Originator:             asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x80, 0x01, 0x01}},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		RecipientEncryptedKeys: asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true},
+	}
+
+	kekri := kekRecipientInfo{
+		Version:                4,
+		KEKID:                  asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{0x04, 0x01, 0x01}},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		EncryptedKey:           []byte{0x01},
+	}
+
+	pwri := passwordRecipientInfo{
+		Version:                0,
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		EncryptedKey:           []byte{0x01},
+	}
+
+	ktri, err := asn1.Marshal(
+		keyTransRecipientInfo{
+			RID:                    id,
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient info: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		ri         asn1.RawValue
+		expectedOK bool
+	}{
+		{
+			name:       "KeyTransRecipientInfo",
+			ri:         asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, FullBytes: ktri},
+			expectedOK: true,
+		},
+		{
+			name:       "KeyAgreeRecipientInfo",
+			ri:         createRecipientInfo(t, recipientInfoKeyAgree, kari),
+			expectedOK: true,
+		},
+		{
+			name:       "KEKRecipientInfo",
+			ri:         createRecipientInfo(t, recipientInfoKEK, kekri),
+			expectedOK: true,
+		},
+		{
+			name:       "PasswordRecipientInfo",
+			ri:         createRecipientInfo(t, recipientInfoPassword, pwri),
+			expectedOK: true,
+		},
+		{
+			name:       "OtherRecipientInfo",
+			ri:         asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true},
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				alg, ok := keyEncryptionAlgorithm(tt.ri)
+				if ok != tt.expectedOK {
+					t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				if ok && !alg.Algorithm.Equal(aesWrapOID) {
+					t.Errorf("Expected OID %s, got %s", aesWrapOID, alg.Algorithm)
+				}
+			},
+		)
+	}
+}
+
+// createCertificate creates a self-signed DER certificate for the given key
+func createCertificate(t *testing.T, commonName string, key crypto.Signer) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		SubjectKeyId: []byte(commonName),
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	return der
+}
@@ -0,0 +1,265 @@
+// Package cmsdetectorhttp classifies files uploaded with multipart/form-data requests,
+// making the detection results available to handlers through the request context and
+// optionally rejecting uploads of kinds that are not allowed.
+package cmsdetectorhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+const (
+	// defaultMaxMemory is the part of the multipart form kept in memory, the rest is stored in temporary files
+	defaultMaxMemory = 32 << 20
+
+	// defaultMaxFileSize limits the number of bytes read from each uploaded file for detection
+	defaultMaxFileSize = 32 << 20
+
+	// formOverhead is the size of the form fields and part headers allowed beside the file by default
+	formOverhead = 1 << 20
+)
+
+// ErrTooLarge is returned when the request body or an uploaded file exceeds its size limit
+var ErrTooLarge = errors.New("upload too large")
+
+// Upload contains the detection result of an uploaded file
+type Upload struct {
+	Field    string // Form field name
+	Filename string // File name sent by the client
+	Size     int64  // File size in bytes
+	Result   cmsdetector.AnyResult
+	Err      error // Detection error, e.g. cmsdetector.ErrUnknownFormat
+}
+
+// Option configures the middleware
+type Option func(*config)
+
+// config contains the middleware settings
+type config struct {
+	maxMemory      int64
+	maxFileSize    int64
+	maxRequestSize int64
+	fields         map[string]bool
+	allowedKinds   map[cmsdetector.Kind]bool
+}
+
+// WithMaxMemory sets the part of the multipart form kept in memory (32 MiB by default)
+func WithMaxMemory(maxMemory int64) Option {
+	return func(c *config) {
+		c.maxMemory = maxMemory
+	}
+}
+
+// WithMaxFileSize sets the maximal size of an uploaded file (32 MiB by default), larger files are rejected
+func WithMaxFileSize(maxFileSize int64) Option {
+	return func(c *config) {
+		c.maxFileSize = maxFileSize
+	}
+}
+
+// WithMaxRequestSize sets the maximal size of the request body, larger requests are rejected before
+// they are stored in temporary files. It defaults to the maximal file size and 1 MiB for the other
+// fields, forms uploading several files need a larger limit
+func WithMaxRequestSize(maxRequestSize int64) Option {
+	return func(c *config) {
+		c.maxRequestSize = maxRequestSize
+	}
+}
+
+// WithFields restricts classification to the given form fields
+func WithFields(fields ...string) Option {
+	return func(c *config) {
+		c.fields = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			c.fields[field] = true
+		}
+	}
+}
+
+// WithAllowedKinds rejects requests with uploads of other kinds, including unrecognized files,
+// with 415 Unsupported Media Type
+func WithAllowedKinds(kinds ...cmsdetector.Kind) Option {
+	return func(c *config) {
+		c.allowedKinds = make(map[cmsdetector.Kind]bool, len(kinds))
+		for _, kind := range kinds {
+			c.allowedKinds[kind] = true
+		}
+	}
+}
+
+// uploadsKey is the context key of the detected uploads
+type uploadsKey struct{}
+
+// Middleware classifies the files of multipart/form-data requests before passing them to next.
+// The parsed form stays available through r.MultipartForm, requests of other content types
+// are passed through unchanged. Requests exceeding the size limits are rejected with
+// 413 Request Entity Too Large.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := newConfig(opts)
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !isMultipart(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			uploads, err := classify(w, r, cfg)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, ErrTooLarge) {
+					status = http.StatusRequestEntityTooLarge
+				}
+
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			for _, upload := range uploads {
+				if cfg.allowedKinds != nil && !cfg.isAllowed(upload) {
+					http.Error(w, rejectionMessage(upload), http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), uploadsKey{}, uploads)))
+		},
+	)
+}
+
+// Classify parses the multipart form of the request and detects the uploaded files. Requests
+// exceeding the size limits fail with ErrTooLarge
+func Classify(r *http.Request, opts ...Option) ([]Upload, error) {
+	return classify(nil, r, newConfig(opts))
+}
+
+// FromContext returns the uploads classified by Middleware
+func FromContext(ctx context.Context) ([]Upload, bool) {
+	uploads, ok := ctx.Value(uploadsKey{}).([]Upload)
+
+	return uploads, ok
+}
+
+// newConfig applies the options to the default settings
+func newConfig(opts []Option) *config {
+	cfg := &config{maxMemory: defaultMaxMemory, maxFileSize: defaultMaxFileSize}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.maxRequestSize == 0 {
+		cfg.maxRequestSize = cfg.maxFileSize + formOverhead
+	}
+
+	return cfg
+}
+
+// isMultipart checks if the request carries a multipart/form-data body
+func isMultipart(r *http.Request) bool {
+	return strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "multipart/form-data")
+}
+
+// isAllowed checks if the upload was detected as one of the allowed kinds
+func (c *config) isAllowed(upload Upload) bool {
+	return upload.Err == nil && c.allowedKinds[upload.Result.Kind]
+}
+
+// rejectionMessage describes why the upload was rejected
+func rejectionMessage(upload Upload) string {
+	if upload.Err != nil {
+		return fmt.Sprintf("%s: %s", upload.Filename, upload.Err)
+	}
+
+	return fmt.Sprintf("%s: %s is not allowed", upload.Filename, upload.Result.Kind)
+}
+
+// classify detects every uploaded file of the configured fields. The body is limited before the
+// form is parsed, as parts beyond the memory limit are written to temporary files
+func classify(w http.ResponseWriter, r *http.Request, cfg *config) ([]Upload, error) {
+	if r.ContentLength > cfg.maxRequestSize {
+		return nil, fmt.Errorf("%w: request exceeds %d bytes", ErrTooLarge, cfg.maxRequestSize)
+	}
+
+	body := &countingBody{ReadCloser: http.MaxBytesReader(w, r.Body, cfg.maxRequestSize)}
+	r.Body = body
+
+	if err := r.ParseMultipartForm(cfg.maxMemory); err != nil {
+		// http.MaxBytesReader fails once the limit is read, without an exported error until Go 1.19
+		if body.read >= cfg.maxRequestSize {
+			return nil, fmt.Errorf("%w: request exceeds %d bytes", ErrTooLarge, cfg.maxRequestSize)
+		}
+
+		return nil, fmt.Errorf("failed to parse multipart form: %w", err)
+	}
+
+	// Fields are sorted to report uploads in a stable order
+	fields := make([]string, 0, len(r.MultipartForm.File))
+	for field := range r.MultipartForm.File {
+		if cfg.fields == nil || cfg.fields[field] {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	var uploads []Upload
+
+	for _, field := range fields {
+		for _, header := range r.MultipartForm.File[field] {
+			upload, err := classifyFile(field, header, cfg.maxFileSize)
+			if err != nil {
+				return nil, err
+			}
+
+			uploads = append(uploads, upload)
+		}
+	}
+
+	return uploads, nil
+}
+
+// classifyFile reads and detects a single uploaded file
+func classifyFile(field string, header *multipart.FileHeader, maxFileSize int64) (Upload, error) {
+	upload := Upload{Field: field, Filename: header.Filename, Size: header.Size}
+
+	if header.Size > maxFileSize {
+		return upload, fmt.Errorf("%w: %s exceeds %d bytes", ErrTooLarge, header.Filename, maxFileSize)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return upload, fmt.Errorf("failed to open %s: %w", header.Filename, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxFileSize))
+	if err != nil {
+		return upload, fmt.Errorf("failed to read %s: %w", header.Filename, err)
+	}
+
+	upload.Result, upload.Err = cmsdetector.DetectAny(data)
+
+	return upload, nil
+}
+
+// countingBody counts the bytes read from the request body
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+// Read implements io.Reader
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+
+	return n, err
+}
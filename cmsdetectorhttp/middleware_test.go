@@ -0,0 +1,192 @@
+package cmsdetectorhttp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// createSignedData creates a minimal ASN.1 encoded ContentInfo with the SignedData content type
+func createSignedData(t *testing.T) []byte {
+	t.Helper()
+
+	data, err := asn1.Marshal(
+		cmsdetector.ContentInfo{
+			ContentType: cmsdetector.PKCS7SignedDataOID,
+			Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x30, 0x00}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal content info: %v", err)
+	}
+
+	return data
+}
+
+// createUploadRequest creates a multipart/form-data request uploading the given files by field name
+func createUploadRequest(t *testing.T, files map[string][]byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	for field, content := range files {
+		part, err := writer.CreateFormFile(field, field+".bin")
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+
+		if _, err := part.Write(content); err != nil {
+			t.Fatalf("Failed to write form file: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to close multipart writer: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return r
+}
+
+// withoutContentLength hides the length of the request body, as with chunked transfer encoding
+func withoutContentLength(r *http.Request) *http.Request {
+	r.ContentLength = -1
+	r.Body = io.NopCloser(r.Body)
+
+	return r
+}
+
+// TestMiddleware tests classification and rejection of uploads
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        *http.Request
+		opts           []Option
+		expectedStatus int
+		expectedKinds  []cmsdetector.Kind
+	}{
+		{
+			name:           "Signed data",
+			request:        createUploadRequest(t, map[string][]byte{"document": createSignedData(t)}),
+			expectedStatus: http.StatusOK,
+			expectedKinds:  []cmsdetector.Kind{cmsdetector.KindSignedData},
+		},
+		{
+			name: "Multiple uploads",
+			request: createUploadRequest(
+				t, map[string][]byte{"b": []byte("plain text"), "a": createSignedData(t)},
+			),
+			expectedStatus: http.StatusOK,
+			expectedKinds:  []cmsdetector.Kind{cmsdetector.KindSignedData, cmsdetector.KindUnknown},
+		},
+		{
+			name:           "Allowed kind",
+			request:        createUploadRequest(t, map[string][]byte{"document": createSignedData(t)}),
+			opts:           []Option{WithAllowedKinds(cmsdetector.KindSignedData)},
+			expectedStatus: http.StatusOK,
+			expectedKinds:  []cmsdetector.Kind{cmsdetector.KindSignedData},
+		},
+		{
+			name:           "Disallowed kind",
+			request:        createUploadRequest(t, map[string][]byte{"document": createSignedData(t)}),
+			opts:           []Option{WithAllowedKinds(cmsdetector.KindPKCS12)},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Unrecognized file with allowed kinds",
+			request:        createUploadRequest(t, map[string][]byte{"document": []byte("plain text")}),
+			opts:           []Option{WithAllowedKinds(cmsdetector.KindSignedData)},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Ignored field",
+			request:        createUploadRequest(t, map[string][]byte{"document": createSignedData(t), "other": nil}),
+			opts:           []Option{WithFields("document"), WithAllowedKinds(cmsdetector.KindSignedData)},
+			expectedStatus: http.StatusOK,
+			expectedKinds:  []cmsdetector.Kind{cmsdetector.KindSignedData},
+		},
+		{
+			name:           "File too large",
+			request:        createUploadRequest(t, map[string][]byte{"document": createSignedData(t)}),
+			opts:           []Option{WithMaxFileSize(4)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Request too large",
+			request:        createUploadRequest(t, map[string][]byte{"document": make([]byte, 4096)}),
+			opts:           []Option{WithMaxRequestSize(1024)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Request too large without content length",
+			request:        withoutContentLength(createUploadRequest(t, map[string][]byte{"document": make([]byte, 4096)})),
+			opts:           []Option{WithMaxRequestSize(1024)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Default request limit follows the file size",
+			request:        withoutContentLength(createUploadRequest(t, map[string][]byte{"document": make([]byte, 2<<20)})),
+			opts:           []Option{WithMaxFileSize(1024)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "Not multipart",
+			request:        httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("data")),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var uploads []Upload
+
+				handler := Middleware(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							uploads, _ = FromContext(r.Context())
+						},
+					), tt.opts...,
+				)
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, tt.request)
+
+				if recorder.Code != tt.expectedStatus {
+					t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+				}
+
+				if len(uploads) != len(tt.expectedKinds) {
+					t.Fatalf("Expected %d uploads, got %d", len(tt.expectedKinds), len(uploads))
+				}
+
+				for i, upload := range uploads {
+					if upload.Result.Kind != tt.expectedKinds[i] {
+						t.Errorf("Expected kind %v, got %v", tt.expectedKinds[i], upload.Result.Kind)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestClassifyTooLarge tests that Classify reports requests exceeding the size limit
+func TestClassifyTooLarge(t *testing.T) {
+	r := withoutContentLength(createUploadRequest(t, map[string][]byte{"document": make([]byte, 4096)}))
+
+	if _, err := Classify(r, WithMaxRequestSize(1024)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge, got %v", err)
+	}
+}
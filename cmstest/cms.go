@@ -0,0 +1,277 @@
+package cmstest
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	// Register hash implementations for the message digest attribute
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// CMS content type and attribute OIDs (RFC 5652)
+var (
+	dataOID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	signedDataOID    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	envelopedDataOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	digestedDataOID  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 5}
+	encryptedDataOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	contentTypeOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	messageDigestOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+// digestHashes maps digest algorithm OIDs to their implementations
+var digestHashes = map[string]crypto.Hash{
+	SHA1OID.String():   crypto.SHA1,
+	SHA256OID.String(): crypto.SHA256,
+	SHA384OID.String(): crypto.SHA384,
+	SHA512OID.String(): crypto.SHA512,
+}
+
+// placeholder is the signature, encrypted key and, unless set with WithContent, ciphertext of generated structures
+var placeholder = []byte{0xDE, 0xAD, 0xBE, 0xEF}
+
+// contentInfo provides the ASN.1 structure of CMS ContentInfo (RFC 5652, section 3).
+// Content is marshaled with its own [0] tag
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+// signedData provides the ASN.1 structure of CMS SignedData (RFC 5652, section 5.1)
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     []asn1.RawValue `asn1:"optional,tag:0"`
+	SignerInfos      []signerInfo    `asn1:"set"`
+}
+
+// encapsulatedContentInfo provides the ASN.1 structure of the signed content
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo provides the ASN.1 structure of CMS SignerInfo (RFC 5652, section 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        []attribute `asn1:"set,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+// attribute provides the ASN.1 structure of a CMS Attribute
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// issuerAndSerialNumber provides the ASN.1 structure identifying a certificate by issuer and serial number
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// envelopedData provides the ASN.1 structure of CMS EnvelopedData (RFC 5652, section 6.1)
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []keyTransRecipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+// keyTransRecipientInfo provides the ASN.1 structure of KeyTransRecipientInfo
+type keyTransRecipientInfo struct {
+	Version                int
+	RID                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// encryptedContentInfo provides the ASN.1 structure of the encrypted content
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           []byte `asn1:"tag:0"`
+}
+
+// digestedData provides the ASN.1 structure of CMS DigestedData (RFC 5652, section 7)
+type digestedData struct {
+	Version          int
+	DigestAlgorithm  pkix.AlgorithmIdentifier
+	EncapContentInfo encapsulatedContentInfo
+	Digest           []byte
+}
+
+// encryptedData provides the ASN.1 structure of CMS EncryptedData (RFC 5652, section 8)
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+// SignedData creates a DER encoded ContentInfo with SignedData carrying a single signer.
+// The signer has content type and message digest attributes and a placeholder signature
+func SignedData(tb testing.TB, opts ...Option) []byte {
+	tb.Helper()
+
+	c := newConfig(opts)
+
+	signedAttrs := []attribute{
+		{Type: contentTypeOID, Values: []asn1.RawValue{rawValue(tb, dataOID)}},
+		{Type: messageDigestOID, Values: []asn1.RawValue{rawValue(tb, messageDigest(c.digest, c.content))}},
+	}
+
+	// Signers identified by subject key identifier require version 3 (RFC 5652, section 5.1)
+	version := 1
+	if len(c.certificates) == 0 {
+		version = 3
+	}
+
+	sd := signedData{
+		Version:          version,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{algorithmIdentifier(tb, c.digest, c.iterations)},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: dataOID},
+		SignerInfos: []signerInfo{
+			{
+				Version:            version,
+				SID:                signerIdentifier(tb, c.certificates),
+				DigestAlgorithm:    algorithmIdentifier(tb, c.digest, c.iterations),
+				SignedAttrs:        signedAttrs,
+				SignatureAlgorithm: algorithmIdentifier(tb, c.signature, c.iterations),
+				Signature:          placeholder,
+			},
+		},
+	}
+
+	if !c.detached {
+		sd.EncapContentInfo.EContent = c.content
+	}
+
+	for _, cert := range c.certificates {
+		sd.Certificates = append(sd.Certificates, asn1.RawValue{FullBytes: cert})
+	}
+
+	return marshalContentInfo(tb, signedDataOID, sd)
+}
+
+// EnvelopedData creates a DER encoded ContentInfo with EnvelopedData for a single key transport recipient
+func EnvelopedData(tb testing.TB, opts ...Option) []byte {
+	tb.Helper()
+
+	c := newConfig(opts)
+
+	// Recipients identified by subject key identifier require version 2 (RFC 5652, section 6.1)
+	version := 0
+	if len(c.certificates) == 0 {
+		version = 2
+	}
+
+	ed := envelopedData{
+		Version: version,
+		RecipientInfos: []keyTransRecipientInfo{
+			{
+				Version:                version,
+				RID:                    signerIdentifier(tb, c.certificates),
+				KeyEncryptionAlgorithm: algorithmIdentifier(tb, orDefault(c.keyEncryption, RSAEncryptionOID), c.iterations),
+				EncryptedKey:           placeholder,
+			},
+		},
+		EncryptedContentInfo: newEncryptedContentInfo(tb, c, AES256CBCOID),
+	}
+
+	return marshalContentInfo(tb, envelopedDataOID, ed)
+}
+
+// DigestedData creates a DER encoded ContentInfo with DigestedData and the digest of the content
+func DigestedData(tb testing.TB, opts ...Option) []byte {
+	tb.Helper()
+
+	c := newConfig(opts)
+
+	dd := digestedData{
+		Version:          0,
+		DigestAlgorithm:  algorithmIdentifier(tb, c.digest, c.iterations),
+		EncapContentInfo: encapsulatedContentInfo{EContentType: dataOID},
+		Digest:           messageDigest(c.digest, c.content),
+	}
+
+	if !c.detached {
+		dd.EncapContentInfo.EContent = c.content
+	}
+
+	return marshalContentInfo(tb, digestedDataOID, dd)
+}
+
+// EncryptedData creates a DER encoded ContentInfo with EncryptedData, as used for password or pre-shared key encryption
+func EncryptedData(tb testing.TB, opts ...Option) []byte {
+	tb.Helper()
+
+	c := newConfig(opts)
+
+	ed := encryptedData{
+		Version:              0,
+		EncryptedContentInfo: newEncryptedContentInfo(tb, c, AES256CBCOID),
+	}
+
+	return marshalContentInfo(tb, encryptedDataOID, ed)
+}
+
+// newEncryptedContentInfo creates the encrypted content info using the selected or the default algorithm
+func newEncryptedContentInfo(tb testing.TB, c *config, def asn1.ObjectIdentifier) encryptedContentInfo {
+	tb.Helper()
+
+	return encryptedContentInfo{
+		ContentType:                dataOID,
+		ContentEncryptionAlgorithm: algorithmIdentifier(tb, orDefault(c.contentEncryption, def), c.iterations),
+		EncryptedContent:           c.content,
+	}
+}
+
+// signerIdentifier identifies the first certificate by issuer and serial number, or
+// returns a placeholder subject key identifier without certificates
+func signerIdentifier(tb testing.TB, certs [][]byte) asn1.RawValue {
+	tb.Helper()
+
+	if len(certs) == 0 {
+		return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: placeholder}
+	}
+
+	cert, err := x509.ParseCertificate(certs[0])
+	if err != nil {
+		tb.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	return rawValue(tb, issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber})
+}
+
+// messageDigest returns the digest of the content, or a placeholder for digest algorithms not in the standard library
+func messageDigest(oid asn1.ObjectIdentifier, content []byte) []byte {
+	hash, ok := digestHashes[oid.String()]
+	if !ok {
+		return make([]byte, 32)
+	}
+
+	h := hash.New()
+	h.Write(content)
+
+	return h.Sum(nil)
+}
+
+// marshalContentInfo wraps the content in a DER encoded ContentInfo of the given type
+func marshalContentInfo(tb testing.TB, contentType asn1.ObjectIdentifier, content interface{}) []byte {
+	tb.Helper()
+
+	return marshal(
+		tb, contentInfo{
+			ContentType: contentType,
+			Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: marshal(tb, content)},
+		},
+	)
+}
@@ -0,0 +1,243 @@
+package cmstest
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// algorithmNames returns the OIDs of the algorithms as strings
+func algorithmNames(algs []cmsdetector.Algorithm) []string {
+	var names []string
+	for _, alg := range algs {
+		names = append(names, alg.OID.String())
+	}
+
+	return names
+}
+
+// containsOID checks if the algorithms include the OID
+func containsOID(algs []cmsdetector.Algorithm, oid asn1.ObjectIdentifier) bool {
+	for _, alg := range algs {
+		if alg.OID.Equal(oid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encapsulatedContent returns the content encapsulated in the generated SignedData
+func encapsulatedContent(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(data, &ci); err != nil {
+		t.Fatalf("Failed to unmarshal content info: %v", err)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		t.Fatalf("Failed to unmarshal signed data: %v", err)
+	}
+
+	return sd.EncapContentInfo.EContent
+}
+
+// TestSignedData tests detection and algorithm inspection of generated SignedData
+func TestSignedData(t *testing.T) {
+	cert := Certificate(t, "cmstest signer")
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		digest    asn1.ObjectIdentifier
+		signature asn1.ObjectIdentifier
+		provider  string
+		detached  bool
+	}{
+		{
+			name:      "Defaults",
+			digest:    SHA256OID,
+			signature: SHA256WithRSAOID,
+		},
+		{
+			name:      "ECDSA with certificate",
+			opts:      []Option{WithSignatureAlgorithm(ECDSAWithSHA256OID), WithCertificates(cert)},
+			digest:    SHA256OID,
+			signature: ECDSAWithSHA256OID,
+		},
+		{
+			name:      "Detached",
+			opts:      []Option{WithDigestAlgorithm(SHA512OID), WithSignatureAlgorithm(Ed25519OID), WithDetached()},
+			digest:    SHA512OID,
+			signature: Ed25519OID,
+			detached:  true,
+		},
+		{
+			name: "GOST",
+			opts: []Option{
+				WithDigestAlgorithm(cmsdetector.GOSTR34112012256OID),
+				WithSignatureAlgorithm(cmsdetector.GOSTR34102012256SignatureOID),
+			},
+			digest:    cmsdetector.GOSTR34112012256OID,
+			signature: cmsdetector.GOSTR34102012256SignatureOID,
+			provider:  cmsdetector.ProviderCryptoPro,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := SignedData(t, tt.opts...)
+
+				result, err := cmsdetector.Detect(data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.Kind != cmsdetector.KindSignedData {
+					t.Errorf("Expected kind %v, got %v", cmsdetector.KindSignedData, result.Kind)
+				}
+
+				if result.Provider != tt.provider {
+					t.Errorf("Expected provider %q, got %q", tt.provider, result.Provider)
+				}
+
+				if content := encapsulatedContent(t, data); (content == nil) != tt.detached {
+					t.Errorf("Expected detached %v, got content %q", tt.detached, content)
+				}
+
+				report, err := cmsdetector.InspectAlgorithms(data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				if !containsOID(report.Digest, tt.digest) {
+					t.Errorf("Expected digest %s, got %v", tt.digest, algorithmNames(report.Digest))
+				}
+
+				if !containsOID(report.Signature, tt.signature) {
+					t.Errorf("Expected signature %s, got %v", tt.signature, algorithmNames(report.Signature))
+				}
+			},
+		)
+	}
+}
+
+// TestSignedDataContent tests that the content is encapsulated
+func TestSignedDataContent(t *testing.T) {
+	content := []byte("signed content")
+
+	encapsulated := encapsulatedContent(t, SignedData(t, WithContent(content)))
+	if !bytes.Equal(encapsulated, content) {
+		t.Errorf("Expected content %q, got %q", content, encapsulated)
+	}
+}
+
+// TestEnvelopedData tests detection and algorithm inspection of generated EnvelopedData and EncryptedData
+func TestEnvelopedData(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              func(tb testing.TB, opts ...Option) []byte
+		opts              []Option
+		kind              cmsdetector.Kind
+		keyEncryption     asn1.ObjectIdentifier
+		contentEncryption asn1.ObjectIdentifier
+		weak              bool
+	}{
+		{
+			name:              "EnvelopedData defaults",
+			data:              EnvelopedData,
+			kind:              cmsdetector.KindEnvelopedData,
+			keyEncryption:     RSAEncryptionOID,
+			contentEncryption: AES256CBCOID,
+		},
+		{
+			name:              "EnvelopedData with RSA-OAEP and Triple-DES",
+			data:              EnvelopedData,
+			opts:              []Option{WithKeyEncryptionAlgorithm(RSAESOAEPOID), WithContentEncryptionAlgorithm(DESEDE3CBCOID)},
+			kind:              cmsdetector.KindEnvelopedData,
+			keyEncryption:     RSAESOAEPOID,
+			contentEncryption: DESEDE3CBCOID,
+			weak:              true,
+		},
+		{
+			name:              "EncryptedData defaults",
+			data:              EncryptedData,
+			kind:              cmsdetector.KindEncryptedData,
+			contentEncryption: AES256CBCOID,
+		},
+		{
+			name:              "EncryptedData with PBES2 and few iterations",
+			data:              EncryptedData,
+			opts:              []Option{WithContentEncryptionAlgorithm(PBES2OID), WithIterations(10)},
+			kind:              cmsdetector.KindEncryptedData,
+			contentEncryption: PBES2OID,
+			weak:              true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := tt.data(t, tt.opts...)
+
+				result, err := cmsdetector.Detect(data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.Kind != tt.kind {
+					t.Errorf("Expected kind %v, got %v", tt.kind, result.Kind)
+				}
+
+				report, err := cmsdetector.InspectAlgorithms(data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				if tt.keyEncryption != nil && !containsOID(report.KeyEncryption, tt.keyEncryption) {
+					t.Errorf("Expected key encryption %s, got %v", tt.keyEncryption, algorithmNames(report.KeyEncryption))
+				}
+
+				if !containsOID(report.ContentEncryption, tt.contentEncryption) {
+					t.Errorf("Expected content encryption %s, got %v", tt.contentEncryption, algorithmNames(report.ContentEncryption))
+				}
+
+				if weak := len(report.Weak) > 0; weak != tt.weak {
+					t.Errorf("Expected weak %v, got %v", tt.weak, report.Weak)
+				}
+			},
+		)
+	}
+}
+
+// TestDigestedData tests detection and algorithm inspection of generated DigestedData
+func TestDigestedData(t *testing.T) {
+	data := DigestedData(t, WithDigestAlgorithm(SHA1OID))
+
+	result, err := cmsdetector.Detect(data)
+	if err != nil {
+		t.Fatalf("Detect returned an error: %v", err)
+	}
+
+	if result.Kind != cmsdetector.KindDigestedData {
+		t.Errorf("Expected kind %v, got %v", cmsdetector.KindDigestedData, result.Kind)
+	}
+
+	report, err := cmsdetector.InspectAlgorithms(data)
+	if err != nil {
+		t.Fatalf("InspectAlgorithms returned an error: %v", err)
+	}
+
+	if !containsOID(report.Digest, SHA1OID) {
+		t.Errorf("Expected digest %s, got %v", SHA1OID, algorithmNames(report.Digest))
+	}
+
+	if len(report.Weak) == 0 {
+		t.Error("Expected SHA-1 to be reported as weak")
+	}
+}
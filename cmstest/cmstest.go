@@ -0,0 +1,258 @@
+// Package cmstest builds minimal, structurally valid CMS (PKCS#7) and PKCS#12 structures for tests,
+// so that projects classifying or inspecting such data do not need to commit binary fixtures.
+//
+// The structures reference the selected algorithms with realistic parameters, but carry placeholder
+// signatures and ciphertexts: they are recognized by parsers such as cmsdetector, not verified or decrypted.
+// The package only depends on the standard library and can be used by the tests of any package.
+package cmstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Digest algorithm OIDs
+var (
+	SHA1OID   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	SHA256OID = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	SHA384OID = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	SHA512OID = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// Signature and key encryption algorithm OIDs
+var (
+	RSAEncryptionOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	RSAESOAEPOID       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 7}
+	SHA256WithRSAOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	ECDSAWithSHA256OID = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	Ed25519OID         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// Content encryption algorithm OIDs
+var (
+	AES128CBCOID                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	AES256CBCOID                     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	DESEDE3CBCOID                    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	PBES2OID                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	PBEWithSHAAnd3KeyTripleDESCBCOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+)
+
+// Algorithm OIDs and arcs used to select algorithm parameters
+var (
+	pbkdf2OID         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	hmacWithSHA256OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	pbes1Arc          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5}
+	pkcs12PBEArc      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1}
+	pkcs1Arc          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1}
+)
+
+// defaultIterations is the key derivation iteration count used unless WithIterations is given
+const defaultIterations = 2048
+
+// Option configures the generated structures. Options not applicable to a builder are ignored
+type Option func(*config)
+
+// config contains the algorithms and contents of the generated structures
+type config struct {
+	digest            asn1.ObjectIdentifier
+	signature         asn1.ObjectIdentifier
+	keyEncryption     asn1.ObjectIdentifier
+	contentEncryption asn1.ObjectIdentifier
+	content           []byte
+	detached          bool
+	certificates      [][]byte
+	iterations        int
+}
+
+// WithDigestAlgorithm sets the digest algorithm of SignedData signers, of DigestedData and of the PFX integrity MAC
+// (SHA-256 by default)
+func WithDigestAlgorithm(oid asn1.ObjectIdentifier) Option {
+	return func(c *config) {
+		c.digest = oid
+	}
+}
+
+// WithSignatureAlgorithm sets the signature algorithm of SignedData signers (SHA-256 with RSA by default)
+func WithSignatureAlgorithm(oid asn1.ObjectIdentifier) Option {
+	return func(c *config) {
+		c.signature = oid
+	}
+}
+
+// WithKeyEncryptionAlgorithm sets the key encryption algorithm of EnvelopedData recipients (RSA by default)
+// and of the PFX private key bag (PBES2 by default)
+func WithKeyEncryptionAlgorithm(oid asn1.ObjectIdentifier) Option {
+	return func(c *config) {
+		c.keyEncryption = oid
+	}
+}
+
+// WithContentEncryptionAlgorithm sets the content encryption algorithm of EnvelopedData and EncryptedData
+// (AES-256-CBC by default) and of the encrypted PFX safe contents (PBES2 by default)
+func WithContentEncryptionAlgorithm(oid asn1.ObjectIdentifier) Option {
+	return func(c *config) {
+		c.contentEncryption = oid
+	}
+}
+
+// WithContent sets the content encapsulated in SignedData and DigestedData, or the placeholder ciphertext of encrypted content
+func WithContent(content []byte) Option {
+	return func(c *config) {
+		c.content = content
+	}
+}
+
+// WithDetached omits the encapsulated content of SignedData and DigestedData, as in detached signatures
+func WithDetached() Option {
+	return func(c *config) {
+		c.detached = true
+	}
+}
+
+// WithCertificates adds DER certificates to SignedData and as certificate bags to PFX containers.
+// The first certificate identifies the SignedData signer and the EnvelopedData recipient
+func WithCertificates(certs ...[]byte) Option {
+	return func(c *config) {
+		c.certificates = append(c.certificates, certs...)
+	}
+}
+
+// WithIterations sets the key derivation iteration count of password-based encryption and of the PFX MAC (2048 by default)
+func WithIterations(iterations int) Option {
+	return func(c *config) {
+		c.iterations = iterations
+	}
+}
+
+// newConfig applies the options to the default configuration
+func newConfig(opts []Option) *config {
+	c := &config{
+		digest:     SHA256OID,
+		signature:  SHA256WithRSAOID,
+		content:    []byte("cmstest content"),
+		iterations: defaultIterations,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// orDefault returns the OID, or the default when the OID was not selected
+func orDefault(oid, def asn1.ObjectIdentifier) asn1.ObjectIdentifier {
+	if len(oid) == 0 {
+		return def
+	}
+
+	return oid
+}
+
+// Certificate creates a self-signed ECDSA P-256 certificate with the given common name in DER form
+func Certificate(tb testing.TB, commonName string) []byte {
+	tb.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		tb.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	return der
+}
+
+// pbeParams provides the ASN.1 structure of PBES1 and PKCS#12 PBE parameters
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// pbkdf2Params provides the ASN.1 structure of PBKDF2 parameters (RFC 8018, appendix A.2)
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	PRF            pkix.AlgorithmIdentifier
+}
+
+// pbes2Params provides the ASN.1 structure of PBES2 parameters (RFC 8018, appendix A.4)
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// algorithmIdentifier creates an AlgorithmIdentifier with the parameters the algorithm is used with:
+// an IV for CBC ciphers, salt and iteration count for password-based encryption and NULL for RSA
+func algorithmIdentifier(tb testing.TB, oid asn1.ObjectIdentifier, iterations int) pkix.AlgorithmIdentifier {
+	tb.Helper()
+
+	alg := pkix.AlgorithmIdentifier{Algorithm: oid}
+
+	switch {
+	case oid.Equal(AES128CBCOID), oid.Equal(AES256CBCOID):
+		alg.Parameters = rawValue(tb, make([]byte, 16))
+	case oid.Equal(DESEDE3CBCOID):
+		alg.Parameters = rawValue(tb, make([]byte, 8))
+	case oid.Equal(PBES2OID):
+		kdf := pbkdf2Params{
+			Salt:           make([]byte, 8),
+			IterationCount: iterations,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: hmacWithSHA256OID, Parameters: asn1.NullRawValue},
+		}
+
+		alg.Parameters = rawValue(
+			tb, pbes2Params{
+				KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: pbkdf2OID, Parameters: rawValue(tb, kdf)},
+				EncryptionScheme:  algorithmIdentifier(tb, AES256CBCOID, iterations),
+			},
+		)
+	case hasPrefix(oid, pbes1Arc), hasPrefix(oid, pkcs12PBEArc):
+		alg.Parameters = rawValue(tb, pbeParams{Salt: make([]byte, 8), Iterations: iterations})
+	case hasPrefix(oid, pkcs1Arc) && !oid.Equal(RSAESOAEPOID):
+		alg.Parameters = asn1.NullRawValue
+	}
+
+	return alg
+}
+
+// hasPrefix checks if the OID lies within the arc
+func hasPrefix(oid, arc asn1.ObjectIdentifier) bool {
+	return len(oid) > len(arc) && oid[:len(arc)].Equal(arc)
+}
+
+// rawValue returns the ASN.1 encoding of the value as a raw value
+func rawValue(tb testing.TB, value interface{}) asn1.RawValue {
+	tb.Helper()
+
+	return asn1.RawValue{FullBytes: marshal(tb, value)}
+}
+
+// marshal returns the ASN.1 encoding of the value or fails the test
+func marshal(tb testing.TB, value interface{}) []byte {
+	tb.Helper()
+
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		tb.Fatalf("Failed to marshal %T: %v", value, err)
+	}
+
+	return der
+}
@@ -0,0 +1,117 @@
+package cmstest
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+// PKCS#12 bag OIDs (RFC 7292, section 4.2)
+var (
+	shroudedKeyBagOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	certBagOID        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	x509CertBagOID    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+)
+
+// pfxVersion is the version of PFX structures defined by RFC 7292
+const pfxVersion = 3
+
+// pfx provides the ASN.1 structure of a PKCS#12 PFX (RFC 7292, section 4)
+type pfx struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData
+}
+
+// macData provides the ASN.1 structure of the PKCS#12 integrity MAC
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+// digestInfo provides the ASN.1 structure of DigestInfo (RFC 8017, section 9.2)
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// safeBag provides the ASN.1 structure of a PKCS#12 SafeBag
+type safeBag struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// certBag provides the ASN.1 structure of a PKCS#12 CertBag
+type certBag struct {
+	CertID    asn1.ObjectIdentifier
+	CertValue []byte `asn1:"tag:0,explicit"`
+}
+
+// encryptedPrivateKeyInfo provides the ASN.1 structure of PKCS#8 EncryptedPrivateKeyInfo
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// PFX creates a DER encoded PKCS#12 PFX laid out like the containers created by OpenSSL: an unencrypted
+// safe holding a shrouded private key bag and the certificate bags, followed by encrypted safe contents.
+// The MAC and the encrypted parts are placeholders
+func PFX(tb testing.TB, opts ...Option) []byte {
+	tb.Helper()
+
+	c := newConfig(opts)
+
+	bags := []safeBag{
+		newSafeBag(
+			tb, shroudedKeyBagOID, encryptedPrivateKeyInfo{
+				Algorithm:     algorithmIdentifier(tb, orDefault(c.keyEncryption, PBES2OID), c.iterations),
+				EncryptedData: placeholder,
+			},
+		),
+	}
+
+	for _, cert := range c.certificates {
+		bags = append(bags, newSafeBag(tb, certBagOID, certBag{CertID: x509CertBagOID, CertValue: cert}))
+	}
+
+	encrypted := encryptedData{
+		Version:              0,
+		EncryptedContentInfo: newEncryptedContentInfo(tb, c, PBES2OID),
+	}
+
+	authSafe := []asn1.RawValue{
+		{FullBytes: marshalContentInfo(tb, dataOID, marshal(tb, bags))},
+		{FullBytes: marshalContentInfo(tb, encryptedDataOID, encrypted)},
+	}
+
+	var authSafeInfo contentInfo
+	if _, err := asn1.Unmarshal(marshalContentInfo(tb, dataOID, marshal(tb, authSafe)), &authSafeInfo); err != nil {
+		tb.Fatalf("Failed to unmarshal authenticated safe: %v", err)
+	}
+
+	return marshal(
+		tb, pfx{
+			Version:  pfxVersion,
+			AuthSafe: authSafeInfo,
+			MacData: macData{
+				Mac: digestInfo{
+					Algorithm: algorithmIdentifier(tb, c.digest, c.iterations),
+					Digest:    make([]byte, 32),
+				},
+				MacSalt:    make([]byte, 8),
+				Iterations: c.iterations,
+			},
+		},
+	)
+}
+
+// newSafeBag creates a SafeBag with the given type wrapping the ASN.1 encoding of value
+func newSafeBag(tb testing.TB, bagType asn1.ObjectIdentifier, value interface{}) safeBag {
+	tb.Helper()
+
+	return safeBag{
+		ID:    bagType,
+		Value: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: marshal(tb, value)},
+	}
+}
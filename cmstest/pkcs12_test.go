@@ -0,0 +1,79 @@
+package cmstest
+
+import (
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// TestPFX tests detection and algorithm inspection of generated PKCS#12 containers
+func TestPFX(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []Option
+		contentEncryption []string
+		weak              bool
+	}{
+		{
+			name:              "Defaults",
+			contentEncryption: []string{PBES2OID.String(), AES256CBCOID.String()},
+		},
+		{
+			name:              "With certificate",
+			opts:              []Option{WithCertificates(Certificate(t, "cmstest holder"))},
+			contentEncryption: []string{PBES2OID.String(), AES256CBCOID.String()},
+		},
+		{
+			name: "Legacy algorithms",
+			opts: []Option{
+				WithDigestAlgorithm(SHA1OID),
+				WithKeyEncryptionAlgorithm(PBEWithSHAAnd3KeyTripleDESCBCOID),
+				WithContentEncryptionAlgorithm(PBEWithSHAAnd3KeyTripleDESCBCOID),
+			},
+			contentEncryption: []string{PBEWithSHAAnd3KeyTripleDESCBCOID.String()},
+			weak:              true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := PFX(t, tt.opts...)
+
+				result, err := cmsdetector.DetectAny(data)
+				if err != nil {
+					t.Fatalf("DetectAny returned an error: %v", err)
+				}
+
+				if result.Kind != cmsdetector.KindPKCS12 {
+					t.Errorf("Expected kind %v, got %v", cmsdetector.KindPKCS12, result.Kind)
+				}
+
+				if !cmsdetector.IsPKCS12(data) {
+					t.Error("Expected IsPKCS12 to return true")
+				}
+
+				report, err := cmsdetector.InspectAlgorithms(data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				for _, oid := range tt.contentEncryption {
+					found := false
+
+					for _, alg := range report.ContentEncryption {
+						found = found || alg.OID.String() == oid
+					}
+
+					if !found {
+						t.Errorf("Expected content encryption %s, got %v", oid, algorithmNames(report.ContentEncryption))
+					}
+				}
+
+				if weak := len(report.Weak) > 0; weak != tt.weak {
+					t.Errorf("Expected weak %v, got %v", tt.weak, report.Weak)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,154 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Properties compared by CompareResults, in the order of the reported differences
+const (
+	ComparedKind              = "kind"
+	ComparedLayers            = "layers"
+	ComparedDigest            = "digest algorithms"
+	ComparedSignature         = "signature algorithms"
+	ComparedKeyEncryption     = "key encryption algorithms"
+	ComparedContentEncryption = "content encryption algorithms"
+	ComparedSigners           = "signers"
+	ComparedCounterSignatures = "counter-signatures"
+)
+
+// comparedProperties lists the compared properties in the order of the reported differences
+var comparedProperties = []string{
+	ComparedKind,
+	ComparedLayers,
+	ComparedDigest,
+	ComparedSignature,
+	ComparedKeyEncryption,
+	ComparedContentEncryption,
+	ComparedSigners,
+	ComparedCounterSignatures,
+}
+
+// Difference is a property in which two structures differ. An empty value means the property
+// does not apply, e.g. signers of EnvelopedData
+type Difference struct {
+	Property string // One of the Compared* constants
+	A, B     string
+}
+
+// String formats the difference for logs and command line output
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %q != %q", d.Property, d.A, d.B)
+}
+
+// CompareResults detects both structures and reports the properties in which they differ: the
+// kind, the layers wrapping the content such as PEM, BER encoding and encapsulated content
+// types, the algorithms and the number of signers. Both must be in a recognized format. An empty
+// result means no differences were found
+func CompareResults(a, b []byte) ([]Difference, error) {
+	propertiesA, err := compareProperties(a)
+	if err != nil {
+		return nil, fmt.Errorf("first structure: %w", err)
+	}
+
+	propertiesB, err := compareProperties(b)
+	if err != nil {
+		return nil, fmt.Errorf("second structure: %w", err)
+	}
+
+	var differences []Difference
+
+	for _, property := range comparedProperties {
+		if propertiesA[property] != propertiesB[property] {
+			differences = append(differences, Difference{Property: property, A: propertiesA[property], B: propertiesB[property]})
+		}
+	}
+
+	return differences, nil
+}
+
+// compareProperties returns the values of the properties compared by CompareResults
+func compareProperties(data []byte) (map[string]string, error) {
+	// BER is accepted, so that structures differing only in their encoding can be compared
+	detector := NewDetector(WithStrictness(StrictnessLenient))
+
+	result, err := detector.DetectAny(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []string
+
+	if result.PEMType != "" {
+		layers = append(layers, "PEM "+result.PEMType)
+
+		if block, _ := pem.Decode(bytes.TrimSpace(data)); block != nil {
+			data = block.Bytes
+
+			// BER is only converted once the PEM encoding is removed
+			if decoded, err := detector.DetectAny(data); err == nil {
+				result.Family, result.Kind = decoded.Family, decoded.Kind
+			}
+		}
+	}
+
+	properties := map[string]string{ComparedKind: result.Kind.String()}
+
+	if result.Family != FamilyCMS {
+		properties[ComparedLayers] = strings.Join(layers, ", ")
+
+		return properties, nil
+	}
+
+	if der, ok := berToDER(data); ok && !bytes.HasPrefix(data, der) {
+		layers = append(layers, "BER")
+		data = der
+	} else {
+		layers = append(layers, "DER")
+	}
+
+	if sd, err := loadSignedData(data); err == nil {
+		layers = append(layers, "encapsulated "+GetOIDDescription(sd.EncapContentInfo.EContentType))
+
+		if len(sd.EncapContentInfo.EContent.FullBytes) == 0 {
+			layers = append(layers, "detached content")
+		}
+
+		if counts, err := CountSignatures(data); err == nil {
+			properties[ComparedSigners] = strconv.Itoa(counts.Signers)
+			properties[ComparedCounterSignatures] = strconv.Itoa(counts.CounterSignatures)
+		}
+	}
+
+	properties[ComparedLayers] = strings.Join(layers, ", ")
+
+	if report, err := InspectAlgorithms(data); err == nil {
+		properties[ComparedDigest] = algorithmNames(report.Digest)
+		properties[ComparedSignature] = algorithmNames(report.Signature)
+		properties[ComparedKeyEncryption] = algorithmNames(report.KeyEncryption)
+		properties[ComparedContentEncryption] = algorithmNames(report.ContentEncryption)
+	}
+
+	return properties, nil
+}
+
+// algorithmNames returns the sorted names of the algorithms, so that their order does not matter
+func algorithmNames(algorithms []Algorithm) string {
+	names := make([]string, 0, len(algorithms))
+
+	for _, alg := range algorithms {
+		if alg.Name != "" {
+			names = append(names, alg.Name)
+		} else {
+			names = append(names, alg.OID.String())
+		}
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,93 @@
+package cmsdetector
+
+import (
+	"encoding/pem"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestCompareResults tests the differences reported for pairs of structures
+func TestCompareResults(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	tests := []struct {
+		name     string
+		a, b     []byte
+		expected []Difference
+	}{
+		{
+			name: "Identical",
+			a:    signed,
+			b:    signed,
+		},
+		{
+			name: "Same structure with other content",
+			a:    signed,
+			b:    cmstest.SignedData(t, cmstest.WithContent([]byte("other content"))),
+		},
+		{
+			name: "Digest algorithm",
+			a:    signed,
+			b:    cmstest.SignedData(t, cmstest.WithDigestAlgorithm(cmstest.SHA384OID)),
+			expected: []Difference{
+				{Property: ComparedDigest, A: "SHA-256", B: "SHA-384"},
+			},
+		},
+		{
+			name: "Layers",
+			a:    signed,
+			b:    pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: toIndefiniteLength(t, cmstest.SignedData(t, cmstest.WithDetached()))}),
+			expected: []Difference{
+				{Property: ComparedLayers, A: "DER, encapsulated PKCS#7 Data", B: "PEM PKCS7, BER, encapsulated PKCS#7 Data, detached content"},
+			},
+		},
+		{
+			name: "Kind",
+			a:    signed,
+			b:    cmstest.EncryptedData(t),
+			expected: []Difference{
+				{Property: ComparedKind, A: "PKCS#7 Signed Data", B: "PKCS#7 Encrypted Data"},
+				{Property: ComparedLayers, A: "DER, encapsulated PKCS#7 Data", B: "DER"},
+				{Property: ComparedDigest, A: "SHA-256", B: ""},
+				{Property: ComparedSignature, A: "SHA-256 with RSA", B: ""},
+				{Property: ComparedContentEncryption, A: "", B: "AES-256-CBC"},
+				{Property: ComparedSigners, A: "1", B: ""},
+				{Property: ComparedCounterSignatures, A: "0", B: ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				differences, err := CompareResults(tt.a, tt.b)
+				if err != nil {
+					t.Fatalf("CompareResults returned an error: %v", err)
+				}
+
+				if !reflect.DeepEqual(differences, tt.expected) {
+					t.Errorf("Expected %v, got %v", tt.expected, differences)
+				}
+			},
+		)
+	}
+}
+
+// TestCompareResultsUnknownFormat tests that both structures must be in a recognized format
+func TestCompareResultsUnknownFormat(t *testing.T) {
+	if _, err := CompareResults(cmstest.SignedData(t), []byte("Hello, world")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected %v, got %v", ErrUnknownFormat, err)
+	}
+}
+
+// TestDifferenceString tests the formatting of differences
+func TestDifferenceString(t *testing.T) {
+	d := Difference{Property: ComparedSigners, A: "1", B: "2"}
+
+	if s := d.String(); s != `signers: "1" != "2"` {
+		t.Errorf("Expected %q, got %q", `signers: "1" != "2"`, s)
+	}
+}
@@ -0,0 +1,177 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// DER identifier octets of the encapsulated content and of the [0] IMPLICIT encrypted content
+const (
+	derTagOctetString = 0x04
+	derTagImplicit0   = 0x80
+)
+
+// derField is a field preceding the encapsulated or encrypted content info of a structure
+type derField struct {
+	tag      byte
+	optional bool
+}
+
+// contentInfoFields lists, by content type, the fields preceding the encapsulated or encrypted
+// content info. Structures with encrypted content are marked with encrypted
+var contentInfoFields = map[string]struct {
+	fields    []derField
+	encrypted bool
+}{
+	PKCS7SignedDataOID.String(): {
+		fields: []derField{{tag: derTagInteger}, {tag: derTagSet}},
+	},
+	PKCS7DigestedDataOID.String(): {
+		fields: []derField{{tag: derTagInteger}, {tag: derTagSequence}},
+	},
+	PKCS7EnvelopedDataOID.String(): {
+		fields:    []derField{{tag: derTagInteger}, {tag: derTagExplicit0, optional: true}, {tag: derTagSet}},
+		encrypted: true,
+	},
+	AuthEnvelopedDataOID.String(): {
+		fields:    []derField{{tag: derTagInteger}, {tag: derTagExplicit0, optional: true}, {tag: derTagSet}},
+		encrypted: true,
+	},
+	PKCS7SignedAndEnvelopedOID.String(): {
+		fields:    []derField{{tag: derTagInteger}, {tag: derTagSet}, {tag: derTagSet}},
+		encrypted: true,
+	},
+	PKCS7EncryptedDataOID.String(): {
+		fields:    []derField{{tag: derTagInteger}},
+		encrypted: true,
+	},
+}
+
+// derWalker steps through the DER headers of an input read with read, so that the length of
+// the content can be taken from its header without reading the content
+type derWalker struct {
+	read func(offset int64, n int) ([]byte, error)
+	ok   bool
+}
+
+// next parses the header of the element at the offset, which must end before end, returning its
+// first identifier octet, the offset of its contents and of the following element. Once a header
+// fails to parse all following calls fail
+func (w *derWalker) next(offset, end int64) (tag byte, contents, following int64) {
+	available := end - offset
+	if available > derMaxHeaderSize {
+		available = derMaxHeaderSize
+	}
+
+	if !w.ok || available < 2 {
+		w.ok = false
+
+		return 0, 0, 0
+	}
+
+	header, err := w.read(offset, int(available))
+	if err != nil {
+		w.ok = false
+
+		return 0, 0, 0
+	}
+
+	tag, headerLen, length, ok := core.ReadHeader(header)
+	contents = offset + int64(headerLen)
+	following = contents + int64(length)
+
+	if !ok || following > end {
+		w.ok = false
+
+		return 0, 0, 0
+	}
+
+	return tag, contents, following
+}
+
+// contentSize returns the length of the content encapsulated or encrypted by the ContentInfo of the
+// given type and size, taken from the header of the content. Detached content has a size of 0
+func contentSize(read func(offset int64, n int) ([]byte, error), size int64, contentType asn1.ObjectIdentifier) (int64, bool) {
+	structure, known := contentInfoFields[contentType.String()]
+	if !known && !contentType.Equal(PKCS7DataOID) {
+		return 0, false
+	}
+
+	w := &derWalker{read: read, ok: true}
+
+	// ContentInfo, its content type and the [0] EXPLICIT content
+	tag, offset, end := w.next(0, size)
+	_, _, offset = w.next(offset, end)
+	explicit, offset, end := w.next(offset, end)
+
+	if !w.ok || tag != derTagSequence || explicit != derTagExplicit0 {
+		return 0, false
+	}
+
+	if !known {
+		return encapsulatedSize(w, offset, end)
+	}
+
+	if tag, offset, end = w.next(offset, end); !w.ok || tag != derTagSequence {
+		return 0, false
+	}
+
+	for _, field := range structure.fields {
+		tag, _, following := w.next(offset, end)
+		if !w.ok || tag != field.tag && !field.optional {
+			return 0, false
+		}
+
+		if tag == field.tag {
+			offset = following
+		}
+	}
+
+	// EncapsulatedContentInfo or EncryptedContentInfo and its content type
+	if tag, offset, end = w.next(offset, end); !w.ok || tag != derTagSequence {
+		return 0, false
+	}
+
+	_, _, offset = w.next(offset, end)
+
+	if !structure.encrypted {
+		if offset == end {
+			return 0, w.ok
+		}
+
+		if explicit, offset, end = w.next(offset, end); explicit != derTagExplicit0 {
+			return 0, false
+		}
+
+		return encapsulatedSize(w, offset, end)
+	}
+
+	// The content encryption algorithm precedes the optional encrypted content
+	_, _, offset = w.next(offset, end)
+	if offset == end {
+		return 0, w.ok
+	}
+
+	tag, contents, following := w.next(offset, end)
+	if !w.ok || tag != derTagImplicit0 && tag != derTagExplicit0 {
+		return 0, false
+	}
+
+	return following - contents, true
+}
+
+// encapsulatedSize returns the length of the OCTET STRING at the offset, or the size of the whole
+// element for PKCS #7 v1.5 content of other types such as Authenticode SpcIndirectDataContent
+func encapsulatedSize(w *derWalker, offset, end int64) (int64, bool) {
+	tag, contents, following := w.next(offset, end)
+	if !w.ok {
+		return 0, false
+	}
+
+	if tag != derTagOctetString {
+		return following - offset, true
+	}
+
+	return following - contents, true
+}
@@ -0,0 +1,56 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoConversion is returned by SuggestConversion when OpenSSL cannot convert between the kinds
+var ErrNoConversion = errors.New("no OpenSSL conversion between the formats")
+
+// conversion is a pair of source and target kinds
+type conversion struct {
+	from, to Kind
+}
+
+// conversionCommands maps conversions to OpenSSL command lines, %[1]s is replaced with the input file name
+var conversionCommands = map[conversion]string{
+	{KindPKCS12, KindCertificate}:                  "openssl pkcs12 -in %[1]s -nokeys -out certificates.pem",
+	{KindPKCS12, KindPrivateKey}:                   "openssl pkcs12 -in %[1]s -nocerts -nodes -out key.pem",
+	{KindPKCS12, KindEncryptedPrivateKey}:          "openssl pkcs12 -in %[1]s -nocerts -out key.pem",
+	{KindEncryptedPKCS12, KindCertificate}:         "openssl pkcs12 -in %[1]s -nokeys -out certificates.pem",
+	{KindEncryptedPKCS12, KindPrivateKey}:          "openssl pkcs12 -in %[1]s -nocerts -nodes -out key.pem",
+	{KindEncryptedPKCS12, KindEncryptedPrivateKey}: "openssl pkcs12 -in %[1]s -nocerts -out key.pem",
+	{KindSignedData, KindCertificate}:              "openssl pkcs7 -inform DER -in %[1]s -print_certs -out certificates.pem",
+	{KindSignedData, KindData}:                     "openssl cms -verify -noverify -inform DER -in %[1]s -out content.bin",
+	{KindWindowsCatalog, KindCertificate}:          "openssl pkcs7 -inform DER -in %[1]s -print_certs -out certificates.pem",
+	{KindEnvelopedData, KindData}:                  "openssl cms -decrypt -inform DER -in %[1]s -recip certificate.pem -inkey key.pem -out content.bin",
+	{KindDigestedData, KindData}:                   "openssl cms -digest_verify -inform DER -in %[1]s -out content.bin",
+	{KindEncryptedData, KindData}:                  "openssl cms -EncryptedData_decrypt -inform DER -in %[1]s -secretkey <hex key> -out content.bin",
+	{KindData, KindSignedData}:                     "openssl cms -sign -binary -nodetach -in %[1]s -signer certificate.pem -inkey key.pem -outform DER -out signed.p7s",
+	{KindData, KindEnvelopedData}:                  "openssl cms -encrypt -binary -in %[1]s -outform DER -out encrypted.p7m certificate.pem",
+	{KindCertificate, KindSignedData}:              "openssl x509 -inform DER -in %[1]s -out certificate.pem && openssl crl2pkcs7 -nocrl -certfile certificate.pem -outform DER -out certificates.p7b",
+	{KindCertificate, KindPKCS12}:                  "openssl x509 -inform DER -in %[1]s -out certificate.pem && openssl pkcs12 -export -in certificate.pem -inkey key.pem -out output.p12",
+	{KindPrivateKey, KindPKCS12}:                   "openssl pkey -inform DER -in %[1]s -out key.pem && openssl pkcs12 -export -in certificate.pem -inkey key.pem -out output.p12",
+	{KindPrivateKey, KindEncryptedPrivateKey}:      "openssl pkcs8 -topk8 -inform DER -in %[1]s -out key-encrypted.pem",
+	{KindEncryptedPrivateKey, KindPrivateKey}:      "openssl pkcs8 -inform DER -in %[1]s -out key.pem",
+}
+
+// SuggestConversion returns the OpenSSL command line converting data of the detected kind to the
+// target kind, e.g. "openssl pkcs12 -in input.p12 -nokeys -out certificates.pem" to extract the
+// certificates of a PKCS#12 container. The input file is named after the suggested extension of
+// the detected kind and assumed to be DER encoded as accepted by Detect, other file names are
+// placeholders. Content requiring the GOST engine is reported with -engine gost. ErrNoConversion is returned when there is no such conversion
+func SuggestConversion(result DetectionResult, target Kind) (string, error) {
+	command, ok := conversionCommands[conversion{from: result.Kind, to: target}]
+	if !ok {
+		return "", fmt.Errorf("%w: %s to %s", ErrNoConversion, result.Kind, target)
+	}
+
+	command = fmt.Sprintf(command, "input"+result.SuggestedExtension())
+	if result.Provider == ProviderCryptoPro {
+		command += " -engine gost"
+	}
+
+	return command, nil
+}
@@ -0,0 +1,75 @@
+package cmsdetector
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestSuggestConversion tests the OpenSSL command lines suggested for conversions between kinds
+func TestSuggestConversion(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      DetectionResult
+		target      Kind
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "PKCS#12 certificates",
+			result:   DetectionResult{Kind: KindPKCS12},
+			target:   KindCertificate,
+			expected: "openssl pkcs12 -in input.p12 -nokeys -out certificates.pem",
+		},
+		{
+			name:     "Encrypted PKCS#12 key",
+			result:   DetectionResult{Kind: KindEncryptedPKCS12},
+			target:   KindPrivateKey,
+			expected: "openssl pkcs12 -in input.p12 -nocerts -nodes -out key.pem",
+		},
+		{
+			name:     "Signed content",
+			result:   DetectionResult{Kind: KindSignedData},
+			target:   KindData,
+			expected: "openssl cms -verify -noverify -inform DER -in input.p7m -out content.bin",
+		},
+		{
+			name:     "GOST enveloped content",
+			result:   DetectionResult{Kind: KindEnvelopedData, Provider: ProviderCryptoPro},
+			target:   KindData,
+			expected: "openssl cms -decrypt -inform DER -in input.p7m -recip certificate.pem -inkey key.pem -out content.bin -engine gost",
+		},
+		{
+			name:     "Certificate to PKCS#7",
+			result:   DetectionResult{Kind: KindCertificate},
+			target:   KindSignedData,
+			expected: "openssl x509 -inform DER -in input.cer -out certificate.pem && openssl crl2pkcs7 -nocrl -certfile certificate.pem -outform DER -out certificates.p7b",
+		},
+		{
+			name:        "Same kind",
+			result:      DetectionResult{Kind: KindSignedData},
+			target:      KindSignedData,
+			expectedErr: ErrNoConversion,
+		},
+		{
+			name:        "Unsupported",
+			result:      DetectionResult{Kind: KindJKS},
+			target:      KindPKCS12,
+			expectedErr: ErrNoConversion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				command, err := SuggestConversion(tt.result, tt.target)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if command != tt.expected {
+					t.Errorf("Expected %q, got %q", tt.expected, command)
+				}
+			},
+		)
+	}
+}
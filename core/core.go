@@ -0,0 +1,121 @@
+// Package core classifies CMS/PKCS structures by their DER header with a hand-written reader that
+// only needs the bytes package: no reflection, encoding/asn1, os or os/exec. It compiles for js/wasm,
+// WASI and TinyGo, so browser-side upload validation and embedded devices get the classification of
+// cmsdetector.KindOf, which is built on this package:
+//
+//	if core.KindOf(upload) != core.KindSignedData {
+//		...
+//	}
+package core
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Kind identifies the kind of a structure recognized by KindOf
+type Kind int
+
+// Kinds recognized by KindOf, a subset of the kinds of cmsdetector
+const (
+	KindUnknown Kind = iota
+	KindData
+	KindSignedData
+	KindEnvelopedData
+	KindSignedAndEnvelopedData
+	KindDigestedData
+	KindEncryptedData
+	KindPKCS12
+	KindWindowsCatalog
+)
+
+// kindNames holds the names of the kinds, the same as reported by cmsdetector
+var kindNames = [...]string{
+	KindUnknown:                "Unknown",
+	KindData:                   "PKCS#7 Data",
+	KindSignedData:             "PKCS#7 Signed Data",
+	KindEnvelopedData:          "PKCS#7 Enveloped Data",
+	KindSignedAndEnvelopedData: "PKCS#7 Signed And Enveloped Data",
+	KindDigestedData:           "PKCS#7 Digested Data",
+	KindEncryptedData:          "PKCS#7 Encrypted Data",
+	KindPKCS12:                 "PKCS#12",
+	KindWindowsCatalog:         "Windows Security Catalog",
+}
+
+// String returns a human-readable name of the kind
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Contents octets of the DER encodings of the recognized OIDs
+var (
+	oidData           = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x01}       // 1.2.840.113549.1.7.1
+	oidSignedData     = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x02}       // 1.2.840.113549.1.7.2
+	oidEnvelopedData  = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x03}       // 1.2.840.113549.1.7.3
+	oidSignedAndEnv   = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x04}       // 1.2.840.113549.1.7.4
+	oidDigestedData   = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x05}       // 1.2.840.113549.1.7.5
+	oidEncryptedData  = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x06}       // 1.2.840.113549.1.7.6
+	oidPKCS12         = []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x0C, 0x0A, 0x01} // 1.2.840.113549.1.12.10.1
+	oidWindowsCatalog = []byte{0x2B, 0x06, 0x01, 0x04, 0x01, 0x82, 0x37, 0x0A, 0x01}       // 1.3.6.1.4.1.311.10.1
+)
+
+// contentTypeKinds maps the encoded content type OIDs to their kinds
+var contentTypeKinds = []struct {
+	oid  []byte
+	kind Kind
+}{
+	{oid: oidData, kind: KindData},
+	{oid: oidSignedData, kind: KindSignedData},
+	{oid: oidEnvelopedData, kind: KindEnvelopedData},
+	{oid: oidSignedAndEnv, kind: KindSignedAndEnvelopedData},
+	{oid: oidDigestedData, kind: KindDigestedData},
+	{oid: oidEncryptedData, kind: KindEncryptedData},
+	{oid: oidPKCS12, kind: KindPKCS12},
+}
+
+// KindOf classifies CMS/PKCS data by its DER header without allocating: the content type of a
+// ContentInfo, security catalogs and PKCS#12 PFX containers. It does not validate the content
+// beyond the fields it reads
+func KindOf(data []byte) Kind {
+	if IsPFX(data) {
+		return KindPKCS12
+	}
+
+	oid, ok := ContentType(data)
+	if !ok {
+		return KindUnknown
+	}
+
+	for _, ct := range contentTypeKinds {
+		if !bytes.Equal(oid, ct.oid) {
+			continue
+		}
+
+		if ct.kind == KindSignedData && isEncapsulatedWindowsCatalog(data) {
+			return KindWindowsCatalog
+		}
+
+		return ct.kind
+	}
+
+	return KindUnknown
+}
+
+// isEncapsulatedWindowsCatalog checks if the DER SignedData encapsulates a security catalog
+func isEncapsulatedWindowsCatalog(data []byte) bool {
+	_, contentInfo, _, _ := ReadElement(data)
+	_, _, rest, _ := ReadElement(contentInfo)
+
+	tag, content, _, ok := ReadElement(rest)
+	if !ok || tag != tagExplicit0 {
+		return false
+	}
+
+	oid, ok := EncapsulatedContentType(content)
+
+	return ok && bytes.Equal(oid, oidWindowsCatalog)
+}
@@ -0,0 +1,105 @@
+package core_test
+
+import (
+	"go/build"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// TestKindOf tests header-only classification of CMS/PKCS structures
+func TestKindOf(t *testing.T) {
+	data := []byte{0x30, 0x0B, 0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x01}
+	signed := cmstest.SignedData(t)
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedKind core.Kind
+	}{
+		{name: "Data without content", data: data, expectedKind: core.KindData},
+		{name: "SignedData", data: signed, expectedKind: core.KindSignedData},
+		{name: "EnvelopedData", data: cmstest.EnvelopedData(t), expectedKind: core.KindEnvelopedData},
+		{name: "PFX", data: cmstest.PFX(t), expectedKind: core.KindPKCS12},
+		{name: "Truncated", data: signed[:len(signed)-1], expectedKind: core.KindUnknown},
+		{name: "Unknown content type", data: []byte{0x30, 0x03, 0x06, 0x01, 0x2A}, expectedKind: core.KindUnknown},
+		{name: "Empty", expectedKind: core.KindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if kind := core.KindOf(tt.data); kind != tt.expectedKind {
+					t.Errorf("Expected kind %s, got %s", tt.expectedKind, kind)
+				}
+			},
+		)
+	}
+}
+
+// TestIsDefinitelyNotCMS tests rejection of data by its leading bytes
+func TestIsDefinitelyNotCMS(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	pfx := cmstest.PFX(t)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{name: "SignedData", data: signed},
+		{name: "SignedData header", data: signed[:4]},
+		{name: "PFX", data: pfx},
+		{name: "BER indefinite length", data: []byte{0x30, 0x80, 0x02, 0x01, 0x03}},
+		{name: "BER non-minimal length", data: []byte{0x30, 0x81, 0x03, 0x06, 0x01, 0x2A}},
+		{name: "Empty"},
+		{name: "Length octets missing", data: []byte{0x30, 0x82, 0x01}},
+		{name: "Not a SEQUENCE", data: []byte{0x31, 0x03, 0x06, 0x01, 0x2A}, expected: true},
+		{name: "PEM", data: []byte("-----BEGIN PKCS7-----"), expected: true},
+		{name: "Text starting with zero", data: []byte("0123456789"), expected: true},
+		{name: "Too many length octets", data: []byte{0x30, 0x85, 0x01, 0x00, 0x00, 0x00, 0x00}, expected: true},
+		{name: "Reserved length octet", data: []byte{0x30, 0xFF}, expected: true},
+		{name: "Contents too short", data: []byte{0x30, 0x02, 0x06, 0x00}, expected: true},
+		{name: "Long form contents too short", data: []byte{0x30, 0x82, 0x00, 0x02}, expected: true},
+		{name: "Unexpected first element", data: []byte{0x30, 0x03, 0x04, 0x01, 0x00}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if rejected := core.IsDefinitelyNotCMS(tt.data); rejected != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, rejected)
+				}
+			},
+		)
+	}
+}
+
+// TestKindString tests the names of defined and undefined kinds
+func TestKindString(t *testing.T) {
+	if name := core.KindWindowsCatalog.String(); name != "Windows Security Catalog" {
+		t.Errorf("Expected %q, got %q", "Windows Security Catalog", name)
+	}
+
+	if name := core.Kind(-1).String(); name != "Kind(-1)" {
+		t.Errorf("Expected %q, got %q", "Kind(-1)", name)
+	}
+}
+
+// TestImports tests that the package stays free of dependencies unavailable under js/wasm and
+// TinyGo, such as encoding/asn1 and its use of reflection, os and os/exec
+func TestImports(t *testing.T) {
+	allowed := map[string]bool{"bytes": true, "strconv": true}
+
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("Failed to read the package: %v", err)
+	}
+
+	for _, imported := range pkg.Imports {
+		if !allowed[imported] {
+			t.Errorf("Expected only %v to be imported, got %s", allowed, imported)
+		}
+	}
+}
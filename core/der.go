@@ -0,0 +1,238 @@
+package core
+
+import "bytes"
+
+// DER identifier octets read by the package
+const (
+	tagInteger          = 0x02
+	tagSequence         = 0x30
+	tagSet              = 0x31
+	tagObjectIdentifier = 0x06
+	tagExplicit0        = 0xA0
+
+	// maxLengthOctets limits long form lengths to 32 bits
+	maxLengthOctets = 4
+
+	// pfxVersion is the only PFX version defined by RFC 7292
+	pfxVersion = 3
+)
+
+// ReadElement splits the DER element at the start of data into its first identifier octet,
+// contents and the bytes following it. Only definite lengths are supported
+func ReadElement(data []byte) (tag byte, contents, rest []byte, ok bool) {
+	tag, headerLen, length, ok := ReadHeader(data)
+	if !ok || length > len(data)-headerLen {
+		return 0, nil, nil, false
+	}
+
+	data = data[headerLen:]
+
+	return tag, data[:length], data[length:], true
+}
+
+// ReadHeader parses the identifier and length octets at the start of data, returning the first
+// identifier octet, the number of identifier and length octets and the length of the contents.
+// Lengths must be minimally encoded in at most four octets
+func ReadHeader(data []byte) (tag byte, headerLen, length int, ok bool) {
+	if len(data) < 2 {
+		return 0, 0, 0, false
+	}
+
+	rest := data[1:]
+
+	// High tag numbers are skipped, callers only compare low tag numbers
+	if data[0]&0x1f == 0x1f {
+		if rest = skipHighTagNumber(rest); len(rest) == 0 {
+			return 0, 0, 0, false
+		}
+	}
+
+	length = int(rest[0])
+	rest = rest[1:]
+
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > maxLengthOctets || len(rest) < octets || rest[0] == 0 {
+			return 0, 0, 0, false
+		}
+
+		length = 0
+		for _, b := range rest[:octets] {
+			length = length<<8 | int(b)
+		}
+
+		// DER requires the short form for lengths below 128
+		if length < 0x80 {
+			return 0, 0, 0, false
+		}
+
+		rest = rest[octets:]
+	}
+
+	if length < 0 {
+		return 0, 0, 0, false
+	}
+
+	return data[0], len(data) - len(rest), length, true
+}
+
+// skipHighTagNumber skips the base-128 tag number following a high tag number identifier octet,
+// returning nil for tag numbers that are not minimally encoded or do not fit in 31 bits
+func skipHighTagNumber(data []byte) []byte {
+	number := 0
+
+	for i, b := range data {
+		if i == 0 && b == 0x80 || i >= maxLengthOctets {
+			return nil
+		}
+
+		number = number<<7 | int(b&0x7f)
+		if b&0x80 == 0 {
+			if number < 0x1f {
+				return nil
+			}
+
+			return data[i+1:]
+		}
+	}
+
+	return nil
+}
+
+// ValidOIDContents checks that the contents octets of an OID form minimally encoded subidentifiers
+func ValidOIDContents(contents []byte) bool {
+	if len(contents) == 0 || contents[len(contents)-1]&0x80 != 0 {
+		return false
+	}
+
+	for i, b := range contents {
+		// A subidentifier must not start with a 0x80 padding octet
+		if b == 0x80 && (i == 0 || contents[i-1]&0x80 == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContentType returns the contents octets of the content type OID of a DER ContentInfo
+// without decoding or copying the content
+func ContentType(data []byte) ([]byte, bool) {
+	tag, contentInfo, _, ok := ReadElement(data)
+	if !ok || tag != tagSequence {
+		return nil, false
+	}
+
+	tag, oid, rest, ok := ReadElement(contentInfo)
+	if !ok || tag != tagObjectIdentifier || !ValidOIDContents(oid) {
+		return nil, false
+	}
+
+	// The optional explicitly tagged content must be a complete element, other trailing elements are ignored
+	if len(rest) > 0 {
+		if _, _, _, ok := ReadElement(rest); !ok {
+			return nil, false
+		}
+	}
+
+	return oid, true
+}
+
+// EncapsulatedContentType returns the contents octets of the encapsulated content type OID
+// of a DER SignedData, given the contents of its explicitly tagged ContentInfo content
+func EncapsulatedContentType(content []byte) ([]byte, bool) {
+	tag, signedData, _, ok := ReadElement(content)
+	if !ok || tag != tagSequence {
+		return nil, false
+	}
+
+	// Skip the version and digestAlgorithms fields
+	for _, expected := range []byte{tagInteger, tagSet} {
+		if tag, _, signedData, ok = ReadElement(signedData); !ok || tag != expected {
+			return nil, false
+		}
+	}
+
+	tag, encapContentInfo, _, ok := ReadElement(signedData)
+	if !ok || tag != tagSequence {
+		return nil, false
+	}
+
+	tag, oid, _, ok := ReadElement(encapContentInfo)
+	if !ok || tag != tagObjectIdentifier {
+		return nil, false
+	}
+
+	return oid, true
+}
+
+// IsPFX checks if the data starts like a PKCS#12 PFX: version 3 followed by a ContentInfo of data
+func IsPFX(data []byte) bool {
+	tag, pfx, _, ok := ReadElement(data)
+	if !ok || tag != tagSequence {
+		return false
+	}
+
+	tag, version, authSafe, ok := ReadElement(pfx)
+	if !ok || tag != tagInteger || len(version) != 1 || version[0] != pfxVersion {
+		return false
+	}
+
+	oid, ok := ContentType(authSafe)
+
+	return ok && bytes.Equal(oid, oidData)
+}
+
+// minContentsLength is the length of the shortest contents of a ContentInfo or PFX, a one octet
+// OBJECT IDENTIFIER or version INTEGER
+const minContentsLength = 3
+
+// IsDefinitelyNotCMS checks the leading bytes of data for patterns impossible in a ContentInfo or
+// PKCS#12 PFX: an identifier other than SEQUENCE, more than four length octets, contents too short
+// for the first element or a first element other than the content type OBJECT IDENTIFIER or the
+// PFX version INTEGER. This rejects text, including PEM and base64, which must be decoded first.
+// BER indefinite and non-minimal lengths are accepted. A prefix too short to tell returns false,
+// so false only means the data may be CMS/PKCS
+func IsDefinitelyNotCMS(prefix []byte) bool {
+	if len(prefix) == 0 {
+		return false
+	}
+
+	if prefix[0] != tagSequence {
+		return true
+	}
+
+	if len(prefix) < 2 {
+		return false
+	}
+
+	rest := prefix[2:]
+
+	switch length := prefix[1]; {
+	case length == 0x80:
+	case length > 0x80:
+		octets := int(length & 0x7f)
+		if octets > maxLengthOctets {
+			return true
+		}
+
+		if len(rest) < octets {
+			return false
+		}
+
+		var value uint64
+		for _, b := range rest[:octets] {
+			value = value<<8 | uint64(b)
+		}
+
+		if value < minContentsLength {
+			return true
+		}
+
+		rest = rest[octets:]
+	case length < minContentsLength:
+		return true
+	}
+
+	return len(rest) > 0 && rest[0] != tagObjectIdentifier && rest[0] != tagInteger
+}
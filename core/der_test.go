@@ -0,0 +1,74 @@
+package core
+
+import "testing"
+
+// TestReadElement tests splitting of DER elements
+func TestReadElement(t *testing.T) {
+	long := append([]byte{0x04, 0x81, 0x80}, make([]byte, 0x80)...)
+
+	tests := []struct {
+		name             string
+		data             []byte
+		expectedOK       bool
+		expectedTag      byte
+		expectedContents int
+		expectedRest     int
+	}{
+		{
+			name:             "Short form",
+			data:             []byte{0x04, 0x02, 0x01, 0x02, 0xFF},
+			expectedOK:       true,
+			expectedTag:      0x04,
+			expectedContents: 2,
+			expectedRest:     1,
+		},
+		{
+			name:             "Long form",
+			data:             long,
+			expectedOK:       true,
+			expectedTag:      0x04,
+			expectedContents: 0x80,
+		},
+		{
+			name: "Non-minimal long form",
+			data: []byte{0x04, 0x81, 0x01, 0x00},
+		},
+		{
+			name: "Indefinite length",
+			data: []byte{0x30, 0x80, 0x00, 0x00},
+		},
+		{
+			name:             "High tag number",
+			data:             []byte{0x1F, 0x81, 0x00, 0x01, 0xAA},
+			expectedOK:       true,
+			expectedTag:      0x1F,
+			expectedContents: 1,
+		},
+		{
+			name: "Non-minimal high tag number",
+			data: []byte{0x1F, 0x04, 0x00},
+		},
+		{
+			name: "Truncated contents",
+			data: []byte{0x04, 0x03, 0x01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				tag, contents, rest, ok := ReadElement(tt.data)
+				if ok != tt.expectedOK {
+					t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				if tag != tt.expectedTag || len(contents) != tt.expectedContents || len(rest) != tt.expectedRest {
+					t.Errorf(
+						"Expected tag %#x with %d content and %d remaining bytes, got %#x with %d and %d",
+						tt.expectedTag, tt.expectedContents, tt.expectedRest, tag, len(contents), len(rest),
+					)
+				}
+			},
+		)
+	}
+}
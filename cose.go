@@ -0,0 +1,310 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CBOR major types (RFC 8949, section 3.1)
+const (
+	cborUnsigned   = 0
+	cborNegative   = 1
+	cborByteString = 2
+	cborTextString = 3
+	cborArray      = 4
+	cborMap        = 5
+	cborTag        = 6
+
+	// cborNull is the encoding of the null simple value
+	cborNull = 0xf6
+)
+
+// coseHeaderAlgorithm is the label of the "alg" header parameter (RFC 9052, section 3.1)
+const coseHeaderAlgorithm = 1
+
+// coseTags maps CBOR tags of COSE messages to their kinds (RFC 9052, section 2)
+var coseTags = map[uint64]Kind{
+	16: KindCOSEEncrypt0,
+	17: KindCOSEMac0,
+	18: KindCOSESign1,
+	96: KindCOSEEncrypt,
+	97: KindCOSEMac,
+	98: KindCOSESign,
+}
+
+// coseArrayLengths contains the number of elements of each COSE message array
+var coseArrayLengths = map[Kind]uint64{
+	KindCOSEEncrypt0: 3,
+	KindCOSEMac0:     4,
+	KindCOSESign1:    4,
+	KindCOSEEncrypt:  4,
+	KindCOSEMac:      5,
+	KindCOSESign:     4,
+}
+
+// coseAlgorithmNames maps COSE algorithm identifiers to their names (IANA COSE Algorithms registry)
+var coseAlgorithmNames = map[int64]string{
+	-259: "RS512",
+	-258: "RS384",
+	-257: "RS256",
+	-39:  "PS512",
+	-38:  "PS384",
+	-37:  "PS256",
+	-36:  "ES512",
+	-35:  "ES384",
+	-25:  "ECDH-ES + HKDF-256",
+	-8:   "EdDSA",
+	-7:   "ES256",
+	-5:   "A256KW",
+	-4:   "A192KW",
+	-3:   "A128KW",
+	1:    "A128GCM",
+	2:    "A192GCM",
+	3:    "A256GCM",
+	5:    "HMAC 256/256",
+	6:    "HMAC 384/384",
+	7:    "HMAC 512/512",
+}
+
+// ErrNotCOSE is returned when the data is not a COSE message
+var ErrNotCOSE = errors.New("not a COSE message")
+
+// errUnsupportedCBOR is returned for malformed or indefinite-length CBOR items
+var errUnsupportedCBOR = errors.New("malformed or unsupported CBOR")
+
+// COSEResult describes a detected COSE message
+type COSEResult struct {
+	Kind      Kind   // KindCOSESign1, KindCOSESign, KindCOSEEncrypt, KindCOSEEncrypt0, KindCOSEMac or KindCOSEMac0
+	Tagged    bool   // Indicates if the message carries its CBOR tag; untagged messages are detected as COSE_Sign1
+	Algorithm string // "alg" header parameter of the message or its first signature
+}
+
+// DetectCOSE detects CBOR encoded COSE messages, including untagged COSE_Sign1 used by mdoc
+func DetectCOSE(data []byte) (COSEResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return COSEResult{}, err
+	}
+
+	r := &cborReader{data: data}
+	result := COSEResult{Kind: KindCOSESign1}
+
+	major, arg := r.readHead()
+	if major == cborTag {
+		kind, ok := coseTags[arg]
+		if !ok {
+			return COSEResult{}, ErrNotCOSE
+		}
+
+		result.Kind, result.Tagged = kind, true
+		major, arg = r.readHead()
+	}
+
+	if r.err != nil || major != cborArray || arg != coseArrayLengths[result.Kind] {
+		return COSEResult{}, ErrNotCOSE
+	}
+
+	algorithm := r.readHeaders()
+
+	// Payload or ciphertext, nil when detached
+	if major, _ := r.peekHead(); major != cborByteString && r.peekByte() != cborNull {
+		return COSEResult{}, ErrNotCOSE
+	}
+
+	r.skipItem(0)
+
+	if result.Kind == KindCOSESign {
+		// Signatures are carried by the first COSE_Signature
+		if major, count := r.readHead(); major != cborArray || count == 0 {
+			return COSEResult{}, ErrNotCOSE
+		}
+
+		if major, count := r.readHead(); major != cborArray || count != 3 {
+			return COSEResult{}, ErrNotCOSE
+		}
+
+		algorithm = r.readHeaders()
+	}
+
+	if r.err != nil {
+		return COSEResult{}, ErrNotCOSE
+	}
+
+	result.Algorithm = algorithm
+
+	return result, nil
+}
+
+// coseAlgorithmName returns the name of a COSE algorithm identifier
+func coseAlgorithmName(id int64) string {
+	if name, ok := coseAlgorithmNames[id]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("%d", id)
+}
+
+// cborReader reads the subset of CBOR used by COSE messages
+type cborReader struct {
+	data []byte
+	err  error
+}
+
+// peekByte returns the next byte without consuming it
+func (r *cborReader) peekByte() byte {
+	if r.err != nil || len(r.data) == 0 {
+		return 0
+	}
+
+	return r.data[0]
+}
+
+// peekHead returns the next item head without consuming it
+func (r *cborReader) peekHead() (byte, uint64) {
+	saved := *r
+	major, arg := r.readHead()
+	*r = saved
+
+	return major, arg
+}
+
+// readHead reads the major type and argument of the next item
+func (r *cborReader) readHead() (byte, uint64) {
+	if r.err != nil || len(r.data) == 0 {
+		r.err = errUnsupportedCBOR
+		return 0, 0
+	}
+
+	major, info := r.data[0]>>5, r.data[0]&0x1f
+	r.data = r.data[1:]
+
+	if info < 24 {
+		return major, uint64(info)
+	}
+
+	// Additional information 24-27 is followed by a 1, 2, 4 or 8 byte argument
+	if info > 27 {
+		r.err = errUnsupportedCBOR
+		return 0, 0
+	}
+
+	size := 1 << (info - 24)
+	if len(r.data) < size {
+		r.err = errUnsupportedCBOR
+		return 0, 0
+	}
+
+	var arg uint64
+	for _, b := range r.data[:size] {
+		arg = arg<<8 | uint64(b)
+	}
+
+	r.data = r.data[size:]
+
+	return major, arg
+}
+
+// readBytes reads a byte or text string
+func (r *cborReader) readBytes(major byte, length uint64) []byte {
+	if r.err != nil || (major != cborByteString && major != cborTextString) || length > uint64(len(r.data)) {
+		r.err = errUnsupportedCBOR
+		return nil
+	}
+
+	b := r.data[:length]
+	r.data = r.data[length:]
+
+	return b
+}
+
+// skipItem skips the next item including nested items
+func (r *cborReader) skipItem(depth int) {
+	if exceedsNestingDepth(depth) {
+		r.err = errUnsupportedCBOR
+		return
+	}
+
+	major, arg := r.readHead()
+
+	switch major {
+	case cborByteString, cborTextString:
+		r.readBytes(major, arg)
+	case cborArray, cborMap:
+		if major == cborMap {
+			arg *= 2
+		}
+
+		// Every item takes at least one byte
+		if arg > uint64(len(r.data)) {
+			r.err = errUnsupportedCBOR
+			return
+		}
+
+		for i := uint64(0); i < arg && r.err == nil; i++ {
+			r.skipItem(depth + 1)
+		}
+	case cborTag:
+		r.skipItem(depth + 1)
+	}
+}
+
+// readHeaders reads the protected and unprotected header buckets and returns the "alg" parameter
+func (r *cborReader) readHeaders() string {
+	major, length := r.readHead()
+	if major != cborByteString {
+		r.err = errUnsupportedCBOR
+		return ""
+	}
+
+	protected := &cborReader{data: r.readBytes(major, length)}
+
+	algorithm := ""
+	if len(protected.data) > 0 {
+		algorithm = protected.readHeaderAlgorithm()
+		if protected.err != nil {
+			r.err = protected.err
+			return ""
+		}
+	}
+
+	if unprotected := r.readHeaderAlgorithm(); algorithm == "" {
+		algorithm = unprotected
+	}
+
+	return algorithm
+}
+
+// readHeaderAlgorithm reads a header map and returns its "alg" parameter
+func (r *cborReader) readHeaderAlgorithm() string {
+	major, count := r.readHead()
+	if r.err != nil || major != cborMap || count > uint64(len(r.data)) {
+		r.err = errUnsupportedCBOR
+		return ""
+	}
+
+	algorithm := ""
+
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		keyMajor, key := r.peekHead()
+		if keyMajor != cborUnsigned || key != coseHeaderAlgorithm {
+			r.skipItem(0)
+			r.skipItem(0)
+
+			continue
+		}
+
+		r.readHead()
+
+		switch valueMajor, value := r.readHead(); valueMajor {
+		case cborUnsigned:
+			algorithm = coseAlgorithmName(int64(value))
+		case cborNegative:
+			algorithm = coseAlgorithmName(-1 - int64(value))
+		case cborTextString:
+			algorithm = string(r.readBytes(valueMajor, value))
+		default:
+			r.err = errUnsupportedCBOR
+		}
+	}
+
+	return algorithm
+}
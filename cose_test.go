@@ -0,0 +1,90 @@
+package cmsdetector
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestDetectCOSE tests detection of COSE messages
+func TestDetectCOSE(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expected    COSEResult
+		expectedErr error
+	}{
+		{
+			name:     "Tagged COSE_Sign1",
+			data:     "d28443a10126a0447465737442abcd",
+			expected: COSEResult{Kind: KindCOSESign1, Tagged: true, Algorithm: "ES256"},
+		},
+		{
+			name:     "Untagged COSE_Sign1",
+			data:     "8443a10126a0447465737442abcd",
+			expected: COSEResult{Kind: KindCOSESign1, Algorithm: "ES256"},
+		},
+		{
+			name:     "COSE_Sign1 with detached payload",
+			data:     "d28443a10126a0f642abcd",
+			expected: COSEResult{Kind: KindCOSESign1, Tagged: true, Algorithm: "ES256"},
+		},
+		{
+			name:     "COSE_Sign",
+			data:     "d8628440a04474657374818343a10127a042abcd",
+			expected: COSEResult{Kind: KindCOSESign, Tagged: true, Algorithm: "EdDSA"},
+		},
+		{
+			name:     "COSE_Encrypt0",
+			data:     "d08343a10101a1054201024474657374",
+			expected: COSEResult{Kind: KindCOSEEncrypt0, Tagged: true, Algorithm: "A128GCM"},
+		},
+		{
+			name:     "COSE_Mac0 with unprotected text algorithm",
+			data:     "d18440a10163666f6f447465737442abcd",
+			expected: COSEResult{Kind: KindCOSEMac0, Tagged: true, Algorithm: "foo"},
+		},
+		{
+			name:        "Unexpected array length",
+			data:        "d28343a10126a044746573",
+			expectedErr: ErrNotCOSE,
+		},
+		{
+			name:        "Unknown tag",
+			data:        "c18443a10126a0447465737442abcd",
+			expectedErr: ErrNotCOSE,
+		},
+		{
+			name:        "Truncated",
+			data:        "d28443a10126a04474",
+			expectedErr: ErrNotCOSE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data, err := hex.DecodeString(tt.data)
+				if err != nil {
+					t.Fatalf("Failed to decode test data: %v", err)
+				}
+
+				result, err := DetectCOSE(data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectCOSERejectsCMS tests that CMS structures are not detected as COSE
+func TestDetectCOSERejectsCMS(t *testing.T) {
+	if _, err := DetectCOSE(createTestData(t, PKCS7SignedDataOID)); !errors.Is(err, ErrNotCOSE) {
+		t.Errorf("Expected error %v, got %v", ErrNotCOSE, err)
+	}
+}
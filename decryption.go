@@ -0,0 +1,281 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrNotEncrypted is returned when the data has no content that can be decrypted
+var ErrNotEncrypted = errors.New("not an encrypted structure")
+
+// CredentialType tells which kind of credential opens encrypted content
+type CredentialType int
+
+const (
+	CredentialUnknown    CredentialType = iota // Recipient of an unsupported type, e.g. OtherRecipientInfo
+	CredentialPrivateKey                       // Private key of a recipient certificate (key transport or key agreement)
+	CredentialKEK                              // Previously distributed key-encryption key
+	CredentialPassword                         // Password or passphrase
+	CredentialContentKey                       // Content-encryption key agreed out of band, e.g. for EncryptedData
+)
+
+// credentialTypeNames maps credential types to their names
+var credentialTypeNames = map[CredentialType]string{
+	CredentialUnknown:    "unknown",
+	CredentialPrivateKey: "private key",
+	CredentialKEK:        "key-encryption key",
+	CredentialPassword:   "password",
+	CredentialContentKey: "content-encryption key",
+}
+
+// String returns a human-readable name of the credential type
+func (t CredentialType) String() string {
+	if name, ok := credentialTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CredentialType(%d)", int(t))
+}
+
+// Credential describes a credential that opens encrypted content. Only the fields identifying
+// the credential of its type are set
+type Credential struct {
+	Type         CredentialType
+	Algorithm    Algorithm      // Key encryption algorithm, or the content encryption algorithm of EncryptedData
+	Issuer       string         // Issuer of the recipient certificate, when identified by issuer and serial number
+	RawIssuer    []byte         // DER encoded issuer, comparable with x509.Certificate.RawIssuer
+	SerialNumber *big.Int       // Serial number of the recipient certificate
+	SubjectKeyID []byte         // Subject key identifier of the recipient certificate
+	KEKID        []byte         // Identifier of the key-encryption key
+	PBE          *PBEParameters // Key derivation of passwords, if known
+}
+
+// MatchesCertificate checks if the credential is the private key of the certificate
+func (c Credential) MatchesCertificate(cert *x509.Certificate) bool {
+	if c.Type != CredentialPrivateKey || cert == nil {
+		return false
+	}
+
+	if c.SerialNumber != nil {
+		return bytes.Equal(c.RawIssuer, cert.RawIssuer) && c.SerialNumber.Cmp(cert.SerialNumber) == 0
+	}
+
+	return len(c.SubjectKeyID) > 0 && bytes.Equal(c.SubjectKeyID, cert.SubjectKeyId)
+}
+
+// recipientEncryptedKey provides the ASN.1 structure of a RecipientEncryptedKey of KeyAgreeRecipientInfo
+type recipientEncryptedKey struct {
+	RID          asn1.RawValue
+	EncryptedKey []byte
+}
+
+// keyIdentifier provides the leading field of KEKIdentifier and RecipientKeyIdentifier, the
+// optional date and other attributes are ignored
+type keyIdentifier struct {
+	ID []byte
+}
+
+// DecryptionRequirements reports the credentials that open the encrypted content, so that the
+// right one can be located before attempting decryption. Any of the returned credentials is
+// sufficient: one per recipient of EnvelopedData, AuthEnvelopedData and SignedAndEnvelopedData,
+// a password or content-encryption key for EncryptedData, and a password for PKCS#12 containers
+// and encrypted PKCS#8 keys
+func DecryptionRequirements(data []byte) (credentials []Credential, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	if contents, err := parsePFX(data); err == nil {
+		if !contents.needsPassword() {
+			return nil, ErrNotEncrypted
+		}
+
+		return []Credential{{Type: CredentialPassword}}, nil
+	}
+
+	var key encryptedPrivateKeyInfo
+	if kind, ok := detectPKCS8(data); ok && kind == KindEncryptedPrivateKey {
+		if _, err := asn1.Unmarshal(data, &key); err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+		}
+
+		return []Credential{passwordCredential(key.Algorithm)}, nil
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	var recipientInfos []asn1.RawValue
+
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		ed, err := parseEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse enveloped data: %w", err)
+		}
+
+		recipientInfos = ed.RecipientInfos
+	case contentInfo.ContentType.Equal(AuthEnvelopedDataOID):
+		aed, err := parseAuthEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse authenticated enveloped data: %w", err)
+		}
+
+		recipientInfos = aed.RecipientInfos
+	case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
+		sed, err := parseSignedAndEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed and enveloped data: %w", err)
+		}
+
+		recipientInfos = sed.RecipientInfos
+	case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
+		ed, err := parseEncryptedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse encrypted data: %w", err)
+		}
+
+		alg := ed.EncryptedContentInfo.ContentEncryptionAlgorithm
+		if isPasswordBasedEncryption(alg.Algorithm) {
+			return []Credential{passwordCredential(alg)}, nil
+		}
+
+		return []Credential{{Type: CredentialContentKey, Algorithm: newAlgorithm(alg.Algorithm)}}, nil
+	default:
+		return nil, ErrNotEncrypted
+	}
+
+	for _, ri := range recipientInfos {
+		credentials = append(credentials, recipientCredentials(ri)...)
+	}
+
+	return credentials, nil
+}
+
+// passwordCredential describes the password of a password-based encryption algorithm
+func passwordCredential(alg pkix.AlgorithmIdentifier) Credential {
+	return Credential{Type: CredentialPassword, Algorithm: newAlgorithm(alg.Algorithm), PBE: parsePBEParameters(alg)}
+}
+
+// recipientCredentials returns the credentials of a RecipientInfo, one per recipient key of
+// KeyAgreeRecipientInfo. Malformed and unsupported alternatives are reported as CredentialUnknown
+func recipientCredentials(ri asn1.RawValue) []Credential {
+	unknown := []Credential{{Type: CredentialUnknown}}
+
+	// KeyTransRecipientInfo is the only untagged alternative of the CHOICE
+	if ri.Class == asn1.ClassUniversal && ri.Tag == asn1.TagSequence {
+		var ktri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(ri.FullBytes, &ktri); err != nil {
+			return unknown
+		}
+
+		return []Credential{recipientKeyCredential(ktri.RID, ktri.KeyEncryptionAlgorithm)}
+	}
+
+	if ri.Class != asn1.ClassContextSpecific {
+		return unknown
+	}
+
+	encoded, err := retagSequence(ri)
+	if err != nil {
+		return unknown
+	}
+
+	switch ri.Tag {
+	case recipientInfoKeyAgree:
+		var kari keyAgreeRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &kari); err != nil {
+			return unknown
+		}
+
+		var keys []recipientEncryptedKey
+		if _, err := asn1.Unmarshal(kari.RecipientEncryptedKeys.FullBytes, &keys); err != nil {
+			return unknown
+		}
+
+		credentials := make([]Credential, 0, len(keys))
+		for _, key := range keys {
+			credentials = append(credentials, recipientKeyCredential(key.RID, kari.KeyEncryptionAlgorithm))
+		}
+
+		return credentials
+	case recipientInfoKEK:
+		var kekri kekRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &kekri); err != nil {
+			return unknown
+		}
+
+		var id keyIdentifier
+		if _, err := asn1.Unmarshal(kekri.KEKID.FullBytes, &id); err != nil {
+			return unknown
+		}
+
+		return []Credential{{Type: CredentialKEK, Algorithm: newAlgorithm(kekri.KeyEncryptionAlgorithm.Algorithm), KEKID: id.ID}}
+	case recipientInfoPassword:
+		var pwri passwordRecipientInfo
+		if _, err := asn1.Unmarshal(encoded, &pwri); err != nil {
+			return unknown
+		}
+
+		credential := Credential{Type: CredentialPassword, Algorithm: newAlgorithm(pwri.KeyEncryptionAlgorithm.Algorithm)}
+
+		if kdf := pwri.KeyDerivationAlgorithm; len(kdf.Algorithm) > 0 {
+			credential.PBE = &PBEParameters{KeyDerivation: newAlgorithm(kdf.Algorithm), Cipher: credential.Algorithm}
+
+			if kdf.Algorithm.Equal(pbkdf2OID) {
+				addPBKDF2Parameters(credential.PBE, kdf)
+			}
+		}
+
+		return []Credential{credential}
+	default:
+		return unknown
+	}
+}
+
+// recipientKeyCredential describes the private key of a recipient identified by issuer and
+// serial number, or by an implicitly tagged subject key identifier or RecipientKeyIdentifier
+func recipientKeyCredential(rid asn1.RawValue, alg pkix.AlgorithmIdentifier) Credential {
+	credential := Credential{Type: CredentialPrivateKey, Algorithm: newAlgorithm(alg.Algorithm)}
+
+	switch {
+	case rid.Class == asn1.ClassUniversal && rid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(rid.FullBytes, &ias); err != nil {
+			return credential
+		}
+
+		var issuer pkix.RDNSequence
+		if _, err := asn1.Unmarshal(ias.Issuer.FullBytes, &issuer); err == nil {
+			credential.Issuer = issuer.String()
+		}
+
+		credential.RawIssuer = ias.Issuer.FullBytes
+		credential.SerialNumber = ias.SerialNumber
+	case rid.Class == asn1.ClassContextSpecific && rid.Tag == 0 && !rid.IsCompound:
+		// subjectKeyIdentifier of KeyTransRecipientInfo
+		credential.SubjectKeyID = rid.Bytes
+	case rid.Class == asn1.ClassContextSpecific && rid.Tag == 0:
+		// rKeyId of KeyAgreeRecipientInfo, a RecipientKeyIdentifier starting with the subject key identifier
+		encoded, err := retagSequence(rid)
+		if err != nil {
+			return credential
+		}
+
+		var id keyIdentifier
+		if _, err := asn1.Unmarshal(encoded, &id); err == nil {
+			credential.SubjectKeyID = id.ID
+		}
+	}
+
+	return credential
+}
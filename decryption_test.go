@@ -0,0 +1,257 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createRecipientsEnvelopedData creates a ContentInfo with EnvelopedData for the recipient infos
+func createRecipientsEnvelopedData(t *testing.T, recipientInfos ...asn1.RawValue) []byte {
+	t.Helper()
+
+	return createContentInfo(
+		t, PKCS7EnvelopedDataOID, envelopedData{
+			Version:        3,
+			RecipientInfos: recipientInfos,
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                PKCS7DataOID,
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: cmstest.AES256CBCOID},
+			},
+		},
+	)
+}
+
+// TestDecryptionRequirements tests the credentials reported for the recipients and encryption schemes
+func TestDecryptionRequirements(t *testing.T) {
+	aesWrapOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 45}
+	ecdhOID := asn1.ObjectIdentifier{1, 3, 132, 1, 11, 1}
+
+	issuer, err := asn1.Marshal(pkix.Name{CommonName: "Recipient CA"}.ToRDNSequence())
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer: %v", err)
+	}
+
+	byIssuer, err := asn1.Marshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: issuer}, SerialNumber: big.NewInt(42)})
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer and serial number: %v", err)
+	}
+
+	keys, err := asn1.Marshal(
+		[]recipientEncryptedKey{
+			{RID: asn1.RawValue{FullBytes: byIssuer}, EncryptedKey: []byte{0x01}},
+			{RID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x04, 0x02, 0xAB, 0xCD}}, EncryptedKey: []byte{0x02}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient encrypted keys: %v", err)
+	}
+
+	kari := keyAgreeRecipientInfo{
+		Version:                3,
+		Originator:             asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x80, 0x01, 0x01}},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: ecdhOID},
+		RecipientEncryptedKeys: asn1.RawValue{FullBytes: keys},
+	}
+
+	kekri := kekRecipientInfo{
+		Version:                4,
+		KEKID:                  asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{0x04, 0x02, 0x12, 0x34}},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		EncryptedKey:           []byte{0x01},
+	}
+
+	pbkdf2Parameters, err := asn1.Marshal(pbkdf2Params{Salt: asn1.RawValue{Tag: asn1.TagOctetString, Bytes: []byte{0x05}}, IterationCount: 10000})
+	if err != nil {
+		t.Fatalf("Failed to marshal PBKDF2 parameters: %v", err)
+	}
+
+	pwri := passwordRecipientInfo{
+		Version:                0,
+		KeyDerivationAlgorithm: pkix.AlgorithmIdentifier{Algorithm: pbkdf2OID, Parameters: asn1.RawValue{FullBytes: pbkdf2Parameters}},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: aesWrapOID},
+		EncryptedKey:           []byte{0x01},
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []Credential
+	}{
+		{
+			name:     "Key transport by subject key identifier",
+			data:     cmstest.EnvelopedData(t),
+			expected: []Credential{{Type: CredentialPrivateKey, Algorithm: Algorithm{Name: "RSA"}, SubjectKeyID: []byte{0xDE, 0xAD, 0xBE, 0xEF}}},
+		},
+		{
+			name: "Key agreement",
+			data: createRecipientsEnvelopedData(t, createRecipientInfo(t, recipientInfoKeyAgree, kari)),
+			expected: []Credential{
+				{Type: CredentialPrivateKey, Algorithm: Algorithm{Name: "ECDH with SHA-256 KDF"}, Issuer: "CN=Recipient CA", SerialNumber: big.NewInt(42)},
+				{Type: CredentialPrivateKey, Algorithm: Algorithm{Name: "ECDH with SHA-256 KDF"}, SubjectKeyID: []byte{0xAB, 0xCD}},
+			},
+		},
+		{
+			name: "KEK, password and other recipients",
+			data: createRecipientsEnvelopedData(
+				t,
+				createRecipientInfo(t, recipientInfoKEK, kekri),
+				createRecipientInfo(t, recipientInfoPassword, pwri),
+				asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 4, IsCompound: true},
+			),
+			expected: []Credential{
+				{Type: CredentialKEK, Algorithm: Algorithm{Name: "AES-256 Key Wrap"}, KEKID: []byte{0x12, 0x34}},
+				{
+					Type:      CredentialPassword,
+					Algorithm: Algorithm{Name: "AES-256 Key Wrap"},
+					PBE:       &PBEParameters{KeyDerivation: Algorithm{Name: "PBKDF2"}, Cipher: Algorithm{Name: "AES-256 Key Wrap"}, PRF: Algorithm{Name: "HMAC with SHA-1"}, Salt: []byte{0x05}, Iterations: 10000},
+				},
+				{Type: CredentialUnknown},
+			},
+		},
+		{
+			name:     "EncryptedData with a content-encryption key",
+			data:     cmstest.EncryptedData(t),
+			expected: []Credential{{Type: CredentialContentKey, Algorithm: Algorithm{Name: "AES-256-CBC"}}},
+		},
+		{
+			name: "EncryptedData with PBES2",
+			data: cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.PBES2OID), cmstest.WithIterations(1000)),
+			expected: []Credential{
+				{
+					Type:      CredentialPassword,
+					Algorithm: Algorithm{Name: "PBES2"},
+					PBE: &PBEParameters{
+						Scheme: Algorithm{Name: "PBES2"}, KeyDerivation: Algorithm{Name: "PBKDF2"}, Cipher: Algorithm{Name: "AES-256-CBC"},
+						PRF: Algorithm{Name: "HMAC with SHA-256"}, Salt: make([]byte, 8), Iterations: 1000,
+					},
+				},
+			},
+		},
+		{
+			name:     "PKCS#12",
+			data:     cmstest.PFX(t),
+			expected: []Credential{{Type: CredentialPassword}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				credentials, err := DecryptionRequirements(tt.data)
+				if err != nil {
+					t.Fatalf("DecryptionRequirements returned an error: %v", err)
+				}
+
+				if len(credentials) != len(tt.expected) {
+					t.Fatalf("Expected %d credentials, got %d: %+v", len(tt.expected), len(credentials), credentials)
+				}
+
+				for i, expected := range tt.expected {
+					assertCredential(t, expected, credentials[i])
+				}
+			},
+		)
+	}
+}
+
+// assertCredential compares the credential, ignoring algorithm OIDs and the raw issuer
+func assertCredential(t *testing.T, expected, actual Credential) {
+	t.Helper()
+
+	if actual.Type != expected.Type {
+		t.Errorf("Expected type %v, got %v", expected.Type, actual.Type)
+	}
+
+	if actual.Algorithm.Name != expected.Algorithm.Name {
+		t.Errorf("Expected algorithm %q, got %q", expected.Algorithm.Name, actual.Algorithm.Name)
+	}
+
+	if actual.Issuer != expected.Issuer {
+		t.Errorf("Expected issuer %q, got %q", expected.Issuer, actual.Issuer)
+	}
+
+	if (expected.SerialNumber == nil) != (actual.SerialNumber == nil) || expected.SerialNumber != nil && expected.SerialNumber.Cmp(actual.SerialNumber) != 0 {
+		t.Errorf("Expected serial number %v, got %v", expected.SerialNumber, actual.SerialNumber)
+	}
+
+	if !bytes.Equal(actual.SubjectKeyID, expected.SubjectKeyID) {
+		t.Errorf("Expected subject key identifier %x, got %x", expected.SubjectKeyID, actual.SubjectKeyID)
+	}
+
+	if !bytes.Equal(actual.KEKID, expected.KEKID) {
+		t.Errorf("Expected KEK identifier %x, got %x", expected.KEKID, actual.KEKID)
+	}
+
+	if (expected.PBE == nil) != (actual.PBE == nil) {
+		t.Fatalf("Expected PBE parameters %+v, got %+v", expected.PBE, actual.PBE)
+	}
+
+	if expected.PBE != nil {
+		assertPBEParameters(t, expected.PBE, actual.PBE)
+	}
+}
+
+// TestDecryptionRequirementsErrors tests that data without encrypted content is rejected
+func TestDecryptionRequirementsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		expectedErr error
+	}{
+		{name: "SignedData", data: cmstest.SignedData(t), expectedErr: ErrNotEncrypted},
+		{name: "DigestedData", data: cmstest.DigestedData(t), expectedErr: ErrNotEncrypted},
+		{name: "Malformed", data: []byte{0x30, 0x03, 0x02, 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := DecryptionRequirements(tt.data)
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+
+				if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected %v, got %v", tt.expectedErr, err)
+				}
+			},
+		)
+	}
+}
+
+// TestCredentialMatchesCertificate tests matching recipient credentials against certificates
+func TestCredentialMatchesCertificate(t *testing.T) {
+	cert, err := x509.ParseCertificate(cmstest.Certificate(t, "Recipient"))
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	other, err := x509.ParseCertificate(cmstest.Certificate(t, "Other"))
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	credentials, err := DecryptionRequirements(cmstest.EnvelopedData(t, cmstest.WithCertificates(cert.Raw)))
+	if err != nil || len(credentials) != 1 {
+		t.Fatalf("Expected a single credential, got %v, %v", credentials, err)
+	}
+
+	if !credentials[0].MatchesCertificate(cert) {
+		t.Error("Expected the credential to match the recipient certificate")
+	}
+
+	if credentials[0].MatchesCertificate(other) {
+		t.Error("Expected the credential not to match another certificate")
+	}
+
+	if (Credential{Type: CredentialPassword}).MatchesCertificate(cert) {
+		t.Error("Expected a password not to match a certificate")
+	}
+}
@@ -0,0 +1,136 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"math"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// DER identifier octets used by the hand-written parsers
+const (
+	derTagInteger          = 0x02
+	derTagSequence         = 0x30
+	derTagSet              = 0x31
+	derTagObjectIdentifier = 0x06
+	derTagExplicit0        = 0xA0
+
+	// derMaxLengthOctets limits long form lengths to 32 bits
+	derMaxLengthOctets = 4
+
+	// derMaxHeaderSize is the size of the longest identifier and length octets accepted by core.ReadHeader
+	derMaxHeaderSize = 1 + derMaxLengthOctets + 1 + derMaxLengthOctets
+)
+
+// Contents octets of the content type OIDs, compared by the fast paths without decoding the OID
+var (
+	encodedPKCS7DataOID          = encodeOIDContents(PKCS7DataOID)
+	encodedPKCS7SignedDataOID    = encodeOIDContents(PKCS7SignedDataOID)
+	encodedPKCS7EnvelopedDataOID = encodeOIDContents(PKCS7EnvelopedDataOID)
+	encodedPKCS7SignedAndEnvOID  = encodeOIDContents(PKCS7SignedAndEnvelopedOID)
+	encodedPKCS7DigestedDataOID  = encodeOIDContents(PKCS7DigestedDataOID)
+	encodedPKCS7EncryptedDataOID = encodeOIDContents(PKCS7EncryptedDataOID)
+	encodedPKCS12OID             = encodeOIDContents(PKCS12OID)
+)
+
+// encodeOIDContents returns the contents octets of the DER encoding of the OID
+func encodeOIDContents(oid asn1.ObjectIdentifier) []byte {
+	der, err := asn1.Marshal(oid)
+	if err != nil {
+		panic(err)
+	}
+
+	// OIDs of the package are shorter than 128 bytes and use a single length octet
+	return der[2:]
+}
+
+// readContentInfo splits a DER ContentInfo like asn1.Unmarshal, decoding only the content type OID
+// and leaving the content unparsed and uncopied. Encodings asn1.Unmarshal might still accept, such
+// as a content other than [0] or unusual content type OIDs, are not read
+func readContentInfo(data []byte) (ContentInfo, bool) {
+	tag, contentInfo, _, ok := core.ReadElement(data)
+	if !ok || tag != derTagSequence {
+		return ContentInfo{}, false
+	}
+
+	tag, encodedOID, rest, ok := core.ReadElement(contentInfo)
+	if !ok || tag != derTagObjectIdentifier {
+		return ContentInfo{}, false
+	}
+
+	oid, ok := decodeOID(encodedOID)
+	if !ok {
+		return ContentInfo{}, false
+	}
+
+	if len(rest) == 0 {
+		return ContentInfo{ContentType: oid}, true
+	}
+
+	// Elements following the content are ignored like extensions of the SEQUENCE
+	tag, content, following, ok := core.ReadElement(rest)
+	if !ok || tag != derTagExplicit0 || len(content) == 0 {
+		return ContentInfo{}, false
+	}
+
+	return ContentInfo{
+		ContentType: oid,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      content,
+			FullBytes:  rest[:len(rest)-len(following)],
+		},
+	}, true
+}
+
+// decodeOID decodes the contents octets of an OID with the restrictions of asn1.Unmarshal: minimally
+// encoded subidentifiers of at most five octets whose values fit in 31 bits
+func decodeOID(contents []byte) (asn1.ObjectIdentifier, bool) {
+	if !core.ValidOIDContents(contents) {
+		return nil, false
+	}
+
+	oid := make(asn1.ObjectIdentifier, 1, len(contents)+1)
+
+	var value int64
+
+	octets := 0
+	for _, b := range contents {
+		value = value<<7 | int64(b&0x7f)
+		if octets++; octets > 5 || value > math.MaxInt32 {
+			return nil, false
+		}
+
+		if b&0x80 != 0 {
+			continue
+		}
+
+		// The first subidentifier combines the first two arcs
+		if len(oid) == 1 {
+			switch {
+			case value < 40:
+				oid = append(oid[:0], 0, int(value))
+			case value < 80:
+				oid = append(oid[:0], 1, int(value-40))
+			default:
+				oid = append(oid[:0], 2, int(value-80))
+			}
+		} else {
+			oid = append(oid, int(value))
+		}
+
+		value, octets = 0, 0
+	}
+
+	return oid, true
+}
+
+// hasContentType checks if the data is a ContentInfo with the given encoded content type
+func hasContentType(data, encodedOID []byte) bool {
+	oid, ok := core.ContentType(data)
+
+	return ok && bytes.Equal(oid, encodedOID)
+}
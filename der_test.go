@@ -0,0 +1,162 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// TestContentTypeOfMatchesUnmarshal tests that the fast path accepts the same ContentInfo
+// structures as encoding/asn1, including truncated and corrupted ones
+func TestContentTypeOfMatchesUnmarshal(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	samples := [][]byte{
+		createContentInfo(t, PKCS7DataOID, []byte{0x01, 0x02}),
+		createSignedData(t, sha256OID, rsaOID),
+		append(createContentInfo(t, PKCS12OID, []byte{0x01}), 0x00, 0x01),
+	}
+
+	var inputs [][]byte
+
+	for _, sample := range samples {
+		for i := range sample {
+			inputs = append(inputs, sample[:i])
+
+			for _, b := range []byte{0x00, 0x80, 0xFF} {
+				corrupted := append([]byte(nil), sample...)
+				corrupted[i] = b
+				inputs = append(inputs, corrupted)
+			}
+		}
+
+		inputs = append(inputs, sample)
+	}
+
+	for _, input := range inputs {
+		contentInfo, err := parseContentInfo(input)
+		oid, ok := core.ContentType(input)
+
+		if ok != (err == nil) {
+			t.Errorf("Expected ok %v for %x, got %v", err == nil, input, ok)
+			continue
+		}
+
+		if ok && !bytes.Equal(oid, encodeOIDContents(contentInfo.ContentType)) {
+			t.Errorf("Expected content type %s for %x, got %x", contentInfo.ContentType, input, oid)
+		}
+
+		if ok && KindOf(input) != kindOfContentInfo(contentInfo) {
+			t.Errorf("Expected kind %s for %x, got %s", kindOfContentInfo(contentInfo), input, KindOf(input))
+		}
+	}
+}
+
+// TestReadContentInfo tests that the hand-rolled reader agrees with encoding/asn1 on every
+// structure it accepts and accepts the DER structures of the corpus
+func TestReadContentInfo(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		expectedOK bool
+	}{
+		{
+			name:       "Signed data",
+			data:       createSignedData(t, sha256OID, rsaOID),
+			expectedOK: true,
+		},
+		{
+			name:       "Trailing data",
+			data:       append(createContentInfo(t, PKCS12OID, []byte{0x01}), 0x00, 0x01),
+			expectedOK: true,
+		},
+		{
+			name:       "No content",
+			data:       []byte{0x30, 0x0B, 0x06, 0x09, 0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x07, 0x01},
+			expectedOK: true,
+		},
+		{
+			name:       "Large first arc",
+			data:       []byte{0x30, 0x05, 0x06, 0x03, 0x88, 0x37, 0x03},
+			expectedOK: true,
+		},
+		{
+			name: "Subidentifier exceeding 31 bits",
+			data: []byte{0x30, 0x08, 0x06, 0x06, 0x2A, 0x88, 0x80, 0x80, 0x80, 0x00},
+		},
+		{
+			name: "Padded subidentifier",
+			data: []byte{0x30, 0x04, 0x06, 0x02, 0x80, 0x01},
+		},
+		{
+			name: "Empty explicit content",
+			data: []byte{0x30, 0x05, 0x06, 0x01, 0x2A, 0xA0, 0x00},
+		},
+		{
+			name: "Indefinite length",
+			data: []byte{0x30, 0x80, 0x06, 0x01, 0x2A, 0x00, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if _, ok := readContentInfo(tt.data); ok != tt.expectedOK {
+					t.Errorf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				checkReadContentInfo(t, tt.data)
+			},
+		)
+	}
+
+	for name, data := range readCorpus(t) {
+		for i := range data {
+			corrupted := append([]byte(nil), data...)
+			corrupted[i] ^= 0xFF
+			checkReadContentInfo(t, corrupted)
+			checkReadContentInfo(t, data[:i])
+		}
+
+		if _, err := parseDERContentInfo(data); err == nil {
+			if _, ok := readContentInfo(data); !ok {
+				t.Errorf("Expected %s to be read without encoding/asn1", name)
+			}
+		}
+	}
+}
+
+// parseDERContentInfo unmarshals a ContentInfo with encoding/asn1 only
+func parseDERContentInfo(data []byte) (ContentInfo, error) {
+	var contentInfo ContentInfo
+	_, err := asn1.Unmarshal(data, &contentInfo)
+
+	return contentInfo, err
+}
+
+// checkReadContentInfo fails the test if readContentInfo accepts data that encoding/asn1 rejects
+// or reads a different structure
+func checkReadContentInfo(t testing.TB, data []byte) {
+	t.Helper()
+
+	contentInfo, ok := readContentInfo(data)
+	if !ok {
+		return
+	}
+
+	expected, err := parseDERContentInfo(data)
+	if err != nil {
+		t.Fatalf("Expected %x to be rejected like encoding/asn1: %v", data, err)
+	}
+
+	if !reflect.DeepEqual(contentInfo, expected) {
+		t.Fatalf("Expected %+v for %x, got %+v", expected, data, contentInfo)
+	}
+}
@@ -1,11 +1,23 @@
 // Package cmsdetector provides functions for detecting and identifying various
 // CMS (Cryptographic Message Syntax) and PKCS (Public Key Cryptography Standards) formats.
+//
+// All functions are safe for concurrent use and never modify their input, and so are RuleSet,
+// Cache and Detector values. A Detector cannot be changed once created by NewDetector.
+// KeyContainerDetector and Scanner values are safe for concurrent use once configured: their
+// methods do not modify them, and their fields must not be changed while they are in use.
+// SetLimits, SetPBEThresholds, SetMetrics, SetPanicRecovery, RegisterOID and LoadOIDs may be called
+// at any time and apply to the calls starting after them. Returned values are owned by the caller,
+// a Cache returns a copy of a cached result to every call. Cached results do not change after
+// RegisterOID and LoadOIDs, Cache.Purge drops them
 package cmsdetector
 
 import (
 	"bytes"
 	"encoding/asn1"
-	"fmt"
+	"sync/atomic"
+
+	"github.com/lEx0/cmsdetector/core"
+	"github.com/lEx0/cmsdetector/heuristics"
 )
 
 // OIDs for various types of CMS/PKCS messages
@@ -19,7 +31,8 @@ var (
 	PKCS7EncryptedDataOID      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
 
 	// Other common OIDs for CMS/PKCS
-	PKCS12OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1}
+	PKCS12OID            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1}
+	AuthEnvelopedDataOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
 )
 
 // Additional type constants for formats that can't be detected via OID
@@ -35,186 +48,317 @@ type ContentInfo struct {
 
 // DetectionResult contains the result of CMS/PKCS type detection
 type DetectionResult struct {
-	Type        string
-	ContentType asn1.ObjectIdentifier
-	IsEncrypted bool // Indicates if the content is encrypted
+	Type             string
+	Kind             Kind
+	ContentType      asn1.ObjectIdentifier
+	InnerContentType asn1.ObjectIdentifier // Type of the content signed, digested or encrypted by the structure, e.g. TSTInfo
+	InnerType        string                // Description of InnerContentType, e.g. "Time-Stamp Token Info"
+	ContentSize      int64                 // Size of the encapsulated or encrypted content from its DER header, 0 if detached
+	Header           []byte                // Outer identifier and length octets and the content type TLV, at most 64 bytes
+	IsEncrypted      bool                  // Indicates if the content is encrypted or a PKCS#12 container holds encrypted bags
+	NeedsPassword    bool                  // Indicates a passphrase is required to open the container, unlike content encrypted for a certificate
+	Provider         string                // Hint about the crypto provider required to process the content, if any
+	Payload          string                // Type of the signed payload, if recognized (e.g. PayloadAppleConfigurationProfile)
 }
 
 // Detect tries to determine the type of CMS/PKCS data
-func Detect(data []byte) (DetectionResult, error) {
-	// Try standard ASN.1 parsing first
-	var contentInfo ContentInfo
-	_, err := asn1.Unmarshal(data, &contentInfo)
+func Detect(data []byte) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	result, err = detect(data)
+	recordDetection(len(data), result.Kind, result.Kind == KindEncryptedPKCS12, err)
+
+	return result, err
+}
+
+// detect determines the type of CMS/PKCS data without reporting metrics
+func detect(data []byte) (DetectionResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return DetectionResult{}, err
+	}
+
+	// Only the content type is decoded, the content is parsed by the checks that need it
+	contentInfo, err := parseContentInfo(data)
 
 	// If standard parsing succeeds
 	if err == nil {
 		result := DetectionResult{
-			ContentType: contentInfo.ContentType,
-			IsEncrypted: false,
+			ContentType:   contentInfo.ContentType,
+			IsEncrypted:   isEncryptedContent(contentInfo),
+			NeedsPassword: needsPasswordContent(contentInfo),
 		}
 
 		// Determine the type based on the OID
-		switch {
-		case contentInfo.ContentType.Equal(PKCS7DataOID):
-			result.Type = "PKCS#7 Data"
-		case contentInfo.ContentType.Equal(PKCS7SignedDataOID):
-			result.Type = "PKCS#7 Signed Data"
-		case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
-			result.Type = "PKCS#7 Enveloped Data"
-		case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
-			result.Type = "PKCS#7 Signed And Enveloped Data"
-		case contentInfo.ContentType.Equal(PKCS7DigestedDataOID):
-			result.Type = "PKCS#7 Digested Data"
-		case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
-			result.Type = "PKCS#7 Encrypted Data"
-		case contentInfo.ContentType.Equal(PKCS12OID):
-			result.Type = "PKCS#12"
-		default:
-			result.Type = fmt.Sprintf("Unknown OID: %s", contentInfo.ContentType.String())
+		result.Kind = kindOfContentInfo(contentInfo)
+		if result.Kind == KindUnknown {
+			result.Type = GetOIDDescription(contentInfo.ContentType)
+		} else {
+			result.Type = result.Kind.String()
+		}
+
+		// Report the provider for content using algorithms outside of the Go standard library
+		result.Provider = detectProvider(contentInfo)
+		result.Payload = detectPayload(contentInfo)
+
+		if inner, ok := innerContentType(contentInfo); ok {
+			result.InnerContentType = inner
+			result.InnerType = GetOIDDescription(inner)
 		}
 
+		result.ContentSize, _ = contentSize(readBytesAt(data), int64(len(data)), contentInfo.ContentType)
+		result.Header = rawHeader(readBytesAt(data), int64(len(data)))
+
 		return result, nil
 	}
 
 	// If standard parsing fails, try to detect encrypted PKCS#12 key containers
 	if isEncryptedPKCS12(data) {
 		result := DetectionResult{
-			Type:        TypeEncryptedPKCS12,
-			IsEncrypted: true,
+			Type:          TypeEncryptedPKCS12,
+			Kind:          KindEncryptedPKCS12,
+			IsEncrypted:   true,
+			NeedsPassword: true,
+		}
+
+		// Containers matched only by the heuristics are assumed to be encrypted
+		if contents, err := parsePFX(data); err == nil {
+			result.IsEncrypted = contents.hasEncryptedBags()
+			result.NeedsPassword = contents.needsPassword()
 		}
 
+		result.Header = rawHeader(readBytesAt(data), int64(len(data)))
+
 		return result, nil
 	}
 
 	// If all detection methods fail
-	return DetectionResult{}, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	return DetectionResult{}, &ParseError{Err: err, Hints: Hints(data)}
 }
 
-// isEncryptedPKCS12 checks if the data appears to be an encrypted PKCS#12 container
-func isEncryptedPKCS12(data []byte) bool {
-	// Basic checks for PKCS#12 format
-	if len(data) < 20 {
+// innerContentType returns the type of the content encapsulated in SignedData and DigestedData,
+// or encrypted by EnvelopedData and similar structures
+func innerContentType(contentInfo ContentInfo) (asn1.ObjectIdentifier, bool) {
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7SignedDataOID):
+		if sd, err := parseSignedData(contentInfo); err == nil {
+			return sd.EncapContentInfo.EContentType, true
+		}
+	case contentInfo.ContentType.Equal(PKCS7DigestedDataOID):
+		if dd, err := parseDigestedData(contentInfo); err == nil {
+			return dd.EncapContentInfo.EContentType, true
+		}
+	default:
+		return encryptedContentType(contentInfo)
+	}
+
+	return nil, false
+}
+
+// isEncryptedContent checks if the ContentInfo holds a structure with encrypted content, which
+// must parse so that a content type alone is not reported as encrypted
+func isEncryptedContent(contentInfo ContentInfo) bool {
+	var err error
+
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		_, err = parseEnvelopedData(contentInfo)
+	case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
+		_, err = parseSignedAndEnvelopedData(contentInfo)
+	case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
+		_, err = parseEncryptedData(contentInfo)
+	case contentInfo.ContentType.Equal(AuthEnvelopedDataOID):
+		_, err = parseAuthEnvelopedData(contentInfo)
+	default:
 		return false
 	}
 
-	// Check basic PKCS#12 signature - should start with SEQUENCE tag (0x30)
-	if data[0] != 0x30 {
+	return err == nil
+}
+
+// needsPasswordContent checks if a password is required to decrypt the content of the
+// ContentInfo: password recipients of EnvelopedData and AuthEnvelopedData, or password-based
+// encryption of EncryptedData
+func needsPasswordContent(contentInfo ContentInfo) bool {
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		ed, err := parseEnvelopedData(contentInfo)
+
+		return err == nil && hasPasswordRecipient(ed.RecipientInfos)
+	case contentInfo.ContentType.Equal(AuthEnvelopedDataOID):
+		aed, err := parseAuthEnvelopedData(contentInfo)
+
+		return err == nil && hasPasswordRecipient(aed.RecipientInfos)
+	case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
+		ed, err := parseEncryptedData(contentInfo)
+
+		return err == nil && isPasswordBasedEncryption(ed.EncryptedContentInfo.ContentEncryptionAlgorithm.Algorithm)
+	default:
 		return false
 	}
+}
 
-	// Look for version 3 indicator which is common in PKCS#12
-	versionBytes := []byte{0x02, 0x01, 0x03} // INTEGER 3
+// isEncryptedPKCS12 checks if the data appears to be an encrypted PKCS#12 container
+func isEncryptedPKCS12(data []byte) bool {
+	return encryptedPKCS12Heuristics.forWindow(scanWindowSize()).Match(data)
+}
 
-	// Try to find the version pattern
-	versionFound := false
+// encryptedPKCS12Heuristics holds the encrypted PKCS#12 heuristic of the scan window in effect
+var encryptedPKCS12Heuristics = &windowHeuristic{build: encryptedPKCS12Heuristic}
+
+// encryptedPKCS12Heuristic recognizes PKCS#12 containers by the PFX header, a SEQUENCE with
+// version 3 followed by the authSafe SEQUENCE, and key bag OIDs, key markers or a typical key
+// container size. The size is checked before the markers, which take a pass over the window each
+func encryptedPKCS12Heuristic(window int) heuristics.Heuristic {
+	return heuristics.Chain{
+		heuristics.SizeRange{Min: 20},
+		pfxHeaderHeuristic{},
+		heuristics.Any{
+			heuristics.SizeRange{Min: 101, Max: 99999},
+			// 1.2.840.113549.1.12.10.1 (PKCS#12 bag types)
+			heuristics.Pattern{Bytes: []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x0C, 0x0A, 0x01}, Window: window},
+			heuristics.Pattern{Bytes: []byte("KEY"), Window: window},
+			heuristics.Pattern{Bytes: []byte("PrivateKey"), Window: window},
+		},
+	}
+}
 
-	for i := 0; i < len(data)-len(versionBytes); i++ {
-		if bytes.Equal(data[i:i+len(versionBytes)], versionBytes) {
-			versionFound = true
-			break
-		}
+// pfxHeaderHeuristic matches data starting with the header of a PFX: a SEQUENCE whose first
+// element is the INTEGER 3, followed by the identifier and length of the authSafe SEQUENCE. Only
+// these elements are read, so the header matches in truncated data and with the indefinite
+// lengths of BER, but not in the bytes of the content
+type pfxHeaderHeuristic struct{}
+
+// Match checks if the data starts with a PFX header
+func (pfxHeaderHeuristic) Match(data []byte) bool {
+	pfx, ok := sequenceContents(data)
+	if !ok {
+		return false
 	}
 
-	if !versionFound {
+	tag, version, authSafe, ok := core.ReadElement(pfx)
+	if !ok || tag != derTagInteger || len(version) != 1 || version[0] != pfxVersion {
 		return false
 	}
 
-	// Additional checks specific for key containers
+	_, ok = sequenceContents(authSafe)
 
-	// Look for key-related OIDs in binary form
-	// 1.2.840.113549.1.12.10.1 (PKCS#12)
-	pkcs12Signature := []byte{0x2A, 0x86, 0x48, 0x86, 0xF7, 0x0D, 0x01, 0x0C, 0x0A, 0x01}
-	if bytes.Contains(data, pkcs12Signature) {
-		return true
+	return ok
+}
+
+// sequenceContents returns the contents of the SEQUENCE at the start of the data as far as they
+// are present. Lengths are read as in BER: indefinite, in a non-minimal long form or beyond the
+// end of truncated data
+func sequenceContents(data []byte) ([]byte, bool) {
+	if len(data) < 2 || data[0] != derTagSequence {
+		return nil, false
 	}
 
-	// Check for private key indicators
-	if bytes.Contains(data, []byte("KEY")) ||
-		bytes.Contains(data, []byte("PrivateKey")) {
-		return true
+	length, octets := int(data[1]), 0
+	switch {
+	case length == 0x80:
+		return data[2:], true
+	case length > 0x80:
+		octets, length = length&0x7f, 0
+		if octets > derMaxLengthOctets || len(data) < 2+octets {
+			return nil, false
+		}
+
+		for _, b := range data[2 : 2+octets] {
+			length = length<<8 | int(b)
+		}
 	}
 
-	// If we found version 3 and the file is in the right size range,
-	// it's likely a PKCS#12 file even if we couldn't find specific signatures
-	return versionFound && len(data) > 100 && len(data) < 100000
+	if data = data[2+octets:]; length >= 0 && length < len(data) {
+		data = data[:length]
+	}
+
+	return data, true
 }
 
-// IsPKCS7Data checks if the data is PKCS#7 data
-func IsPKCS7Data(data []byte) bool {
-	result, err := Detect(data)
+// windowHeuristic builds a heuristic searching the scan window once per window size, instead of
+// on every match, as the size only changes with SetLimits
+type windowHeuristic struct {
+	build func(window int) heuristics.Heuristic
+	built atomic.Value // builtHeuristic of the last window size
+}
 
-	if err != nil {
-		return false
+// builtHeuristic is a heuristic built for a window size
+type builtHeuristic struct {
+	window    int
+	heuristic heuristics.Heuristic
+}
+
+// forWindow returns the heuristic for the window size
+func (w *windowHeuristic) forWindow(window int) heuristics.Heuristic {
+	if built, ok := w.built.Load().(builtHeuristic); ok && built.window == window {
+		return built.heuristic
 	}
 
-	return result.ContentType.Equal(PKCS7DataOID)
+	heuristic := w.build(window)
+	w.built.Store(builtHeuristic{window: window, heuristic: heuristic})
+
+	return heuristic
+}
+
+// IsPKCS7Data checks if the data is PKCS#7 data
+func IsPKCS7Data(data []byte) bool {
+	return hasContentType(data, encodedPKCS7DataOID)
 }
 
 // IsPKCS7SignedData checks if the data is PKCS#7 signed data
 func IsPKCS7SignedData(data []byte) bool {
-	result, err := Detect(data)
-
-	if err != nil {
-		return false
-	}
-
-	return result.ContentType.Equal(PKCS7SignedDataOID)
+	return hasContentType(data, encodedPKCS7SignedDataOID)
 }
 
 // IsPKCS7EnvelopedData checks if the data is PKCS#7 enveloped data
 func IsPKCS7EnvelopedData(data []byte) bool {
-	result, err := Detect(data)
+	return hasContentType(data, encodedPKCS7EnvelopedDataOID)
+}
 
-	if err != nil {
-		return false
-	}
+// IsPKCS7SignedAndEnvelopedData checks if the data is PKCS#7 signed and enveloped data
+func IsPKCS7SignedAndEnvelopedData(data []byte) bool {
+	return hasContentType(data, encodedPKCS7SignedAndEnvOID)
+}
 
-	return result.ContentType.Equal(PKCS7EnvelopedDataOID)
+// IsPKCS7DigestedData checks if the data is PKCS#7 digested data
+func IsPKCS7DigestedData(data []byte) bool {
+	return hasContentType(data, encodedPKCS7DigestedDataOID)
 }
 
-// IsPKCS12 checks if the data is a PKCS#12 container (including encrypted ones)
-func IsPKCS12(data []byte) bool {
-	result, err := Detect(data)
+// IsPKCS7EncryptedData checks if the data is PKCS#7 encrypted data
+func IsPKCS7EncryptedData(data []byte) bool {
+	return hasContentType(data, encodedPKCS7EncryptedDataOID)
+}
 
+// IsWindowsCatalog checks if the data is a Microsoft security catalog (.cat)
+func IsWindowsCatalog(data []byte) bool {
+	if !IsPKCS7SignedData(data) {
+		return false
+	}
+
+	contentInfo, err := parseContentInfo(data)
 	if err != nil {
 		return false
 	}
 
-	// Check for both regular and encrypted PKCS#12 containers
-	return result.ContentType.Equal(PKCS12OID) ||
-		result.Type == TypeEncryptedPKCS12
+	return kindOfContentInfo(contentInfo) == KindWindowsCatalog
 }
 
-// IsUserKeyPKCS12 checks if the data appears to be a user PKCS#12 key container
-func IsUserKeyPKCS12(data []byte) bool {
-	result, err := Detect(data)
-	if err != nil {
-		return false
+// IsPKCS12 checks if the data is a PKCS#12 container (including encrypted ones)
+func IsPKCS12(data []byte) bool {
+	// Like Detect, fall back to the encrypted container heuristics only for data that is not a ContentInfo
+	if oid, ok := core.ContentType(data); ok {
+		return bytes.Equal(oid, encodedPKCS12OID)
 	}
 
-	// Check for both regular and encrypted PKCS#12 containers
-	return result.ContentType.Equal(PKCS12OID) ||
-		result.Type == TypeEncryptedPKCS12
+	return isEncryptedPKCS12(data)
 }
 
 // GetOIDDescription returns a human-readable description of the OID
 func GetOIDDescription(oid asn1.ObjectIdentifier) string {
-	switch {
-	case oid.Equal(PKCS7DataOID):
-		return "PKCS#7 Data"
-	case oid.Equal(PKCS7SignedDataOID):
-		return "PKCS#7 Signed Data"
-	case oid.Equal(PKCS7EnvelopedDataOID):
-		return "PKCS#7 Enveloped Data"
-	case oid.Equal(PKCS7SignedAndEnvelopedOID):
-		return "PKCS#7 Signed And Enveloped Data"
-	case oid.Equal(PKCS7DigestedDataOID):
-		return "PKCS#7 Digested Data"
-	case oid.Equal(PKCS7EncryptedDataOID):
-		return "PKCS#7 Encrypted Data"
-	case oid.Equal(PKCS12OID):
-		return "PKCS#12"
-	default:
-		return fmt.Sprintf("Unknown OID: %s", oid.String())
+	if info, ok := LookupOID(oid); ok {
+		return info.Name
 	}
+
+	return describeUnknownOID("Unknown OID", oid)
 }
@@ -0,0 +1,113 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+
+	"github.com/lEx0/cmsdetector/heuristics"
+)
+
+// KeyContainerProfile selects the heuristics a KeyContainerDetector uses to recognize user key containers
+type KeyContainerProfile int
+
+// Heuristic profiles of a KeyContainerDetector. The zero value is KeyContainerGeneric
+const (
+	KeyContainerGeneric KeyContainerProfile = iota // Any PKCS#12 container, also when recognized by the encrypted container heuristics
+	KeyContainerNCA                                // PKCS#12 containers of KalkanCrypt with keys or certificates of the NCA of Kazakhstan
+	KeyContainerTumar                              // PKCS#12 containers exported by Tumar CSP (Gamma Technologies)
+)
+
+// String returns the name of the profile
+func (p KeyContainerProfile) String() string {
+	switch p {
+	case KeyContainerGeneric:
+		return "generic"
+	case KeyContainerNCA:
+		return "NCA"
+	case KeyContainerTumar:
+		return "Tumar"
+	default:
+		return fmt.Sprintf("KeyContainerProfile(%d)", int(p))
+	}
+}
+
+// KeyContainerDetector recognizes user key containers with the heuristics of a profile. The zero
+// value uses the generic profile. It is safe for concurrent use
+type KeyContainerDetector struct {
+	Profile KeyContainerProfile
+}
+
+// IsKeyContainer checks if the data appears to be a user key container of the profile. Every
+// profile requires a PKCS#12 container as reported by IsPKCS12, the NCA and Tumar profiles also
+// search the leading bytes for the OIDs or markers of their providers. Unknown profiles match nothing
+func (d *KeyContainerDetector) IsKeyContainer(data []byte) bool {
+	if !IsPKCS12(data) {
+		return false
+	}
+
+	switch d.Profile {
+	case KeyContainerGeneric:
+		return true
+	case KeyContainerNCA:
+		return ncaKeyHeuristics.forWindow(scanWindowSize()).Match(data)
+	case KeyContainerTumar:
+		return tumarKeyHeuristics.forWindow(scanWindowSize()).Match(data)
+	default:
+		return false
+	}
+}
+
+// Heuristics of the key container profiles for the scan window in effect
+var (
+	ncaKeyHeuristics   = &windowHeuristic{build: ncaKeyHeuristic}
+	tumarKeyHeuristics = &windowHeuristic{build: tumarKeyHeuristic}
+)
+
+// ncaKeyHeuristic searches the window for OIDs of the Kazakhstan national arc, used by GOST keys
+// and NCA certificate policies
+func ncaKeyHeuristic(window int) heuristics.Heuristic {
+	return heuristics.Pattern{Bytes: encodedKazakhArcOID, Window: window}
+}
+
+// tumarKeyHeuristic searches the window for OIDs of the Gamma Technologies arc, used by the
+// algorithms and policies of Tumar CSP, and for its markers
+func tumarKeyHeuristic(window int) heuristics.Heuristic {
+	markers := heuristics.Any{heuristics.Pattern{Bytes: encodedGammaArcOID, Window: window}}
+	for _, marker := range tumarMarkers {
+		markers = append(markers, heuristics.Pattern{Bytes: []byte(marker), Window: window, UTF16: true})
+	}
+
+	return markers
+}
+
+// gammaArcOID is the private enterprise arc of Gamma Technologies, the vendor of Tumar CSP
+var gammaArcOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 6801}
+
+// Contents prefixes of the DER encoding of OIDs under the provider arcs
+var (
+	encodedKazakhArcOID = encodeOIDContents(kazakhArcOID)
+	encodedGammaArcOID  = encodeOIDContents(gammaArcOID)
+)
+
+// tumarMarkers contains strings found in key containers created by Tumar CSP
+// (Gamma Technologies), either in plain ASCII or as BMPString friendly names
+var tumarMarkers = []string{
+	"TUMAR",
+	"Tumar",
+	"tumar",
+	"Gamma Technologies",
+}
+
+// IsUserKeyPKCS12 checks if the data appears to be a user PKCS#12 key container
+//
+// Deprecated: Use KeyContainerDetector with KeyContainerGeneric, which behaves the same
+func IsUserKeyPKCS12(data []byte) bool {
+	return (&KeyContainerDetector{Profile: KeyContainerGeneric}).IsKeyContainer(data)
+}
+
+// IsTumarKeyContainer checks if the data appears to be a Tumar CSP key container
+//
+// Deprecated: Use KeyContainerDetector with KeyContainerTumar, which behaves the same
+func IsTumarKeyContainer(data []byte) bool {
+	return (&KeyContainerDetector{Profile: KeyContainerTumar}).IsKeyContainer(data)
+}
@@ -0,0 +1,130 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// TestIsTumarKeyContainer tests detection of Tumar CSP key containers
+func TestIsTumarKeyContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{
+			name:     "ASCII marker",
+			data:     append(createMockPKCS12Key(t), []byte("TUMAR")...),
+			expected: true,
+		},
+		{
+			name:     "BMPString marker",
+			data:     append(createMockPKCS12Key(t), 0x00, 'T', 0x00, 'u', 0x00, 'm', 0x00, 'a', 0x00, 'r'),
+			expected: true,
+		},
+		{
+			name:     "PKCS#12 without marker",
+			data:     createMockPKCS12Key(t),
+			expected: false,
+		},
+		{
+			name:     "Marker outside of PKCS#12",
+			data:     []byte("TUMAR"),
+			expected: false,
+		},
+		{
+			name:     "PKCS#7 Data",
+			data:     createTestData(t, PKCS7DataOID),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if result := IsTumarKeyContainer(tt.data); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestKeyContainerDetector tests the heuristic profiles of KeyContainerDetector
+func TestKeyContainerDetector(t *testing.T) {
+	kalkanKey := append(createMockPKCS12Key(t), encodeOIDContents(asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 1})...)
+	tumarKey := append(createMockPKCS12Key(t), []byte("Gamma Technologies")...)
+
+	tests := []struct {
+		name     string
+		profile  KeyContainerProfile
+		data     []byte
+		expected bool
+	}{
+		{
+			name:     "Generic PKCS#12",
+			profile:  KeyContainerGeneric,
+			data:     createMockPKCS12Key(t),
+			expected: true,
+		},
+		{
+			name:     "Generic PKCS#7 Data",
+			profile:  KeyContainerGeneric,
+			data:     createTestData(t, PKCS7DataOID),
+			expected: false,
+		},
+		{
+			name:     "NCA key",
+			profile:  KeyContainerNCA,
+			data:     kalkanKey,
+			expected: true,
+		},
+		{
+			name:     "NCA without Kazakhstan OIDs",
+			profile:  KeyContainerNCA,
+			data:     tumarKey,
+			expected: false,
+		},
+		{
+			name:     "Tumar key",
+			profile:  KeyContainerTumar,
+			data:     tumarKey,
+			expected: true,
+		},
+		{
+			name:     "Tumar OIDs without marker",
+			profile:  KeyContainerTumar,
+			data:     append(createMockPKCS12Key(t), encodeOIDContents(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 6801, 1, 5, 8})...),
+			expected: true,
+		},
+		{
+			name:     "Tumar marker without OIDs",
+			profile:  KeyContainerTumar,
+			data:     append(createMockPKCS12Key(t), []byte("Tumar")...),
+			expected: true,
+		},
+		{
+			name:     "Tumar without OIDs or marker",
+			profile:  KeyContainerTumar,
+			data:     kalkanKey,
+			expected: false,
+		},
+		{
+			name:     "Unknown profile",
+			profile:  KeyContainerProfile(-1),
+			data:     createMockPKCS12Key(t),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				d := &KeyContainerDetector{Profile: tt.profile}
+				if result := d.IsKeyContainer(tt.data); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
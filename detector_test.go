@@ -1,12 +1,20 @@
 package cmsdetector
 
 import (
+	"bytes"
+	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/rand"
+	"runtime"
 	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
 )
 
 // createTestData creates ASN.1 encoded ContentInfo structure with the given OID
-func createTestData(t *testing.T, oid asn1.ObjectIdentifier) []byte {
+func createTestData(t testing.TB, oid asn1.ObjectIdentifier) []byte {
 	contentInfo := ContentInfo{
 		ContentType: oid,
 		Content: asn1.RawValue{
@@ -26,7 +34,7 @@ func createTestData(t *testing.T, oid asn1.ObjectIdentifier) []byte {
 }
 
 // createMockPKCS12Key creates a mock encrypted PKCS#12 key for testing
-func createMockPKCS12Key(t *testing.T) []byte {
+func createMockPKCS12Key(t testing.TB) []byte {
 	// Basic PKCS#12 header with version 3
 	header := []byte{
 		0x30, 0x82, 0x01, 0x00, // SEQUENCE tag with length
@@ -158,6 +166,74 @@ func TestEncryptedPKCS12Detection(t *testing.T) {
 	}
 }
 
+// TestPFXHeaderHeuristic tests that the PKCS#12 version is only found in the PFX header
+func TestPFXHeaderHeuristic(t *testing.T) {
+	version := []byte{0x02, 0x01, 0x03}
+	authSafe := []byte{0x30, 0x82, 0x00, 0x50, 0x06, 0x09}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{
+			name:     "Truncated container",
+			data:     createMockPKCS12Key(t),
+			expected: true,
+		},
+		{
+			name:     "Complete container",
+			data:     cmstest.PFX(t),
+			expected: true,
+		},
+		{
+			name:     "Indefinite lengths",
+			data:     append(append([]byte{0x30, 0x80}, version...), 0x30, 0x80, 0x06, 0x09),
+			expected: true,
+		},
+		{
+			name:     "Version in the content",
+			data:     append(append([]byte{0x30, 0x0b, 0x04, 0x09}, version...), authSafe...),
+			expected: false,
+		},
+		{
+			name:     "Other version",
+			data:     append([]byte{0x30, 0x80, 0x02, 0x01, 0x01}, authSafe...),
+			expected: false,
+		},
+		{
+			name:     "Missing authSafe",
+			data:     append(append([]byte{0x30, 0x80}, version...), 0x04, 0x00),
+			expected: false,
+		},
+		{
+			name:     "Truncated version",
+			data:     []byte{0x30, 0x80, 0x02, 0x01},
+			expected: false,
+		},
+		{
+			name:     "Not a SEQUENCE",
+			data:     append(append([]byte{0x31, 0x80}, version...), authSafe...),
+			expected: false,
+		},
+		{
+			name:     "Excessive length octets",
+			data:     append(append([]byte{0x30, 0x85, 0x01, 0x00, 0x00, 0x00, 0x00}, version...), authSafe...),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if result := (pfxHeaderHeuristic{}).Match(tt.data); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
 // TestSpecificFormatDetection tests the specific format detection functions
 func TestSpecificFormatDetection(t *testing.T) {
 	tests := []struct {
@@ -202,6 +278,42 @@ func TestSpecificFormatDetection(t *testing.T) {
 			testFunc: IsPKCS7EnvelopedData,
 			expected: false,
 		},
+		{
+			name:     "IsPKCS7SignedAndEnvelopedData with PKCS7SignedAndEnvelopedOID",
+			oid:      PKCS7SignedAndEnvelopedOID,
+			testFunc: IsPKCS7SignedAndEnvelopedData,
+			expected: true,
+		},
+		{
+			name:     "IsPKCS7SignedAndEnvelopedData with PKCS7EnvelopedDataOID",
+			oid:      PKCS7EnvelopedDataOID,
+			testFunc: IsPKCS7SignedAndEnvelopedData,
+			expected: false,
+		},
+		{
+			name:     "IsPKCS7DigestedData with PKCS7DigestedDataOID",
+			oid:      PKCS7DigestedDataOID,
+			testFunc: IsPKCS7DigestedData,
+			expected: true,
+		},
+		{
+			name:     "IsPKCS7DigestedData with PKCS7DataOID",
+			oid:      PKCS7DataOID,
+			testFunc: IsPKCS7DigestedData,
+			expected: false,
+		},
+		{
+			name:     "IsPKCS7EncryptedData with PKCS7EncryptedDataOID",
+			oid:      PKCS7EncryptedDataOID,
+			testFunc: IsPKCS7EncryptedData,
+			expected: true,
+		},
+		{
+			name:     "IsPKCS7EncryptedData with PKCS7EnvelopedDataOID",
+			oid:      PKCS7EnvelopedDataOID,
+			testFunc: IsPKCS7EncryptedData,
+			expected: false,
+		},
 		{
 			name:     "IsPKCS12 with PKCS12OID",
 			oid:      PKCS12OID,
@@ -297,3 +409,538 @@ func TestGetOIDDescription(t *testing.T) {
 		)
 	}
 }
+
+// BenchmarkDetect measures full detection of SignedData
+func BenchmarkDetect(b *testing.B) {
+	data := createSignedData(b, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Detect(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkDetect measures the detection function on the data, which must succeed
+func benchmarkDetect(b *testing.B, data []byte, detect func([]byte) error) {
+	b.Helper()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := detect(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDetectSmall measures detection of a minimal ContentInfo
+func BenchmarkDetectSmall(b *testing.B) {
+	benchmarkDetect(
+		b, createTestData(b, PKCS7DataOID), func(data []byte) error {
+			_, err := Detect(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectHuge measures detection of SignedData with 16 MiB of content
+func BenchmarkDetectHuge(b *testing.B) {
+	benchmarkDetect(
+		b, cmstest.SignedData(b, cmstest.WithContent(make([]byte, 16<<20))), func(data []byte) error {
+			_, err := Detect(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectEnveloped measures detection of EnvelopedData with 16 MiB of encrypted content
+func BenchmarkDetectEnveloped(b *testing.B) {
+	benchmarkDetect(
+		b, cmstest.EnvelopedData(b, cmstest.WithContent(make([]byte, 16<<20))), func(data []byte) error {
+			_, err := Detect(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectPEM measures detection of PEM encoded SignedData by DetectAny
+func BenchmarkDetectPEM(b *testing.B) {
+	data := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: cmstest.SignedData(b)})
+
+	benchmarkDetect(
+		b, data, func(data []byte) error {
+			_, err := DetectAny(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectBER measures the lenient detection of SignedData with an indefinite length
+func BenchmarkDetectBER(b *testing.B) {
+	var contentInfo asn1.RawValue
+	if _, err := asn1.Unmarshal(cmstest.SignedData(b), &contentInfo); err != nil {
+		b.Fatal(err)
+	}
+
+	data := append(append([]byte{0x30, 0x80}, contentInfo.Bytes...), 0x00, 0x00)
+	detector := NewDetector(WithStrictness(StrictnessLenient))
+
+	benchmarkDetect(
+		b, data, func(data []byte) error {
+			_, err := detector.Detect(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectEncryptedPKCS12 measures the heuristics recognizing encrypted PKCS#12 containers
+func BenchmarkDetectEncryptedPKCS12(b *testing.B) {
+	benchmarkDetect(
+		b, createMockPKCS12Key(b), func(data []byte) error {
+			_, err := Detect(data)
+			return err
+		},
+	)
+}
+
+// BenchmarkDetectUnknown measures the rejection of 1 MiB of random data, which runs every heuristic
+// over the scan window
+func BenchmarkDetectUnknown(b *testing.B) {
+	data := make([]byte, 1<<20)
+	if _, err := rand.New(rand.NewSource(1)).Read(data); err != nil {
+		b.Fatal(err)
+	}
+
+	data[0] = derTagSequence
+
+	benchmarkDetect(
+		b, data, func(data []byte) error {
+			if _, err := Detect(data); err == nil {
+				return errors.New("expected an error")
+			}
+
+			return nil
+		},
+	)
+}
+
+// BenchmarkDetectKeyContainer measures the Tumar CSP markers search of a key container
+func BenchmarkDetectKeyContainer(b *testing.B) {
+	detector := &KeyContainerDetector{Profile: KeyContainerTumar}
+
+	benchmarkDetect(
+		b, cmstest.PFX(b), func(data []byte) error {
+			if detector.IsKeyContainer(data) {
+				return errors.New("expected no Tumar markers")
+			}
+
+			return nil
+		},
+	)
+}
+
+// BenchmarkIsPKCS7SignedData measures the content type fast path used by the Is* helpers
+func BenchmarkIsPKCS7SignedData(b *testing.B) {
+	data := createSignedData(b, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if !IsPKCS7SignedData(data) {
+			b.Fatal("Expected SignedData")
+		}
+	}
+}
+
+// TestIsPKCS7Allocations tests that the content type checks stay on the header-only fast path
+func TestIsPKCS7Allocations(t *testing.T) {
+	digested := cmstest.DigestedData(t)
+	encrypted := cmstest.EncryptedData(t)
+
+	allocs := testing.AllocsPerRun(
+		100, func() {
+			IsPKCS7DigestedData(digested)
+			IsPKCS7EncryptedData(encrypted)
+			IsPKCS7SignedAndEnvelopedData(encrypted)
+		},
+	)
+
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+// TestDetectAllocations tests that detection neither copies the content of large structures nor
+// allocates for the key container heuristics
+func TestDetectAllocations(t *testing.T) {
+	const contentSize = 8 << 20
+
+	inputs := map[string][]byte{
+		"signed":    cmstest.SignedData(t, cmstest.WithContent(make([]byte, contentSize))),
+		"enveloped": cmstest.EnvelopedData(t, cmstest.WithContent(make([]byte, contentSize))),
+	}
+
+	for name, data := range inputs {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+
+		if _, err := Detect(data); err != nil {
+			t.Fatalf("Detect returned an error for %s data: %v", name, err)
+		}
+
+		runtime.ReadMemStats(&after)
+
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > contentSize/8 {
+			t.Errorf("Expected Detect not to copy the %s content, allocated %d bytes", name, allocated)
+		}
+	}
+
+	pfx := cmstest.PFX(t)
+	detector := &KeyContainerDetector{Profile: KeyContainerTumar}
+
+	if allocs := testing.AllocsPerRun(100, func() { detector.IsKeyContainer(pfx) }); allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+// createEncryptedContentInfo creates AES-256-CBC encrypted data content
+func createEncryptedContentInfo() encryptedContentInfo {
+	return encryptedContentInfo{
+		ContentType:                PKCS7DataOID,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}},
+		EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x01, 0x02}},
+	}
+}
+
+// createAuthEnvelopedData creates AuthEnvelopedData, whose kind is unknown but content is encrypted
+func createAuthEnvelopedData(t *testing.T) []byte {
+	t.Helper()
+
+	return createContentInfo(
+		t, AuthEnvelopedDataOID, authEnvelopedData{
+			Version:                  0,
+			RecipientInfos:           []asn1.RawValue{{FullBytes: []byte{0x30, 0x00}}},
+			AuthEncryptedContentInfo: createEncryptedContentInfo(),
+			MAC:                      make([]byte, 16),
+		},
+	)
+}
+
+// createCertificatePFX creates a PFX holding an unencrypted certificate bag only
+func createCertificatePFX(t *testing.T) []byte {
+	t.Helper()
+
+	return createPFX(t, []safeBag{createSafeBag(t, PKCS12CertBagOID, certBag{CertID: x509CertBagOID, CertValue: []byte{0x30, 0x00}})}, nil)
+}
+
+// TestDetectIsEncrypted tests that IsEncrypted is reported for parsed structures with encrypted content
+func TestDetectIsEncrypted(t *testing.T) {
+	encryptedContent := createEncryptedContentInfo()
+
+	signedAndEnveloped := signedAndEnvelopedData{
+		Version:              1,
+		RecipientInfos:       []asn1.RawValue{{FullBytes: []byte{0x30, 0x00}}},
+		DigestAlgorithms:     []pkix.AlgorithmIdentifier{{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}}},
+		EncryptedContentInfo: encryptedContent,
+		SignerInfos:          []asn1.RawValue{{FullBytes: []byte{0x30, 0x00}}},
+	}
+
+	certBags := []safeBag{createSafeBag(t, PKCS12CertBagOID, certBag{CertID: x509CertBagOID, CertValue: []byte{0x30, 0x00}})}
+	shroudedKey := createSafeBag(
+		t, PKCS12ShroudedKeyBagOID, encryptedPrivateKeyInfo{
+			Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+			EncryptedData: []byte{0x01, 0x02},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{name: "SignedData", data: cmstest.SignedData(t), expected: false},
+		{name: "DigestedData", data: cmstest.DigestedData(t), expected: false},
+		{name: "EnvelopedData", data: cmstest.EnvelopedData(t), expected: true},
+		{name: "EncryptedData", data: cmstest.EncryptedData(t), expected: true},
+		{name: "SignedAndEnvelopedData", data: createContentInfo(t, PKCS7SignedAndEnvelopedOID, signedAndEnveloped), expected: true},
+		{name: "AuthEnvelopedData", data: createAuthEnvelopedData(t), expected: true},
+		{name: "Malformed EnvelopedData", data: createTestData(t, PKCS7EnvelopedDataOID), expected: false},
+		{name: "Malformed AuthEnvelopedData", data: createTestData(t, AuthEnvelopedDataOID), expected: false},
+		{name: "PFX with shrouded key", data: createPFX(t, []safeBag{shroudedKey}, nil), expected: true},
+		{name: "PFX with encrypted safe", data: createPFX(t, certBags, []encryptedContentInfo{encryptedContent}), expected: true},
+		{name: "PFX with certificates only", data: createCertificatePFX(t), expected: false},
+		{name: "Heuristic PKCS#12", data: createMockPKCS12Key(t), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.IsEncrypted != tt.expected {
+					t.Errorf("Expected IsEncrypted %v, got %v", tt.expected, result.IsEncrypted)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectNeedsPassword tests that NeedsPassword is only reported for password-protected containers
+func TestDetectNeedsPassword(t *testing.T) {
+	pwri := passwordRecipientInfo{
+		Version:                0,
+		KeyDerivationAlgorithm: pkix.AlgorithmIdentifier{Algorithm: pbkdf2OID},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 9}},
+		EncryptedKey:           []byte{0x01, 0x02},
+	}
+
+	passwordRecipient := envelopedData{
+		Version:        3,
+		RecipientInfos: []asn1.RawValue{createRecipientInfo(t, recipientInfoPassword, pwri)},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                PKCS7DataOID,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{name: "SignedData", data: cmstest.SignedData(t), expected: false},
+		{name: "EnvelopedData for a certificate", data: cmstest.EnvelopedData(t), expected: false},
+		{name: "EnvelopedData for a password", data: createContentInfo(t, PKCS7EnvelopedDataOID, passwordRecipient), expected: true},
+		{name: "EncryptedData with a pre-shared key", data: cmstest.EncryptedData(t), expected: false},
+		{name: "EncryptedData with PBES2", data: cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.PBES2OID)), expected: true},
+		{name: "PFX", data: cmstest.PFX(t), expected: true},
+		{name: "Heuristic PKCS#12", data: createMockPKCS12Key(t), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.NeedsPassword != tt.expected {
+					t.Errorf("Expected NeedsPassword %v, got %v", tt.expected, result.NeedsPassword)
+				}
+
+				anyResult, err := DetectAny(tt.data)
+				if err != nil {
+					t.Fatalf("DetectAny returned an error: %v", err)
+				}
+
+				if anyResult.NeedsPassword != tt.expected {
+					t.Errorf("Expected DetectAny NeedsPassword %v, got %v", tt.expected, anyResult.NeedsPassword)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectInnerContentType tests the content type reported for content signed or encrypted by the structure
+func TestDetectInnerContentType(t *testing.T) {
+	tstInfoOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+
+	timeStampToken := createContentInfo(
+		t, PKCS7SignedDataOID, signedData{
+			Version:          3,
+			EncapContentInfo: encapsulatedContentInfo{EContentType: tstInfoOID},
+		},
+	)
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedOID  asn1.ObjectIdentifier
+		expectedType string
+	}{
+		{
+			name:         "Time-stamp token",
+			data:         timeStampToken,
+			expectedOID:  tstInfoOID,
+			expectedType: "Time-Stamp Token Info",
+		},
+		{
+			name:         "Security catalog",
+			data:         createWindowsCatalog(t),
+			expectedOID:  WindowsCatalogOID,
+			expectedType: "Windows Security Catalog",
+		},
+		{
+			name:         "DigestedData",
+			data:         cmstest.DigestedData(t),
+			expectedOID:  PKCS7DataOID,
+			expectedType: "PKCS#7 Data",
+		},
+		{
+			name:         "EnvelopedData",
+			data:         cmstest.EnvelopedData(t),
+			expectedOID:  PKCS7DataOID,
+			expectedType: "PKCS#7 Data",
+		},
+		{
+			name:         "EncryptedData",
+			data:         cmstest.EncryptedData(t),
+			expectedOID:  PKCS7DataOID,
+			expectedType: "PKCS#7 Data",
+		},
+		{
+			name: "Malformed SignedData",
+			data: createTestData(t, PKCS7SignedDataOID),
+		},
+		{
+			name: "Data",
+			data: createTestData(t, PKCS7DataOID),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if !result.InnerContentType.Equal(tt.expectedOID) || result.InnerType != tt.expectedType {
+					t.Errorf("Expected %v (%q), got %v (%q)", tt.expectedOID, tt.expectedType, result.InnerContentType, result.InnerType)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectContentSize tests the size reported for content encapsulated or encrypted by the structure
+func TestDetectContentSize(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected int64
+	}{
+		{
+			name:     "SignedData",
+			data:     createSignedDataWithContent(t, []byte("Hello, World!")),
+			expected: 13,
+		},
+		{
+			name:     "Detached SignedData",
+			data:     createSignedData(t, sha256OID, rsaEncryptionOID),
+			expected: 0,
+		},
+		{
+			name:     "EnvelopedData",
+			data:     createEnvelopedData(t, rsaEncryptionOID, aesOID),
+			expected: 2,
+		},
+		{
+			name:     "Data",
+			data:     createContentInfo(t, PKCS7DataOID, []byte("payload")),
+			expected: 7,
+		},
+		{
+			name:     "Malformed SignedData",
+			data:     createTestData(t, PKCS7SignedDataOID),
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.ContentSize != tt.expected {
+					t.Errorf("Expected content size %d, got %d", tt.expected, result.ContentSize)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectHeader tests the leading bytes reported as the header of the structure
+func TestDetectHeader(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	contentType := func(oid asn1.ObjectIdentifier) []byte {
+		encoded, err := asn1.Marshal(oid)
+		if err != nil {
+			t.Fatalf("Failed to marshal OID: %v", err)
+		}
+
+		return encoded
+	}
+
+	tests := []struct {
+		name           string
+		data           []byte
+		expectedSuffix []byte
+	}{
+		{
+			name:           "SignedData",
+			data:           createSignedData(t, sha256OID, rsaEncryptionOID),
+			expectedSuffix: contentType(PKCS7SignedDataOID),
+		},
+		{
+			name:           "EnvelopedData",
+			data:           createEnvelopedData(t, rsaEncryptionOID, aesOID),
+			expectedSuffix: contentType(PKCS7EnvelopedDataOID),
+		},
+		{
+			name:           "Long content type",
+			data:           createContentInfo(t, asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 63, 64}, []byte{0x01}),
+			expectedSuffix: []byte{60, 61}, // Truncated after 64 bytes
+		},
+		{
+			name:           "PKCS#12",
+			data:           readCorpus(t)["keystore.p12"],
+			expectedSuffix: []byte{0x02, 0x01, 0x03},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if !bytes.HasPrefix(tt.data, result.Header) || !bytes.HasSuffix(result.Header, tt.expectedSuffix) {
+					t.Errorf("Expected a header of the data ending with %x, got %x", tt.expectedSuffix, result.Header)
+				}
+
+				if len(result.Header) > maxRawHeaderSize {
+					t.Errorf("Expected at most %d header bytes, got %d", maxRawHeaderSize, len(result.Header))
+				}
+
+				// The header must not retain the input
+				result.Header[0] ^= 0xff
+				if tt.data[0] != 0x30 {
+					t.Error("Expected the header to be a copy of the data")
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrUnknownEncoding is returned by DetectString for text that is not PEM, base64 or hex encoded
+var ErrUnknownEncoding = errors.New("text is not PEM, base64 or hex encoded")
+
+// DetectString determines the type of CMS/PKCS data encoded as text like Detect, e.g. a string
+// field of a JSON document. The encoding is detected: a PEM block, standard or URL-safe base64 with
+// or without padding, or hex digits, ignoring whitespace. Text of hex digits only is decoded as hex,
+// base64 of a DER structure starts with "M" and is never mistaken for it
+func DetectString(s string) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	result, err = detectString(s)
+	recordDetection(len(s), result.Kind, result.Kind == KindEncryptedPKCS12, err)
+
+	return result, err
+}
+
+// detectString decodes the text and determines the type of the structure without reporting metrics
+func detectString(s string) (DetectionResult, error) {
+	if err := checkInputLength(len(s)); err != nil {
+		return DetectionResult{}, err
+	}
+
+	data, err := decodeText([]byte(strings.TrimSpace(s)))
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	return detect(data)
+}
+
+// decodeText decodes PEM, hex or base64 text, in this order
+func decodeText(text []byte) ([]byte, error) {
+	if bytes.HasPrefix(text, []byte("-----BEGIN ")) {
+		block, _ := pem.Decode(text)
+		if block == nil {
+			return nil, fmt.Errorf("%w: invalid PEM block", ErrUnknownEncoding)
+		}
+
+		return block.Bytes, nil
+	}
+
+	if digits, ok := hexDigits(text); ok {
+		if err := checkAllocation(len(digits) / 2); err != nil {
+			return nil, err
+		}
+
+		decoded := make([]byte, len(digits)/2)
+		if _, err := hex.Decode(decoded, digits); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnknownEncoding, err)
+		}
+
+		return decoded, nil
+	}
+
+	if decoded, ok := decodeBase64Text(text); ok {
+		return decoded, nil
+	}
+
+	return nil, ErrUnknownEncoding
+}
+
+// hexDigits returns the text without whitespace if it consists of an even number of hex digits
+func hexDigits(text []byte) ([]byte, bool) {
+	digits := text
+	if bytes.IndexFunc(text, unicode.IsSpace) >= 0 {
+		digits = bytes.Join(bytes.Fields(text), nil)
+	}
+
+	if len(digits) == 0 || len(digits)%2 != 0 {
+		return nil, false
+	}
+
+	for _, c := range digits {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return nil, false
+		}
+	}
+
+	return digits, true
+}
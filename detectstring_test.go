@@ -0,0 +1,113 @@
+package cmsdetector
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestDetectString tests detection of text encoded structures
+func TestDetectString(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	std := base64.StdEncoding.EncodeToString(signed)
+
+	tests := []struct {
+		name         string
+		text         string
+		expectedKind Kind
+		expectedErr  error
+	}{
+		{
+			name:         "PEM",
+			text:         string(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: signed})),
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "Base64",
+			text:         std,
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "Base64 with line breaks",
+			text:         wrapBase64(signed),
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "URL-safe base64 without padding",
+			text:         base64.RawURLEncoding.EncodeToString(signed),
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "Hex",
+			text:         hex.EncodeToString(signed),
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "Upper case hex with spaces",
+			text:         " " + strings.ToUpper(hex.EncodeToString(signed[:8])) + "\n" + hex.EncodeToString(signed[8:]) + "\n",
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "PKCS#12",
+			text:         base64.StdEncoding.EncodeToString(cmstest.PFX(t)),
+			expectedKind: KindEncryptedPKCS12,
+		},
+		{
+			name:        "Invalid PEM block",
+			text:        "-----BEGIN PKCS7-----\nMIIB\n",
+			expectedErr: ErrUnknownEncoding,
+		},
+		{
+			name:        "Odd number of hex digits",
+			text:        "308",
+			expectedErr: ErrUnknownEncoding,
+		},
+		{
+			name:        "Plain text",
+			text:        "Hello, world",
+			expectedErr: ErrUnknownEncoding,
+		},
+		{
+			name:        "Empty",
+			expectedErr: ErrUnknownEncoding,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectString(tt.text)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result.Kind != tt.expectedKind {
+					t.Errorf("Expected kind %s, got %s", tt.expectedKind, result.Kind)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectStringLimits tests that the text and the decoded structure are subject to the limits
+func TestDetectStringLimits(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	text := base64.StdEncoding.EncodeToString(signed)
+
+	withLimits(t, Limits{MaxInputSize: len(text) - 1})
+
+	if _, err := DetectString(text); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Expected %v, got %v", ErrInputTooLarge, err)
+	}
+
+	withLimits(t, Limits{MaxAllocation: len(signed) - 1})
+
+	if _, err := DetectString(hex.EncodeToString(signed)); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Expected %v, got %v", ErrInputTooLarge, err)
+	}
+}
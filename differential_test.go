@@ -0,0 +1,150 @@
+//go:build openssl
+
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Differential tests comparing the classifications of this package with OpenSSL, the de-facto
+// reference implementation. They require the openssl binary and run with: go test -tags openssl
+
+// openSSLCMSKinds lists the kinds OpenSSL reads with "openssl cms"
+var openSSLCMSKinds = map[Kind]bool{
+	KindData:           true,
+	KindSignedData:     true,
+	KindEnvelopedData:  true,
+	KindDigestedData:   true,
+	KindEncryptedData:  true,
+	KindWindowsCatalog: true,
+}
+
+// asn1ParseObject matches an OBJECT IDENTIFIER nested in the outer element in "openssl asn1parse" output
+var asn1ParseObject = regexp.MustCompile(`d=1 .* prim: OBJECT +:(\S+)\s*$`)
+
+// runOpenSSL runs the openssl command, reporting its output and whether it succeeded
+func runOpenSSL(args ...string) (string, bool) {
+	output, err := exec.Command("openssl", args...).CombinedOutput()
+
+	return string(output), err == nil
+}
+
+// openSSLContentType returns the content type of a ContentInfo parsed by "openssl asn1parse"
+func openSSLContentType(path string) (asn1.ObjectIdentifier, bool) {
+	output, ok := runOpenSSL("asn1parse", "-inform", "DER", "-in", path)
+	if !ok {
+		return nil, false
+	}
+
+	// The content type is the first element of the outer SEQUENCE
+	lines := strings.Split(output, "\n")
+	if len(lines) < 2 {
+		return nil, false
+	}
+
+	match := asn1ParseObject.FindStringSubmatch(lines[1])
+	if match == nil {
+		return nil, false
+	}
+
+	name := match[1]
+	if oid, ok := LookupOpenSSLName(name); ok {
+		return oid, true
+	}
+
+	oid, err := parseDottedOID(name)
+
+	return oid, err == nil
+}
+
+// differentialInputs returns the corpus and mutations of it, written to files in a temporary directory
+func differentialInputs(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	inputs := make(map[string][]byte)
+
+	for name, data := range readCorpus(t) {
+		inputs[name] = data
+		inputs[name+".truncated"] = data[:len(data)/2]
+
+		// Corrupt the tag and length octets of the outer and the first nested elements
+		for _, offset := range []int{0, 1, 2, 4, 5} {
+			if offset >= len(data) {
+				continue
+			}
+
+			corrupted := append([]byte(nil), data...)
+			corrupted[offset] ^= 0x01
+			inputs[name+".corrupted-"+string(rune('0'+offset))] = corrupted
+		}
+	}
+
+	return inputs
+}
+
+// TestDifferentialOpenSSL compares content types and CMS classifications with OpenSSL
+func TestDifferentialOpenSSL(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found")
+	}
+
+	dir := t.TempDir()
+
+	for name, data := range differentialInputs(t) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+
+		result, err := Detect(data)
+		detected := err == nil && result.Kind != KindEncryptedPKCS12
+
+		// A ContentInfo detected by this package must be valid DER with the same content type for OpenSSL
+		contentType, parsed := openSSLContentType(path)
+		if detected && !parsed {
+			t.Errorf("%s: detected %v, but OpenSSL cannot parse the content type", name, result.Kind)
+		}
+
+		if detected && parsed && !contentType.Equal(result.ContentType) {
+			t.Errorf("%s: detected content type %s, OpenSSL reports %s", name, result.ContentType, contentType)
+		}
+
+		// Every structure OpenSSL reads as a known CMS content type must be detected as CMS content.
+		// OpenSSL also reads ContentInfo with unknown content types, which are not compared
+		_, cmsOK := runOpenSSL("cms", "-cmsout", "-inform", "DER", "-in", path, "-noout")
+		if cmsOK && parsed && openSSLCMSKinds[kindOfContentInfo(ContentInfo{ContentType: contentType})] &&
+			!(detected && openSSLCMSKinds[result.Kind]) {
+			t.Errorf("%s: OpenSSL reads CMS content type %s, detected %v (%v)", name, contentType, result.Kind, err)
+		}
+	}
+}
+
+// TestDifferentialOpenSSLPKCS12 compares the classification of PKCS#12 containers with OpenSSL
+func TestDifferentialOpenSSLPKCS12(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found")
+	}
+
+	dir := t.TempDir()
+
+	for name, data := range differentialInputs(t) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+
+		// The corpus containers are protected with the password "corpus"
+		_, pkcs12OK := runOpenSSL("pkcs12", "-in", path, "-noout", "-passin", "pass:corpus")
+
+		result, err := DetectAny(data)
+		if pkcs12OK && (err != nil || result.Kind != KindPKCS12) {
+			t.Errorf("%s: OpenSSL reads a PKCS#12 container, detected %v (%v)", name, result.Kind, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// ErrNotDigestedData is returned when the data is not PKCS#7 digested data
+var ErrNotDigestedData = errors.New("not a PKCS#7 digested data")
+
+// DigestedDataInfo describes the contents of PKCS#7 DigestedData
+type DigestedDataInfo struct {
+	Version         int
+	DigestAlgorithm Algorithm
+	ContentType     asn1.ObjectIdentifier // Type of the digested content, usually PKCS7DataOID
+	Content         []byte                // Digested content, nil when detached or not an OCTET STRING
+	Detached        bool                  // Indicates the digested content is not included
+	Digest          []byte                // Digest value as stored, it is not verified
+}
+
+// InspectDigestedData returns the digest algorithm, digest value and content of PKCS#7 DigestedData
+func InspectDigestedData(data []byte) (info *DigestedDataInfo, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	if !contentInfo.ContentType.Equal(PKCS7DigestedDataOID) {
+		return nil, ErrNotDigestedData
+	}
+
+	dd, err := parseDigestedData(contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse digested data: %w", err)
+	}
+
+	info = &DigestedDataInfo{
+		Version:         dd.Version,
+		DigestAlgorithm: newAlgorithm(dd.DigestAlgorithm.Algorithm),
+		ContentType:     dd.EncapContentInfo.EContentType,
+		Digest:          dd.Digest,
+	}
+
+	if content, ok := encapsulatedContent(dd.EncapContentInfo); ok {
+		info.Content = append([]byte(nil), content...)
+	} else {
+		info.Detached = len(dd.EncapContentInfo.EContent.FullBytes) == 0
+	}
+
+	return info, nil
+}
@@ -0,0 +1,84 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestInspectDigestedData tests the digest algorithm, digest value and content of DigestedData
+func TestInspectDigestedData(t *testing.T) {
+	content := []byte("digested content")
+	digest := sha256.Sum256(content)
+
+	tests := []struct {
+		name             string
+		data             []byte
+		expectedDigest   string
+		expectedContent  []byte
+		expectedDetached bool
+	}{
+		{
+			name:            "SHA-256",
+			data:            cmstest.DigestedData(t, cmstest.WithContent(content)),
+			expectedDigest:  "SHA-256",
+			expectedContent: content,
+		},
+		{
+			name:             "Detached SHA-1",
+			data:             cmstest.DigestedData(t, cmstest.WithDigestAlgorithm(cmstest.SHA1OID), cmstest.WithDetached()),
+			expectedDigest:   "SHA-1",
+			expectedDetached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, err := InspectDigestedData(tt.data)
+				if err != nil {
+					t.Fatalf("InspectDigestedData returned an error: %v", err)
+				}
+
+				if info.DigestAlgorithm.Name != tt.expectedDigest {
+					t.Errorf("Expected digest algorithm %s, got %s", tt.expectedDigest, info.DigestAlgorithm.Name)
+				}
+
+				if !info.ContentType.Equal(PKCS7DataOID) {
+					t.Errorf("Expected content type %v, got %v", PKCS7DataOID, info.ContentType)
+				}
+
+				if !bytes.Equal(info.Content, tt.expectedContent) {
+					t.Errorf("Expected content %q, got %q", tt.expectedContent, info.Content)
+				}
+
+				if info.Detached != tt.expectedDetached {
+					t.Errorf("Expected detached %v, got %v", tt.expectedDetached, info.Detached)
+				}
+			},
+		)
+	}
+
+	info, err := InspectDigestedData(cmstest.DigestedData(t, cmstest.WithContent(content)))
+	if err != nil {
+		t.Fatalf("InspectDigestedData returned an error: %v", err)
+	}
+
+	if !bytes.Equal(info.Digest, digest[:]) {
+		t.Errorf("Expected digest %x, got %x", digest, info.Digest)
+	}
+}
+
+// TestInspectDigestedDataErrors tests that other content types and malformed data are rejected
+func TestInspectDigestedDataErrors(t *testing.T) {
+	if _, err := InspectDigestedData(cmstest.SignedData(t)); !errors.Is(err, ErrNotDigestedData) {
+		t.Errorf("Expected %v, got %v", ErrNotDigestedData, err)
+	}
+
+	if _, err := InspectDigestedData([]byte{0x30, 0x03, 0x02, 0x01}); err == nil {
+		t.Error("Expected an error for malformed data")
+	}
+}
@@ -0,0 +1,322 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// dumpBytesPerLine is the number of bytes in a line of WriteDump
+const dumpBytesPerLine = 16
+
+// dumpMaxStringLength limits the string values shown in the annotations
+const dumpMaxStringLength = 48
+
+// universalTagNames maps universal tags to their ASN.1 type names
+var universalTagNames = map[int]string{
+	0:                       "end-of-contents",
+	asn1.TagBoolean:         "BOOLEAN",
+	asn1.TagInteger:         "INTEGER",
+	asn1.TagBitString:       "BIT STRING",
+	asn1.TagOctetString:     "OCTET STRING",
+	asn1.TagNull:            "NULL",
+	asn1.TagOID:             "OBJECT IDENTIFIER",
+	asn1.TagEnum:            "ENUMERATED",
+	asn1.TagUTF8String:      "UTF8String",
+	asn1.TagSequence:        "SEQUENCE",
+	asn1.TagSet:             "SET",
+	asn1.TagNumericString:   "NumericString",
+	asn1.TagPrintableString: "PrintableString",
+	asn1.TagT61String:       "T61String",
+	asn1.TagIA5String:       "IA5String",
+	asn1.TagUTCTime:         "UTCTime",
+	asn1.TagGeneralizedTime: "GeneralizedTime",
+	asn1.TagGeneralString:   "GeneralString",
+	asn1.TagBMPString:       "BMPString",
+}
+
+// encryptionCategories lists the categories of algorithms whose AlgorithmIdentifier precedes encrypted octets
+var encryptionCategories = map[OIDCategory]bool{
+	OIDCategoryKeyEncryption:     true,
+	OIDCategoryContentEncryption: true,
+	OIDCategoryPasswordBased:     true,
+}
+
+// WriteDump writes a hexdump of BER or DER encoded data with the ASN.1 structure annotated in the
+// margin: tag names, lengths, OID descriptions, values of integers and strings, and where
+// encrypted octets begin and end. Each element starts a new line, indented by its nesting depth.
+// A malformed encoding is annotated at its offset and the remaining bytes are dumped without
+// annotations; the returned error then describes it
+func WriteDump(w io.Writer, data []byte) error {
+	if err := checkInputSize(data); err != nil {
+		return err
+	}
+
+	d := &dumper{w: w, l: &linter{data: data}}
+
+	for pos := 0; pos < len(data) && d.err == nil; {
+		end, err := d.element(pos, len(data), 0, false)
+		if err != nil {
+			d.line(err.offset, nil, 0, "malformed: "+err.message)
+			d.hex(d.next, data[d.next:], 0, "")
+
+			if d.err != nil {
+				return d.err
+			}
+
+			return fmt.Errorf("malformed encoding at offset %d: %s", err.offset, err.message)
+		}
+
+		pos = end
+	}
+
+	return d.err
+}
+
+// dumper writes the lines of WriteDump, keeping the first write error
+type dumper struct {
+	w    io.Writer
+	l    *linter
+	next int // Offset of the first byte not written yet
+	err  error
+}
+
+// element writes the element at the offset, which must end before the limit, returning the
+// offset following it
+func (d *dumper) element(offset, limit, depth int, encrypted bool) (int, *lintError) {
+	if exceedsNestingDepth(depth) {
+		return 0, &lintError{offset: offset, message: "nesting depth exceeds the limit"}
+	}
+
+	e := &berElement{offset: offset}
+
+	pos, err := d.l.parseIdentifier(e, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	length, indefinite, pos, err := d.l.parseLength(offset, pos, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	if indefinite && !e.constructed {
+		return 0, &lintError{offset: offset, message: "indefinite length of a primitive element"}
+	}
+
+	if !indefinite && length > limit-pos {
+		return 0, &lintError{offset: offset, message: fmt.Sprintf("element of %d bytes exceeds the enclosing element or input", length)}
+	}
+
+	annotation := describeTag(e)
+
+	switch {
+	case indefinite:
+		annotation += ", indefinite length"
+	case e.constructed:
+		annotation += fmt.Sprintf(", %d bytes", length)
+	default:
+		e.contents = d.l.data[pos : pos+length]
+		annotation += describeValue(e)
+	}
+
+	if encrypted {
+		annotation += ", encrypted octets begin"
+	}
+
+	if !e.constructed {
+		d.hex(offset, d.l.data[offset:pos+length], depth, annotation)
+
+		return d.endEncrypted(pos+length, depth, encrypted), nil
+	}
+
+	d.hex(offset, d.l.data[offset:pos], depth, annotation)
+
+	end := limit
+	if !indefinite {
+		end = pos + length
+	}
+
+	encryptedChild := false
+
+	for pos < end {
+		if indefinite && end-pos >= 2 && d.l.data[pos] == 0 && d.l.data[pos+1] == 0 {
+			d.hex(pos, d.l.data[pos:pos+2], depth+1, "end-of-contents")
+
+			return d.endEncrypted(pos+2, depth, encrypted), nil
+		}
+
+		next, err := d.element(pos, end, depth+1, encryptedChild)
+		if err != nil {
+			return 0, err
+		}
+
+		// The octets following an encryption AlgorithmIdentifier are encrypted, e.g. the
+		// encryptedContent of EncryptedContentInfo or the encryptedKey of a recipient
+		encryptedChild = !encrypted && isEncryptionAlgorithm(d.l.data[pos:next])
+		pos = next
+	}
+
+	if indefinite {
+		return 0, &lintError{offset: offset, message: "missing end-of-contents octets"}
+	}
+
+	return d.endEncrypted(end, depth, encrypted), nil
+}
+
+// endEncrypted marks the end of encrypted octets at the offset, which it returns
+func (d *dumper) endEncrypted(offset, depth int, encrypted bool) int {
+	if encrypted {
+		d.line(offset, nil, depth, "encrypted octets end")
+	}
+
+	return offset
+}
+
+// hex writes the bytes at the offset in lines of dumpBytesPerLine bytes, annotating the first line
+func (d *dumper) hex(offset int, data []byte, depth int, annotation string) {
+	for len(data) > 0 {
+		n := len(data)
+		if n > dumpBytesPerLine {
+			n = dumpBytesPerLine
+		}
+
+		d.line(offset, data[:n], depth, annotation)
+		offset, data, annotation = offset+n, data[n:], ""
+	}
+}
+
+// line writes a line with the offset, the bytes in hex and the annotation indented by the depth
+func (d *dumper) line(offset int, data []byte, depth int, annotation string) {
+	if d.err != nil {
+		return
+	}
+
+	if end := offset + len(data); end > d.next {
+		d.next = end
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%08x ", offset)
+
+	for i := 0; i < dumpBytesPerLine; i++ {
+		if i < len(data) {
+			fmt.Fprintf(&b, " %02x", data[i])
+		} else {
+			b.WriteString("   ")
+		}
+	}
+
+	if annotation != "" {
+		b.WriteString("  ")
+		b.WriteString(strings.Repeat("  ", depth))
+		b.WriteString(annotation)
+	}
+
+	_, d.err = fmt.Fprintln(d.w, strings.TrimRight(b.String(), " "))
+}
+
+// describeTag returns the ASN.1 type name of a universal element or the tag of other classes
+func describeTag(e *berElement) string {
+	switch e.class {
+	case asn1.ClassUniversal:
+		if name, ok := universalTagNames[e.tag]; ok {
+			return name
+		}
+
+		return fmt.Sprintf("[UNIVERSAL %d]", e.tag)
+	case asn1.ClassApplication:
+		return fmt.Sprintf("[APPLICATION %d]", e.tag)
+	case asn1.ClassContextSpecific:
+		return fmt.Sprintf("[%d]", e.tag)
+	default:
+		return fmt.Sprintf("[PRIVATE %d]", e.tag)
+	}
+}
+
+// describeValue describes the value of a primitive element
+func describeValue(e *berElement) string {
+	if e.class != asn1.ClassUniversal {
+		return fmt.Sprintf(", %d bytes", len(e.contents))
+	}
+
+	switch e.tag {
+	case 0, asn1.TagNull:
+		return ""
+	case asn1.TagBoolean:
+		return fmt.Sprintf(" %t", len(e.contents) == 1 && e.contents[0] != 0)
+	case asn1.TagOID:
+		oid, ok := oidValue(e)
+		if !ok {
+			return ", invalid"
+		}
+
+		if info, ok := LookupOID(oid); ok {
+			return fmt.Sprintf(" %s (%s)", oid, info.Name)
+		}
+
+		if arc, ok := ClassifyOIDArc(oid); ok {
+			return fmt.Sprintf(" %s (%s)", oid, arc)
+		}
+
+		return " " + oid.String()
+	case asn1.TagInteger, asn1.TagEnum:
+		if len(e.contents) == 0 {
+			return ", invalid"
+		}
+
+		value := new(big.Int).SetBytes(e.contents)
+		if e.contents[0]&0x80 != 0 {
+			value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(e.contents)*8)))
+		}
+
+		if len(e.contents) > 8 {
+			return fmt.Sprintf(", %d bits", value.BitLen())
+		}
+
+		return " " + value.String()
+	case asn1.TagUTF8String, asn1.TagNumericString, asn1.TagPrintableString, asn1.TagIA5String,
+		asn1.TagUTCTime, asn1.TagGeneralizedTime:
+		if !utf8.Valid(e.contents) {
+			return fmt.Sprintf(", %d bytes", len(e.contents))
+		}
+
+		s := string(e.contents)
+		if utf8.RuneCountInString(s) > dumpMaxStringLength {
+			s = string([]rune(s)[:dumpMaxStringLength]) + "..."
+		}
+
+		return fmt.Sprintf(" %q", s)
+	default:
+		return fmt.Sprintf(", %d bytes", len(e.contents))
+	}
+}
+
+// isEncryptionAlgorithm checks if the encoding is an AlgorithmIdentifier of a key, content or
+// password-based encryption algorithm
+func isEncryptionAlgorithm(data []byte) bool {
+	tag, contents, _, ok := core.ReadElement(data)
+	if !ok || tag != derTagSequence {
+		return false
+	}
+
+	tag, contents, _, ok = core.ReadElement(contents)
+	if !ok || tag != derTagObjectIdentifier {
+		return false
+	}
+
+	oid, ok := oidValue(&berElement{class: asn1.ClassUniversal, tag: asn1.TagOID, contents: contents})
+	if !ok {
+		return false
+	}
+
+	info, ok := LookupOID(oid)
+
+	return ok && encryptionCategories[info.Category]
+}
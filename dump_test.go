@@ -0,0 +1,114 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestWriteDump tests the annotations of the hexdump
+func TestWriteDump(t *testing.T) {
+	encrypted := cmstest.EncryptedData(t)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name: "SignedData",
+			data: cmstest.SignedData(t),
+			expected: []string{
+				"SEQUENCE, ",
+				"OBJECT IDENTIFIER 1.2.840.113549.1.7.2 (PKCS#7 Signed Data)",
+				"INTEGER 3",
+				"OBJECT IDENTIFIER 2.16.840.1.101.3.4.2.1 (SHA-256)",
+				"NULL",
+			},
+		},
+		{
+			name: "Encryption boundaries",
+			data: encrypted,
+			expected: []string{
+				"OBJECT IDENTIFIER 2.16.840.1.101.3.4.1.42 (AES-256-CBC)",
+				"[0], 15 bytes, encrypted octets begin",
+				"encrypted octets end",
+			},
+		},
+		{
+			name:     "Indefinite length",
+			data:     toIndefiniteLength(t, encrypted),
+			expected: []string{"SEQUENCE, indefinite length", "end-of-contents"},
+		},
+		{
+			name: "Values",
+			data: []byte{
+				0x30, 0x15,
+				0x01, 0x01, 0xff,
+				0x02, 0x01, 0x80,
+				0x13, 0x02, 'R', 'U',
+				0x06, 0x05, 0x2b, 0x06, 0x01, 0x04, 0x01,
+				0x9f, 0x1f, 0x01, 0x00,
+			},
+			expected: []string{"BOOLEAN true", "INTEGER -128", `PrintableString "RU"`, "OBJECT IDENTIFIER 1.3.6.1.4.1 (", "[31], 1 bytes"},
+		},
+		{
+			name:      "Truncated",
+			data:      encrypted[:len(encrypted)-4],
+			expected:  []string{"malformed: element of 79 bytes exceeds the enclosing element or input", "00000000  30 4f 06 09"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var buf bytes.Buffer
+
+				err := WriteDump(&buf, tt.data)
+				if tt.expectErr != (err != nil) {
+					t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+				}
+
+				for _, expected := range tt.expected {
+					if !strings.Contains(buf.String(), expected) {
+						t.Errorf("Expected output to contain %q, got:\n%s", expected, buf.String())
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestWriteDumpLayout tests the offsets, hex columns and indentation of the hexdump
+func TestWriteDumpLayout(t *testing.T) {
+	data := []byte{0x30, 0x14, 0x04, 0x12, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17}
+
+	var buf bytes.Buffer
+	if err := WriteDump(&buf, data); err != nil {
+		t.Fatalf("WriteDump returned an error: %v", err)
+	}
+
+	expected := "" +
+		"00000000  30 14                                            SEQUENCE, 20 bytes\n" +
+		"00000002  04 12 00 01 02 03 04 05 06 07 08 09 0a 0b 0c 0d    OCTET STRING, 18 bytes\n" +
+		"00000012  0e 0f 10 11\n"
+
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+// TestWriteDumpLimits tests that inputs larger than MaxInputSize are rejected
+func TestWriteDumpLimits(t *testing.T) {
+	withLimits(t, Limits{MaxInputSize: 16})
+
+	var buf bytes.Buffer
+	if err := WriteDump(&buf, cmstest.SignedData(t)); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Expected %v, got %v", ErrInputTooLarge, err)
+	}
+}
@@ -0,0 +1,198 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"strings"
+)
+
+// maxEmailParts limits the total number of inspected email parts
+const maxEmailParts = 256
+
+// smimeExtensions contains file extensions of CMS attachments (RFC 8551, section 3.2.1)
+var smimeExtensions = map[string]bool{
+	".p7m": true,
+	".p7s": true,
+	".p7c": true,
+	".p7z": true,
+	".p7b": true,
+}
+
+// DetectEmail parses an RFC 822 message, locates its S/MIME parts and CMS attachments
+// (.p7m, .p7s, .p7c, ...) and detects the type of each. Parts that can't be parsed as CMS are skipped.
+func DetectEmail(r io.Reader) ([]DetectionResult, error) {
+	msg, err := mail.ReadMessage(newSizeLimitedReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email message: %w", err)
+	}
+
+	var results []DetectionResult
+
+	err = walkMIME(
+		textproto.MIMEHeader(msg.Header), msg.Body, func(part mimePart) error {
+			if !isSMIMEMediaType(part.mediaType) && !smimeExtensions[attachmentExtension(part.header, part.params)] {
+				return nil
+			}
+
+			content, err := readAll(part.body)
+			if err != nil {
+				return fmt.Errorf("failed to read email part: %w", err)
+			}
+
+			decoded, err := decodeTransferEncoding(part.header.Get("Content-Transfer-Encoding"), content)
+			if err != nil {
+				return nil
+			}
+
+			if result, err := Detect(decoded); err == nil {
+				results = append(results, result)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// mimePart is an entity of a MIME tree other than a multipart entity
+type mimePart struct {
+	index     int // Number of the entity in walk order, the message itself is 1
+	header    textproto.MIMEHeader
+	mediaType string
+	params    map[string]string
+	body      io.Reader
+}
+
+// mimeWalker passes the parts found while walking the MIME tree of an email to visit
+type mimeWalker struct {
+	parts int
+	visit func(mimePart) error
+}
+
+// walkMIME calls visit for every part of the MIME entity, descending into multipart bodies. The
+// walk stops at the first error returned by visit or at ErrInputTooLarge from the body
+func walkMIME(header textproto.MIMEHeader, body io.Reader, visit func(mimePart) error) error {
+	walker := &mimeWalker{visit: visit}
+
+	return walker.walk(header, body, 0)
+}
+
+// walk inspects the MIME entity, descending into multipart bodies
+func (w *mimeWalker) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	w.parts++
+	if w.parts > maxEmailParts {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return w.visit(mimePart{index: w.parts, header: header, mediaType: mediaType, params: params, body: body})
+	}
+
+	if exceedsNestingDepth(depth) || params["boundary"] == "" {
+		return nil
+	}
+
+	reader := multipart.NewReader(body, params["boundary"])
+
+	for {
+		part, err := reader.NextRawPart()
+		if errors.Is(err, ErrInputTooLarge) {
+			return err
+		}
+
+		if err != nil {
+			// A truncated or malformed multipart body ends the walk of this entity
+			return nil
+		}
+
+		if err := w.walk(part.Header, part, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+// attachmentExtension returns the lower-case file extension of the part's file name, if any
+func attachmentExtension(header textproto.MIMEHeader, contentTypeParams map[string]string) string {
+	return strings.ToLower(path.Ext(attachmentName(header, contentTypeParams)))
+}
+
+// attachmentName returns the base name of the part's file name, if any
+func attachmentName(header textproto.MIMEHeader, contentTypeParams map[string]string) string {
+	name := contentTypeParams["name"]
+
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = params["filename"]
+	}
+
+	if name == "" {
+		return ""
+	}
+
+	return path.Base(strings.ReplaceAll(name, "\\", "/"))
+}
+
+// isEmail checks if the data is an RFC 822 message with MIME content
+func isEmail(data []byte) bool {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+
+	return msg.Header.Get("Mime-Version") != "" && msg.Header.Get("Content-Type") != ""
+}
+
+// walkEmail calls visit for every attachment of an RFC 822 message, i.e. every part other than
+// multipart entities and text bodies without a file name. Parts exceeding the maximum allocation
+// are visited with ErrInputTooLarge
+func walkEmail(data []byte, visit func(containerEntry)) error {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse email message: %w", err)
+	}
+
+	return walkMIME(
+		textproto.MIMEHeader(msg.Header), msg.Body, func(part mimePart) error {
+			name := attachmentName(part.header, part.params)
+			if name == "" && strings.HasPrefix(part.mediaType, "text/") {
+				return nil
+			}
+
+			if name == "" {
+				name = fmt.Sprintf("part%d", part.index)
+			}
+
+			entry := containerEntry{name: name}
+
+			content, err := readAll(part.body)
+			switch {
+			case errors.Is(err, ErrInputTooLarge):
+				entry.err = err
+			case err != nil:
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			default:
+				entry.data, entry.err = decodeTransferEncoding(part.header.Get("Content-Transfer-Encoding"), content)
+				entry.size = int64(len(entry.data))
+			}
+
+			visit(entry)
+
+			return nil
+		},
+	)
+}
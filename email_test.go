@@ -0,0 +1,118 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestDetectEmail tests detection of S/MIME parts and CMS attachments in email messages
+func TestDetectEmail(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	signed := wrapBase64(createSignedData(t, sha256OID, rsaOID))
+	enveloped := wrapBase64(createEnvelopedData(t, rsaOID, aesOID))
+
+	tests := []struct {
+		name          string
+		message       string
+		expectedTypes []string
+	}{
+		{
+			name: "Enveloped message",
+			message: "From: alice@example.com\r\nTo: bob@example.com\r\nSubject: Secret\r\nMIME-Version: 1.0\r\n" +
+				"Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name=smime.p7m\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + enveloped,
+			expectedTypes: []string{"PKCS#7 Enveloped Data"},
+		},
+		{
+			name: "Signed message with attachment",
+			message: "From: alice@example.com\r\nSubject: Documents\r\nMIME-Version: 1.0\r\n" +
+				"Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; boundary=outer\r\n\r\n" +
+				"--outer\r\n" +
+				"Content-Type: multipart/mixed; boundary=inner\r\n\r\n" +
+				"--inner\r\nContent-Type: text/plain\r\n\r\nSee attached\r\n" +
+				"--inner\r\nContent-Type: application/octet-stream\r\n" +
+				"Content-Disposition: attachment; filename=\"contract.pdf.P7M\"\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + enveloped + "\r\n" +
+				"--inner\r\nContent-Type: application/pdf\r\n" +
+				"Content-Disposition: attachment; filename=\"report.pdf\"\r\n\r\n%PDF-1.7\r\n" +
+				"--inner--\r\n" +
+				"--outer\r\nContent-Type: application/pkcs7-signature; name=smime.p7s\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + signed + "\r\n" +
+				"--outer--\r\n",
+			expectedTypes: []string{"PKCS#7 Enveloped Data", "PKCS#7 Signed Data"},
+		},
+		{
+			name:          "Plain message",
+			message:       "From: alice@example.com\r\nSubject: Hello\r\n\r\nHello, Bob",
+			expectedTypes: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				results, err := DetectEmail(strings.NewReader(tt.message))
+				if err != nil {
+					t.Fatalf("DetectEmail returned an error: %v", err)
+				}
+
+				types := make([]string, 0, len(results))
+				for _, result := range results {
+					types = append(types, result.Type)
+				}
+
+				if !equalStrings(types, tt.expectedTypes) {
+					t.Errorf("Expected types %v, got %v", tt.expectedTypes, types)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectEmailInvalidMessage tests DetectEmail with data that is not an email message
+func TestDetectEmailInvalidMessage(t *testing.T) {
+	if _, err := DetectEmail(strings.NewReader("not a header line")); err == nil {
+		t.Error("Expected error for invalid message, got nil")
+	}
+}
+
+// TestWalkEmailOversizedPart tests that an attachment exceeding the maximum allocation is reported
+// with ErrInputTooLarge without ending the walk
+func TestWalkEmailOversizedPart(t *testing.T) {
+	withLimits(t, Limits{MaxAllocation: 1024})
+
+	message := "MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"frontier\"\r\n\r\n" +
+		"--frontier\r\nContent-Type: application/octet-stream\r\n" +
+		"Content-Disposition: attachment; filename=\"large.bin\"\r\n\r\n" + strings.Repeat("x", 4096) + "\r\n" +
+		"--frontier\r\nContent-Type: application/octet-stream\r\n" +
+		"Content-Disposition: attachment; filename=\"small.bin\"\r\n\r\nsmall\r\n" +
+		"--frontier--\r\n"
+
+	var entries []containerEntry
+
+	err := walkEmail(
+		[]byte(message), func(entry containerEntry) {
+			entries = append(entries, entry)
+		},
+	)
+	if err != nil {
+		t.Fatalf("walkEmail returned an error: %v", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 attachments, got %d", len(entries))
+	}
+
+	if entries[0].name != "large.bin" || !errors.Is(entries[0].err, ErrInputTooLarge) {
+		t.Errorf("Expected large.bin with ErrInputTooLarge, got %s with %v", entries[0].name, entries[0].err)
+	}
+
+	if entries[1].name != "small.bin" || string(entries[1].data) != "small" || entries[1].err != nil {
+		t.Errorf("Expected small.bin, got %s with %q and %v", entries[1].name, entries[1].data, entries[1].err)
+	}
+}
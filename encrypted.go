@@ -0,0 +1,134 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// hmacWithSHA1OID is the default pseudorandom function of PBKDF2 (RFC 8018, appendix A.2)
+var hmacWithSHA1OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+
+// ErrNotEncryptedData is returned when the data is not PKCS#7 encrypted data
+var ErrNotEncryptedData = errors.New("not a PKCS#7 encrypted data")
+
+// PBEParameters describes the parameters of password-based encryption. For PBES2 the key is
+// derived with KeyDerivation and the content encrypted with Cipher, for PBES1 and the PKCS#12
+// PBE schemes both are the scheme itself
+type PBEParameters struct {
+	Scheme        Algorithm // PBES2, a PBES1 or a PKCS#12 PBE scheme
+	KeyDerivation Algorithm
+	Cipher        Algorithm
+	PRF           Algorithm // Pseudorandom function of PBKDF2, HMAC with SHA-1 when omitted
+	Salt          []byte
+	Iterations    int
+	KeyLength     int // Key length in bytes given in the PBKDF2 parameters, 0 when omitted
+}
+
+// EncryptedDataInfo describes the contents of PKCS#7 EncryptedData
+type EncryptedDataInfo struct {
+	Version              int
+	ContentType          asn1.ObjectIdentifier // Type of the encrypted content, usually PKCS7DataOID
+	ContentEncryption    Algorithm
+	PBE                  *PBEParameters // Password-based encryption parameters, nil for other algorithms
+	EncryptedContentSize int            // Size of the encrypted content octets, 0 when detached
+}
+
+// InspectEncryptedData returns the content encryption algorithm and the password-based encryption
+// parameters of PKCS#7 EncryptedData
+func InspectEncryptedData(data []byte) (info *EncryptedDataInfo, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	if !contentInfo.ContentType.Equal(PKCS7EncryptedDataOID) {
+		return nil, ErrNotEncryptedData
+	}
+
+	ed, err := parseEncryptedData(contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted data: %w", err)
+	}
+
+	eci := ed.EncryptedContentInfo
+	info = &EncryptedDataInfo{
+		Version:              ed.Version,
+		ContentType:          eci.ContentType,
+		ContentEncryption:    newAlgorithm(eci.ContentEncryptionAlgorithm.Algorithm),
+		PBE:                  parsePBEParameters(eci.ContentEncryptionAlgorithm),
+		EncryptedContentSize: len(eci.EncryptedContent.Bytes),
+	}
+
+	return info, nil
+}
+
+// parsePBEParameters returns the parameters of a password-based encryption algorithm, or nil for
+// other algorithms and malformed parameters
+func parsePBEParameters(alg pkix.AlgorithmIdentifier) *PBEParameters {
+	switch {
+	case alg.Algorithm.Equal(pbes2OID):
+		var params pbes2Params
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil
+		}
+
+		pbe := &PBEParameters{
+			Scheme:        newAlgorithm(alg.Algorithm),
+			KeyDerivation: newAlgorithm(params.KeyDerivationFunc.Algorithm),
+			Cipher:        newAlgorithm(params.EncryptionScheme.Algorithm),
+		}
+
+		if params.KeyDerivationFunc.Algorithm.Equal(pbkdf2OID) {
+			addPBKDF2Parameters(pbe, params.KeyDerivationFunc)
+		}
+
+		return pbe
+	case hasOIDPrefix(alg.Algorithm, pbes1Arc), hasOIDPrefix(alg.Algorithm, pkcs12PBEArc):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil
+		}
+
+		scheme := newAlgorithm(alg.Algorithm)
+
+		return &PBEParameters{
+			Scheme:        scheme,
+			KeyDerivation: scheme,
+			Cipher:        scheme,
+			Salt:          params.Salt,
+			Iterations:    params.Iterations,
+		}
+	default:
+		return nil
+	}
+}
+
+// addPBKDF2Parameters adds the salt, iteration count, key length and pseudorandom function of
+// PBKDF2 parameters, malformed parameters are ignored
+func addPBKDF2Parameters(pbe *PBEParameters, kdf pkix.AlgorithmIdentifier) {
+	var params pbkdf2Params
+	if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+		return
+	}
+
+	// The salt may also be an AlgorithmIdentifier of another source, which is not reported
+	if params.Salt.Class == asn1.ClassUniversal && params.Salt.Tag == asn1.TagOctetString {
+		pbe.Salt = params.Salt.Bytes
+	}
+
+	pbe.Iterations = params.IterationCount
+	pbe.KeyLength = params.KeyLength
+	pbe.PRF = newAlgorithm(hmacWithSHA1OID)
+
+	if len(params.PRF.Algorithm) > 0 {
+		pbe.PRF = newAlgorithm(params.PRF.Algorithm)
+	}
+}
@@ -0,0 +1,122 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestInspectEncryptedData tests the content encryption algorithm and PBE parameters of EncryptedData
+func TestInspectEncryptedData(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              []byte
+		expectedAlgorithm string
+		expectedPBE       *PBEParameters
+	}{
+		{
+			name:              "AES-256-CBC",
+			data:              cmstest.EncryptedData(t),
+			expectedAlgorithm: "AES-256-CBC",
+		},
+		{
+			name:              "PBES2",
+			data:              cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.PBES2OID), cmstest.WithIterations(600000)),
+			expectedAlgorithm: "PBES2",
+			expectedPBE: &PBEParameters{
+				Scheme:        Algorithm{Name: "PBES2"},
+				KeyDerivation: Algorithm{Name: "PBKDF2"},
+				Cipher:        Algorithm{Name: "AES-256-CBC"},
+				PRF:           Algorithm{Name: "HMAC with SHA-256"},
+				Salt:          make([]byte, 8),
+				Iterations:    600000,
+			},
+		},
+		{
+			name:              "PKCS#12 PBE",
+			data:              cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.PBEWithSHAAnd3KeyTripleDESCBCOID), cmstest.WithIterations(1)),
+			expectedAlgorithm: "PBE with SHA-1 and 3-key Triple-DES-CBC",
+			expectedPBE: &PBEParameters{
+				Scheme:        Algorithm{Name: "PBE with SHA-1 and 3-key Triple-DES-CBC"},
+				KeyDerivation: Algorithm{Name: "PBE with SHA-1 and 3-key Triple-DES-CBC"},
+				Cipher:        Algorithm{Name: "PBE with SHA-1 and 3-key Triple-DES-CBC"},
+				Salt:          make([]byte, 8),
+				Iterations:    1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, err := InspectEncryptedData(tt.data)
+				if err != nil {
+					t.Fatalf("InspectEncryptedData returned an error: %v", err)
+				}
+
+				if info.ContentEncryption.Name != tt.expectedAlgorithm {
+					t.Errorf("Expected content encryption %s, got %s", tt.expectedAlgorithm, info.ContentEncryption.Name)
+				}
+
+				if info.EncryptedContentSize == 0 {
+					t.Error("Expected the size of the encrypted content")
+				}
+
+				if tt.expectedPBE == nil {
+					if info.PBE != nil {
+						t.Errorf("Expected no PBE parameters, got %+v", info.PBE)
+					}
+
+					return
+				}
+
+				if info.PBE == nil {
+					t.Fatal("Expected PBE parameters, got nil")
+				}
+
+				assertPBEParameters(t, tt.expectedPBE, info.PBE)
+			},
+		)
+	}
+}
+
+// assertPBEParameters compares the PBE parameters, ignoring the algorithm OIDs
+func assertPBEParameters(t *testing.T, expected, actual *PBEParameters) {
+	t.Helper()
+
+	for _, pair := range [][2]Algorithm{
+		{expected.Scheme, actual.Scheme},
+		{expected.KeyDerivation, actual.KeyDerivation},
+		{expected.Cipher, actual.Cipher},
+		{expected.PRF, actual.PRF},
+	} {
+		if pair[0].Name != pair[1].Name {
+			t.Errorf("Expected algorithm %q, got %q", pair[0].Name, pair[1].Name)
+		}
+	}
+
+	if !bytes.Equal(expected.Salt, actual.Salt) {
+		t.Errorf("Expected salt %x, got %x", expected.Salt, actual.Salt)
+	}
+
+	if expected.Iterations != actual.Iterations {
+		t.Errorf("Expected %d iterations, got %d", expected.Iterations, actual.Iterations)
+	}
+
+	if expected.KeyLength != actual.KeyLength {
+		t.Errorf("Expected key length %d, got %d", expected.KeyLength, actual.KeyLength)
+	}
+}
+
+// TestInspectEncryptedDataErrors tests that other content types and malformed data are rejected
+func TestInspectEncryptedDataErrors(t *testing.T) {
+	if _, err := InspectEncryptedData(cmstest.EnvelopedData(t)); !errors.Is(err, ErrNotEncryptedData) {
+		t.Errorf("Expected %v, got %v", ErrNotEncryptedData, err)
+	}
+
+	if _, err := InspectEncryptedData([]byte{0x30, 0x03, 0x02, 0x01}); err == nil {
+		t.Error("Expected an error for malformed data")
+	}
+}
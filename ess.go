@@ -0,0 +1,141 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// ESS signing certificate attribute OIDs (RFC 2634, RFC 5035)
+var (
+	SigningCertificateAttributeOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+	SigningCertificateV2AttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+)
+
+// Default hash algorithms of the certificate hashes in ESS signing certificate attributes
+var (
+	essSHA1OID   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	essSHA256OID = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+)
+
+// SigningCertificateInfo describes the ESS signing certificate attribute of a signer
+type SigningCertificateInfo struct {
+	Present       bool      // Indicates a signingCertificate or signingCertificateV2 signed attribute
+	Version       int       // 1 for signingCertificate, 2 for signingCertificateV2
+	HashAlgorithm Algorithm // Hash of the signing certificate: always SHA-1 in version 1, SHA-256 by default in version 2
+	CertHash      []byte    // Hash of the signing certificate, the first of the referenced certificates
+	Certificates  int       // Number of referenced certificates, the signing certificate first
+}
+
+// essSigningCertificate provides the ASN.1 structure of SigningCertificate and SigningCertificateV2,
+// the certificate identifiers differ between the versions
+type essSigningCertificate struct {
+	Certs    []asn1.RawValue
+	Policies asn1.RawValue `asn1:"optional"`
+}
+
+// essCertID provides the ASN.1 structure of ESSCertID (RFC 2634, section 5.4.1)
+type essCertID struct {
+	CertHash     []byte
+	IssuerSerial asn1.RawValue `asn1:"optional"`
+}
+
+// essCertIDv2 provides the ASN.1 structure of ESSCertIDv2 with an explicit hash algorithm (RFC 5035, section 4)
+type essCertIDv2 struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	CertHash      []byte
+	IssuerSerial  asn1.RawValue `asn1:"optional"`
+}
+
+// SigningCertificates reports the ESS signingCertificate or signingCertificateV2 attribute of each
+// signer of the SignedData, in the order of the SignerInfos. Signers without the attribute are
+// reported with Present set to false. When a signer has both attributes, version 2 is reported
+func SigningCertificates(data []byte) (infos []SigningCertificateInfo, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.SignedAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed attributes: %w", err)
+		}
+
+		info, err := signingCertificate(attrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// signingCertificate parses the ESS signing certificate attribute of a signer, preferring version 2
+func signingCertificate(attrs []attribute) (SigningCertificateInfo, error) {
+	version := 2
+
+	values := attributeValues(attrs, SigningCertificateV2AttributeOID)
+	if len(values) == 0 {
+		version = 1
+		values = attributeValues(attrs, SigningCertificateAttributeOID)
+	}
+
+	if len(values) == 0 {
+		return SigningCertificateInfo{}, nil
+	}
+
+	var attr essSigningCertificate
+	if _, err := asn1.Unmarshal(values[0].FullBytes, &attr); err != nil {
+		return SigningCertificateInfo{}, err
+	}
+
+	info := SigningCertificateInfo{Present: true, Version: version, Certificates: len(attr.Certs)}
+	if len(attr.Certs) == 0 {
+		return info, nil
+	}
+
+	if version == 1 {
+		var certID essCertID
+		if _, err := asn1.Unmarshal(attr.Certs[0].FullBytes, &certID); err != nil {
+			return SigningCertificateInfo{}, err
+		}
+
+		info.HashAlgorithm = newAlgorithm(essSHA1OID)
+		info.CertHash = certID.CertHash
+
+		return info, nil
+	}
+
+	// The hash algorithm is omitted when it is the default SHA-256
+	var first asn1.RawValue
+	if _, err := asn1.Unmarshal(attr.Certs[0].Bytes, &first); err != nil {
+		return SigningCertificateInfo{}, err
+	}
+
+	if first.Tag == asn1.TagOctetString {
+		var certID essCertID
+		if _, err := asn1.Unmarshal(attr.Certs[0].FullBytes, &certID); err != nil {
+			return SigningCertificateInfo{}, err
+		}
+
+		info.HashAlgorithm = newAlgorithm(essSHA256OID)
+		info.CertHash = certID.CertHash
+
+		return info, nil
+	}
+
+	var certID essCertIDv2
+	if _, err := asn1.Unmarshal(attr.Certs[0].FullBytes, &certID); err != nil {
+		return SigningCertificateInfo{}, err
+	}
+
+	info.HashAlgorithm = newAlgorithm(certID.HashAlgorithm.Algorithm)
+	info.CertHash = certID.CertHash
+
+	return info, nil
+}
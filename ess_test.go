@@ -0,0 +1,121 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+// TestSigningCertificates tests the detection of ESS signing certificate attributes
+func TestSigningCertificates(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	gostDigestOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 3, 1}
+	hash := bytes.Repeat([]byte{0xAB}, 32)
+
+	type certsV1 struct {
+		Certs []essCertID
+	}
+
+	type certsV2 struct {
+		Certs []essCertIDv2
+	}
+
+	type certsV2Default struct {
+		Certs []essCertID
+	}
+
+	tests := []struct {
+		name      string
+		attrs     []attribute
+		expected  SigningCertificateInfo
+		expectErr bool
+	}{
+		{
+			name:     "No attribute",
+			attrs:    []attribute{createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)},
+			expected: SigningCertificateInfo{},
+		},
+		{
+			name:  "Version 1",
+			attrs: []attribute{createAttribute(t, SigningCertificateAttributeOID, certsV1{Certs: []essCertID{{CertHash: hash[:20]}}})},
+			expected: SigningCertificateInfo{
+				Present: true, Version: 1, HashAlgorithm: newAlgorithm(essSHA1OID), CertHash: hash[:20], Certificates: 1,
+			},
+		},
+		{
+			name:  "Version 2 with default SHA-256",
+			attrs: []attribute{createAttribute(t, SigningCertificateV2AttributeOID, certsV2Default{Certs: []essCertID{{CertHash: hash}}})},
+			expected: SigningCertificateInfo{
+				Present: true, Version: 2, HashAlgorithm: newAlgorithm(essSHA256OID), CertHash: hash, Certificates: 1,
+			},
+		},
+		{
+			name: "Version 2 with GOST hash and chain",
+			attrs: []attribute{
+				createAttribute(
+					t, SigningCertificateV2AttributeOID, certsV2{
+						Certs: []essCertIDv2{
+							{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: gostDigestOID}, CertHash: hash},
+							{HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: gostDigestOID}, CertHash: hash[:8]},
+						},
+					},
+				),
+			},
+			expected: SigningCertificateInfo{
+				Present: true, Version: 2, HashAlgorithm: newAlgorithm(gostDigestOID), CertHash: hash, Certificates: 2,
+			},
+		},
+		{
+			name: "Both versions",
+			attrs: []attribute{
+				createAttribute(t, SigningCertificateAttributeOID, certsV1{Certs: []essCertID{{CertHash: hash[:20]}}}),
+				createAttribute(t, SigningCertificateV2AttributeOID, certsV2Default{Certs: []essCertID{{CertHash: hash}}}),
+			},
+			expected: SigningCertificateInfo{
+				Present: true, Version: 2, HashAlgorithm: newAlgorithm(essSHA256OID), CertHash: hash, Certificates: 1,
+			},
+		},
+		{
+			name:      "Malformed",
+			attrs:     []attribute{createAttribute(t, SigningCertificateV2AttributeOID, 42)},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := createSignedDataWithSigners(t, []signerInfo{createSignerInfo(t, sha256OID, rsaOID, tt.attrs, nil)})
+
+				infos, err := SigningCertificates(data)
+				if (err != nil) != tt.expectErr {
+					t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+				}
+
+				if tt.expectErr {
+					return
+				}
+
+				if len(infos) != 1 {
+					t.Fatalf("Expected 1 signer, got %d", len(infos))
+				}
+
+				info := infos[0]
+				if info.Present != tt.expected.Present || info.Version != tt.expected.Version || info.Certificates != tt.expected.Certificates {
+					t.Errorf("Expected %+v, got %+v", tt.expected, info)
+				}
+
+				if !info.HashAlgorithm.OID.Equal(tt.expected.HashAlgorithm.OID) || !bytes.Equal(info.CertHash, tt.expected.CertHash) {
+					t.Errorf("Expected hash %s %x, got %s %x", tt.expected.HashAlgorithm.Name, tt.expected.CertHash, info.HashAlgorithm.Name, info.CertHash)
+				}
+			},
+		)
+	}
+
+	if _, err := SigningCertificates(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+}
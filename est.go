@@ -0,0 +1,57 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"strings"
+)
+
+// smimeTypeCertsOnly is the smime-type of the degenerate SignedData returned by EST servers (RFC 7030, section 4.1.3)
+const smimeTypeCertsOnly = "certs-only"
+
+// ErrNotEST is returned when an HTTP response is not an EST certs-only response
+var ErrNotEST = errors.New("not an EST certs-only response")
+
+// DetectEST detects an EST certs-only response, as returned by the /cacerts, /simpleenroll and
+// /simplereenroll operations, from the Content-Type header of the HTTP response and its body.
+// The body is base64 encoded as required by RFC 7030, DER bodies are accepted as well.
+// Certs-only SignedData cannot be told apart from a .p7b file by its content alone, so Detect and
+// DetectAny report it as KindSignedData
+func DetectEST(contentType string, body []byte) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(body); err != nil {
+		return DetectionResult{}, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || normalizeSMIMEMediaType(mediaType) != MediaTypePKCS7MIME ||
+		!strings.EqualFold(params["smime-type"], smimeTypeCertsOnly) {
+		return DetectionResult{}, ErrNotEST
+	}
+
+	der := bytes.TrimSpace(body)
+	if len(der) > 0 && der[0] != 0x30 {
+		if der, err = decodeTransferEncoding("base64", der); err != nil {
+			return DetectionResult{}, ErrNotEST
+		}
+	}
+
+	result, err = detect(der)
+	if err != nil || result.Kind != KindSignedData || !isCertsOnly(der) {
+		return DetectionResult{}, ErrNotEST
+	}
+
+	result.Kind = KindESTCertsOnly
+	result.Type = result.Kind.String()
+
+	return result, nil
+}
+
+// isCertsOnly checks if the data is degenerate SignedData carrying certificates and no signers
+func isCertsOnly(data []byte) bool {
+	sd, err := loadSignedData(data)
+
+	return err == nil && len(sd.SignerInfos) == 0 && len(sd.Certificates.Bytes) > 0
+}
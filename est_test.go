@@ -0,0 +1,72 @@
+package cmsdetector
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestDetectEST tests detection of EST certs-only responses
+func TestDetectEST(t *testing.T) {
+	certsOnly := createSignedDataWithSigners(t, nil, cmstest.Certificate(t, "EST CA"))
+	encoded := base64.StdEncoding.EncodeToString(certsOnly)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		err         error
+	}{
+		{
+			name:        "Base64 body",
+			contentType: "application/pkcs7-mime; smime-type=certs-only",
+			body:        []byte(encoded[:64] + "\r\n" + encoded[64:] + "\r\n"),
+		},
+		{
+			name:        "DER body with legacy media type",
+			contentType: "application/x-pkcs7-mime; smime-type=CERTS-ONLY",
+			body:        certsOnly,
+		},
+		{
+			name:        "Missing smime-type",
+			contentType: "application/pkcs7-mime",
+			body:        []byte(encoded),
+			err:         ErrNotEST,
+		},
+		{
+			name:        "Signed data",
+			contentType: "application/pkcs7-mime; smime-type=certs-only",
+			body:        createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			err:         ErrNotEST,
+		},
+		{
+			name:        "No certificates",
+			contentType: "application/pkcs7-mime; smime-type=certs-only",
+			body:        createSignedDataWithSigners(t, nil),
+			err:         ErrNotEST,
+		},
+		{
+			name:        "Invalid base64",
+			contentType: "application/pkcs7-mime; smime-type=certs-only",
+			body:        []byte("not base64!"),
+			err:         ErrNotEST,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectEST(tt.contentType, tt.body)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Expected error %v, got %v", tt.err, err)
+				}
+
+				if tt.err == nil && (result.Kind != KindESTCertsOnly || result.Type != "EST Certs-Only Response") {
+					t.Errorf("Expected %v, got %v (%s)", KindESTCertsOnly, result.Kind, result.Type)
+				}
+			},
+		)
+	}
+}
@@ -28,7 +28,7 @@ func Example_detect() {
 	fmt.Printf("Content type OID: %s\n", result.ContentType.String())
 
 	// Output:
-	// Error detecting format: failed to parse ASN.1 structure: asn1: structure error: tags don't match (16 vs {class:1 tag:20 length:104 isCompound:false}) {optional:false explicit:false application:false private:false defaultValue:<nil> tag:<nil> stringType:0 timeType:0 set:false omitEmpty:false} ContentInfo @2
+	// Error detecting format: failed to parse ASN.1 structure: asn1: structure error: tags don't match (16 vs {class:1 tag:20 length:104 isCompound:false}) {optional:false explicit:false application:false private:false defaultValue:<nil> tag:<nil> stringType:0 timeType:0 set:false omitEmpty:false} ContentInfo @2 (this looks like base64 encoded data)
 }
 
 // ExampleFileDetection demonstrates how to detect the format of a file
@@ -0,0 +1,46 @@
+package cmsdetector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Fingerprint returns a stable hash of the structure of CMS data: the content types of its layers as
+// reported by InspectLayers and the sizes of the innermost content, but not signatures, certificates
+// or attributes. The same document signed again, by other signers or at another time, has the same
+// fingerprint, so that deduplication systems can group such variants. Kinds are determined by the
+// content types and not hashed, so fingerprints do not change when detection improves
+func Fingerprint(data []byte) (fingerprint string, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return "", err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	h := sha256.New()
+	writeLayer(h, inspectLayer(contentInfo, 0), 0)
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeLayer writes the canonical form of the layer and its nested layers, one line per layer.
+// Sizes of layers wrapping other layers change with their signatures and are omitted
+func writeLayer(w io.Writer, layer Layer, depth int) {
+	var size int64
+	if len(layer.Layers) == 0 {
+		size = layer.Size
+	}
+
+	fmt.Fprintf(w, "%d %s %t %t %d\n", depth, layer.ContentType, layer.Encrypted, layer.Detached, size)
+
+	for _, inner := range layer.Layers {
+		writeLayer(w, inner, depth+1)
+	}
+}
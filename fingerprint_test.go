@@ -0,0 +1,94 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestFingerprint tests that fingerprints group structures differing only in their signatures
+func TestFingerprint(t *testing.T) {
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	octets, err := asn1.Marshal([]byte("content"))
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	signer := createSignerInfo(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, nil, nil)
+	resigned := createContentInfo(
+		t, PKCS7SignedDataOID, signedData{
+			Version:          1,
+			DigestAlgorithms: []pkix.AlgorithmIdentifier{signer.DigestAlgorithm},
+			EncapContentInfo: encapsulatedContentInfo{
+				EContentType: PKCS7DataOID,
+				EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets},
+			},
+			SignerInfos: []signerInfo{signer, signer},
+		},
+	)
+
+	signed := createSignedDataWithContent(t, []byte("content"))
+
+	tests := []struct {
+		name  string
+		data  []byte
+		equal bool
+	}{
+		{
+			name:  "Re-signed content",
+			data:  resigned,
+			equal: true,
+		},
+		{
+			name: "Other content size",
+			data: createSignedDataWithContent(t, []byte("other content")),
+		},
+		{
+			name: "Detached signature",
+			data: createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+		},
+		{
+			name: "Enveloped content",
+			data: createEnvelopedData(t, rsaEncryptionOID, aesOID),
+		},
+		{
+			name: "Data",
+			data: createContentInfo(t, PKCS7DataOID, []byte("content")),
+		},
+	}
+
+	expected, err := Fingerprint(signed)
+	if err != nil {
+		t.Fatalf("Fingerprint returned an error: %v", err)
+	}
+
+	// The canonical form must not change between releases
+	if golden := "535ef7e9d52bdc36ad42d874726da498391e9dca579f743e65f05b3b19f6377c"; expected != golden {
+		t.Errorf("Expected fingerprint %s, got %s", golden, expected)
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				fingerprint, err := Fingerprint(tt.data)
+				if err != nil {
+					t.Fatalf("Fingerprint returned an error: %v", err)
+				}
+
+				if (fingerprint == expected) != tt.equal {
+					t.Errorf("Expected equal fingerprints %v, got %s and %s", tt.equal, fingerprint, expected)
+				}
+			},
+		)
+	}
+}
+
+// TestFingerprintInvalidData tests that data which is not a ContentInfo is rejected
+func TestFingerprintInvalidData(t *testing.T) {
+	if _, err := Fingerprint([]byte("not a structure")); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
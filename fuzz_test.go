@@ -0,0 +1,314 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corpusDir contains real-world structures created by testdata/corpus/generate.sh
+const corpusDir = "testdata/corpus"
+
+// readCorpus returns the structures of the corpus by file name
+func readCorpus(tb testing.TB) map[string][]byte {
+	tb.Helper()
+
+	entries, err := os.ReadDir(corpusDir)
+	if err != nil {
+		tb.Fatalf("Failed to read corpus: %v", err)
+	}
+
+	corpus := make(map[string][]byte, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".sh") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(corpusDir, entry.Name()))
+		if err != nil {
+			tb.Fatalf("Failed to read %s: %v", entry.Name(), err)
+		}
+
+		corpus[entry.Name()] = data
+	}
+
+	return corpus
+}
+
+// addSeeds adds the corpus and the structures created by the test helpers to the seed corpus
+func addSeeds(f *testing.F) {
+	f.Helper()
+
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	for _, data := range readCorpus(f) {
+		f.Add(data)
+	}
+
+	seeds := [][]byte{
+		createSignedData(f, sha256OID, rsaOID),
+		createEnvelopedData(f, rsaOID, aesOID),
+		createTestData(f, PKCS7DataOID),
+		createWindowsCatalog(f),
+		createPFX(f, nil, nil),
+		createMockPKCS12Key(f),
+		createJavaKeystore(jksMagic, 2, 1),
+		createBKS(),
+		createOpenSSHPrivateKey("none"),
+		createSSHPublicKeyBlob(),
+		{},
+		{0x30, 0x80},
+		{0x30, 0x84, 0xFF, 0xFF, 0xFF, 0xFF},
+	}
+
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+}
+
+// FuzzDetect checks that the CMS/PKCS detection functions do not panic and agree with each other
+func FuzzDetect(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			result, err := Detect(data)
+
+			// A prefix covering the whole input and a small io.ReaderAt input are passed to Detect
+			prefixResult, prefixErr := DetectPrefix(data, int64(len(data)))
+			if (err == nil) != (prefixErr == nil) || result.Kind != prefixResult.Kind {
+				t.Errorf("DetectPrefix returned %v, %v; Detect returned %v, %v", prefixResult.Kind, prefixErr, result.Kind, err)
+			}
+
+			readerResult, readerErr := DetectReaderAt(bytes.NewReader(data), int64(len(data)))
+			if (err == nil) != (readerErr == nil) || result.Kind != readerResult.Kind {
+				t.Errorf("DetectReaderAt returned %v, %v; Detect returned %v, %v", readerResult.Kind, readerErr, result.Kind, err)
+			}
+
+			// Every prefix of a larger input must be handled
+			for n := 0; n < len(data); n++ {
+				_, _ = DetectPrefix(data[:n], int64(len(data))+1)
+			}
+
+			// Data accepted by Detect must not be rejected from any of its prefixes
+			if err == nil {
+				for n := 0; n <= len(data); n++ {
+					if IsDefinitelyNotCMS(data[:n]) {
+						t.Errorf("IsDefinitelyNotCMS rejected a %d byte prefix of %v", n, result.Kind)
+					}
+				}
+			}
+
+			_ = KindOf(data)
+			_, _ = DetectString(string(data))
+		},
+	)
+}
+
+// FuzzReadContentInfo checks that the hand-rolled ContentInfo reader agrees with encoding/asn1
+func FuzzReadContentInfo(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			checkReadContentInfo(t, data)
+		},
+	)
+}
+
+// FuzzIsFunctions checks that the Is* helpers do not panic and agree with KindOf
+func FuzzIsFunctions(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			kind := KindOf(data)
+
+			if IsPKCS7Data(data) != (kind == KindData) {
+				t.Errorf("IsPKCS7Data disagrees with KindOf %v", kind)
+			}
+
+			if IsPKCS7EnvelopedData(data) != (kind == KindEnvelopedData) {
+				t.Errorf("IsPKCS7EnvelopedData disagrees with KindOf %v", kind)
+			}
+
+			if IsPKCS7SignedAndEnvelopedData(data) != (kind == KindSignedAndEnvelopedData) {
+				t.Errorf("IsPKCS7SignedAndEnvelopedData disagrees with KindOf %v", kind)
+			}
+
+			if IsPKCS7DigestedData(data) != (kind == KindDigestedData) {
+				t.Errorf("IsPKCS7DigestedData disagrees with KindOf %v", kind)
+			}
+
+			if IsPKCS7EncryptedData(data) != (kind == KindEncryptedData) {
+				t.Errorf("IsPKCS7EncryptedData disagrees with KindOf %v", kind)
+			}
+
+			_ = IsPKCS7SignedData(data)
+			_ = IsWindowsCatalog(data)
+			_ = IsPKCS12(data)
+			_ = IsUserKeyPKCS12(data)
+			_ = IsTumarKeyContainer(data)
+
+			for _, profile := range []KeyContainerProfile{KeyContainerGeneric, KeyContainerNCA, KeyContainerTumar} {
+				_ = (&KeyContainerDetector{Profile: profile}).IsKeyContainer(data)
+			}
+		},
+	)
+}
+
+// FuzzInspect checks that the functions inspecting the content of CMS/PKCS structures do not panic
+func FuzzInspect(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			_, _ = InspectAlgorithms(data)
+			_, _ = InspectKeys(data)
+			_, _ = SigningTimes(data)
+			_, _ = SigningCertificates(data)
+			_, _ = AlgorithmProtections(data)
+			_, _ = InspectLTV(data)
+			_, _, _ = ExtractRevocationData(data)
+			_, _ = CountSignatures(data)
+			_, _ = SignatureFamily(data)
+			_, _ = DetectSMIME(data)
+			_, _ = DetectSCEP(data)
+			_, _ = InspectDigestedData(data)
+			_, _ = InspectEncryptedData(data)
+			_, _ = DecryptionRequirements(data)
+			_, _ = InspectKeyPackage(data)
+			_, _ = InspectLayers(data)
+			_, _ = Fingerprint(data)
+			_, _ = Capabilities(data)
+			_, _ = DetectPrivateKey(data)
+			_, _ = PKCS12Bags(data)
+			_, _ = PKCS12Certificates(data)
+			_, _ = NCAKeyMetadata(data)
+			_, _ = CheckNCAProfile(data)
+			_, _ = CheckCAdESBaseline(data, CAdESBaselineLTA)
+			_, _ = OpenPKCS12(data, "")
+			_ = Lint(data)
+			_ = WriteDump(io.Discard, data)
+		},
+	)
+}
+
+// FuzzDetectAny checks that the detection of every supported format family does not panic
+func FuzzDetectAny(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			_, _ = DetectAny(data)
+			_, _ = NewDetector(WithStrictness(StrictnessLenient)).DetectAny(data)
+			_, _ = NewDetector(WithStrictness(StrictnessStrict)).DetectAny(data)
+			_, _ = DetectKeystore(data)
+			_, _ = DetectSSHKey(data)
+			_, _ = DetectJOSE(data)
+			_, _ = DetectXMLSignature(data)
+			_, _ = DetectPDFSignatures(data)
+			_, _ = DetectArchive(bytes.NewReader(data), int64(len(data)))
+			_ = (&Scanner{Unpack: true}).Classify("fuzz", data)
+			_, _ = DetectCOSE(data)
+			_, _ = DetectEST("application/pkcs7-mime; smime-type=certs-only", data)
+			_ = Hints(data)
+		},
+	)
+}
+
+// FuzzUnmarshalBinary checks that decoding protobuf encoded results received over RPC does not panic
+func FuzzUnmarshalBinary(f *testing.F) {
+	for _, value := range []interface{ MarshalBinary() ([]byte, error) }{
+		DetectionResult{Type: "PKCS#7 Signed Data", Kind: KindSignedData, ContentType: PKCS7SignedDataOID},
+		AnyResult{Family: FamilyCMS, Kind: KindSignedData, Confidence: ConfidenceHigh, PEMType: "PKCS7"},
+		AlgorithmReport{Digest: []Algorithm{{OID: PKCS7DataOID, Name: "Data"}}, Weak: []Finding{{Reason: reasonWeakDigest}}},
+		SignatureCounts{Signers: 1, CounterSignatures: 2},
+	} {
+		data, err := value.MarshalBinary()
+		if err != nil {
+			f.Fatalf("MarshalBinary returned an error: %v", err)
+		}
+
+		f.Add(data)
+	}
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			_ = new(DetectionResult).UnmarshalBinary(data)
+			_ = new(AnyResult).UnmarshalBinary(data)
+			_ = new(AlgorithmReport).UnmarshalBinary(data)
+			_ = new(SignatureCounts).UnmarshalBinary(data)
+		},
+	)
+}
+
+// FuzzUnmarshalText checks that decoding the text encoding of results does not panic and that
+// decoded results survive a round trip
+func FuzzUnmarshalText(f *testing.F) {
+	f.Add([]byte(`kind="PKCS#7 Signed Data" content_type=1.2.840.113549.1.7.2 content_size=13 header=3080`))
+	f.Add([]byte(`kind=Unknown type="Time-Stamp Token Info" payload="say \"hi\""`))
+
+	f.Fuzz(
+		func(t *testing.T, data []byte) {
+			var result DetectionResult
+			if err := result.UnmarshalText(data); err != nil {
+				return
+			}
+
+			text, err := result.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText returned an error: %v", err)
+			}
+
+			var decoded DetectionResult
+			if err := decoded.UnmarshalText(text); err != nil || decoded.String() != string(text) {
+				t.Errorf("Round trip of %s returned %s, %v", text, decoded, err)
+			}
+		},
+	)
+}
+
+// TestCorpus tests detection of the real-world structures of the corpus
+func TestCorpus(t *testing.T) {
+	expectedKinds := map[string]Kind{
+		"certificate.cer":  KindCertificate,
+		"certificates.p7b": KindSignedData,
+		"data.p7":          KindData,
+		"detached.p7s":     KindSignedData,
+		"digested.p7":      KindDigestedData,
+		"encrypted-key.p8": KindEncryptedPrivateKey,
+		"encrypted.p7e":    KindEncryptedData,
+		"enveloped.p7m":    KindEnvelopedData,
+		"id_ed25519":       KindOpenSSHPrivateKey,
+		"id_ed25519.pub":   KindSSHPublicKey,
+		"keystore.p12":     KindPKCS12,
+		"private-key.p8":   KindPrivateKey,
+		"request.csr":      KindCertificateRequest,
+		"signed.p7s":       KindSignedData,
+	}
+
+	corpus := readCorpus(t)
+	if len(corpus) != len(expectedKinds) {
+		t.Errorf("Expected %d corpus files, got %d", len(expectedKinds), len(corpus))
+	}
+
+	for name, data := range corpus {
+		result, err := DetectAny(data)
+		if err != nil {
+			t.Errorf("%s: DetectAny returned an error: %v", name, err)
+			continue
+		}
+
+		if result.Kind != expectedKinds[name] {
+			t.Errorf("%s: Expected kind %v, got %v", name, expectedKinds[name], result.Kind)
+		}
+	}
+}
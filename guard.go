@@ -0,0 +1,38 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrPanic is returned instead of panicking when panic recovery is enabled
+var ErrPanic = errors.New("panic while inspecting input")
+
+// panicRecovery is non-zero when panic recovery is enabled
+var panicRecovery int32
+
+// SetPanicRecovery enables converting panics in Detect, DetectAny, DetectReaderAt, DetectPrefix and
+// InspectAlgorithms into errors wrapping ErrPanic. The detection functions must not panic on any input
+// and are fuzzed to ensure it; recovery is a safety net for services that must not crash on inputs
+// triggering an unknown bug, and is disabled by default so that such bugs are not hidden
+func SetPanicRecovery(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+
+	atomic.StoreInt32(&panicRecovery, value)
+}
+
+// recoverPanic converts a panic into an error wrapping ErrPanic when panic recovery is enabled.
+// It must be deferred directly by the guarded function
+func recoverPanic(err *error) {
+	if atomic.LoadInt32(&panicRecovery) == 0 {
+		return
+	}
+
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrPanic, r)
+	}
+}
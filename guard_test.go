@@ -0,0 +1,47 @@
+package cmsdetector
+
+import (
+	"errors"
+	"testing"
+)
+
+// panickingMetrics panics when an input size is observed
+type panickingMetrics struct {
+	recordingMetrics
+}
+
+// ObserveInputSize implements Metrics
+func (m *panickingMetrics) ObserveInputSize(int) {
+	panic("observer failed")
+}
+
+// TestPanicRecovery tests conversion of panics into errors
+func TestPanicRecovery(t *testing.T) {
+	SetMetrics(&panickingMetrics{})
+	t.Cleanup(
+		func() {
+			SetMetrics(nil)
+			SetPanicRecovery(false)
+		},
+	)
+
+	SetPanicRecovery(true)
+
+	if _, err := Detect([]byte("data")); !errors.Is(err, ErrPanic) {
+		t.Errorf("Expected Detect error %v, got %v", ErrPanic, err)
+	}
+
+	if _, err := DetectAny([]byte("data")); !errors.Is(err, ErrPanic) {
+		t.Errorf("Expected DetectAny error %v, got %v", ErrPanic, err)
+	}
+
+	SetPanicRecovery(false)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic with recovery disabled")
+		}
+	}()
+
+	_, _ = Detect([]byte("data"))
+}
@@ -0,0 +1,166 @@
+// Package heuristics matches byte patterns and size ranges of data that cannot be recognized by its
+// ASN.1 structure alone, such as key containers of national crypto providers. Heuristics are
+// composed with Chain and Any, and can be tuned and tested apart from the OID-based detection of
+// cmsdetector, which builds its key container checks on this package:
+//
+//	container := heuristics.Chain{
+//		heuristics.Pattern{Bytes: []byte{0x30}, Anchored: true},
+//		heuristics.SizeRange{Min: 100, Max: 100000},
+//		heuristics.Pattern{Bytes: []byte("Tumar"), UTF16: true, Window: 8192},
+//	}
+//
+//	if container.Match(data) {
+//		...
+//	}
+package heuristics
+
+import (
+	"bytes"
+	"unicode/utf16"
+)
+
+// Heuristic checks if data has a trait of a format
+type Heuristic interface {
+	Match(data []byte) bool
+}
+
+// Pattern matches data containing a byte sequence
+type Pattern struct {
+	Bytes    []byte
+	Offset   int  // Position of the bytes when Anchored
+	Anchored bool // Only match the bytes at Offset instead of anywhere in the window
+	Window   int  // Number of leading bytes searched, zero searches the whole data
+	UTF16    bool // Also match the bytes as ASCII text encoded in UTF-16BE, like BMPString values
+}
+
+// Match checks if the data contains the pattern
+func (p Pattern) Match(data []byte) bool {
+	if p.Window > 0 && len(data) > p.Window {
+		data = data[:p.Window]
+	}
+
+	if p.match(data, p.Bytes) {
+		return true
+	}
+
+	if !p.UTF16 {
+		return false
+	}
+
+	if isASCII(p.Bytes) {
+		return p.matchUTF16(data)
+	}
+
+	return p.match(data, encodeUTF16(p.Bytes))
+}
+
+// match checks if the data contains the bytes at the offset, or anywhere when not anchored
+func (p Pattern) match(data, b []byte) bool {
+	if !p.Anchored {
+		return bytes.Contains(data, b)
+	}
+
+	return p.Offset >= 0 && p.Offset <= len(data)-len(b) && bytes.Equal(data[p.Offset:p.Offset+len(b)], b)
+}
+
+// matchUTF16 checks if the data contains the ASCII bytes encoded in UTF-16BE like match, without
+// allocating the encoding. Candidates are found by the first character, the low byte of its code unit
+func (p Pattern) matchUTF16(data []byte) bool {
+	if len(p.Bytes) == 0 {
+		return p.match(data, nil)
+	}
+
+	if p.Anchored {
+		return p.Offset >= 0 && isUTF16At(data, p.Offset, p.Bytes)
+	}
+
+	for offset := 1; offset < len(data); {
+		i := bytes.IndexByte(data[offset:], p.Bytes[0])
+		if i < 0 {
+			return false
+		}
+
+		if isUTF16At(data, offset+i-1, p.Bytes) {
+			return true
+		}
+
+		offset += i + 1
+	}
+
+	return false
+}
+
+// isUTF16At checks if the ASCII text is encoded in UTF-16BE at the offset of the data
+func isUTF16At(data []byte, offset int, text []byte) bool {
+	if offset > len(data)-2*len(text) {
+		return false
+	}
+
+	for i, c := range text {
+		if data[offset+2*i] != 0 || data[offset+2*i+1] != c {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isASCII checks if the bytes are ASCII characters
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encodeUTF16 encodes ASCII text in UTF-16BE
+func encodeUTF16(text []byte) []byte {
+	encoded := make([]byte, 0, len(text)*2)
+	for _, r := range utf16.Encode([]rune(string(text))) {
+		encoded = append(encoded, byte(r>>8), byte(r))
+	}
+
+	return encoded
+}
+
+// SizeRange matches data whose length is within Min and Max inclusive. A zero Max means no upper bound
+type SizeRange struct {
+	Min int
+	Max int
+}
+
+// Match checks if the length of the data is within the range
+func (r SizeRange) Match(data []byte) bool {
+	return len(data) >= r.Min && (r.Max <= 0 || len(data) <= r.Max)
+}
+
+// Chain matches data matching all of its heuristics, checked in order. An empty chain matches anything
+type Chain []Heuristic
+
+// Match checks if the data matches every heuristic of the chain
+func (c Chain) Match(data []byte) bool {
+	for _, h := range c {
+		if !h.Match(data) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Any matches data matching at least one of its heuristics. An empty Any matches nothing
+type Any []Heuristic
+
+// Match checks if the data matches one of the heuristics
+func (a Any) Match(data []byte) bool {
+	for _, h := range a {
+		if h.Match(data) {
+			return true
+		}
+	}
+
+	return false
+}
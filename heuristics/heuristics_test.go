@@ -0,0 +1,188 @@
+package heuristics
+
+import (
+	"testing"
+)
+
+// TestPattern tests matching of byte patterns
+func TestPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  Pattern
+		data     []byte
+		expected bool
+	}{
+		{
+			name:     "Anywhere",
+			pattern:  Pattern{Bytes: []byte("KEY")},
+			data:     []byte("private KEY data"),
+			expected: true,
+		},
+		{
+			name:     "Missing",
+			pattern:  Pattern{Bytes: []byte("KEY")},
+			data:     []byte("private key data"),
+			expected: false,
+		},
+		{
+			name:     "Anchored at offset",
+			pattern:  Pattern{Bytes: []byte{0x02, 0x01, 0x03}, Offset: 2, Anchored: true},
+			data:     []byte{0x30, 0x10, 0x02, 0x01, 0x03},
+			expected: true,
+		},
+		{
+			name:     "Anchored at another offset",
+			pattern:  Pattern{Bytes: []byte{0x02, 0x01, 0x03}, Anchored: true},
+			data:     []byte{0x30, 0x10, 0x02, 0x01, 0x03},
+			expected: false,
+		},
+		{
+			name:     "Anchored beyond the end",
+			pattern:  Pattern{Bytes: []byte{0x03, 0x00}, Offset: 4, Anchored: true},
+			data:     []byte{0x30, 0x10, 0x02, 0x01, 0x03},
+			expected: false,
+		},
+		{
+			name:     "Within window",
+			pattern:  Pattern{Bytes: []byte("KEY"), Window: 11},
+			data:     []byte("private KEY data"),
+			expected: true,
+		},
+		{
+			name:     "Beyond window",
+			pattern:  Pattern{Bytes: []byte("KEY"), Window: 10},
+			data:     []byte("private KEY data"),
+			expected: false,
+		},
+		{
+			name:     "UTF-16BE",
+			pattern:  Pattern{Bytes: []byte("Tumar"), UTF16: true},
+			data:     []byte{0x1e, 0x0a, 0x00, 'T', 0x00, 'u', 0x00, 'm', 0x00, 'a', 0x00, 'r'},
+			expected: true,
+		},
+		{
+			name:     "UTF-16BE after a partial match",
+			pattern:  Pattern{Bytes: []byte("Tumar"), UTF16: true},
+			data:     []byte{0x00, 'T', 0x00, 'u', 0x01, 'T', 0x00, 'T', 0x00, 'u', 0x00, 'm', 0x00, 'a', 0x00, 'r'},
+			expected: true,
+		},
+		{
+			name:     "UTF-16BE without zero high bytes",
+			pattern:  Pattern{Bytes: []byte("Tumar"), UTF16: true},
+			data:     []byte{0x01, 'T', 0x01, 'u', 0x01, 'm', 0x01, 'a', 0x01, 'r'},
+			expected: false,
+		},
+		{
+			name:     "UTF-16BE truncated",
+			pattern:  Pattern{Bytes: []byte("Tumar"), UTF16: true},
+			data:     []byte{0x00, 'T', 0x00, 'u', 0x00, 'm', 0x00, 'a', 0x00},
+			expected: false,
+		},
+		{
+			name:     "UTF-16BE anchored",
+			pattern:  Pattern{Bytes: []byte("KEY"), Offset: 1, Anchored: true, UTF16: true},
+			data:     []byte{0x1e, 0x00, 'K', 0x00, 'E', 0x00, 'Y'},
+			expected: true,
+		},
+		{
+			name:     "UTF-16BE beyond window",
+			pattern:  Pattern{Bytes: []byte("KEY"), Window: 6, UTF16: true},
+			data:     []byte{0x1e, 0x00, 'K', 0x00, 'E', 0x00, 'Y'},
+			expected: false,
+		},
+		{
+			name:     "UTF-16BE of non-ASCII text",
+			pattern:  Pattern{Bytes: []byte("Gamma é"), UTF16: true},
+			data:     []byte{0x00, 'G', 0x00, 'a', 0x00, 'm', 0x00, 'm', 0x00, 'a', 0x00, ' ', 0x00, 0xe9},
+			expected: true,
+		},
+		{
+			name:     "UTF-16BE not enabled",
+			pattern:  Pattern{Bytes: []byte("Tumar")},
+			data:     []byte{0x1e, 0x0a, 0x00, 'T', 0x00, 'u', 0x00, 'm', 0x00, 'a', 0x00, 'r'},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if result := tt.pattern.Match(tt.data); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestSizeRange tests matching of data sizes
+func TestSizeRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     SizeRange
+		length   int
+		expected bool
+	}{
+		{name: "Below minimum", size: SizeRange{Min: 20}, length: 19, expected: false},
+		{name: "Minimum", size: SizeRange{Min: 20}, length: 20, expected: true},
+		{name: "No maximum", size: SizeRange{Min: 20}, length: 1 << 20, expected: true},
+		{name: "Maximum", size: SizeRange{Min: 20, Max: 100}, length: 100, expected: true},
+		{name: "Above maximum", size: SizeRange{Min: 20, Max: 100}, length: 101, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if result := tt.size.Match(make([]byte, tt.length)); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// TestComposition tests Chain and Any
+func TestComposition(t *testing.T) {
+	sequence := Pattern{Bytes: []byte{0x30}, Anchored: true}
+	small := SizeRange{Max: 4}
+
+	tests := []struct {
+		name      string
+		heuristic Heuristic
+		data      []byte
+		expected  bool
+	}{
+		{name: "Chain of matches", heuristic: Chain{sequence, small}, data: []byte{0x30, 0x00}, expected: true},
+		{name: "Chain with a mismatch", heuristic: Chain{sequence, small}, data: []byte{0x30, 0, 0, 0, 0}, expected: false},
+		{name: "Empty chain", heuristic: Chain{}, data: []byte{0x04}, expected: true},
+		{name: "Any with a match", heuristic: Any{sequence, small}, data: []byte{0x04}, expected: true},
+		{name: "Any without matches", heuristic: Any{sequence, small}, data: []byte{0x04, 0, 0, 0, 0}, expected: false},
+		{name: "Empty any", heuristic: Any{}, data: []byte{0x30}, expected: false},
+		{name: "Nested", heuristic: Chain{sequence, Any{small, Pattern{Bytes: []byte("KEY")}}}, data: []byte("0 KEY"), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if result := tt.heuristic.Match(tt.data); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
+
+// BenchmarkPatternUTF16 measures the search of a missing UTF-16BE marker in 64 KiB of data
+func BenchmarkPatternUTF16(b *testing.B) {
+	pattern := Pattern{Bytes: []byte("Tumar"), UTF16: true}
+	data := make([]byte, 64<<10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if pattern.Match(data) {
+			b.Fatal("Expected no match")
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Formats reported by hints for unrecognized data
+const (
+	HintZIP    = "zip"
+	HintPDF    = "pdf"
+	HintXML    = "xml"
+	HintBase64 = "base64"
+	HintText   = "text"
+	HintRandom = "random"
+	HintBinary = "binary"
+)
+
+const (
+	// minEntropySampleSize is the minimal data size for which the entropy check is meaningful
+	minEntropySampleSize = 256
+
+	// highEntropyThreshold is the Shannon entropy in bits per byte above which large samples look
+	// random or encrypted, smaller samples are compared with entropyThreshold
+	highEntropyThreshold = 7.5
+
+	// minBase64Length is the minimal length of text reported as base64
+	minBase64Length = 16
+)
+
+// Hint describes what unrecognized data probably is
+type Hint struct {
+	Format      string // One of the Hint* constants
+	Description string // Human-readable description, e.g. "a ZIP archive"
+}
+
+// UnknownFormatError is returned by DetectAny when no format matches, with hints about the data
+type UnknownFormatError struct {
+	Hints []Hint
+}
+
+// Error implements the error interface
+func (e *UnknownFormatError) Error() string {
+	if len(e.Hints) == 0 {
+		return ErrUnknownFormat.Error()
+	}
+
+	return fmt.Sprintf("%s: this looks like %s", ErrUnknownFormat, e.Hints[0].Description)
+}
+
+// Unwrap allows errors.Is(err, ErrUnknownFormat)
+func (e *UnknownFormatError) Unwrap() error {
+	return ErrUnknownFormat
+}
+
+// ParseError is returned by Detect for data that fails to parse as an ASN.1 structure, with hints
+// about the data, e.g. to tell encrypted blobs from text or other binary formats
+type ParseError struct {
+	Err   error
+	Hints []Hint
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	if len(e.Hints) == 0 {
+		return fmt.Sprintf("failed to parse ASN.1 structure: %s", e.Err)
+	}
+
+	return fmt.Sprintf("failed to parse ASN.1 structure: %s (this looks like %s)", e.Err, e.Hints[0].Description)
+}
+
+// Unwrap returns the ASN.1 parsing error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Hints returns guesses about what the data is, most specific first
+func Hints(data []byte) []Hint {
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case len(trimmed) == 0:
+		return nil
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return []Hint{{Format: HintZIP, Description: "a ZIP archive"}}
+	case bytes.HasPrefix(trimmed, []byte("%PDF-")):
+		return []Hint{{Format: HintPDF, Description: "a PDF document"}}
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.HasPrefix(trimmed, []byte("<")) && bytes.HasSuffix(trimmed, []byte(">")):
+		return []Hint{{Format: HintXML, Description: "an XML document"}}
+	}
+
+	if decoded, ok := decodeBase64Text(trimmed); ok {
+		hint := Hint{Format: HintBase64, Description: "base64 encoded data"}
+		if result, err := detectAny(decoded); err == nil {
+			hint.Description = "base64 encoded " + result.Kind.String()
+		}
+
+		return []Hint{hint}
+	}
+
+	// Statistics are computed over the leading bytes of large inputs
+	sample := scanWindow(data)
+
+	if isText(sample) {
+		return []Hint{{Format: HintText, Description: "plain text"}}
+	}
+
+	if len(sample) < minEntropySampleSize {
+		return nil
+	}
+
+	// Encrypted and compressed data use nearly all byte values evenly, unlike structured binary formats
+	if entropy(sample) > entropyThreshold(len(sample)) {
+		return []Hint{{Format: HintRandom, Description: "random or encrypted data"}}
+	}
+
+	return []Hint{{Format: HintBinary, Description: "structured binary data"}}
+}
+
+// base64Alphabet marks the characters of the standard and URL-safe base64 alphabets and padding
+var base64Alphabet = func() (alphabet [256]bool) {
+	for _, c := range []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/-_=") {
+		alphabet[c] = true
+	}
+
+	return alphabet
+}()
+
+// decodeBase64Text decodes text consisting only of standard or URL-safe base64 characters and whitespace
+func decodeBase64Text(text []byte) ([]byte, bool) {
+	scratch := getScratch()
+	defer putScratch(scratch)
+
+	// Text is rejected at its first character outside the alphabets, without a pass over the rest
+	compact := *scratch
+	for i := 0; i < len(text); {
+		c := text[i]
+		if base64Alphabet[c] {
+			compact = append(compact, c)
+			i++
+
+			continue
+		}
+
+		r, size := rune(c), 1
+		if c >= utf8.RuneSelf {
+			r, size = utf8.DecodeRune(text[i:])
+		}
+
+		if !unicode.IsSpace(r) {
+			*scratch = compact
+
+			return nil, false
+		}
+
+		i += size
+	}
+
+	*scratch = compact
+
+	// Unpadded encodings decode to the most bytes, one buffer serves all attempts
+	size := base64.RawStdEncoding.DecodedLen(len(compact))
+	if len(compact) < minBase64Length || checkAllocation(size) != nil {
+		return nil, false
+	}
+
+	decoded := make([]byte, size)
+
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if n, err := encoding.Decode(decoded, compact); err == nil {
+			return decoded[:n], true
+		}
+	}
+
+	return nil, false
+}
+
+// isText checks if the data is valid UTF-8 consisting of printable characters and whitespace
+func isText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// entropy returns the Shannon entropy of the data in bits per byte
+func entropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var result float64
+
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		p := float64(count) / float64(len(data))
+		result -= p * math.Log2(p)
+	}
+
+	return result
+}
+
+// entropyThreshold returns the entropy above which a sample of size bytes looks random. The entropy
+// estimated from the byte frequencies of a small sample is biased low: for uniformly random bytes
+// it falls short of 8 bits by about 255/(2 size ln 2), e.g. 0.7 bits for 256 bytes, so the
+// threshold is lowered by the same amount
+func entropyThreshold(size int) float64 {
+	return highEntropyThreshold - 255/(2*float64(size)*math.Ln2)
+}
@@ -0,0 +1,221 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestHints tests guesses about unrecognized data
+func TestHints(t *testing.T) {
+	random := make([]byte, 4096)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("Failed to generate random data: %v", err)
+	}
+
+	signedData := base64.StdEncoding.EncodeToString(createTestData(t, PKCS7SignedDataOID))
+
+	tests := []struct {
+		name                string
+		data                []byte
+		expectedFormat      string
+		expectedDescription string
+	}{
+		{
+			name:                "ZIP archive",
+			data:                []byte("PK\x03\x04\x14\x00\x00\x00"),
+			expectedFormat:      HintZIP,
+			expectedDescription: "a ZIP archive",
+		},
+		{
+			name:                "PDF document",
+			data:                []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"),
+			expectedFormat:      HintPDF,
+			expectedDescription: "a PDF document",
+		},
+		{
+			name:                "XML document",
+			data:                []byte("<?xml version=\"1.0\"?>\n<root/>\n"),
+			expectedFormat:      HintXML,
+			expectedDescription: "an XML document",
+		},
+		{
+			name:                "Base64 encoded CMS",
+			data:                []byte(signedData[:20] + "\n" + signedData[20:]),
+			expectedFormat:      HintBase64,
+			expectedDescription: "base64 encoded PKCS#7 Signed Data",
+		},
+		{
+			name:                "Base64 with Unicode spaces",
+			data:                []byte(signedData[:20] + "\u00a0\u2003" + signedData[20:]),
+			expectedFormat:      HintBase64,
+			expectedDescription: "base64 encoded PKCS#7 Signed Data",
+		},
+		{
+			name:                "URL-safe base64",
+			data:                []byte(base64.RawURLEncoding.EncodeToString(createTestData(t, PKCS7SignedDataOID))),
+			expectedFormat:      HintBase64,
+			expectedDescription: "base64 encoded PKCS#7 Signed Data",
+		},
+		{
+			name:                "Base64 encoded unknown data",
+			data:                []byte(base64.StdEncoding.EncodeToString([]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b})),
+			expectedFormat:      HintBase64,
+			expectedDescription: "base64 encoded data",
+		},
+		{
+			name:                "Plain text",
+			data:                []byte("Hello, world!\nThis is not a signature.\n"),
+			expectedFormat:      HintText,
+			expectedDescription: "plain text",
+		},
+		{
+			name:                "Random data",
+			data:                random,
+			expectedFormat:      HintRandom,
+			expectedDescription: "random or encrypted data",
+		},
+		{
+			name:                "Structured binary data",
+			data:                bytes.Repeat([]byte{0x00, 0x00, 0x01, 0x7f, 0xff, 0x10}, 64),
+			expectedFormat:      HintBinary,
+			expectedDescription: "structured binary data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				hints := Hints(tt.data)
+				if len(hints) == 0 {
+					t.Fatal("Expected hints, got none")
+				}
+
+				if hints[0].Format != tt.expectedFormat {
+					t.Errorf("Expected format %s, got %s", tt.expectedFormat, hints[0].Format)
+				}
+
+				if hints[0].Description != tt.expectedDescription {
+					t.Errorf("Expected description %q, got %q", tt.expectedDescription, hints[0].Description)
+				}
+			},
+		)
+	}
+}
+
+// TestHintsSmallRandomData tests that random samples too small for an unbiased entropy estimate
+// are reported as random, while structured data of the same size is not
+func TestHintsSmallRandomData(t *testing.T) {
+	for _, size := range []int{minEntropySampleSize, 300, 350, 400, 512, 1024} {
+		t.Run(
+			fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+				for i := 0; i < 100; i++ {
+					data := make([]byte, size)
+					if _, err := rand.Read(data); err != nil {
+						t.Fatalf("Failed to generate random data: %v", err)
+					}
+
+					if hints := Hints(data); len(hints) == 0 || hints[0].Format != HintRandom {
+						t.Fatalf("Expected %s hint, got %v", HintRandom, hints)
+					}
+				}
+
+				structured := bytes.Repeat([]byte{0x30, 0x82, 0x01, 0x00, 0x02, 0x01, 0x03, 0x04}, size/8)
+				if hints := Hints(structured); len(hints) == 0 || hints[0].Format != HintBinary {
+					t.Errorf("Expected %s hint, got %v", HintBinary, hints)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectAnyHints tests that DetectAny reports hints for unrecognized data
+func TestDetectAnyHints(t *testing.T) {
+	_, err := DetectAny([]byte("%PDF-1.7\n"))
+
+	var unknown *UnknownFormatError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected UnknownFormatError, got %v", err)
+	}
+
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected error to wrap %v", ErrUnknownFormat)
+	}
+
+	if !strings.Contains(err.Error(), "this looks like a PDF document") {
+		t.Errorf("Expected PDF hint in error message, got %q", err.Error())
+	}
+}
+
+// TestDetectAnyHintsMalformedPEM tests that DetectAny reports hints for text that starts like PEM
+// but has no complete block
+func TestDetectAnyHintsMalformedPEM(t *testing.T) {
+	_, err := DetectAny([]byte("-----BEGIN CERTIFICATE-----\nMIIB\n"))
+
+	var unknown *UnknownFormatError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected UnknownFormatError, got %v", err)
+	}
+
+	if len(unknown.Hints) == 0 {
+		t.Error("Expected hints, got none")
+	}
+}
+
+// TestDetectParseError tests that Detect reports hints for data failing to parse as ASN.1
+func TestDetectParseError(t *testing.T) {
+	random := make([]byte, 1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatalf("Failed to generate random data: %v", err)
+	}
+
+	// Not a SEQUENCE, so that the data cannot parse by chance
+	random[0] = 0xff
+
+	tests := []struct {
+		name           string
+		data           []byte
+		expectedFormat string
+	}{
+		{
+			name:           "Encrypted blob",
+			data:           random,
+			expectedFormat: HintRandom,
+		},
+		{
+			name:           "Text",
+			data:           []byte("Exported key material, see the HSM audit log.\n"),
+			expectedFormat: HintText,
+		},
+		{
+			name: "Short binary data",
+			data: []byte{0xff, 0x00, 0x01},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := Detect(tt.data)
+
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) {
+					t.Fatalf("Expected ParseError, got %v", err)
+				}
+
+				var format string
+				if len(parseErr.Hints) > 0 {
+					format = parseErr.Hints[0].Format
+				}
+
+				if format != tt.expectedFormat {
+					t.Errorf("Expected hint %q, got %q", tt.expectedFormat, format)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build go1.21
+
+package cmsdetector
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHooks returns hooks that write structured logs of the scan progress and detection decisions:
+// file starts and heuristic matches at debug level, results at info level and failures as warnings
+func LogHooks(logger *slog.Logger) Hooks {
+	return Hooks{
+		OnFileStart: func(path string) {
+			logger.Debug("scanning file", slog.String("path", path))
+		},
+		OnResult: func(file ScannedFile) {
+			if file.Err != nil {
+				logger.Warn("file not classified", slog.String("path", file.Path), slog.Any("error", file.Err))
+
+				return
+			}
+
+			logger.LogAttrs(
+				context.Background(), slog.LevelInfo, "file classified",
+				slog.String("path", file.Path),
+				slog.Int64("size", file.Size),
+				slog.String("family", file.Result.Family.String()),
+				slog.String("kind", file.Result.Kind.String()),
+				slog.String("confidence", file.Result.Confidence.String()),
+				slog.Bool("encrypted", file.Encrypted),
+			)
+		},
+		OnHeuristicFired: func(file ScannedFile) {
+			logger.Debug(
+				"heuristic match", slog.String("path", file.Path),
+				slog.String("kind", file.Result.Kind.String()),
+				slog.String("confidence", file.Result.Confidence.String()),
+			)
+		},
+	}
+}
@@ -0,0 +1,54 @@
+//go:build go1.21
+
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+)
+
+// TestLogHooks tests the structured log records written during a scan
+func TestLogHooks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"keystore.bks": {Data: createBKS()},
+		"readme.txt":   {Data: []byte("Hello, world")},
+	}
+
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	if _, err := (&Scanner{Hooks: LogHooks(logger)}).Scan(fsys, "."); err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	var messages []string
+
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record struct {
+			Msg string `json:"msg"`
+		}
+
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("Failed to decode log record: %v", err)
+		}
+
+		messages = append(messages, record.Msg)
+	}
+
+	expected := []string{
+		"scanning file",
+		"heuristic match",
+		"file classified",
+		"scanning file",
+		"file not classified",
+	}
+
+	if !equalStrings(messages, expected) {
+		t.Errorf("Expected messages %v, got %v", expected, messages)
+	}
+}
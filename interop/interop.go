@@ -0,0 +1,90 @@
+// Package interop hands blobs recognized by cmsdetector over to third-party parsers in the form
+// they expect: pkcs12.Decode of software.sslmate.com/src/go-pkcs12 and pkcs7.Parse of
+// go.mozilla.org/pkcs7. Both take a single DER encoded structure, so the helpers strip PEM
+// armor and base64 text, re-encode BER in DER and check that the structure is one the target
+// library parses. The libraries are not imported, keeping the module free of dependencies:
+//
+//	der, err := interop.AsPKCS12ForDecode(data)
+//	if err != nil {
+//		return err
+//	}
+//
+//	key, cert, err := pkcs12.Decode(der, password)
+package interop
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// ErrNotPKCS7 is returned when the data is not a PKCS#7 structure parsed by go.mozilla.org/pkcs7
+var ErrNotPKCS7 = errors.New("not a PKCS#7 data, signed, enveloped or encrypted data structure")
+
+// pkcs7Kinds lists the kinds of ContentInfo parsed by pkcs7.Parse
+var pkcs7Kinds = map[cmsdetector.Kind]bool{
+	cmsdetector.KindData:           true,
+	cmsdetector.KindSignedData:     true,
+	cmsdetector.KindEnvelopedData:  true,
+	cmsdetector.KindEncryptedData:  true,
+	cmsdetector.KindWindowsCatalog: true,
+}
+
+// AsPKCS12ForDecode returns the DER encoded PFX of a DER, BER, PEM or base64 encoded PKCS#12
+// container, ready for pkcs12.Decode or pkcs12.DecodeChain. The authenticated safe inside is
+// left as is, since the integrity MAC covers its encoding. Data of other formats is rejected
+// with cmsdetector.ErrNotPKCS12
+func AsPKCS12ForDecode(data []byte) ([]byte, error) {
+	der, err := normalize(data)
+	if err != nil {
+		return nil, cmsdetector.ErrNotPKCS12
+	}
+
+	if result, err := cmsdetector.DetectAny(der); err != nil || result.Kind != cmsdetector.KindPKCS12 {
+		return nil, cmsdetector.ErrNotPKCS12
+	}
+
+	return der, nil
+}
+
+// AsPKCS7ForParse returns the DER encoded ContentInfo of a DER, BER, PEM or base64 encoded
+// PKCS#7 structure, ready for pkcs7.Parse. Only the content types supported by pkcs7.Parse
+// are accepted: data, signed data, including Windows security catalogs, enveloped data and
+// encrypted data. Other data is rejected with ErrNotPKCS7
+func AsPKCS7ForParse(data []byte) ([]byte, error) {
+	der, err := normalize(data)
+	if err != nil {
+		return nil, ErrNotPKCS7
+	}
+
+	if result, err := cmsdetector.DetectAny(der); err != nil || !pkcs7Kinds[result.Kind] {
+		return nil, ErrNotPKCS7
+	}
+
+	return der, nil
+}
+
+// normalize decodes PEM and base64 text and re-encodes the structure in DER
+func normalize(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN ")):
+		block, _ := pem.Decode(trimmed)
+		if block == nil {
+			return nil, errors.New("invalid PEM block")
+		}
+
+		data = block.Bytes
+	case len(trimmed) > 0 && trimmed[0] != 0x30:
+		// Binary structures start with a SEQUENCE, base64 text never does
+		if decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(trimmed), nil))); err == nil {
+			data = decoded
+		}
+	}
+
+	return cmsdetector.ToDER(data)
+}
@@ -0,0 +1,100 @@
+package interop
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/lEx0/cmsdetector"
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// indefinite re-encodes the outer SEQUENCE of a DER structure with an indefinite length
+func indefinite(t *testing.T, der []byte) []byte {
+	t.Helper()
+
+	var raw asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &raw); err != nil {
+		t.Fatalf("Failed to unmarshal structure: %v", err)
+	}
+
+	ber := append([]byte{0x30, 0x80}, raw.Bytes...)
+
+	return append(ber, 0x00, 0x00)
+}
+
+// TestAsPKCS12ForDecode tests the normalization of PKCS#12 containers for go-pkcs12
+func TestAsPKCS12ForDecode(t *testing.T) {
+	pfx, err := os.ReadFile("../testdata/pkcs12/password.p12")
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expectedErr error
+	}{
+		{name: "DER", data: pfx},
+		{name: "BER", data: indefinite(t, pfx)},
+		{name: "PEM", data: pem.EncodeToMemory(&pem.Block{Type: "PKCS12", Bytes: pfx})},
+		{name: "Base64", data: []byte(base64.StdEncoding.EncodeToString(pfx) + "\n")},
+		{name: "Signed Data", data: cmstest.SignedData(t), expectedErr: cmsdetector.ErrNotPKCS12},
+		{name: "Garbage", data: []byte("not a container"), expectedErr: cmsdetector.ErrNotPKCS12},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				der, err := AsPKCS12ForDecode(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if tt.expectedErr == nil && !bytes.Equal(der, pfx) {
+					t.Errorf("Expected the DER encoded container, got %x", der)
+				}
+			},
+		)
+	}
+}
+
+// TestAsPKCS7ForParse tests the normalization of PKCS#7 structures for go.mozilla.org/pkcs7
+func TestAsPKCS7ForParse(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    []byte
+		expectedErr error
+	}{
+		{name: "DER", data: signed, expected: signed},
+		{name: "BER", data: indefinite(t, signed), expected: signed},
+		{name: "PEM", data: pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: signed}), expected: signed},
+		{name: "Base64", data: []byte(base64.StdEncoding.EncodeToString(signed)), expected: signed},
+		{name: "Enveloped Data", data: cmstest.EnvelopedData(t), expected: cmstest.EnvelopedData(t)},
+		{name: "Digested Data", data: cmstest.DigestedData(t), expectedErr: ErrNotPKCS7},
+		{name: "PKCS#12", data: cmstest.PFX(t), expectedErr: ErrNotPKCS7},
+		{name: "Invalid PEM", data: []byte("-----BEGIN PKCS7-----\n"), expectedErr: ErrNotPKCS7},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				der, err := AsPKCS7ForParse(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if !bytes.Equal(der, tt.expected) {
+					t.Errorf("Expected %x, got %x", tt.expected, der)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,190 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// JOSE serializations (RFC 7515, section 7)
+const (
+	JOSESerializationCompact = "compact"
+	JOSESerializationJSON    = "json"
+)
+
+// Number of dot-separated segments of compact serializations
+const (
+	jwsCompactSegments = 3
+	jweCompactSegments = 5
+)
+
+// ErrNotJOSE is returned when the data is not a JWS or JWE object
+var ErrNotJOSE = errors.New("not a JOSE object")
+
+// JOSEResult describes a detected JWS or JWE object
+type JOSEResult struct {
+	Kind          Kind   // KindJWS or KindJWE
+	Serialization string // JOSESerializationCompact or JOSESerializationJSON
+	Algorithm     string // "alg" header parameter of the first signature or recipient
+	Encryption    string // "enc" header parameter of JWE objects
+}
+
+// joseHeader contains the header parameters reported in JOSEResult
+type joseHeader struct {
+	Algorithm  string `json:"alg"`
+	Encryption string `json:"enc"`
+}
+
+// joseSignature provides the per-signature members of the JWS JSON serialization
+type joseSignature struct {
+	Protected string     `json:"protected"`
+	Header    joseHeader `json:"header"`
+	Signature *string    `json:"signature"`
+}
+
+// joseRecipient provides the per-recipient members of the JWE JSON serialization
+type joseRecipient struct {
+	Header joseHeader `json:"header"`
+}
+
+// joseJSON provides the members of the JWS and JWE JSON serializations (general and flattened)
+type joseJSON struct {
+	joseSignature
+
+	Payload     *string         `json:"payload"`
+	Signatures  []joseSignature `json:"signatures"`
+	Ciphertext  *string         `json:"ciphertext"`
+	Unprotected joseHeader      `json:"unprotected"`
+	Recipients  []joseRecipient `json:"recipients"`
+}
+
+// DetectJOSE detects compact and JSON serialized JWS and JWE objects
+func DetectJOSE(data []byte) (JOSEResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return JOSEResult{}, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJOSEJSON(trimmed)
+	}
+
+	return parseJOSECompact(string(trimmed))
+}
+
+// parseJOSECompact parses the protected header of a compact serialized JWS or JWE
+func parseJOSECompact(token string) (JOSEResult, error) {
+	result := JOSEResult{Serialization: JOSESerializationCompact}
+
+	segments := strings.Split(token, ".")
+
+	switch len(segments) {
+	case jwsCompactSegments:
+		result.Kind = KindJWS
+	case jweCompactSegments:
+		result.Kind = KindJWE
+	default:
+		return JOSEResult{}, ErrNotJOSE
+	}
+
+	for _, segment := range segments[1:] {
+		if _, err := decodeBase64URL(segment); err != nil {
+			return JOSEResult{}, ErrNotJOSE
+		}
+	}
+
+	header, err := decodeJOSEHeader(segments[0])
+	if err != nil || header.Algorithm == "" {
+		return JOSEResult{}, ErrNotJOSE
+	}
+
+	result.Algorithm = header.Algorithm
+	result.Encryption = header.Encryption
+
+	if result.Kind == KindJWE && result.Encryption == "" {
+		return JOSEResult{}, ErrNotJOSE
+	}
+
+	return result, nil
+}
+
+// parseJOSEJSON parses a JSON serialized JWS or JWE
+func parseJOSEJSON(data []byte) (JOSEResult, error) {
+	var object joseJSON
+	if err := json.Unmarshal(data, &object); err != nil {
+		return JOSEResult{}, ErrNotJOSE
+	}
+
+	result := JOSEResult{Serialization: JOSESerializationJSON}
+
+	switch {
+	case object.Payload != nil && (object.Signature != nil || len(object.Signatures) > 0):
+		result.Kind = KindJWS
+
+		signature := object.joseSignature
+		if len(object.Signatures) > 0 {
+			signature = object.Signatures[0]
+		}
+
+		protected, err := decodeJOSEHeader(signature.Protected)
+		if err != nil {
+			return JOSEResult{}, ErrNotJOSE
+		}
+
+		result.Algorithm = firstNonEmpty(protected.Algorithm, signature.Header.Algorithm)
+	case object.Ciphertext != nil:
+		result.Kind = KindJWE
+
+		protected, err := decodeJOSEHeader(object.Protected)
+		if err != nil {
+			return JOSEResult{}, ErrNotJOSE
+		}
+
+		recipient := object.Header
+		if len(object.Recipients) > 0 {
+			recipient = object.Recipients[0].Header
+		}
+
+		result.Algorithm = firstNonEmpty(protected.Algorithm, object.Unprotected.Algorithm, recipient.Algorithm)
+		result.Encryption = firstNonEmpty(protected.Encryption, object.Unprotected.Encryption, recipient.Encryption)
+	default:
+		return JOSEResult{}, ErrNotJOSE
+	}
+
+	return result, nil
+}
+
+// decodeJOSEHeader decodes a base64url encoded JOSE header, an empty segment yields an empty header
+func decodeJOSEHeader(segment string) (joseHeader, error) {
+	var header joseHeader
+	if segment == "" {
+		return header, nil
+	}
+
+	decoded, err := decodeBase64URL(segment)
+	if err != nil {
+		return header, err
+	}
+
+	err = json.Unmarshal(decoded, &header)
+
+	return header, err
+}
+
+// decodeBase64URL decodes base64url data with or without padding
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
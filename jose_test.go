@@ -0,0 +1,92 @@
+package cmsdetector
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// encodeJOSEHeader encodes a JOSE header as a base64url segment
+func encodeJOSEHeader(header string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header))
+}
+
+// TestDetectJOSE tests detection of compact and JSON serialized JOSE objects
+func TestDetectJOSE(t *testing.T) {
+	jwsHeader := encodeJOSEHeader(`{"alg":"ES256","typ":"JWT"}`)
+	jweHeader := encodeJOSEHeader(`{"alg":"RSA-OAEP","enc":"A256GCM"}`)
+	payload := encodeJOSEHeader(`{"sub":"1234567890"}`)
+
+	tests := []struct {
+		name        string
+		data        string
+		expected    JOSEResult
+		expectedErr error
+	}{
+		{
+			name:     "Compact JWS",
+			data:     jwsHeader + "." + payload + ".c2lnbmF0dXJl\n",
+			expected: JOSEResult{Kind: KindJWS, Serialization: JOSESerializationCompact, Algorithm: "ES256"},
+		},
+		{
+			name:     "Compact JWS with detached payload",
+			data:     jwsHeader + "..c2lnbmF0dXJl",
+			expected: JOSEResult{Kind: KindJWS, Serialization: JOSESerializationCompact, Algorithm: "ES256"},
+		},
+		{
+			name: "Compact JWE",
+			data: jweHeader + ".a2V5.aXY.Y2lwaGVydGV4dA.dGFn",
+			expected: JOSEResult{
+				Kind: KindJWE, Serialization: JOSESerializationCompact, Algorithm: "RSA-OAEP", Encryption: "A256GCM",
+			},
+		},
+		{
+			name:     "General JWS JSON",
+			data:     `{"payload":"` + payload + `","signatures":[{"protected":"` + jwsHeader + `","signature":"c2ln"}]}`,
+			expected: JOSEResult{Kind: KindJWS, Serialization: JOSESerializationJSON, Algorithm: "ES256"},
+		},
+		{
+			name:     "Flattened JWS JSON with unprotected header",
+			data:     `{"payload":"` + payload + `","header":{"alg":"HS256"},"signature":"c2ln"}`,
+			expected: JOSEResult{Kind: KindJWS, Serialization: JOSESerializationJSON, Algorithm: "HS256"},
+		},
+		{
+			name: "General JWE JSON",
+			data: `{"protected":"` + encodeJOSEHeader(`{"enc":"A128CBC-HS256"}`) + `",` +
+				`"recipients":[{"header":{"alg":"ECDH-ES+A128KW"},"encrypted_key":"a2V5"}],"iv":"aXY","ciphertext":"Y3Q","tag":"dGFn"}`,
+			expected: JOSEResult{
+				Kind: KindJWE, Serialization: JOSESerializationJSON, Algorithm: "ECDH-ES+A128KW", Encryption: "A128CBC-HS256",
+			},
+		},
+		{
+			name:        "JSON without JOSE members",
+			data:        `{"protected":"x"}`,
+			expectedErr: ErrNotJOSE,
+		},
+		{
+			name:        "Dotted version string",
+			data:        "1.2.3",
+			expectedErr: ErrNotJOSE,
+		},
+		{
+			name:        "Compact JWE without enc",
+			data:        jwsHeader + ".a2V5.aXY.Y2lwaGVydGV4dA.dGFn",
+			expectedErr: ErrNotJOSE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectJOSE([]byte(tt.data))
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
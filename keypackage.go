@@ -0,0 +1,125 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// Key package content types used by key escrow and key distribution systems
+var (
+	AsymmetricKeyPackageOID = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 2, 1, 2, 78, 5} // RFC 5958
+	SymmetricKeyPackageOID  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 25} // RFC 6031
+	EncryptedKeyPackageOID  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 2, 1, 2, 78, 2} // RFC 6032
+)
+
+// ErrNotKeyPackage is returned when the data does not carry a key package
+var ErrNotKeyPackage = errors.New("not a key package")
+
+// KeyPackageInfo describes a key package, either as the ContentInfo itself or encapsulated in
+// SignedData, EnvelopedData, AuthEnvelopedData or EncryptedData
+type KeyPackageInfo struct {
+	ContentType asn1.ObjectIdentifier // AsymmetricKeyPackageOID, SymmetricKeyPackageOID or EncryptedKeyPackageOID
+	Signed      bool                  // Indicates the key package is encapsulated in SignedData
+	Encrypted   bool                  // Indicates the keys are encrypted and cannot be counted
+	Protection  asn1.ObjectIdentifier // Content type encrypting the keys, e.g. PKCS7EnvelopedDataOID
+	Keys        int                   // Number of keys, 0 when encrypted, detached or not parseable
+}
+
+// symmetricKeyPackage provides the ASN.1 structure of SymmetricKeyPackage (RFC 6031, section 2.0)
+type symmetricKeyPackage struct {
+	Version    int             `asn1:"optional,default:1"`
+	Attributes asn1.RawValue   `asn1:"optional,tag:0"`
+	Keys       []asn1.RawValue // OneSymmetricKey
+}
+
+// InspectKeyPackage reports the type and number of keys of an asymmetric (RFC 5958), symmetric
+// (RFC 6031) or encrypted (RFC 6032) key package
+func InspectKeyPackage(data []byte) (info *KeyPackageInfo, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	switch contentType := contentInfo.ContentType; {
+	case isKeyPackage(contentType):
+		return inspectKeyPackageContent(contentType, contentInfo.Content.Bytes), nil
+	case contentType.Equal(PKCS7SignedDataOID):
+		sd, err := parseSignedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed data: %w", err)
+		}
+
+		if !isKeyPackage(sd.EncapContentInfo.EContentType) {
+			return nil, ErrNotKeyPackage
+		}
+
+		content, _ := encapsulatedContent(sd.EncapContentInfo)
+		info = inspectKeyPackageContent(sd.EncapContentInfo.EContentType, content)
+		info.Signed = true
+
+		return info, nil
+	}
+
+	encrypted, ok := encryptedContentType(contentInfo)
+	if !ok || !isKeyPackage(encrypted) {
+		return nil, ErrNotKeyPackage
+	}
+
+	return &KeyPackageInfo{ContentType: encrypted, Encrypted: true, Protection: contentInfo.ContentType}, nil
+}
+
+// isKeyPackage checks if the content type is one of the key package content types
+func isKeyPackage(contentType asn1.ObjectIdentifier) bool {
+	return contentType.Equal(AsymmetricKeyPackageOID) || contentType.Equal(SymmetricKeyPackageOID) ||
+		contentType.Equal(EncryptedKeyPackageOID)
+}
+
+// inspectKeyPackageContent counts the keys of the DER encoded key package of the content type
+func inspectKeyPackageContent(contentType asn1.ObjectIdentifier, content []byte) *KeyPackageInfo {
+	info := &KeyPackageInfo{ContentType: contentType}
+
+	switch {
+	case contentType.Equal(AsymmetricKeyPackageOID):
+		var keys []asn1.RawValue // OneAsymmetricKey
+		if rest, err := asn1.Unmarshal(content, &keys); err == nil && len(rest) == 0 {
+			info.Keys = len(keys)
+		}
+	case contentType.Equal(SymmetricKeyPackageOID):
+		var pkg symmetricKeyPackage
+		if rest, err := asn1.Unmarshal(content, &pkg); err == nil && len(rest) == 0 {
+			info.Keys = len(pkg.Keys)
+		}
+	case contentType.Equal(EncryptedKeyPackageOID):
+		info.Encrypted = true
+		info.Protection = encryptedKeyPackageProtection(content)
+	}
+
+	return info
+}
+
+// encryptedKeyPackageProtection returns the content type of the EncryptedKeyPackage CHOICE:
+// untagged EncryptedData, [0] EnvelopedData or [1] AuthEnvelopedData (RFC 6032, section 3)
+func encryptedKeyPackageProtection(content []byte) asn1.ObjectIdentifier {
+	var choice asn1.RawValue
+	if _, err := asn1.Unmarshal(content, &choice); err != nil || !choice.IsCompound {
+		return nil
+	}
+
+	switch {
+	case choice.Class == asn1.ClassUniversal && choice.Tag == asn1.TagSequence:
+		return PKCS7EncryptedDataOID
+	case choice.Class == asn1.ClassContextSpecific && choice.Tag == 0:
+		return PKCS7EnvelopedDataOID
+	case choice.Class == asn1.ClassContextSpecific && choice.Tag == 1:
+		return AuthEnvelopedDataOID
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createAsymmetricKeyPackage creates a DER encoded AsymmetricKeyPackage holding the given number of keys
+func createAsymmetricKeyPackage(t *testing.T, keys int) []byte {
+	t.Helper()
+
+	var pkg []asn1.RawValue
+
+	for i := 0; i < keys; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal private key: %v", err)
+		}
+
+		pkg = append(pkg, asn1.RawValue{FullBytes: der})
+	}
+
+	data, err := asn1.Marshal(pkg)
+	if err != nil {
+		t.Fatalf("Failed to marshal key package: %v", err)
+	}
+
+	return data
+}
+
+// TestInspectKeyPackage tests the key package types and key counts reported by InspectKeyPackage
+func TestInspectKeyPackage(t *testing.T) {
+	asymmetric := createAsymmetricKeyPackage(t, 2)
+
+	octets, err := asn1.Marshal(asymmetric)
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	signed := createContentInfo(
+		t, PKCS7SignedDataOID, signedData{
+			Version: 3,
+			EncapContentInfo: encapsulatedContentInfo{
+				EContentType: AsymmetricKeyPackageOID,
+				EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets},
+			},
+		},
+	)
+
+	symmetric := struct {
+		Keys []struct{ Key []byte }
+	}{
+		Keys: []struct{ Key []byte }{{Key: make([]byte, 16)}, {Key: make([]byte, 32)}, {Key: make([]byte, 32)}},
+	}
+
+	encrypted := createContentInfo(
+		t, PKCS7EncryptedDataOID, encryptedData{
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                SymmetricKeyPackageOID,
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}},
+			},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected KeyPackageInfo
+		err      error
+	}{
+		{
+			name:     "Asymmetric key package",
+			data:     createContentInfo(t, AsymmetricKeyPackageOID, asn1.RawValue{FullBytes: asymmetric}),
+			expected: KeyPackageInfo{ContentType: AsymmetricKeyPackageOID, Keys: 2},
+		},
+		{
+			name:     "Signed asymmetric key package",
+			data:     signed,
+			expected: KeyPackageInfo{ContentType: AsymmetricKeyPackageOID, Signed: true, Keys: 2},
+		},
+		{
+			name:     "Symmetric key package",
+			data:     createContentInfo(t, SymmetricKeyPackageOID, symmetric),
+			expected: KeyPackageInfo{ContentType: SymmetricKeyPackageOID, Keys: 3},
+		},
+		{
+			name: "Encrypted key package with enveloped data",
+			data: createContentInfo(
+				t, EncryptedKeyPackageOID, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x02, 0x01, 0x00}},
+			),
+			expected: KeyPackageInfo{ContentType: EncryptedKeyPackageOID, Encrypted: true, Protection: PKCS7EnvelopedDataOID},
+		},
+		{
+			name:     "Symmetric key package in encrypted data",
+			data:     encrypted,
+			expected: KeyPackageInfo{ContentType: SymmetricKeyPackageOID, Encrypted: true, Protection: PKCS7EncryptedDataOID},
+		},
+		{
+			name:     "Malformed key package",
+			data:     createContentInfo(t, AsymmetricKeyPackageOID, 1),
+			expected: KeyPackageInfo{ContentType: AsymmetricKeyPackageOID},
+		},
+		{
+			name: "Signed data",
+			data: createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			err:  ErrNotKeyPackage,
+		},
+		{
+			name: "Enveloped data",
+			data: createEnvelopedData(t, rsaEncryptionOID, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}),
+			err:  ErrNotKeyPackage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, err := InspectKeyPackage(tt.data)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Expected error %v, got %v", tt.err, err)
+				}
+
+				if tt.err != nil {
+					return
+				}
+
+				if !info.ContentType.Equal(tt.expected.ContentType) || !info.Protection.Equal(tt.expected.Protection) {
+					t.Errorf("Expected %v protected by %v, got %v protected by %v", tt.expected.ContentType, tt.expected.Protection, info.ContentType, info.Protection)
+				}
+
+				if info.Signed != tt.expected.Signed || info.Encrypted != tt.expected.Encrypted || info.Keys != tt.expected.Keys {
+					t.Errorf("Expected %+v, got %+v", tt.expected, *info)
+				}
+			},
+		)
+	}
+}
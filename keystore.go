@@ -0,0 +1,185 @@
+package cmsdetector
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Magic numbers of Java keystores
+const (
+	jksMagic   = 0xFEEDFEED
+	jceksMagic = 0xCECECECE
+)
+
+const (
+	// bksEntryNull terminates the entry list of a BouncyCastle keystore
+	bksEntryNull = 0
+
+	// BouncyCastle keystore entry types
+	bksEntryCertificate = 1
+	bksEntryKey         = 2
+	bksEntrySecret      = 3
+	bksEntrySealed      = 4
+
+	// maxBKSSaltLength limits the salt length accepted as a BouncyCastle keystore header
+	maxBKSSaltLength = 128
+)
+
+// ErrNotKeystore is returned when the data is not a supported keystore
+var ErrNotKeystore = errors.New("not a supported keystore")
+
+// errTruncatedData is returned when binary data ends in the middle of a field
+var errTruncatedData = errors.New("truncated data")
+
+// KeystoreResult describes a detected keystore
+type KeystoreResult struct {
+	Kind    Kind // KindJKS, KindJCEKS, KindBKS, KindPKCS12 or KindEncryptedPKCS12
+	Version int  // Keystore format version
+	Entries int  // Number of entries; for PKCS#12 only the SafeBags readable without a password are counted
+}
+
+// DetectKeystore determines the kind of a Java, BouncyCastle or PKCS#12 keystore
+func DetectKeystore(data []byte) (KeystoreResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return KeystoreResult{}, err
+	}
+
+	if len(data) >= 12 {
+		switch binary.BigEndian.Uint32(data) {
+		case jksMagic:
+			return javaKeystore(KindJKS, data), nil
+		case jceksMagic:
+			return javaKeystore(KindJCEKS, data), nil
+		}
+	}
+
+	if contents, err := parsePFX(data); err == nil {
+		return KeystoreResult{Kind: KindPKCS12, Version: contents.pfx.Version, Entries: len(contents.bags)}, nil
+	}
+
+	if isEncryptedPKCS12(data) {
+		return KeystoreResult{Kind: KindEncryptedPKCS12, Version: pfxVersion}, nil
+	}
+
+	if result, err := parseBKS(data); err == nil {
+		return result, nil
+	}
+
+	return KeystoreResult{}, ErrNotKeystore
+}
+
+// javaKeystore reads the version and entry count of a JKS or JCEKS header
+func javaKeystore(kind Kind, data []byte) KeystoreResult {
+	return KeystoreResult{
+		Kind:    kind,
+		Version: int(binary.BigEndian.Uint32(data[4:])),
+		Entries: int(binary.BigEndian.Uint32(data[8:])),
+	}
+}
+
+// binaryReader reads the big-endian, length-prefixed primitives used by Java DataOutputStream
+// and the SSH wire format
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+// next returns the next n bytes, recording an error if the data is too short
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.data) {
+		r.err = errTruncatedData
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
+
+// readByte reads a single byte
+func (r *binaryReader) readByte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+// readUint32 reads a 4-byte integer
+func (r *binaryReader) readUint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+// skipUTF skips a string written by DataOutputStream.writeUTF
+func (r *binaryReader) skipUTF() {
+	if b := r.next(2); b != nil {
+		r.next(int(binary.BigEndian.Uint16(b)))
+	}
+}
+
+// readBytes reads a byte array prefixed with its 4-byte length
+func (r *binaryReader) readBytes() []byte {
+	return r.next(int(int32(r.readUint32())))
+}
+
+// parseBKS parses the header and walks the entries of a BouncyCastle BKS keystore
+func parseBKS(data []byte) (KeystoreResult, error) {
+	r := &binaryReader{data: data}
+
+	version := r.readUint32()
+	if version != 1 && version != 2 {
+		return KeystoreResult{}, ErrNotKeystore
+	}
+
+	saltLength := int(r.readUint32())
+	if saltLength == 0 || saltLength > maxBKSSaltLength {
+		return KeystoreResult{}, ErrNotKeystore
+	}
+
+	r.next(saltLength)
+
+	if iterations := r.readUint32(); iterations == 0 {
+		return KeystoreResult{}, ErrNotKeystore
+	}
+
+	result := KeystoreResult{Kind: KindBKS, Version: int(version)}
+
+	for entryType := r.readByte(); r.err == nil && entryType != bksEntryNull; entryType = r.readByte() {
+		r.skipUTF() // alias
+		r.next(8)   // creation date
+		chainLength := int(int32(r.readUint32()))
+
+		for i := 0; i < chainLength && r.err == nil; i++ {
+			r.skipUTF() // certificate type
+			r.readBytes()
+		}
+
+		switch entryType {
+		case bksEntryCertificate:
+			r.skipUTF()
+			r.readBytes()
+		case bksEntryKey:
+			r.next(1)   // key type
+			r.skipUTF() // format
+			r.skipUTF() // algorithm
+			r.readBytes()
+		case bksEntrySecret, bksEntrySealed:
+			r.readBytes()
+		default:
+			return KeystoreResult{}, ErrNotKeystore
+		}
+
+		result.Entries++
+	}
+
+	if r.err != nil {
+		return KeystoreResult{}, ErrNotKeystore
+	}
+
+	return result, nil
+}
@@ -0,0 +1,131 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// binaryWriter writes the big-endian, length-prefixed primitives read by binaryReader
+type binaryWriter struct {
+	bytes.Buffer
+}
+
+// writeUint32 writes a 4-byte integer
+func (w *binaryWriter) writeUint32(v uint32) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+// writeUTF writes a string as DataOutputStream.writeUTF
+func (w *binaryWriter) writeUTF(s string) {
+	_ = binary.Write(w, binary.BigEndian, uint16(len(s)))
+	w.WriteString(s)
+}
+
+// writeBytes writes a byte array prefixed with its 4-byte length
+func (w *binaryWriter) writeBytes(b []byte) {
+	w.writeUint32(uint32(len(b)))
+	w.Write(b)
+}
+
+// createJavaKeystore creates a JKS or JCEKS header
+func createJavaKeystore(magic, version, entries uint32) []byte {
+	var w binaryWriter
+
+	w.writeUint32(magic)
+	w.writeUint32(version)
+	w.writeUint32(entries)
+
+	return w.Bytes()
+}
+
+// createBKS creates a BouncyCastle keystore with a certificate and a secret entry
+func createBKS() []byte {
+	var w binaryWriter
+
+	w.writeUint32(2)
+	w.writeBytes(make([]byte, 20))
+	w.writeUint32(1500)
+
+	w.WriteByte(bksEntryCertificate)
+	w.writeUTF("certificate")
+	w.Write(make([]byte, 8))
+	w.writeUint32(0)
+	w.writeUTF("X.509")
+	w.writeBytes([]byte{0x30, 0x00})
+
+	w.WriteByte(bksEntrySecret)
+	w.writeUTF("secret")
+	w.Write(make([]byte, 8))
+	w.writeUint32(0)
+	w.writeBytes([]byte{0x01, 0x02, 0x03})
+
+	w.WriteByte(bksEntryNull)
+	w.Write(make([]byte, 20)) // HMAC
+
+	return w.Bytes()
+}
+
+// TestDetectKeystore tests detection of Java, BouncyCastle and PKCS#12 keystores
+func TestDetectKeystore(t *testing.T) {
+	bks := createBKS()
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    KeystoreResult
+		expectedErr error
+	}{
+		{
+			name:     "JKS",
+			data:     createJavaKeystore(jksMagic, 2, 3),
+			expected: KeystoreResult{Kind: KindJKS, Version: 2, Entries: 3},
+		},
+		{
+			name:     "JCEKS",
+			data:     createJavaKeystore(jceksMagic, 2, 1),
+			expected: KeystoreResult{Kind: KindJCEKS, Version: 2, Entries: 1},
+		},
+		{
+			name:     "BKS",
+			data:     bks,
+			expected: KeystoreResult{Kind: KindBKS, Version: 2, Entries: 2},
+		},
+		{
+			name:     "PKCS#12",
+			data:     createPFX(t, []safeBag{createSafeBag(t, PKCS12CertBagOID, certBag{CertID: x509CertBagOID, CertValue: []byte{0x30, 0x00}})}, nil),
+			expected: KeystoreResult{Kind: KindPKCS12, Version: 3, Entries: 1},
+		},
+		{
+			name:     "Encrypted PKCS#12",
+			data:     createMockPKCS12Key(t),
+			expected: KeystoreResult{Kind: KindEncryptedPKCS12, Version: 3},
+		},
+		{
+			name:        "Truncated BKS",
+			data:        bks[:40],
+			expectedErr: ErrNotKeystore,
+		},
+		{
+			name:        "PKCS#7 Signed Data",
+			data:        createTestData(t, PKCS7SignedDataOID),
+			expectedErr: ErrNotKeystore,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectKeystore(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
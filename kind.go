@@ -0,0 +1,178 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"strings"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// WindowsCatalogOID identifies Microsoft security catalogs (szOID_CTL), signed as SignedData content
+var WindowsCatalogOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 1}
+
+// Kind identifies the kind of the detected structure
+type Kind int
+
+// Kinds of recognized structures
+const (
+	KindUnknown Kind = iota
+	KindData
+	KindSignedData
+	KindEnvelopedData
+	KindSignedAndEnvelopedData
+	KindDigestedData
+	KindEncryptedData
+	KindPKCS12
+	KindEncryptedPKCS12
+	KindWindowsCatalog
+	KindJKS
+	KindJCEKS
+	KindBKS
+	KindOpenSSHPrivateKey
+	KindSSHPublicKey
+	KindPuTTYPrivateKey
+	KindJWS
+	KindJWE
+	KindCOSESign1
+	KindCOSESign
+	KindCOSEEncrypt
+	KindCOSEEncrypt0
+	KindCOSEMac
+	KindCOSEMac0
+	KindCertificate
+	KindCertificateRequest
+	KindPrivateKey
+	KindEncryptedPrivateKey
+	KindPGPMessage
+	KindPGPPublicKey
+	KindPGPPrivateKey
+	KindPGPSignature
+	KindESTCertsOnly
+	KindCMPMessage
+	KindXMLSignature
+	KindXAdES
+)
+
+// kindNames maps kinds to the type names reported in DetectionResult.Type
+var kindNames = map[Kind]string{
+	KindUnknown:                "Unknown",
+	KindData:                   "PKCS#7 Data",
+	KindSignedData:             "PKCS#7 Signed Data",
+	KindEnvelopedData:          "PKCS#7 Enveloped Data",
+	KindSignedAndEnvelopedData: "PKCS#7 Signed And Enveloped Data",
+	KindDigestedData:           "PKCS#7 Digested Data",
+	KindEncryptedData:          "PKCS#7 Encrypted Data",
+	KindPKCS12:                 "PKCS#12",
+	KindEncryptedPKCS12:        TypeEncryptedPKCS12,
+	KindWindowsCatalog:         "Windows Security Catalog",
+	KindJKS:                    "Java KeyStore",
+	KindJCEKS:                  "Java Cryptography Extension KeyStore",
+	KindBKS:                    "BouncyCastle KeyStore",
+	KindOpenSSHPrivateKey:      "OpenSSH Private Key",
+	KindSSHPublicKey:           "SSH Public Key",
+	KindPuTTYPrivateKey:        "PuTTY Private Key",
+	KindJWS:                    "JSON Web Signature",
+	KindJWE:                    "JSON Web Encryption",
+	KindCOSESign1:              "COSE_Sign1",
+	KindCOSESign:               "COSE_Sign",
+	KindCOSEEncrypt:            "COSE_Encrypt",
+	KindCOSEEncrypt0:           "COSE_Encrypt0",
+	KindCOSEMac:                "COSE_Mac",
+	KindCOSEMac0:               "COSE_Mac0",
+	KindCertificate:            "X.509 Certificate",
+	KindCertificateRequest:     "PKCS#10 Certificate Request",
+	KindPrivateKey:             "PKCS#8 Private Key",
+	KindEncryptedPrivateKey:    "PKCS#8 Encrypted Private Key",
+	KindPGPMessage:             "OpenPGP Message",
+	KindPGPPublicKey:           "OpenPGP Public Key",
+	KindPGPPrivateKey:          "OpenPGP Private Key",
+	KindPGPSignature:           "OpenPGP Signature",
+	KindESTCertsOnly:           "EST Certs-Only Response",
+	KindCMPMessage:             "CMP PKIMessage",
+	KindXMLSignature:           "XML Signature",
+	KindXAdES:                  "XAdES Signature",
+}
+
+// String returns a human-readable name of the kind
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// MarshalText returns the name of the kind as reported by String, for kinds defined by the package
+func (k Kind) MarshalText() ([]byte, error) {
+	name, ok := kindNames[k]
+	if !ok {
+		return nil, fmt.Errorf("undefined kind %d", int(k))
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText sets the kind from its name as reported by String, ignoring case
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if strings.EqualFold(name, string(text)) {
+			*k = kind
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown kind %q", text)
+}
+
+// coreKinds maps the kinds of the core package, which implements KindOf, to the kinds of the package
+var coreKinds = [...]Kind{
+	core.KindUnknown:                KindUnknown,
+	core.KindData:                   KindData,
+	core.KindSignedData:             KindSignedData,
+	core.KindEnvelopedData:          KindEnvelopedData,
+	core.KindSignedAndEnvelopedData: KindSignedAndEnvelopedData,
+	core.KindDigestedData:           KindDigestedData,
+	core.KindEncryptedData:          KindEncryptedData,
+	core.KindPKCS12:                 KindPKCS12,
+	core.KindWindowsCatalog:         KindWindowsCatalog,
+}
+
+// kindOfContentInfo determines the kind of the structure wrapped in ContentInfo
+func kindOfContentInfo(contentInfo ContentInfo) Kind {
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7DataOID):
+		return KindData
+	case contentInfo.ContentType.Equal(PKCS7SignedDataOID):
+		// Security catalogs are SignedData with their own content type
+		if sd, err := parseSignedData(contentInfo); err == nil && sd.EncapContentInfo.EContentType.Equal(WindowsCatalogOID) {
+			return KindWindowsCatalog
+		}
+
+		return KindSignedData
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		return KindEnvelopedData
+	case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
+		return KindSignedAndEnvelopedData
+	case contentInfo.ContentType.Equal(PKCS7DigestedDataOID):
+		return KindDigestedData
+	case contentInfo.ContentType.Equal(PKCS7EncryptedDataOID):
+		return KindEncryptedData
+	case contentInfo.ContentType.Equal(PKCS12OID):
+		return KindPKCS12
+	default:
+		info, _ := lookupRegisteredOID(contentInfo.ContentType)
+
+		return info.Kind
+	}
+}
+
+// KindOf classifies CMS/PKCS data by its DER header without allocating: the content type of a
+// ContentInfo, security catalogs and PKCS#12 PFX containers. It is meant for high-throughput
+// sniffing and, unlike Detect, does not validate the content beyond the fields it reads.
+// OIDs registered with RegisterOID and formats other than CMS/PKCS#12 report KindUnknown. The
+// classification is implemented by the core package, e.g. for use in WebAssembly
+func KindOf(data []byte) Kind {
+	return coreKinds[core.KindOf(data)]
+}
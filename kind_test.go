@@ -0,0 +1,211 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// createWindowsCatalog creates ASN.1 encoded SignedData with the security catalog content type
+func createWindowsCatalog(t testing.TB) []byte {
+	t.Helper()
+
+	sd := signedData{
+		Version:          1,
+		EncapContentInfo: encapsulatedContentInfo{EContentType: WindowsCatalogOID},
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// TestDetectKind tests the kind reported by Detect
+func TestDetectKind(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedKind Kind
+		expectedType string
+	}{
+		{
+			name:         "PKCS#7 Signed Data",
+			data:         createTestData(t, PKCS7SignedDataOID),
+			expectedKind: KindSignedData,
+			expectedType: "PKCS#7 Signed Data",
+		},
+		{
+			name:         "Windows security catalog",
+			data:         createWindowsCatalog(t),
+			expectedKind: KindWindowsCatalog,
+			expectedType: "Windows Security Catalog",
+		},
+		{
+			name:         "PKCS#12",
+			data:         createTestData(t, PKCS12OID),
+			expectedKind: KindPKCS12,
+			expectedType: "PKCS#12",
+		},
+		{
+			name:         "Unknown OID",
+			data:         createTestData(t, asn1.ObjectIdentifier{1, 2, 3, 4}),
+			expectedKind: KindUnknown,
+			expectedType: "Unknown OID: 1.2.3.4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if result.Kind != tt.expectedKind {
+					t.Errorf("Expected kind %v, got %v", tt.expectedKind, result.Kind)
+				}
+
+				if result.Type != tt.expectedType {
+					t.Errorf("Expected type %s, got %s", tt.expectedType, result.Type)
+				}
+			},
+		)
+	}
+}
+
+// TestIsWindowsCatalog tests detection of Microsoft security catalogs
+func TestIsWindowsCatalog(t *testing.T) {
+	catalog := createWindowsCatalog(t)
+
+	if !IsWindowsCatalog(catalog) {
+		t.Error("Expected security catalog to be detected")
+	}
+
+	if !IsPKCS7SignedData(catalog) {
+		t.Error("Expected security catalog to be reported as PKCS#7 Signed Data")
+	}
+
+	if IsWindowsCatalog(createTestData(t, PKCS7SignedDataOID)) {
+		t.Error("Expected generic SignedData not to be detected as security catalog")
+	}
+}
+
+// TestKindString tests the names of kinds
+func TestKindString(t *testing.T) {
+	if name := KindEncryptedPKCS12.String(); name != TypeEncryptedPKCS12 {
+		t.Errorf("Expected %s, got %s", TypeEncryptedPKCS12, name)
+	}
+
+	if name := Kind(-1).String(); name != "Kind(-1)" {
+		t.Errorf("Expected Kind(-1), got %s", name)
+	}
+}
+
+// TestKindOf tests header-only classification
+func TestKindOf(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	signedData := createSignedData(t, sha256OID, rsaOID)
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedKind Kind
+	}{
+		{
+			name:         "PKCS#7 Data",
+			data:         createTestData(t, PKCS7DataOID),
+			expectedKind: KindData,
+		},
+		{
+			name:         "PKCS#7 Signed Data",
+			data:         signedData,
+			expectedKind: KindSignedData,
+		},
+		{
+			name:         "PKCS#7 Encrypted Data",
+			data:         createTestData(t, PKCS7EncryptedDataOID),
+			expectedKind: KindEncryptedData,
+		},
+		{
+			name:         "Windows security catalog",
+			data:         createWindowsCatalog(t),
+			expectedKind: KindWindowsCatalog,
+		},
+		{
+			name:         "PKCS#12 PFX",
+			data:         createPFX(t, nil, nil),
+			expectedKind: KindPKCS12,
+		},
+		{
+			name:         "Unknown OID",
+			data:         createTestData(t, asn1.ObjectIdentifier{1, 2, 3, 4}),
+			expectedKind: KindUnknown,
+		},
+		{
+			name:         "Truncated",
+			data:         signedData[:len(signedData)-1],
+			expectedKind: KindUnknown,
+		},
+		{
+			name:         "Not DER",
+			data:         []byte("-----BEGIN PKCS7-----"),
+			expectedKind: KindUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if kind := KindOf(tt.data); kind != tt.expectedKind {
+					t.Errorf("Expected kind %s, got %s", tt.expectedKind, kind)
+				}
+			},
+		)
+	}
+}
+
+// TestKindOfAllocations tests that KindOf does not allocate
+func TestKindOfAllocations(t *testing.T) {
+	catalog := createWindowsCatalog(t)
+	pfx := createPFX(t, nil, nil)
+
+	allocs := testing.AllocsPerRun(
+		100, func() {
+			KindOf(catalog)
+			KindOf(pfx)
+		},
+	)
+
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+// BenchmarkKindOf measures header-only classification of SignedData
+func BenchmarkKindOf(b *testing.B) {
+	data := createSignedData(b, asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if KindOf(data) != KindSignedData {
+			b.Fatal("Expected SignedData")
+		}
+	}
+}
+
+// TestCoreKinds tests that every kind of the core package maps to the kind of the same name
+func TestCoreKinds(t *testing.T) {
+	for k := core.KindUnknown; int(k) < len(coreKinds); k++ {
+		if kind := coreKinds[k]; kind.String() != k.String() {
+			t.Errorf("Expected %s for core kind %d, got %s", k, int(k), kind)
+		}
+	}
+
+	if name := core.Kind(len(coreKinds)).String(); name != fmt.Sprintf("Kind(%d)", len(coreKinds)) {
+		t.Errorf("Expected core kinds to end at %d, got %s", len(coreKinds)-1, name)
+	}
+}
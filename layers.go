@@ -0,0 +1,136 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// Content types of RFC 4073 wrapping other ContentInfo structures
+var (
+	ContentCollectionOID     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 19}
+	ContentWithAttributesOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 20}
+)
+
+// Layer is a content type of a CMS structure with the content types nested in it
+type Layer struct {
+	ContentType asn1.ObjectIdentifier
+	Kind        Kind    // Kind of the content, KindUnknown for content types without a kind
+	Type        string  // Name of the kind or description of the content type
+	Encrypted   bool    // Indicates encrypted content whose layers cannot be inspected
+	Detached    bool    // Indicates encapsulated content that is not included
+	Size        int64   // Size of the encapsulated or encrypted content, or of the content of a ContentInfo
+	Layers      []Layer // Encapsulated or encrypted content, members of a ContentCollection or the content of ContentWithAttributes
+}
+
+// contentWithAttributes provides the ASN.1 structure of ContentWithAttributes (RFC 4073, section 3)
+type contentWithAttributes struct {
+	Content ContentInfo
+	Attrs   []attribute
+}
+
+// InspectLayers returns the layers of a CMS structure: the content encapsulated in SignedData and
+// DigestedData, the type of encrypted content, and every ContentInfo of a ContentCollection or
+// ContentWithAttributes (RFC 4073), each classified like Detect. Nesting is bounded by
+// Limits.MaxNestingDepth
+func InspectLayers(data []byte) (layer *Layer, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	root := inspectLayer(contentInfo, 0)
+
+	return &root, nil
+}
+
+// inspectLayer classifies the ContentInfo and inspects the layers nested in it
+func inspectLayer(contentInfo ContentInfo, depth int) Layer {
+	layer := newLayer(contentInfo)
+	layer.Size = contentInfoSize(contentInfo)
+
+	if exceedsNestingDepth(depth) {
+		return layer
+	}
+
+	switch contentType := contentInfo.ContentType; {
+	case contentType.Equal(PKCS7SignedDataOID):
+		if sd, err := parseSignedData(contentInfo); err == nil {
+			layer.Layers = []Layer{encapsulatedLayer(sd.EncapContentInfo, depth+1)}
+		}
+	case contentType.Equal(PKCS7DigestedDataOID):
+		if dd, err := parseDigestedData(contentInfo); err == nil {
+			layer.Layers = []Layer{encapsulatedLayer(dd.EncapContentInfo, depth+1)}
+		}
+	case contentType.Equal(ContentCollectionOID):
+		var members []ContentInfo
+		if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &members); err == nil {
+			for _, member := range members {
+				layer.Layers = append(layer.Layers, inspectLayer(member, depth+1))
+			}
+		}
+	case contentType.Equal(ContentWithAttributesOID):
+		var cwa contentWithAttributes
+		if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &cwa); err == nil {
+			layer.Layers = []Layer{inspectLayer(cwa.Content, depth+1)}
+		}
+	default:
+		if eci, ok := encryptedContentOf(contentInfo); ok {
+			inner := newLayer(ContentInfo{ContentType: eci.ContentType})
+			inner.Encrypted = true
+			inner.Size = int64(len(eci.EncryptedContent.Bytes))
+			layer.Layers = []Layer{inner}
+		}
+	}
+
+	return layer
+}
+
+// encapsulatedLayer inspects the encapsulated content, which is the DER encoding of the content
+// of a ContentInfo with the eContentType
+func encapsulatedLayer(eci encapsulatedContentInfo, depth int) Layer {
+	content, ok := encapsulatedContent(eci)
+	if !ok {
+		layer := newLayer(ContentInfo{ContentType: eci.EContentType})
+		layer.Detached = len(eci.EContent.FullBytes) == 0
+
+		return layer
+	}
+
+	layer := inspectLayer(ContentInfo{ContentType: eci.EContentType, Content: asn1.RawValue{Bytes: content}}, depth)
+	layer.Size = int64(len(content))
+
+	return layer
+}
+
+// contentInfoSize returns the size of the content of a ContentInfo, the octets of the OCTET STRING
+// for Data
+func contentInfoSize(contentInfo ContentInfo) int64 {
+	if contentInfo.ContentType.Equal(PKCS7DataOID) {
+		var octets asn1.RawValue
+		if rest, err := asn1.Unmarshal(contentInfo.Content.Bytes, &octets); err == nil && len(rest) == 0 &&
+			octets.Class == asn1.ClassUniversal && octets.Tag == asn1.TagOctetString && !octets.IsCompound {
+			return int64(len(octets.Bytes))
+		}
+	}
+
+	return int64(len(contentInfo.Content.Bytes))
+}
+
+// newLayer returns the layer of the ContentInfo without its nested layers
+func newLayer(contentInfo ContentInfo) Layer {
+	layer := Layer{ContentType: contentInfo.ContentType, Kind: kindOfContentInfo(contentInfo)}
+
+	if layer.Kind == KindUnknown {
+		layer.Type = GetOIDDescription(contentInfo.ContentType)
+	} else {
+		layer.Type = layer.Kind.String()
+	}
+
+	return layer
+}
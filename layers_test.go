@@ -0,0 +1,197 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// formatLayers formats the layer and its nested layers as "Type[Nested, ...]"
+func formatLayers(layer Layer) string {
+	var b strings.Builder
+
+	b.WriteString(layer.Type)
+
+	if layer.Encrypted {
+		b.WriteString(" (encrypted)")
+	}
+
+	if layer.Detached {
+		b.WriteString(" (detached)")
+	}
+
+	if len(layer.Layers) > 0 {
+		nested := make([]string, 0, len(layer.Layers))
+		for _, inner := range layer.Layers {
+			nested = append(nested, formatLayers(inner))
+		}
+
+		b.WriteString("[" + strings.Join(nested, ", ") + "]")
+	}
+
+	return b.String()
+}
+
+// TestInspectLayers tests the layers reported for nested and collected content
+func TestInspectLayers(t *testing.T) {
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	signed := createSignedDataWithContent(t, []byte("content"))
+	enveloped := createEnvelopedData(t, rsaEncryptionOID, aesOID)
+
+	withAttributes := createContentInfo(
+		t, ContentWithAttributesOID, struct {
+			Content asn1.RawValue
+			Attrs   []attribute
+		}{
+			Content: asn1.RawValue{FullBytes: createContentInfo(t, PKCS7DataOID, []byte("content"))},
+			Attrs:   []attribute{createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)},
+		},
+	)
+
+	members := []asn1.RawValue{{FullBytes: signed}, {FullBytes: enveloped}, {FullBytes: withAttributes}}
+
+	collection, err := asn1.Marshal(members)
+	if err != nil {
+		t.Fatalf("Failed to marshal content collection: %v", err)
+	}
+
+	octets, err := asn1.Marshal(collection)
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	signedCollection := createContentInfo(
+		t, PKCS7SignedDataOID, signedData{
+			Version: 3,
+			EncapContentInfo: encapsulatedContentInfo{
+				EContentType: ContentCollectionOID,
+				EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets},
+			},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "Signed data",
+			data:     signed,
+			expected: "PKCS#7 Signed Data[PKCS#7 Data]",
+		},
+		{
+			name:     "Detached signature",
+			data:     createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			expected: "PKCS#7 Signed Data[PKCS#7 Data (detached)]",
+		},
+		{
+			name:     "Enveloped data",
+			data:     enveloped,
+			expected: "PKCS#7 Enveloped Data[PKCS#7 Data (encrypted)]",
+		},
+		{
+			name:     "Content collection",
+			data:     createContentInfo(t, ContentCollectionOID, members),
+			expected: "Content Collection[PKCS#7 Signed Data[PKCS#7 Data], PKCS#7 Enveloped Data[PKCS#7 Data (encrypted)], Content With Attributes[PKCS#7 Data]]",
+		},
+		{
+			name:     "Signed content collection",
+			data:     signedCollection,
+			expected: "PKCS#7 Signed Data[Content Collection[PKCS#7 Signed Data[PKCS#7 Data], PKCS#7 Enveloped Data[PKCS#7 Data (encrypted)], Content With Attributes[PKCS#7 Data]]]",
+		},
+		{
+			name:     "Malformed content collection",
+			data:     createContentInfo(t, ContentCollectionOID, 1),
+			expected: "Content Collection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				layer, err := InspectLayers(tt.data)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if got := formatLayers(*layer); got != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, got)
+				}
+			},
+		)
+	}
+}
+
+// TestInspectLayersNestingDepth tests that nested collections are inspected up to the nesting depth limit
+func TestInspectLayersNestingDepth(t *testing.T) {
+	withLimits(t, Limits{MaxNestingDepth: 2})
+
+	data := createContentInfo(t, PKCS7DataOID, []byte("content"))
+	for i := 0; i < 4; i++ {
+		data = createContentInfo(t, ContentCollectionOID, []asn1.RawValue{{FullBytes: data}})
+	}
+
+	layer, err := InspectLayers(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got, expected := formatLayers(*layer), "Content Collection[Content Collection[Content Collection]]"; got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+// TestInspectLayersSize tests the content size reported for the innermost layer
+func TestInspectLayersSize(t *testing.T) {
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected int64
+	}{
+		{
+			name:     "Signed data",
+			data:     createSignedDataWithContent(t, []byte("content")),
+			expected: 7,
+		},
+		{
+			name:     "Detached signature",
+			data:     createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			expected: 0,
+		},
+		{
+			name:     "Enveloped data",
+			data:     createEnvelopedData(t, rsaEncryptionOID, aesOID),
+			expected: 2,
+		},
+		{
+			name:     "Data",
+			data:     createContentInfo(t, PKCS7DataOID, []byte("content")),
+			expected: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				layer, err := InspectLayers(tt.data)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				for len(layer.Layers) > 0 {
+					layer = &layer.Layers[0]
+				}
+
+				if layer.Size != tt.expected {
+					t.Errorf("Expected size %d, got %d", tt.expected, layer.Size)
+				}
+			},
+		)
+	}
+}
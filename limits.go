@@ -0,0 +1,131 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrInputTooLarge is returned for inputs exceeding Limits.MaxInputSize
+var ErrInputTooLarge = errors.New("input exceeds the maximum size")
+
+// Limits bounds the resources spent on untrusted input. A zero field disables the limit
+type Limits struct {
+	MaxInputSize     int // Maximum size of inspected data in bytes
+	MaxNestingDepth  int // Maximum nesting of countersignatures, MIME entities, CBOR items and content layers
+	MaxScanWindow    int // Maximum number of leading bytes searched by heuristic byte scans
+	MaxPBEIterations int // Maximum iteration count of password-based key derivations, e.g. of PKCS#12 containers
+	MaxUnpackDepth   int // Maximum nesting of archives, e-mail messages and PDF documents unpacked by Scanner
+	MaxAllocation    int // Maximum size of a buffer allocated by one decoding operation, e.g. of an archive member
+}
+
+// limits holds the limits in effect, guarded by limitsMu
+var (
+	limitsMu sync.RWMutex
+	limits   = DefaultLimits()
+)
+
+// DefaultLimits returns the limits in effect unless changed with SetLimits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxInputSize:     64 << 20,
+		MaxNestingDepth:  16,
+		MaxScanWindow:    1 << 20,
+		MaxPBEIterations: 100000,
+		MaxUnpackDepth:   4,
+		MaxAllocation:    64 << 20,
+	}
+}
+
+// SetLimits replaces the limits enforced by all detection functions. It is safe for concurrent use
+func SetLimits(l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+
+	limits = l
+}
+
+// CurrentLimits returns the limits in effect
+func CurrentLimits() Limits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+
+	return limits
+}
+
+// checkInputSize returns ErrInputTooLarge if the data exceeds the maximum input size
+func checkInputSize(data []byte) error {
+	return checkInputLength(len(data))
+}
+
+// checkInputLength returns ErrInputTooLarge if an input of n bytes exceeds the maximum input size
+func checkInputLength(n int) error {
+	if limit := CurrentLimits().MaxInputSize; limit > 0 && n > limit {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrInputTooLarge, n, limit)
+	}
+
+	return nil
+}
+
+// exceedsNestingDepth checks if the nesting depth reaches the maximum nesting depth
+func exceedsNestingDepth(depth int) bool {
+	limit := CurrentLimits().MaxNestingDepth
+
+	return limit > 0 && depth >= limit
+}
+
+// scanWindowSize returns the number of leading bytes searched by heuristic byte scans, zero for all
+func scanWindowSize() int {
+	if limit := CurrentLimits().MaxScanWindow; limit > 0 {
+		return limit
+	}
+
+	return 0
+}
+
+// scanWindow returns the leading part of the data searched by heuristic byte scans
+func scanWindow(data []byte) []byte {
+	if limit := CurrentLimits().MaxScanWindow; limit > 0 && len(data) > limit {
+		return data[:limit]
+	}
+
+	return data
+}
+
+// sizeLimitedReader fails with ErrInputTooLarge once more than the maximum input size is read
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int
+}
+
+// newSizeLimitedReader limits the reader to the maximum input size, if any
+func newSizeLimitedReader(r io.Reader) io.Reader {
+	limit := CurrentLimits().MaxInputSize
+	if limit <= 0 {
+		return r
+	}
+
+	return &sizeLimitedReader{r: r, remaining: limit}
+}
+
+// Read implements io.Reader
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrInputTooLarge
+	}
+
+	// Read one byte past the limit to tell inputs of exactly the maximum size from larger ones
+	if len(p) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+
+	l.remaining -= n
+	if l.remaining < 0 {
+		return n + l.remaining, ErrInputTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,241 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// withLimits applies the limits for the duration of the test
+func withLimits(t *testing.T, l Limits) {
+	t.Helper()
+
+	previous := CurrentLimits()
+	SetLimits(l)
+
+	t.Cleanup(
+		func() {
+			SetLimits(previous)
+		},
+	)
+}
+
+// TestMaxInputSize tests rejection of oversized inputs by the detection functions
+func TestMaxInputSize(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	data := createSignedData(t, sha256OID, rsaOID)
+	withLimits(t, Limits{MaxInputSize: len(data) - 1})
+
+	tests := []struct {
+		name   string
+		detect func([]byte) error
+	}{
+		{
+			name:   "Detect",
+			detect: func(data []byte) error { _, err := Detect(data); return err },
+		},
+		{
+			name:   "DetectAny",
+			detect: func(data []byte) error { _, err := DetectAny(data); return err },
+		},
+		{
+			name:   "InspectAlgorithms",
+			detect: func(data []byte) error { _, err := InspectAlgorithms(data); return err },
+		},
+		{
+			name:   "SigningTimes",
+			detect: func(data []byte) error { _, err := SigningTimes(data); return err },
+		},
+		{
+			name:   "DetectKeystore",
+			detect: func(data []byte) error { _, err := DetectKeystore(data); return err },
+		},
+		{
+			name:   "DetectSSHKey",
+			detect: func(data []byte) error { _, err := DetectSSHKey(data); return err },
+		},
+		{
+			name:   "DetectJOSE",
+			detect: func(data []byte) error { _, err := DetectJOSE(data); return err },
+		},
+		{
+			name:   "DetectCOSE",
+			detect: func(data []byte) error { _, err := DetectCOSE(data); return err },
+		},
+		{
+			name:   "DetectSMIME",
+			detect: func(data []byte) error { _, err := DetectSMIME(data); return err },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if err := tt.detect(data); !errors.Is(err, ErrInputTooLarge) {
+					t.Errorf("Expected error %v, got %v", ErrInputTooLarge, err)
+				}
+			},
+		)
+	}
+
+	SetLimits(Limits{MaxInputSize: len(data)})
+
+	if _, err := Detect(data); err != nil {
+		t.Errorf("Expected input of exactly the maximum size to be accepted, got %v", err)
+	}
+}
+
+// TestDetectEmailMaxInputSize tests that DetectEmail stops reading oversized messages
+func TestDetectEmailMaxInputSize(t *testing.T) {
+	message := "From: alice@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n" +
+		"--b\r\nContent-Type: text/plain\r\n\r\n" + strings.Repeat("x", 1024) + "\r\n--b--\r\n"
+
+	withLimits(t, Limits{MaxInputSize: len(message)})
+
+	if _, err := DetectEmail(strings.NewReader(message)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	SetLimits(Limits{MaxInputSize: len(message) - 1})
+
+	if _, err := DetectEmail(strings.NewReader(message)); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Expected error %v, got %v", ErrInputTooLarge, err)
+	}
+}
+
+// TestMaxNestingDepth tests that deeply nested MIME entities are not inspected
+func TestMaxNestingDepth(t *testing.T) {
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	message := "From: alice@example.com\r\nContent-Type: multipart/mixed; boundary=outer\r\n\r\n" +
+		"--outer\r\nContent-Type: multipart/mixed; boundary=inner\r\n\r\n" +
+		"--inner\r\nContent-Type: application/pkcs7-mime; name=smime.p7m\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(createEnvelopedData(t, rsaOID, aesOID)) + "\r\n" +
+		"--inner--\r\n--outer--\r\n"
+
+	tests := []struct {
+		name            string
+		maxDepth        int
+		expectedResults int
+	}{
+		{
+			name:            "Within limit",
+			maxDepth:        2,
+			expectedResults: 1,
+		},
+		{
+			name:            "Too deep",
+			maxDepth:        1,
+			expectedResults: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withLimits(t, Limits{MaxNestingDepth: tt.maxDepth})
+
+				results, err := DetectEmail(strings.NewReader(message))
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+
+				if len(results) != tt.expectedResults {
+					t.Errorf("Expected %d results, got %d", tt.expectedResults, len(results))
+				}
+			},
+		)
+	}
+}
+
+// TestMaxScanWindow tests that heuristic scans only search the leading bytes
+func TestMaxScanWindow(t *testing.T) {
+	data := createMockPKCS12Key(t)
+
+	if !IsPKCS12(data) {
+		t.Fatal("Expected mock key container to be detected without a scan window")
+	}
+
+	// The key marker lies beyond the first 20 bytes
+	withLimits(t, Limits{MaxScanWindow: 20})
+
+	if IsPKCS12(data) {
+		t.Error("Expected marker outside the scan window to be ignored")
+	}
+
+	if window := scanWindow(data); !bytes.Equal(window, data[:20]) {
+		t.Errorf("Expected window of 20 bytes, got %d", len(window))
+	}
+}
+
+// TestMaxAllocation tests rejection of decoded data exceeding the maximum allocation
+func TestMaxAllocation(t *testing.T) {
+	member := bytes.Repeat([]byte{0x42}, 256)
+
+	tests := []struct {
+		name   string
+		decode func() error
+	}{
+		{
+			name: "ZIP member",
+			decode: func() error {
+				archive := createZIP(t, []archiveMember{{name: "large.bin", data: member}})
+				files, err := DetectArchive(bytes.NewReader(archive), int64(len(archive)))
+				if err != nil {
+					return err
+				}
+
+				return files[0].Err
+			},
+		},
+		{
+			name: "TAR member",
+			decode: func() error {
+				archive := createTAR(t, []archiveMember{{name: "large.bin", data: member}}, true)
+				files, err := DetectArchive(bytes.NewReader(archive), int64(len(archive)))
+				if err != nil {
+					return err
+				}
+
+				return files[0].Err
+			},
+		},
+		{
+			name: "Base64 body",
+			decode: func() error {
+				_, err := decodeTransferEncoding("base64", []byte(wrapBase64(member)))
+				return err
+			},
+		},
+		{
+			name: "BER re-encoding",
+			decode: func() error {
+				_, err := ToDER(append([]byte{0x24, 0x80, 0x04, 0x82, 0x01, 0x00}, append(member, 0x00, 0x00)...))
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withLimits(t, Limits{MaxAllocation: len(member) - 1})
+
+				if err := tt.decode(); !errors.Is(err, ErrInputTooLarge) {
+					t.Errorf("Expected error %v, got %v", ErrInputTooLarge, err)
+				}
+
+				SetLimits(Limits{MaxAllocation: 2 * len(member)})
+
+				if err := tt.decode(); errors.Is(err, ErrInputTooLarge) {
+					t.Errorf("Expected data within the limit to be decoded, got %v", err)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,493 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// Severity tells how serious a Diagnostic is
+type Severity int
+
+// Severities of lint diagnostics
+const (
+	SeverityWarning Severity = iota // Permitted by the standards, but a common cause of interoperability failures
+	SeverityError                   // Violates a requirement of the standards
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Rules reported by Lint
+const (
+	RuleMalformed           = "malformed"                 // Invalid BER, or an element of an unexpected type
+	RuleBEREncoding         = "ber-encoding"              // Indefinite length or constructed string encoding
+	RuleNonCanonicalLength  = "non-canonical-length"      // Length not encoded in the shortest form
+	RuleUnsortedSet         = "unsorted-set"              // SET OF elements not in DER order
+	RuleTrailingData        = "trailing-data"             // Bytes following the outer element
+	RuleMissingField        = "missing-field"             // Mandatory field absent
+	RuleVersion             = "version"                   // Version number not matching the structure content
+	RuleContentTypeMismatch = "content-type-mismatch"     // content-type attribute differs from eContentType
+	RuleUnlistedDigest      = "unlisted-digest-algorithm" // Signer digest algorithm not listed in digestAlgorithms
+	RuleAlgorithmProtection = "algorithm-protection"      // cmsAlgorithmProtect attribute differs from the signer algorithms
+)
+
+// Diagnostic describes a structural issue found by Lint
+type Diagnostic struct {
+	Severity Severity
+	Rule     string
+	Offset   int // Offset of the offending element in the input
+	Message  string
+}
+
+// String formats the diagnostic for logs and command line output
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at offset %d: %s [%s]", d.Severity, d.Offset, d.Message, d.Rule)
+}
+
+// Lint checks a CMS ContentInfo or PKCS#12 PFX for conformance with the DER rules of X.690 and the
+// structure of RFC 5652 and RFC 7292: BER encodings, non-canonical lengths, mandatory fields,
+// version numbers and content type attributes. BER is reported as a warning, except in signed
+// attributes and certificates which must be DER encoded. The diagnostics are sorted by offset;
+// an empty result means no issues were found
+func Lint(data []byte) []Diagnostic {
+	l := &linter{data: data}
+
+	if err := checkInputSize(data); err != nil {
+		l.report(SeverityError, RuleMalformed, 0, err.Error())
+
+		return l.diagnostics
+	}
+
+	root, err := l.parse(0, len(data), 0)
+	if err != nil {
+		l.report(SeverityError, RuleMalformed, err.offset, err.message)
+
+		return l.finish()
+	}
+
+	if root.end < len(data) {
+		l.report(SeverityWarning, RuleTrailingData, root.end, fmt.Sprintf("%d bytes following the outer element", len(data)-root.end))
+	}
+
+	if isPFX(root) {
+		l.checkPFX(root)
+	} else {
+		l.checkContentInfo(root, "ContentInfo")
+	}
+
+	return l.finish()
+}
+
+// isPFX checks if the element is laid out like a PKCS#12 PFX: a version followed by a ContentInfo
+func isPFX(e *berElement) bool {
+	if len(e.children) < 2 || !e.children[0].isUniversal(asn1.TagInteger) {
+		return false
+	}
+
+	authSafe := e.children[1].children
+
+	return len(authSafe) == 2 && authSafe[0].isUniversal(asn1.TagOID) && authSafe[1].isContextSpecific(0)
+}
+
+// berElement is an element of a BER encoding parsed by Lint
+type berElement struct {
+	offset      int // Offset of the identifier octets
+	identifier  []byte
+	end         int // Offset following the element, including end-of-contents octets
+	class       int
+	tag         int
+	constructed bool
+	contents    []byte        // Contents octets of primitive elements
+	children    []*berElement // Elements nested in constructed elements
+}
+
+// isUniversal checks if the element has the given universal tag
+func (e *berElement) isUniversal(tag int) bool {
+	return e.class == asn1.ClassUniversal && e.tag == tag
+}
+
+// isContextSpecific checks if the element has the given context-specific tag
+func (e *berElement) isContextSpecific(tag int) bool {
+	return e.class == asn1.ClassContextSpecific && e.tag == tag
+}
+
+// lintError is a BER decoding error at an offset of the input
+type lintError struct {
+	offset  int
+	message string
+}
+
+// derRange is a part of the input that must be DER encoded
+type derRange struct {
+	start, end int
+	what       string
+}
+
+// linter collects the diagnostics of an input
+type linter struct {
+	data        []byte
+	diagnostics []Diagnostic
+	derRanges   []derRange
+}
+
+// report adds a diagnostic
+func (l *linter) report(severity Severity, rule string, offset int, message string) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{Severity: severity, Rule: rule, Offset: offset, Message: message})
+}
+
+// requireDER marks the encoding of the element as required to be DER
+func (l *linter) requireDER(e *berElement, what string) {
+	l.derRanges = append(l.derRanges, derRange{start: e.offset, end: e.end, what: what})
+}
+
+// finish raises the severity of encoding issues in parts required to be DER and sorts the diagnostics
+func (l *linter) finish() []Diagnostic {
+	for i, d := range l.diagnostics {
+		if d.Rule != RuleBEREncoding && d.Rule != RuleNonCanonicalLength && d.Rule != RuleUnsortedSet {
+			continue
+		}
+
+		for _, r := range l.derRanges {
+			if d.Offset >= r.start && d.Offset < r.end {
+				l.diagnostics[i].Severity = SeverityError
+				l.diagnostics[i].Message += "; DER is required for " + r.what
+
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(
+		l.diagnostics, func(i, j int) bool {
+			return l.diagnostics[i].Offset < l.diagnostics[j].Offset
+		},
+	)
+
+	return l.diagnostics
+}
+
+// constructedStringTags lists the universal types DER requires to use the primitive encoding
+var constructedStringTags = map[int]string{
+	asn1.TagBitString:       "BIT STRING",
+	asn1.TagOctetString:     "OCTET STRING",
+	asn1.TagUTF8String:      "UTF8String",
+	asn1.TagNumericString:   "NumericString",
+	asn1.TagPrintableString: "PrintableString",
+	asn1.TagT61String:       "T61String",
+	asn1.TagIA5String:       "IA5String",
+	asn1.TagUTCTime:         "UTCTime",
+	asn1.TagGeneralizedTime: "GeneralizedTime",
+	asn1.TagGeneralString:   "GeneralString",
+	asn1.TagBMPString:       "BMPString",
+}
+
+// parse decodes the BER element at the offset, which must end before the limit, reporting
+// encodings that are valid BER but not DER
+func (l *linter) parse(offset, limit, depth int) (*berElement, *lintError) {
+	if exceedsNestingDepth(depth) {
+		return nil, &lintError{offset: offset, message: "nesting depth exceeds the limit"}
+	}
+
+	e := &berElement{offset: offset}
+
+	pos, err := l.parseIdentifier(e, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	length, indefinite, pos, err := l.parseLength(offset, pos, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if indefinite {
+		if !e.constructed {
+			return nil, &lintError{offset: offset, message: "indefinite length of a primitive element"}
+		}
+
+		l.report(SeverityWarning, RuleBEREncoding, offset, "indefinite length")
+
+		return e, l.parseIndefinite(e, pos, limit, depth)
+	}
+
+	if length > limit-pos {
+		return nil, &lintError{offset: offset, message: fmt.Sprintf("element of %d bytes exceeds the enclosing element or input", length)}
+	}
+
+	e.end = pos + length
+
+	if !e.constructed {
+		e.contents = l.data[pos:e.end]
+
+		return e, nil
+	}
+
+	for pos < e.end {
+		child, err := l.parse(pos, e.end, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		e.children = append(e.children, child)
+		pos = child.end
+	}
+
+	l.checkConstructed(e)
+
+	return e, nil
+}
+
+// parseIndefinite decodes the elements of an indefinite length element up to the end-of-contents octets
+func (l *linter) parseIndefinite(e *berElement, pos, limit, depth int) *lintError {
+	for {
+		if limit-pos < 2 {
+			return &lintError{offset: e.offset, message: "missing end-of-contents octets"}
+		}
+
+		if l.data[pos] == 0 && l.data[pos+1] == 0 {
+			e.end = pos + 2
+			l.checkConstructed(e)
+
+			return nil
+		}
+
+		child, err := l.parse(pos, limit, depth+1)
+		if err != nil {
+			return err
+		}
+
+		e.children = append(e.children, child)
+		pos = child.end
+	}
+}
+
+// parseIdentifier decodes the identifier octets of the element, returning the offset of the length octets
+func (l *linter) parseIdentifier(e *berElement, limit int) (int, *lintError) {
+	pos := e.offset
+	if pos >= limit {
+		return 0, &lintError{offset: pos, message: "missing identifier octets"}
+	}
+
+	b := l.data[pos]
+	e.class = int(b >> 6)
+	e.constructed = b&0x20 != 0
+	e.tag = int(b & 0x1f)
+	pos++
+
+	if e.tag != 0x1f {
+		e.identifier = l.data[e.offset:pos]
+
+		return pos, nil
+	}
+
+	// High tag numbers must be minimally encoded in base 128, even in BER
+	e.tag = 0
+
+	for i := 0; ; i++ {
+		if pos >= limit || i >= derMaxLengthOctets {
+			return 0, &lintError{offset: e.offset, message: "invalid high tag number"}
+		}
+
+		b = l.data[pos]
+		if i == 0 && b == 0x80 {
+			return 0, &lintError{offset: e.offset, message: "high tag number with leading zero octet"}
+		}
+
+		e.tag = e.tag<<7 | int(b&0x7f)
+		pos++
+
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	if e.tag < 0x1f {
+		return 0, &lintError{offset: e.offset, message: fmt.Sprintf("high tag number form for tag %d", e.tag)}
+	}
+
+	e.identifier = l.data[e.offset:pos]
+
+	return pos, nil
+}
+
+// parseLength decodes the length octets at pos of the element at the offset, returning the offset of its contents
+func (l *linter) parseLength(offset, pos, limit int) (length int, indefinite bool, next int, err *lintError) {
+	if pos >= limit {
+		return 0, false, 0, &lintError{offset: offset, message: "missing length octets"}
+	}
+
+	b := l.data[pos]
+	pos++
+
+	switch {
+	case b < 0x80:
+		return int(b), false, pos, nil
+	case b == 0x80:
+		return 0, true, pos, nil
+	case b == 0xff:
+		return 0, false, 0, &lintError{offset: offset, message: "reserved length octet 0xff"}
+	}
+
+	octets := int(b & 0x7f)
+	if octets > limit-pos {
+		return 0, false, 0, &lintError{offset: offset, message: "truncated length octets"}
+	}
+
+	value := int64(0)
+	leadingZeros := 0
+
+	for i, o := range l.data[pos : pos+octets] {
+		if value == 0 && o == 0 && i < octets-1 {
+			leadingZeros++
+
+			continue
+		}
+
+		if value > int64(len(l.data)) {
+			return 0, false, 0, &lintError{offset: offset, message: "length exceeds the input"}
+		}
+
+		value = value<<8 | int64(o)
+	}
+
+	if value > int64(limit-pos-octets) {
+		return 0, false, 0, &lintError{offset: offset, message: fmt.Sprintf("element of %d bytes exceeds the enclosing element or input", value)}
+	}
+
+	switch {
+	case value < 0x80:
+		l.report(SeverityWarning, RuleNonCanonicalLength, offset, fmt.Sprintf("long form for length %d", value))
+	case leadingZeros > 0:
+		l.report(SeverityWarning, RuleNonCanonicalLength, offset, fmt.Sprintf("%d leading zero length octets", leadingZeros))
+	}
+
+	return int(value), false, pos + octets, nil
+}
+
+// checkConstructed reports constructed encodings of strings and unsorted SET OF elements
+func (l *linter) checkConstructed(e *berElement) {
+	if e.class != asn1.ClassUniversal {
+		return
+	}
+
+	if name, ok := constructedStringTags[e.tag]; ok {
+		l.report(SeverityWarning, RuleBEREncoding, e.offset, "constructed "+name)
+	}
+
+	if e.tag == asn1.TagSet {
+		l.checkSetOrder(e, "SET OF")
+	}
+}
+
+// checkSetOrder reports the first element of a SET OF that is not in ascending order of its encoding
+func (l *linter) checkSetOrder(e *berElement, what string) {
+	for i := 1; i < len(e.children); i++ {
+		prev, cur := e.children[i-1], e.children[i]
+		if bytes.Compare(l.data[prev.offset:prev.end], l.data[cur.offset:cur.end]) > 0 {
+			l.report(SeverityWarning, RuleUnsortedSet, cur.offset, what+" elements not sorted by their encodings")
+
+			return
+		}
+	}
+}
+
+// fields reads the fields of a SEQUENCE in order, reporting missing and unexpected fields
+type fields struct {
+	l         *linter
+	sequence  *berElement
+	structure string
+	next      int
+}
+
+// newFields starts reading the fields of the element, which must be a SEQUENCE
+func (l *linter) newFields(e *berElement, structure string) (*fields, bool) {
+	if !e.isUniversal(asn1.TagSequence) || !e.constructed {
+		l.report(SeverityError, RuleMalformed, e.offset, structure+" is not a SEQUENCE")
+
+		return nil, false
+	}
+
+	return &fields{l: l, sequence: e, structure: structure}, true
+}
+
+// field returns the next field if it matches, reporting it as missing unless it is optional
+func (f *fields) field(name string, optional bool, matches func(*berElement) bool) *berElement {
+	if f.next < len(f.sequence.children) && matches(f.sequence.children[f.next]) {
+		f.next++
+
+		return f.sequence.children[f.next-1]
+	}
+
+	if !optional {
+		offset := f.sequence.offset
+		if f.next < len(f.sequence.children) {
+			offset = f.sequence.children[f.next].offset
+		}
+
+		f.l.report(SeverityError, RuleMissingField, offset, fmt.Sprintf("%s %s is missing", f.structure, name))
+	}
+
+	return nil
+}
+
+// done reports elements following the last field
+func (f *fields) done() {
+	if f.next < len(f.sequence.children) {
+		f.l.report(SeverityError, RuleMalformed, f.sequence.children[f.next].offset, "unexpected element in "+f.structure)
+	}
+}
+
+// universal matches elements with the universal tag
+func universal(tag int) func(*berElement) bool {
+	return func(e *berElement) bool {
+		return e.isUniversal(tag)
+	}
+}
+
+// contextSpecific matches elements with the context-specific tag
+func contextSpecific(tag int) func(*berElement) bool {
+	return func(e *berElement) bool {
+		return e.isContextSpecific(tag)
+	}
+}
+
+// oidValue decodes an OBJECT IDENTIFIER element
+func oidValue(e *berElement) (asn1.ObjectIdentifier, bool) {
+	if e == nil || !e.isUniversal(asn1.TagOID) || e.constructed || !core.ValidOIDContents(e.contents) {
+		return nil, false
+	}
+
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(append(appendDERLength([]byte{derTagObjectIdentifier}, len(e.contents)), e.contents...), &oid); err != nil {
+		return nil, false
+	}
+
+	return oid, true
+}
+
+// intValue decodes a small INTEGER element such as a version number
+func intValue(e *berElement) (int, bool) {
+	if e == nil || !e.isUniversal(asn1.TagInteger) || e.constructed || len(e.contents) == 0 || len(e.contents) > 2 {
+		return 0, false
+	}
+
+	value := int(int8(e.contents[0]))
+	for _, b := range e.contents[1:] {
+		value = value<<8 | int(b)
+	}
+
+	return value, true
+}
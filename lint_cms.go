@@ -0,0 +1,506 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// Tags of the alternatives of the RecipientInfo CHOICE besides KeyTransRecipientInfo (RFC 5652, section 6.2)
+const recipientInfoOther = 4
+
+// Tags of the CertificateChoices and RevocationInfoChoice alternatives with other formats (RFC 5652, section 10.2)
+const (
+	certificateChoiceV1AttrCert = 1
+	certificateChoiceV2AttrCert = 2
+	certificateChoiceOther      = 3
+	revocationInfoChoiceOther   = 1
+)
+
+// checkContentInfo checks a ContentInfo and its content (RFC 5652, section 3)
+func (l *linter) checkContentInfo(e *berElement, structure string) {
+	f, ok := l.newFields(e, structure)
+	if !ok {
+		return
+	}
+
+	// Further fields are not reported for structures that are not a ContentInfo at all
+	field := f.field("contentType", false, universal(asn1.TagOID))
+	if field == nil {
+		return
+	}
+
+	contentType, ok := oidValue(field)
+	if !ok {
+		l.report(SeverityError, RuleMalformed, field.offset, "invalid OBJECT IDENTIFIER encoding")
+
+		return
+	}
+
+	explicit := f.field("content", false, contextSpecific(0))
+	f.done()
+
+	if explicit == nil {
+		return
+	}
+
+	if !explicit.constructed || len(explicit.children) != 1 {
+		l.report(SeverityError, RuleMalformed, explicit.offset, structure+" content is not a single explicitly tagged element")
+
+		return
+	}
+
+	content := explicit.children[0]
+
+	switch {
+	case contentType.Equal(PKCS7DataOID):
+		if !content.isUniversal(asn1.TagOctetString) {
+			l.report(SeverityError, RuleMalformed, content.offset, "Data content is not an OCTET STRING")
+		}
+	case contentType.Equal(PKCS7SignedDataOID):
+		l.checkSignedData(content)
+	case contentType.Equal(PKCS7EnvelopedDataOID):
+		l.checkEnvelopedData(content)
+	case contentType.Equal(PKCS7DigestedDataOID):
+		l.checkDigestedData(content)
+	case contentType.Equal(PKCS7EncryptedDataOID):
+		l.checkEncryptedData(content)
+	}
+}
+
+// checkVersion reports a version number differing from the one required for the structure content
+func (l *linter) checkVersion(e *berElement, structure string, expected int, reason string) {
+	version, ok := intValue(e)
+	if !ok || version == expected {
+		return
+	}
+
+	message := fmt.Sprintf("%s version %d, expected %d", structure, version, expected)
+	if reason != "" {
+		message += " " + reason
+	}
+
+	l.report(SeverityError, RuleVersion, e.offset, message)
+}
+
+// checkSignedData checks SignedData (RFC 5652, section 5)
+func (l *linter) checkSignedData(e *berElement) {
+	f, ok := l.newFields(e, "SignedData")
+	if !ok {
+		return
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	digestAlgorithms := f.field("digestAlgorithms", false, universal(asn1.TagSet))
+	encapContentInfo := f.field("encapContentInfo", false, universal(asn1.TagSequence))
+	certificates := f.field("certificates", true, contextSpecific(0))
+	crls := f.field("crls", true, contextSpecific(1))
+	signerInfos := f.field("signerInfos", false, universal(asn1.TagSet))
+	f.done()
+
+	eContentType, hasContentType := l.checkEncapContentInfo(encapContentInfo)
+
+	if certificates != nil {
+		for _, cert := range certificates.children {
+			l.requireDER(cert, "certificates")
+		}
+	}
+
+	listed := make(map[string]bool)
+
+	if digestAlgorithms != nil {
+		for _, alg := range digestAlgorithms.children {
+			if len(alg.children) > 0 {
+				if oid, ok := oidValue(alg.children[0]); ok {
+					listed[oid.String()] = true
+				}
+			}
+		}
+	}
+
+	signerV3 := false
+
+	if signerInfos != nil {
+		for _, si := range signerInfos.children {
+			if l.checkSignerInfo(si, eContentType, hasContentType, listed) {
+				signerV3 = true
+			}
+		}
+	}
+
+	// Version rules of RFC 5652, section 5.1
+	switch {
+	case hasOtherFormats(certificates, crls):
+		l.checkVersion(version, "SignedData", 5, "with certificates or revocation information of other formats")
+	case hasChoice(certificates, certificateChoiceV2AttrCert):
+		l.checkVersion(version, "SignedData", 4, "with version 2 attribute certificates")
+	case hasChoice(certificates, certificateChoiceV1AttrCert) || signerV3:
+		l.checkVersion(version, "SignedData", 3, "with version 3 signers or version 1 attribute certificates")
+	case hasContentType && !eContentType.Equal(PKCS7DataOID):
+		// PKCS #7 v1.5 signed data, e.g. Authenticode signatures and security catalogs, uses version 1
+		if v, ok := intValue(version); ok && v == 1 {
+			l.report(SeverityWarning, RuleVersion, version.offset, "SignedData version 1 of PKCS #7 v1.5, CMS requires version 3 for eContentType other than id-data")
+		} else {
+			l.checkVersion(version, "SignedData", 3, "for eContentType other than id-data")
+		}
+	default:
+		l.checkVersion(version, "SignedData", 1, "for id-data content and version 1 signers")
+	}
+}
+
+// checkEncapContentInfo checks EncapsulatedContentInfo, returning its eContentType
+func (l *linter) checkEncapContentInfo(e *berElement) (asn1.ObjectIdentifier, bool) {
+	if e == nil {
+		return nil, false
+	}
+
+	f, ok := l.newFields(e, "EncapsulatedContentInfo")
+	if !ok {
+		return nil, false
+	}
+
+	eContentType, ok := oidValue(f.field("eContentType", false, universal(asn1.TagOID)))
+	eContent := f.field("eContent", true, contextSpecific(0))
+	f.done()
+
+	if eContent != nil && (!eContent.constructed || len(eContent.children) != 1 || !eContent.children[0].isUniversal(asn1.TagOctetString)) {
+		l.report(SeverityError, RuleMalformed, eContent.offset, "eContent is not an explicitly tagged OCTET STRING")
+	}
+
+	return eContentType, ok
+}
+
+// checkSignerInfo checks SignerInfo (RFC 5652, section 5.3), reporting whether it has version 3
+func (l *linter) checkSignerInfo(e *berElement, eContentType asn1.ObjectIdentifier, hasContentType bool, listed map[string]bool) bool {
+	f, ok := l.newFields(e, "SignerInfo")
+	if !ok {
+		return false
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	sid := f.field(
+		"sid", false, func(e *berElement) bool {
+			return e.isUniversal(asn1.TagSequence) || e.isContextSpecific(0)
+		},
+	)
+	digestAlgorithm := f.field("digestAlgorithm", false, universal(asn1.TagSequence))
+	signedAttrs := f.field("signedAttrs", true, contextSpecific(0))
+	signatureAlgorithm := f.field("signatureAlgorithm", false, universal(asn1.TagSequence))
+	f.field("signature", false, universal(asn1.TagOctetString))
+	f.field("unsignedAttrs", true, contextSpecific(1))
+	f.done()
+
+	if sid != nil {
+		if sid.isUniversal(asn1.TagSequence) {
+			l.checkVersion(version, "SignerInfo", 1, "for a signer identified by issuer and serial number")
+		} else {
+			l.checkVersion(version, "SignerInfo", 3, "for a signer identified by subject key identifier")
+		}
+	}
+
+	if digestAlgorithm != nil && len(digestAlgorithm.children) > 0 {
+		if oid, ok := oidValue(digestAlgorithm.children[0]); ok && !listed[oid.String()] {
+			l.report(SeverityWarning, RuleUnlistedDigest, digestAlgorithm.offset, fmt.Sprintf("digest algorithm %s not listed in SignedData digestAlgorithms", oid))
+		}
+	}
+
+	if signedAttrs == nil {
+		if hasContentType && !eContentType.Equal(PKCS7DataOID) {
+			l.report(SeverityError, RuleMissingField, e.offset, "SignerInfo signedAttrs is missing, it is required for eContentType other than id-data")
+		}
+	} else {
+		l.checkSignedAttributes(signedAttrs, eContentType, hasContentType)
+		l.checkAlgorithmProtection(signedAttrs, digestAlgorithm, signatureAlgorithm)
+	}
+
+	v, _ := intValue(version)
+
+	return v == 3
+}
+
+// checkSignedAttributes checks the DER encoding and the mandatory attributes of signed attributes
+func (l *linter) checkSignedAttributes(e *berElement, eContentType asn1.ObjectIdentifier, hasContentType bool) {
+	l.requireDER(e, "signed attributes")
+	l.checkSetOrder(e, "signedAttrs")
+
+	var contentType, messageDigest *berElement
+
+	for _, attr := range e.children {
+		if !attr.isUniversal(asn1.TagSequence) || len(attr.children) != 2 || !attr.children[1].isUniversal(asn1.TagSet) {
+			l.report(SeverityError, RuleMalformed, attr.offset, "signed attribute is not a SEQUENCE of type and SET of values")
+
+			continue
+		}
+
+		attrType, _ := oidValue(attr.children[0])
+
+		switch {
+		case attrType.Equal(ContentTypeAttributeOID):
+			contentType = attr.children[1]
+		case attrType.Equal(MessageDigestAttributeOID):
+			messageDigest = attr.children[1]
+		}
+	}
+
+	// Both attributes are mandatory when signed attributes are present (RFC 5652, section 11)
+	if messageDigest == nil {
+		l.report(SeverityError, RuleMissingField, e.offset, "signedAttrs message-digest attribute is missing")
+	}
+
+	if contentType == nil {
+		l.report(SeverityError, RuleMissingField, e.offset, "signedAttrs content-type attribute is missing")
+
+		return
+	}
+
+	if len(contentType.children) != 1 {
+		l.report(SeverityError, RuleMalformed, contentType.offset, "content-type attribute must have a single value")
+
+		return
+	}
+
+	value, ok := oidValue(contentType.children[0])
+	if ok && hasContentType && !value.Equal(eContentType) {
+		l.report(
+			SeverityError, RuleContentTypeMismatch, contentType.children[0].offset,
+			fmt.Sprintf("content-type attribute %s differs from eContentType %s", value, eContentType),
+		)
+	}
+}
+
+// checkAlgorithmProtection checks that the cmsAlgorithmProtect attribute declares the signer algorithms (RFC 6211)
+func (l *linter) checkAlgorithmProtection(signedAttrs, digestAlgorithm, signatureAlgorithm *berElement) {
+	if digestAlgorithm == nil || signatureAlgorithm == nil {
+		return
+	}
+
+	var digest, signature pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(l.data[digestAlgorithm.offset:digestAlgorithm.end], &digest); err != nil {
+		return
+	}
+
+	if _, err := asn1.Unmarshal(l.data[signatureAlgorithm.offset:signatureAlgorithm.end], &signature); err != nil {
+		return
+	}
+
+	for _, attr := range signedAttrs.children {
+		if len(attr.children) != 2 {
+			continue
+		}
+
+		if attrType, _ := oidValue(attr.children[0]); !attrType.Equal(CMSAlgorithmProtectionAttributeOID) {
+			continue
+		}
+
+		for _, value := range attr.children[1].children {
+			protection, err := checkAlgorithmProtection(l.data[value.offset:value.end], digest, signature)
+			if err != nil {
+				l.report(SeverityError, RuleMalformed, value.offset, "malformed cmsAlgorithmProtect attribute")
+
+				continue
+			}
+
+			if !protection.Matches {
+				l.report(
+					SeverityError, RuleAlgorithmProtection, value.offset,
+					fmt.Sprintf("cmsAlgorithmProtect attribute differs from digest algorithm %s and signature algorithm %s", digest.Algorithm, signature.Algorithm),
+				)
+			}
+		}
+	}
+}
+
+// checkEnvelopedData checks EnvelopedData (RFC 5652, section 6)
+func (l *linter) checkEnvelopedData(e *berElement) {
+	f, ok := l.newFields(e, "EnvelopedData")
+	if !ok {
+		return
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	originatorInfo := f.field("originatorInfo", true, contextSpecific(0))
+	recipientInfos := f.field("recipientInfos", false, universal(asn1.TagSet))
+	encryptedContentInfo := f.field("encryptedContentInfo", false, universal(asn1.TagSequence))
+	unprotectedAttrs := f.field("unprotectedAttrs", true, contextSpecific(1))
+	f.done()
+
+	l.checkEncryptedContentInfo(encryptedContentInfo)
+
+	if recipientInfos == nil {
+		return
+	}
+
+	if len(recipientInfos.children) == 0 {
+		l.report(SeverityError, RuleMissingField, recipientInfos.offset, "EnvelopedData recipientInfos is empty")
+	}
+
+	passwordOrOther, nonZeroVersions := false, false
+
+	for _, ri := range recipientInfos.children {
+		if ri.isContextSpecific(recipientInfoPassword) || ri.isContextSpecific(recipientInfoOther) {
+			passwordOrOther = true
+		}
+
+		if v, ok := l.checkRecipientInfo(ri); ok && v != 0 {
+			nonZeroVersions = true
+		}
+	}
+
+	// Version rules of RFC 5652, section 6.1
+	switch {
+	case originatorInfo != nil && hasOriginatorOtherFormats(originatorInfo):
+		l.checkVersion(version, "EnvelopedData", 4, "with originator certificates or revocation information of other formats")
+	case passwordOrOther:
+		l.checkVersion(version, "EnvelopedData", 3, "with password or other recipients")
+	case originatorInfo != nil || unprotectedAttrs != nil || nonZeroVersions:
+		l.checkVersion(version, "EnvelopedData", 2, "with originatorInfo, unprotectedAttrs or recipients of version other than 0")
+	default:
+		l.checkVersion(version, "EnvelopedData", 0, "for version 0 key transport recipients")
+	}
+}
+
+// checkRecipientInfo checks the version of a RecipientInfo alternative and returns it
+func (l *linter) checkRecipientInfo(e *berElement) (int, bool) {
+	if e.isContextSpecific(recipientInfoOther) || len(e.children) == 0 {
+		return 0, false
+	}
+
+	version := e.children[0]
+
+	switch {
+	case e.isUniversal(asn1.TagSequence):
+		if len(e.children) < 2 {
+			return 0, false
+		}
+
+		if e.children[1].isUniversal(asn1.TagSequence) {
+			l.checkVersion(version, "KeyTransRecipientInfo", 0, "for a recipient identified by issuer and serial number")
+		} else {
+			l.checkVersion(version, "KeyTransRecipientInfo", 2, "for a recipient identified by subject key identifier")
+		}
+	case e.isContextSpecific(recipientInfoKeyAgree):
+		l.checkVersion(version, "KeyAgreeRecipientInfo", 3, "")
+	case e.isContextSpecific(recipientInfoKEK):
+		l.checkVersion(version, "KEKRecipientInfo", 4, "")
+	case e.isContextSpecific(recipientInfoPassword):
+		l.checkVersion(version, "PasswordRecipientInfo", 0, "")
+	}
+
+	return intValue(version)
+}
+
+// checkEncryptedContentInfo checks the mandatory fields of EncryptedContentInfo
+func (l *linter) checkEncryptedContentInfo(e *berElement) {
+	if e == nil {
+		return
+	}
+
+	f, ok := l.newFields(e, "EncryptedContentInfo")
+	if !ok {
+		return
+	}
+
+	f.field("contentType", false, universal(asn1.TagOID))
+	f.field("contentEncryptionAlgorithm", false, universal(asn1.TagSequence))
+	f.field("encryptedContent", true, contextSpecific(0))
+	f.done()
+}
+
+// checkDigestedData checks DigestedData (RFC 5652, section 7)
+func (l *linter) checkDigestedData(e *berElement) {
+	f, ok := l.newFields(e, "DigestedData")
+	if !ok {
+		return
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	f.field("digestAlgorithm", false, universal(asn1.TagSequence))
+	encapContentInfo := f.field("encapContentInfo", false, universal(asn1.TagSequence))
+	f.field("digest", false, universal(asn1.TagOctetString))
+	f.done()
+
+	eContentType, ok := l.checkEncapContentInfo(encapContentInfo)
+	if !ok {
+		return
+	}
+
+	if eContentType.Equal(PKCS7DataOID) {
+		l.checkVersion(version, "DigestedData", 0, "for id-data content")
+	} else {
+		l.checkVersion(version, "DigestedData", 2, "for eContentType other than id-data")
+	}
+}
+
+// checkEncryptedData checks EncryptedData (RFC 5652, section 8)
+func (l *linter) checkEncryptedData(e *berElement) {
+	f, ok := l.newFields(e, "EncryptedData")
+	if !ok {
+		return
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	l.checkEncryptedContentInfo(f.field("encryptedContentInfo", false, universal(asn1.TagSequence)))
+
+	if f.field("unprotectedAttrs", true, contextSpecific(1)) != nil {
+		l.checkVersion(version, "EncryptedData", 2, "with unprotectedAttrs")
+	} else {
+		l.checkVersion(version, "EncryptedData", 0, "without unprotectedAttrs")
+	}
+
+	f.done()
+}
+
+// checkPFX checks a PKCS#12 PFX (RFC 7292, section 4) and its authenticated safe ContentInfo
+func (l *linter) checkPFX(e *berElement) {
+	f, ok := l.newFields(e, "PFX")
+	if !ok {
+		return
+	}
+
+	version := f.field("version", false, universal(asn1.TagInteger))
+	authSafe := f.field("authSafe", false, universal(asn1.TagSequence))
+	f.field("macData", true, universal(asn1.TagSequence))
+	f.done()
+
+	l.checkVersion(version, "PFX", pfxVersion, "")
+
+	if authSafe != nil {
+		l.checkContentInfo(authSafe, "PFX authSafe")
+	}
+}
+
+// hasChoice checks if the implicitly tagged CertificateSet or RevocationInfoChoices contain the alternative
+func hasChoice(set *berElement, tag int) bool {
+	if set == nil {
+		return false
+	}
+
+	for _, e := range set.children {
+		if e.isContextSpecific(tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasOtherFormats checks for certificates or revocation information of other formats
+func hasOtherFormats(certificates, crls *berElement) bool {
+	return hasChoice(certificates, certificateChoiceOther) || hasChoice(crls, revocationInfoChoiceOther)
+}
+
+// hasOriginatorOtherFormats checks OriginatorInfo for certificates or revocation information of other formats
+func hasOriginatorOtherFormats(originatorInfo *berElement) bool {
+	var certificates, crls *berElement
+
+	for _, e := range originatorInfo.children {
+		switch {
+		case e.isContextSpecific(0):
+			certificates = e
+		case e.isContextSpecific(1):
+			crls = e
+		}
+	}
+
+	return hasOtherFormats(certificates, crls)
+}
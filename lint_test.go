@@ -0,0 +1,236 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// toIndefiniteLength re-encodes the outer element of DER data with an indefinite length
+func toIndefiniteLength(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	_, headerLen, _, ok := core.ReadHeader(data)
+	if !ok {
+		t.Fatal("Failed to read DER header")
+	}
+
+	ber := append([]byte{data[0], 0x80}, data[headerLen:]...)
+
+	return append(ber, 0x00, 0x00)
+}
+
+// toLongFormLength re-encodes the outer element of DER data with four length octets
+func toLongFormLength(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	_, headerLen, length, ok := core.ReadHeader(data)
+	if !ok {
+		t.Fatal("Failed to read DER header")
+	}
+
+	ber := []byte{data[0], 0x84, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(ber[2:], uint32(length))
+
+	return append(ber, data[headerLen:]...)
+}
+
+// createSignedDataWithAttributes creates SignedData with a signer identified by issuer and serial number
+// carrying the given signed attributes
+func createSignedDataWithAttributes(t *testing.T, attrs ...attribute) []byte {
+	t.Helper()
+
+	si := createSignerInfo(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, attrs, nil)
+	si.Version = 1
+	si.SID = asn1.RawValue{FullBytes: []byte{0x30, 0x05, 0x30, 0x00, 0x02, 0x01, 0x01}}
+
+	return createSignedDataWithSigners(t, []signerInfo{si})
+}
+
+// rules returns the rules and severities of the diagnostics
+func rules(diagnostics []Diagnostic) []string {
+	var result []string
+	for _, d := range diagnostics {
+		result = append(result, d.Severity.String()+" "+d.Rule)
+	}
+
+	return result
+}
+
+// TestLint tests the diagnostics reported by Lint
+func TestLint(t *testing.T) {
+	cert := cmstest.Certificate(t, "Lint")
+	messageDigest := createAttribute(t, MessageDigestAttributeOID, make([]byte, 32))
+
+	// SignedData lacking its signerInfos
+	incomplete := createContentInfo(
+		t, PKCS7SignedDataOID, struct {
+			Version          int
+			DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+			EncapContentInfo encapsulatedContentInfo
+		}{Version: 1, EncapContentInfo: encapsulatedContentInfo{EContentType: PKCS7DataOID}},
+	)
+
+	// The version contents follow the outer header and the INTEGER header
+	pfxVersion2 := createPFX(t, nil, nil)
+	_, headerLen, _, _ := core.ReadHeader(pfxVersion2)
+	pfxVersion2[headerLen+2] = 2
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{
+			name: "SignedData",
+			data: cmstest.SignedData(t, cmstest.WithCertificates(cert)),
+		},
+		{
+			name: "Detached SignedData identified by subject key identifier",
+			data: cmstest.SignedData(t, cmstest.WithDetached()),
+		},
+		{
+			name: "EnvelopedData",
+			data: cmstest.EnvelopedData(t, cmstest.WithCertificates(cert)),
+		},
+		{
+			name: "EncryptedData",
+			data: cmstest.EncryptedData(t),
+		},
+		{
+			name: "PFX",
+			data: cmstest.PFX(t, cmstest.WithCertificates(cert)),
+		},
+		{
+			name:     "Indefinite length",
+			data:     toIndefiniteLength(t, cmstest.SignedData(t)),
+			expected: []string{"warning ber-encoding"},
+		},
+		{
+			name:     "Long form length",
+			data:     toLongFormLength(t, cmstest.EncryptedData(t)),
+			expected: []string{"warning non-canonical-length"},
+		},
+		{
+			name:     "Constructed OCTET STRING",
+			data:     createContentInfo(t, PKCS7DataOID, asn1.RawValue{Tag: asn1.TagOctetString, IsCompound: true, Bytes: []byte{0x04, 0x01, 0x41}}),
+			expected: []string{"warning ber-encoding"},
+		},
+		{
+			name:     "Trailing data",
+			data:     append(cmstest.EncryptedData(t), 0x00, 0x00),
+			expected: []string{"warning trailing-data"},
+		},
+		{
+			name:     "Truncated",
+			data:     cmstest.SignedData(t)[:40],
+			expected: []string{"error malformed"},
+		},
+		{
+			name:     "Not a ContentInfo",
+			data:     cert,
+			expected: []string{"error missing-field"},
+		},
+		{
+			name:     "Missing signerInfos",
+			data:     incomplete,
+			expected: []string{"error missing-field"},
+		},
+		{
+			name:     "SignedData version 1 with version 3 signer",
+			data:     createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			expected: []string{"error version"},
+		},
+		{
+			name:     "PKCS #7 v1.5 security catalog",
+			data:     createWindowsCatalog(t),
+			expected: []string{"warning version"},
+		},
+		{
+			name:     "EnvelopedData version 0 with version 2 recipient",
+			data:     createEnvelopedData(t, cmstest.RSAEncryptionOID, cmstest.AES256CBCOID),
+			expected: []string{"error version"},
+		},
+		{
+			name:     "PFX version 2",
+			data:     pfxVersion2,
+			expected: []string{"error version"},
+		},
+		{
+			name:     "Content type mismatch",
+			data:     createSignedDataWithAttributes(t, createAttribute(t, ContentTypeAttributeOID, WindowsCatalogOID), messageDigest),
+			expected: []string{"error content-type-mismatch"},
+		},
+		{
+			name:     "Missing message digest",
+			data:     createSignedDataWithAttributes(t, createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)),
+			expected: []string{"error missing-field"},
+		},
+		{
+			name: "Algorithm protection mismatch",
+			data: createSignedDataWithAttributes(
+				t, createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID),
+				createAlgorithmProtection(t, cmstest.SHA1OID, cmstest.SHA256WithRSAOID, nil), messageDigest,
+			),
+			expected: []string{"error algorithm-protection"},
+		},
+		{
+			name:     "Unsorted signed attributes",
+			data:     createSignedDataWithAttributes(t, messageDigest, createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)),
+			expected: []string{"error unsorted-set"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				diagnostics := Lint(tt.data)
+
+				if got := rules(diagnostics); !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Expected %v, got %v", tt.expected, diagnostics)
+				}
+			},
+		)
+	}
+}
+
+// TestLintCorpus tests that the CMS and PKCS#12 structures created by OpenSSL are reported as conforming
+func TestLintCorpus(t *testing.T) {
+	for name, data := range readCorpus(t) {
+		if result, err := DetectAny(data); err != nil || result.Family != FamilyCMS {
+			continue
+		}
+
+		if diagnostics := Lint(data); len(diagnostics) != 0 {
+			t.Errorf("%s: Expected no diagnostics, got %v", name, diagnostics)
+		}
+	}
+}
+
+// TestLintLimits tests that Lint respects the input size and nesting depth limits
+func TestLintLimits(t *testing.T) {
+	withLimits(t, Limits{MaxInputSize: 16, MaxNestingDepth: 2})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "Input too large", data: make([]byte, 17)},
+		{name: "Nesting too deep", data: []byte{0x30, 0x04, 0x30, 0x02, 0x30, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := rules(Lint(tt.data)); !reflect.DeepEqual(got, []string{"error malformed"}) {
+					t.Errorf("Expected a malformed error, got %v", got)
+				}
+			},
+		)
+	}
+}
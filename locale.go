@@ -0,0 +1,140 @@
+package cmsdetector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language identifies the language of human-readable descriptions by its ISO 639-1 code
+type Language string
+
+// Supported description languages
+const (
+	LanguageEnglish Language = "en"
+	LanguageRussian Language = "ru"
+	LanguageKazakh  Language = "kk"
+)
+
+// localizedKindNames maps kinds to their descriptions in languages other than English
+var localizedKindNames = map[Language]map[Kind]string{
+	LanguageRussian: {
+		KindUnknown:                "Неизвестный формат",
+		KindData:                   "Данные PKCS#7",
+		KindSignedData:             "Подписанные данные PKCS#7",
+		KindEnvelopedData:          "Данные PKCS#7 в цифровом конверте",
+		KindSignedAndEnvelopedData: "Подписанные данные PKCS#7 в цифровом конверте",
+		KindDigestedData:           "Хешированные данные PKCS#7",
+		KindEncryptedData:          "Зашифрованные данные PKCS#7",
+		KindPKCS12:                 "Контейнер PKCS#12",
+		KindEncryptedPKCS12:        "Зашифрованный контейнер PKCS#12",
+		KindWindowsCatalog:         "Каталог безопасности Windows",
+		KindJKS:                    "Хранилище ключей Java (JKS)",
+		KindJCEKS:                  "Хранилище ключей Java JCE (JCEKS)",
+		KindBKS:                    "Хранилище ключей BouncyCastle (BKS)",
+		KindOpenSSHPrivateKey:      "Закрытый ключ OpenSSH",
+		KindSSHPublicKey:           "Открытый ключ SSH",
+		KindPuTTYPrivateKey:        "Закрытый ключ PuTTY",
+		KindJWS:                    "Подпись JSON Web Signature (JWS)",
+		KindJWE:                    "Зашифрованный объект JSON Web Encryption (JWE)",
+		KindCOSESign1:              "Сообщение COSE_Sign1",
+		KindCOSESign:               "Сообщение COSE_Sign",
+		KindCOSEEncrypt:            "Сообщение COSE_Encrypt",
+		KindCOSEEncrypt0:           "Сообщение COSE_Encrypt0",
+		KindCOSEMac:                "Сообщение COSE_Mac",
+		KindCOSEMac0:               "Сообщение COSE_Mac0",
+		KindCertificate:            "Сертификат X.509",
+		KindCertificateRequest:     "Запрос на сертификат PKCS#10",
+		KindPrivateKey:             "Закрытый ключ PKCS#8",
+		KindEncryptedPrivateKey:    "Зашифрованный закрытый ключ PKCS#8",
+		KindPGPMessage:             "Сообщение OpenPGP",
+		KindPGPPublicKey:           "Открытый ключ OpenPGP",
+		KindPGPPrivateKey:          "Закрытый ключ OpenPGP",
+		KindPGPSignature:           "Подпись OpenPGP",
+		KindESTCertsOnly:           "Ответ EST со списком сертификатов",
+		KindCMPMessage:             "Сообщение CMP PKIMessage",
+		KindXMLSignature:           "Подпись XML",
+		KindXAdES:                  "Подпись XAdES",
+	},
+	LanguageKazakh: {
+		KindUnknown:                "Белгісіз формат",
+		KindData:                   "PKCS#7 деректері",
+		KindSignedData:             "PKCS#7 қол қойылған деректері",
+		KindEnvelopedData:          "PKCS#7 цифрлық конверттегі деректері",
+		KindSignedAndEnvelopedData: "PKCS#7 цифрлық конверттегі қол қойылған деректері",
+		KindDigestedData:           "PKCS#7 хэштелген деректері",
+		KindEncryptedData:          "PKCS#7 шифрланған деректері",
+		KindPKCS12:                 "PKCS#12 контейнері",
+		KindEncryptedPKCS12:        "Шифрланған PKCS#12 контейнері",
+		KindWindowsCatalog:         "Windows қауіпсіздік каталогы",
+		KindJKS:                    "Java кілттер қоймасы (JKS)",
+		KindJCEKS:                  "Java JCE кілттер қоймасы (JCEKS)",
+		KindBKS:                    "BouncyCastle кілттер қоймасы (BKS)",
+		KindOpenSSHPrivateKey:      "OpenSSH жабық кілті",
+		KindSSHPublicKey:           "SSH ашық кілті",
+		KindPuTTYPrivateKey:        "PuTTY жабық кілті",
+		KindJWS:                    "JSON Web Signature (JWS) қолтаңбасы",
+		KindJWE:                    "JSON Web Encryption (JWE) шифрланған нысаны",
+		KindCOSESign1:              "COSE_Sign1 хабарламасы",
+		KindCOSESign:               "COSE_Sign хабарламасы",
+		KindCOSEEncrypt:            "COSE_Encrypt хабарламасы",
+		KindCOSEEncrypt0:           "COSE_Encrypt0 хабарламасы",
+		KindCOSEMac:                "COSE_Mac хабарламасы",
+		KindCOSEMac0:               "COSE_Mac0 хабарламасы",
+		KindCertificate:            "X.509 сертификаты",
+		KindCertificateRequest:     "PKCS#10 сертификатқа сұраныс",
+		KindPrivateKey:             "PKCS#8 жабық кілті",
+		KindEncryptedPrivateKey:    "PKCS#8 шифрланған жабық кілті",
+		KindPGPMessage:             "OpenPGP хабарламасы",
+		KindPGPPublicKey:           "OpenPGP ашық кілті",
+		KindPGPPrivateKey:          "OpenPGP жабық кілті",
+		KindPGPSignature:           "OpenPGP қолтаңбасы",
+		KindESTCertsOnly:           "Сертификаттар тізімі бар EST жауабы",
+		KindCMPMessage:             "CMP PKIMessage хабарламасы",
+		KindXMLSignature:           "XML қолтаңбасы",
+		KindXAdES:                  "XAdES қолтаңбасы",
+	},
+}
+
+// localizedUnknownOID formats descriptions of unknown content types in languages other than English
+var localizedUnknownOID = map[Language]string{
+	LanguageRussian: "Неизвестный OID: %s",
+	LanguageKazakh:  "Белгісіз OID: %s",
+}
+
+// normalize reduces a language tag such as "ru-RU" or "kk_KZ" to its primary language subtag
+func (l Language) normalize() Language {
+	tag := strings.ToLower(string(l))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	return Language(tag)
+}
+
+// DescribeIn returns the description of the kind in the given language, falling back to English.
+// Language tags with a region, e.g. "ru-RU" or "kk-KZ", are accepted
+func (k Kind) DescribeIn(lang Language) string {
+	if name, ok := localizedKindNames[lang.normalize()][k]; ok {
+		return name
+	}
+
+	return k.String()
+}
+
+// DescribeIn returns the description of the detected type in the given language, falling back to English.
+// Content types without a Kind are described by their OID table name, which is not localized
+func (r DetectionResult) DescribeIn(lang Language) string {
+	if r.Kind != KindUnknown || len(r.ContentType) == 0 {
+		return r.Kind.DescribeIn(lang)
+	}
+
+	if info, ok := LookupOID(r.ContentType); ok {
+		return info.Name
+	}
+
+	if format, ok := localizedUnknownOID[lang.normalize()]; ok {
+		return fmt.Sprintf(format, r.ContentType.String())
+	}
+
+	return r.Type
+}
@@ -0,0 +1,85 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// TestKindDescribeIn tests localized kind descriptions
+func TestKindDescribeIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     Kind
+		lang     Language
+		expected string
+	}{
+		{
+			name:     "English",
+			kind:     KindSignedData,
+			lang:     LanguageEnglish,
+			expected: "PKCS#7 Signed Data",
+		},
+		{
+			name:     "Russian",
+			kind:     KindSignedData,
+			lang:     LanguageRussian,
+			expected: "Подписанные данные PKCS#7",
+		},
+		{
+			name:     "Kazakh",
+			kind:     KindCertificate,
+			lang:     LanguageKazakh,
+			expected: "X.509 сертификаты",
+		},
+		{
+			name:     "Language tag with region",
+			kind:     KindPKCS12,
+			lang:     "ru-RU",
+			expected: "Контейнер PKCS#12",
+		},
+		{
+			name:     "Unsupported language",
+			kind:     KindPKCS12,
+			lang:     "de",
+			expected: "PKCS#12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if description := tt.kind.DescribeIn(tt.lang); description != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, description)
+				}
+			},
+		)
+	}
+}
+
+// TestLocalizedKindNamesComplete tests that every kind is translated to every supported language
+func TestLocalizedKindNamesComplete(t *testing.T) {
+	for lang, names := range localizedKindNames {
+		for kind := range kindNames {
+			if names[kind] == "" {
+				t.Errorf("Missing %s description of %s", lang, kind)
+			}
+		}
+	}
+}
+
+// TestDetectionResultDescribeIn tests localized descriptions of unknown content types
+func TestDetectionResultDescribeIn(t *testing.T) {
+	unknown := DetectionResult{Type: "Unknown OID: 1.2.3.4", ContentType: asn1.ObjectIdentifier{1, 2, 3, 4}}
+	if description := unknown.DescribeIn(LanguageKazakh); description != "Белгісіз OID: 1.2.3.4" {
+		t.Errorf("Expected %s, got %s", "Белгісіз OID: 1.2.3.4", description)
+	}
+
+	if description := unknown.DescribeIn(LanguageEnglish); description != unknown.Type {
+		t.Errorf("Expected %s, got %s", unknown.Type, description)
+	}
+
+	signed := DetectionResult{Type: "PKCS#7 Signed Data", Kind: KindSignedData, ContentType: PKCS7SignedDataOID}
+	if description := signed.DescribeIn(LanguageRussian); description != "Подписанные данные PKCS#7" {
+		t.Errorf("Expected %s, got %s", "Подписанные данные PKCS#7", description)
+	}
+}
@@ -0,0 +1,125 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// CAdES unsigned attribute OIDs of validation material and archive time-stamps (RFC 5126, ETSI EN 319 122-1)
+var (
+	CompleteCertificateRefsAttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 21}
+	CompleteRevocationRefsAttributeOID  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 22}
+	CertificateValuesAttributeOID       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 23}
+	RevocationValuesAttributeOID        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 24}
+	ArchiveTimestampAttributeOID        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 27}
+	ArchiveTimestampV2AttributeOID      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 48}
+	ArchiveTimestampV3AttributeOID      = asn1.ObjectIdentifier{0, 4, 0, 1733, 2, 4}
+)
+
+// ocspResponseRevocationInfoOID identifies OCSP responses in the SignedData crls field (RFC 5940)
+var ocspResponseRevocationInfoOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 16, 2}
+
+// archiveTimestampOIDs lists the archive-time-stamp attributes of all versions
+var archiveTimestampOIDs = []asn1.ObjectIdentifier{
+	ArchiveTimestampAttributeOID, ArchiveTimestampV2AttributeOID, ArchiveTimestampV3AttributeOID,
+}
+
+// LTVReport describes the long-term validation material of SignedData
+type LTVReport struct {
+	Signers       []SignerLTV
+	CRLs          int // CRLs in the SignedData crls field
+	OCSPResponses int // OCSP responses in the SignedData crls field (RFC 5940)
+}
+
+// SignerLTV describes the time-stamps and validation material in the unsigned attributes of a signer
+type SignerLTV struct {
+	SignatureTimestamps int  // signature-time-stamp attributes (CAdES-T)
+	CompleteReferences  bool // complete-certificate-references and complete-revocation-references attributes (CAdES-C)
+	CertificateValues   bool // certificate-values attribute
+	RevocationValues    bool // revocation-values attribute
+	CRLs                int  // CRLs in the revocation-values attribute
+	OCSPResponses       int  // OCSP responses in the revocation-values attribute
+	ArchiveTimestamps   int  // archive-time-stamp attributes of any version (CAdES-A)
+}
+
+// revocationValues provides the ASN.1 structure of RevocationValues (RFC 5126, section 6.3.4)
+type revocationValues struct {
+	CRLVals      []asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	OCSPVals     []asn1.RawValue `asn1:"optional,explicit,tag:1"`
+	OtherRevVals asn1.RawValue   `asn1:"optional,explicit,tag:2"`
+}
+
+// otherRevocationInfoFormat provides the ASN.1 structure of OtherRevocationInfoFormat (RFC 5652, section 10.2.1)
+type otherRevocationInfoFormat struct {
+	OtherRevInfoFormat asn1.ObjectIdentifier
+	OtherRevInfo       asn1.RawValue
+}
+
+// Ready reports whether every signer has a signature or archive time-stamp and revocation material
+// is embedded, in revocation-values attributes or the crls field. Certificate values are not
+// required, since the chain may be carried in the certificates field
+func (r LTVReport) Ready() bool {
+	if len(r.Signers) == 0 {
+		return false
+	}
+
+	for _, signer := range r.Signers {
+		if signer.SignatureTimestamps+signer.ArchiveTimestamps == 0 {
+			return false
+		}
+
+		if signer.CRLs+signer.OCSPResponses+r.CRLs+r.OCSPResponses == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InspectLTV reports the archive time-stamps and the revocation material of SignedData, so
+// archivists can tell whether the signatures can be validated in the long term
+func InspectLTV(data []byte) (report *LTVReport, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report = &LTVReport{}
+	crls, responses := revocationInfoChoices(sd.CRLs)
+	report.CRLs, report.OCSPResponses = len(crls), len(responses)
+
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.UnsignedAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse unsigned attributes: %w", err)
+		}
+
+		signer := SignerLTV{
+			SignatureTimestamps: len(attributeValues(attrs, TimeStampTokenAttributeOID)),
+			CompleteReferences: len(attributeValues(attrs, CompleteCertificateRefsAttributeOID)) > 0 &&
+				len(attributeValues(attrs, CompleteRevocationRefsAttributeOID)) > 0,
+			CertificateValues: len(attributeValues(attrs, CertificateValuesAttributeOID)) > 0,
+		}
+
+		for _, oid := range archiveTimestampOIDs {
+			signer.ArchiveTimestamps += len(attributeValues(attrs, oid))
+		}
+
+		for _, value := range attributeValues(attrs, RevocationValuesAttributeOID) {
+			var values revocationValues
+			if _, err := asn1.Unmarshal(value.FullBytes, &values); err != nil {
+				return nil, fmt.Errorf("failed to parse revocation values: %w", err)
+			}
+
+			signer.RevocationValues = true
+			signer.CRLs += len(values.CRLVals)
+			signer.OCSPResponses += len(values.OCSPVals)
+		}
+
+		report.Signers = append(report.Signers, signer)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,127 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createSignedDataWithCRLs creates SignedData with a signer carrying the unsigned attributes and
+// the given RevocationInfoChoices in the crls field
+func createSignedDataWithCRLs(t *testing.T, unsignedAttrs []attribute, crls ...[]byte) []byte {
+	t.Helper()
+
+	si := createSignerInfo(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID, nil, unsignedAttrs)
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{si.DigestAlgorithm},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: PKCS7DataOID},
+		SignerInfos:      []signerInfo{si},
+	}
+
+	if len(crls) > 0 {
+		var set []byte
+		for _, crl := range crls {
+			set = append(set, crl...)
+		}
+
+		sd.CRLs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: set}
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// TestInspectLTV tests the report of time-stamps and revocation material of SignedData
+func TestInspectLTV(t *testing.T) {
+	// Placeholders for time-stamp tokens, CRLs and OCSP responses
+	token := asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: []byte{0x05, 0x00}}
+	crl := []byte{0x30, 0x02, 0x05, 0x00}
+
+	ocsp := createOCSPRevocationInfo(t, createBasicOCSPResponse(t, 42))
+
+	revocationVals := revocationValues{
+		CRLVals:  []asn1.RawValue{token, token},
+		OCSPVals: []asn1.RawValue{token},
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected LTVReport
+		ready    bool
+	}{
+		{
+			name:     "Plain signature",
+			data:     createSignedDataWithCRLs(t, nil),
+			expected: LTVReport{Signers: []SignerLTV{{}}},
+		},
+		{
+			name:     "Time-stamped signature",
+			data:     createSignedDataWithCRLs(t, []attribute{createAttribute(t, TimeStampTokenAttributeOID, token)}),
+			expected: LTVReport{Signers: []SignerLTV{{SignatureTimestamps: 1}}},
+		},
+		{
+			name: "Time-stamped signature with revocation values",
+			data: createSignedDataWithCRLs(
+				t, []attribute{
+					createAttribute(t, TimeStampTokenAttributeOID, token),
+					createAttribute(t, CompleteCertificateRefsAttributeOID, token),
+					createAttribute(t, CompleteRevocationRefsAttributeOID, token),
+					createAttribute(t, CertificateValuesAttributeOID, token),
+					createAttribute(t, RevocationValuesAttributeOID, revocationVals),
+				},
+			),
+			expected: LTVReport{
+				Signers: []SignerLTV{
+					{
+						SignatureTimestamps: 1, CompleteReferences: true, CertificateValues: true,
+						RevocationValues: true, CRLs: 2, OCSPResponses: 1,
+					},
+				},
+			},
+			ready: true,
+		},
+		{
+			name: "Archive time-stamps with revocation information in crls",
+			data: createSignedDataWithCRLs(
+				t, []attribute{
+					createAttribute(t, ArchiveTimestampV2AttributeOID, token),
+					createAttribute(t, ArchiveTimestampV3AttributeOID, token),
+				},
+				crl, ocsp,
+			),
+			expected: LTVReport{Signers: []SignerLTV{{ArchiveTimestamps: 2}}, CRLs: 1, OCSPResponses: 1},
+			ready:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := InspectLTV(tt.data)
+				if err != nil {
+					t.Fatalf("InspectLTV returned an error: %v", err)
+				}
+
+				if len(report.Signers) != 1 || report.Signers[0] != tt.expected.Signers[0] {
+					t.Errorf("Expected signers %+v, got %+v", tt.expected.Signers, report.Signers)
+				}
+
+				if report.CRLs != tt.expected.CRLs || report.OCSPResponses != tt.expected.OCSPResponses {
+					t.Errorf("Expected %d CRLs and %d OCSP responses, got %d and %d", tt.expected.CRLs, tt.expected.OCSPResponses, report.CRLs, report.OCSPResponses)
+				}
+
+				if report.Ready() != tt.ready {
+					t.Errorf("Expected ready %v, got %v", tt.ready, report.Ready())
+				}
+			},
+		)
+	}
+
+	if _, err := InspectLTV(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package cmsdetector
+
+import (
+	"errors"
+	"sync"
+)
+
+// Metrics receives counters and observations of Detect and DetectAny calls, e.g. to export them
+// to Prometheus. Implementations must be safe for concurrent use
+type Metrics interface {
+	IncDetections(kind Kind)         // Counts inputs recognized as the given kind
+	IncParseFailures()               // Counts inputs in no recognized format
+	IncHeuristicFallbacks(kind Kind) // Counts inputs recognized by heuristics rather than structural parsing
+	ObserveInputSize(size int)       // Observes the size of every input in bytes
+}
+
+// metrics holds the metrics receiver, guarded by metricsMu
+var (
+	metricsMu sync.RWMutex
+	metrics   Metrics
+)
+
+// SetMetrics sets the receiver of detection metrics, nil disables metrics. It is safe for concurrent use
+func SetMetrics(m Metrics) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	metrics = m
+}
+
+// currentMetrics returns the metrics receiver, or nil when metrics are disabled
+func currentMetrics() Metrics {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+
+	return metrics
+}
+
+// recordDetection reports the outcome of a detection to the metrics receiver.
+// Inputs rejected by MaxInputSize are observed but not counted as parse failures
+func recordDetection(size int, kind Kind, heuristic bool, err error) {
+	m := currentMetrics()
+	if m == nil {
+		return
+	}
+
+	m.ObserveInputSize(size)
+
+	if errors.Is(err, ErrInputTooLarge) {
+		return
+	}
+
+	if err != nil {
+		m.IncParseFailures()
+
+		return
+	}
+
+	m.IncDetections(kind)
+
+	if heuristic {
+		m.IncHeuristicFallbacks(kind)
+	}
+}
@@ -0,0 +1,137 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"sync"
+	"testing"
+)
+
+// recordingMetrics records the metrics reported by the detection functions
+type recordingMetrics struct {
+	mu         sync.Mutex
+	detections map[Kind]int
+	failures   int
+	heuristics map[Kind]int
+	sizes      []int
+}
+
+// newRecordingMetrics creates a recordingMetrics and sets it as the metrics receiver for the test
+func newRecordingMetrics(t *testing.T) *recordingMetrics {
+	t.Helper()
+
+	m := &recordingMetrics{detections: make(map[Kind]int), heuristics: make(map[Kind]int)}
+	SetMetrics(m)
+
+	t.Cleanup(
+		func() {
+			SetMetrics(nil)
+		},
+	)
+
+	return m
+}
+
+// IncDetections implements Metrics
+func (m *recordingMetrics) IncDetections(kind Kind) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.detections[kind]++
+}
+
+// IncParseFailures implements Metrics
+func (m *recordingMetrics) IncParseFailures() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.failures++
+}
+
+// IncHeuristicFallbacks implements Metrics
+func (m *recordingMetrics) IncHeuristicFallbacks(kind Kind) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heuristics[kind]++
+}
+
+// ObserveInputSize implements Metrics
+func (m *recordingMetrics) ObserveInputSize(size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sizes = append(m.sizes, size)
+}
+
+// TestMetrics tests the metrics reported by Detect and DetectAny
+func TestMetrics(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	signed := createSignedData(t, sha256OID, rsaOID)
+	key := createMockPKCS12Key(t)
+	base64Signed := []byte(wrapBase64(signed))
+
+	m := newRecordingMetrics(t)
+
+	if _, err := Detect(signed); err != nil {
+		t.Fatalf("Detect returned an error: %v", err)
+	}
+
+	if _, err := Detect(key); err != nil {
+		t.Fatalf("Detect returned an error: %v", err)
+	}
+
+	if _, err := Detect([]byte("not CMS")); err == nil {
+		t.Fatal("Expected error for invalid data, got nil")
+	}
+
+	// Hints decode the base64 text, which must not be counted as another input
+	if _, err := DetectAny(base64Signed); err == nil {
+		t.Fatal("Expected error for base64 text, got nil")
+	}
+
+	expectedDetections := map[Kind]int{KindSignedData: 1, KindEncryptedPKCS12: 1}
+	if len(m.detections) != len(expectedDetections) {
+		t.Errorf("Expected detections %v, got %v", expectedDetections, m.detections)
+	}
+
+	for kind, count := range expectedDetections {
+		if m.detections[kind] != count {
+			t.Errorf("Expected %d detections of kind %v, got %d", count, kind, m.detections[kind])
+		}
+	}
+
+	if m.failures != 2 {
+		t.Errorf("Expected 2 parse failures, got %d", m.failures)
+	}
+
+	if len(m.heuristics) != 1 || m.heuristics[KindEncryptedPKCS12] != 1 {
+		t.Errorf("Expected 1 heuristic fallback for %v, got %v", KindEncryptedPKCS12, m.heuristics)
+	}
+
+	expectedSizes := []int{len(signed), len(key), len("not CMS"), len(base64Signed)}
+	if len(m.sizes) != len(expectedSizes) {
+		t.Fatalf("Expected sizes %v, got %v", expectedSizes, m.sizes)
+	}
+
+	for i, size := range expectedSizes {
+		if m.sizes[i] != size {
+			t.Errorf("Expected size %d, got %d", size, m.sizes[i])
+		}
+	}
+}
+
+// TestMetricsInputTooLarge tests that rejected inputs are not counted as parse failures
+func TestMetricsInputTooLarge(t *testing.T) {
+	m := newRecordingMetrics(t)
+	withLimits(t, Limits{MaxInputSize: 4})
+
+	if _, err := Detect([]byte("too large")); err == nil {
+		t.Fatal("Expected error for oversized input, got nil")
+	}
+
+	if m.failures != 0 || len(m.sizes) != 1 {
+		t.Errorf("Expected only the input size to be observed, got %d failures and sizes %v", m.failures, m.sizes)
+	}
+}
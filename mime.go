@@ -0,0 +1,186 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+)
+
+// mediaTypeOctetStream is reported for kinds without a registered media type
+const mediaTypeOctetStream = "application/octet-stream"
+
+// fileType contains the canonical media type and file extension of a kind
+type fileType struct {
+	mediaType string
+	extension string
+}
+
+// fileTypes maps kinds to their canonical media types and file extensions
+var fileTypes = map[Kind]fileType{
+	KindData:                   {MediaTypePKCS7MIME, ".p7m"},
+	KindSignedData:             {MediaTypePKCS7MIME, ".p7m"},
+	KindEnvelopedData:          {MediaTypePKCS7MIME, ".p7m"},
+	KindSignedAndEnvelopedData: {MediaTypePKCS7MIME, ".p7m"},
+	KindDigestedData:           {MediaTypePKCS7MIME, ".p7m"},
+	KindEncryptedData:          {MediaTypePKCS7MIME, ".p7m"},
+	KindPKCS12:                 {"application/x-pkcs12", ".p12"},
+	KindEncryptedPKCS12:        {"application/x-pkcs12", ".p12"},
+	KindWindowsCatalog:         {"application/vnd.ms-pki.seccat", ".cat"},
+	KindJKS:                    {"application/x-java-keystore", ".jks"},
+	KindJCEKS:                  {"application/x-java-jce-keystore", ".jceks"},
+	KindBKS:                    {mediaTypeOctetStream, ".bks"},
+	KindOpenSSHPrivateKey:      {mediaTypeOctetStream, ".key"},
+	KindSSHPublicKey:           {"text/plain", ".pub"},
+	KindPuTTYPrivateKey:        {mediaTypeOctetStream, ".ppk"},
+	KindJWS:                    {"application/jose", ".jws"},
+	KindJWE:                    {"application/jose", ".jwe"},
+	KindCOSESign1:              {"application/cose", ".cose"},
+	KindCOSESign:               {"application/cose", ".cose"},
+	KindCOSEEncrypt:            {"application/cose", ".cose"},
+	KindCOSEEncrypt0:           {"application/cose", ".cose"},
+	KindCOSEMac:                {"application/cose", ".cose"},
+	KindCOSEMac0:               {"application/cose", ".cose"},
+	KindCertificate:            {"application/pkix-cert", ".cer"},
+	KindCertificateRequest:     {"application/pkcs10", ".p10"},
+	KindPrivateKey:             {"application/pkcs8", ".p8"},
+	KindEncryptedPrivateKey:    {"application/pkcs8-encrypted", ".p8e"},
+	KindPGPMessage:             {"application/pgp-encrypted", ".pgp"},
+	KindPGPPublicKey:           {"application/pgp-keys", ".pgp"},
+	KindPGPPrivateKey:          {"application/pgp-keys", ".pgp"},
+	KindPGPSignature:           {"application/pgp-signature", ".sig"},
+	KindESTCertsOnly:           {MediaTypePKCS7MIME, ".p7c"},
+	KindCMPMessage:             {MediaTypePKIXCMP, ".pki"},
+	KindXMLSignature:           {"application/xml", ".xml"},
+	KindXAdES:                  {"application/xml", ".xml"},
+}
+
+// cmsKinds contains the kinds wrapped in a CMS ContentInfo
+var cmsKinds = []Kind{
+	KindData, KindSignedData, KindEnvelopedData, KindSignedAndEnvelopedData, KindDigestedData, KindEncryptedData,
+}
+
+// declaredTypeAliases maps media types and extensions in common use to the kinds they may contain,
+// in addition to the canonical values of fileTypes
+var declaredTypeAliases = map[string][]Kind{
+	mediaTypeXPKCS7MIME:                cmsKinds,
+	MediaTypePKCS7Signature:            {KindSignedData},
+	mediaTypeXPKCS7Signature:           {KindSignedData},
+	"application/x-pkcs7-certificates": {KindSignedData},
+	"application/pkcs12":               {KindPKCS12, KindEncryptedPKCS12},
+	"application/x-x509-ca-cert":       {KindCertificate},
+	"application/x-x509-user-cert":     {KindCertificate},
+	"application/jose+json":            {KindJWS, KindJWE},
+	"application/x-pem-file":           {KindCertificate, KindCertificateRequest, KindPrivateKey, KindEncryptedPrivateKey},
+	".cms":                             cmsKinds,
+	".p7s":                             {KindSignedData},
+	".p7b":                             {KindSignedData},
+	".p7c":                             {KindSignedData},
+	".pfx":                             {KindPKCS12, KindEncryptedPKCS12},
+	".crt":                             {KindCertificate},
+	".der":                             {KindCertificate},
+	".csr":                             {KindCertificateRequest},
+	".key":                             {KindPrivateKey, KindEncryptedPrivateKey, KindOpenSSHPrivateKey},
+	".pem":                             {KindCertificate, KindCertificateRequest, KindPrivateKey, KindEncryptedPrivateKey},
+	".asc":                             {KindPGPMessage, KindPGPPublicKey, KindPGPPrivateKey, KindPGPSignature},
+	".gpg":                             {KindPGPMessage, KindPGPPublicKey, KindPGPPrivateKey},
+	".keystore":                        {KindJKS, KindJCEKS, KindPKCS12},
+	".jwt":                             {KindJWS, KindJWE},
+}
+
+// ErrUnknownDeclaredType is returned when a declared media type or extension maps to no kind
+var ErrUnknownDeclaredType = errors.New("unknown declared type")
+
+// ExpectedKindsFor returns the kinds that a file with the given media type (e.g. "application/pkcs7-mime")
+// or extension (".p7m", "p7m" or a file name) is expected to contain
+func ExpectedKindsFor(mimeOrExt string) []Kind {
+	key := normalizeDeclaredType(mimeOrExt)
+	if key == "" || key == mediaTypeOctetStream {
+		return nil
+	}
+
+	seen := make(map[Kind]bool)
+
+	for kind, fileType := range fileTypes {
+		if fileType.mediaType == key || fileType.extension == key {
+			seen[kind] = true
+		}
+	}
+
+	for _, kind := range declaredTypeAliases[key] {
+		seen[kind] = true
+	}
+
+	kinds := make([]Kind, 0, len(seen))
+	for kind := range seen {
+		kinds = append(kinds, kind)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	return kinds
+}
+
+// MatchesDeclaredType checks if the detected kind of the data is one of the kinds expected
+// for the declared media type or extension
+func MatchesDeclaredType(data []byte, declared string) (bool, error) {
+	kinds := ExpectedKindsFor(declared)
+	if len(kinds) == 0 {
+		return false, fmt.Errorf("%w: %q", ErrUnknownDeclaredType, declared)
+	}
+
+	result, err := DetectAny(data)
+	if err != nil {
+		return false, err
+	}
+
+	for _, kind := range kinds {
+		if result.Kind == kind {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// normalizeDeclaredType converts a media type to its lower-case form without parameters
+// and an extension or file name to a lower-case extension with the leading dot
+func normalizeDeclaredType(declared string) string {
+	declared = strings.ToLower(strings.TrimSpace(declared))
+
+	if strings.Contains(declared, "/") {
+		mediaType, _, err := mime.ParseMediaType(declared)
+		if err != nil {
+			return ""
+		}
+
+		return mediaType
+	}
+
+	if ext := path.Ext(declared); ext != "" {
+		return ext
+	}
+
+	if declared == "" {
+		return ""
+	}
+
+	return "." + declared
+}
+
+// MIMEType returns the canonical media type of the detected kind, application/octet-stream if there is none
+func (r DetectionResult) MIMEType() string {
+	if fileType, ok := fileTypes[r.Kind]; ok {
+		return fileType.mediaType
+	}
+
+	return mediaTypeOctetStream
+}
+
+// SuggestedExtension returns the conventional file extension of the detected kind, including the leading dot,
+// or an empty string if there is none
+func (r DetectionResult) SuggestedExtension() string {
+	return fileTypes[r.Kind].extension
+}
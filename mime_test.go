@@ -0,0 +1,183 @@
+package cmsdetector
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMIMEType tests the media types and file extensions suggested for detection results
+func TestMIMEType(t *testing.T) {
+	tests := []struct {
+		name              string
+		result            DetectionResult
+		expectedMIMEType  string
+		expectedExtension string
+	}{
+		{
+			name:              "Signed data",
+			result:            DetectionResult{Kind: KindSignedData},
+			expectedMIMEType:  "application/pkcs7-mime",
+			expectedExtension: ".p7m",
+		},
+		{
+			name:              "Encrypted PKCS#12",
+			result:            DetectionResult{Kind: KindEncryptedPKCS12},
+			expectedMIMEType:  "application/x-pkcs12",
+			expectedExtension: ".p12",
+		},
+		{
+			name:              "Windows security catalog",
+			result:            DetectionResult{Kind: KindWindowsCatalog},
+			expectedMIMEType:  "application/vnd.ms-pki.seccat",
+			expectedExtension: ".cat",
+		},
+		{
+			name:              "Unknown",
+			result:            DetectionResult{},
+			expectedMIMEType:  "application/octet-stream",
+			expectedExtension: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if mimeType := tt.result.MIMEType(); mimeType != tt.expectedMIMEType {
+					t.Errorf("Expected MIME type %s, got %s", tt.expectedMIMEType, mimeType)
+				}
+
+				if extension := tt.result.SuggestedExtension(); extension != tt.expectedExtension {
+					t.Errorf("Expected extension %s, got %s", tt.expectedExtension, extension)
+				}
+			},
+		)
+	}
+}
+
+// TestFileTypesCoverKinds tests that every known kind has a file type
+func TestFileTypesCoverKinds(t *testing.T) {
+	for kind := range kindNames {
+		if _, ok := fileTypes[kind]; !ok && kind != KindUnknown {
+			t.Errorf("Expected file type for %v", kind)
+		}
+	}
+}
+
+// TestExpectedKindsFor tests the kinds expected for declared media types and extensions
+func TestExpectedKindsFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared string
+		expected []Kind
+	}{
+		{
+			name:     "Media type with parameters",
+			declared: "application/pkcs7-mime; smime-type=signed-data",
+			expected: []Kind{KindData, KindSignedData, KindEnvelopedData, KindSignedAndEnvelopedData, KindDigestedData, KindEncryptedData, KindESTCertsOnly},
+		},
+		{
+			name:     "Detached signature media type",
+			declared: "Application/PKCS7-Signature",
+			expected: []Kind{KindSignedData},
+		},
+		{
+			name:     "Extension",
+			declared: ".PFX",
+			expected: []Kind{KindPKCS12, KindEncryptedPKCS12},
+		},
+		{
+			name:     "Extension without dot",
+			declared: "p12",
+			expected: []Kind{KindPKCS12, KindEncryptedPKCS12},
+		},
+		{
+			name:     "File name",
+			declared: "driver.cat",
+			expected: []Kind{KindWindowsCatalog},
+		},
+		{
+			name:     "Generic media type",
+			declared: "application/octet-stream",
+		},
+		{
+			name:     "Unknown extension",
+			declared: ".docx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				kinds := ExpectedKindsFor(tt.declared)
+				if len(kinds) != len(tt.expected) {
+					t.Fatalf("Expected %v, got %v", tt.expected, kinds)
+				}
+
+				for i := range kinds {
+					if kinds[i] != tt.expected[i] {
+						t.Errorf("Expected %v, got %v", tt.expected, kinds)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestMatchesDeclaredType tests comparison of detected content with the declared type
+func TestMatchesDeclaredType(t *testing.T) {
+	signedData := createTestData(t, PKCS7SignedDataOID)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		declared    string
+		expected    bool
+		expectedErr error
+	}{
+		{
+			name:     "Matching media type",
+			data:     signedData,
+			declared: "application/pkcs7-signature",
+			expected: true,
+		},
+		{
+			name:     "Matching extension",
+			data:     signedData,
+			declared: "document.p7s",
+			expected: true,
+		},
+		{
+			name:     "Mismatching extension",
+			data:     signedData,
+			declared: "key.p12",
+			expected: false,
+		},
+		{
+			name:        "Unknown declared type",
+			data:        signedData,
+			declared:    "application/octet-stream",
+			expectedErr: ErrUnknownDeclaredType,
+		},
+		{
+			name:        "Unrecognized content",
+			data:        []byte("plain text"),
+			declared:    ".p7m",
+			expectedErr: ErrUnknownFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				matches, err := MatchesDeclaredType(tt.data, tt.declared)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if matches != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, matches)
+				}
+			},
+		)
+	}
+}
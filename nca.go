@@ -0,0 +1,201 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"time"
+)
+
+// ErrNotNCAKeyContainer is returned when the data is not a key container of the NCA of Kazakhstan
+var ErrNotNCAKeyContainer = errors.New("not an NCA key container")
+
+// NCAKeyType is the type of key in an NCA container, following the AUTH_RSA256, RSA256 and GOST512
+// prefixes of the container files issued by the NCA of Kazakhstan
+type NCAKeyType int
+
+// Types of NCA keys. NCAKeyUnknown is reported when no certificate is readable without the password
+const (
+	NCAKeyUnknown NCAKeyType = iota
+	NCAKeyAuth               // RSA key for authentication, its certificate lacks the nonRepudiation usage
+	NCAKeyRSA                // RSA key for signing
+	NCAKeyGOST               // GOST key, used both for authentication and signing
+)
+
+// String returns the file name prefix of the key type
+func (t NCAKeyType) String() string {
+	switch t {
+	case NCAKeyAuth:
+		return "AUTH"
+	case NCAKeyRSA:
+		return "RSA"
+	case NCAKeyGOST:
+		return "GOST"
+	default:
+		return "unknown"
+	}
+}
+
+// NCAKeyInfo describes an NCA (KalkanCrypt) key container as far as it is readable without the password
+type NCAKeyInfo struct {
+	KeyType       NCAKeyType
+	StorageScheme Algorithm // Password-based encryption scheme of the shrouded key bag, e.g. PBES2
+	StorageCipher Algorithm // Cipher of a PBES2 storage scheme
+	Iterations    int       // Key derivation iterations of the storage scheme
+	Curve         Algorithm // Parameter set of a GOST key, taken from its certificate
+	FriendlyName  string    // friendlyName of the key bag, the alias shown by KalkanCrypt
+	NotBefore     time.Time // Start of validity of the key certificate, about when the container was created
+	MACIterations int       // Key derivation iterations of the integrity MAC
+}
+
+// NCAKeyMetadata extracts what is readable without the password from a PKCS#12 key container of
+// the NCA of Kazakhstan: the storage scheme of the key, its type and GOST parameter set as given
+// by the certificate, and hints on when the container was created. Containers are recognized by
+// the NCA profile of KeyContainerDetector or by certificates with OIDs of the Kazakhstan national
+// arc, other data is rejected with ErrNotNCAKeyContainer
+func NCAKeyMetadata(data []byte) (info NCAKeyInfo, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return NCAKeyInfo{}, err
+	}
+
+	contents, err := parsePFX(data)
+	if err != nil {
+		return NCAKeyInfo{}, ErrNotNCAKeyContainer
+	}
+
+	bags := contents.readableBags()
+
+	key, hasKey := ncaKeyBag(bags)
+	cert := ncaKeyCertificate(bags, key)
+
+	nca := (&KeyContainerDetector{Profile: KeyContainerNCA}).IsKeyContainer(data)
+	if !nca && (cert == nil || !bytes.Contains(cert.Raw, encodedKazakhArcOID)) {
+		return NCAKeyInfo{}, ErrNotNCAKeyContainer
+	}
+
+	info.MACIterations = contents.pfx.MacData.Iterations
+
+	if hasKey {
+		info.FriendlyName, _ = bagAttributes(key)
+		info.addStorageScheme(key)
+	}
+
+	if cert != nil {
+		info.addCertificate(cert)
+	}
+
+	return info, nil
+}
+
+// ncaKeyBag returns the first shrouded key bag
+func ncaKeyBag(bags []safeBag) (safeBag, bool) {
+	for _, bag := range bags {
+		if bag.ID.Equal(PKCS12ShroudedKeyBagOID) {
+			return bag, true
+		}
+	}
+
+	return safeBag{}, false
+}
+
+// ncaKeyCertificate returns the certificate with the localKeyId of the key, or the first end
+// entity certificate when the key has none
+func ncaKeyCertificate(bags []safeBag, key safeBag) *x509.Certificate {
+	_, keyID := bagAttributes(key)
+
+	var endEntity *x509.Certificate
+
+	for _, bag := range bags {
+		cert, ok := bagCertificate(bag)
+		if !ok {
+			continue
+		}
+
+		if _, certID := bagAttributes(bag); len(keyID) > 0 && bytes.Equal(certID, keyID) {
+			return cert
+		}
+
+		if endEntity == nil && !cert.IsCA {
+			endEntity = cert
+		}
+	}
+
+	return endEntity
+}
+
+// addStorageScheme reports the password-based encryption of the shrouded key bag
+func (i *NCAKeyInfo) addStorageScheme(bag safeBag) {
+	var key encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &key); err != nil {
+		return
+	}
+
+	alg := key.Algorithm
+	i.StorageScheme = newAlgorithm(alg.Algorithm)
+
+	if !alg.Algorithm.Equal(pbes2OID) {
+		var params pbeParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err == nil {
+			i.Iterations = params.Iterations
+		}
+
+		return
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return
+	}
+
+	i.StorageCipher = newAlgorithm(params.EncryptionScheme.Algorithm)
+
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err == nil {
+		i.Iterations = kdfParams.IterationCount
+	}
+}
+
+// addCertificate reports the key type and GOST parameter set given by the key certificate
+func (i *NCAKeyInfo) addCertificate(cert *x509.Certificate) {
+	i.NotBefore = cert.NotBefore
+
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return
+	}
+
+	oid := spki.Algorithm.Algorithm
+
+	switch {
+	case isGOSTAlgorithm(oid) || hasOIDPrefix(oid, kazakhArcOID):
+		i.KeyType = NCAKeyGOST
+		i.Curve = gostParamSet(spki.Algorithm.Parameters)
+	case oid.Equal(rsaEncryptionOID) && cert.KeyUsage&x509.KeyUsageContentCommitment != 0:
+		i.KeyType = NCAKeyRSA
+	case oid.Equal(rsaEncryptionOID):
+		i.KeyType = NCAKeyAuth
+	}
+}
+
+// gostParamSet returns the public key parameter set of GOST key parameters, which KalkanCrypt may
+// encode as a bare OID instead of the SEQUENCE of RFC 4491
+func gostParamSet(params asn1.RawValue) Algorithm {
+	var paramSet asn1.ObjectIdentifier
+	if params.Class == asn1.ClassUniversal && params.Tag == asn1.TagOID {
+		if _, err := asn1.Unmarshal(params.FullBytes, &paramSet); err != nil {
+			return Algorithm{}
+		}
+	} else {
+		var gost gostKeyParameters
+		if _, err := asn1.Unmarshal(params.FullBytes, &gost); err != nil {
+			return Algorithm{}
+		}
+
+		paramSet = gost.PublicKeyParamSet
+	}
+
+	return Algorithm{OID: paramSet, Name: GetOIDDescription(paramSet)}
+}
@@ -0,0 +1,270 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// ncaPolicyOID is a certificate policy OID of the NCA of Kazakhstan
+var ncaPolicyOID = asn1.ObjectIdentifier{1, 2, 398, 3, 3, 2, 1}
+
+// testTBSCertificate provides the ASN.1 structure of a TBSCertificate with extensions
+type testTBSCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             pkix.RDNSequence
+	Validity           struct{ NotBefore, NotAfter time.Time }
+	Subject            pkix.RDNSequence
+	PublicKey          subjectPublicKeyInfo
+	Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+// createNCACertificate creates a DER certificate with the public key, key usage bits and policies.
+// RSA keys are signed with SHA-256 with RSA, others with GOST 34.310, the signature is not valid
+func createNCACertificate(t *testing.T, publicKey subjectPublicKeyInfo, keyUsage byte, policies ...asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	usage, err := asn1.Marshal(asn1.BitString{Bytes: []byte{keyUsage}, BitLength: 8})
+	if err != nil {
+		t.Fatalf("Failed to marshal key usage: %v", err)
+	}
+
+	extensions := []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 15}, Critical: true, Value: usage}}
+
+	if len(policies) > 0 {
+		var policyInfos []struct{ Policy asn1.ObjectIdentifier }
+		for _, policy := range policies {
+			policyInfos = append(policyInfos, struct{ Policy asn1.ObjectIdentifier }{policy})
+		}
+
+		value, err := asn1.Marshal(policyInfos)
+		if err != nil {
+			t.Fatalf("Failed to marshal certificate policies: %v", err)
+		}
+
+		extensions = append(extensions, pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 32}, Value: value})
+	}
+
+	name := pkix.Name{CommonName: "ИВАНОВ ИВАН"}.ToRDNSequence()
+	signatureAlgorithm := pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 2}}
+	if publicKey.Algorithm.Algorithm.Equal(rsaEncryptionOID) {
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}, Parameters: asn1.NullRawValue}
+	}
+
+	tbs := testTBSCertificate{
+		Version:            2,
+		SerialNumber:       big.NewInt(1),
+		SignatureAlgorithm: signatureAlgorithm,
+		Issuer:             name,
+		Subject:            name,
+		PublicKey:          publicKey,
+		Extensions:         extensions,
+	}
+	tbs.Validity.NotBefore = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	tbs.Validity.NotAfter = time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tbsBytes, err := asn1.Marshal(tbs)
+	if err != nil {
+		t.Fatalf("Failed to marshal TBS certificate: %v", err)
+	}
+
+	der, err := asn1.Marshal(
+		signedEnvelope{
+			TBS:                asn1.RawValue{FullBytes: tbsBytes},
+			SignatureAlgorithm: signatureAlgorithm,
+			Signature:          asn1.BitString{Bytes: make([]byte, 64), BitLength: 512},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal certificate: %v", err)
+	}
+
+	return der
+}
+
+// createNCAKeyContainer creates a PFX with a shrouded key bag encrypted with the algorithm and the
+// certificate, both with the same localKeyId
+func createNCAKeyContainer(t *testing.T, storage pkix.AlgorithmIdentifier, cert []byte) []byte {
+	t.Helper()
+
+	localKeyID := []byte{0x0a, 0x0b, 0x0c}
+	keyInfo := encryptedPrivateKeyInfo{Algorithm: storage, EncryptedData: make([]byte, 16)}
+
+	return createPFX(
+		t, []safeBag{
+			createSafeBag(
+				t, PKCS12ShroudedKeyBagOID, keyInfo,
+				createAttribute(t, FriendlyNameAttributeOID, createBMPString("a1b2c3")),
+				createAttribute(t, LocalKeyIDAttributeOID, localKeyID),
+			),
+			createSafeBag(
+				t, PKCS12CertBagOID, certBag{CertID: x509CertBagOID, CertValue: cert},
+				createAttribute(t, LocalKeyIDAttributeOID, localKeyID),
+			),
+		}, nil,
+	)
+}
+
+// TestNCAKeyMetadata tests extraction of NCA key container metadata without the password
+func TestNCAKeyMetadata(t *testing.T) {
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	tripleDESOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	kazakhParamSetOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 2, 3, 2}
+	tc26ParamSetOID := asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 1}
+
+	marshal := func(value interface{}) asn1.RawValue {
+		der, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to marshal value: %v", err)
+		}
+
+		return asn1.RawValue{FullBytes: der}
+	}
+
+	pbes2 := pkix.AlgorithmIdentifier{
+		Algorithm: pbes2OID,
+		Parameters: marshal(
+			pbes2Params{
+				KeyDerivationFunc: pkix.AlgorithmIdentifier{
+					Algorithm:  pbkdf2OID,
+					Parameters: marshal(pbkdf2Params{Salt: marshal(make([]byte, 8)), IterationCount: 2000}),
+				},
+				EncryptionScheme: pkix.AlgorithmIdentifier{Algorithm: aesOID, Parameters: marshal(make([]byte, 16))},
+			},
+		),
+	}
+	tripleDES := pkix.AlgorithmIdentifier{Algorithm: tripleDESOID, Parameters: marshal(pbeParams{Salt: make([]byte, 8), Iterations: 1024})}
+
+	kazakhGOSTKey := subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 1}, Parameters: marshal(kazakhParamSetOID)},
+		PublicKey: asn1.BitString{Bytes: make([]byte, 64), BitLength: 512},
+	}
+	tc26GOSTKey := subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}, Parameters: marshal(gostKeyParameters{PublicKeyParamSet: tc26ParamSetOID})},
+		PublicKey: asn1.BitString{Bytes: make([]byte, 128), BitLength: 1024},
+	}
+	rsaPublicKey := marshal(struct {
+		N *big.Int
+		E int
+	}{N: new(big.Int).Lsh(big.NewInt(1), 2047), E: 65537}).FullBytes
+	rsaKey := subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: rsaEncryptionOID, Parameters: asn1.NullRawValue},
+		PublicKey: asn1.BitString{Bytes: rsaPublicKey, BitLength: 8 * len(rsaPublicKey)},
+	}
+
+	// Key usage bits: digitalSignature 0x80, nonRepudiation 0x40, keyEncipherment 0x20
+	const (
+		authUsage = 0xa0
+		signUsage = 0xc0
+	)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    NCAKeyInfo
+		expectedErr error
+	}{
+		{
+			name: "GOST key with PBES2",
+			data: createNCAKeyContainer(t, pbes2, createNCACertificate(t, kazakhGOSTKey, signUsage)),
+			expected: NCAKeyInfo{
+				KeyType:       NCAKeyGOST,
+				StorageScheme: newAlgorithm(pbes2OID),
+				StorageCipher: newAlgorithm(aesOID),
+				Iterations:    2000,
+				Curve:         Algorithm{OID: kazakhParamSetOID, Name: GetOIDDescription(kazakhParamSetOID)},
+			},
+		},
+		{
+			name: "GOST key with RFC 4491 parameters",
+			data: createNCAKeyContainer(t, pbes2, createNCACertificate(t, tc26GOSTKey, signUsage, ncaPolicyOID)),
+			expected: NCAKeyInfo{
+				KeyType:       NCAKeyGOST,
+				StorageScheme: newAlgorithm(pbes2OID),
+				StorageCipher: newAlgorithm(aesOID),
+				Iterations:    2000,
+				Curve:         Algorithm{OID: tc26ParamSetOID, Name: GetOIDDescription(tc26ParamSetOID)},
+			},
+		},
+		{
+			name: "RSA authentication key",
+			data: createNCAKeyContainer(t, tripleDES, createNCACertificate(t, rsaKey, authUsage, ncaPolicyOID)),
+			expected: NCAKeyInfo{
+				KeyType:       NCAKeyAuth,
+				StorageScheme: newAlgorithm(tripleDESOID),
+				Iterations:    1024,
+			},
+		},
+		{
+			name: "RSA signing key",
+			data: createNCAKeyContainer(t, tripleDES, createNCACertificate(t, rsaKey, signUsage, ncaPolicyOID)),
+			expected: NCAKeyInfo{
+				KeyType:       NCAKeyRSA,
+				StorageScheme: newAlgorithm(tripleDESOID),
+				Iterations:    1024,
+			},
+		},
+		{
+			name:        "PKCS#12 without NCA OIDs",
+			data:        createNCAKeyContainer(t, tripleDES, createNCACertificate(t, rsaKey, signUsage)),
+			expectedErr: ErrNotNCAKeyContainer,
+		},
+		{
+			name:        "PKCS#7 Signed Data",
+			data:        createTestData(t, PKCS7SignedDataOID),
+			expectedErr: ErrNotNCAKeyContainer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, err := NCAKeyMetadata(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if err != nil {
+					return
+				}
+
+				if info.KeyType != tt.expected.KeyType {
+					t.Errorf("Expected key type %v, got %v", tt.expected.KeyType, info.KeyType)
+				}
+
+				if !info.StorageScheme.OID.Equal(tt.expected.StorageScheme.OID) || info.StorageScheme.Name != tt.expected.StorageScheme.Name {
+					t.Errorf("Expected storage scheme %v, got %v", tt.expected.StorageScheme, info.StorageScheme)
+				}
+
+				if !info.StorageCipher.OID.Equal(tt.expected.StorageCipher.OID) {
+					t.Errorf("Expected storage cipher %v, got %v", tt.expected.StorageCipher, info.StorageCipher)
+				}
+
+				if info.Iterations != tt.expected.Iterations {
+					t.Errorf("Expected %d iterations, got %d", tt.expected.Iterations, info.Iterations)
+				}
+
+				if !info.Curve.OID.Equal(tt.expected.Curve.OID) || info.Curve.Name != tt.expected.Curve.Name {
+					t.Errorf("Expected curve %v, got %v", tt.expected.Curve, info.Curve)
+				}
+
+				if info.FriendlyName != "a1b2c3" {
+					t.Errorf("Expected friendly name %q, got %q", "a1b2c3", info.FriendlyName)
+				}
+
+				if expected := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC); !info.NotBefore.Equal(expected) {
+					t.Errorf("Expected NotBefore %v, got %v", expected, info.NotBefore)
+				}
+
+				if info.MACIterations != 2048 {
+					t.Errorf("Expected 2048 MAC iterations, got %d", info.MACIterations)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,70 @@
+package cmsdetector
+
+import "encoding/asn1"
+
+// ncaDigestAlgorithms lists the digest algorithms accepted by e-government services of Kazakhstan
+var ncaDigestAlgorithms = map[string]bool{
+	"2.16.840.1.101.3.4.2.1": true, // SHA-256
+	"1.2.398.3.10.1.3.1":     true, // GOST 34.311-95
+	"1.2.643.7.1.1.2.2":      true, // GOST R 34.11-2012 (256 bit), adopted as ST RK GOST R 34.11-2015
+	"1.2.643.7.1.1.2.3":      true, // GOST R 34.11-2012 (512 bit)
+}
+
+// ncaSignatureAlgorithms lists the signature algorithms of keys issued by the NCA, RSA signers may
+// give the key algorithm instead of the signature algorithm
+var ncaSignatureAlgorithms = map[string]bool{
+	"1.2.840.113549.1.1.1":  true, // RSA
+	"1.2.840.113549.1.1.11": true, // SHA-256 with RSA
+	"1.2.398.3.10.1.1.1.2":  true, // GOST 34.310-2004 with GOST 34.311-95
+	"1.2.643.7.1.1.3.2":     true, // GOST R 34.10-2012 with GOST R 34.11-2012 (256 bit)
+	"1.2.643.7.1.1.3.3":     true, // GOST R 34.10-2012 with GOST R 34.11-2012 (512 bit)
+}
+
+// ncaSignedAttributes lists the signed attributes every signer must carry
+var ncaSignedAttributes = []asn1.ObjectIdentifier{
+	ContentTypeAttributeOID,
+	MessageDigestAttributeOID,
+	SigningTimeAttributeOID,
+}
+
+// CheckNCAProfile checks SignedData against the CMS profile of the NCA of Kazakhstan required by
+// e-government services: every signer carries the contentType, messageDigest, signingTime and
+// signing certificate signed attributes and a signature time-stamp (CAdES-T), uses SHA-256, GOST
+// or RSA algorithms of NCA keys, and its certificate is embedded. Signatures are not verified.
+// Data other than SignedData is rejected with ErrNotSignedData
+func CheckNCAProfile(data []byte) (report *ProfileReport, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	report = &ProfileReport{}
+	report.add(-1, ProfileRuleSigner, len(sd.SignerInfos) > 0, "%d signers", len(sd.SignerInfos))
+
+	certs := parseCertificates(sd.Certificates)
+
+	for i, si := range sd.SignerInfos {
+		signed, unsigned, err := signerAttributes(si)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, oid := range ncaSignedAttributes {
+			report.add(i, ProfileRuleAttribute, len(attributeValues(signed, oid)) > 0, "signed attribute %s", GetOIDDescription(oid))
+		}
+
+		report.add(i, ProfileRuleAttribute, hasSigningCertificateAttribute(signed), "signed attribute %s", GetOIDDescription(SigningCertificateV2AttributeOID))
+
+		digest, signature := si.DigestAlgorithm.Algorithm, si.SignatureAlgorithm.Algorithm
+		report.add(i, ProfileRuleAlgorithm, ncaDigestAlgorithms[digest.String()], "digest algorithm %s", GetAlgorithmName(digest))
+		report.add(i, ProfileRuleAlgorithm, ncaSignatureAlgorithms[signature.String()], "signature algorithm %s", GetAlgorithmName(signature))
+
+		report.add(i, ProfileRuleTimestamp, len(attributeValues(unsigned, TimeStampTokenAttributeOID)) > 0, "signature time-stamp")
+
+		report.add(i, ProfileRuleCertificate, hasSignerCertificate(si, certs), "signer certificate embedded")
+	}
+
+	return report, nil
+}
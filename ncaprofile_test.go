@@ -0,0 +1,116 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestCheckNCAProfile tests the checks of SignedData against the NCA signature profile
+func TestCheckNCAProfile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	cert := createCertificate(t, "signer", key)
+	kazakhGOSTDigestOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 3, 1}
+	kazakhGOSTSignatureOID := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 2}
+	ecdsaWithSHA256OID := asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+	signedAttrs := []attribute{
+		createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID),
+		createAttribute(t, MessageDigestAttributeOID, make([]byte, 32)),
+		createAttribute(t, SigningTimeAttributeOID, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)),
+		createAttribute(t, SigningCertificateV2AttributeOID, asn1.RawValue{FullBytes: []byte{0x30, 0x00}}),
+	}
+	timeStamp := createAttribute(t, TimeStampTokenAttributeOID, asn1.RawValue{FullBytes: []byte{0x30, 0x00}})
+
+	signer := func(digestOID, signatureOID asn1.ObjectIdentifier, signed, unsigned []attribute) signerInfo {
+		si := createSignerInfo(t, digestOID, signatureOID, signed, unsigned)
+		si.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte("signer")}
+
+		return si
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{
+			name: "GOST CAdES-T",
+			data: createSignedDataWithSigners(
+				t, []signerInfo{signer(kazakhGOSTDigestOID, kazakhGOSTSignatureOID, signedAttrs, []attribute{timeStamp})}, cert,
+			),
+		},
+		{
+			name: "CAdES-BES without time-stamp",
+			data: createSignedDataWithSigners(t, []signerInfo{signer(cmstest.SHA256OID, cmstest.SHA256WithRSAOID, signedAttrs, nil)}, cert),
+			expected: []string{
+				"FAIL signer 0: signature time-stamp [timestamp]",
+			},
+		},
+		{
+			name: "Missing attributes and certificate",
+			data: createSignedDataWithSigners(t, []signerInfo{signer(cmstest.SHA256OID, cmstest.SHA256WithRSAOID, signedAttrs[:2], []attribute{timeStamp})}),
+			expected: []string{
+				"FAIL signer 0: signed attribute " + GetOIDDescription(SigningTimeAttributeOID) + " [attribute]",
+				"FAIL signer 0: signed attribute " + GetOIDDescription(SigningCertificateV2AttributeOID) + " [attribute]",
+				"FAIL signer 0: signer certificate embedded [certificate]",
+			},
+		},
+		{
+			name: "Foreign algorithms",
+			data: createSignedDataWithSigners(
+				t, []signerInfo{signer(cmstest.SHA1OID, ecdsaWithSHA256OID, signedAttrs, []attribute{timeStamp})}, cert,
+			),
+			expected: []string{
+				"FAIL signer 0: digest algorithm " + GetAlgorithmName(cmstest.SHA1OID) + " [algorithm]",
+				"FAIL signer 0: signature algorithm " + GetAlgorithmName(ecdsaWithSHA256OID) + " [algorithm]",
+			},
+		},
+		{
+			name: "No signers",
+			data: createSignedDataWithSigners(t, nil, cert),
+			expected: []string{
+				"FAIL: 0 signers [signer]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := CheckNCAProfile(tt.data)
+				if err != nil {
+					t.Fatalf("CheckNCAProfile returned an error: %v", err)
+				}
+
+				var failures []string
+				for _, check := range report.Failures() {
+					failures = append(failures, check.String())
+				}
+
+				if !reflect.DeepEqual(failures, tt.expected) {
+					t.Errorf("Expected failures %q, got %q", tt.expected, failures)
+				}
+
+				if passed := len(tt.expected) == 0; report.Passed() != passed {
+					t.Errorf("Expected passed %v, got %v", passed, report.Passed())
+				}
+			},
+		)
+	}
+
+	if _, err := CheckNCAProfile(createTestData(t, PKCS7EnvelopedDataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected error %v, got %v", ErrNotSignedData, err)
+	}
+}
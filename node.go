@@ -0,0 +1,122 @@
+package cmsdetector
+
+import "errors"
+
+// SkipNode is returned by a Walk visitor to skip the children of the visited node
+var SkipNode = errors.New("skip node")
+
+// Node is a detection result of a complex input as a tree, e.g. the content layers of a CMS
+// structure or the files unpacked from a container, that can be walked and encoded as JSON
+// without knowing the API it was built from
+type Node struct {
+	Kind     Kind              `json:"kind"`
+	Name     string            `json:"name,omitempty"`     // Path of a file or description of a content type
+	Offset   int64             `json:"offset"`             // Byte offset in the data of the root node, -1 if unknown
+	Length   int64             `json:"length"`             // Size in bytes, of the content for CMS layers
+	Metadata map[string]string `json:"metadata,omitempty"` // Properties specific to the source, e.g. "content_type"
+	Children []*Node           `json:"children,omitempty"`
+}
+
+// Walk calls visit for the node and its descendants in depth-first order, with the depth of each
+// node starting at 0. Returning SkipNode skips the children of the visited node, other errors stop
+// the walk and are returned
+func (n *Node) Walk(visit func(node *Node, depth int) error) error {
+	err := n.walk(visit, 0)
+	if errors.Is(err, SkipNode) {
+		return nil
+	}
+
+	return err
+}
+
+// walk visits the node and its descendants at the given depth
+func (n *Node) walk(visit func(node *Node, depth int) error, depth int) error {
+	if err := visit(n, depth); err != nil {
+		return err
+	}
+
+	for _, child := range n.Children {
+		if err := child.walk(visit, depth+1); err != nil && !errors.Is(err, SkipNode) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setMetadata records the property unless the value is empty
+func (n *Node) setMetadata(key, value string) {
+	if value == "" {
+		return
+	}
+
+	if n.Metadata == nil {
+		n.Metadata = make(map[string]string)
+	}
+
+	n.Metadata[key] = value
+}
+
+// Node returns the layer and its nested layers as a tree. Metadata holds the "content_type" and,
+// if set, "encrypted" and "detached"
+func (l *Layer) Node() *Node {
+	return l.node(0)
+}
+
+// node returns the layer as a tree, only the offset of the root layer is known
+func (l *Layer) node(offset int64) *Node {
+	node := &Node{Kind: l.Kind, Name: l.Type, Offset: offset, Length: l.Size}
+	node.setMetadata("content_type", l.ContentType.String())
+
+	if l.Encrypted {
+		node.setMetadata("encrypted", "true")
+	}
+
+	if l.Detached {
+		node.setMetadata("detached", "true")
+	}
+
+	for i := range l.Layers {
+		node.Children = append(node.Children, l.Layers[i].node(-1))
+	}
+
+	return node
+}
+
+// Node returns the file and the files unpacked from it as a tree. Metadata holds the "family",
+// "confidence" and, if set, "content_type", "encrypted", "container", "error" and "unpack_error"
+func (f ScannedFile) Node() *Node {
+	return f.node(0)
+}
+
+// node returns the file as a tree, the offsets of unpacked files are unknown
+func (f ScannedFile) node(offset int64) *Node {
+	node := &Node{Kind: f.Result.Kind, Name: f.Path, Offset: offset, Length: f.Size}
+
+	if f.Err == nil {
+		node.setMetadata("family", f.Result.Family.String())
+		node.setMetadata("confidence", f.Result.Confidence.String())
+	} else {
+		node.setMetadata("error", f.Err.Error())
+	}
+
+	if len(f.ContentType) > 0 {
+		node.setMetadata("content_type", f.ContentType.String())
+	}
+
+	if f.Encrypted {
+		node.setMetadata("encrypted", "true")
+	}
+
+	node.setMetadata("container", f.Container)
+
+	if f.UnpackErr != nil {
+		node.setMetadata("unpack_error", f.UnpackErr.Error())
+	}
+
+	for _, child := range f.Children {
+		node.Children = append(node.Children, child.node(-1))
+	}
+
+	return node
+}
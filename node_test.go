@@ -0,0 +1,182 @@
+package cmsdetector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestLayerNode tests the tree of the content layers of SignedData
+func TestLayerNode(t *testing.T) {
+	layer, err := InspectLayers(createSignedDataWithContent(t, []byte("content")))
+	if err != nil {
+		t.Fatalf("InspectLayers returned an error: %v", err)
+	}
+
+	expected := &Node{
+		Kind:     KindSignedData,
+		Name:     KindSignedData.String(),
+		Length:   layer.Size,
+		Metadata: map[string]string{"content_type": PKCS7SignedDataOID.String()},
+		Children: []*Node{
+			{
+				Kind:     KindData,
+				Name:     KindData.String(),
+				Offset:   -1,
+				Length:   7,
+				Metadata: map[string]string{"content_type": PKCS7DataOID.String()},
+			},
+		},
+	}
+
+	if node := layer.Node(); !reflect.DeepEqual(node, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, node)
+	}
+}
+
+// TestScannedFileNode tests the tree of the files unpacked from an archive
+func TestScannedFileNode(t *testing.T) {
+	archive := createZIP(t, []archiveMember{{name: "id.p12", data: cmstest.PFX(t)}, {name: "notes.txt", data: []byte("Hello, world")}})
+
+	node := (&Scanner{Unpack: true}).Classify("backup.zip", archive).Node()
+
+	var lines []string
+	err := node.Walk(
+		func(node *Node, depth int) error {
+			lines = append(lines, fmt.Sprintf("%s%s %v %s %s", strings.Repeat("  ", depth), node.Name, node.Kind, node.Metadata["container"], node.Metadata["family"]))
+
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("Walk returned an error: %v", err)
+	}
+
+	expected := []string{
+		"backup.zip Unknown archive ",
+		"  backup.zip/id.p12 PKCS#12  CMS/PKCS",
+		"  backup.zip/notes.txt Unknown  ",
+	}
+
+	if !equalStrings(lines, expected) {
+		t.Errorf("Expected %q, got %q", expected, lines)
+	}
+
+	if !strings.HasPrefix(node.Children[1].Metadata["error"], "unknown format") {
+		t.Errorf("Expected an unknown format error, got %q", node.Children[1].Metadata["error"])
+	}
+}
+
+// TestNodeWalk tests skipping children and stopping a walk
+func TestNodeWalk(t *testing.T) {
+	tree := &Node{
+		Name: "root",
+		Children: []*Node{
+			{Name: "a", Children: []*Node{{Name: "a1"}}},
+			{Name: "b", Children: []*Node{{Name: "b1"}}},
+			{Name: "c"},
+		},
+	}
+
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name        string
+		result      func(node *Node) error
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "All nodes",
+			result:   func(*Node) error { return nil },
+			expected: []string{"root", "a", "a1", "b", "b1", "c"},
+		},
+		{
+			name: "Skipped children",
+			result: func(node *Node) error {
+				if node.Name == "a" {
+					return SkipNode
+				}
+
+				return nil
+			},
+			expected: []string{"root", "a", "b", "b1", "c"},
+		},
+		{
+			name: "Stopped walk",
+			result: func(node *Node) error {
+				if node.Name == "b" {
+					return errStop
+				}
+
+				return nil
+			},
+			expected:    []string{"root", "a", "a1", "b"},
+			expectedErr: errStop,
+		},
+		{
+			name:     "Skipped root",
+			result:   func(*Node) error { return SkipNode },
+			expected: []string{"root"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var visited []string
+
+				err := tree.Walk(
+					func(node *Node, _ int) error {
+						visited = append(visited, node.Name)
+
+						return tt.result(node)
+					},
+				)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if !equalStrings(visited, tt.expected) {
+					t.Errorf("Expected %v, got %v", tt.expected, visited)
+				}
+			},
+		)
+	}
+}
+
+// TestNodeJSON tests that a tree survives encoding as JSON
+func TestNodeJSON(t *testing.T) {
+	tree := &Node{
+		Kind:     KindSignedData,
+		Name:     "signed.p7s",
+		Length:   1024,
+		Metadata: map[string]string{"family": "CMS/PKCS"},
+		Children: []*Node{{Kind: KindData, Offset: -1, Length: 7}},
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatalf("Failed to marshal node: %v", err)
+	}
+
+	expected := `{"kind":"PKCS#7 Signed Data","name":"signed.p7s","offset":0,"length":1024,"metadata":{"family":"CMS/PKCS"},` +
+		`"children":[{"kind":"PKCS#7 Data","offset":-1,"length":7}]}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	var decoded Node
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal node: %v", err)
+	}
+
+	if !reflect.DeepEqual(&decoded, tree) {
+		t.Errorf("Expected %+v, got %+v", tree, &decoded)
+	}
+}
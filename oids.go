@@ -0,0 +1,651 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// OIDCategory groups registered OIDs by their purpose
+type OIDCategory string
+
+// OID categories
+const (
+	OIDCategoryContentType       OIDCategory = "content type"
+	OIDCategoryBag               OIDCategory = "PKCS#12 bag"
+	OIDCategoryDigest            OIDCategory = "digest algorithm"
+	OIDCategoryMAC               OIDCategory = "MAC algorithm"
+	OIDCategoryPublicKey         OIDCategory = "public key algorithm"
+	OIDCategorySignature         OIDCategory = "signature algorithm"
+	OIDCategoryKeyEncryption     OIDCategory = "key encryption algorithm"
+	OIDCategoryContentEncryption OIDCategory = "content encryption algorithm"
+	OIDCategoryPasswordBased     OIDCategory = "password-based encryption algorithm"
+	OIDCategoryCompression       OIDCategory = "compression algorithm"
+	OIDCategoryCurve             OIDCategory = "curve or parameter set"
+	OIDCategoryAttribute         OIDCategory = "attribute"
+	OIDCategoryCommitmentType    OIDCategory = "commitment type"
+	OIDCategoryExtension         OIDCategory = "certificate extension"
+	OIDCategoryAccessMethod      OIDCategory = "access method"
+	OIDCategoryKeyPurpose        OIDCategory = "extended key usage"
+	OIDCategoryPolicy            OIDCategory = "certificate policy"
+	OIDCategoryNameAttribute     OIDCategory = "name attribute"
+)
+
+// algorithmCategories lists the categories describing cryptographic algorithms
+var algorithmCategories = map[OIDCategory]bool{
+	OIDCategoryDigest:            true,
+	OIDCategoryMAC:               true,
+	OIDCategoryPublicKey:         true,
+	OIDCategorySignature:         true,
+	OIDCategoryKeyEncryption:     true,
+	OIDCategoryContentEncryption: true,
+	OIDCategoryPasswordBased:     true,
+}
+
+// IsAlgorithm checks if the category describes a cryptographic algorithm
+func (c OIDCategory) IsAlgorithm() bool {
+	return algorithmCategories[c]
+}
+
+// OIDInfo describes a registered OID
+type OIDInfo struct {
+	Name        string      // Human-readable name
+	Reference   string      // Standard or specification defining the OID
+	Category    OIDCategory // Purpose of the OID
+	Kind        Kind        // Kind reported by Detect for registered content types
+	OpenSSLName string      // OpenSSL short name, e.g. "pkcs7-signedData"
+	IANAName    string      // Name in the IANA S/MIME registries, e.g. "id-ct-authData"
+}
+
+// oidDatabase maps dotted OIDs to their descriptions
+var oidDatabase = map[string]OIDInfo{
+	// PKCS#7 / CMS content types
+	"1.2.840.113549.1.7.1":     {Name: "PKCS#7 Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.7.2":     {Name: "PKCS#7 Signed Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.7.3":     {Name: "PKCS#7 Enveloped Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.7.4":     {Name: "PKCS#7 Signed And Enveloped Data", Reference: "RFC 2315", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.7.5":     {Name: "PKCS#7 Digested Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.7.6":     {Name: "PKCS#7 Encrypted Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.12.10.1": {Name: "PKCS#12", Reference: "RFC 7292", Category: OIDCategoryContentType},
+
+	// S/MIME content types (id-ct)
+	"1.2.840.113549.1.9.16.1.1":  {Name: "Receipt", Reference: "RFC 2634", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.2":  {Name: "Authenticated Data", Reference: "RFC 5652", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.4":  {Name: "Time-Stamp Token Info", Reference: "RFC 3161", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.6":  {Name: "Content Info", Reference: "RFC 2634", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.9":  {Name: "Compressed Data", Reference: "RFC 3274", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.16": {Name: "Firmware Package", Reference: "RFC 4108", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.17": {Name: "Firmware Load Receipt", Reference: "RFC 4108", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.18": {Name: "Firmware Load Error", Reference: "RFC 4108", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.19": {Name: "Content Collection", Reference: "RFC 4073", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.20": {Name: "Content With Attributes", Reference: "RFC 4073", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.23": {Name: "Authenticated Enveloped Data", Reference: "RFC 5083", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.24": {Name: "RPKI Route Origin Authorization", Reference: "RFC 6482", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.25": {Name: "Symmetric Key Package", Reference: "RFC 6031", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.26": {Name: "RPKI Manifest", Reference: "RFC 6486", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.31": {Name: "Timestamped Data", Reference: "RFC 5544", Category: OIDCategoryContentType},
+	"1.2.840.113549.1.9.16.1.35": {Name: "RPKI Ghostbusters Record", Reference: "RFC 6493", Category: OIDCategoryContentType},
+
+	// Key package content types (id-ct-KP)
+	"2.16.840.1.101.2.1.2.78.2": {Name: "Encrypted Key Package", Reference: "RFC 6032", Category: OIDCategoryContentType},
+	"2.16.840.1.101.2.1.2.78.5": {Name: "Asymmetric Key Package", Reference: "RFC 5958", Category: OIDCategoryContentType},
+
+	// Vendor content types
+	"1.3.6.1.4.1.311.2.1.4":  {Name: "SPC Indirect Data Content", Reference: "Microsoft Authenticode", Category: OIDCategoryContentType},
+	"1.3.6.1.4.1.311.10.1":   {Name: "Windows Security Catalog", Reference: "Microsoft", Category: OIDCategoryContentType},
+	"1.3.6.1.4.1.311.12.1.1": {Name: "Catalog List", Reference: "Microsoft", Category: OIDCategoryContentType},
+	"1.3.6.1.4.1.311.12.1.2": {Name: "Catalog List Member", Reference: "Microsoft", Category: OIDCategoryContentType},
+
+	// PKCS#12 bag types
+	"1.2.840.113549.1.12.10.1.1": {Name: "PKCS#12 Key Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.12.10.1.2": {Name: "PKCS#12 Shrouded Key Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.12.10.1.3": {Name: "PKCS#12 Certificate Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.12.10.1.4": {Name: "PKCS#12 CRL Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.12.10.1.5": {Name: "PKCS#12 Secret Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.12.10.1.6": {Name: "PKCS#12 Safe Contents Bag", Reference: "RFC 7292", Category: OIDCategoryBag},
+	"1.2.840.113549.1.9.22.1":    {Name: "X.509 Certificate (PKCS#9 certType)", Reference: "RFC 2985", Category: OIDCategoryBag},
+	"1.2.840.113549.1.9.22.2":    {Name: "SDSI Certificate (PKCS#9 certType)", Reference: "RFC 2985", Category: OIDCategoryBag},
+	"1.2.840.113549.1.9.23.1":    {Name: "X.509 CRL (PKCS#9 crlType)", Reference: "RFC 2985", Category: OIDCategoryBag},
+
+	// Digest algorithms
+	"1.2.840.113549.2.2":      {Name: "MD2", Reference: "RFC 1319", Category: OIDCategoryDigest},
+	"1.2.840.113549.2.4":      {Name: "MD4", Reference: "RFC 1320", Category: OIDCategoryDigest},
+	"1.2.840.113549.2.5":      {Name: "MD5", Reference: "RFC 1321", Category: OIDCategoryDigest},
+	"1.3.14.3.2.26":           {Name: "SHA-1", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.4":  {Name: "SHA-224", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.1":  {Name: "SHA-256", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.2":  {Name: "SHA-384", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.3":  {Name: "SHA-512", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.5":  {Name: "SHA-512/224", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.6":  {Name: "SHA-512/256", Reference: "FIPS 180-4", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.7":  {Name: "SHA3-224", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.8":  {Name: "SHA3-256", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.9":  {Name: "SHA3-384", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.10": {Name: "SHA3-512", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.11": {Name: "SHAKE128", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"2.16.840.1.101.3.4.2.12": {Name: "SHAKE256", Reference: "FIPS 202", Category: OIDCategoryDigest},
+	"1.3.36.3.2.1":            {Name: "RIPEMD-160", Reference: "ISO/IEC 10118-3", Category: OIDCategoryDigest},
+	"1.2.156.10197.1.401":     {Name: "SM3", Reference: "GB/T 32905-2016", Category: OIDCategoryDigest},
+	"1.2.643.2.2.9":           {Name: "GOST R 34.11-94", Reference: "RFC 4357", Category: OIDCategoryDigest},
+	"1.2.643.7.1.1.2.2":       {Name: "GOST R 34.11-2012 (256 bit)", Reference: "RFC 6986", Category: OIDCategoryDigest},
+	"1.2.643.7.1.1.2.3":       {Name: "GOST R 34.11-2012 (512 bit)", Reference: "RFC 6986", Category: OIDCategoryDigest},
+	"1.2.398.3.10.1.3.1":      {Name: "GOST 34.311-95", Reference: "Kazakhstan NCA", Category: OIDCategoryDigest},
+
+	// MAC algorithms
+	"1.2.840.113549.2.7":      {Name: "HMAC with SHA-1", Reference: "RFC 8018", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.8":      {Name: "HMAC with SHA-224", Reference: "RFC 4231", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.9":      {Name: "HMAC with SHA-256", Reference: "RFC 4231", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.10":     {Name: "HMAC with SHA-384", Reference: "RFC 4231", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.11":     {Name: "HMAC with SHA-512", Reference: "RFC 4231", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.12":     {Name: "HMAC with SHA-512/224", Reference: "RFC 8018", Category: OIDCategoryMAC},
+	"1.2.840.113549.2.13":     {Name: "HMAC with SHA-512/256", Reference: "RFC 8018", Category: OIDCategoryMAC},
+	"2.16.840.1.101.3.4.2.13": {Name: "HMAC with SHA3-224", Reference: "NIST CSOR", Category: OIDCategoryMAC},
+	"2.16.840.1.101.3.4.2.14": {Name: "HMAC with SHA3-256", Reference: "NIST CSOR", Category: OIDCategoryMAC},
+	"2.16.840.1.101.3.4.2.15": {Name: "HMAC with SHA3-384", Reference: "NIST CSOR", Category: OIDCategoryMAC},
+	"2.16.840.1.101.3.4.2.16": {Name: "HMAC with SHA3-512", Reference: "NIST CSOR", Category: OIDCategoryMAC},
+	"1.2.840.113549.1.5.14":   {Name: "PBMAC1", Reference: "RFC 8018", Category: OIDCategoryMAC},
+	"1.2.643.2.2.10":          {Name: "HMAC with GOST R 34.11-94", Reference: "RFC 4357", Category: OIDCategoryMAC},
+	"1.2.643.7.1.1.4.1":       {Name: "HMAC with GOST R 34.11-2012 (256 bit)", Reference: "RFC 7836", Category: OIDCategoryMAC},
+	"1.2.643.7.1.1.4.2":       {Name: "HMAC with GOST R 34.11-2012 (512 bit)", Reference: "RFC 7836", Category: OIDCategoryMAC},
+
+	// Public key algorithms
+	"1.2.840.113549.1.1.1":   {Name: "RSA", Reference: "RFC 8017", Category: OIDCategoryPublicKey},
+	"1.2.840.10040.4.1":      {Name: "DSA", Reference: "RFC 3279", Category: OIDCategoryPublicKey},
+	"1.2.840.10045.2.1":      {Name: "EC public key", Reference: "RFC 5480", Category: OIDCategoryPublicKey},
+	"1.2.840.10046.2.1":      {Name: "Diffie-Hellman", Reference: "RFC 3279", Category: OIDCategoryPublicKey},
+	"1.3.101.110":            {Name: "X25519", Reference: "RFC 8410", Category: OIDCategoryPublicKey},
+	"1.3.101.111":            {Name: "X448", Reference: "RFC 8410", Category: OIDCategoryPublicKey},
+	"1.2.156.10197.1.301":    {Name: "SM2", Reference: "GB/T 32918-2016", Category: OIDCategoryPublicKey},
+	"1.2.643.2.2.19":         {Name: "GOST R 34.10-2001", Reference: "RFC 4357", Category: OIDCategoryPublicKey},
+	"1.2.643.2.2.20":         {Name: "GOST R 34.10-94", Reference: "RFC 4357", Category: OIDCategoryPublicKey},
+	"1.2.643.7.1.1.1.1":      {Name: "GOST R 34.10-2012 (256 bit)", Reference: "RFC 7091", Category: OIDCategoryPublicKey},
+	"1.2.643.7.1.1.1.2":      {Name: "GOST R 34.10-2012 (512 bit)", Reference: "RFC 7091", Category: OIDCategoryPublicKey},
+	"1.2.398.3.10.1.1.1.1":   {Name: "GOST 34.310-2004", Reference: "Kazakhstan NCA", Category: OIDCategoryPublicKey},
+	"2.16.840.1.101.3.4.4.1": {Name: "ML-KEM-512", Reference: "FIPS 203", Category: OIDCategoryPublicKey},
+	"2.16.840.1.101.3.4.4.2": {Name: "ML-KEM-768", Reference: "FIPS 203", Category: OIDCategoryPublicKey},
+	"2.16.840.1.101.3.4.4.3": {Name: "ML-KEM-1024", Reference: "FIPS 203", Category: OIDCategoryPublicKey},
+
+	// Signature algorithms
+	"1.2.840.113549.1.1.2":    {Name: "MD2 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.3":    {Name: "MD4 with RSA", Reference: "RFC 2313", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.4":    {Name: "MD5 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.5":    {Name: "SHA-1 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.10":   {Name: "RSASSA-PSS", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.11":   {Name: "SHA-256 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.12":   {Name: "SHA-384 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.13":   {Name: "SHA-512 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.14":   {Name: "SHA-224 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.15":   {Name: "SHA-512/224 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.113549.1.1.16":   {Name: "SHA-512/256 with RSA", Reference: "RFC 8017", Category: OIDCategorySignature},
+	"1.2.840.10040.4.3":       {Name: "SHA-1 with DSA", Reference: "RFC 3279", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.1":  {Name: "SHA-224 with DSA", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.2":  {Name: "SHA-256 with DSA", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.3":  {Name: "SHA-384 with DSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.4":  {Name: "SHA-512 with DSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"1.2.840.10045.4.1":       {Name: "ECDSA with SHA-1", Reference: "RFC 3279", Category: OIDCategorySignature},
+	"1.2.840.10045.4.3.1":     {Name: "ECDSA with SHA-224", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"1.2.840.10045.4.3.2":     {Name: "ECDSA with SHA-256", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"1.2.840.10045.4.3.3":     {Name: "ECDSA with SHA-384", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"1.2.840.10045.4.3.4":     {Name: "ECDSA with SHA-512", Reference: "RFC 5758", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.9":  {Name: "ECDSA with SHA3-224", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.10": {Name: "ECDSA with SHA3-256", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.11": {Name: "ECDSA with SHA3-384", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.12": {Name: "ECDSA with SHA3-512", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.13": {Name: "SHA3-224 with RSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.14": {Name: "SHA3-256 with RSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.15": {Name: "SHA3-384 with RSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.16": {Name: "SHA3-512 with RSA", Reference: "NIST CSOR", Category: OIDCategorySignature},
+	"1.3.101.112":             {Name: "Ed25519", Reference: "RFC 8410", Category: OIDCategorySignature},
+	"1.3.101.113":             {Name: "Ed448", Reference: "RFC 8410", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.17": {Name: "ML-DSA-44", Reference: "FIPS 204", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.18": {Name: "ML-DSA-65", Reference: "FIPS 204", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.19": {Name: "ML-DSA-87", Reference: "FIPS 204", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.20": {Name: "SLH-DSA-SHA2-128s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.21": {Name: "SLH-DSA-SHA2-128f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.22": {Name: "SLH-DSA-SHA2-192s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.23": {Name: "SLH-DSA-SHA2-192f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.24": {Name: "SLH-DSA-SHA2-256s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.25": {Name: "SLH-DSA-SHA2-256f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.26": {Name: "SLH-DSA-SHAKE-128s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.27": {Name: "SLH-DSA-SHAKE-128f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.28": {Name: "SLH-DSA-SHAKE-192s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.29": {Name: "SLH-DSA-SHAKE-192f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.30": {Name: "SLH-DSA-SHAKE-256s", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"2.16.840.1.101.3.4.3.31": {Name: "SLH-DSA-SHAKE-256f", Reference: "FIPS 205", Category: OIDCategorySignature},
+	"1.2.156.10197.1.501":     {Name: "SM2 with SM3", Reference: "GB/T 32918-2016", Category: OIDCategorySignature},
+	"1.2.643.2.2.3":           {Name: "GOST R 34.11-94 with GOST R 34.10-2001", Reference: "RFC 4491", Category: OIDCategorySignature},
+	"1.2.643.2.2.4":           {Name: "GOST R 34.11-94 with GOST R 34.10-94", Reference: "RFC 4491", Category: OIDCategorySignature},
+	"1.2.643.7.1.1.3.2":       {Name: "GOST R 34.10-2012 with GOST R 34.11-2012 (256 bit)", Reference: "RFC 9215", Category: OIDCategorySignature},
+	"1.2.643.7.1.1.3.3":       {Name: "GOST R 34.10-2012 with GOST R 34.11-2012 (512 bit)", Reference: "RFC 9215", Category: OIDCategorySignature},
+	"1.2.398.3.10.1.1.1.2":    {Name: "GOST 34.310-2004 with GOST 34.311-95", Reference: "Kazakhstan NCA", Category: OIDCategorySignature},
+
+	// Key encryption and key agreement algorithms
+	"1.2.840.113549.1.1.7":       {Name: "RSAES-OAEP", Reference: "RFC 8017", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.1.8":       {Name: "MGF1", Reference: "RFC 8017", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.1.9":       {Name: "OAEP pSpecified", Reference: "RFC 8017", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.5":     {Name: "AES-128 Key Wrap", Reference: "RFC 3394", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.25":    {Name: "AES-192 Key Wrap", Reference: "RFC 3394", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.45":    {Name: "AES-256 Key Wrap", Reference: "RFC 3394", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.8":     {Name: "AES-128 Key Wrap with Padding", Reference: "RFC 5649", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.28":    {Name: "AES-192 Key Wrap with Padding", Reference: "RFC 5649", Category: OIDCategoryKeyEncryption},
+	"2.16.840.1.101.3.4.1.48":    {Name: "AES-256 Key Wrap with Padding", Reference: "RFC 5649", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.5":  {Name: "Ephemeral-Static Diffie-Hellman", Reference: "RFC 2631", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.6":  {Name: "Triple-DES Key Wrap", Reference: "RFC 3217", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.7":  {Name: "RC2 Key Wrap", Reference: "RFC 3217", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.9":  {Name: "Password Recipient Info KEK", Reference: "RFC 3211", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.28": {Name: "HKDF with SHA-256", Reference: "RFC 8619", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.29": {Name: "HKDF with SHA-384", Reference: "RFC 8619", Category: OIDCategoryKeyEncryption},
+	"1.2.840.113549.1.9.16.3.30": {Name: "HKDF with SHA-512", Reference: "RFC 8619", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.11.0":             {Name: "ECDH with SHA-224 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.11.1":             {Name: "ECDH with SHA-256 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.11.2":             {Name: "ECDH with SHA-384 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.11.3":             {Name: "ECDH with SHA-512 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.14.0":             {Name: "Cofactor ECDH with SHA-224 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.14.1":             {Name: "Cofactor ECDH with SHA-256 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.14.2":             {Name: "Cofactor ECDH with SHA-384 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.14.3":             {Name: "Cofactor ECDH with SHA-512 KDF", Reference: "RFC 5753", Category: OIDCategoryKeyEncryption},
+	"1.3.133.16.840.63.0.2":      {Name: "ECDH with SHA-1 KDF", Reference: "RFC 3278", Category: OIDCategoryKeyEncryption},
+	"1.3.133.16.840.63.0.3":      {Name: "Cofactor ECDH with SHA-1 KDF", Reference: "RFC 3278", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.12":               {Name: "ECDH", Reference: "SEC 1", Category: OIDCategoryKeyEncryption},
+	"1.3.132.1.13":               {Name: "ECMQV", Reference: "SEC 1", Category: OIDCategoryKeyEncryption},
+	"1.2.643.7.1.1.6.1":          {Name: "GOST R 34.10-2012 key agreement (256 bit)", Reference: "RFC 9189", Category: OIDCategoryKeyEncryption},
+	"1.2.643.7.1.1.6.2":          {Name: "GOST R 34.10-2012 key agreement (512 bit)", Reference: "RFC 9189", Category: OIDCategoryKeyEncryption},
+	"1.2.643.7.1.1.7.1.1":        {Name: "Magma KExp15 key wrap", Reference: "RFC 9189", Category: OIDCategoryKeyEncryption},
+	"1.2.643.7.1.1.7.2.1":        {Name: "Kuznyechik KExp15 key wrap", Reference: "RFC 9189", Category: OIDCategoryKeyEncryption},
+
+	// Content encryption algorithms
+	"1.3.14.3.2.7":               {Name: "DES-CBC", Reference: "FIPS 81", Category: OIDCategoryContentEncryption},
+	"1.2.840.113549.3.7":         {Name: "Triple-DES-CBC", Reference: "RFC 8018", Category: OIDCategoryContentEncryption},
+	"1.2.840.113549.3.2":         {Name: "RC2-CBC", Reference: "RFC 8018", Category: OIDCategoryContentEncryption},
+	"1.2.840.113549.3.4":         {Name: "RC4", Reference: "RFC 2246", Category: OIDCategoryContentEncryption},
+	"1.2.840.113533.7.66.10":     {Name: "CAST5-CBC", Reference: "RFC 2984", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.1":     {Name: "AES-128-ECB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.2":     {Name: "AES-128-CBC", Reference: "RFC 3565", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.3":     {Name: "AES-128-OFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.4":     {Name: "AES-128-CFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.6":     {Name: "AES-128-GCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.7":     {Name: "AES-128-CCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.21":    {Name: "AES-192-ECB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.22":    {Name: "AES-192-CBC", Reference: "RFC 3565", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.23":    {Name: "AES-192-OFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.24":    {Name: "AES-192-CFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.26":    {Name: "AES-192-GCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.27":    {Name: "AES-192-CCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.41":    {Name: "AES-256-ECB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.42":    {Name: "AES-256-CBC", Reference: "RFC 3565", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.43":    {Name: "AES-256-OFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.44":    {Name: "AES-256-CFB", Reference: "NIST CSOR", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.46":    {Name: "AES-256-GCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"2.16.840.1.101.3.4.1.47":    {Name: "AES-256-CCM", Reference: "RFC 5084", Category: OIDCategoryContentEncryption},
+	"1.2.840.113549.1.9.16.3.18": {Name: "ChaCha20-Poly1305", Reference: "RFC 8103", Category: OIDCategoryContentEncryption},
+	"1.2.392.200011.61.1.1.1.2":  {Name: "Camellia-128-CBC", Reference: "RFC 3657", Category: OIDCategoryContentEncryption},
+	"1.2.392.200011.61.1.1.1.3":  {Name: "Camellia-192-CBC", Reference: "RFC 3657", Category: OIDCategoryContentEncryption},
+	"1.2.392.200011.61.1.1.1.4":  {Name: "Camellia-256-CBC", Reference: "RFC 3657", Category: OIDCategoryContentEncryption},
+	"1.2.410.200004.1.4":         {Name: "SEED-CBC", Reference: "RFC 4010", Category: OIDCategoryContentEncryption},
+	"1.2.156.10197.1.104":        {Name: "SM4", Reference: "GB/T 32907-2016", Category: OIDCategoryContentEncryption},
+	"1.2.643.2.2.21":             {Name: "GOST 28147-89", Reference: "RFC 4357", Category: OIDCategoryContentEncryption},
+	"1.2.643.7.1.1.5.1.1":        {Name: "Magma CTR-ACPKM", Reference: "RFC 9189", Category: OIDCategoryContentEncryption},
+	"1.2.643.7.1.1.5.1.2":        {Name: "Magma CTR-ACPKM-OMAC", Reference: "RFC 9189", Category: OIDCategoryContentEncryption},
+	"1.2.643.7.1.1.5.2.1":        {Name: "Kuznyechik CTR-ACPKM", Reference: "RFC 9189", Category: OIDCategoryContentEncryption},
+	"1.2.643.7.1.1.5.2.2":        {Name: "Kuznyechik CTR-ACPKM-OMAC", Reference: "RFC 9189", Category: OIDCategoryContentEncryption},
+
+	// Compression of CompressedData, not a cryptographic algorithm
+	"1.2.840.113549.1.9.16.3.8": {Name: "zlib Compression", Reference: "RFC 3274", Category: OIDCategoryCompression},
+
+	// Password-based encryption and key derivation
+	"1.2.840.113549.1.5.1":    {Name: "PBE with MD2 and DES-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.4":    {Name: "PBE with MD2 and RC2-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.3":    {Name: "PBE with MD5 and DES-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.6":    {Name: "PBE with MD5 and RC2-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.10":   {Name: "PBE with SHA-1 and DES-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.11":   {Name: "PBE with SHA-1 and RC2-CBC", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.12":   {Name: "PBKDF2", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.5.13":   {Name: "PBES2", Reference: "RFC 8018", Category: OIDCategoryPasswordBased},
+	"1.3.6.1.4.1.11591.4.11":  {Name: "scrypt", Reference: "RFC 7914", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.1": {Name: "PBE with SHA-1 and 128 bit RC4", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.2": {Name: "PBE with SHA-1 and 40 bit RC4", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.3": {Name: "PBE with SHA-1 and 3-key Triple-DES-CBC", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.4": {Name: "PBE with SHA-1 and 2-key Triple-DES-CBC", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.5": {Name: "PBE with SHA-1 and 128 bit RC2-CBC", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+	"1.2.840.113549.1.12.1.6": {Name: "PBE with SHA-1 and 40 bit RC2-CBC", Reference: "RFC 7292", Category: OIDCategoryPasswordBased},
+
+	// Elliptic curves and algorithm parameter sets
+	"1.2.840.10045.3.1.1":   {Name: "NIST P-192", Reference: "RFC 5480", Category: OIDCategoryCurve},
+	"1.3.132.0.33":          {Name: "NIST P-224", Reference: "RFC 5480", Category: OIDCategoryCurve},
+	"1.2.840.10045.3.1.7":   {Name: "NIST P-256", Reference: "RFC 5480", Category: OIDCategoryCurve},
+	"1.3.132.0.34":          {Name: "NIST P-384", Reference: "RFC 5480", Category: OIDCategoryCurve},
+	"1.3.132.0.35":          {Name: "NIST P-521", Reference: "RFC 5480", Category: OIDCategoryCurve},
+	"1.3.132.0.10":          {Name: "secp256k1", Reference: "SEC 2", Category: OIDCategoryCurve},
+	"1.3.36.3.3.2.8.1.1.7":  {Name: "brainpoolP256r1", Reference: "RFC 5639", Category: OIDCategoryCurve},
+	"1.3.36.3.3.2.8.1.1.11": {Name: "brainpoolP384r1", Reference: "RFC 5639", Category: OIDCategoryCurve},
+	"1.3.36.3.3.2.8.1.1.13": {Name: "brainpoolP512r1", Reference: "RFC 5639", Category: OIDCategoryCurve},
+	"1.2.643.2.2.35.1":      {Name: "GOST R 34.10-2001 CryptoPro-A parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.2.2.35.2":      {Name: "GOST R 34.10-2001 CryptoPro-B parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.2.2.35.3":      {Name: "GOST R 34.10-2001 CryptoPro-C parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.2.2.36.0":      {Name: "GOST R 34.10-2001 CryptoPro-XchA parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.2.2.36.1":      {Name: "GOST R 34.10-2001 CryptoPro-XchB parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.7.1.2.1.1.1":   {Name: "GOST R 34.10-2012 (256 bit) parameter set A", Reference: "RFC 7836", Category: OIDCategoryCurve},
+	"1.2.643.7.1.2.1.2.1":   {Name: "GOST R 34.10-2012 (512 bit) parameter set A", Reference: "RFC 7836", Category: OIDCategoryCurve},
+	"1.2.643.7.1.2.1.2.2":   {Name: "GOST R 34.10-2012 (512 bit) parameter set B", Reference: "RFC 7836", Category: OIDCategoryCurve},
+	"1.2.643.7.1.2.1.2.3":   {Name: "GOST R 34.10-2012 (512 bit) parameter set C", Reference: "RFC 7836", Category: OIDCategoryCurve},
+	"1.2.643.2.2.30.1":      {Name: "GOST R 34.11-94 CryptoPro parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.2.2.31.1":      {Name: "GOST 28147-89 CryptoPro-A parameters", Reference: "RFC 4357", Category: OIDCategoryCurve},
+	"1.2.643.7.1.2.5.1.1":   {Name: "GOST 28147-89 TC26-Z parameters", Reference: "RFC 7836", Category: OIDCategoryCurve},
+
+	// PKCS#9 attributes
+	"1.2.840.113549.1.9.1":  {Name: "Email Address", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.2":  {Name: "Unstructured Name", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.3":  {Name: "Content Type", Reference: "RFC 5652", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.4":  {Name: "Message Digest", Reference: "RFC 5652", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.5":  {Name: "Signing Time", Reference: "RFC 5652", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.6":  {Name: "Countersignature", Reference: "RFC 5652", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.7":  {Name: "Challenge Password", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.8":  {Name: "Unstructured Address", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.14": {Name: "Extension Request", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.15": {Name: "S/MIME Capabilities", Reference: "RFC 8551", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.20": {Name: "Friendly Name", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.21": {Name: "Local Key ID", Reference: "RFC 2985", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.52": {Name: "CMS Algorithm Protection", Reference: "RFC 6211", Category: OIDCategoryAttribute},
+
+	// S/MIME and CAdES signed and unsigned attributes (id-aa)
+	"1.2.840.113549.1.9.16.2.1":  {Name: "Receipt Request", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.2":  {Name: "Security Label", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.3":  {Name: "Mail List Expansion History", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.4":  {Name: "Content Hint", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.5":  {Name: "Message Signature Digest", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.7":  {Name: "Content Identifier", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.9":  {Name: "Equivalent Labels", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.10": {Name: "Content Reference", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.11": {Name: "Encryption Key Preference", Reference: "RFC 8551", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.12": {Name: "Signing Certificate", Reference: "RFC 2634", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.14": {Name: "Time-Stamp Token", Reference: "RFC 3161", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.15": {Name: "Signature Policy Identifier", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.16": {Name: "Commitment Type Indication", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.17": {Name: "Signer Location", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.18": {Name: "Signer Attributes", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.19": {Name: "Other Signing Certificate", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.20": {Name: "Content Time-Stamp", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.21": {Name: "Complete Certificate References", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.22": {Name: "Complete Revocation References", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.23": {Name: "Certificate Values", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.24": {Name: "Revocation Values", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.25": {Name: "CAdES-C Time-Stamp", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.26": {Name: "Time-Stamped Certificates and CRLs", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.27": {Name: "Archive Time-Stamp", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.47": {Name: "Signing Certificate V2", Reference: "RFC 5035", Category: OIDCategoryAttribute},
+	"1.2.840.113549.1.9.16.2.48": {Name: "Archive Time-Stamp V2", Reference: "RFC 5126", Category: OIDCategoryAttribute},
+	"0.4.0.1733.2.4":             {Name: "Archive Time-Stamp V3", Reference: "ETSI EN 319 122-1", Category: OIDCategoryAttribute},
+	"0.4.0.1733.2.5":             {Name: "ATS Hash Index", Reference: "ETSI TS 101 733", Category: OIDCategoryAttribute},
+	"0.4.0.19122.1.5":            {Name: "ATS Hash Index V3", Reference: "ETSI EN 319 122-1", Category: OIDCategoryAttribute},
+
+	// Commitment types
+	"1.2.840.113549.1.9.16.6.1": {Name: "Proof of Origin", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+	"1.2.840.113549.1.9.16.6.2": {Name: "Proof of Receipt", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+	"1.2.840.113549.1.9.16.6.3": {Name: "Proof of Delivery", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+	"1.2.840.113549.1.9.16.6.4": {Name: "Proof of Sender", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+	"1.2.840.113549.1.9.16.6.5": {Name: "Proof of Approval", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+	"1.2.840.113549.1.9.16.6.6": {Name: "Proof of Creation", Reference: "RFC 5126", Category: OIDCategoryCommitmentType},
+
+	// Microsoft and Apple attributes
+	"1.3.6.1.4.1.311.2.1.11": {Name: "SPC Statement Type", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.2.1.12": {Name: "SPC SP Opus Info", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.2.1.15": {Name: "SPC PE Image Data", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.2.1.21": {Name: "Individual Code Signing", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.2.1.22": {Name: "Commercial Code Signing", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.2.4.1":  {Name: "Nested Signature", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.3.3.1":  {Name: "RFC 3161 Countersignature", Reference: "Microsoft Authenticode", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.12.2.1": {Name: "Catalog Name Value", Reference: "Microsoft", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.12.2.2": {Name: "Catalog Member Info", Reference: "Microsoft", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.17.1":   {Name: "Microsoft CSP Name", Reference: "Microsoft", Category: OIDCategoryAttribute},
+	"1.3.6.1.4.1.311.17.2":   {Name: "Microsoft Local Machine Keyset", Reference: "Microsoft", Category: OIDCategoryAttribute},
+	"1.2.840.113635.100.9.1": {Name: "Apple Code Directory Hashes", Reference: "Apple", Category: OIDCategoryAttribute},
+	"1.2.840.113635.100.9.2": {Name: "Apple Code Directory Hashes V2", Reference: "Apple", Category: OIDCategoryAttribute},
+
+	// Certificate extensions and qualified certificate statements
+	"2.5.29.9":                {Name: "Subject Directory Attributes", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.14":               {Name: "Subject Key Identifier", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.15":               {Name: "Key Usage", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.16":               {Name: "Private Key Usage Period", Reference: "RFC 3280", Category: OIDCategoryExtension},
+	"2.5.29.17":               {Name: "Subject Alternative Name", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.18":               {Name: "Issuer Alternative Name", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.19":               {Name: "Basic Constraints", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.20":               {Name: "CRL Number", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.21":               {Name: "CRL Reason Code", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.23":               {Name: "Hold Instruction Code", Reference: "RFC 3280", Category: OIDCategoryExtension},
+	"2.5.29.24":               {Name: "Invalidity Date", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.27":               {Name: "Delta CRL Indicator", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.28":               {Name: "Issuing Distribution Point", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.29":               {Name: "Certificate Issuer", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.30":               {Name: "Name Constraints", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.31":               {Name: "CRL Distribution Points", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.32":               {Name: "Certificate Policies", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.33":               {Name: "Policy Mappings", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.35":               {Name: "Authority Key Identifier", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.36":               {Name: "Policy Constraints", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.37":               {Name: "Extended Key Usage", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.46":               {Name: "Freshest CRL", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"2.5.29.54":               {Name: "Inhibit Any Policy", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.1.1":       {Name: "Authority Information Access", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.1.3":       {Name: "Qualified Certificate Statements", Reference: "RFC 3739", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.1":          {Name: "QC Compliance", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.2":          {Name: "QC Limit Value", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.3":          {Name: "QC Retention Period", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.4":          {Name: "QC Secure Signature Creation Device", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.5":          {Name: "QC PKI Disclosure Statements", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.6":          {Name: "QC Type", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.6.1":        {Name: "QC Type Electronic Signature", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.6.2":        {Name: "QC Type Electronic Seal", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"0.4.0.1862.1.6.3":        {Name: "QC Type Web Authentication", Reference: "ETSI EN 319 412-5", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.1.11":      {Name: "Subject Information Access", Reference: "RFC 5280", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.1.12":      {Name: "Logotype", Reference: "RFC 9399", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.1.24":      {Name: "TLS Feature", Reference: "RFC 7633", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.48.1.2":    {Name: "OCSP Nonce", Reference: "RFC 6960", Category: OIDCategoryExtension},
+	"1.3.6.1.5.5.7.48.1.5":    {Name: "OCSP No Check", Reference: "RFC 6960", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.11129.2.4.2": {Name: "Certificate Transparency SCT List", Reference: "RFC 6962", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.11129.2.4.3": {Name: "Certificate Transparency Precertificate Poison", Reference: "RFC 6962", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.311.20.2":    {Name: "Certificate Template Name", Reference: "Microsoft", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.311.21.1":    {Name: "CA Version", Reference: "Microsoft", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.311.21.2":    {Name: "Previous CA Certificate Hash", Reference: "Microsoft", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.311.21.7":    {Name: "Certificate Template", Reference: "Microsoft", Category: OIDCategoryExtension},
+	"1.3.6.1.4.1.311.21.10":   {Name: "Application Policies", Reference: "Microsoft", Category: OIDCategoryExtension},
+	"1.2.643.100.111":         {Name: "Subject Sign Tool", Reference: "FSB Order 795", Category: OIDCategoryExtension},
+	"1.2.643.100.112":         {Name: "Issuer Sign Tool", Reference: "FSB Order 795", Category: OIDCategoryExtension},
+
+	// Access methods
+	"1.3.6.1.5.5.7.48.1":   {Name: "OCSP", Reference: "RFC 6960", Category: OIDCategoryAccessMethod},
+	"1.3.6.1.5.5.7.48.1.1": {Name: "OCSP Basic Response", Reference: "RFC 6960", Category: OIDCategoryAccessMethod},
+	"1.3.6.1.5.5.7.48.2":   {Name: "CA Issuers", Reference: "RFC 5280", Category: OIDCategoryAccessMethod},
+	"1.3.6.1.5.5.7.48.3":   {Name: "Time Stamping", Reference: "RFC 5280", Category: OIDCategoryAccessMethod},
+	"1.3.6.1.5.5.7.48.5":   {Name: "CA Repository", Reference: "RFC 5280", Category: OIDCategoryAccessMethod},
+
+	// Extended key usages
+	"2.5.29.37.0":             {Name: "Any Extended Key Usage", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.1":       {Name: "TLS Web Server Authentication", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.2":       {Name: "TLS Web Client Authentication", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.3":       {Name: "Code Signing", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.4":       {Name: "Email Protection", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.5":       {Name: "IPsec End System", Reference: "RFC 2459", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.6":       {Name: "IPsec Tunnel", Reference: "RFC 2459", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.7":       {Name: "IPsec User", Reference: "RFC 2459", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.8":       {Name: "Time Stamping", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.9":       {Name: "OCSP Signing", Reference: "RFC 5280", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.17":      {Name: "IPsec IKE", Reference: "RFC 4945", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.5.5.7.3.36":      {Name: "Document Signing", Reference: "RFC 9336", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.1":  {Name: "Microsoft Trust List Signing", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.3":  {Name: "Microsoft Server Gated Crypto", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.4":  {Name: "Microsoft Encrypting File System", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.5":  {Name: "Windows Hardware Driver Verification", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.6":  {Name: "Windows System Component Verification", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.9":  {Name: "Microsoft Root List Signer", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.12": {Name: "Microsoft Document Signing", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.10.3.13": {Name: "Microsoft Lifetime Signing", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.3.6.1.4.1.311.20.2.2":  {Name: "Microsoft Smart Card Logon", Reference: "Microsoft", Category: OIDCategoryKeyPurpose},
+	"1.2.840.113635.100.4.1":  {Name: "Apple Code Signing", Reference: "Apple", Category: OIDCategoryKeyPurpose},
+
+	// Certificate policies
+	"2.5.29.32.0":               {Name: "Any Policy", Reference: "RFC 5280", Category: OIDCategoryPolicy},
+	"2.23.140.1.1":              {Name: "CA/Browser Forum Extended Validation", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"2.23.140.1.2.1":            {Name: "CA/Browser Forum Domain Validated", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"2.23.140.1.2.2":            {Name: "CA/Browser Forum Organization Validated", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"2.23.140.1.2.3":            {Name: "CA/Browser Forum Individual Validated", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"2.23.140.1.3":              {Name: "CA/Browser Forum Extended Validation Code Signing", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"2.23.140.1.4.1":            {Name: "CA/Browser Forum Code Signing", Reference: "CA/B Forum", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.1":            {Name: "ETSI Normalized Certificate Policy (NCP)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.2":            {Name: "ETSI Extended Normalized Certificate Policy (NCP+)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.3":            {Name: "ETSI Lightweight Certificate Policy (LCP)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.4":            {Name: "ETSI Extended Validation Certificate Policy (EVCP)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.6":            {Name: "ETSI Domain Validation Certificate Policy (DVCP)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.2042.1.7":            {Name: "ETSI Organizational Validation Certificate Policy (OVCP)", Reference: "ETSI EN 319 411-1", Category: OIDCategoryPolicy},
+	"0.4.0.194112.1.0":          {Name: "ETSI Qualified Certificate Policy for Natural Persons (QCP-n)", Reference: "ETSI EN 319 411-2", Category: OIDCategoryPolicy},
+	"0.4.0.194112.1.1":          {Name: "ETSI Qualified Certificate Policy for Legal Persons (QCP-l)", Reference: "ETSI EN 319 411-2", Category: OIDCategoryPolicy},
+	"0.4.0.194112.1.2":          {Name: "ETSI Qualified Certificate Policy for Natural Persons with QSCD (QCP-n-qscd)", Reference: "ETSI EN 319 411-2", Category: OIDCategoryPolicy},
+	"0.4.0.194112.1.3":          {Name: "ETSI Qualified Certificate Policy for Legal Persons with QSCD (QCP-l-qscd)", Reference: "ETSI EN 319 411-2", Category: OIDCategoryPolicy},
+	"0.4.0.194112.1.4":          {Name: "ETSI Qualified Certificate Policy for Web Authentication (QCP-w)", Reference: "ETSI EN 319 411-2", Category: OIDCategoryPolicy},
+	"0.4.0.1456.1.1":            {Name: "ETSI Qualified Certificate Policy Public with SSCD (QCP+)", Reference: "ETSI TS 101 456", Category: OIDCategoryPolicy},
+	"0.4.0.1456.1.2":            {Name: "ETSI Qualified Certificate Policy Public (QCP)", Reference: "ETSI TS 101 456", Category: OIDCategoryPolicy},
+	"1.2.643.100.113.1":         {Name: "Russian Signature Tool Class KC1", Reference: "FSB Order 795", Category: OIDCategoryPolicy},
+	"1.2.643.100.113.2":         {Name: "Russian Signature Tool Class KC2", Reference: "FSB Order 795", Category: OIDCategoryPolicy},
+	"1.2.643.100.113.3":         {Name: "Russian Signature Tool Class KC3", Reference: "FSB Order 795", Category: OIDCategoryPolicy},
+	"1.2.840.113635.100.6.1.2":  {Name: "Apple iPhone Developer", Reference: "Apple", Category: OIDCategoryPolicy},
+	"1.2.840.113635.100.6.1.4":  {Name: "Apple iPhone Distribution", Reference: "Apple", Category: OIDCategoryPolicy},
+	"1.2.840.113635.100.6.1.13": {Name: "Apple Developer ID Application", Reference: "Apple", Category: OIDCategoryPolicy},
+	"1.2.840.113635.100.6.1.16": {Name: "Apple Pass Type ID", Reference: "Apple", Category: OIDCategoryPolicy},
+	"1.2.840.113635.100.6.2.1":  {Name: "Apple Worldwide Developer Relations CA", Reference: "Apple", Category: OIDCategoryPolicy},
+
+	// Name attributes
+	"2.5.4.3":                    {Name: "Common Name", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.4":                    {Name: "Surname", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.5":                    {Name: "Serial Number", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.6":                    {Name: "Country", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.7":                    {Name: "Locality", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.8":                    {Name: "State or Province", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.9":                    {Name: "Street Address", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.10":                   {Name: "Organization", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.11":                   {Name: "Organizational Unit", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.12":                   {Name: "Title", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.13":                   {Name: "Description", Reference: "X.520", Category: OIDCategoryNameAttribute},
+	"2.5.4.15":                   {Name: "Business Category", Reference: "X.520", Category: OIDCategoryNameAttribute},
+	"2.5.4.17":                   {Name: "Postal Code", Reference: "X.520", Category: OIDCategoryNameAttribute},
+	"2.5.4.41":                   {Name: "Name", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.42":                   {Name: "Given Name", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.43":                   {Name: "Initials", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.44":                   {Name: "Generation Qualifier", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.45":                   {Name: "X.500 Unique Identifier", Reference: "X.520", Category: OIDCategoryNameAttribute},
+	"2.5.4.46":                   {Name: "DN Qualifier", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.65":                   {Name: "Pseudonym", Reference: "RFC 5280", Category: OIDCategoryNameAttribute},
+	"2.5.4.97":                   {Name: "Organization Identifier", Reference: "ETSI EN 319 412-1", Category: OIDCategoryNameAttribute},
+	"0.9.2342.19200300.100.1.1":  {Name: "User ID", Reference: "RFC 4519", Category: OIDCategoryNameAttribute},
+	"0.9.2342.19200300.100.1.25": {Name: "Domain Component", Reference: "RFC 4519", Category: OIDCategoryNameAttribute},
+	"1.3.6.1.4.1.311.60.2.1.1":   {Name: "Jurisdiction Locality", Reference: "CA/B Forum", Category: OIDCategoryNameAttribute},
+	"1.3.6.1.4.1.311.60.2.1.2":   {Name: "Jurisdiction State or Province", Reference: "CA/B Forum", Category: OIDCategoryNameAttribute},
+	"1.3.6.1.4.1.311.60.2.1.3":   {Name: "Jurisdiction Country", Reference: "CA/B Forum", Category: OIDCategoryNameAttribute},
+	"1.3.6.1.4.1.311.20.2.3":     {Name: "User Principal Name", Reference: "Microsoft", Category: OIDCategoryNameAttribute},
+	"1.2.643.3.131.1.1":          {Name: "INN", Reference: "FSB Order 795", Category: OIDCategoryNameAttribute},
+	"1.2.643.100.1":              {Name: "OGRN", Reference: "FSB Order 795", Category: OIDCategoryNameAttribute},
+	"1.2.643.100.3":              {Name: "SNILS", Reference: "FSB Order 795", Category: OIDCategoryNameAttribute},
+	"1.2.643.100.4":              {Name: "INN of Legal Entity", Reference: "FSB Order 795", Category: OIDCategoryNameAttribute},
+	"1.2.643.100.5":              {Name: "OGRNIP", Reference: "FSB Order 795", Category: OIDCategoryNameAttribute},
+}
+
+// LookupOID returns the description of an OID registered with RegisterOID or known to the detector
+func LookupOID(oid asn1.ObjectIdentifier) (OIDInfo, bool) {
+	dotted := oid.String()
+
+	if info, ok := lookupRegisteredOID(oid); ok {
+		return withCrossReferences(dotted, info), true
+	}
+
+	info, ok := oidDatabase[dotted]
+	if !ok {
+		return OIDInfo{}, false
+	}
+
+	return withCrossReferences(dotted, info), true
+}
+
+// privateEnterpriseArc is the IANA private enterprise numbers arc
+const privateEnterpriseArc = "1.3.6.1.4.1"
+
+// oidArcs maps well-known registration arcs to descriptions used for unregistered OIDs
+var oidArcs = map[string]string{
+	"1.2.840":                       "US national arc",
+	"1.2.840.10040":                 "under ANSI X9.57",
+	"1.2.840.10045":                 "under ANSI X9.62",
+	"1.2.840.10046":                 "under ANSI X9.42",
+	"1.2.840.113549":                "RSA Data Security arc",
+	"1.2.840.113549.1.1":            "under pkcs-1",
+	"1.2.840.113549.1.5":            "under pkcs-5",
+	"1.2.840.113549.1.7":            "under pkcs-7",
+	"1.2.840.113549.1.9":            "under pkcs-9",
+	"1.2.840.113549.1.9.16":         "under pkcs-9 smime",
+	"1.2.840.113549.1.9.16.1":       "under pkcs-9 smime ct",
+	"1.2.840.113549.1.9.16.2":       "under pkcs-9 smime aa",
+	"1.2.840.113549.1.9.16.3":       "under pkcs-9 smime alg",
+	"1.2.840.113549.1.9.16.6":       "under pkcs-9 smime cti",
+	"1.2.840.113549.1.12":           "under pkcs-12",
+	"1.2.840.113549.2":              "under RSA Data Security digest algorithms",
+	"1.2.840.113549.3":              "under RSA Data Security encryption algorithms",
+	"1.2.840.113635":                "Apple arc",
+	"1.2.156":                       "Chinese national arc",
+	"1.2.156.10197":                 "under OSCCA algorithms",
+	"1.2.392":                       "Japanese national arc",
+	"1.2.398":                       "Kazakhstan national arc",
+	"1.2.410":                       "Korean national arc",
+	"1.2.643":                       "Russian national arc",
+	"1.2.643.2.2":                   "under CryptoPro algorithms",
+	"1.2.643.7.1":                   "under TC 26 algorithms",
+	"1.2.643.100":                   "under Russian certificate attributes",
+	"1.3.14.3.2":                    "under OIW security algorithms",
+	"1.3.36":                        "TeleTrusT arc",
+	"1.3.101":                       "under Thawte algorithms",
+	"1.3.132":                       "Certicom arc",
+	privateEnterpriseArc:            "private enterprise arc",
+	privateEnterpriseArc + ".311":   "Microsoft arc",
+	privateEnterpriseArc + ".311.2": "under Microsoft Authenticode",
+	privateEnterpriseArc + ".11129": "Google arc",
+	"1.3.6.1.5.5.7":                 "under PKIX",
+	"1.3.6.1.5.5.7.1":               "under PKIX private extensions",
+	"1.3.6.1.5.5.7.3":               "under PKIX key purposes",
+	"1.3.6.1.5.5.7.48":              "under PKIX access descriptors",
+	"2.5.4":                         "under X.520 attribute types",
+	"2.5.29":                        "under X.509 certificate extensions",
+	"2.16.840.1.101.3.4":            "under NIST algorithms",
+	"2.16.840.1.101.3.4.1":          "under NIST AES algorithms",
+	"2.16.840.1.101.3.4.2":          "under NIST hash algorithms",
+	"2.16.840.1.101.3.4.3":          "under NIST signature algorithms",
+	"2.16.840.1.113730":             "Netscape arc",
+	"2.23.140":                      "CA/Browser Forum arc",
+	"0.4.0":                         "ETSI arc",
+	"0.4.0.1862":                    "under ETSI qualified certificate statements",
+	"0.4.0.194112":                  "under ETSI qualified certificate policies",
+}
+
+// ClassifyOIDArc describes the closest well-known registration arc containing the OID,
+// e.g. "under pkcs-9 smime ct", "Microsoft arc" or "private enterprise 1.3.6.1.4.1.X"
+func ClassifyOIDArc(oid asn1.ObjectIdentifier) (string, bool) {
+	for n := len(oid); n >= 2; n-- {
+		arc := oid[:n].String()
+
+		description, ok := oidArcs[arc]
+		if !ok {
+			continue
+		}
+
+		// Name the enterprise number of OIDs under unlisted private enterprises
+		if arc == privateEnterpriseArc && len(oid) > n {
+			return "private enterprise " + oid[:n+1].String(), true
+		}
+
+		return description, true
+	}
+
+	return "", false
+}
+
+// describeUnknownOID formats an unregistered OID with its arc classification
+func describeUnknownOID(prefix string, oid asn1.ObjectIdentifier) string {
+	if arc, ok := ClassifyOIDArc(oid); ok {
+		return fmt.Sprintf("%s: %s (%s)", prefix, oid.String(), arc)
+	}
+
+	return fmt.Sprintf("%s: %s", prefix, oid.String())
+}
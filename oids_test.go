@@ -0,0 +1,195 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestLookupOID tests lookup of registered OIDs
+func TestLookupOID(t *testing.T) {
+	tests := []struct {
+		name             string
+		oid              asn1.ObjectIdentifier
+		expectedOK       bool
+		expectedName     string
+		expectedCategory OIDCategory
+	}{
+		{
+			name:             "Content type",
+			oid:              PKCS7SignedDataOID,
+			expectedOK:       true,
+			expectedName:     "PKCS#7 Signed Data",
+			expectedCategory: OIDCategoryContentType,
+		},
+		{
+			name:             "S/MIME content type",
+			oid:              asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4},
+			expectedOK:       true,
+			expectedName:     "Time-Stamp Token Info",
+			expectedCategory: OIDCategoryContentType,
+		},
+		{
+			name:             "Signed attribute",
+			oid:              SigningTimeAttributeOID,
+			expectedOK:       true,
+			expectedName:     "Signing Time",
+			expectedCategory: OIDCategoryAttribute,
+		},
+		{
+			name:             "GOST digest",
+			oid:              GOSTR34112012256OID,
+			expectedOK:       true,
+			expectedName:     "GOST R 34.11-2012 (256 bit)",
+			expectedCategory: OIDCategoryDigest,
+		},
+		{
+			name:             "Microsoft extended key usage",
+			oid:              asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 13},
+			expectedOK:       true,
+			expectedName:     "Microsoft Lifetime Signing",
+			expectedCategory: OIDCategoryKeyPurpose,
+		},
+		{
+			name:             "ETSI QC statement",
+			oid:              asn1.ObjectIdentifier{0, 4, 0, 1862, 1, 4},
+			expectedOK:       true,
+			expectedName:     "QC Secure Signature Creation Device",
+			expectedCategory: OIDCategoryExtension,
+		},
+		{
+			name:             "Compression algorithm",
+			oid:              asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 3, 8},
+			expectedOK:       true,
+			expectedName:     "zlib Compression",
+			expectedCategory: OIDCategoryCompression,
+		},
+		{
+			name:       "Unregistered OID",
+			oid:        asn1.ObjectIdentifier{1, 2, 3, 4, 5},
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, ok := LookupOID(tt.oid)
+				if ok != tt.expectedOK {
+					t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				if info.Name != tt.expectedName {
+					t.Errorf("Expected name %s, got %s", tt.expectedName, info.Name)
+				}
+
+				if info.Category != tt.expectedCategory {
+					t.Errorf("Expected category %s, got %s", tt.expectedCategory, info.Category)
+				}
+			},
+		)
+	}
+}
+
+// TestOIDDatabase tests that every registered OID is valid and fully described
+func TestOIDDatabase(t *testing.T) {
+	for dotted, info := range oidDatabase {
+		var oid asn1.ObjectIdentifier
+
+		for _, arc := range strings.Split(dotted, ".") {
+			n, err := strconv.Atoi(arc)
+			if err != nil {
+				t.Fatalf("Invalid arc %q in OID %s", arc, dotted)
+			}
+
+			oid = append(oid, n)
+		}
+
+		if _, err := asn1.Marshal(oid); err != nil || oid.String() != dotted {
+			t.Errorf("Invalid OID %s", dotted)
+		}
+
+		if info.Name == "" || info.Reference == "" || info.Category == "" {
+			t.Errorf("Incomplete description of OID %s: %+v", dotted, info)
+		}
+	}
+}
+
+// TestGetAlgorithmNameIgnoresNonAlgorithms tests that registered non-algorithm OIDs are not reported as algorithms
+func TestGetAlgorithmNameIgnoresNonAlgorithms(t *testing.T) {
+	if name := GetAlgorithmName(SigningTimeAttributeOID); name != "Unknown algorithm: 1.2.840.113549.1.9.5 (under pkcs-9)" {
+		t.Errorf("Expected unknown algorithm, got %s", name)
+	}
+}
+
+// TestClassifyOIDArc tests classification of unregistered OIDs by their arc
+func TestClassifyOIDArc(t *testing.T) {
+	tests := []struct {
+		name        string
+		oid         asn1.ObjectIdentifier
+		expectedOK  bool
+		expectedArc string
+	}{
+		{
+			name:        "S/MIME content type",
+			oid:         asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 99},
+			expectedOK:  true,
+			expectedArc: "under pkcs-9 smime ct",
+		},
+		{
+			name:        "Microsoft",
+			oid:         asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 99, 1},
+			expectedOK:  true,
+			expectedArc: "Microsoft arc",
+		},
+		{
+			name:        "Kazakhstan",
+			oid:         asn1.ObjectIdentifier{1, 2, 398, 3, 10, 99},
+			expectedOK:  true,
+			expectedArc: "Kazakhstan national arc",
+		},
+		{
+			name:        "Unlisted private enterprise",
+			oid:         asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1, 2},
+			expectedOK:  true,
+			expectedArc: "private enterprise 1.3.6.1.4.1.99999",
+		},
+		{
+			name:        "Private enterprise arc",
+			oid:         asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1},
+			expectedOK:  true,
+			expectedArc: "private enterprise arc",
+		},
+		{
+			name:       "Unknown arc",
+			oid:        asn1.ObjectIdentifier{1, 2, 3, 4, 5},
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				arc, ok := ClassifyOIDArc(tt.oid)
+				if ok != tt.expectedOK {
+					t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				if arc != tt.expectedArc {
+					t.Errorf("Expected arc %q, got %q", tt.expectedArc, arc)
+				}
+			},
+		)
+	}
+}
+
+// TestGetOIDDescriptionArc tests that descriptions of unregistered OIDs mention their arc
+func TestGetOIDDescriptionArc(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 99}
+
+	expected := "Unknown OID: 1.2.643.7.1.1.99 (under TC 26 algorithms)"
+	if description := GetOIDDescription(oid); description != expected {
+		t.Errorf("Expected %s, got %s", expected, description)
+	}
+}
@@ -0,0 +1,452 @@
+package cmsdetector
+
+import "encoding/asn1"
+
+// openSSLNames maps dotted OIDs to OpenSSL short names (crypto/objects/objects.txt)
+var openSSLNames = map[string]string{
+	"1.2.840.113549.1.7.1":       "pkcs7-data",
+	"1.2.840.113549.1.7.2":       "pkcs7-signedData",
+	"1.2.840.113549.1.7.3":       "pkcs7-envelopedData",
+	"1.2.840.113549.1.7.4":       "pkcs7-signedAndEnvelopedData",
+	"1.2.840.113549.1.7.5":       "pkcs7-digestData",
+	"1.2.840.113549.1.7.6":       "pkcs7-encryptedData",
+	"1.2.840.113549.1.9.16.1.1":  "id-smime-ct-receipt",
+	"1.2.840.113549.1.9.16.1.2":  "id-smime-ct-authData",
+	"1.2.840.113549.1.9.16.1.4":  "id-smime-ct-TSTInfo",
+	"1.2.840.113549.1.9.16.1.6":  "id-smime-ct-contentInfo",
+	"1.2.840.113549.1.9.16.1.9":  "id-smime-ct-compressedData",
+	"1.2.840.113549.1.9.16.1.19": "id-smime-ct-contentCollection",
+	"1.2.840.113549.1.9.16.1.23": "id-smime-ct-authEnvelopedData",
+	"1.2.840.113549.1.9.16.1.24": "id-ct-routeOriginAuthz",
+	"1.2.840.113549.1.9.16.1.26": "id-ct-rpkiManifest",
+	"1.2.840.113549.1.9.16.1.35": "id-ct-rpkiGhostbusters",
+	"1.2.840.113549.1.12.10.1.1": "keyBag",
+	"1.2.840.113549.1.12.10.1.2": "pkcs8ShroudedKeyBag",
+	"1.2.840.113549.1.12.10.1.3": "certBag",
+	"1.2.840.113549.1.12.10.1.4": "crlBag",
+	"1.2.840.113549.1.12.10.1.5": "secretBag",
+	"1.2.840.113549.1.12.10.1.6": "safeContentsBag",
+	"1.2.840.113549.1.9.22.1":    "x509Certificate",
+	"1.2.840.113549.1.9.22.2":    "sdsiCertificate",
+	"1.2.840.113549.1.9.23.1":    "x509Crl",
+	"1.2.840.113549.2.2":         "MD2",
+	"1.2.840.113549.2.4":         "MD4",
+	"1.2.840.113549.2.5":         "MD5",
+	"1.3.14.3.2.26":              "SHA1",
+	"2.16.840.1.101.3.4.2.4":     "SHA224",
+	"2.16.840.1.101.3.4.2.1":     "SHA256",
+	"2.16.840.1.101.3.4.2.2":     "SHA384",
+	"2.16.840.1.101.3.4.2.3":     "SHA512",
+	"2.16.840.1.101.3.4.2.5":     "SHA512-224",
+	"2.16.840.1.101.3.4.2.6":     "SHA512-256",
+	"2.16.840.1.101.3.4.2.7":     "SHA3-224",
+	"2.16.840.1.101.3.4.2.8":     "SHA3-256",
+	"2.16.840.1.101.3.4.2.9":     "SHA3-384",
+	"2.16.840.1.101.3.4.2.10":    "SHA3-512",
+	"2.16.840.1.101.3.4.2.11":    "SHAKE128",
+	"2.16.840.1.101.3.4.2.12":    "SHAKE256",
+	"1.3.36.3.2.1":               "RIPEMD160",
+	"1.2.156.10197.1.401":        "SM3",
+	"1.2.643.2.2.9":              "md_gost94",
+	"1.2.643.7.1.1.2.2":          "md_gost12_256",
+	"1.2.643.7.1.1.2.3":          "md_gost12_512",
+	"1.2.840.113549.2.7":         "hmacWithSHA1",
+	"1.2.840.113549.2.8":         "hmacWithSHA224",
+	"1.2.840.113549.2.9":         "hmacWithSHA256",
+	"1.2.840.113549.2.10":        "hmacWithSHA384",
+	"1.2.840.113549.2.11":        "hmacWithSHA512",
+	"1.2.840.113549.2.12":        "hmacWithSHA512-224",
+	"1.2.840.113549.2.13":        "hmacWithSHA512-256",
+	"2.16.840.1.101.3.4.2.13":    "id-hmacWithSHA3-224",
+	"2.16.840.1.101.3.4.2.14":    "id-hmacWithSHA3-256",
+	"2.16.840.1.101.3.4.2.15":    "id-hmacWithSHA3-384",
+	"2.16.840.1.101.3.4.2.16":    "id-hmacWithSHA3-512",
+	"1.2.840.113549.1.5.14":      "PBMAC1",
+	"1.2.643.2.2.10":             "id-HMACGostR3411-94",
+	"1.2.643.7.1.1.4.1":          "id-tc26-hmac-gost-3411-2012-256",
+	"1.2.643.7.1.1.4.2":          "id-tc26-hmac-gost-3411-2012-512",
+	"1.2.840.113549.1.1.1":       "rsaEncryption",
+	"1.2.840.10040.4.1":          "DSA",
+	"1.2.840.10045.2.1":          "id-ecPublicKey",
+	"1.2.840.10046.2.1":          "dhpublicnumber",
+	"1.3.101.110":                "X25519",
+	"1.3.101.111":                "X448",
+	"1.2.156.10197.1.301":        "SM2",
+	"1.2.643.2.2.19":             "gost2001",
+	"1.2.643.2.2.20":             "gost94",
+	"1.2.643.7.1.1.1.1":          "gost2012_256",
+	"1.2.643.7.1.1.1.2":          "gost2012_512",
+	"2.16.840.1.101.3.4.4.1":     "id-alg-ml-kem-512",
+	"2.16.840.1.101.3.4.4.2":     "id-alg-ml-kem-768",
+	"2.16.840.1.101.3.4.4.3":     "id-alg-ml-kem-1024",
+	"1.2.840.113549.1.1.2":       "RSA-MD2",
+	"1.2.840.113549.1.1.3":       "RSA-MD4",
+	"1.2.840.113549.1.1.4":       "RSA-MD5",
+	"1.2.840.113549.1.1.5":       "RSA-SHA1",
+	"1.2.840.113549.1.1.10":      "RSASSA-PSS",
+	"1.2.840.113549.1.1.11":      "RSA-SHA256",
+	"1.2.840.113549.1.1.12":      "RSA-SHA384",
+	"1.2.840.113549.1.1.13":      "RSA-SHA512",
+	"1.2.840.113549.1.1.14":      "RSA-SHA224",
+	"1.2.840.113549.1.1.15":      "RSA-SHA512/224",
+	"1.2.840.113549.1.1.16":      "RSA-SHA512/256",
+	"1.2.840.10040.4.3":          "DSA-SHA1",
+	"2.16.840.1.101.3.4.3.1":     "dsa_with_SHA224",
+	"2.16.840.1.101.3.4.3.2":     "dsa_with_SHA256",
+	"2.16.840.1.101.3.4.3.3":     "id-dsa-with-sha384",
+	"2.16.840.1.101.3.4.3.4":     "id-dsa-with-sha512",
+	"1.2.840.10045.4.1":          "ecdsa-with-SHA1",
+	"1.2.840.10045.4.3.1":        "ecdsa-with-SHA224",
+	"1.2.840.10045.4.3.2":        "ecdsa-with-SHA256",
+	"1.2.840.10045.4.3.3":        "ecdsa-with-SHA384",
+	"1.2.840.10045.4.3.4":        "ecdsa-with-SHA512",
+	"2.16.840.1.101.3.4.3.9":     "id-ecdsa-with-sha3-224",
+	"2.16.840.1.101.3.4.3.10":    "id-ecdsa-with-sha3-256",
+	"2.16.840.1.101.3.4.3.11":    "id-ecdsa-with-sha3-384",
+	"2.16.840.1.101.3.4.3.12":    "id-ecdsa-with-sha3-512",
+	"2.16.840.1.101.3.4.3.13":    "id-rsassa-pkcs1-v1_5-with-sha3-224",
+	"2.16.840.1.101.3.4.3.14":    "id-rsassa-pkcs1-v1_5-with-sha3-256",
+	"2.16.840.1.101.3.4.3.15":    "id-rsassa-pkcs1-v1_5-with-sha3-384",
+	"2.16.840.1.101.3.4.3.16":    "id-rsassa-pkcs1-v1_5-with-sha3-512",
+	"1.3.101.112":                "ED25519",
+	"1.3.101.113":                "ED448",
+	"2.16.840.1.101.3.4.3.17":    "id-ml-dsa-44",
+	"2.16.840.1.101.3.4.3.18":    "id-ml-dsa-65",
+	"2.16.840.1.101.3.4.3.19":    "id-ml-dsa-87",
+	"2.16.840.1.101.3.4.3.20":    "id-slh-dsa-sha2-128s",
+	"2.16.840.1.101.3.4.3.21":    "id-slh-dsa-sha2-128f",
+	"2.16.840.1.101.3.4.3.22":    "id-slh-dsa-sha2-192s",
+	"2.16.840.1.101.3.4.3.23":    "id-slh-dsa-sha2-192f",
+	"2.16.840.1.101.3.4.3.24":    "id-slh-dsa-sha2-256s",
+	"2.16.840.1.101.3.4.3.25":    "id-slh-dsa-sha2-256f",
+	"2.16.840.1.101.3.4.3.26":    "id-slh-dsa-shake-128s",
+	"2.16.840.1.101.3.4.3.27":    "id-slh-dsa-shake-128f",
+	"2.16.840.1.101.3.4.3.28":    "id-slh-dsa-shake-192s",
+	"2.16.840.1.101.3.4.3.29":    "id-slh-dsa-shake-192f",
+	"2.16.840.1.101.3.4.3.30":    "id-slh-dsa-shake-256s",
+	"2.16.840.1.101.3.4.3.31":    "id-slh-dsa-shake-256f",
+	"1.2.156.10197.1.501":        "SM2-SM3",
+	"1.2.643.2.2.3":              "id-GostR3411-94-with-GostR3410-2001",
+	"1.2.643.2.2.4":              "id-GostR3411-94-with-GostR3410-94",
+	"1.2.643.7.1.1.3.2":          "id-tc26-signwithdigest-gost3410-2012-256",
+	"1.2.643.7.1.1.3.3":          "id-tc26-signwithdigest-gost3410-2012-512",
+	"1.2.840.113549.1.1.7":       "RSAES-OAEP",
+	"1.2.840.113549.1.1.8":       "MGF1",
+	"1.2.840.113549.1.1.9":       "PSPECIFIED",
+	"2.16.840.1.101.3.4.1.5":     "id-aes128-wrap",
+	"2.16.840.1.101.3.4.1.25":    "id-aes192-wrap",
+	"2.16.840.1.101.3.4.1.45":    "id-aes256-wrap",
+	"2.16.840.1.101.3.4.1.8":     "id-aes128-wrap-pad",
+	"2.16.840.1.101.3.4.1.28":    "id-aes192-wrap-pad",
+	"2.16.840.1.101.3.4.1.48":    "id-aes256-wrap-pad",
+	"1.2.840.113549.1.9.16.3.5":  "id-smime-alg-ESDH",
+	"1.2.840.113549.1.9.16.3.6":  "id-smime-alg-CMS3DESwrap",
+	"1.2.840.113549.1.9.16.3.7":  "id-smime-alg-CMSRC2wrap",
+	"1.2.840.113549.1.9.16.3.9":  "id-alg-PWRI-KEK",
+	"1.2.840.113549.1.9.16.3.8":  "ZLIB",
+	"1.3.132.1.11.0":             "dhSinglePass-stdDH-sha224kdf-scheme",
+	"1.3.132.1.11.1":             "dhSinglePass-stdDH-sha256kdf-scheme",
+	"1.3.132.1.11.2":             "dhSinglePass-stdDH-sha384kdf-scheme",
+	"1.3.132.1.11.3":             "dhSinglePass-stdDH-sha512kdf-scheme",
+	"1.3.132.1.14.0":             "dhSinglePass-cofactorDH-sha224kdf-scheme",
+	"1.3.132.1.14.1":             "dhSinglePass-cofactorDH-sha256kdf-scheme",
+	"1.3.132.1.14.2":             "dhSinglePass-cofactorDH-sha384kdf-scheme",
+	"1.3.132.1.14.3":             "dhSinglePass-cofactorDH-sha512kdf-scheme",
+	"1.3.133.16.840.63.0.2":      "dhSinglePass-stdDH-sha1kdf-scheme",
+	"1.3.133.16.840.63.0.3":      "dhSinglePass-cofactorDH-sha1kdf-scheme",
+	"1.2.643.7.1.1.6.1":          "id-tc26-agreement-gost-3410-2012-256",
+	"1.2.643.7.1.1.6.2":          "id-tc26-agreement-gost-3410-2012-512",
+	"1.2.643.7.1.1.7.1.1":        "id-tc26-wrap-gostr3412-2015-magma-kexp15",
+	"1.2.643.7.1.1.7.2.1":        "id-tc26-wrap-gostr3412-2015-kuznyechik-kexp15",
+	"1.3.14.3.2.7":               "DES-CBC",
+	"1.2.840.113549.3.7":         "DES-EDE3-CBC",
+	"1.2.840.113549.3.2":         "RC2-CBC",
+	"1.2.840.113549.3.4":         "RC4",
+	"1.2.840.113533.7.66.10":     "CAST5-CBC",
+	"2.16.840.1.101.3.4.1.1":     "AES-128-ECB",
+	"2.16.840.1.101.3.4.1.2":     "AES-128-CBC",
+	"2.16.840.1.101.3.4.1.3":     "AES-128-OFB",
+	"2.16.840.1.101.3.4.1.4":     "AES-128-CFB",
+	"2.16.840.1.101.3.4.1.6":     "id-aes128-GCM",
+	"2.16.840.1.101.3.4.1.7":     "id-aes128-CCM",
+	"2.16.840.1.101.3.4.1.21":    "AES-192-ECB",
+	"2.16.840.1.101.3.4.1.22":    "AES-192-CBC",
+	"2.16.840.1.101.3.4.1.23":    "AES-192-OFB",
+	"2.16.840.1.101.3.4.1.24":    "AES-192-CFB",
+	"2.16.840.1.101.3.4.1.26":    "id-aes192-GCM",
+	"2.16.840.1.101.3.4.1.27":    "id-aes192-CCM",
+	"2.16.840.1.101.3.4.1.41":    "AES-256-ECB",
+	"2.16.840.1.101.3.4.1.42":    "AES-256-CBC",
+	"2.16.840.1.101.3.4.1.43":    "AES-256-OFB",
+	"2.16.840.1.101.3.4.1.44":    "AES-256-CFB",
+	"2.16.840.1.101.3.4.1.46":    "id-aes256-GCM",
+	"2.16.840.1.101.3.4.1.47":    "id-aes256-CCM",
+	"1.2.392.200011.61.1.1.1.2":  "CAMELLIA-128-CBC",
+	"1.2.392.200011.61.1.1.1.3":  "CAMELLIA-192-CBC",
+	"1.2.392.200011.61.1.1.1.4":  "CAMELLIA-256-CBC",
+	"1.2.410.200004.1.4":         "SEED-CBC",
+	"1.2.643.2.2.21":             "gost89",
+	"1.2.643.7.1.1.5.1.1":        "magma-ctr-acpkm",
+	"1.2.643.7.1.1.5.1.2":        "magma-ctr-acpkm-omac",
+	"1.2.643.7.1.1.5.2.1":        "kuznyechik-ctr-acpkm",
+	"1.2.643.7.1.1.5.2.2":        "kuznyechik-ctr-acpkm-omac",
+	"1.2.840.113549.1.5.1":       "PBE-MD2-DES",
+	"1.2.840.113549.1.5.4":       "PBE-MD2-RC2-64",
+	"1.2.840.113549.1.5.3":       "PBE-MD5-DES",
+	"1.2.840.113549.1.5.6":       "PBE-MD5-RC2-64",
+	"1.2.840.113549.1.5.10":      "PBE-SHA1-DES",
+	"1.2.840.113549.1.5.11":      "PBE-SHA1-RC2-64",
+	"1.2.840.113549.1.5.12":      "PBKDF2",
+	"1.2.840.113549.1.5.13":      "PBES2",
+	"1.3.6.1.4.1.11591.4.11":     "id-scrypt",
+	"1.2.840.113549.1.12.1.1":    "PBE-SHA1-RC4-128",
+	"1.2.840.113549.1.12.1.2":    "PBE-SHA1-RC4-40",
+	"1.2.840.113549.1.12.1.3":    "PBE-SHA1-3DES",
+	"1.2.840.113549.1.12.1.4":    "PBE-SHA1-2DES",
+	"1.2.840.113549.1.12.1.5":    "PBE-SHA1-RC2-128",
+	"1.2.840.113549.1.12.1.6":    "PBE-SHA1-RC2-40",
+	"1.2.840.10045.3.1.1":        "prime192v1",
+	"1.3.132.0.33":               "secp224r1",
+	"1.2.840.10045.3.1.7":        "prime256v1",
+	"1.3.132.0.34":               "secp384r1",
+	"1.3.132.0.35":               "secp521r1",
+	"1.3.132.0.10":               "secp256k1",
+	"1.3.36.3.3.2.8.1.1.7":       "brainpoolP256r1",
+	"1.3.36.3.3.2.8.1.1.11":      "brainpoolP384r1",
+	"1.3.36.3.3.2.8.1.1.13":      "brainpoolP512r1",
+	"1.2.643.2.2.35.1":           "id-GostR3410-2001-CryptoPro-A-ParamSet",
+	"1.2.643.2.2.35.2":           "id-GostR3410-2001-CryptoPro-B-ParamSet",
+	"1.2.643.2.2.35.3":           "id-GostR3410-2001-CryptoPro-C-ParamSet",
+	"1.2.643.2.2.36.0":           "id-GostR3410-2001-CryptoPro-XchA-ParamSet",
+	"1.2.643.2.2.36.1":           "id-GostR3410-2001-CryptoPro-XchB-ParamSet",
+	"1.2.643.7.1.2.1.1.1":        "id-tc26-gost-3410-2012-256-paramSetA",
+	"1.2.643.7.1.2.1.2.1":        "id-tc26-gost-3410-2012-512-paramSetA",
+	"1.2.643.7.1.2.1.2.2":        "id-tc26-gost-3410-2012-512-paramSetB",
+	"1.2.643.7.1.2.1.2.3":        "id-tc26-gost-3410-2012-512-paramSetC",
+	"1.2.643.2.2.30.1":           "id-GostR3411-94-CryptoProParamSet",
+	"1.2.643.2.2.31.1":           "id-Gost28147-89-CryptoPro-A-ParamSet",
+	"1.2.643.7.1.2.5.1.1":        "id-tc26-gost-28147-param-Z",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+	"1.2.840.113549.1.9.2":       "unstructuredName",
+	"1.2.840.113549.1.9.3":       "contentType",
+	"1.2.840.113549.1.9.4":       "messageDigest",
+	"1.2.840.113549.1.9.5":       "signingTime",
+	"1.2.840.113549.1.9.6":       "countersignature",
+	"1.2.840.113549.1.9.7":       "challengePassword",
+	"1.2.840.113549.1.9.8":       "unstructuredAddress",
+	"1.2.840.113549.1.9.14":      "extensionReq",
+	"1.2.840.113549.1.9.15":      "SMIME-CAPS",
+	"1.2.840.113549.1.9.20":      "friendlyName",
+	"1.2.840.113549.1.9.21":      "localKeyID",
+	"1.2.840.113549.1.9.52":      "id-aa-CMSAlgorithmProtection",
+	"1.2.840.113549.1.9.16.2.1":  "id-smime-aa-receiptRequest",
+	"1.2.840.113549.1.9.16.2.2":  "id-smime-aa-securityLabel",
+	"1.2.840.113549.1.9.16.2.3":  "id-smime-aa-mlExpandHistory",
+	"1.2.840.113549.1.9.16.2.4":  "id-smime-aa-contentHint",
+	"1.2.840.113549.1.9.16.2.5":  "id-smime-aa-msgSigDigest",
+	"1.2.840.113549.1.9.16.2.7":  "id-smime-aa-contentIdentifier",
+	"1.2.840.113549.1.9.16.2.9":  "id-smime-aa-equivalentLabels",
+	"1.2.840.113549.1.9.16.2.10": "id-smime-aa-contentReference",
+	"1.2.840.113549.1.9.16.2.11": "id-smime-aa-encrypKeyPref",
+	"1.2.840.113549.1.9.16.2.12": "id-smime-aa-signingCertificate",
+	"1.2.840.113549.1.9.16.2.14": "id-smime-aa-timeStampToken",
+	"1.2.840.113549.1.9.16.2.15": "id-smime-aa-ets-sigPolicyId",
+	"1.2.840.113549.1.9.16.2.16": "id-smime-aa-ets-commitmentType",
+	"1.2.840.113549.1.9.16.2.17": "id-smime-aa-ets-signerLocation",
+	"1.2.840.113549.1.9.16.2.18": "id-smime-aa-ets-signerAttr",
+	"1.2.840.113549.1.9.16.2.19": "id-smime-aa-ets-otherSigCert",
+	"1.2.840.113549.1.9.16.2.20": "id-smime-aa-ets-contentTimestamp",
+	"1.2.840.113549.1.9.16.2.21": "id-smime-aa-ets-CertificateRefs",
+	"1.2.840.113549.1.9.16.2.22": "id-smime-aa-ets-RevocationRefs",
+	"1.2.840.113549.1.9.16.2.23": "id-smime-aa-ets-certValues",
+	"1.2.840.113549.1.9.16.2.24": "id-smime-aa-ets-revocationValues",
+	"1.2.840.113549.1.9.16.2.25": "id-smime-aa-ets-escTimeStamp",
+	"1.2.840.113549.1.9.16.2.26": "id-smime-aa-ets-certCRLTimestamp",
+	"1.2.840.113549.1.9.16.2.27": "id-smime-aa-ets-archiveTimeStamp",
+	"1.2.840.113549.1.9.16.2.47": "id-smime-aa-signingCertificateV2",
+	"1.2.840.113549.1.9.16.6.1":  "id-smime-cti-ets-proofOfOrigin",
+	"1.2.840.113549.1.9.16.6.2":  "id-smime-cti-ets-proofOfReceipt",
+	"1.2.840.113549.1.9.16.6.3":  "id-smime-cti-ets-proofOfDelivery",
+	"1.2.840.113549.1.9.16.6.4":  "id-smime-cti-ets-proofOfSender",
+	"1.2.840.113549.1.9.16.6.5":  "id-smime-cti-ets-proofOfApproval",
+	"1.2.840.113549.1.9.16.6.6":  "id-smime-cti-ets-proofOfCreation",
+	"1.3.6.1.4.1.311.2.1.21":     "msCodeInd",
+	"1.3.6.1.4.1.311.2.1.22":     "msCodeCom",
+	"1.3.6.1.4.1.311.17.1":       "CSPName",
+	"1.3.6.1.4.1.311.17.2":       "LocalKeySet",
+	"2.5.29.9":                   "subjectDirectoryAttributes",
+	"2.5.29.14":                  "subjectKeyIdentifier",
+	"2.5.29.15":                  "keyUsage",
+	"2.5.29.16":                  "privateKeyUsagePeriod",
+	"2.5.29.17":                  "subjectAltName",
+	"2.5.29.18":                  "issuerAltName",
+	"2.5.29.19":                  "basicConstraints",
+	"2.5.29.20":                  "crlNumber",
+	"2.5.29.21":                  "CRLReason",
+	"2.5.29.23":                  "holdInstructionCode",
+	"2.5.29.24":                  "invalidityDate",
+	"2.5.29.27":                  "deltaCRL",
+	"2.5.29.28":                  "issuingDistributionPoint",
+	"2.5.29.29":                  "certificateIssuer",
+	"2.5.29.30":                  "nameConstraints",
+	"2.5.29.31":                  "crlDistributionPoints",
+	"2.5.29.32":                  "certificatePolicies",
+	"2.5.29.33":                  "policyMappings",
+	"2.5.29.35":                  "authorityKeyIdentifier",
+	"2.5.29.36":                  "policyConstraints",
+	"2.5.29.37":                  "extendedKeyUsage",
+	"2.5.29.46":                  "freshestCRL",
+	"2.5.29.54":                  "inhibitAnyPolicy",
+	"1.3.6.1.5.5.7.1.1":          "authorityInfoAccess",
+	"1.3.6.1.5.5.7.1.3":          "qcStatements",
+	"1.3.6.1.5.5.7.1.11":         "subjectInfoAccess",
+	"1.3.6.1.5.5.7.1.24":         "tlsfeature",
+	"1.3.6.1.5.5.7.48.1.2":       "Nonce",
+	"1.3.6.1.5.5.7.48.1.5":       "noCheck",
+	"1.3.6.1.4.1.11129.2.4.2":    "ct_precert_scts",
+	"1.3.6.1.4.1.11129.2.4.3":    "ct_precert_poison",
+	"1.2.643.100.111":            "subjectSignTool",
+	"1.2.643.100.112":            "issuerSignTool",
+	"1.3.6.1.5.5.7.48.1":         "OCSP",
+	"1.3.6.1.5.5.7.48.1.1":       "basicOCSPResponse",
+	"1.3.6.1.5.5.7.48.2":         "caIssuers",
+	"1.3.6.1.5.5.7.48.3":         "ad_timestamping",
+	"1.3.6.1.5.5.7.48.5":         "caRepository",
+	"2.5.29.37.0":                "anyExtendedKeyUsage",
+	"1.3.6.1.5.5.7.3.1":          "serverAuth",
+	"1.3.6.1.5.5.7.3.2":          "clientAuth",
+	"1.3.6.1.5.5.7.3.3":          "codeSigning",
+	"1.3.6.1.5.5.7.3.4":          "emailProtection",
+	"1.3.6.1.5.5.7.3.5":          "ipsecEndSystem",
+	"1.3.6.1.5.5.7.3.6":          "ipsecTunnel",
+	"1.3.6.1.5.5.7.3.7":          "ipsecUser",
+	"1.3.6.1.5.5.7.3.8":          "timeStamping",
+	"1.3.6.1.5.5.7.3.9":          "OCSPSigning",
+	"1.3.6.1.5.5.7.3.17":         "ipsecIKE",
+	"1.3.6.1.4.1.311.10.3.1":     "msCTLSign",
+	"1.3.6.1.4.1.311.10.3.3":     "msSGC",
+	"1.3.6.1.4.1.311.10.3.4":     "msEFS",
+	"1.3.6.1.4.1.311.20.2.2":     "msSmartcardLogin",
+	"2.5.29.32.0":                "anyPolicy",
+	"1.2.643.100.113.1":          "classSignToolKC1",
+	"1.2.643.100.113.2":          "classSignToolKC2",
+	"1.2.643.100.113.3":          "classSignToolKC3",
+	"2.5.4.3":                    "CN",
+	"2.5.4.4":                    "SN",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "street",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.12":                   "title",
+	"2.5.4.13":                   "description",
+	"2.5.4.15":                   "businessCategory",
+	"2.5.4.17":                   "postalCode",
+	"2.5.4.41":                   "name",
+	"2.5.4.42":                   "GN",
+	"2.5.4.43":                   "initials",
+	"2.5.4.44":                   "generationQualifier",
+	"2.5.4.45":                   "x500UniqueIdentifier",
+	"2.5.4.46":                   "dnQualifier",
+	"2.5.4.65":                   "pseudonym",
+	"2.5.4.97":                   "organizationIdentifier",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+	"1.3.6.1.4.1.311.60.2.1.1":   "jurisdictionL",
+	"1.3.6.1.4.1.311.60.2.1.2":   "jurisdictionST",
+	"1.3.6.1.4.1.311.60.2.1.3":   "jurisdictionC",
+	"1.3.6.1.4.1.311.20.2.3":     "msUPN",
+	"1.2.643.3.131.1.1":          "INN",
+	"1.2.643.100.1":              "OGRN",
+	"1.2.643.100.3":              "SNILS",
+	"1.2.643.100.4":              "INNLE",
+	"1.2.643.100.5":              "OGRNIP"}
+
+// ianaSMIMENames maps dotted OIDs to names in the IANA "SMI Security for S/MIME" registries
+var ianaSMIMENames = map[string]string{
+	"1.2.840.113549.1.9.16.1.1":  "id-ct-receipt",
+	"1.2.840.113549.1.9.16.1.2":  "id-ct-authData",
+	"1.2.840.113549.1.9.16.1.4":  "id-ct-TSTInfo",
+	"1.2.840.113549.1.9.16.1.6":  "id-ct-contentInfo",
+	"1.2.840.113549.1.9.16.1.9":  "id-ct-compressedData",
+	"1.2.840.113549.1.9.16.1.16": "id-ct-firmwarePackage",
+	"1.2.840.113549.1.9.16.1.17": "id-ct-firmwareLoadReceipt",
+	"1.2.840.113549.1.9.16.1.18": "id-ct-firmwareLoadError",
+	"1.2.840.113549.1.9.16.1.19": "id-ct-contentCollection",
+	"1.2.840.113549.1.9.16.1.20": "id-ct-contentWithAttrs",
+	"1.2.840.113549.1.9.16.1.23": "id-ct-authEnvelopedData",
+	"1.2.840.113549.1.9.16.1.24": "id-ct-routeOriginAuthz",
+	"1.2.840.113549.1.9.16.1.26": "id-ct-rpkiManifest",
+	"1.2.840.113549.1.9.16.1.31": "id-ct-timestampedData",
+	"1.2.840.113549.1.9.16.1.35": "id-ct-rpkiGhostbusters",
+	"1.2.840.113549.1.9.16.2.1":  "id-aa-receiptRequest",
+	"1.2.840.113549.1.9.16.2.2":  "id-aa-securityLabel",
+	"1.2.840.113549.1.9.16.2.3":  "id-aa-mlExpandHistory",
+	"1.2.840.113549.1.9.16.2.4":  "id-aa-contentHint",
+	"1.2.840.113549.1.9.16.2.5":  "id-aa-msgSigDigest",
+	"1.2.840.113549.1.9.16.2.7":  "id-aa-contentIdentifier",
+	"1.2.840.113549.1.9.16.2.9":  "id-aa-equivalentLabels",
+	"1.2.840.113549.1.9.16.2.10": "id-aa-contentReference",
+	"1.2.840.113549.1.9.16.2.11": "id-aa-encrypKeyPref",
+	"1.2.840.113549.1.9.16.2.12": "id-aa-signingCertificate",
+	"1.2.840.113549.1.9.16.2.14": "id-aa-timeStampToken",
+	"1.2.840.113549.1.9.16.2.15": "id-aa-ets-sigPolicyId",
+	"1.2.840.113549.1.9.16.2.16": "id-aa-ets-commitmentType",
+	"1.2.840.113549.1.9.16.2.17": "id-aa-ets-signerLocation",
+	"1.2.840.113549.1.9.16.2.18": "id-aa-ets-signerAttr",
+	"1.2.840.113549.1.9.16.2.19": "id-aa-ets-otherSigCert",
+	"1.2.840.113549.1.9.16.2.20": "id-aa-ets-contentTimestamp",
+	"1.2.840.113549.1.9.16.2.21": "id-aa-ets-CertificateRefs",
+	"1.2.840.113549.1.9.16.2.22": "id-aa-ets-RevocationRefs",
+	"1.2.840.113549.1.9.16.2.23": "id-aa-ets-certValues",
+	"1.2.840.113549.1.9.16.2.24": "id-aa-ets-revocationValues",
+	"1.2.840.113549.1.9.16.2.25": "id-aa-ets-escTimeStamp",
+	"1.2.840.113549.1.9.16.2.26": "id-aa-ets-certCRLTimestamp",
+	"1.2.840.113549.1.9.16.2.27": "id-aa-ets-archiveTimestamp",
+	"1.2.840.113549.1.9.16.2.47": "id-aa-signingCertificateV2",
+	"1.2.840.113549.1.9.16.2.48": "id-aa-ets-archiveTimestampV2",
+	"1.2.840.113549.1.9.16.3.5":  "id-alg-ESDH",
+	"1.2.840.113549.1.9.16.3.6":  "id-alg-CMS3DESwrap",
+	"1.2.840.113549.1.9.16.3.7":  "id-alg-CMSRC2wrap",
+	"1.2.840.113549.1.9.16.3.8":  "id-alg-zlibCompress",
+	"1.2.840.113549.1.9.16.3.9":  "id-alg-PWRI-KEK",
+	"1.2.840.113549.1.9.16.3.18": "id-alg-AEADChaCha20Poly1305",
+	"1.2.840.113549.1.9.16.3.28": "id-alg-hkdf-with-sha256",
+	"1.2.840.113549.1.9.16.3.29": "id-alg-hkdf-with-sha384",
+	"1.2.840.113549.1.9.16.3.30": "id-alg-hkdf-with-sha512",
+	"1.2.840.113549.1.9.16.6.1":  "id-cti-ets-proofOfOrigin",
+	"1.2.840.113549.1.9.16.6.2":  "id-cti-ets-proofOfReceipt",
+	"1.2.840.113549.1.9.16.6.3":  "id-cti-ets-proofOfDelivery",
+	"1.2.840.113549.1.9.16.6.4":  "id-cti-ets-proofOfSender",
+	"1.2.840.113549.1.9.16.6.5":  "id-cti-ets-proofOfApproval",
+	"1.2.840.113549.1.9.16.6.6":  "id-cti-ets-proofOfCreation"}
+
+// withCrossReferences fills in the OpenSSL and IANA names of the OID unless already set
+func withCrossReferences(oid string, info OIDInfo) OIDInfo {
+	if info.OpenSSLName == "" {
+		info.OpenSSLName = openSSLNames[oid]
+	}
+
+	if info.IANAName == "" {
+		info.IANAName = ianaSMIMENames[oid]
+	}
+
+	return info
+}
+
+// LookupOpenSSLName returns the OID with the given OpenSSL short name, e.g. "pkcs7-signedData"
+func LookupOpenSSLName(name string) (asn1.ObjectIdentifier, bool) {
+	for dotted, openSSLName := range openSSLNames {
+		if openSSLName != name {
+			continue
+		}
+
+		oid, err := parseDottedOID(dotted)
+
+		return oid, err == nil
+	}
+
+	return nil, false
+}
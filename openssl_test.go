@@ -0,0 +1,79 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// TestOIDCrossReferences tests the OpenSSL and IANA names reported by LookupOID
+func TestOIDCrossReferences(t *testing.T) {
+	tests := []struct {
+		name                string
+		oid                 asn1.ObjectIdentifier
+		expectedOpenSSLName string
+		expectedIANAName    string
+	}{
+		{
+			name:                "PKCS#7 content type",
+			oid:                 PKCS7SignedDataOID,
+			expectedOpenSSLName: "pkcs7-signedData",
+		},
+		{
+			name:                "S/MIME content type",
+			oid:                 asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 2},
+			expectedOpenSSLName: "id-smime-ct-authData",
+			expectedIANAName:    "id-ct-authData",
+		},
+		{
+			name:             "IANA only",
+			oid:              asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 16},
+			expectedIANAName: "id-ct-firmwarePackage",
+		},
+		{
+			name:                "GOST digest",
+			oid:                 GOSTR34112012256OID,
+			expectedOpenSSLName: "md_gost12_256",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				info, ok := LookupOID(tt.oid)
+				if !ok {
+					t.Fatalf("Expected OID %s to be known", tt.oid)
+				}
+
+				if info.OpenSSLName != tt.expectedOpenSSLName {
+					t.Errorf("Expected OpenSSL name %q, got %q", tt.expectedOpenSSLName, info.OpenSSLName)
+				}
+
+				if info.IANAName != tt.expectedIANAName {
+					t.Errorf("Expected IANA name %q, got %q", tt.expectedIANAName, info.IANAName)
+				}
+			},
+		)
+	}
+}
+
+// TestLookupOpenSSLName tests resolution of OpenSSL short names
+func TestLookupOpenSSLName(t *testing.T) {
+	if oid, ok := LookupOpenSSLName("pkcs7-signedData"); !ok || !oid.Equal(PKCS7SignedDataOID) {
+		t.Errorf("Expected %s, got %s (%v)", PKCS7SignedDataOID, oid, ok)
+	}
+
+	if _, ok := LookupOpenSSLName("no-such-name"); ok {
+		t.Error("Expected unknown OpenSSL name not to be resolved")
+	}
+}
+
+// TestCrossReferencesKnown tests that every cross-referenced OID is in the description table
+func TestCrossReferencesKnown(t *testing.T) {
+	for _, names := range []map[string]string{openSSLNames, ianaSMIMENames} {
+		for oid := range names {
+			if _, ok := oidDatabase[oid]; !ok {
+				t.Errorf("Cross-referenced OID %s is missing from the description table", oid)
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/rc4"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"unicode/utf16"
+)
+
+var (
+	// errUnsupportedPBE is returned for password-based encryption schemes that cannot be decrypted
+	errUnsupportedPBE = errors.New("unsupported password-based encryption scheme")
+
+	// errDecryptionFailed is returned when the ciphertext has invalid padding, usually for a wrong password
+	errDecryptionFailed = errors.New("decryption failed")
+)
+
+// pbeCipher describes the cipher of a password-based encryption scheme
+type pbeCipher struct {
+	keySize   int
+	newCipher func(key []byte) (cipher.Block, error) // nil for the RC4 stream cipher
+}
+
+// pkcs12PBECiphers maps PKCS#12 PBE schemes to their ciphers (RFC 7292, appendix C)
+var pkcs12PBECiphers = map[string]pbeCipher{
+	"1.2.840.113549.1.12.1.1": {keySize: 16},
+	"1.2.840.113549.1.12.1.2": {keySize: 5},
+	"1.2.840.113549.1.12.1.3": {keySize: 24, newCipher: des.NewTripleDESCipher},
+	"1.2.840.113549.1.12.1.4": {keySize: 16, newCipher: newTwoKeyTripleDESCipher},
+	"1.2.840.113549.1.12.1.5": {keySize: 16, newCipher: func(key []byte) (cipher.Block, error) { return newRC2Cipher(key, 128) }},
+	"1.2.840.113549.1.12.1.6": {keySize: 5, newCipher: func(key []byte) (cipher.Block, error) { return newRC2Cipher(key, 40) }},
+}
+
+// pbes2Ciphers maps the encryption schemes of PBES2 to their CBC mode ciphers
+var pbes2Ciphers = map[string]pbeCipher{
+	"2.16.840.1.101.3.4.1.2":  {keySize: 16, newCipher: aes.NewCipher},          // AES-128-CBC
+	"2.16.840.1.101.3.4.1.22": {keySize: 24, newCipher: aes.NewCipher},          // AES-192-CBC
+	"2.16.840.1.101.3.4.1.42": {keySize: 32, newCipher: aes.NewCipher},          // AES-256-CBC
+	"1.2.840.113549.3.7":      {keySize: 24, newCipher: des.NewTripleDESCipher}, // DES-EDE3-CBC
+}
+
+// pbkdf2Hashes maps the pseudorandom functions of PBKDF2 to their hash functions
+var pbkdf2Hashes = map[string]func() hash.Hash{
+	"1.2.840.113549.2.7":  sha1.New,
+	"1.2.840.113549.2.8":  sha256.New224,
+	"1.2.840.113549.2.9":  sha256.New,
+	"1.2.840.113549.2.10": sha512.New384,
+	"1.2.840.113549.2.11": sha512.New,
+}
+
+// macHashes maps the digest algorithms of the PKCS#12 integrity MAC to their hash functions
+var macHashes = map[string]func() hash.Hash{
+	"1.3.14.3.2.26":          sha1.New,
+	"2.16.840.1.101.3.4.2.4": sha256.New224,
+	"2.16.840.1.101.3.4.2.1": sha256.New,
+	"2.16.840.1.101.3.4.2.2": sha512.New384,
+	"2.16.840.1.101.3.4.2.3": sha512.New,
+}
+
+// PKCS#12 key derivation purposes (RFC 7292, appendix B.3)
+const (
+	pkcs12KeyID = 1
+	pkcs12IVID  = 2
+	pkcs12MACID = 3
+)
+
+// pbePasswords returns the encodings of the password tried for the scheme: UTF-8 for PBES2, and
+// a BMPString with the terminating zero for PKCS#12 schemes
+func pbePasswords(alg asn1.ObjectIdentifier, password string) [][]byte {
+	if hasOIDPrefix(alg, pkcs12PBEArc) {
+		return bmpPasswords(password)
+	}
+
+	return [][]byte{[]byte(password)}
+}
+
+// bmpPasswords returns the BMPString encodings of the password. Implementations encode the
+// empty password with or without the terminating zero, so both are returned
+func bmpPasswords(password string) [][]byte {
+	if password == "" {
+		return [][]byte{{0, 0}, {}}
+	}
+
+	encoded := make([]byte, 0, 2*len(password)+2)
+	for _, unit := range utf16.Encode([]rune(password)) {
+		encoded = append(encoded, byte(unit>>8), byte(unit))
+	}
+
+	return [][]byte{append(encoded, 0, 0)}
+}
+
+// decryptWithPassword decrypts with each encoding of the password until the plaintext is accepted
+func decryptWithPassword(alg pkix.AlgorithmIdentifier, password string, ciphertext []byte, accept func([]byte) bool) ([]byte, error) {
+	for _, encoded := range pbePasswords(alg.Algorithm, password) {
+		plaintext, err := decryptPBE(alg, encoded, ciphertext)
+		if errors.Is(err, errUnsupportedPBE) {
+			return nil, err
+		}
+
+		// A wrong password passes the padding check once in 256 attempts, but is rejected by accept
+		if err == nil && accept(plaintext) {
+			return plaintext, nil
+		}
+	}
+
+	return nil, errDecryptionFailed
+}
+
+// decryptPBE decrypts content encrypted with a PKCS#12 PBE scheme or PBES2 with PBKDF2. The
+// password must be BMPString encoded for PKCS#12 schemes and UTF-8 encoded for PBES2
+func decryptPBE(alg pkix.AlgorithmIdentifier, password, ciphertext []byte) ([]byte, error) {
+	if alg.Algorithm.Equal(pbes2OID) {
+		return decryptPBES2(alg, password, ciphertext)
+	}
+
+	scheme, ok := pkcs12PBECiphers[alg.Algorithm.String()]
+	if !ok {
+		return nil, errUnsupportedPBE
+	}
+
+	var params pbeParams
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBE parameters: %w", err)
+	}
+
+	if err := checkPBEIterations(params.Iterations); err != nil {
+		return nil, err
+	}
+
+	key := pkcs12KDF(sha1.New, password, params.Salt, pkcs12KeyID, params.Iterations, scheme.keySize)
+
+	if scheme.newCipher == nil {
+		stream, err := rc4.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+
+		plaintext := make([]byte, len(ciphertext))
+		stream.XORKeyStream(plaintext, ciphertext)
+
+		return plaintext, nil
+	}
+
+	block, err := scheme.newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := pkcs12KDF(sha1.New, password, params.Salt, pkcs12IVID, params.Iterations, block.BlockSize())
+
+	return decryptCBC(block, iv, ciphertext)
+}
+
+// decryptPBES2 decrypts content encrypted with PBES2 using PBKDF2 and a CBC mode cipher
+func decryptPBES2(alg pkix.AlgorithmIdentifier, password, ciphertext []byte) ([]byte, error) {
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %w", err)
+	}
+
+	scheme, ok := pbes2Ciphers[params.EncryptionScheme.Algorithm.String()]
+	if !ok || !params.KeyDerivationFunc.Algorithm.Equal(pbkdf2OID) {
+		return nil, errUnsupportedPBE
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %w", err)
+	}
+
+	prf := hmacWithSHA1OID
+	if len(kdf.PRF.Algorithm) > 0 {
+		prf = kdf.PRF.Algorithm
+	}
+
+	newHash, ok := pbkdf2Hashes[prf.String()]
+	if !ok || kdf.Salt.Class != asn1.ClassUniversal || kdf.Salt.Tag != asn1.TagOctetString {
+		return nil, errUnsupportedPBE
+	}
+
+	if err := checkPBEIterations(kdf.IterationCount); err != nil {
+		return nil, err
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse cipher parameters: %w", err)
+	}
+
+	block, err := scheme.newCipher(pbkdf2(newHash, password, kdf.Salt.Bytes, kdf.IterationCount, scheme.keySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptCBC(block, iv, ciphertext)
+}
+
+// checkPBEIterations rejects iteration counts above Limits.MaxPBEIterations
+func checkPBEIterations(iterations int) error {
+	if limit := CurrentLimits().MaxPBEIterations; iterations <= 0 || (limit > 0 && iterations > limit) {
+		return fmt.Errorf("%w: %d iterations", errUnsupportedPBE, iterations)
+	}
+
+	return nil
+}
+
+// decryptCBC decrypts CBC mode ciphertext and removes the PKCS#7 padding
+func decryptCBC(block cipher.Block, iv, ciphertext []byte) ([]byte, error) {
+	size := block.BlockSize()
+	if len(iv) != size || len(ciphertext) == 0 || len(ciphertext)%size != 0 {
+		return nil, errDecryptionFailed
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > size || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errDecryptionFailed
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// newTwoKeyTripleDESCipher creates a Triple DES cipher with a 16 byte key, reusing the first key
+func newTwoKeyTripleDESCipher(key []byte) (cipher.Block, error) {
+	return des.NewTripleDESCipher(append(append([]byte{}, key...), key[:8]...))
+}
+
+// pkcs12KDF derives key material from a BMPString encoded password (RFC 7292, appendix B.2)
+func pkcs12KDF(newHash func() hash.Hash, password, salt []byte, id byte, iterations, size int) []byte {
+	h := newHash()
+	u, v := h.Size(), h.BlockSize()
+
+	// I is the concatenation of the salt and the password, each repeated to a multiple of v bytes
+	fill := func(data []byte) []byte {
+		filled := make([]byte, v*((len(data)+v-1)/v))
+		for i := range filled {
+			filled[i] = data[i%len(data)]
+		}
+
+		return filled
+	}
+
+	var i []byte
+	if len(salt) > 0 {
+		i = append(i, fill(salt)...)
+	}
+
+	if len(password) > 0 {
+		i = append(i, fill(password)...)
+	}
+
+	d := bytes.Repeat([]byte{id}, v)
+	key := make([]byte, 0, size+u)
+
+	for len(key) < size {
+		h.Reset()
+		h.Write(d)
+		h.Write(i)
+		a := h.Sum(nil)
+
+		for r := 1; r < iterations; r++ {
+			h.Reset()
+			h.Write(a)
+			a = h.Sum(a[:0])
+		}
+
+		key = append(key, a...)
+
+		// Ij = (Ij + B + 1) mod 2^(v*8), where B is A repeated to v bytes
+		for j := 0; j < len(i); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				carry += int(i[j+k]) + int(a[k%u])
+				i[j+k] = byte(carry)
+				carry >>= 8
+			}
+		}
+	}
+
+	return key[:size]
+}
+
+// pbkdf2 derives a key from a password with PBKDF2 (RFC 8018, section 5.2)
+func pbkdf2(newHash func() hash.Hash, password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(newHash, password)
+	key := make([]byte, 0, size+prf.Size())
+
+	for block := uint32(1); len(key) < size; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := prf.Sum(nil)
+
+		t := append([]byte{}, u...)
+		for r := 1; r < iterations; r++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+
+		key = append(key, t...)
+	}
+
+	return key[:size]
+}
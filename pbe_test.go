@@ -0,0 +1,217 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestPKCS12KDF tests the PKCS#12 key derivation with the vectors of OpenSSL and BouncyCastle
+func TestPKCS12KDF(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		salt       string
+		id         byte
+		iterations int
+		expected   string
+	}{
+		{
+			name:       "Key",
+			password:   "smeg",
+			salt:       "0a58cf64530d823f",
+			id:         pkcs12KeyID,
+			iterations: 1,
+			expected:   "8aaae6297b6cb04642ab5b077851284eb7128f1a2a7fbca3",
+		},
+		{
+			name:       "IV",
+			password:   "smeg",
+			salt:       "0a58cf64530d823f",
+			id:         pkcs12IVID,
+			iterations: 1,
+			expected:   "79993dfe048d3b76",
+		},
+		{
+			name:       "1000 iterations",
+			password:   "queeg",
+			salt:       "05dec959acff72f7",
+			id:         pkcs12KeyID,
+			iterations: 1000,
+			expected:   "ed2034e36328830ff09df1e1a07dd357185dac0d4f9eb3d4",
+		},
+		{
+			name:       "Long password and key",
+			password:   "0123456789abcdefghijklmnopqrstuvwxyz",
+			salt:       "000102030405060708090a0b0c0d0e0f",
+			id:         3,
+			iterations: 3,
+			expected:   "72718dd1932ade677b4e48eb61a9c8089e777c8612a006e37d88b548a19e102b6619edee60d3a731fe2c2ffd0fa23b04",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				salt, _ := hex.DecodeString(tt.salt)
+				expected, _ := hex.DecodeString(tt.expected)
+
+				if key := pkcs12KDF(sha1.New, bmpPasswords(tt.password)[0], salt, tt.id, tt.iterations, len(expected)); !bytes.Equal(key, expected) {
+					t.Errorf("Expected %x, got %x", expected, key)
+				}
+			},
+		)
+	}
+}
+
+// TestPBKDF2 tests PBKDF2 with the vectors of RFC 6070 and RFC 7914
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		name       string
+		sha256     bool
+		password   string
+		salt       string
+		iterations int
+		expected   string
+	}{
+		{
+			name:       "HMAC-SHA1, 1 iteration",
+			password:   "password",
+			salt:       "salt",
+			iterations: 1,
+			expected:   "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		},
+		{
+			name:       "HMAC-SHA1, 4096 iterations",
+			password:   "password",
+			salt:       "salt",
+			iterations: 4096,
+			expected:   "4b007901b765489abead49d926f721d065a429c1",
+		},
+		{
+			name:       "HMAC-SHA1, long key",
+			password:   "passwordPASSWORDpassword",
+			salt:       "saltSALTsaltSALTsaltSALTsaltSALTsalt",
+			iterations: 4096,
+			expected:   "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038",
+		},
+		{
+			name:       "HMAC-SHA256",
+			sha256:     true,
+			password:   "passwd",
+			salt:       "salt",
+			iterations: 1,
+			expected:   "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				newHash := sha1.New
+				if tt.sha256 {
+					newHash = sha256.New
+				}
+
+				expected, _ := hex.DecodeString(tt.expected)
+
+				if key := pbkdf2(newHash, []byte(tt.password), []byte(tt.salt), tt.iterations, len(expected)); !bytes.Equal(key, expected) {
+					t.Errorf("Expected %x, got %x", expected, key)
+				}
+			},
+		)
+	}
+}
+
+// createPBES2Algorithm creates PBES2 parameters with PBKDF2, HMAC-SHA256 and AES-128-CBC
+func createPBES2Algorithm(t *testing.T, salt, iv []byte, iterations int) pkix.AlgorithmIdentifier {
+	t.Helper()
+
+	marshal := func(value interface{}) asn1.RawValue {
+		encoded, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to marshal PBES2 parameters: %v", err)
+		}
+
+		return asn1.RawValue{FullBytes: encoded}
+	}
+
+	kdf := pbkdf2Params{
+		Salt:           marshal(salt),
+		IterationCount: iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}, Parameters: asn1.NullRawValue},
+	}
+
+	params := pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: pbkdf2OID, Parameters: marshal(kdf)},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}, Parameters: marshal(iv)},
+	}
+
+	return pkix.AlgorithmIdentifier{Algorithm: pbes2OID, Parameters: marshal(params)}
+}
+
+// TestDecryptPBE tests decryption of PBES2 content and the rejection of wrong passwords and
+// excessive iteration counts
+func TestDecryptPBE(t *testing.T) {
+	salt := []byte("saltsalt")
+	iv := make([]byte, aes.BlockSize)
+	plaintext := []byte("preview")
+
+	block, err := aes.NewCipher(pbkdf2(sha256.New, []byte("secret"), salt, 100, 16))
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{9}, 9)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	tests := []struct {
+		name        string
+		password    string
+		iterations  int
+		expected    []byte
+		expectedErr error
+	}{
+		{
+			name:       "Correct password",
+			password:   "secret",
+			iterations: 100,
+			expected:   plaintext,
+		},
+		{
+			name:        "Wrong password",
+			password:    "wrong",
+			iterations:  100,
+			expectedErr: errDecryptionFailed,
+		},
+		{
+			name:        "Too many iterations",
+			password:    "secret",
+			iterations:  CurrentLimits().MaxPBEIterations + 1,
+			expectedErr: errUnsupportedPBE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				decrypted, err := decryptPBE(createPBES2Algorithm(t, salt, iv, tt.iterations), []byte(tt.password), ciphertext)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if !bytes.Equal(decrypted, tt.expected) {
+					t.Errorf("Expected %q, got %q", tt.expected, decrypted)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,329 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// SubFilter values of PDF signature dictionaries (ISO 32000-2, section 12.8.3)
+const (
+	PDFSubFilterCAdESDetached = "ETSI.CAdES.detached" // PAdES signature (ETSI EN 319 142-1)
+	PDFSubFilterRFC3161       = "ETSI.RFC3161"        // Document time-stamp
+	PDFSubFilterPKCS7Detached = "adbe.pkcs7.detached" // Legacy detached PKCS#7 signature
+	PDFSubFilterPKCS7SHA1     = "adbe.pkcs7.sha1"     // Legacy PKCS#7 signature of the SHA-1 digest of the document
+	PDFSubFilterX509RSASHA1   = "adbe.x509.rsa_sha1"  // Legacy PKCS#1 signature, Contents holds no CMS
+)
+
+// pdfHeader starts every PDF document
+var pdfHeader = []byte("%PDF-")
+
+// pdfByteRangeKey is written in every signature dictionary, which is not compressed since
+// ByteRange refers to the file offsets of its Contents
+var pdfByteRangeKey = []byte("/ByteRange")
+
+// Errors returned by DetectPDFSignatures
+var (
+	ErrNotPDF       = errors.New("not a PDF document")
+	ErrNotSignedPDF = errors.New("PDF document has no signature")
+)
+
+// PDFSignature describes a signature dictionary of a PDF document
+type PDFSignature struct {
+	Offset    int64           // File offset of the signature dictionary
+	Type      string          // Value of the Type entry, Sig or DocTimeStamp, empty if missing
+	Filter    string          // Preferred signature handler, e.g. Adobe.PPKLite
+	SubFilter string          // Encoding of the signature, e.g. PDFSubFilterCAdESDetached
+	Result    DetectionResult // Detection result of the CMS structure in Contents
+	Data      []byte          // CMS structure in Contents without the zero padding
+}
+
+// IsPAdES checks if the signature is a PAdES signature or document time-stamp rather than a
+// legacy PKCS#7 signature, validators apply the ETSI EN 319 142-1 rules to the former only
+func (s PDFSignature) IsPAdES() bool {
+	return s.SubFilter == PDFSubFilterCAdESDetached || s.SubFilter == PDFSubFilterRFC3161
+}
+
+// DetectPDFSignatures finds the signature dictionaries of a PDF document, including those of
+// incremental updates, and detects the CMS structures in their Contents. Dictionaries are located
+// by their ByteRange entry without parsing the cross-reference table, signatures are not verified
+func DetectPDFSignatures(data []byte) (signatures []PDFSignature, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(bytes.TrimPrefix(data, utf8BOM), pdfHeader) {
+		return nil, ErrNotPDF
+	}
+
+	for offset := 0; ; {
+		index := bytes.Index(data[offset:], pdfByteRangeKey)
+		if index < 0 {
+			break
+		}
+
+		key := offset + index
+		offset = key + len(pdfByteRangeKey)
+
+		start, ok := pdfDictionaryStart(data, key)
+		if !ok {
+			continue
+		}
+
+		entries, _, err := parsePDFDictionary(data, start, 0)
+		if err != nil {
+			continue
+		}
+
+		signature, ok := newPDFSignature(entries)
+		if !ok {
+			continue
+		}
+
+		signature.Offset = int64(start)
+		signatures = append(signatures, signature)
+	}
+
+	if len(signatures) == 0 {
+		return nil, ErrNotSignedPDF
+	}
+
+	return signatures, nil
+}
+
+// newPDFSignature builds a signature from the entries of a signature dictionary, which must hold
+// a hexadecimal Contents string
+func newPDFSignature(entries map[string][]byte) (PDFSignature, bool) {
+	contents, ok := decodePDFHexString(entries["Contents"])
+	if !ok {
+		return PDFSignature{}, false
+	}
+
+	signature := PDFSignature{
+		Type:      pdfName(entries["Type"]),
+		Filter:    pdfName(entries["Filter"]),
+		SubFilter: pdfName(entries["SubFilter"]),
+		Data:      contents,
+	}
+
+	// Contents is reserved before signing and padded with zeros after the DER encoding
+	if _, _, rest, ok := core.ReadElement(contents); ok {
+		signature.Data = contents[:len(contents)-len(rest)]
+	}
+
+	if result, err := Detect(signature.Data); err == nil {
+		signature.Result = result
+	}
+
+	return signature, true
+}
+
+// pdfDictionaryStart returns the offset of the "<<" opening the innermost dictionary enclosing
+// the given offset, skipping nested dictionaries that end before it. The search is bounded by
+// MaxScanWindow, which exceeds the size of signature dictionaries
+func pdfDictionaryStart(data []byte, offset int) (int, bool) {
+	depth := 0
+
+	limit := 0
+	if window := scanWindowSize(); window > 0 && offset > window {
+		limit = offset - window
+	}
+
+	for i := offset - 1; i > limit; i-- {
+		switch {
+		case data[i] == '>' && data[i-1] == '>':
+			depth++
+			i--
+		case data[i] == '<' && data[i-1] == '<':
+			if depth == 0 {
+				return i - 1, true
+			}
+
+			depth--
+			i--
+		}
+	}
+
+	return 0, false
+}
+
+// parsePDFDictionary parses the dictionary opening at data[offset], returning the raw values of
+// its entries and the offset following it. References such as "12 0 R" keep their first number only
+func parsePDFDictionary(data []byte, offset, depth int) (map[string][]byte, int, error) {
+	if exceedsNestingDepth(depth) {
+		return nil, 0, fmt.Errorf("PDF objects nested deeper than %d levels", CurrentLimits().MaxNestingDepth)
+	}
+
+	entries := make(map[string][]byte)
+	offset += 2
+
+	for {
+		offset = skipPDFWhitespace(data, offset)
+		if offset >= len(data) {
+			return nil, 0, errors.New("unterminated PDF dictionary")
+		}
+
+		if bytes.HasPrefix(data[offset:], []byte(">>")) {
+			return entries, offset + 2, nil
+		}
+
+		// Tokens other than names are the remainder of an indirect reference
+		if data[offset] != '/' {
+			end, err := skipPDFObject(data, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			offset = end
+
+			continue
+		}
+
+		end := pdfTokenEnd(data, offset+1)
+		key := string(data[offset+1 : end])
+
+		offset = skipPDFWhitespace(data, end)
+
+		end, err := skipPDFObject(data, offset, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		entries[key] = data[offset:end]
+		offset = end
+	}
+}
+
+// skipPDFObject returns the offset following the object at data[offset]
+func skipPDFObject(data []byte, offset, depth int) (int, error) {
+	if offset >= len(data) {
+		return 0, errors.New("truncated PDF object")
+	}
+
+	switch {
+	case bytes.HasPrefix(data[offset:], []byte("<<")):
+		_, end, err := parsePDFDictionary(data, offset, depth)
+
+		return end, err
+	case data[offset] == '<':
+		end := bytes.IndexByte(data[offset:], '>')
+		if end < 0 {
+			return 0, errors.New("unterminated PDF hexadecimal string")
+		}
+
+		return offset + end + 1, nil
+	case data[offset] == '(':
+		return skipPDFLiteralString(data, offset)
+	case data[offset] == '[':
+		if exceedsNestingDepth(depth) {
+			return 0, fmt.Errorf("PDF objects nested deeper than %d levels", CurrentLimits().MaxNestingDepth)
+		}
+
+		for offset = skipPDFWhitespace(data, offset+1); offset < len(data) && data[offset] != ']'; offset = skipPDFWhitespace(data, offset) {
+			end, err := skipPDFObject(data, offset, depth+1)
+			if err != nil {
+				return 0, err
+			}
+
+			offset = end
+		}
+
+		if offset >= len(data) {
+			return 0, errors.New("unterminated PDF array")
+		}
+
+		return offset + 1, nil
+	case data[offset] == '/':
+		return pdfTokenEnd(data, offset+1), nil
+	}
+
+	end := pdfTokenEnd(data, offset)
+	if end == offset {
+		return 0, fmt.Errorf("unexpected PDF delimiter %q", data[offset])
+	}
+
+	return end, nil
+}
+
+// skipPDFLiteralString returns the offset following the literal string opening at data[offset],
+// which may contain balanced and escaped parentheses
+func skipPDFLiteralString(data []byte, offset int) (int, error) {
+	depth := 0
+
+	for i := offset; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+
+	return 0, errors.New("unterminated PDF literal string")
+}
+
+// skipPDFWhitespace returns the offset of the first byte at or after offset that is neither
+// white-space nor part of a comment
+func skipPDFWhitespace(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case 0, '\t', '\n', '\f', '\r', ' ':
+			offset++
+		case '%':
+			for offset < len(data) && data[offset] != '\n' && data[offset] != '\r' {
+				offset++
+			}
+		default:
+			return offset
+		}
+	}
+
+	return offset
+}
+
+// pdfTokenEnd returns the offset of the first white-space or delimiter byte at or after offset
+func pdfTokenEnd(data []byte, offset int) int {
+	for offset < len(data) && !bytes.ContainsRune([]byte("\x00\t\n\f\r ()<>[]{}/%"), rune(data[offset])) {
+		offset++
+	}
+
+	return offset
+}
+
+// pdfName returns a raw name object without its solidus, empty for other objects
+func pdfName(value []byte) string {
+	if len(value) < 2 || value[0] != '/' {
+		return ""
+	}
+
+	return string(value[1:])
+}
+
+// decodePDFHexString decodes a raw hexadecimal string object, which may contain white-space and
+// an odd number of digits with an implied trailing zero
+func decodePDFHexString(value []byte) ([]byte, bool) {
+	if len(value) < 2 || value[0] != '<' || value[len(value)-1] != '>' {
+		return nil, false
+	}
+
+	digits := bytes.Join(bytes.Fields(value[1:len(value)-1]), nil)
+	if len(digits)%2 != 0 {
+		digits = append(digits, '0')
+	}
+
+	decoded := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(decoded, digits); err != nil {
+		return nil, false
+	}
+
+	return decoded, true
+}
@@ -0,0 +1,161 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createPDF creates a PDF document with an indirect object per given dictionary, the first
+// following the header and the others appended as incremental updates
+func createPDF(dictionaries ...string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+
+	for i, dictionary := range dictionaries {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\nxref\n0 1\ntrailer\n<< /Size %d >>\n%%%%EOF\n", i+1, dictionary, i+2)
+	}
+
+	return []byte(b.String())
+}
+
+// createPDFSignatureDictionary creates a signature dictionary with Contents reserved for 8 KiB of CMS
+func createPDFSignatureDictionary(sigType, subFilter string, contents []byte) string {
+	padded := make([]byte, 8192)
+	copy(padded, contents)
+
+	return fmt.Sprintf(
+		"<< /Type /%s /Filter /Adobe.PPKLite /SubFilter /%s /ByteRange [0 100 16484 200]\n"+
+			"/Contents <%s>\n/M (D:20240301093000+05'00') /Reason (Approved \\(final\\)) /Prop_Build << /App << /Name /Writer >> >> /P 12 0 R >>",
+		sigType, subFilter, strings.ToUpper(hex.EncodeToString(padded)),
+	)
+}
+
+// TestDetectPDFSignatures tests detection of PAdES and legacy PKCS#7 signatures of PDF documents
+func TestDetectPDFSignatures(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    []PDFSignature
+		expectedErr error
+	}{
+		{
+			name: "PAdES signature",
+			data: createPDF(createPDFSignatureDictionary("Sig", PDFSubFilterCAdESDetached, signed)),
+			expected: []PDFSignature{
+				{Type: "Sig", Filter: "Adobe.PPKLite", SubFilter: PDFSubFilterCAdESDetached, Data: signed},
+			},
+		},
+		{
+			name: "Legacy signature with PAdES document time-stamp in an incremental update",
+			data: createPDF(
+				createPDFSignatureDictionary("Sig", PDFSubFilterPKCS7Detached, signed),
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				createPDFSignatureDictionary("DocTimeStamp", PDFSubFilterRFC3161, signed),
+			),
+			expected: []PDFSignature{
+				{Type: "Sig", Filter: "Adobe.PPKLite", SubFilter: PDFSubFilterPKCS7Detached, Data: signed},
+				{Type: "DocTimeStamp", Filter: "Adobe.PPKLite", SubFilter: PDFSubFilterRFC3161, Data: signed},
+			},
+		},
+		{
+			name: "Odd number of digits",
+			data: createPDF("<</SubFilter/adbe.pkcs7.sha1/ByteRange[0 1 2 3]/Contents<3 0>>>"),
+			expected: []PDFSignature{
+				{SubFilter: PDFSubFilterPKCS7SHA1, Data: []byte{0x30}},
+			},
+		},
+		{
+			name:        "Unsigned document",
+			data:        createPDF("<< /Type /Catalog /Pages 2 0 R >>"),
+			expectedErr: ErrNotSignedPDF,
+		},
+		{
+			name:        "Signature dictionary without Contents",
+			data:        createPDF("<< /Type /Sig /ByteRange [0 1 2 3] /Contents 5 0 R >>"),
+			expectedErr: ErrNotSignedPDF,
+		},
+		{
+			name:        "Unterminated dictionary",
+			data:        createPDF("<< /Type /Sig /ByteRange [0 1 2 3] /Contents <3000")[:60],
+			expectedErr: ErrNotSignedPDF,
+		},
+		{
+			name:        "Not a PDF document",
+			data:        signed,
+			expectedErr: ErrNotPDF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				signatures, err := DetectPDFSignatures(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if len(signatures) != len(tt.expected) {
+					t.Fatalf("Expected %d signatures, got %d", len(tt.expected), len(signatures))
+				}
+
+				for i, expected := range tt.expected {
+					signature := signatures[i]
+
+					if signature.Type != expected.Type || signature.Filter != expected.Filter || signature.SubFilter != expected.SubFilter {
+						t.Errorf("Expected %s %s %s, got %s %s %s", expected.Type, expected.Filter, expected.SubFilter, signature.Type, signature.Filter, signature.SubFilter)
+					}
+
+					if !bytes.Equal(signature.Data, expected.Data) {
+						t.Errorf("Expected %d bytes of CMS, got %d", len(expected.Data), len(signature.Data))
+					}
+
+					if !bytes.HasPrefix(tt.data[signature.Offset:], []byte("<<")) {
+						t.Errorf("Expected the dictionary at offset %d", signature.Offset)
+					}
+				}
+			},
+		)
+	}
+
+	signatures, err := DetectPDFSignatures(createPDF(createPDFSignatureDictionary("Sig", PDFSubFilterCAdESDetached, signed)))
+	if err != nil {
+		t.Fatalf("DetectPDFSignatures returned an error: %v", err)
+	}
+
+	if signatures[0].Result.Kind != KindSignedData {
+		t.Errorf("Expected %v, got %v", KindSignedData, signatures[0].Result.Kind)
+	}
+}
+
+// TestPDFSignatureIsPAdES tests the distinction of PAdES and legacy SubFilter values
+func TestPDFSignatureIsPAdES(t *testing.T) {
+	tests := []struct {
+		subFilter string
+		expected  bool
+	}{
+		{subFilter: PDFSubFilterCAdESDetached, expected: true},
+		{subFilter: PDFSubFilterRFC3161, expected: true},
+		{subFilter: PDFSubFilterPKCS7Detached, expected: false},
+		{subFilter: PDFSubFilterPKCS7SHA1, expected: false},
+		{subFilter: PDFSubFilterX509RSASHA1, expected: false},
+		{subFilter: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.subFilter, func(t *testing.T) {
+				if result := (PDFSignature{SubFilter: tt.subFilter}).IsPAdES(); result != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
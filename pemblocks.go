@@ -0,0 +1,43 @@
+package cmsdetector
+
+import (
+	"encoding/pem"
+	"errors"
+)
+
+// ErrNotPEM is returned by DetectPEMBlocks for data without a PEM block
+var ErrNotPEM = errors.New("no PEM block found")
+
+// PEMBlock contains the classification of a block of PEM encoded data
+type PEMBlock struct {
+	Index  int       // Position of the block in the data, starting at 0
+	Type   string    // Block type, e.g. CERTIFICATE
+	Result AnyResult // Classification of the block like DetectAny, KindUnknown for unrecognized contents
+}
+
+// DetectPEMBlocks classifies every block of PEM encoded data in order, e.g. a certificate chain
+// followed by its private key, where DetectAny reports the first block only. Text around the
+// blocks and malformed blocks are skipped
+func DetectPEMBlocks(data []byte) (blocks []PEMBlock, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	for rest := data; ; {
+		block, next := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		blocks = append(blocks, PEMBlock{Index: len(blocks), Type: block.Type, Result: detectPEMBlock(block, rest)})
+		rest = next
+	}
+
+	if len(blocks) == 0 {
+		return nil, ErrNotPEM
+	}
+
+	return blocks, nil
+}
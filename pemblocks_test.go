@@ -0,0 +1,87 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestDetectPEMBlocks tests classification of every block of PEM bundles
+func TestDetectPEMBlocks(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+
+	encode := func(blocks ...*pem.Block) []byte {
+		var data []byte
+		for _, block := range blocks {
+			data = append(data, pem.EncodeToMemory(block)...)
+		}
+
+		return data
+	}
+
+	certificate := &pem.Block{Type: "CERTIFICATE", Bytes: createCertificate(t, "Leaf", key)}
+	issuer := &pem.Block{Type: "CERTIFICATE", Bytes: cmstest.Certificate(t, "Issuer")}
+	privateKey := &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}
+
+	x509Result := AnyResult{Family: FamilyX509, Kind: KindCertificate, Confidence: ConfidenceHigh, PEMType: "CERTIFICATE"}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    []PEMBlock
+		expectedErr error
+	}{
+		{
+			name: "Chain and key",
+			data: encode(certificate, issuer, privateKey),
+			expected: []PEMBlock{
+				{Index: 0, Type: "CERTIFICATE", Result: x509Result},
+				{Index: 1, Type: "CERTIFICATE", Result: x509Result},
+				{Index: 2, Type: "PRIVATE KEY", Result: AnyResult{Family: FamilyPKCS8, Kind: KindPrivateKey, Confidence: ConfidenceHigh, PEMType: "PRIVATE KEY"}},
+			},
+		},
+		{
+			name: "Text between blocks and unrecognized contents",
+			data: append(append([]byte("subject=CN=Leaf\n"), encode(certificate)...), encode(&pem.Block{Type: "DATA", Bytes: []byte{0x01}})...),
+			expected: []PEMBlock{
+				{Index: 0, Type: "CERTIFICATE", Result: x509Result},
+				{Index: 1, Type: "DATA", Result: AnyResult{Family: FamilyPEM, Kind: KindUnknown, Confidence: ConfidenceLow, PEMType: "DATA"}},
+			},
+		},
+		{
+			name:        "No block",
+			data:        []byte("-----BEGIN CERTIFICATE-----\n"),
+			expectedErr: ErrNotPEM,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				blocks, err := DetectPEMBlocks(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if !reflect.DeepEqual(blocks, tt.expected) {
+					t.Errorf("Expected %+v, got %+v", tt.expected, blocks)
+				}
+			},
+		)
+	}
+}
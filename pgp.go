@@ -0,0 +1,119 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// pgpArmorPrefix starts every ASCII-armored OpenPGP block (RFC 9580, section 6.2)
+const pgpArmorPrefix = "-----BEGIN PGP "
+
+// pgpArmorKinds maps armor header lines to kinds
+var pgpArmorKinds = map[string]Kind{
+	"MESSAGE":           KindPGPMessage,
+	"SIGNED MESSAGE":    KindPGPMessage,
+	"PUBLIC KEY BLOCK":  KindPGPPublicKey,
+	"PRIVATE KEY BLOCK": KindPGPPrivateKey,
+	"SIGNATURE":         KindPGPSignature,
+}
+
+// pgpPacket describes how the first packet of binary OpenPGP data is recognized
+type pgpPacket struct {
+	kind     Kind
+	versions []byte // Accepted values of the first body octet
+}
+
+// pgpPackets maps the packet tags that can start OpenPGP data (RFC 9580, section 5)
+var pgpPackets = map[byte]pgpPacket{
+	1:  {kind: KindPGPMessage, versions: []byte{3, 6}},       // Public-Key Encrypted Session Key
+	2:  {kind: KindPGPSignature, versions: []byte{3, 4, 6}},  // Signature
+	3:  {kind: KindPGPMessage, versions: []byte{4, 5, 6}},    // Symmetric-Key Encrypted Session Key
+	4:  {kind: KindPGPMessage, versions: []byte{3, 6}},       // One-Pass Signature
+	5:  {kind: KindPGPPrivateKey, versions: []byte{3, 4, 6}}, // Secret-Key
+	6:  {kind: KindPGPPublicKey, versions: []byte{3, 4, 6}},  // Public-Key
+	8:  {kind: KindPGPMessage, versions: []byte{0, 1, 2, 3}}, // Compressed Data, algorithm octet
+	11: {kind: KindPGPMessage, versions: []byte("btu1lm")},   // Literal Data, format octet
+}
+
+// detectPGPArmor detects ASCII-armored OpenPGP blocks by their header line
+func detectPGPArmor(data []byte) (Kind, bool) {
+	if !bytes.HasPrefix(data, []byte(pgpArmorPrefix)) {
+		return KindUnknown, false
+	}
+
+	line := data[len(pgpArmorPrefix):]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	line = bytes.TrimSuffix(bytes.TrimSpace(line), []byte("-----"))
+	kind, ok := pgpArmorKinds[string(line)]
+
+	return kind, ok
+}
+
+// detectPGPPacket detects binary OpenPGP data by the header and first body octet of its first packet
+func detectPGPPacket(data []byte) (Kind, bool) {
+	if len(data) < 3 || data[0]&0x80 == 0 {
+		return KindUnknown, false
+	}
+
+	var (
+		tag    byte
+		length int
+		body   []byte
+	)
+
+	if data[0]&0x40 != 0 {
+		// OpenPGP format header
+		tag = data[0] & 0x3f
+		length, body = pgpNewLength(data[1:])
+	} else {
+		// Legacy format header
+		tag = (data[0] >> 2) & 0x0f
+		length, body = pgpLegacyLength(data[0]&0x03, data[1:])
+	}
+
+	packet, ok := pgpPackets[tag]
+	if !ok || len(body) == 0 || length > len(body) {
+		return KindUnknown, false
+	}
+
+	if bytes.IndexByte(packet.versions, body[0]) < 0 {
+		return KindUnknown, false
+	}
+
+	return packet.kind, true
+}
+
+// pgpNewLength decodes an OpenPGP format body length, partial lengths are reported as the first chunk length
+func pgpNewLength(data []byte) (int, []byte) {
+	switch {
+	case len(data) >= 1 && data[0] < 192:
+		return int(data[0]), data[1:]
+	case len(data) >= 2 && data[0] < 224:
+		return (int(data[0])-192)<<8 + int(data[1]) + 192, data[2:]
+	case len(data) >= 5 && data[0] == 255:
+		return int(binary.BigEndian.Uint32(data[1:])), data[5:]
+	case len(data) >= 1 && data[0] != 255:
+		return 1 << (data[0] & 0x1f), data[1:]
+	}
+
+	return -1, nil
+}
+
+// pgpLegacyLength decodes a legacy format body length, indeterminate lengths span the rest of the data
+func pgpLegacyLength(lengthType byte, data []byte) (int, []byte) {
+	switch {
+	case lengthType == 0 && len(data) >= 1:
+		return int(data[0]), data[1:]
+	case lengthType == 1 && len(data) >= 2:
+		return int(binary.BigEndian.Uint16(data)), data[2:]
+	case lengthType == 2 && len(data) >= 4:
+		return int(binary.BigEndian.Uint32(data)), data[4:]
+	case lengthType == 3:
+		return len(data), data
+	}
+
+	return -1, nil
+}
@@ -0,0 +1,78 @@
+package cmsdetector
+
+import (
+	"testing"
+)
+
+// TestDetectPGPPacket tests detection of binary OpenPGP data by its first packet
+func TestDetectPGPPacket(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedKind Kind
+		expectedOK   bool
+	}{
+		{
+			name:         "OpenPGP format public key",
+			data:         []byte{0xc6, 0x03, 0x04, 0x00, 0x00},
+			expectedKind: KindPGPPublicKey,
+			expectedOK:   true,
+		},
+		{
+			name:         "Legacy format signature",
+			data:         []byte{0x89, 0x00, 0x03, 0x04, 0x00, 0x00},
+			expectedKind: KindPGPSignature,
+			expectedOK:   true,
+		},
+		{
+			name:         "Legacy format compressed data with indeterminate length",
+			data:         []byte{0xa3, 0x01, 0x78, 0x9c},
+			expectedKind: KindPGPMessage,
+			expectedOK:   true,
+		},
+		{
+			name:         "Two-octet length",
+			data:         append([]byte{0xc5, 0xc0, 0x00, 0x04}, make([]byte, 191)...),
+			expectedKind: KindPGPPrivateKey,
+			expectedOK:   true,
+		},
+		{
+			name: "Unsupported key version",
+			data: []byte{0xc6, 0x03, 0x09, 0x00, 0x00},
+		},
+		{
+			name: "Length beyond data",
+			data: []byte{0xc6, 0x20, 0x04, 0x00},
+		},
+		{
+			name: "DER sequence",
+			data: createTestData(t, PKCS7DataOID),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				kind, ok := detectPGPPacket(tt.data)
+				if ok != tt.expectedOK {
+					t.Fatalf("Expected ok %v, got %v", tt.expectedOK, ok)
+				}
+
+				if kind != tt.expectedKind {
+					t.Errorf("Expected kind %v, got %v", tt.expectedKind, kind)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectPGPArmor tests detection of ASCII-armored OpenPGP blocks
+func TestDetectPGPArmor(t *testing.T) {
+	if kind, ok := detectPGPArmor([]byte("-----BEGIN PGP SIGNED MESSAGE-----\r\nHash: SHA256\r\n")); !ok || kind != KindPGPMessage {
+		t.Errorf("Expected %v, got %v (ok %v)", KindPGPMessage, kind, ok)
+	}
+
+	if _, ok := detectPGPArmor([]byte("-----BEGIN PGP ARMORED FILE-----\n")); ok {
+		t.Error("Expected unknown armor header not to be detected")
+	}
+}
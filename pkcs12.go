@@ -0,0 +1,341 @@
+package cmsdetector
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"unicode/utf16"
+)
+
+// PKCS#12 bag type OIDs (RFC 7292, section 4.2)
+var (
+	PKCS12KeyBagOID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	PKCS12ShroudedKeyBagOID  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	PKCS12CertBagOID         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	PKCS12CRLBagOID          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 4}
+	PKCS12SecretBagOID       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 5}
+	PKCS12SafeContentsBagOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 6}
+)
+
+// PKCS#12 bag attribute OIDs (PKCS#9)
+var (
+	FriendlyNameAttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	LocalKeyIDAttributeOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+)
+
+// pbes2OID identifies the PBES2 password-based encryption scheme (RFC 8018)
+var pbes2OID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+
+const (
+	// pfxVersion is the only PFX version defined by RFC 7292
+	pfxVersion = 3
+
+	// maxAuthenticatedSafeEntries limits the number of inspected authenticated safe entries
+	maxAuthenticatedSafeEntries = 64
+)
+
+// ErrNotPKCS12 is returned when the data is not a PKCS#12 container
+var ErrNotPKCS12 = errors.New("not a PKCS#12 container")
+
+// errNotPFX is returned when the data is valid ASN.1 but not a PKCS#12 PFX
+var errNotPFX = errors.New("not a PKCS#12 PFX structure")
+
+// pfx provides the ASN.1 structure of a PKCS#12 PFX (RFC 7292, section 4)
+type pfx struct {
+	Version  int
+	AuthSafe ContentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+// macData provides the ASN.1 structure of the PKCS#12 integrity MAC
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+// digestInfo provides the ASN.1 structure of DigestInfo (RFC 8017, section 9.2)
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// safeBag provides the ASN.1 structure of a PKCS#12 SafeBag
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue `asn1:"tag:0,explicit"`
+	Attributes []attribute   `asn1:"set,optional"`
+}
+
+// encryptedPrivateKeyInfo provides the ASN.1 structure of PKCS#8 EncryptedPrivateKeyInfo
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params provides the ASN.1 structure of PBES2 parameters (RFC 8018, appendix A.4)
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pkcs12Contents holds the parsed, not encrypted parts of a PFX
+type pkcs12Contents struct {
+	pfx            pfx
+	authSafe       []byte // DER encoded AuthenticatedSafe, the input of the integrity MAC
+	encryptedInfos []encryptedContentInfo
+	bags           []safeBag
+}
+
+// parsePFX unmarshals a PKCS#12 PFX and the unencrypted parts of its authenticated safe
+func parsePFX(data []byte) (*pkcs12Contents, error) {
+	var contents pkcs12Contents
+	if _, err := asn1.Unmarshal(data, &contents.pfx); err != nil {
+		return nil, err
+	}
+
+	if contents.pfx.Version != pfxVersion || !contents.pfx.AuthSafe.ContentType.Equal(PKCS7DataOID) {
+		return nil, errNotPFX
+	}
+
+	if _, err := asn1.Unmarshal(contents.pfx.AuthSafe.Content.Bytes, &contents.authSafe); err != nil {
+		return nil, err
+	}
+
+	var authSafe []ContentInfo
+	if _, err := asn1.Unmarshal(contents.authSafe, &authSafe); err != nil {
+		return nil, err
+	}
+
+	if len(authSafe) > maxAuthenticatedSafeEntries {
+		authSafe = authSafe[:maxAuthenticatedSafeEntries]
+	}
+
+	for _, ci := range authSafe {
+		switch {
+		case ci.ContentType.Equal(PKCS7DataOID):
+			var safeContentsBytes []byte
+			if _, err := asn1.Unmarshal(ci.Content.Bytes, &safeContentsBytes); err != nil {
+				continue
+			}
+
+			var bags []safeBag
+			if _, err := asn1.Unmarshal(safeContentsBytes, &bags); err != nil {
+				continue
+			}
+
+			contents.bags = append(contents.bags, bags...)
+		case ci.ContentType.Equal(PKCS7EncryptedDataOID):
+			ed, err := parseEncryptedData(ci)
+			if err != nil {
+				continue
+			}
+
+			contents.encryptedInfos = append(contents.encryptedInfos, ed.EncryptedContentInfo)
+		}
+	}
+
+	return &contents, nil
+}
+
+// hasEncryptedBags checks if the PFX holds encrypted safe contents or shrouded key bags
+func (c *pkcs12Contents) hasEncryptedBags() bool {
+	if len(c.encryptedInfos) > 0 {
+		return true
+	}
+
+	for _, bag := range c.bags {
+		if bag.ID.Equal(PKCS12ShroudedKeyBagOID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasMAC checks if the PFX is protected by an integrity MAC
+func (c *pkcs12Contents) hasMAC() bool {
+	return len(c.pfx.MacData.Mac.Algorithm.Algorithm) > 0
+}
+
+// needsPassword checks if a password is required to open the PFX, either to decrypt its bags or
+// to verify the integrity MAC, which is derived from the password
+func (c *pkcs12Contents) needsPassword() bool {
+	return c.hasMAC() || c.hasEncryptedBags()
+}
+
+// PKCS12Bag describes a safe bag stored outside the encrypted safe contents of a PKCS#12 container
+type PKCS12Bag struct {
+	Type         asn1.ObjectIdentifier // Bag type, e.g. PKCS12ShroudedKeyBagOID
+	FriendlyName string                // friendlyName attribute, if any
+	LocalKeyID   []byte                // localKeyId attribute linking a key to its certificate, if any
+}
+
+// PKCS12Bags lists the bags of a PKCS#12 container that can be read without the password, with
+// their friendlyName and localKeyId attributes. Bags inside encrypted safe contents are not listed,
+// but the attributes of shrouded key bags are stored in clear text
+func PKCS12Bags(data []byte) (bags []PKCS12Bag, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contents, err := parsePFX(data)
+	if err != nil {
+		return nil, ErrNotPKCS12
+	}
+
+	bags = make([]PKCS12Bag, 0, len(contents.bags))
+	for _, bag := range contents.bags {
+		info := PKCS12Bag{Type: bag.ID}
+		info.FriendlyName, info.LocalKeyID = bagAttributes(bag)
+
+		bags = append(bags, info)
+	}
+
+	return bags, nil
+}
+
+// bagAttributes returns the friendlyName and localKeyId attributes of the bag
+func bagAttributes(bag safeBag) (friendlyName string, localKeyID []byte) {
+	if values := attributeValues(bag.Attributes, FriendlyNameAttributeOID); len(values) > 0 {
+		friendlyName = decodeDirectoryString(values[0])
+	}
+
+	if values := attributeValues(bag.Attributes, LocalKeyIDAttributeOID); len(values) > 0 {
+		localKeyID = values[0].Bytes
+	}
+
+	return friendlyName, localKeyID
+}
+
+// decodeDirectoryString decodes a BMPString, which encoding/asn1 does not support before Go 1.19,
+// or any other ASN.1 string type
+func decodeDirectoryString(value asn1.RawValue) string {
+	if value.Class == asn1.ClassUniversal && value.Tag == asn1.TagBMPString {
+		if len(value.Bytes)%2 != 0 {
+			return ""
+		}
+
+		units := make([]uint16, 0, len(value.Bytes)/2)
+		for i := 0; i < len(value.Bytes); i += 2 {
+			units = append(units, uint16(value.Bytes[i])<<8|uint16(value.Bytes[i+1]))
+		}
+
+		return string(utf16.Decode(units))
+	}
+
+	var s string
+	if _, err := asn1.Unmarshal(value.FullBytes, &s); err != nil {
+		return ""
+	}
+
+	return s
+}
+
+// PKCS12Certificates returns the certificates of a PKCS#12 container that can be read without asking
+// for the password: certificate bags stored unencrypted, and those in safe contents encrypted with
+// the empty password, as many export tools do by default. Certificates protected with a password
+// are skipped, as are encrypted safe contents whose key derivation exceeds Limits.MaxPBEIterations
+func PKCS12Certificates(data []byte) (certs []*x509.Certificate, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contents, err := parsePFX(data)
+	if err != nil {
+		return nil, ErrNotPKCS12
+	}
+
+	bags := contents.readableBags()
+
+	certs = make([]*x509.Certificate, 0, len(bags))
+	for _, bag := range bags {
+		if cert, ok := bagCertificate(bag); ok {
+			certs = append(certs, cert)
+		}
+	}
+
+	return certs, nil
+}
+
+// readableBags returns the bags that can be read without the password: the unencrypted ones and
+// those in safe contents encrypted with the empty password
+func (c *pkcs12Contents) readableBags() []safeBag {
+	bags := c.bags
+	for _, info := range c.encryptedInfos {
+		if decrypted, err := decryptSafeContents(info, ""); err == nil {
+			bags = append(bags, decrypted...)
+		}
+	}
+
+	return bags
+}
+
+// bagCertificate parses the X.509 certificate of a certificate bag
+func bagCertificate(bag safeBag) (*x509.Certificate, bool) {
+	if !bag.ID.Equal(PKCS12CertBagOID) {
+		return nil, false
+	}
+
+	var cb certBag
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil || !cb.CertID.Equal(x509CertBagOID) {
+		return nil, false
+	}
+
+	cert, err := x509.ParseCertificate(cb.CertValue)
+
+	return cert, err == nil
+}
+
+// decryptSafeContents decrypts encrypted safe contents with the password and parses its bags
+func decryptSafeContents(info encryptedContentInfo, password string) ([]safeBag, error) {
+	ciphertext, ok := encryptedContent(info)
+	if !ok {
+		return nil, errDecryptionFailed
+	}
+
+	var bags []safeBag
+
+	_, err := decryptWithPassword(
+		info.ContentEncryptionAlgorithm, password, ciphertext, func(plaintext []byte) bool {
+			rest, err := asn1.Unmarshal(plaintext, &bags)
+
+			return err == nil && len(rest) == 0
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bags, nil
+}
+
+// encryptedContent returns the octets of the implicitly tagged encrypted content, which BER allows
+// to be split into segments of a constructed encoding
+func encryptedContent(info encryptedContentInfo) ([]byte, bool) {
+	raw := info.EncryptedContent
+	if !raw.IsCompound {
+		return raw.Bytes, len(raw.Bytes) > 0
+	}
+
+	var content []byte
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			segment []byte
+			err     error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &segment); err != nil {
+			return nil, false
+		}
+
+		content = append(content, segment...)
+	}
+
+	return content, len(content) > 0
+}
@@ -0,0 +1,186 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// ErrIncorrectPassword is returned when the password does not open the PKCS#12 container
+var ErrIncorrectPassword = errors.New("incorrect PKCS#12 password")
+
+// PKCS12Contents holds the keys and certificates of a PKCS#12 container opened with its password
+type PKCS12Contents struct {
+	Keys         []PKCS12Key
+	Certificates []PKCS12Certificate
+}
+
+// PKCS12Key is a private key stored in a PKCS#12 key bag or shrouded key bag
+type PKCS12Key struct {
+	PKCS12Bag
+	PrivateKey crypto.PrivateKey // Parsed key, nil for algorithms unsupported by crypto/x509 such as GOST
+	Info       PrivateKeyResult  // Key algorithm, size and curve
+	Raw        []byte            // DER encoded PKCS#8 PrivateKeyInfo
+}
+
+// PKCS12Certificate is a certificate stored in a PKCS#12 certificate bag
+type PKCS12Certificate struct {
+	PKCS12Bag
+	Certificate *x509.Certificate
+}
+
+// Certificate returns the certificate with the same localKeyId as the key, or nil
+func (c *PKCS12Contents) Certificate(key PKCS12Key) *x509.Certificate {
+	if len(key.LocalKeyID) == 0 {
+		return nil
+	}
+
+	for _, cert := range c.Certificates {
+		if bytes.Equal(cert.LocalKeyID, key.LocalKeyID) {
+			return cert.Certificate
+		}
+	}
+
+	return nil
+}
+
+// OpenPKCS12 verifies the integrity MAC of a PKCS#12 container with the password, decrypts its
+// safe contents and shrouded key bags, and returns the keys and certificates. PKCS#12 PBE
+// schemes, including legacy RC2 and RC4, and PBES2 with PBKDF2 are supported. Key derivations
+// are bounded by Limits.MaxPBEIterations
+func OpenPKCS12(data []byte, password string) (contents *PKCS12Contents, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	parsed, err := parsePFX(data)
+	if err != nil {
+		return nil, ErrNotPKCS12
+	}
+
+	if parsed.hasMAC() {
+		if err := parsed.verifyMAC(password); err != nil {
+			return nil, err
+		}
+	}
+
+	bags := parsed.bags
+	for _, info := range parsed.encryptedInfos {
+		decrypted, err := decryptSafeContents(info, password)
+		if err != nil {
+			return nil, openError("safe contents", err)
+		}
+
+		bags = append(bags, decrypted...)
+	}
+
+	contents = &PKCS12Contents{}
+	for _, bag := range bags {
+		info := PKCS12Bag{Type: bag.ID}
+		info.FriendlyName, info.LocalKeyID = bagAttributes(bag)
+
+		switch {
+		case bag.ID.Equal(PKCS12KeyBagOID), bag.ID.Equal(PKCS12ShroudedKeyBagOID):
+			key, err := openKeyBag(bag, password)
+			if err != nil {
+				return nil, openError("private key", err)
+			}
+
+			key.PKCS12Bag = info
+			contents.Keys = append(contents.Keys, key)
+		case bag.ID.Equal(PKCS12CertBagOID):
+			var cb certBag
+			if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil || !cb.CertID.Equal(x509CertBagOID) {
+				continue
+			}
+
+			cert, err := x509.ParseCertificate(cb.CertValue)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse certificate: %w", err)
+			}
+
+			contents.Certificates = append(contents.Certificates, PKCS12Certificate{PKCS12Bag: info, Certificate: cert})
+		}
+	}
+
+	return contents, nil
+}
+
+// verifyMAC checks the integrity MAC with the password (RFC 7292, appendix B.4)
+func (c *pkcs12Contents) verifyMAC(password string) error {
+	mac := c.pfx.MacData
+
+	newHash, ok := macHashes[mac.Mac.Algorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("%w: MAC with %s", errUnsupportedPBE, GetAlgorithmName(mac.Mac.Algorithm.Algorithm))
+	}
+
+	if err := checkPBEIterations(mac.Iterations); err != nil {
+		return err
+	}
+
+	for _, encoded := range bmpPasswords(password) {
+		h := hmac.New(newHash, pkcs12KDF(newHash, encoded, mac.MacSalt, pkcs12MACID, mac.Iterations, newHash().Size()))
+		h.Write(c.authSafe)
+
+		if hmac.Equal(h.Sum(nil), mac.Mac.Digest) {
+			return nil
+		}
+	}
+
+	return ErrIncorrectPassword
+}
+
+// openKeyBag returns the private key of a key bag, decrypting shrouded key bags with the password
+func openKeyBag(bag safeBag, password string) (PKCS12Key, error) {
+	der := bag.Value.Bytes
+
+	if bag.ID.Equal(PKCS12ShroudedKeyBagOID) {
+		var encrypted encryptedPrivateKeyInfo
+		if _, err := asn1.Unmarshal(der, &encrypted); err != nil {
+			return PKCS12Key{}, err
+		}
+
+		var err error
+
+		der, err = decryptWithPassword(
+			encrypted.Algorithm, password, encrypted.EncryptedData, func(plaintext []byte) bool {
+				kind, ok := detectPKCS8(plaintext)
+
+				return ok && kind == KindPrivateKey
+			},
+		)
+		if err != nil {
+			return PKCS12Key{}, err
+		}
+	}
+
+	var key privateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &key); err != nil || len(rest) > 0 {
+		return PKCS12Key{}, ErrNotPrivateKey
+	}
+
+	result := PKCS12Key{Info: PrivateKeyResult{Format: PrivateKeyFormatPKCS8}, Raw: der}
+	result.Info.addKeyParameters(key)
+
+	if privateKey, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		result.PrivateKey = privateKey
+	}
+
+	return result, nil
+}
+
+// openError reports a decryption failure, which means a wrong password for containers without a MAC
+func openError(what string, err error) error {
+	if errors.Is(err, errDecryptionFailed) {
+		return ErrIncorrectPassword
+	}
+
+	return fmt.Errorf("failed to decrypt %s: %w", what, err)
+}
@@ -0,0 +1,120 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenPKCS12 tests opening containers created by OpenSSL with their passwords
+func TestOpenPKCS12(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		password    string
+		expectedErr error
+	}{
+		{
+			name: "Legacy RC2 and Triple DES",
+			file: "empty-rc2.p12",
+		},
+		{
+			name: "PBES2 with AES",
+			file: "empty-aes.p12",
+		},
+		{
+			name:     "Password protected",
+			file:     "password.p12",
+			password: "secret",
+		},
+		{
+			name:     "Unencrypted key bag",
+			file:     "unencrypted.p12",
+			password: "secret",
+		},
+		{
+			name:     "Without MAC",
+			file:     "nomac.p12",
+			password: "secret",
+		},
+		{
+			name:        "Wrong password",
+			file:        "password.p12",
+			password:    "wrong",
+			expectedErr: ErrIncorrectPassword,
+		},
+		{
+			name:        "Wrong password without MAC",
+			file:        "nomac.p12",
+			password:    "wrong",
+			expectedErr: ErrIncorrectPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data, err := os.ReadFile(filepath.Join("testdata", "pkcs12", tt.file))
+				if err != nil {
+					t.Fatalf("Failed to read container: %v", err)
+				}
+
+				contents, err := OpenPKCS12(data, tt.password)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if tt.expectedErr != nil {
+					return
+				}
+
+				if len(contents.Keys) != 1 || len(contents.Certificates) != 1 {
+					t.Fatalf("Expected a key and a certificate, got %d keys and %d certificates", len(contents.Keys), len(contents.Certificates))
+				}
+
+				key := contents.Keys[0]
+				if key.FriendlyName != "Иванов И.И." {
+					t.Errorf("Expected friendly name %q, got %q", "Иванов И.И.", key.FriendlyName)
+				}
+
+				if key.Info.Curve.Name != "NIST P-256" {
+					t.Errorf("Expected curve %q, got %q", "NIST P-256", key.Info.Curve.Name)
+				}
+
+				privateKey, ok := key.PrivateKey.(*ecdsa.PrivateKey)
+				if !ok {
+					t.Fatalf("Expected an ECDSA private key, got %T", key.PrivateKey)
+				}
+
+				cert := contents.Certificate(key)
+				if cert == nil {
+					t.Fatal("Expected the certificate of the key, got nil")
+				}
+
+				if !privateKey.PublicKey.Equal(cert.PublicKey) {
+					t.Error("Expected the certificate to match the private key")
+				}
+			},
+		)
+	}
+
+	if _, err := OpenPKCS12(createTestData(t, PKCS7SignedDataOID), ""); !errors.Is(err, ErrNotPKCS12) {
+		t.Errorf("Expected error %v, got %v", ErrNotPKCS12, err)
+	}
+}
+
+// TestOpenPKCS12Iterations tests that key derivations above the limit are not attempted
+func TestOpenPKCS12Iterations(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "pkcs12", "password.p12"))
+	if err != nil {
+		t.Fatalf("Failed to read container: %v", err)
+	}
+
+	withLimits(t, Limits{MaxPBEIterations: 1000})
+
+	if _, err := OpenPKCS12(data, "secret"); err == nil || errors.Is(err, ErrIncorrectPassword) {
+		t.Errorf("Expected an iteration limit error, got %v", err)
+	}
+}
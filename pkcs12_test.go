@@ -0,0 +1,285 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+// createSafeBag creates a SafeBag with the given type wrapping the ASN.1 encoding of value
+func createSafeBag(t testing.TB, bagType asn1.ObjectIdentifier, value interface{}, attrs ...attribute) safeBag {
+	t.Helper()
+
+	inner, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal bag value: %v", err)
+	}
+
+	return safeBag{
+		ID:         bagType,
+		Value:      asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+		Attributes: attrs,
+	}
+}
+
+// createPFX creates a PKCS#12 PFX with the given unencrypted bags and encrypted safe contents
+func createPFX(t testing.TB, bags []safeBag, encrypted []encryptedContentInfo) []byte {
+	t.Helper()
+
+	var authSafe []asn1.RawValue
+
+	if len(bags) > 0 {
+		safeContents, err := asn1.Marshal(bags)
+		if err != nil {
+			t.Fatalf("Failed to marshal safe contents: %v", err)
+		}
+
+		authSafe = append(authSafe, asn1.RawValue{FullBytes: createContentInfo(t, PKCS7DataOID, safeContents)})
+	}
+
+	for _, info := range encrypted {
+		ed := encryptedData{Version: 0, EncryptedContentInfo: info}
+		authSafe = append(authSafe, asn1.RawValue{FullBytes: createContentInfo(t, PKCS7EncryptedDataOID, ed)})
+	}
+
+	authSafeBytes, err := asn1.Marshal(authSafe)
+	if err != nil {
+		t.Fatalf("Failed to marshal authenticated safe: %v", err)
+	}
+
+	var authSafeInfo ContentInfo
+	if _, err := asn1.Unmarshal(createContentInfo(t, PKCS7DataOID, authSafeBytes), &authSafeInfo); err != nil {
+		t.Fatalf("Failed to unmarshal authenticated safe: %v", err)
+	}
+
+	authSafeInfo.Content.FullBytes = nil
+
+	data, err := asn1.Marshal(
+		pfx{
+			Version:  pfxVersion,
+			AuthSafe: authSafeInfo,
+			MacData: macData{
+				Mac: digestInfo{
+					Algorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}},
+					Digest:    make([]byte, 32),
+				},
+				MacSalt:    make([]byte, 8),
+				Iterations: 2048,
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal PFX: %v", err)
+	}
+
+	return data
+}
+
+// TestParsePFX tests parsing of PKCS#12 PFX structures
+func TestParsePFX(t *testing.T) {
+	keyInfo := encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+		EncryptedData: []byte{0x01, 0x02},
+	}
+
+	certs := encryptedContentInfo{
+		ContentType:                PKCS7DataOID,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+	}
+
+	data := createPFX(t, []safeBag{createSafeBag(t, PKCS12ShroudedKeyBagOID, keyInfo)}, []encryptedContentInfo{certs})
+
+	contents, err := parsePFX(data)
+	if err != nil {
+		t.Fatalf("parsePFX returned an error: %v", err)
+	}
+
+	if len(contents.bags) != 1 || !contents.bags[0].ID.Equal(PKCS12ShroudedKeyBagOID) {
+		t.Errorf("Expected a single shrouded key bag, got %v", contents.bags)
+	}
+
+	if len(contents.encryptedInfos) != 1 {
+		t.Errorf("Expected a single encrypted safe, got %d", len(contents.encryptedInfos))
+	}
+
+	if contents.pfx.MacData.Iterations != 2048 {
+		t.Errorf("Expected 2048 MAC iterations, got %d", contents.pfx.MacData.Iterations)
+	}
+
+	if _, err := parsePFX(createTestData(t, PKCS7DataOID)); err == nil {
+		t.Error("Expected error for PKCS#7 Data, got nil")
+	}
+}
+
+// createBMPString creates an ASN.1 BMPString value
+func createBMPString(s string) asn1.RawValue {
+	var encoded []byte
+	for _, unit := range utf16.Encode([]rune(s)) {
+		encoded = append(encoded, byte(unit>>8), byte(unit))
+	}
+
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagBMPString, Bytes: encoded}
+}
+
+// TestPKCS12Bags tests listing of cleartext PKCS#12 bags with their attributes
+func TestPKCS12Bags(t *testing.T) {
+	keyInfo := encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+		EncryptedData: []byte{0x01, 0x02},
+	}
+
+	certs := encryptedContentInfo{
+		ContentType:                PKCS7DataOID,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+	}
+
+	localKeyID := []byte{0x01, 0x02, 0x03, 0x04}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    []PKCS12Bag
+		expectedErr error
+	}{
+		{
+			name: "Shrouded key bag with BMPString friendly name",
+			data: createPFX(
+				t, []safeBag{
+					createSafeBag(
+						t, PKCS12ShroudedKeyBagOID, keyInfo,
+						createAttribute(t, FriendlyNameAttributeOID, createBMPString("Иванов И.И.")),
+						createAttribute(t, LocalKeyIDAttributeOID, localKeyID),
+					),
+				}, []encryptedContentInfo{certs},
+			),
+			expected: []PKCS12Bag{{Type: PKCS12ShroudedKeyBagOID, FriendlyName: "Иванов И.И.", LocalKeyID: localKeyID}},
+		},
+		{
+			name: "UTF8String friendly name",
+			data: createPFX(
+				t, []safeBag{
+					createSafeBag(
+						t, PKCS12CertBagOID, []byte{0x01},
+						createAttribute(t, FriendlyNameAttributeOID, asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte("server")}),
+					),
+				}, nil,
+			),
+			expected: []PKCS12Bag{{Type: PKCS12CertBagOID, FriendlyName: "server"}},
+		},
+		{
+			name:     "Bag without attributes",
+			data:     createPFX(t, []safeBag{createSafeBag(t, PKCS12ShroudedKeyBagOID, keyInfo)}, nil),
+			expected: []PKCS12Bag{{Type: PKCS12ShroudedKeyBagOID}},
+		},
+		{
+			name:     "Only encrypted safe contents",
+			data:     createPFX(t, nil, []encryptedContentInfo{certs}),
+			expected: []PKCS12Bag{},
+		},
+		{
+			name:        "PKCS#7 Signed Data",
+			data:        createTestData(t, PKCS7SignedDataOID),
+			expectedErr: ErrNotPKCS12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				bags, err := PKCS12Bags(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if len(bags) != len(tt.expected) {
+					t.Fatalf("Expected %d bags, got %d", len(tt.expected), len(bags))
+				}
+
+				for i, bag := range bags {
+					expected := tt.expected[i]
+
+					if !bag.Type.Equal(expected.Type) {
+						t.Errorf("Expected bag type %v, got %v", expected.Type, bag.Type)
+					}
+
+					if bag.FriendlyName != expected.FriendlyName {
+						t.Errorf("Expected friendly name %q, got %q", expected.FriendlyName, bag.FriendlyName)
+					}
+
+					if !bytes.Equal(bag.LocalKeyID, expected.LocalKeyID) {
+						t.Errorf("Expected local key ID %x, got %x", expected.LocalKeyID, bag.LocalKeyID)
+					}
+				}
+			},
+		)
+	}
+}
+
+// TestPKCS12Certificates tests previewing certificates of containers created by OpenSSL
+func TestPKCS12Certificates(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{
+			name:     "RC2-40 with empty password",
+			file:     "empty-rc2.p12",
+			expected: []string{"CN=Иванов И.И."},
+		},
+		{
+			name:     "Triple DES with empty password",
+			file:     "empty-3des.p12",
+			expected: []string{"CN=Иванов И.И."},
+		},
+		{
+			name:     "PBES2 with empty password",
+			file:     "empty-aes.p12",
+			expected: []string{"CN=Иванов И.И."},
+		},
+		{
+			name:     "Unencrypted certificates",
+			file:     "unencrypted.p12",
+			expected: []string{"CN=Иванов И.И."},
+		},
+		{
+			name: "Password protected",
+			file: "password.p12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data, err := os.ReadFile(filepath.Join("testdata", "pkcs12", tt.file))
+				if err != nil {
+					t.Fatalf("Failed to read container: %v", err)
+				}
+
+				certs, err := PKCS12Certificates(data)
+				if err != nil {
+					t.Fatalf("PKCS12Certificates returned an error: %v", err)
+				}
+
+				if len(certs) != len(tt.expected) {
+					t.Fatalf("Expected %d certificates, got %d", len(tt.expected), len(certs))
+				}
+
+				for i, cert := range certs {
+					if subject := cert.Subject.String(); subject != tt.expected[i] {
+						t.Errorf("Expected subject %q, got %q", tt.expected[i], subject)
+					}
+				}
+			},
+		)
+	}
+
+	if _, err := PKCS12Certificates(createTestData(t, PKCS7SignedDataOID)); !errors.Is(err, ErrNotPKCS12) {
+		t.Errorf("Expected error %v, got %v", ErrNotPKCS12, err)
+	}
+}
@@ -0,0 +1,37 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+)
+
+// privateKeyInfo provides the ASN.1 structure of PKCS#8 PrivateKeyInfo / OneAsymmetricKey (RFC 5958)
+type privateKeyInfo struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+	Attributes asn1.RawValue `asn1:"optional,tag:0"`
+	PublicKey  asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// detectPKCS8 detects DER encoded PKCS#8 private keys, encrypted or not
+func detectPKCS8(der []byte) (Kind, bool) {
+	var key privateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &key); err == nil && len(rest) == 0 && (key.Version == 0 || key.Version == 1) {
+		return KindPrivateKey, true
+	}
+
+	// EncryptedPrivateKeyInfo is only accepted with a password-based encryption scheme,
+	// since any AlgorithmIdentifier followed by an OCTET STRING would match otherwise
+	var encrypted encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &encrypted); err == nil && len(rest) == 0 && isPasswordBasedEncryption(encrypted.Algorithm.Algorithm) {
+		return KindEncryptedPrivateKey, true
+	}
+
+	return KindUnknown, false
+}
+
+// isPasswordBasedEncryption checks if the OID is PBES2, a PBES1 scheme or a PKCS#12 PBE scheme
+func isPasswordBasedEncryption(oid asn1.ObjectIdentifier) bool {
+	return hasOIDPrefix(oid, pbes1Arc) || hasOIDPrefix(oid, pkcs12PBEArc)
+}
@@ -0,0 +1,172 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TimeStampTokenAttributeOID identifies the signature time-stamp unsigned attribute (RFC 3161, appendix A)
+// carried by CAdES-T signatures
+var TimeStampTokenAttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+
+// Rules of policy violations
+const (
+	PolicyRuleMaxSize    = "max-size"
+	PolicyRuleKind       = "kind"
+	PolicyRuleEncryption = "encryption"
+	PolicyRuleWeak       = "weak-algorithm"
+	PolicyRuleProvider   = "provider"
+	PolicyRuleAttribute  = "attribute"
+)
+
+// Policy declares the CMS/PKCS structures accepted by a service. A zero field imposes no restriction
+type Policy struct {
+	AllowKinds        []Kind                  // Accepted kinds
+	RequireEncryption bool                    // Reject content that is not encrypted
+	ForbidWeakAlgs    bool                    // Reject the deprecated algorithms and weak parameters reported by InspectAlgorithms
+	MaxSize           int                     // Maximum size of the data in bytes
+	RequireProvider   string                  // Required provider hint, e.g. ProviderCryptoPro for GOST algorithms
+	RequireAttributes []asn1.ObjectIdentifier // Signed or unsigned attributes every signer must carry
+}
+
+// PolicyViolation describes a requirement of a Policy the data does not meet
+type PolicyViolation struct {
+	Rule    string
+	Message string
+}
+
+// String returns the violation in the form "message [rule]"
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("%s [%s]", v.Message, v.Rule)
+}
+
+// PolicyResult contains the outcome of a policy check
+type PolicyResult struct {
+	Detection  DetectionResult
+	Algorithms *AlgorithmReport // Algorithms of the structure, if inspected for ForbidWeakAlgs
+	Violations []PolicyViolation
+}
+
+// Allowed checks if the data meets every requirement of the policy
+func (r PolicyResult) Allowed() bool {
+	return len(r.Violations) == 0
+}
+
+// ErrPolicyViolation is returned, wrapped in PolicyViolationError, for data violating a policy
+var ErrPolicyViolation = errors.New("policy violation")
+
+// PolicyViolationError is returned by CheckPolicy with the violated requirements
+type PolicyViolationError struct {
+	Violations []PolicyViolation
+}
+
+// Error implements the error interface
+func (e *PolicyViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.String()
+	}
+
+	return fmt.Sprintf("%s: %s", ErrPolicyViolation, strings.Join(messages, "; "))
+}
+
+// Unwrap allows errors.Is(err, ErrPolicyViolation)
+func (e *PolicyViolationError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// CheckPolicy detects the CMS/PKCS data and checks it against the policy. Violations are listed in
+// the result and returned as PolicyViolationError, detection failures are returned as is
+func CheckPolicy(data []byte, policy Policy) (PolicyResult, error) {
+	var result PolicyResult
+
+	// Oversized data is rejected before parsing
+	if policy.MaxSize > 0 && len(data) > policy.MaxSize {
+		result.addViolation(PolicyRuleMaxSize, "size %d bytes exceeds %d bytes", len(data), policy.MaxSize)
+
+		return result, result.err()
+	}
+
+	detection, err := Detect(data)
+	if err != nil {
+		return result, err
+	}
+
+	result.Detection = detection
+
+	if len(policy.AllowKinds) > 0 && !containsKind(policy.AllowKinds, detection.Kind) {
+		result.addViolation(PolicyRuleKind, "kind %v is not allowed", detection.Kind)
+	}
+
+	if policy.RequireEncryption && !detection.IsEncrypted {
+		result.addViolation(PolicyRuleEncryption, "%s is not encrypted", detection.Type)
+	}
+
+	if policy.RequireProvider != "" && detection.Provider != policy.RequireProvider {
+		result.addViolation(PolicyRuleProvider, "provider %q required, got %q", policy.RequireProvider, detection.Provider)
+	}
+
+	if policy.ForbidWeakAlgs {
+		if result.Algorithms, err = InspectAlgorithms(data); err != nil {
+			return result, err
+		}
+
+		for _, finding := range result.Algorithms.Weak {
+			result.addViolation(PolicyRuleWeak, "%s", finding)
+		}
+	}
+
+	if len(policy.RequireAttributes) > 0 {
+		result.checkAttributes(data, policy.RequireAttributes)
+	}
+
+	return result, result.err()
+}
+
+// addViolation adds a violation of the rule to the result
+func (r *PolicyResult) addViolation(rule, format string, args ...interface{}) {
+	r.Violations = append(r.Violations, PolicyViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns a PolicyViolationError if the result lists violations
+func (r *PolicyResult) err() error {
+	if r.Allowed() {
+		return nil
+	}
+
+	return &PolicyViolationError{Violations: r.Violations}
+}
+
+// checkAttributes adds violations for signers lacking a required signed or unsigned attribute
+func (r *PolicyResult) checkAttributes(data []byte, required []asn1.ObjectIdentifier) {
+	sd, err := loadSignedData(data)
+	if err != nil || len(sd.SignerInfos) == 0 {
+		r.addViolation(PolicyRuleAttribute, "signer attributes required, but %v has no signers", r.Detection.Kind)
+
+		return
+	}
+
+	for i, si := range sd.SignerInfos {
+		signed, _ := parseAttributes(si.SignedAttrs)
+		unsigned, _ := parseAttributes(si.UnsignedAttrs)
+
+		for _, oid := range required {
+			if len(attributeValues(signed, oid)) == 0 && len(attributeValues(unsigned, oid)) == 0 {
+				r.addViolation(PolicyRuleAttribute, "signer %d lacks attribute %s", i, GetOIDDescription(oid))
+			}
+		}
+	}
+}
+
+// containsKind checks if the kind is in the list
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createCAdEST creates SignedData with a GOST signer carrying a signature time-stamp token
+func createCAdEST(t *testing.T) []byte {
+	t.Helper()
+
+	timeStamp := createAttribute(t, TimeStampTokenAttributeOID, asn1.RawValue{FullBytes: []byte{0x30, 0x00}})
+	si := createSignerInfo(t, GOSTR34112012256OID, GOSTR34102012256SignatureOID, nil, []attribute{timeStamp})
+
+	return createSignedDataWithSigners(t, []signerInfo{si})
+}
+
+// violationRules returns the rules of the violations
+func violationRules(violations []PolicyViolation) []string {
+	var result []string
+	for _, v := range violations {
+		result = append(result, v.Rule)
+	}
+
+	return result
+}
+
+// TestCheckPolicy tests the violations reported by CheckPolicy
+func TestCheckPolicy(t *testing.T) {
+	gostCAdEST := Policy{
+		AllowKinds:        []Kind{KindSignedData},
+		MaxSize:           10 << 20,
+		RequireProvider:   ProviderCryptoPro,
+		RequireAttributes: []asn1.ObjectIdentifier{TimeStampTokenAttributeOID},
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		policy   Policy
+		expected []string
+	}{
+		{
+			name:   "Empty policy",
+			data:   cmstest.SignedData(t),
+			policy: Policy{},
+		},
+		{
+			name:   "GOST CAdES-T",
+			data:   createCAdEST(t),
+			policy: gostCAdEST,
+		},
+		{
+			name:     "CAdES-BES",
+			data:     createSignedData(t, GOSTR34112012256OID, GOSTR34102012256SignatureOID),
+			policy:   gostCAdEST,
+			expected: []string{PolicyRuleAttribute},
+		},
+		{
+			name:     "Not GOST signed",
+			data:     cmstest.SignedData(t),
+			policy:   gostCAdEST,
+			expected: []string{PolicyRuleProvider, PolicyRuleAttribute},
+		},
+		{
+			name:     "Kind not allowed",
+			data:     cmstest.EnvelopedData(t),
+			policy:   gostCAdEST,
+			expected: []string{PolicyRuleKind, PolicyRuleProvider, PolicyRuleAttribute},
+		},
+		{
+			name:     "Too large",
+			data:     createCAdEST(t),
+			policy:   Policy{MaxSize: 16},
+			expected: []string{PolicyRuleMaxSize},
+		},
+		{
+			name:   "Encrypted",
+			data:   cmstest.EnvelopedData(t),
+			policy: Policy{RequireEncryption: true},
+		},
+		{
+			name:     "Not encrypted",
+			data:     cmstest.SignedData(t),
+			policy:   Policy{RequireEncryption: true},
+			expected: []string{PolicyRuleEncryption},
+		},
+		{
+			name:   "Encrypted AuthEnvelopedData",
+			data:   createAuthEnvelopedData(t),
+			policy: Policy{RequireEncryption: true},
+		},
+		{
+			name:     "PFX with certificates only",
+			data:     createCertificatePFX(t),
+			policy:   Policy{RequireEncryption: true},
+			expected: []string{PolicyRuleEncryption},
+		},
+		{
+			name:   "Strong algorithms",
+			data:   cmstest.EncryptedData(t),
+			policy: Policy{ForbidWeakAlgs: true},
+		},
+		{
+			name:     "Weak algorithms",
+			data:     cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.DESEDE3CBCOID)),
+			policy:   Policy{ForbidWeakAlgs: true},
+			expected: []string{PolicyRuleWeak},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := CheckPolicy(tt.data, tt.policy)
+
+				if got := violationRules(result.Violations); !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Expected violations %v, got %v", tt.expected, result.Violations)
+				}
+
+				if result.Allowed() != (len(tt.expected) == 0) {
+					t.Errorf("Expected Allowed() to be %v", len(tt.expected) == 0)
+				}
+
+				var violationErr *PolicyViolationError
+
+				switch {
+				case len(tt.expected) == 0 && err != nil:
+					t.Errorf("Expected no error, got %v", err)
+				case len(tt.expected) > 0 && !errors.As(err, &violationErr):
+					t.Errorf("Expected PolicyViolationError, got %v", err)
+				case len(tt.expected) > 0 && !errors.Is(err, ErrPolicyViolation):
+					t.Errorf("Expected ErrPolicyViolation, got %v", err)
+				}
+			},
+		)
+	}
+}
+
+// TestCheckPolicyDetectionError tests that detection failures are not reported as violations
+func TestCheckPolicyDetectionError(t *testing.T) {
+	result, err := CheckPolicy([]byte("not a CMS structure"), Policy{AllowKinds: []Kind{KindSignedData}})
+	if err == nil || errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("Expected a detection error, got %v", err)
+	}
+
+	if len(result.Violations) != 0 {
+		t.Errorf("Expected no violations, got %v", result.Violations)
+	}
+}
@@ -0,0 +1,298 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// MaxPrefixSize is the largest number of leading bytes DetectPrefix requires to classify data
+const MaxPrefixSize = 4 << 10
+
+// ErrNeedMoreData is returned by DetectPrefix, wrapped in NeedMoreDataError, when the prefix is too short
+var ErrNeedMoreData = errors.New("more data is needed")
+
+// errPrefixBoundExceeded is returned when classification would require more than MaxPrefixSize bytes
+var errPrefixBoundExceeded = fmt.Errorf("classification requires more than %d bytes", MaxPrefixSize)
+
+// NeedMoreDataError is returned by DetectPrefix when the prefix ends before the fields it needs
+type NeedMoreDataError struct {
+	Needed int64 // Number of bytes to append to the prefix
+}
+
+// Error implements the error interface
+func (e *NeedMoreDataError) Error() string {
+	return fmt.Sprintf("%s: %d more bytes required", ErrNeedMoreData, e.Needed)
+}
+
+// Unwrap allows errors.Is(err, ErrNeedMoreData)
+func (e *NeedMoreDataError) Unwrap() error {
+	return ErrNeedMoreData
+}
+
+// DetectPrefix classifies CMS/PKCS data of totalSize bytes from its leading bytes. Only the DER
+// headers of the ContentInfo and, for SignedData, of the encapsulated content info are read, which
+// never requires more than MaxPrefixSize bytes. When the prefix is too short, the returned
+// NeedMoreDataError reports how many bytes must be appended to read the next field; a longer
+// prefix may be requested again if that field is a header whose length octets were missing.
+//
+// Unless the prefix covers the whole input, the result is not validated beyond the fields read
+// and Provider, Payload and ContentSize are not reported, InnerContentType only for SignedData; use
+// DetectReaderAt to inspect the content
+func DetectPrefix(prefix []byte, totalSize int64) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	if totalSize < 0 {
+		return DetectionResult{}, fmt.Errorf("invalid input size %d", totalSize)
+	}
+
+	if int64(len(prefix)) >= totalSize {
+		return Detect(prefix[:totalSize])
+	}
+
+	p := &prefixReader{prefix: prefix, total: totalSize}
+
+	result, err = p.detect()
+	if errors.Is(err, ErrNeedMoreData) {
+		return DetectionResult{}, err
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	recordDetection(int(totalSize), result.Kind, result.Kind == KindEncryptedPKCS12, err)
+
+	return result, err
+}
+
+// prefixReader reads DER elements from the leading bytes of an input of known size
+type prefixReader struct {
+	prefix []byte
+	total  int64
+}
+
+// detect classifies the ContentInfo or PKCS#12 PFX at the start of the input
+func (p *prefixReader) detect() (DetectionResult, error) {
+	tag, headerLen, length, err := p.header(0)
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	if tag != derTagSequence {
+		return DetectionResult{}, errors.New("not a DER SEQUENCE")
+	}
+
+	if int64(headerLen)+int64(length) > p.total {
+		return DetectionResult{}, errTruncatedElement
+	}
+
+	tag, oid, next, err := p.element(headerLen)
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	if tag == derTagInteger {
+		return p.detectPFX(oid, next)
+	}
+
+	var contentType asn1.ObjectIdentifier
+	if tag != derTagObjectIdentifier || !core.ValidOIDContents(oid) {
+		return DetectionResult{}, errors.New("missing content type")
+	}
+
+	if _, err := asn1.Unmarshal(p.prefix[headerLen:next], &contentType); err != nil {
+		return DetectionResult{}, err
+	}
+
+	result := DetectionResult{
+		ContentType: contentType,
+		Kind:        kindOfContentInfo(ContentInfo{ContentType: contentType}),
+		Header:      copyRawHeader(p.prefix[:next]),
+	}
+
+	// The content is not read, so encryption is indicated by the content type alone
+	result.IsEncrypted = encryptedKinds[result.Kind] || contentType.Equal(AuthEnvelopedDataOID)
+
+	if bytes.Equal(oid, encodedPKCS7SignedDataOID) {
+		inner, err := p.encapsulatedContentType(next, headerLen+length)
+		if err != nil {
+			return DetectionResult{}, err
+		}
+
+		if inner != nil {
+			result.InnerContentType = inner
+			result.InnerType = GetOIDDescription(inner)
+		}
+
+		if inner.Equal(WindowsCatalogOID) {
+			result.Kind = KindWindowsCatalog
+		}
+	}
+
+	if result.Kind == KindUnknown {
+		result.Type = GetOIDDescription(contentType)
+	} else {
+		result.Type = result.Kind.String()
+	}
+
+	return result, nil
+}
+
+// detectPFX classifies a PKCS#12 PFX given its version and the offset of its authSafe
+func (p *prefixReader) detectPFX(version []byte, offset int) (DetectionResult, error) {
+	if len(version) != 1 || version[0] != pfxVersion {
+		return DetectionResult{}, errors.New("unsupported PFX version")
+	}
+
+	tag, headerLen, _, err := p.header(offset)
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	if tag != derTagSequence {
+		return DetectionResult{}, errors.New("missing PFX authSafe")
+	}
+
+	tag, oid, _, err := p.element(offset + headerLen)
+	if err != nil {
+		return DetectionResult{}, err
+	}
+
+	if tag != derTagObjectIdentifier || !bytes.Equal(oid, encodedPKCS7DataOID) {
+		return DetectionResult{}, errors.New("unexpected PFX authSafe content type")
+	}
+
+	// Like Detect, report PFX containers as encrypted key containers
+	return DetectionResult{
+		Type:          TypeEncryptedPKCS12,
+		Kind:          KindEncryptedPKCS12,
+		IsEncrypted:   true,
+		NeedsPassword: true,
+		Header:        copyRawHeader(p.prefix[:offset]),
+	}, nil
+}
+
+// encapsulatedContentType returns the eContentType of the SignedData content at the offset, or nil
+// if it is not found. The version and digestAlgorithms fields are skipped by their headers
+func (p *prefixReader) encapsulatedContentType(offset, end int) (asn1.ObjectIdentifier, error) {
+	if offset >= end {
+		return nil, nil
+	}
+
+	for _, expected := range []byte{derTagExplicit0, derTagSequence, derTagInteger, derTagSet, derTagSequence} {
+		tag, headerLen, length, err := p.header(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag != expected {
+			return nil, nil
+		}
+
+		offset += headerLen
+		if expected == derTagInteger || expected == derTagSet {
+			offset += length
+		}
+	}
+
+	tag, oid, next, err := p.element(offset)
+	if err != nil || tag != derTagObjectIdentifier || !core.ValidOIDContents(oid) {
+		return nil, err
+	}
+
+	var contentType asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(p.prefix[offset:next], &contentType); err != nil {
+		return nil, nil
+	}
+
+	return contentType, nil
+}
+
+// header parses the identifier and length octets of the element at the offset
+func (p *prefixReader) header(offset int) (tag byte, headerLen, length int, err error) {
+	available := p.from(offset)
+	if err := p.require(offset + derHeaderSize(available)); err != nil {
+		return 0, 0, 0, err
+	}
+
+	tag, headerLen, length, ok := core.ReadHeader(available)
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid DER header at offset %d", offset)
+	}
+
+	return tag, headerLen, length, nil
+}
+
+// element returns the tag and contents of the element at the offset and the offset following it
+func (p *prefixReader) element(offset int) (tag byte, contents []byte, next int, err error) {
+	tag, headerLen, length, err := p.header(offset)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+
+	next = offset + headerLen + length
+	if err := p.require(next); err != nil {
+		return 0, nil, 0, err
+	}
+
+	return tag, p.prefix[offset+headerLen : next], next, nil
+}
+
+// require checks that the first end bytes of the input are available in the prefix
+func (p *prefixReader) require(end int) error {
+	switch {
+	case int64(end) > p.total:
+		return errTruncatedElement
+	case end > MaxPrefixSize:
+		return errPrefixBoundExceeded
+	case end > len(p.prefix):
+		return &NeedMoreDataError{Needed: int64(end - len(p.prefix))}
+	}
+
+	return nil
+}
+
+// from returns the prefix bytes starting at the offset
+func (p *prefixReader) from(offset int) []byte {
+	if offset >= len(p.prefix) {
+		return nil
+	}
+
+	return p.prefix[offset:]
+}
+
+// derHeaderSize returns the number of identifier and length octets of the element starting with data,
+// as far as it can be determined from the available bytes
+func derHeaderSize(data []byte) int {
+	size := 1
+
+	// High tag numbers continue while bit 8 of the tag number octets is set
+	if len(data) > 0 && data[0]&0x1f == 0x1f {
+		for size < len(data) && data[size]&0x80 != 0 {
+			size++
+		}
+
+		size++
+	}
+
+	// Long form lengths are followed by the number of length octets given in the first one
+	if size < len(data) && data[size]&0x80 != 0 {
+		size += int(data[size] & 0x7f)
+	}
+
+	return size + 1
+}
+
+// IsDefinitelyNotCMS checks if the leading bytes of data rule out a CMS/PKCS structure, letting
+// pipelines skip Detect for the bulk of their input: data not starting with a SEQUENCE, with an
+// impossible length or with a first element other than the OBJECT IDENTIFIER of a ContentInfo or
+// the version of a PFX. Data accepted by Detect is never rejected, text such as PEM is, so it must
+// be decoded first or passed to DetectAny. A prefix too short to tell returns false. The check is
+// implemented by the core package
+func IsDefinitelyNotCMS(prefix []byte) bool {
+	return core.IsDefinitelyNotCMS(prefix)
+}
@@ -0,0 +1,212 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+// TestDetectPrefix tests classification from a prefix grown by the number of bytes DetectPrefix requests
+func TestDetectPrefix(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	unknownOID := asn1.ObjectIdentifier{1, 2, 3, 4}
+
+	tests := []struct {
+		name              string
+		data              []byte
+		expectedKind      Kind
+		expectedType      string
+		expectedInnerType string
+		expectedEncrypted bool
+	}{
+		{
+			name:              "Large signed data",
+			data:              createLargeSignedData(t, sha256OID, rsaOID),
+			expectedKind:      KindSignedData,
+			expectedType:      "PKCS#7 Signed Data",
+			expectedInnerType: "PKCS#7 Data",
+		},
+		{
+			name:              "Large enveloped data",
+			data:              createLargeEnvelopedData(t, rsaOID, aesOID),
+			expectedKind:      KindEnvelopedData,
+			expectedType:      "PKCS#7 Enveloped Data",
+			expectedEncrypted: true,
+		},
+		{
+			name:              "Windows security catalog",
+			data:              createWindowsCatalog(t),
+			expectedKind:      KindWindowsCatalog,
+			expectedType:      "Windows Security Catalog",
+			expectedInnerType: "Windows Security Catalog",
+		},
+		{
+			name:         "Unknown content type",
+			data:         createTestData(t, unknownOID),
+			expectedKind: KindUnknown,
+			expectedType: "Unknown OID: 1.2.3.4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var (
+					prefix []byte
+					result DetectionResult
+					err    error
+				)
+
+				for {
+					result, err = DetectPrefix(prefix, int64(len(tt.data)))
+
+					var needMore *NeedMoreDataError
+					if !errors.As(err, &needMore) {
+						break
+					}
+
+					if needMore.Needed <= 0 || len(prefix)+int(needMore.Needed) > len(tt.data) {
+						t.Fatalf("Unexpected number of bytes requested: %d", needMore.Needed)
+					}
+
+					prefix = tt.data[:len(prefix)+int(needMore.Needed)]
+				}
+
+				if err != nil {
+					t.Fatalf("DetectPrefix returned an error: %v", err)
+				}
+
+				if len(prefix) > MaxPrefixSize || len(prefix) == len(tt.data) {
+					t.Errorf("Expected classification from a bounded prefix, read %d of %d bytes", len(prefix), len(tt.data))
+				}
+
+				if result.Kind != tt.expectedKind {
+					t.Errorf("Expected kind %v, got %v", tt.expectedKind, result.Kind)
+				}
+
+				if result.Type != tt.expectedType {
+					t.Errorf("Expected type %s, got %s", tt.expectedType, result.Type)
+				}
+
+				if result.InnerType != tt.expectedInnerType {
+					t.Errorf("Expected inner type %q, got %q", tt.expectedInnerType, result.InnerType)
+				}
+
+				if result.IsEncrypted != tt.expectedEncrypted {
+					t.Errorf("Expected IsEncrypted %v, got %v", tt.expectedEncrypted, result.IsEncrypted)
+				}
+
+				if detected, _ := Detect(tt.data); !bytes.Equal(result.Header, detected.Header) {
+					t.Errorf("Expected header %x, got %x", detected.Header, result.Header)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectPrefixNeedMoreData tests the number of bytes requested for a short prefix
+func TestDetectPrefixNeedMoreData(t *testing.T) {
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	data := createLargeEnvelopedData(t, rsaOID, aesOID)
+
+	tests := []struct {
+		name           string
+		prefixLen      int
+		expectedNeeded int64
+	}{
+		{
+			name:           "Empty prefix",
+			prefixLen:      0,
+			expectedNeeded: 2,
+		},
+		{
+			name:           "Missing length octets",
+			prefixLen:      2,
+			expectedNeeded: 3,
+		},
+		{
+			// 5 octets of the ContentInfo header and 2 of the content type header
+			name:           "Missing content type",
+			prefixLen:      7,
+			expectedNeeded: 9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := DetectPrefix(data[:tt.prefixLen], int64(len(data)))
+				if !errors.Is(err, ErrNeedMoreData) {
+					t.Fatalf("Expected error %v, got %v", ErrNeedMoreData, err)
+				}
+
+				var needMore *NeedMoreDataError
+				if errors.As(err, &needMore) && needMore.Needed != tt.expectedNeeded {
+					t.Errorf("Expected %d more bytes, got %d", tt.expectedNeeded, needMore.Needed)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectPrefixInvalidInput tests DetectPrefix with data that cannot be classified from a prefix
+func TestDetectPrefixInvalidInput(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	data := createLargeSignedData(t, sha256OID, rsaOID)
+
+	tests := []struct {
+		name      string
+		prefix    []byte
+		totalSize int64
+	}{
+		{
+			name:      "Not a SEQUENCE",
+			prefix:    []byte("%PDF-1.7 and more"),
+			totalSize: 1 << 20,
+		},
+		{
+			name:      "Truncated input",
+			prefix:    data[:64],
+			totalSize: int64(len(data) - 1),
+		},
+		{
+			name:      "Negative size",
+			prefix:    nil,
+			totalSize: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				_, err := DetectPrefix(tt.prefix, tt.totalSize)
+				if err == nil || errors.Is(err, ErrNeedMoreData) {
+					t.Errorf("Expected parse error, got %v", err)
+				}
+			},
+		)
+	}
+}
+
+// TestIsDefinitelyNotCMS tests that the corpus accepted by Detect is not rejected and that common
+// formats other than CMS/PKCS are
+func TestIsDefinitelyNotCMS(t *testing.T) {
+	for name, data := range readCorpus(t) {
+		if _, err := Detect(data); err == nil && IsDefinitelyNotCMS(data) {
+			t.Errorf("Expected %s to be accepted", name)
+		}
+	}
+
+	for _, prefix := range []string{"%PDF-1.7", "-----BEGIN PKCS7-----", "MIIGxwYJKoZIhvcNAQcCoIIGuDCC", "PK\x03\x04", "\x89PNG\r\n"} {
+		if !IsDefinitelyNotCMS([]byte(prefix)) {
+			t.Errorf("Expected %q to be rejected", prefix)
+		}
+	}
+}
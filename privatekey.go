@@ -0,0 +1,209 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+)
+
+// Formats of private keys reported by DetectPrivateKey
+const (
+	PrivateKeyFormatPKCS8 = "PKCS#8"
+	PrivateKeyFormatSEC1  = "SEC1"
+)
+
+// privateKeyPEMTypes lists the PEM block types accepted by DetectPrivateKey
+var privateKeyPEMTypes = map[string]bool{
+	"PRIVATE KEY":           true,
+	"ENCRYPTED PRIVATE KEY": true,
+	"EC PRIVATE KEY":        true,
+}
+
+// OIDs of key algorithms whose parameters are reported by DetectPrivateKey
+var (
+	rsaPSSOID      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	dsaOID         = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+	ecPublicKeyOID = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// fixedKeySizes maps key algorithms without size parameters to their key size in bits
+var fixedKeySizes = map[string]int{
+	"1.3.101.110":       256, // X25519
+	"1.3.101.111":       448, // X448
+	"1.3.101.112":       256, // Ed25519
+	"1.3.101.113":       456, // Ed448
+	"1.2.643.2.2.19":    256, // GOST R 34.10-2001
+	"1.2.643.7.1.1.1.1": 256, // GOST R 34.10-2012 (256 bit)
+	"1.2.643.7.1.1.1.2": 512, // GOST R 34.10-2012 (512 bit)
+}
+
+// curveSizes maps named elliptic curves to their size in bits
+var curveSizes = map[string]int{
+	"1.2.840.10045.3.1.1":   192, // NIST P-192
+	"1.3.132.0.33":          224, // NIST P-224
+	"1.2.840.10045.3.1.7":   256, // NIST P-256
+	"1.3.132.0.34":          384, // NIST P-384
+	"1.3.132.0.35":          521, // NIST P-521
+	"1.3.132.0.10":          256, // secp256k1
+	"1.3.36.3.3.2.8.1.1.7":  256, // brainpoolP256r1
+	"1.3.36.3.3.2.8.1.1.11": 384, // brainpoolP384r1
+	"1.3.36.3.3.2.8.1.1.13": 512, // brainpoolP512r1
+}
+
+// ErrNotPrivateKey is returned when the data is not a PKCS#8 or SEC1 private key
+var ErrNotPrivateKey = errors.New("not a PKCS#8 or SEC1 private key")
+
+// PrivateKeyResult describes a detected private key
+type PrivateKeyResult struct {
+	Format    string    // PrivateKeyFormatPKCS8 or PrivateKeyFormatSEC1
+	Encrypted bool      // Indicates an encrypted PKCS#8 key, whose algorithm is unknown without the password
+	Algorithm Algorithm // Key algorithm, e.g. RSA, EC public key or Ed25519
+	Size      int       // Key size in bits: modulus of RSA and DSA keys, curve size of EC, EdDSA and GOST keys
+	Curve     Algorithm // Named curve of EC keys or parameter set of GOST keys, if any
+}
+
+// ecPrivateKey provides the ASN.1 structure of a SEC1 ECPrivateKey (RFC 5915)
+type ecPrivateKey struct {
+	Version    int
+	PrivateKey []byte
+	Parameters asn1.RawValue  `asn1:"optional,tag:0"`
+	PublicKey  asn1.BitString `asn1:"optional,explicit,tag:1"`
+}
+
+// curveParameters returns the EC parameters wrapped in the explicitly tagged parameters field
+func (k ecPrivateKey) curveParameters() asn1.RawValue {
+	var params asn1.RawValue
+	if _, err := asn1.Unmarshal(k.Parameters.Bytes, &params); err != nil {
+		return asn1.RawValue{}
+	}
+
+	return params
+}
+
+// rsaPrivateKeyModulus provides the leading fields of a PKCS#1 RSAPrivateKey
+type rsaPrivateKeyModulus struct {
+	Version int
+	N       *big.Int
+}
+
+// dsaParameters provides the ASN.1 structure of DSA domain parameters (RFC 3279)
+type dsaParameters struct {
+	P, Q, G *big.Int
+}
+
+// gostKeyParameters provides the leading field of GOST R 34.10 public key parameters (RFC 4491)
+type gostKeyParameters struct {
+	PublicKeyParamSet asn1.ObjectIdentifier
+}
+
+// DetectPrivateKey detects DER or PEM encoded PKCS#8 and SEC1 private keys and reports the key
+// algorithm with its size and named curve
+func DetectPrivateKey(data []byte) (result PrivateKeyResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return PrivateKeyResult{}, err
+	}
+
+	der := data
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("-----BEGIN ")) {
+		block, _ := pem.Decode(trimmed)
+		if block == nil || !privateKeyPEMTypes[block.Type] {
+			return PrivateKeyResult{}, ErrNotPrivateKey
+		}
+
+		der = block.Bytes
+	}
+
+	if kind, ok := detectPKCS8(der); ok {
+		result = PrivateKeyResult{Format: PrivateKeyFormatPKCS8, Encrypted: kind == KindEncryptedPrivateKey}
+		if !result.Encrypted {
+			var key privateKeyInfo
+			if _, err := asn1.Unmarshal(der, &key); err != nil {
+				return PrivateKeyResult{}, ErrNotPrivateKey
+			}
+
+			result.addKeyParameters(key)
+		}
+
+		return result, nil
+	}
+
+	if key, ok := parseECPrivateKey(der); ok {
+		result = PrivateKeyResult{Format: PrivateKeyFormatSEC1, Algorithm: newAlgorithm(ecPublicKeyOID)}
+		result.addCurve(key.curveParameters())
+
+		return result, nil
+	}
+
+	return PrivateKeyResult{}, ErrNotPrivateKey
+}
+
+// parseECPrivateKey parses a DER encoded SEC1 private key
+func parseECPrivateKey(der []byte) (ecPrivateKey, bool) {
+	var key ecPrivateKey
+	if rest, err := asn1.Unmarshal(der, &key); err != nil || len(rest) > 0 || key.Version != 1 || len(key.PrivateKey) == 0 {
+		return ecPrivateKey{}, false
+	}
+
+	return key, true
+}
+
+// addKeyParameters reports the algorithm of a PKCS#8 key with the size and curve found in the
+// algorithm parameters or the private key
+func (r *PrivateKeyResult) addKeyParameters(key privateKeyInfo) {
+	oid := key.Algorithm.Algorithm
+	params := key.Algorithm.Parameters
+
+	r.Algorithm = newAlgorithm(oid)
+	r.Size = fixedKeySizes[oid.String()]
+
+	switch {
+	case oid.Equal(rsaEncryptionOID) || oid.Equal(rsaPSSOID):
+		var rsaKey rsaPrivateKeyModulus
+		if _, err := asn1.Unmarshal(key.PrivateKey, &rsaKey); err == nil && rsaKey.N != nil {
+			r.Size = rsaKey.N.BitLen()
+		}
+	case oid.Equal(dsaOID):
+		var dsa dsaParameters
+		if _, err := asn1.Unmarshal(params.FullBytes, &dsa); err == nil && dsa.P != nil {
+			r.Size = dsa.P.BitLen()
+		}
+	case oid.Equal(ecPublicKeyOID):
+		r.addCurve(params)
+
+		// The curve may be repeated in, or only given by, the SEC1 key inside PKCS#8
+		if ecKey, ok := parseECPrivateKey(key.PrivateKey); ok && len(r.Curve.OID) == 0 {
+			r.addCurve(ecKey.curveParameters())
+		}
+	case isGOSTAlgorithm(oid):
+		var gost gostKeyParameters
+		if _, err := asn1.Unmarshal(params.FullBytes, &gost); err == nil {
+			r.Curve = Algorithm{OID: gost.PublicKeyParamSet, Name: GetOIDDescription(gost.PublicKeyParamSet)}
+		}
+	}
+}
+
+// addCurve reports the named curve of EC parameters
+func (r *PrivateKeyResult) addCurve(params asn1.RawValue) {
+	if curve, size, ok := namedCurve(params); ok {
+		r.Curve, r.Size = curve, size
+	}
+}
+
+// namedCurve returns the named curve of EC parameters with its size, explicit curve parameters
+// are not named
+func namedCurve(params asn1.RawValue) (Algorithm, int, bool) {
+	if params.Class != asn1.ClassUniversal || params.Tag != asn1.TagOID {
+		return Algorithm{}, 0, false
+	}
+
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params.FullBytes, &curve); err != nil {
+		return Algorithm{}, 0, false
+	}
+
+	return Algorithm{OID: curve, Name: GetOIDDescription(curve)}, curveSizes[curve.String()], true
+}
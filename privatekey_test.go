@@ -0,0 +1,208 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// createPrivateKeyInfo creates a DER encoded PKCS#8 key with the given algorithm parameters
+func createPrivateKeyInfo(t *testing.T, oid asn1.ObjectIdentifier, params interface{}) []byte {
+	t.Helper()
+
+	algorithm := pkix.AlgorithmIdentifier{Algorithm: oid}
+	if params != nil {
+		encoded, err := asn1.Marshal(params)
+		if err != nil {
+			t.Fatalf("Failed to marshal algorithm parameters: %v", err)
+		}
+
+		algorithm.Parameters = asn1.RawValue{FullBytes: encoded}
+	}
+
+	data, err := asn1.Marshal(privateKeyInfo{Algorithm: algorithm, PrivateKey: make([]byte, 32)})
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+
+	return data
+}
+
+// TestDetectPrivateKey tests detection of key algorithms, sizes and curves of PKCS#8 and SEC1 keys
+func TestDetectPrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	marshalPKCS8 := func(key interface{}) []byte {
+		data, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal private key: %v", err)
+		}
+
+		return data
+	}
+
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal EC private key: %v", err)
+	}
+
+	encrypted, err := asn1.Marshal(
+		encryptedPrivateKeyInfo{
+			Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID},
+			EncryptedData: []byte{0x01, 0x02, 0x03},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal encrypted private key: %v", err)
+	}
+
+	gostParams := struct {
+		PublicKeyParamSet asn1.ObjectIdentifier
+		DigestParamSet    asn1.ObjectIdentifier
+	}{
+		PublicKeyParamSet: asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 1, 1},
+		DigestParamSet:    asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2},
+	}
+
+	dsaParams := dsaParameters{P: new(big.Int).Lsh(big.NewInt(1), 2047), Q: big.NewInt(3), G: big.NewInt(2)}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		format      string
+		encrypted   bool
+		algorithm   string
+		size        int
+		curve       string
+		expectedErr error
+	}{
+		{
+			name:      "RSA PKCS#8",
+			data:      marshalPKCS8(rsaKey),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "RSA",
+			size:      2048,
+		},
+		{
+			name:      "EC PKCS#8 PEM",
+			data:      pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: marshalPKCS8(ecKey)}),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "EC public key",
+			size:      384,
+			curve:     "NIST P-384",
+		},
+		{
+			name:      "EC SEC1 PEM",
+			data:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+			format:    PrivateKeyFormatSEC1,
+			algorithm: "EC public key",
+			size:      384,
+			curve:     "NIST P-384",
+		},
+		{
+			name:      "EC SEC1 DER",
+			data:      sec1,
+			format:    PrivateKeyFormatSEC1,
+			algorithm: "EC public key",
+			size:      384,
+			curve:     "NIST P-384",
+		},
+		{
+			name:      "Ed25519 PKCS#8",
+			data:      marshalPKCS8(edKey),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "Ed25519",
+			size:      256,
+		},
+		{
+			name:      "GOST R 34.10-2012 PKCS#8",
+			data:      createPrivateKeyInfo(t, asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}, gostParams),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "GOST R 34.10-2012 (256 bit)",
+			size:      256,
+			curve:     "GOST R 34.10-2012 (256 bit) parameter set A",
+		},
+		{
+			name:      "DSA PKCS#8",
+			data:      createPrivateKeyInfo(t, dsaOID, dsaParams),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "DSA",
+			size:      2048,
+		},
+		{
+			name:      "EC PKCS#8 with explicit curve",
+			data:      createPrivateKeyInfo(t, ecPublicKeyOID, dsaParams),
+			format:    PrivateKeyFormatPKCS8,
+			algorithm: "EC public key",
+		},
+		{
+			name:      "Encrypted PKCS#8",
+			data:      pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}),
+			format:    PrivateKeyFormatPKCS8,
+			encrypted: true,
+		},
+		{
+			name:        "Certificate PEM",
+			data:        pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: marshalPKCS8(edKey)}),
+			expectedErr: ErrNotPrivateKey,
+		},
+		{
+			name:        "PKCS#7 Signed Data",
+			data:        createTestData(t, PKCS7SignedDataOID),
+			expectedErr: ErrNotPrivateKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectPrivateKey(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result.Format != tt.format {
+					t.Errorf("Expected format %q, got %q", tt.format, result.Format)
+				}
+
+				if result.Encrypted != tt.encrypted {
+					t.Errorf("Expected encrypted %v, got %v", tt.encrypted, result.Encrypted)
+				}
+
+				if result.Algorithm.Name != tt.algorithm {
+					t.Errorf("Expected algorithm %q, got %q", tt.algorithm, result.Algorithm.Name)
+				}
+
+				if result.Size != tt.size {
+					t.Errorf("Expected size %d, got %d", tt.size, result.Size)
+				}
+
+				if result.Curve.Name != tt.curve {
+					t.Errorf("Expected curve %q, got %q", tt.curve, result.Curve.Name)
+				}
+			},
+		)
+	}
+}
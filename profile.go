@@ -0,0 +1,98 @@
+package cmsdetector
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Rules of the checks of signature profiles
+const (
+	ProfileRuleSigner      = "signer"
+	ProfileRuleAttribute   = "attribute"
+	ProfileRuleAlgorithm   = "algorithm"
+	ProfileRuleTimestamp   = "timestamp"
+	ProfileRuleCertificate = "certificate"
+	ProfileRuleRevocation  = "revocation"
+	ProfileRuleArchive     = "archive-timestamp"
+)
+
+// ProfileCheck is the outcome of a requirement of a signature profile
+type ProfileCheck struct {
+	Signer  int // Index of the SignerInfo, -1 for requirements of the SignedData
+	Rule    string
+	Passed  bool
+	Message string
+}
+
+// String returns the check in the form "PASS signer 0: message [rule]"
+func (c ProfileCheck) String() string {
+	outcome := "FAIL"
+	if c.Passed {
+		outcome = "PASS"
+	}
+
+	if c.Signer < 0 {
+		return fmt.Sprintf("%s: %s [%s]", outcome, c.Message, c.Rule)
+	}
+
+	return fmt.Sprintf("%s signer %d: %s [%s]", outcome, c.Signer, c.Message, c.Rule)
+}
+
+// ProfileReport lists the checks of SignedData against a signature profile
+type ProfileReport struct {
+	Checks []ProfileCheck
+}
+
+// Passed reports whether every check of the profile passed
+func (r ProfileReport) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the checks that did not pass
+func (r ProfileReport) Failures() []ProfileCheck {
+	var failures []ProfileCheck
+
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failures = append(failures, check)
+		}
+	}
+
+	return failures
+}
+
+// add adds the outcome of a check to the report
+func (r *ProfileReport) add(signer int, rule string, passed bool, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, ProfileCheck{Signer: signer, Rule: rule, Passed: passed, Message: fmt.Sprintf(format, args...)})
+}
+
+// signerAttributes parses the signed and unsigned attributes of the signer
+func signerAttributes(si signerInfo) (signed, unsigned []attribute, err error) {
+	if signed, err = parseAttributes(si.SignedAttrs); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse signed attributes: %w", err)
+	}
+
+	if unsigned, err = parseAttributes(si.UnsignedAttrs); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse unsigned attributes: %w", err)
+	}
+
+	return signed, unsigned, nil
+}
+
+// hasSigningCertificateAttribute checks if the signed attributes carry an ESS signing certificate
+// attribute of either version
+func hasSigningCertificateAttribute(signed []attribute) bool {
+	return len(attributeValues(signed, SigningCertificateV2AttributeOID)) > 0 ||
+		len(attributeValues(signed, SigningCertificateAttributeOID)) > 0
+}
+
+// hasSignerCertificate checks if one of the certificates is referenced by the signer identifier
+func hasSignerCertificate(si signerInfo, certs []*x509.Certificate) bool {
+	for _, cert := range certs {
+		if matchesCertificate(si.SID, cert) {
+			return true
+		}
+	}
+
+	return false
+}
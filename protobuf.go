@@ -0,0 +1,403 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrMalformedEncoding is returned by UnmarshalBinary for data that is not a valid protobuf encoding
+var ErrMalformedEncoding = errors.New("malformed protobuf encoding")
+
+// Protobuf wire types (https://protobuf.dev/programming-guides/encoding/)
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protoField is a decoded protobuf field, varint holds the value of varint fields and bytes
+// the contents of length-delimited fields
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+// appendProtoVarint appends a varint field, zero values are omitted as in proto3
+func appendProtoVarint(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = appendUvarint(b, uint64(num)<<3|wireVarint)
+
+	return appendUvarint(b, v)
+}
+
+// appendProtoInt appends an integer or enum field
+func appendProtoInt(b []byte, num, v int) []byte {
+	return appendProtoVarint(b, num, uint64(int64(v)))
+}
+
+// appendProtoBool appends a bool field
+func appendProtoBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+
+	return appendProtoVarint(b, num, 1)
+}
+
+// appendProtoString appends a string field, empty strings are omitted as in proto3
+func appendProtoString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+
+	return appendProtoBytes(b, num, []byte(s))
+}
+
+// appendProtoOID appends an OID as a string field in dotted decimal notation
+func appendProtoOID(b []byte, num int, oid asn1.ObjectIdentifier) []byte {
+	if len(oid) == 0 {
+		return b
+	}
+
+	return appendProtoString(b, num, oid.String())
+}
+
+// appendProtoBytes appends a length-delimited field, also for empty embedded messages
+func appendProtoBytes(b []byte, num int, v []byte) []byte {
+	b = appendUvarint(b, uint64(num)<<3|wireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+
+	return append(b, v...)
+}
+
+// appendUvarint appends the varint encoding of v
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// readProtoFields calls fn for every field of the encoded message. Fields of other wire types
+// than varint and length-delimited are skipped, so that fields added later can be ignored
+func readProtoFields(data []byte, fn func(f protoField) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 || key>>3 > 1<<29-1 {
+			return fmt.Errorf("%w: invalid field key", ErrMalformedEncoding)
+		}
+
+		data = data[n:]
+		field := protoField{num: int(key >> 3)}
+
+		switch key & 7 {
+		case wireVarint:
+			if field.varint, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("%w: invalid varint in field %d", ErrMalformedEncoding, field.num)
+			}
+
+			data = data[n:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("%w: invalid length of field %d", ErrMalformedEncoding, field.num)
+			}
+
+			field.bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if key&7 == wireFixed32 {
+				size = 4
+			}
+
+			if len(data) < size {
+				return fmt.Errorf("%w: truncated field %d", ErrMalformedEncoding, field.num)
+			}
+
+			data = data[size:]
+
+			continue
+		default:
+			return fmt.Errorf("%w: unsupported wire type %d", ErrMalformedEncoding, key&7)
+		}
+
+		if err := fn(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// int returns the value of an integer or enum field
+func (f protoField) int() int {
+	return int(int64(f.varint))
+}
+
+// oid returns the OID of a string field in dotted decimal notation
+func (f protoField) oid() (asn1.ObjectIdentifier, error) {
+	oid, err := parseDottedOID(string(f.bytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed OID in field %d", ErrMalformedEncoding, f.num)
+	}
+
+	return oid, nil
+}
+
+// MarshalBinary encodes the result as the DetectionResult message of cmsdetector.proto. Gob uses
+// the same encoding
+func (r DetectionResult) MarshalBinary() ([]byte, error) {
+	var b []byte
+
+	b = appendProtoString(b, 1, r.Type)
+	b = appendProtoInt(b, 2, int(r.Kind))
+	b = appendProtoOID(b, 3, r.ContentType)
+	b = appendProtoBool(b, 4, r.IsEncrypted)
+	b = appendProtoString(b, 5, r.Provider)
+	b = appendProtoString(b, 6, r.Payload)
+	b = appendProtoBool(b, 7, r.NeedsPassword)
+	b = appendProtoOID(b, 8, r.InnerContentType)
+	b = appendProtoString(b, 9, r.InnerType)
+	b = appendProtoVarint(b, 10, uint64(r.ContentSize))
+
+	if len(r.Header) > 0 {
+		b = appendProtoBytes(b, 11, r.Header)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the DetectionResult message of cmsdetector.proto
+func (r *DetectionResult) UnmarshalBinary(data []byte) error {
+	var result DetectionResult
+
+	err := readProtoFields(
+		data, func(f protoField) (err error) {
+			switch f.num {
+			case 1:
+				result.Type = string(f.bytes)
+			case 2:
+				result.Kind = Kind(f.int())
+			case 3:
+				result.ContentType, err = f.oid()
+			case 4:
+				result.IsEncrypted = f.varint != 0
+			case 5:
+				result.Provider = string(f.bytes)
+			case 6:
+				result.Payload = string(f.bytes)
+			case 7:
+				result.NeedsPassword = f.varint != 0
+			case 8:
+				result.InnerContentType, err = f.oid()
+			case 9:
+				result.InnerType = string(f.bytes)
+			case 10:
+				result.ContentSize = int64(f.varint)
+			case 11:
+				result.Header = append([]byte(nil), f.bytes...)
+			}
+
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	*r = result
+
+	return nil
+}
+
+// MarshalBinary encodes the result as the AnyResult message of cmsdetector.proto. Gob uses
+// the same encoding
+func (r AnyResult) MarshalBinary() ([]byte, error) {
+	var b []byte
+
+	b = appendProtoInt(b, 1, int(r.Family))
+	b = appendProtoInt(b, 2, int(r.Kind))
+	b = appendProtoInt(b, 3, int(r.Confidence))
+	b = appendProtoString(b, 4, r.PEMType)
+	b = appendProtoString(b, 5, r.Rule)
+	b = appendProtoBool(b, 6, r.NeedsPassword)
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the AnyResult message of cmsdetector.proto
+func (r *AnyResult) UnmarshalBinary(data []byte) error {
+	var result AnyResult
+
+	err := readProtoFields(
+		data, func(f protoField) error {
+			switch f.num {
+			case 1:
+				result.Family = Family(f.int())
+			case 2:
+				result.Kind = Kind(f.int())
+			case 3:
+				result.Confidence = Confidence(f.int())
+			case 4:
+				result.PEMType = string(f.bytes)
+			case 5:
+				result.Rule = string(f.bytes)
+			case 6:
+				result.NeedsPassword = f.varint != 0
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	*r = result
+
+	return nil
+}
+
+// MarshalBinary encodes the report as the AlgorithmReport message of cmsdetector.proto. Gob uses
+// the same encoding
+func (r AlgorithmReport) MarshalBinary() ([]byte, error) {
+	var b []byte
+
+	for i, group := range [][]Algorithm{r.Digest, r.Signature, r.KeyEncryption, r.ContentEncryption} {
+		for _, alg := range group {
+			b = appendProtoBytes(b, i+1, alg.appendProto(nil))
+		}
+	}
+
+	for _, finding := range r.Weak {
+		var f []byte
+		if len(finding.Algorithm.OID) > 0 || finding.Algorithm.Name != "" {
+			f = appendProtoBytes(f, 1, finding.Algorithm.appendProto(nil))
+		}
+
+		b = appendProtoBytes(b, 5, appendProtoString(f, 2, finding.Reason))
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the AlgorithmReport message of cmsdetector.proto
+func (r *AlgorithmReport) UnmarshalBinary(data []byte) error {
+	var report AlgorithmReport
+
+	groups := map[int]*[]Algorithm{
+		1: &report.Digest,
+		2: &report.Signature,
+		3: &report.KeyEncryption,
+		4: &report.ContentEncryption,
+	}
+
+	err := readProtoFields(
+		data, func(f protoField) error {
+			if group, ok := groups[f.num]; ok {
+				alg, err := parseProtoAlgorithm(f.bytes)
+				*group = append(*group, alg)
+
+				return err
+			}
+
+			if f.num != 5 {
+				return nil
+			}
+
+			var finding Finding
+
+			err := readProtoFields(
+				f.bytes, func(f protoField) (err error) {
+					switch f.num {
+					case 1:
+						finding.Algorithm, err = parseProtoAlgorithm(f.bytes)
+					case 2:
+						finding.Reason = string(f.bytes)
+					}
+
+					return err
+				},
+			)
+			report.Weak = append(report.Weak, finding)
+
+			return err
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	*r = report
+
+	return nil
+}
+
+// appendProto appends the Algorithm message of cmsdetector.proto
+func (a Algorithm) appendProto(b []byte) []byte {
+	b = appendProtoOID(b, 1, a.OID)
+
+	return appendProtoString(b, 2, a.Name)
+}
+
+// parseProtoAlgorithm decodes the Algorithm message of cmsdetector.proto
+func parseProtoAlgorithm(data []byte) (Algorithm, error) {
+	var alg Algorithm
+
+	err := readProtoFields(
+		data, func(f protoField) (err error) {
+			switch f.num {
+			case 1:
+				alg.OID, err = f.oid()
+			case 2:
+				alg.Name = string(f.bytes)
+			}
+
+			return err
+		},
+	)
+
+	return alg, err
+}
+
+// MarshalBinary encodes the counts as the SignatureCounts message of cmsdetector.proto. Gob uses
+// the same encoding
+func (c SignatureCounts) MarshalBinary() ([]byte, error) {
+	var b []byte
+
+	b = appendProtoInt(b, 1, c.Signers)
+	b = appendProtoInt(b, 2, c.CounterSignatures)
+
+	return b, nil
+}
+
+// UnmarshalBinary decodes the SignatureCounts message of cmsdetector.proto
+func (c *SignatureCounts) UnmarshalBinary(data []byte) error {
+	var counts SignatureCounts
+
+	err := readProtoFields(
+		data, func(f protoField) error {
+			switch f.num {
+			case 1:
+				counts.Signers = f.int()
+			case 2:
+				counts.CounterSignatures = f.int()
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	*c = counts
+
+	return nil
+}
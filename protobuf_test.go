@@ -0,0 +1,217 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/asn1"
+	"encoding/gob"
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// binaryValue is implemented by pointers to the types with a protobuf encoding
+type binaryValue interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// TestMarshalBinaryRoundTrip tests that the protobuf and gob encodings decode to the original value
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	sha1 := Algorithm{OID: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Name: "SHA-1"}
+	aes := Algorithm{OID: asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}, Name: "AES-256-CBC"}
+
+	tests := []struct {
+		name  string
+		value binaryValue
+		empty binaryValue
+	}{
+		{
+			name: "DetectionResult",
+			value: &DetectionResult{
+				Type:             "PKCS#7 Signed Data",
+				Kind:             KindSignedData,
+				ContentType:      PKCS7SignedDataOID,
+				InnerContentType: PKCS7DataOID,
+				InnerType:        "PKCS#7 Data",
+				ContentSize:      1 << 40,
+				Header:           []byte{0x30, 0x82, 0x01, 0x00, 0x06, 0x09},
+				IsEncrypted:      true,
+				NeedsPassword:    true,
+				Provider:         ProviderCryptoPro,
+				Payload:          PayloadAppleConfigurationProfile,
+			},
+			empty: &DetectionResult{},
+		},
+		{
+			name:  "Zero DetectionResult",
+			value: &DetectionResult{},
+			empty: &DetectionResult{},
+		},
+		{
+			name:  "AnyResult",
+			value: &AnyResult{Family: FamilyCustom, Kind: KindUnknown, Confidence: ConfidenceMedium, PEMType: "PKCS7", Rule: "Acme", NeedsPassword: true},
+			empty: &AnyResult{},
+		},
+		{
+			name: "AlgorithmReport",
+			value: &AlgorithmReport{
+				Digest:            []Algorithm{sha1},
+				Signature:         []Algorithm{{OID: cmstest.SHA256WithRSAOID, Name: "SHA-256 with RSA"}},
+				KeyEncryption:     []Algorithm{{OID: rsaEncryptionOID, Name: "RSA"}},
+				ContentEncryption: []Algorithm{aes, {OID: pbkdf2OID, Name: "PBKDF2"}},
+				Weak:              []Finding{{Algorithm: sha1, Reason: reasonWeakDigest}, {Reason: "RSA key too short: 1024 bits"}},
+			},
+			empty: &AlgorithmReport{},
+		},
+		{
+			name:  "SignatureCounts",
+			value: &SignatureCounts{Signers: 2, CounterSignatures: 300},
+			empty: &SignatureCounts{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data, err := tt.value.MarshalBinary()
+				if err != nil {
+					t.Fatalf("MarshalBinary returned an error: %v", err)
+				}
+
+				decoded := reflect.New(reflect.TypeOf(tt.empty).Elem()).Interface().(binaryValue)
+				if err := decoded.UnmarshalBinary(data); err != nil {
+					t.Fatalf("UnmarshalBinary returned an error: %v", err)
+				}
+
+				if !reflect.DeepEqual(decoded, tt.value) {
+					t.Errorf("Expected %+v, got %+v", tt.value, decoded)
+				}
+
+				var buf bytes.Buffer
+				if err := gob.NewEncoder(&buf).Encode(tt.value); err != nil {
+					t.Fatalf("Failed to gob encode: %v", err)
+				}
+
+				if err := gob.NewDecoder(&buf).Decode(tt.empty); err != nil {
+					t.Fatalf("Failed to gob decode: %v", err)
+				}
+
+				if !reflect.DeepEqual(tt.empty, tt.value) {
+					t.Errorf("Expected %+v after gob round trip, got %+v", tt.value, tt.empty)
+				}
+			},
+		)
+	}
+}
+
+// TestMarshalBinaryStable tests the wire encoding of DetectionResult against cmsdetector.proto
+func TestMarshalBinaryStable(t *testing.T) {
+	result := DetectionResult{Type: "Data", Kind: KindSignedData, ContentType: PKCS7SignedDataOID}
+
+	data, err := result.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary returned an error: %v", err)
+	}
+
+	var expected []byte
+	expected = append(expected, 0x0a, 0x04)
+	expected = append(expected, "Data"...)
+	expected = append(expected, 0x10, 0x02, 0x1a, 0x14)
+	expected = append(expected, "1.2.840.113549.1.7.2"...)
+
+	if !bytes.Equal(data, expected) {
+		t.Errorf("Expected %x, got %x", expected, data)
+	}
+}
+
+// TestUnmarshalBinary tests decoding of unknown fields and malformed encodings
+func TestUnmarshalBinary(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		expected  AnyResult
+		expectErr bool
+	}{
+		{
+			name:     "Unknown fields",
+			data:     []byte{0x08, 0x01, 0x78, 0x05, 0x7d, 0, 0, 0, 0, 0x79, 0, 0, 0, 0, 0, 0, 0, 0, 0x82, 0x01, 0x01, 0x41, 0x10, 0x02},
+			expected: AnyResult{Family: FamilyCMS, Kind: KindSignedData},
+		},
+		{name: "Empty", data: nil},
+		{name: "Truncated varint", data: []byte{0x08, 0x80}, expectErr: true},
+		{name: "Truncated string", data: []byte{0x22, 0x05, 0x41}, expectErr: true},
+		{name: "Truncated fixed32", data: []byte{0x7d, 0x00}, expectErr: true},
+		{name: "Field number zero", data: []byte{0x00, 0x01}, expectErr: true},
+		{name: "Group wire type", data: []byte{0x0b}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var result AnyResult
+
+				err := result.UnmarshalBinary(tt.data)
+				if tt.expectErr {
+					if !errors.Is(err, ErrMalformedEncoding) {
+						t.Errorf("Expected ErrMalformedEncoding, got %v", err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("UnmarshalBinary returned an error: %v", err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+
+	var result DetectionResult
+	if err := result.UnmarshalBinary([]byte{0x1a, 0x03, '1', '.', 'x'}); !errors.Is(err, ErrMalformedEncoding) {
+		t.Errorf("Expected ErrMalformedEncoding for a malformed OID, got %v", err)
+	}
+}
+
+// TestProtoEnums tests that cmsdetector.proto defines every kind, family and confidence level
+func TestProtoEnums(t *testing.T) {
+	definition, err := os.ReadFile("cmsdetector.proto")
+	if err != nil {
+		t.Fatalf("Failed to read proto definition: %v", err)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected int
+	}{
+		{prefix: "KIND", expected: len(kindNames)},
+		{prefix: "FAMILY", expected: len(familyNames)},
+		{prefix: "CONFIDENCE", expected: int(ConfidenceHigh) + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.prefix, func(t *testing.T) {
+				values := regexp.MustCompile(`(?m)^\s+`+tt.prefix+`_\w+ = (\d+);$`).FindAllSubmatch(definition, -1)
+				if len(values) != tt.expected {
+					t.Fatalf("Expected %d values, got %d", tt.expected, len(values))
+				}
+
+				for i, value := range values {
+					if n, _ := strconv.Atoi(string(value[1])); n != i {
+						t.Errorf("Expected value %d, got %d", i, n)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,85 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+)
+
+// GOST algorithm OIDs used by CryptoPro CSP (RFC 4357, RFC 9215)
+var (
+	// GOST R 34.11 digest algorithms
+	GOSTR341194OID      = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 9}
+	GOSTR34112012256OID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2}
+	GOSTR34112012512OID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 3}
+
+	// GOST R 34.10 public key and signature algorithms
+	GOSTR34102001OID             = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 19}
+	GOSTR34102012256OID          = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}
+	GOSTR34102012512OID          = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}
+	GOSTR34102001SignatureOID    = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 3}
+	GOSTR34102012256SignatureOID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}
+	GOSTR34102012512SignatureOID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 3}
+
+	// GOST 28147-89 and GOST R 34.12-2015 (Magma, Kuznyechik) encryption algorithms
+	GOST28147OID          = asn1.ObjectIdentifier{1, 2, 643, 2, 2, 21}
+	GOSTMagmaCTROID       = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 1, 1}
+	GOSTMagmaOMACOID      = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 1, 2}
+	GOSTKuznyechikCTROID  = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 2, 1}
+	GOSTKuznyechikOMACOID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 5, 2, 2}
+
+	// GOST R 34.12-2015 key export (key wrap) algorithms
+	GOSTMagmaKExp15OID      = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 7, 1, 1}
+	GOSTKuznyechikKExp15OID = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 7, 2, 1}
+)
+
+// Provider hints reported in DetectionResult
+const (
+	ProviderCryptoPro   = "CryptoPro CSP"
+	ProviderKalkanCrypt = "KalkanCrypt"
+)
+
+// russianArcOID is the root of the Russian national OID arc used by CryptoPro and TC 26
+var russianArcOID = asn1.ObjectIdentifier{1, 2, 643}
+
+// kazakhArcOID is the root of the Kazakhstan national OID arc used by KalkanCrypt
+var kazakhArcOID = asn1.ObjectIdentifier{1, 2, 398}
+
+// hasOIDPrefix checks if the OID lies under the given arc
+func hasOIDPrefix(oid, arc asn1.ObjectIdentifier) bool {
+	if len(oid) < len(arc) {
+		return false
+	}
+
+	return oid[:len(arc)].Equal(arc)
+}
+
+// isGOSTAlgorithm checks if the algorithm OID belongs to the GOST family used by CryptoPro
+func isGOSTAlgorithm(oid asn1.ObjectIdentifier) bool {
+	return hasOIDPrefix(oid, russianArcOID)
+}
+
+// detectProvider returns a hint about the crypto provider that produced the content,
+// or an empty string when the content can be processed with standard algorithms
+func detectProvider(contentInfo ContentInfo) string {
+	report := &AlgorithmReport{}
+	report.addContentInfo(contentInfo)
+
+	for _, oid := range report.all() {
+		if provider := providerOf(oid); provider != "" {
+			return provider
+		}
+	}
+
+	return ""
+}
+
+// providerOf returns the crypto provider implementing the national algorithm OID, if any
+func providerOf(oid asn1.ObjectIdentifier) string {
+	switch {
+	case isGOSTAlgorithm(oid):
+		return ProviderCryptoPro
+	case hasOIDPrefix(oid, kazakhArcOID):
+		return ProviderKalkanCrypt
+	}
+
+	return ""
+}
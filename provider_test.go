@@ -0,0 +1,65 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"testing"
+)
+
+// TestDetectProvider tests the provider hint reported by Detect
+func TestDetectProvider(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	tests := []struct {
+		name             string
+		data             []byte
+		expectedProvider string
+	}{
+		{
+			name:             "GOST R 34.10-2012 signature",
+			data:             createSignedData(t, GOSTR34112012256OID, GOSTR34102012256SignatureOID),
+			expectedProvider: ProviderCryptoPro,
+		},
+		{
+			name:             "GOST 28147-89 encryption",
+			data:             createEnvelopedData(t, GOSTR34102001OID, GOST28147OID),
+			expectedProvider: ProviderCryptoPro,
+		},
+		{
+			name:             "Kuznyechik encryption",
+			data:             createEnvelopedData(t, GOSTKuznyechikKExp15OID, GOSTKuznyechikCTROID),
+			expectedProvider: ProviderCryptoPro,
+		},
+		{
+			name:             "GOST 34.310-2004 signature",
+			data:             createSignedData(t, asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 3, 1}, asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 2}),
+			expectedProvider: ProviderKalkanCrypt,
+		},
+		{
+			name:             "RSA signature",
+			data:             createSignedData(t, sha256OID, rsaOID),
+			expectedProvider: "",
+		},
+		{
+			name:             "AES encryption",
+			data:             createEnvelopedData(t, rsaOID, aesOID),
+			expectedProvider: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := Detect(tt.data)
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.Provider != tt.expectedProvider {
+					t.Errorf("Expected provider %q, got %q", tt.expectedProvider, result.Provider)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,252 @@
+package cmsdetector
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrNotSignedOrEnveloped is returned when the data has neither signers nor recipients
+var ErrNotSignedOrEnveloped = errors.New("not a PKCS#7 signed or enveloped data")
+
+// PublicKey describes a public key visible in a CMS/PKCS structure
+type PublicKey struct {
+	Algorithm Algorithm // Key algorithm, e.g. RSA, EC public key or GOST R 34.10-2012
+	Size      int       // Key size in bits: modulus of RSA and DSA keys, curve size of EC, EdDSA and GOST keys
+	Curve     Algorithm // Named curve of EC keys or parameter set of GOST keys, if any
+}
+
+// PartyKey is the public key of a signer or recipient
+type PartyKey struct {
+	Index      int       // Index of the SignerInfo or RecipientInfo
+	Subject    string    // Subject of the certificate carrying the key, empty for originator keys
+	Key        PublicKey // Public key, unset if Found is false
+	Found      bool      // Indicates if the key is visible in the structure
+	Originator bool      // Indicates the ephemeral originator key of key agreement, which shares the curve of the recipient key
+}
+
+// KeyReport lists the public keys of the signers and recipients of a CMS structure
+type KeyReport struct {
+	Signers    []PartyKey // One entry per SignerInfo
+	Recipients []PartyKey // One entry per recipient key of key transport and key agreement recipients
+}
+
+// rsaPublicKey provides the leading field of a PKCS#1 RSAPublicKey
+type rsaPublicKey struct {
+	N *big.Int
+}
+
+// InspectKeys reports the public keys of the signers of SignedData and SignedAndEnvelopedData and
+// of the recipients of EnvelopedData, AuthEnvelopedData and SignedAndEnvelopedData, with the key
+// size and named curve. Keys are taken from the embedded certificates, signers and recipients
+// whose certificate is not embedded are reported with Found unset. Key agreement recipients
+// without a certificate are reported with the originator key, which uses the same curve. Other
+// content types are rejected with ErrNotSignedOrEnveloped
+func InspectKeys(data []byte) (report *KeyReport, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	report = &KeyReport{}
+
+	switch {
+	case contentInfo.ContentType.Equal(PKCS7SignedDataOID):
+		sd, err := parseSignedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed data: %w", err)
+		}
+
+		report.addSigners(sd.SignerInfos, parseCertificates(sd.Certificates))
+	case contentInfo.ContentType.Equal(PKCS7EnvelopedDataOID):
+		ed, err := parseEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse enveloped data: %w", err)
+		}
+
+		report.addRecipients(ed.RecipientInfos, originatorCertificates(ed.OriginatorInfo))
+	case contentInfo.ContentType.Equal(AuthEnvelopedDataOID):
+		aed, err := parseAuthEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse authenticated enveloped data: %w", err)
+		}
+
+		report.addRecipients(aed.RecipientInfos, originatorCertificates(aed.OriginatorInfo))
+	case contentInfo.ContentType.Equal(PKCS7SignedAndEnvelopedOID):
+		sed, err := parseSignedAndEnvelopedData(contentInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed and enveloped data: %w", err)
+		}
+
+		// PKCS#7 SignerInfo has the layout of CMS SignerInfo identified by issuer and serial number
+		signers := make([]signerInfo, len(sed.SignerInfos))
+		for i, raw := range sed.SignerInfos {
+			if _, err := asn1.Unmarshal(raw.FullBytes, &signers[i]); err != nil {
+				return nil, fmt.Errorf("failed to parse signer info: %w", err)
+			}
+		}
+
+		certs := parseCertificates(sed.Certificates)
+		report.addSigners(signers, certs)
+		report.addRecipients(sed.RecipientInfos, certs)
+	default:
+		return nil, ErrNotSignedOrEnveloped
+	}
+
+	return report, nil
+}
+
+// addSigners reports the keys of the certificates identified by the signers
+func (r *KeyReport) addSigners(signers []signerInfo, certs []*x509.Certificate) {
+	for i, si := range signers {
+		key := PartyKey{Index: i}
+
+		for _, cert := range certs {
+			if matchesCertificate(si.SID, cert) {
+				key.addCertificate(cert)
+				break
+			}
+		}
+
+		r.Signers = append(r.Signers, key)
+	}
+}
+
+// addRecipients reports the keys of the certificates identified by the key transport and key
+// agreement recipients, falling back to the originator key of key agreement
+func (r *KeyReport) addRecipients(recipientInfos []asn1.RawValue, certs []*x509.Certificate) {
+	for i, ri := range recipientInfos {
+		for _, credential := range recipientCredentials(ri) {
+			if credential.Type != CredentialPrivateKey {
+				continue
+			}
+
+			key := PartyKey{Index: i}
+
+			for _, cert := range certs {
+				if credential.MatchesCertificate(cert) {
+					key.addCertificate(cert)
+					break
+				}
+			}
+
+			if !key.Found {
+				if spki, ok := originatorPublicKey(ri); ok {
+					key.Key, key.Found, key.Originator = describePublicKey(spki), true, true
+				}
+			}
+
+			r.Recipients = append(r.Recipients, key)
+		}
+	}
+}
+
+// addCertificate reports the key and subject of the certificate
+func (k *PartyKey) addCertificate(cert *x509.Certificate) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return
+	}
+
+	k.Subject = cert.Subject.String()
+	k.Key = describePublicKey(spki)
+	k.Found = true
+}
+
+// originatorCertificates parses the certificates of the OriginatorInfo of EnvelopedData
+func originatorCertificates(originatorInfo asn1.RawValue) []*x509.Certificate {
+	for rest := originatorInfo.Bytes; len(rest) > 0; {
+		var (
+			field asn1.RawValue
+			err   error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &field); err != nil {
+			return nil
+		}
+
+		if field.Class == asn1.ClassContextSpecific && field.Tag == 0 {
+			return parseCertificates(field)
+		}
+	}
+
+	return nil
+}
+
+// originatorPublicKey returns the originator public key of a KeyAgreeRecipientInfo, if the
+// originator is not identified by a certificate
+func originatorPublicKey(ri asn1.RawValue) (subjectPublicKeyInfo, bool) {
+	if ri.Class != asn1.ClassContextSpecific || ri.Tag != recipientInfoKeyAgree {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	encoded, err := retagSequence(ri)
+	if err != nil {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	var kari keyAgreeRecipientInfo
+	if _, err := asn1.Unmarshal(encoded, &kari); err != nil {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	var originator asn1.RawValue
+	if _, err := asn1.Unmarshal(kari.Originator.Bytes, &originator); err != nil {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	// originatorKey is the implicitly tagged [1] alternative of OriginatorIdentifierOrKey, the
+	// explicit tag of the field is kept by asn1.RawValue
+	if originator.Class != asn1.ClassContextSpecific || originator.Tag != 1 {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	if encoded, err = retagSequence(originator); err != nil {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(encoded, &spki); err != nil {
+		return subjectPublicKeyInfo{}, false
+	}
+
+	return spki, true
+}
+
+// describePublicKey reports the algorithm of the public key with the size and curve found in the
+// algorithm parameters or the key
+func describePublicKey(spki subjectPublicKeyInfo) PublicKey {
+	oid := spki.Algorithm.Algorithm
+	params := spki.Algorithm.Parameters
+
+	key := PublicKey{Algorithm: newAlgorithm(oid), Size: fixedKeySizes[oid.String()]}
+
+	switch {
+	case oid.Equal(rsaEncryptionOID) || oid.Equal(rsaPSSOID):
+		var rsaKey rsaPublicKey
+		if _, err := asn1.Unmarshal(spki.PublicKey.RightAlign(), &rsaKey); err == nil && rsaKey.N != nil {
+			key.Size = rsaKey.N.BitLen()
+		}
+	case oid.Equal(dsaOID):
+		var dsa dsaParameters
+		if _, err := asn1.Unmarshal(params.FullBytes, &dsa); err == nil && dsa.P != nil {
+			key.Size = dsa.P.BitLen()
+		}
+	case oid.Equal(ecPublicKeyOID):
+		if curve, size, ok := namedCurve(params); ok {
+			key.Curve, key.Size = curve, size
+		}
+	case isGOSTAlgorithm(oid) || hasOIDPrefix(oid, kazakhArcOID):
+		key.Curve = gostParamSet(params)
+	}
+
+	return key
+}
@@ -0,0 +1,218 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createSPKI creates a SubjectPublicKeyInfo with DER encoded algorithm parameters
+func createSPKI(t *testing.T, oid asn1.ObjectIdentifier, params interface{}, key []byte) subjectPublicKeyInfo {
+	t.Helper()
+
+	encoded, err := asn1.Marshal(params)
+	if err != nil {
+		t.Fatalf("Failed to marshal key parameters: %v", err)
+	}
+
+	return subjectPublicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: encoded}},
+		PublicKey: asn1.BitString{Bytes: key, BitLength: 8 * len(key)},
+	}
+}
+
+// createIssuerAndSerial identifies the certificate by issuer and serial number
+func createIssuerAndSerial(t *testing.T, der []byte) asn1.RawValue {
+	t.Helper()
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	encoded, err := asn1.Marshal(issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber})
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer and serial number: %v", err)
+	}
+
+	return asn1.RawValue{FullBytes: encoded}
+}
+
+// TestInspectKeys tests the public keys reported for signers and recipients
+func TestInspectKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	ecCert := createCertificate(t, "EC Signer", ecKey)
+
+	modulus, err := asn1.Marshal(struct {
+		N *big.Int
+		E int
+	}{N: new(big.Int).Lsh(big.NewInt(1), 2047), E: 65537})
+	if err != nil {
+		t.Fatalf("Failed to marshal RSA public key: %v", err)
+	}
+
+	rsaCert := createNCACertificate(t, createSPKI(t, rsaEncryptionOID, asn1.NullRawValue, modulus), 0xc0)
+
+	gost512OID := asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}
+	paramSetOID := asn1.ObjectIdentifier{1, 2, 643, 7, 1, 2, 1, 2, 1}
+	gostCert := createNCACertificate(t, createSPKI(t, gost512OID, gostKeyParameters{PublicKeyParamSet: paramSetOID}, make([]byte, 128)), 0xc0)
+
+	p256OID := asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	p256 := PublicKey{Algorithm: newAlgorithm(ecPublicKeyOID), Size: 256, Curve: Algorithm{OID: p256OID, Name: GetOIDDescription(p256OID)}}
+
+	originatorKey := createRecipientInfo(t, 1, createSPKI(t, ecPublicKeyOID, p256OID, make([]byte, 65)))
+	encodedOriginator, err := asn1.Marshal(originatorKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal originator key: %v", err)
+	}
+
+	keys, err := asn1.Marshal([]recipientEncryptedKey{{RID: createIssuerAndSerial(t, ecCert), EncryptedKey: []byte{0x01}}})
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient encrypted keys: %v", err)
+	}
+
+	kari := keyAgreeRecipientInfo{
+		Version:                3,
+		Originator:             asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: encodedOriginator},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 3, 132, 1, 11, 1}},
+		RecipientEncryptedKeys: asn1.RawValue{FullBytes: keys},
+	}
+
+	ktri := keyTransRecipientInfo{
+		RID:                    createIssuerAndSerial(t, rsaCert),
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: rsaEncryptionOID},
+		EncryptedKey:           []byte{0x01},
+	}
+
+	encodedKTRI, err := asn1.Marshal(ktri)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient info: %v", err)
+	}
+
+	certs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rsaCert})
+	if err != nil {
+		t.Fatalf("Failed to marshal originator certificates: %v", err)
+	}
+
+	withOriginatorInfo := createContentInfo(
+		t, PKCS7EnvelopedDataOID, envelopedData{
+			Version:        2,
+			OriginatorInfo: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+			RecipientInfos: []asn1.RawValue{{FullBytes: encodedKTRI}},
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                PKCS7DataOID,
+				ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: cmstest.AES256CBCOID},
+			},
+		},
+	)
+
+	tests := []struct {
+		name               string
+		data               []byte
+		expectedSigners    []PartyKey
+		expectedRecipients []PartyKey
+	}{
+		{
+			name:            "ECDSA signer",
+			data:            cmstest.SignedData(t, cmstest.WithCertificates(ecCert)),
+			expectedSigners: []PartyKey{{Subject: "CN=EC Signer", Key: p256, Found: true}},
+		},
+		{
+			name: "RSA signer",
+			data: cmstest.SignedData(t, cmstest.WithCertificates(rsaCert)),
+			expectedSigners: []PartyKey{
+				{Subject: "CN=ИВАНОВ ИВАН", Key: PublicKey{Algorithm: newAlgorithm(rsaEncryptionOID), Size: 2048}, Found: true},
+			},
+		},
+		{
+			name: "GOST signer",
+			data: cmstest.SignedData(t, cmstest.WithCertificates(gostCert)),
+			expectedSigners: []PartyKey{
+				{
+					Subject: "CN=ИВАНОВ ИВАН",
+					Key:     PublicKey{Algorithm: newAlgorithm(gost512OID), Size: 512, Curve: Algorithm{OID: paramSetOID, Name: GetOIDDescription(paramSetOID)}},
+					Found:   true,
+				},
+			},
+		},
+		{
+			name:            "Signer certificate not embedded",
+			data:            cmstest.SignedData(t),
+			expectedSigners: []PartyKey{{}},
+		},
+		{
+			name:               "Recipient certificate not embedded",
+			data:               cmstest.EnvelopedData(t, cmstest.WithCertificates(ecCert)),
+			expectedRecipients: []PartyKey{{}},
+		},
+		{
+			name: "Recipient certificate in originator info",
+			data: withOriginatorInfo,
+			expectedRecipients: []PartyKey{
+				{Subject: "CN=ИВАНОВ ИВАН", Key: PublicKey{Algorithm: newAlgorithm(rsaEncryptionOID), Size: 2048}, Found: true},
+			},
+		},
+		{
+			name:               "Key agreement originator key",
+			data:               createRecipientsEnvelopedData(t, createRecipientInfo(t, recipientInfoKeyAgree, kari)),
+			expectedRecipients: []PartyKey{{Key: p256, Found: true, Originator: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := InspectKeys(tt.data)
+				if err != nil {
+					t.Fatalf("InspectKeys returned an error: %v", err)
+				}
+
+				assertPartyKeys(t, "signer", tt.expectedSigners, report.Signers)
+				assertPartyKeys(t, "recipient", tt.expectedRecipients, report.Recipients)
+			},
+		)
+	}
+}
+
+// assertPartyKeys compares the reported keys with the expected ones
+func assertPartyKeys(t *testing.T, party string, expected, actual []PartyKey) {
+	t.Helper()
+
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d %s keys, got %d", len(expected), party, len(actual))
+	}
+
+	for i := range expected {
+		e, a := expected[i], actual[i]
+		if a.Index != e.Index || a.Subject != e.Subject || a.Found != e.Found || a.Originator != e.Originator {
+			t.Errorf("Expected %s key %+v, got %+v", party, e, a)
+		}
+
+		if !a.Key.Algorithm.OID.Equal(e.Key.Algorithm.OID) || a.Key.Size != e.Key.Size || !a.Key.Curve.OID.Equal(e.Key.Curve.OID) || a.Key.Curve.Name != e.Key.Curve.Name {
+			t.Errorf("Expected %s key %+v, got %+v", party, e.Key, a.Key)
+		}
+	}
+}
+
+// TestInspectKeysErrors tests InspectKeys with data without signers or recipients
+func TestInspectKeysErrors(t *testing.T) {
+	if _, err := InspectKeys(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedOrEnveloped) {
+		t.Errorf("Expected ErrNotSignedOrEnveloped, got %v", err)
+	}
+
+	if _, err := InspectKeys([]byte{0x01, 0x02, 0x03}); err == nil {
+		t.Error("Expected error for invalid data, got nil")
+	}
+}
@@ -0,0 +1,43 @@
+package cmsdetector
+
+// maxRawHeaderSize limits DetectionResult.Header for structures with long content type OIDs
+const maxRawHeaderSize = 64
+
+// rawHeader returns a copy of the identifier and length octets of the structure of the given size
+// followed by its first element, the content type of a ContentInfo or the version of a PFX
+func rawHeader(read func(offset int64, n int) ([]byte, error), size int64) []byte {
+	w := &derWalker{read: read, ok: true}
+
+	_, offset, end := w.next(0, size)
+	if _, _, end = w.next(offset, end); !w.ok {
+		return nil
+	}
+
+	if end > maxRawHeaderSize {
+		end = maxRawHeaderSize
+	}
+
+	header, err := read(0, int(end))
+	if err != nil {
+		return nil
+	}
+
+	return append([]byte(nil), header...)
+}
+
+// copyRawHeader returns a copy of the leading bytes of a structure, limited to maxRawHeaderSize,
+// so that results do not retain the input
+func copyRawHeader(header []byte) []byte {
+	if len(header) > maxRawHeaderSize {
+		header = header[:maxRawHeaderSize]
+	}
+
+	return append([]byte(nil), header...)
+}
+
+// readBytesAt returns a read function of derWalker for data in memory
+func readBytesAt(data []byte) func(offset int64, n int) ([]byte, error) {
+	return func(offset int64, n int) ([]byte, error) {
+		return data[offset : offset+int64(n)], nil
+	}
+}
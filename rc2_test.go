@@ -0,0 +1,97 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestRC2Cipher tests the RC2 cipher with the test vectors of RFC 2268, section 5
+func TestRC2Cipher(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		effectiveBits int
+		plaintext     string
+		ciphertext    string
+	}{
+		{
+			name:          "63 effective bits",
+			key:           "0000000000000000",
+			effectiveBits: 63,
+			plaintext:     "0000000000000000",
+			ciphertext:    "ebb773f993278eff",
+		},
+		{
+			name:          "64-bit key",
+			key:           "ffffffffffffffff",
+			effectiveBits: 64,
+			plaintext:     "ffffffffffffffff",
+			ciphertext:    "278b27e42e2f0d49",
+		},
+		{
+			name:          "Non-zero plaintext",
+			key:           "3000000000000000",
+			effectiveBits: 64,
+			plaintext:     "1000000000000001",
+			ciphertext:    "30649edf9be7d2c2",
+		},
+		{
+			name:          "8-bit key",
+			key:           "88",
+			effectiveBits: 64,
+			plaintext:     "0000000000000000",
+			ciphertext:    "61a8a244adacccf0",
+		},
+		{
+			name:          "56-bit key",
+			key:           "88bca90e90875a",
+			effectiveBits: 64,
+			plaintext:     "0000000000000000",
+			ciphertext:    "6ccf4308974c267f",
+		},
+		{
+			name:          "128-bit key",
+			key:           "88bca90e90875a7f0f79c384627bafb2",
+			effectiveBits: 128,
+			plaintext:     "0000000000000000",
+			ciphertext:    "2269552ab0f85ca6",
+		},
+		{
+			name:          "264-bit key",
+			key:           "88bca90e90875a7f0f79c384627bafb216f80a6f85920584c42fceb0be255daf1e",
+			effectiveBits: 129,
+			plaintext:     "0000000000000000",
+			ciphertext:    "5b78d3a43dfff1f1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				key, _ := hex.DecodeString(tt.key)
+				plaintext, _ := hex.DecodeString(tt.plaintext)
+				ciphertext, _ := hex.DecodeString(tt.ciphertext)
+
+				block, err := newRC2Cipher(key, tt.effectiveBits)
+				if err != nil {
+					t.Fatalf("newRC2Cipher returned an error: %v", err)
+				}
+
+				encrypted := make([]byte, rc2BlockSize)
+				block.Encrypt(encrypted, plaintext)
+
+				if !bytes.Equal(encrypted, ciphertext) {
+					t.Errorf("Expected ciphertext %x, got %x", ciphertext, encrypted)
+				}
+
+				decrypted := make([]byte, rc2BlockSize)
+				block.Decrypt(decrypted, ciphertext)
+
+				if !bytes.Equal(decrypted, plaintext) {
+					t.Errorf("Expected plaintext %x, got %x", plaintext, decrypted)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,185 @@
+package cmsdetector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lEx0/cmsdetector/core"
+)
+
+// readerAtInlineSize is the size up to which DetectReaderAt reads an element into memory as a whole
+const readerAtInlineSize = 64 << 10
+
+// errTruncatedElement is returned when a DER element extends beyond the end of the input
+var errTruncatedElement = errors.New("DER element extends beyond the end of the input")
+
+// DetectReaderAt determines the type of CMS/PKCS data of the given size stored in r without
+// loading it into memory. Inputs up to 64 KiB are passed to Detect; for larger inputs only the
+// DER headers and small fields are read, skipping the contents of large primitive values such as
+// encapsulated or encrypted content, whose size is taken from its header. MaxInputSize limits the
+// number of bytes read, not the input size
+func DetectReaderAt(r io.ReaderAt, size int64) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	if size < 0 {
+		return DetectionResult{}, fmt.Errorf("invalid input size %d", size)
+	}
+
+	result, err = detectReaderAt(r, size)
+	recordDetection(int(size), result.Kind, result.Kind == KindEncryptedPKCS12, err)
+
+	return result, err
+}
+
+// detectReaderAt determines the type of CMS/PKCS data stored in r without reporting metrics
+func detectReaderAt(r io.ReaderAt, size int64) (DetectionResult, error) {
+	if size <= readerAtInlineSize {
+		data, err := readAtFull(r, 0, int(size))
+		if err != nil {
+			return DetectionResult{}, err
+		}
+
+		return detect(data)
+	}
+
+	skeleton := &derSkeleton{r: r}
+
+	data, _, err := skeleton.element(0, size, 0)
+	if err != nil {
+		return DetectionResult{}, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	result, err := detect(data)
+	if err != nil {
+		return result, err
+	}
+
+	// The skeleton lacks the contents of large values and re-encodes lengths, the content size and
+	// the header are read from the input
+	result.ContentSize, _ = contentSize(skeleton.readAt, size, result.ContentType)
+	result.Header = rawHeader(skeleton.readAt, size)
+
+	return result, nil
+}
+
+// readAtFull reads exactly n bytes at the offset
+func readAtFull(r io.ReaderAt, offset int64, n int) ([]byte, error) {
+	data := make([]byte, n)
+
+	// ReadAt may return io.EOF together with the last bytes of the input
+	if read, err := r.ReadAt(data, offset); read < n {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// derSkeleton rebuilds a DER structure from an io.ReaderAt, replacing the contents of large
+// primitive values with empty ones so the result can be passed to the in-memory parsers
+type derSkeleton struct {
+	r    io.ReaderAt
+	read int // Number of bytes read into the skeleton
+}
+
+// element reads the DER element at the offset, which must end before end, returning
+// its skeleton encoding and the offset of the following element
+func (s *derSkeleton) element(offset, end int64, depth int) ([]byte, int64, error) {
+	if exceedsNestingDepth(depth) {
+		return nil, 0, fmt.Errorf("DER nesting deeper than %d levels", CurrentLimits().MaxNestingDepth)
+	}
+
+	available := end - offset
+	if available > derMaxHeaderSize {
+		available = derMaxHeaderSize
+	}
+
+	header, err := s.readAt(offset, int(available))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tag, headerLen, length, ok := core.ReadHeader(header)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid DER header at offset %d", offset)
+	}
+
+	next := offset + int64(headerLen) + int64(length)
+	if next > end {
+		return nil, 0, errTruncatedElement
+	}
+
+	if next-offset <= readerAtInlineSize {
+		full, err := s.readAt(offset, int(next-offset))
+
+		return full, next, err
+	}
+
+	// Keep the identifier octets, the length octets are re-encoded for the skeleton contents
+	identifier := header[:headerLen-derLengthSize(length)]
+
+	// Only the headers of large primitive values are kept
+	if tag&0x20 == 0 {
+		return appendDERLength(identifier, 0), next, nil
+	}
+
+	var contents []byte
+
+	for child := offset + int64(headerLen); child < next; {
+		encoded, following, err := s.element(child, next, depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		contents = append(contents, encoded...)
+		child = following
+	}
+
+	return append(appendDERLength(identifier, len(contents)), contents...), next, nil
+}
+
+// readAt reads n bytes at the offset, enforcing MaxInputSize on the total number of bytes read
+func (s *derSkeleton) readAt(offset int64, n int) ([]byte, error) {
+	s.read += n
+
+	if limit := CurrentLimits().MaxInputSize; limit > 0 && s.read > limit {
+		return nil, ErrInputTooLarge
+	}
+
+	return readAtFull(s.r, offset, n)
+}
+
+// derLengthSize returns the number of length octets of the DER encoding of the length
+func derLengthSize(length int) int {
+	if length < 0x80 {
+		return 1
+	}
+
+	size := 1
+
+	for ; length > 0; length >>= 8 {
+		size++
+	}
+
+	return size
+}
+
+// appendDERLength appends the DER encoding of the length
+func appendDERLength(b []byte, length int) []byte {
+	if length < 0x80 {
+		return append(b, byte(length))
+	}
+
+	octets := derLengthSize(length) - 1
+	b = append(b, 0x80|byte(octets))
+
+	for i := octets - 1; i >= 0; i-- {
+		b = append(b, byte(length>>(8*i)))
+	}
+
+	return b
+}
@@ -0,0 +1,210 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+// largeContentSize is the size of the content embedded by the large structures created for DetectReaderAt
+const largeContentSize = 1 << 20
+
+// countingReaderAt counts the bytes read through it
+type countingReaderAt struct {
+	r    *bytes.Reader
+	read int
+}
+
+// ReadAt implements io.ReaderAt
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += n
+
+	return n, err
+}
+
+// createLargeSignedData creates ASN.1 encoded SignedData encapsulating large id-data content
+func createLargeSignedData(t *testing.T, digestOID, signatureOID asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	octets, err := asn1.Marshal(make([]byte, largeContentSize))
+	if err != nil {
+		t.Fatalf("Failed to marshal content: %v", err)
+	}
+
+	signer := createSignerInfo(t, digestOID, signatureOID, nil, nil)
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{signer.DigestAlgorithm},
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: PKCS7DataOID,
+			EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets},
+		},
+		SignerInfos: []signerInfo{signer},
+	}
+
+	return createContentInfo(t, PKCS7SignedDataOID, sd)
+}
+
+// createLargeEnvelopedData creates ASN.1 encoded EnvelopedData with large encrypted content
+func createLargeEnvelopedData(t *testing.T, keyEncryptionOID, contentEncryptionOID asn1.ObjectIdentifier) []byte {
+	t.Helper()
+
+	ktri, err := asn1.Marshal(
+		keyTransRecipientInfo{
+			Version:                2,
+			RID:                    asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: []byte{0x01, 0x02}},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: keyEncryptionOID},
+			EncryptedKey:           []byte{0xCA, 0xFE},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal recipient info: %v", err)
+	}
+
+	ed := envelopedData{
+		RecipientInfos: []asn1.RawValue{{FullBytes: ktri}},
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType:                PKCS7DataOID,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: contentEncryptionOID},
+			EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: make([]byte, largeContentSize)},
+		},
+	}
+
+	return createContentInfo(t, PKCS7EnvelopedDataOID, ed)
+}
+
+// TestDetectReaderAt tests that DetectReaderAt matches Detect while reading only the headers of large inputs
+func TestDetectReaderAt(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	tests := []struct {
+		name              string
+		data              []byte
+		expectedKind      Kind
+		expectedProvider  string
+		expectedEncrypted bool
+		expectedSize      int64
+		expectedMaxRead   int
+	}{
+		{
+			name:            "Small signed data",
+			data:            createSignedData(t, sha256OID, rsaOID),
+			expectedKind:    KindSignedData,
+			expectedMaxRead: readerAtInlineSize,
+		},
+		{
+			name:             "Large GOST signed data",
+			data:             createLargeSignedData(t, GOSTR34112012256OID, GOSTR34102012256SignatureOID),
+			expectedKind:     KindSignedData,
+			expectedProvider: ProviderCryptoPro,
+			expectedSize:     largeContentSize,
+			expectedMaxRead:  1024,
+		},
+		{
+			name:              "Large enveloped data",
+			data:              createLargeEnvelopedData(t, rsaOID, aesOID),
+			expectedKind:      KindEnvelopedData,
+			expectedEncrypted: true,
+			expectedSize:      largeContentSize,
+			expectedMaxRead:   1024,
+		},
+		{
+			name:              "Large GOST enveloped data",
+			data:              createLargeEnvelopedData(t, GOSTR34102001OID, GOST28147OID),
+			expectedKind:      KindEnvelopedData,
+			expectedProvider:  ProviderCryptoPro,
+			expectedEncrypted: true,
+			expectedSize:      largeContentSize,
+			expectedMaxRead:   1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				r := &countingReaderAt{r: bytes.NewReader(tt.data)}
+
+				result, err := DetectReaderAt(r, int64(len(tt.data)))
+				if err != nil {
+					t.Fatalf("DetectReaderAt returned an error: %v", err)
+				}
+
+				if result.Kind != tt.expectedKind {
+					t.Errorf("Expected kind %v, got %v", tt.expectedKind, result.Kind)
+				}
+
+				if result.Provider != tt.expectedProvider {
+					t.Errorf("Expected provider %q, got %q", tt.expectedProvider, result.Provider)
+				}
+
+				if result.IsEncrypted != tt.expectedEncrypted {
+					t.Errorf("Expected IsEncrypted %v, got %v", tt.expectedEncrypted, result.IsEncrypted)
+				}
+
+				if detected, _ := Detect(tt.data); !bytes.Equal(result.Header, detected.Header) {
+					t.Errorf("Expected header %x, got %x", detected.Header, result.Header)
+				}
+
+				if result.ContentSize != tt.expectedSize {
+					t.Errorf("Expected content size %d, got %d", tt.expectedSize, result.ContentSize)
+				}
+
+				if r.read > tt.expectedMaxRead {
+					t.Errorf("Expected at most %d bytes to be read, got %d", tt.expectedMaxRead, r.read)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectReaderAtInvalidInput tests DetectReaderAt with truncated and oversized inputs
+func TestDetectReaderAtInvalidInput(t *testing.T) {
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	data := createLargeEnvelopedData(t, rsaOID, aesOID)
+
+	if _, err := DetectReaderAt(bytes.NewReader(data), int64(len(data)-1)); !errors.Is(err, errTruncatedElement) {
+		t.Errorf("Expected error %v, got %v", errTruncatedElement, err)
+	}
+
+	if _, err := DetectReaderAt(bytes.NewReader(data), -1); err == nil {
+		t.Error("Expected error for negative size, got nil")
+	}
+
+	withLimits(t, Limits{MaxInputSize: 64})
+
+	if _, err := DetectReaderAt(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("Expected error %v, got %v", ErrInputTooLarge, err)
+	}
+}
+
+// TestAppendDERLength tests the DER length encoding used by the skeleton
+func TestAppendDERLength(t *testing.T) {
+	tests := []struct {
+		length   int
+		expected []byte
+	}{
+		{length: 0, expected: []byte{0x00}},
+		{length: 0x7f, expected: []byte{0x7f}},
+		{length: 0x80, expected: []byte{0x81, 0x80}},
+		{length: 0x100, expected: []byte{0x82, 0x01, 0x00}},
+		{length: 0x10000, expected: []byte{0x83, 0x01, 0x00, 0x00}},
+	}
+
+	for _, tt := range tests {
+		encoded := appendDERLength(nil, tt.length)
+		if !bytes.Equal(encoded, tt.expected) {
+			t.Errorf("Expected encoding % x of length %d, got % x", tt.expected, tt.length, encoded)
+		}
+
+		if len(encoded) != derLengthSize(tt.length) {
+			t.Errorf("Expected length size %d, got %d", len(encoded), derLengthSize(tt.length))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidOIDRegistration is returned for OID registrations without an OID or a name
+var ErrInvalidOIDRegistration = errors.New("invalid OID registration")
+
+// registeredOIDs holds the OIDs registered by the application, guarded by registeredOIDsMu
+var (
+	registeredOIDsMu sync.RWMutex
+	registeredOIDs   = map[string]OIDInfo{}
+)
+
+// oidRegistryEntry provides the JSON structure of an OID loaded by LoadOIDs
+type oidRegistryEntry struct {
+	Name      string `json:"name"`
+	Reference string `json:"reference"`
+	Category  string `json:"category"`
+	Kind      string `json:"kind"`
+}
+
+// RegisterOID adds or replaces the description of an OID. Registered descriptions take precedence
+// over the built-in table, and content types registered with a Kind are reported by Detect.
+// It is safe for concurrent use
+func RegisterOID(oid asn1.ObjectIdentifier, info OIDInfo) error {
+	if len(oid) < 2 || info.Name == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidOIDRegistration, oid.String())
+	}
+
+	registeredOIDsMu.Lock()
+	defer registeredOIDsMu.Unlock()
+
+	registeredOIDs[oid.String()] = info
+
+	return nil
+}
+
+// LoadOIDs registers the OIDs of a JSON object mapping dotted OIDs to descriptions, e.g.
+//
+//	{"1.2.398.3.10.1.99": {"name": "Local document", "category": "content type", "kind": "PKCS#7 Signed Data"}}
+//
+// The kind is one of the Kind names. Nothing is registered if any entry is invalid
+func LoadOIDs(r io.Reader) error {
+	var entries map[string]oidRegistryEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("failed to decode OID registry: %w", err)
+	}
+
+	parsed := make(map[string]OIDInfo, len(entries))
+
+	for dotted, entry := range entries {
+		oid, err := parseDottedOID(dotted)
+		if err != nil {
+			return err
+		}
+
+		kind, ok := kindByName(entry.Kind)
+		if !ok {
+			return fmt.Errorf("%w: %s: unknown kind %q", ErrInvalidOIDRegistration, dotted, entry.Kind)
+		}
+
+		if entry.Name == "" {
+			return fmt.Errorf("%w: %s: missing name", ErrInvalidOIDRegistration, dotted)
+		}
+
+		parsed[oid.String()] = OIDInfo{
+			Name:      entry.Name,
+			Reference: entry.Reference,
+			Category:  OIDCategory(entry.Category),
+			Kind:      kind,
+		}
+	}
+
+	registeredOIDsMu.Lock()
+	defer registeredOIDsMu.Unlock()
+
+	for oid, info := range parsed {
+		registeredOIDs[oid] = info
+	}
+
+	return nil
+}
+
+// lookupRegisteredOID returns the description of an OID registered by the application
+func lookupRegisteredOID(oid asn1.ObjectIdentifier) (OIDInfo, bool) {
+	registeredOIDsMu.RLock()
+	defer registeredOIDsMu.RUnlock()
+
+	info, ok := registeredOIDs[oid.String()]
+
+	return info, ok
+}
+
+// parseDottedOID parses an OID in dotted decimal notation
+func parseDottedOID(dotted string) (asn1.ObjectIdentifier, error) {
+	arcs := strings.Split(dotted, ".")
+	if len(arcs) < 2 {
+		return nil, fmt.Errorf("%w: malformed OID %q", ErrInvalidOIDRegistration, dotted)
+	}
+
+	oid := make(asn1.ObjectIdentifier, len(arcs))
+
+	for i, arc := range arcs {
+		n, err := strconv.Atoi(arc)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%w: malformed OID %q", ErrInvalidOIDRegistration, dotted)
+		}
+
+		oid[i] = n
+	}
+
+	return oid, nil
+}
+
+// kindByName returns the kind with the given name, or KindUnknown for an empty name
+func kindByName(name string) (Kind, bool) {
+	if name == "" {
+		return KindUnknown, true
+	}
+
+	for kind, kindName := range kindNames {
+		if kindName == name {
+			return kind, true
+		}
+	}
+
+	return KindUnknown, false
+}
@@ -0,0 +1,147 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// unregisterOIDs removes the given OIDs from the application registry when the test finishes
+func unregisterOIDs(t *testing.T, oids ...string) {
+	t.Helper()
+
+	t.Cleanup(
+		func() {
+			registeredOIDsMu.Lock()
+			defer registeredOIDsMu.Unlock()
+
+			for _, oid := range oids {
+				delete(registeredOIDs, oid)
+			}
+		},
+	)
+}
+
+// TestRegisterOID tests detection of content types registered by the application
+func TestRegisterOID(t *testing.T) {
+	oid := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 99, 1}
+	unregisterOIDs(t, oid.String())
+
+	data := createContentInfo(t, oid, []byte{0x01})
+
+	result, err := Detect(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Kind != KindUnknown {
+		t.Fatalf("Expected kind %s before registration, got %s", KindUnknown, result.Kind)
+	}
+
+	err = RegisterOID(oid, OIDInfo{Name: "Local Signed Document", Category: OIDCategoryContentType, Kind: KindSignedData})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err = Detect(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Kind != KindSignedData {
+		t.Errorf("Expected kind %s, got %s", KindSignedData, result.Kind)
+	}
+
+	if description := GetOIDDescription(oid); description != "Local Signed Document" {
+		t.Errorf("Expected description %s, got %s", "Local Signed Document", description)
+	}
+}
+
+// TestRegisterOIDInvalid tests rejection of incomplete registrations
+func TestRegisterOIDInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		oid  asn1.ObjectIdentifier
+		info OIDInfo
+	}{
+		{
+			name: "Missing OID",
+			info: OIDInfo{Name: "Name"},
+		},
+		{
+			name: "Missing name",
+			oid:  asn1.ObjectIdentifier{1, 2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if err := RegisterOID(tt.oid, tt.info); !errors.Is(err, ErrInvalidOIDRegistration) {
+					t.Errorf("Expected error %v, got %v", ErrInvalidOIDRegistration, err)
+				}
+			},
+		)
+	}
+}
+
+// TestLoadOIDs tests registration of OIDs from JSON
+func TestLoadOIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		expectedErr error
+		expected    map[string]OIDInfo
+	}{
+		{
+			name: "Valid registry",
+			json: `{
+				"1.2.398.3.10.99.2": {"name": "Local Envelope", "reference": "ST RK 1073", "category": "content type", "kind": "PKCS#7 Enveloped Data"},
+				"1.2.398.3.10.99.3": {"name": "Local Policy", "category": "certificate policy"}
+			}`,
+			expected: map[string]OIDInfo{
+				"1.2.398.3.10.99.2": {Name: "Local Envelope", Reference: "ST RK 1073", Category: OIDCategoryContentType, Kind: KindEnvelopedData},
+				"1.2.398.3.10.99.3": {Name: "Local Policy", Category: OIDCategoryPolicy},
+			},
+		},
+		{
+			name:        "Malformed OID",
+			json:        `{"1.2.x": {"name": "Name"}}`,
+			expectedErr: ErrInvalidOIDRegistration,
+		},
+		{
+			name:        "Unknown kind",
+			json:        `{"1.2.398.3.10.99.4": {"name": "Name", "kind": "Unknown Kind"}}`,
+			expectedErr: ErrInvalidOIDRegistration,
+		},
+		{
+			name:        "Missing name",
+			json:        `{"1.2.398.3.10.99.5": {"kind": "PKCS#7 Data"}}`,
+			expectedErr: ErrInvalidOIDRegistration,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				unregisterOIDs(t, "1.2.398.3.10.99.2", "1.2.398.3.10.99.3", "1.2.398.3.10.99.4", "1.2.398.3.10.99.5")
+
+				if err := LoadOIDs(strings.NewReader(tt.json)); !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				for dotted, expected := range tt.expected {
+					oid, err := parseDottedOID(dotted)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+
+					if info, ok := LookupOID(oid); !ok || info != expected {
+						t.Errorf("Expected %+v for %s, got %+v", expected, dotted, info)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,274 @@
+package cmsdetector
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Statuses of certificates in OCSP responses (RFC 6960, section 4.2.1)
+const (
+	OCSPStatusGood = iota
+	OCSPStatusRevoked
+	OCSPStatusUnknown
+)
+
+// OIDs of OCSP response types and CRL extensions
+var (
+	ocspBasicResponseOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	crlNumberOID         = asn1.ObjectIdentifier{2, 5, 29, 20}
+)
+
+// crlSignatureAlgorithms maps the signature algorithms of CRLs to their crypto/x509 values
+var crlSignatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"1.2.840.113549.1.1.5":  x509.SHA1WithRSA,
+	"1.2.840.113549.1.1.11": x509.SHA256WithRSA,
+	"1.2.840.113549.1.1.12": x509.SHA384WithRSA,
+	"1.2.840.113549.1.1.13": x509.SHA512WithRSA,
+	"1.2.840.10045.4.1":     x509.ECDSAWithSHA1,
+	"1.2.840.10045.4.3.2":   x509.ECDSAWithSHA256,
+	"1.2.840.10045.4.3.3":   x509.ECDSAWithSHA384,
+	"1.2.840.10045.4.3.4":   x509.ECDSAWithSHA512,
+	"1.3.101.112":           x509.PureEd25519,
+}
+
+// OCSPResponse is an OCSP response embedded in SignedData
+type OCSPResponse struct {
+	Raw        []byte // DER encoded BasicOCSPResponse, responses of the crls field are unwrapped
+	ProducedAt time.Time
+	Responses  []OCSPSingleResponse
+}
+
+// OCSPSingleResponse is the status of a certificate in an OCSP response
+type OCSPSingleResponse struct {
+	SerialNumber *big.Int
+	Status       int       // OCSPStatusGood, OCSPStatusRevoked or OCSPStatusUnknown
+	RevokedAt    time.Time // Revocation time of revoked certificates
+	ThisUpdate   time.Time
+	NextUpdate   time.Time // Zero if the responder did not set it
+}
+
+// ocspResponse provides the ASN.1 structure of OCSPResponse (RFC 6960, section 4.2.1)
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseBytes provides the ASN.1 structure of the typed response of an OCSPResponse
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+// basicOCSPResponse provides the ASN.1 structure of BasicOCSPResponse (RFC 6960, section 4.2.1)
+type basicOCSPResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspResponseData provides the ASN.1 structure of ResponseData
+type ocspResponseData struct {
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+	Extensions     []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// ocspSingleResponse provides the ASN.1 structure of SingleResponse
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+// ocspCertID provides the ASN.1 structure of CertID
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// ocspRevokedInfo provides the ASN.1 structure of RevokedInfo
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// ExtractRevocationData returns the CRLs and OCSP responses embedded in the crls field of SignedData
+// and in the revocation-values attributes of its signers, for validators prefetching revocation
+// information. The CRLs carry the fields parsed by the standard library of Go 1.18, their
+// extensions are returned in ExtraExtensions
+func ExtractRevocationData(data []byte) (crls []x509.RevocationList, responses []OCSPResponse, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawCRLs, rawResponses := revocationInfoChoices(sd.CRLs)
+
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.UnsignedAttrs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse unsigned attributes: %w", err)
+		}
+
+		for _, value := range attributeValues(attrs, RevocationValuesAttributeOID) {
+			var values revocationValues
+			if _, err := asn1.Unmarshal(value.FullBytes, &values); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse revocation values: %w", err)
+			}
+
+			for _, crl := range values.CRLVals {
+				rawCRLs = append(rawCRLs, crl.FullBytes)
+			}
+
+			for _, response := range values.OCSPVals {
+				rawResponses = append(rawResponses, response.FullBytes)
+			}
+		}
+	}
+
+	for _, der := range rawCRLs {
+		crl, err := parseCRL(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse CRL: %w", err)
+		}
+
+		crls = append(crls, crl)
+	}
+
+	for _, der := range rawResponses {
+		response, err := parseBasicOCSPResponse(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse OCSP response: %w", err)
+		}
+
+		responses = append(responses, response)
+	}
+
+	return crls, responses, nil
+}
+
+// revocationInfoChoices returns the DER encoded CRLs and BasicOCSPResponses of RevocationInfoChoices
+func revocationInfoChoices(raw asn1.RawValue) (crls, responses [][]byte) {
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			choice asn1.RawValue
+			err    error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &choice); err != nil {
+			break
+		}
+
+		switch {
+		case choice.Class == asn1.ClassUniversal && choice.Tag == asn1.TagSequence:
+			crls = append(crls, choice.FullBytes)
+		case choice.Class == asn1.ClassContextSpecific && choice.Tag == 1:
+			if response, ok := otherRevocationOCSPResponse(choice); ok {
+				responses = append(responses, response)
+			}
+		}
+	}
+
+	return crls, responses
+}
+
+// otherRevocationOCSPResponse returns the BasicOCSPResponse of an id-ri-ocsp-response OtherRevocationInfoFormat
+func otherRevocationOCSPResponse(choice asn1.RawValue) ([]byte, bool) {
+	encoded, err := retagSequence(choice)
+	if err != nil {
+		return nil, false
+	}
+
+	var other otherRevocationInfoFormat
+	if _, err := asn1.Unmarshal(encoded, &other); err != nil || !other.OtherRevInfoFormat.Equal(ocspResponseRevocationInfoOID) {
+		return nil, false
+	}
+
+	var response ocspResponse
+	if _, err := asn1.Unmarshal(other.OtherRevInfo.FullBytes, &response); err != nil ||
+		!response.ResponseBytes.ResponseType.Equal(ocspBasicResponseOID) {
+		return nil, false
+	}
+
+	return response.ResponseBytes.Response, true
+}
+
+// parseCRL parses a DER encoded CertificateList into the fields of x509.RevocationList available in Go 1.18
+func parseCRL(der []byte) (x509.RevocationList, error) {
+	list, err := x509.ParseDERCRL(der)
+	if err != nil {
+		return x509.RevocationList{}, err
+	}
+
+	tbs := list.TBSCertList
+	crl := x509.RevocationList{
+		SignatureAlgorithm:  crlSignatureAlgorithms[list.SignatureAlgorithm.Algorithm.String()],
+		RevokedCertificates: tbs.RevokedCertificates,
+		ThisUpdate:          tbs.ThisUpdate,
+		NextUpdate:          tbs.NextUpdate,
+		ExtraExtensions:     tbs.Extensions,
+	}
+
+	for _, ext := range tbs.Extensions {
+		if ext.Id.Equal(crlNumberOID) {
+			number := new(big.Int)
+			if _, err := asn1.Unmarshal(ext.Value, &number); err == nil {
+				crl.Number = number
+			}
+		}
+	}
+
+	return crl, nil
+}
+
+// parseBasicOCSPResponse parses a DER encoded BasicOCSPResponse
+func parseBasicOCSPResponse(der []byte) (OCSPResponse, error) {
+	var basic basicOCSPResponse
+	if _, err := asn1.Unmarshal(der, &basic); err != nil {
+		return OCSPResponse{}, err
+	}
+
+	if len(basic.TBSResponseData.Responses) == 0 {
+		return OCSPResponse{}, errors.New("no responses")
+	}
+
+	response := OCSPResponse{Raw: der, ProducedAt: basic.TBSResponseData.ProducedAt}
+
+	for _, single := range basic.TBSResponseData.Responses {
+		status := OCSPSingleResponse{
+			SerialNumber: single.CertID.SerialNumber,
+			ThisUpdate:   single.ThisUpdate,
+			NextUpdate:   single.NextUpdate,
+		}
+
+		switch {
+		case bool(single.Good):
+			status.Status = OCSPStatusGood
+		case bool(single.Unknown):
+			status.Status = OCSPStatusUnknown
+		default:
+			status.Status = OCSPStatusRevoked
+			status.RevokedAt = single.Revoked.RevocationTime
+		}
+
+		response.Responses = append(response.Responses, status)
+	}
+
+	return response, nil
+}
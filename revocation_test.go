@@ -0,0 +1,218 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createCRL creates a DER encoded CRL revoking the serial number
+func createCRL(t *testing.T, serial int64) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CRL Issuer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCRLSign | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	issuer, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	crl, err := x509.CreateRevocationList(
+		rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(7),
+			ThisUpdate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			NextUpdate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			RevokedCertificates: []pkix.RevokedCertificate{
+				{SerialNumber: big.NewInt(serial), RevocationTime: time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		}, issuer, key,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create CRL: %v", err)
+	}
+
+	return crl
+}
+
+// createBasicOCSPResponse creates a DER encoded BasicOCSPResponse with a good status for the serial number
+func createBasicOCSPResponse(t *testing.T, serial int64) []byte {
+	t.Helper()
+
+	certID, err := asn1.Marshal(
+		ocspCertID{
+			HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: cmstest.SHA1OID},
+			IssuerNameHash: make([]byte, 20),
+			IssuerKeyHash:  make([]byte, 20),
+			SerialNumber:   big.NewInt(serial),
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal CertID: %v", err)
+	}
+
+	thisUpdate, err := asn1.MarshalWithParams(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "generalized")
+	if err != nil {
+		t.Fatalf("Failed to marshal time: %v", err)
+	}
+
+	// The good status is an implicitly tagged NULL
+	single := append(append(certID, 0x80, 0x00), thisUpdate...)
+
+	responseData := struct {
+		ResponderID asn1.RawValue
+		ProducedAt  time.Time `asn1:"generalized"`
+		Responses   []asn1.RawValue
+	}{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: []byte{0x04, 0x01, 0x00}},
+		ProducedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Responses:   []asn1.RawValue{{Tag: asn1.TagSequence, IsCompound: true, Bytes: single}},
+	}
+
+	der, err := asn1.Marshal(
+		struct {
+			TBSResponseData    interface{}
+			SignatureAlgorithm pkix.AlgorithmIdentifier
+			Signature          asn1.BitString
+		}{
+			TBSResponseData:    responseData,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: cmstest.SHA256WithRSAOID},
+			Signature:          asn1.BitString{Bytes: []byte{0xDE, 0xAD}, BitLength: 16},
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal BasicOCSPResponse: %v", err)
+	}
+
+	return der
+}
+
+// createOCSPRevocationInfo wraps a BasicOCSPResponse in an id-ri-ocsp-response RevocationInfoChoice (RFC 5940)
+func createOCSPRevocationInfo(t *testing.T, basic []byte) []byte {
+	t.Helper()
+
+	response, err := asn1.Marshal(
+		ocspResponse{ResponseBytes: ocspResponseBytes{ResponseType: ocspBasicResponseOID, Response: basic}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal OCSPResponse: %v", err)
+	}
+
+	other, err := asn1.Marshal(
+		otherRevocationInfoFormat{OtherRevInfoFormat: ocspResponseRevocationInfoOID, OtherRevInfo: asn1.RawValue{FullBytes: response}},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal OtherRevocationInfoFormat: %v", err)
+	}
+
+	// Retag the SEQUENCE as [1] IMPLICIT
+	other[0] = 0xa1
+
+	return other
+}
+
+// TestExtractRevocationData tests the extraction of CRLs and OCSP responses from SignedData
+func TestExtractRevocationData(t *testing.T) {
+	basic := createBasicOCSPResponse(t, 42)
+
+	revocationVals := revocationValues{
+		CRLVals:  []asn1.RawValue{{FullBytes: createCRL(t, 43)}},
+		OCSPVals: []asn1.RawValue{{FullBytes: basic}},
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		crls      []int64
+		responses []int64
+		expectErr bool
+	}{
+		{
+			name: "No revocation data",
+			data: createSignedDataWithCRLs(t, nil),
+		},
+		{
+			name:      "crls field",
+			data:      createSignedDataWithCRLs(t, nil, createCRL(t, 41), createOCSPRevocationInfo(t, basic)),
+			crls:      []int64{41},
+			responses: []int64{42},
+		},
+		{
+			name:      "revocation-values attribute",
+			data:      createSignedDataWithCRLs(t, []attribute{createAttribute(t, RevocationValuesAttributeOID, revocationVals)}),
+			crls:      []int64{43},
+			responses: []int64{42},
+		},
+		{
+			name:      "Malformed CRL",
+			data:      createSignedDataWithCRLs(t, nil, []byte{0x30, 0x02, 0x05, 0x00}),
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				crls, responses, err := ExtractRevocationData(tt.data)
+				if (err != nil) != tt.expectErr {
+					t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+				}
+
+				if len(crls) != len(tt.crls) || len(responses) != len(tt.responses) {
+					t.Fatalf("Expected %d CRLs and %d OCSP responses, got %d and %d", len(tt.crls), len(tt.responses), len(crls), len(responses))
+				}
+
+				for i, crl := range crls {
+					if crl.Number == nil || crl.Number.Int64() != 7 || crl.SignatureAlgorithm != x509.ECDSAWithSHA256 {
+						t.Errorf("Expected CRL number 7 signed with ECDSA-SHA256, got %v and %v", crl.Number, crl.SignatureAlgorithm)
+					}
+
+					if len(crl.RevokedCertificates) != 1 || crl.RevokedCertificates[0].SerialNumber.Int64() != tt.crls[i] {
+						t.Errorf("Expected revoked serial %d, got %v", tt.crls[i], crl.RevokedCertificates)
+					}
+				}
+
+				for i, response := range responses {
+					if len(response.Responses) != 1 || response.Responses[0].SerialNumber.Int64() != tt.responses[i] {
+						t.Fatalf("Expected a response for serial %d, got %+v", tt.responses[i], response.Responses)
+					}
+
+					if response.Responses[0].Status != OCSPStatusGood {
+						t.Errorf("Expected good status, got %d", response.Responses[0].Status)
+					}
+				}
+			},
+		)
+	}
+
+	if _, _, err := ExtractRevocationData(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+}
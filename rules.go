@@ -0,0 +1,314 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidRule is returned for detection rules that cannot be compiled
+var ErrInvalidRule = errors.New("invalid detection rule")
+
+// Rule is a user-defined detection rule, e.g. for in-house container formats
+type Rule struct {
+	Name      string    `json:"name"`
+	Condition Condition `json:"condition"`
+}
+
+// Condition describes data matched by a rule. A condition matches when all of its fields match,
+// at least one field must be set
+type Condition struct {
+	Bytes   string      `json:"bytes,omitempty"`    // Hex byte pattern, "??" matches any byte
+	Offset  *int        `json:"offset,omitempty"`   // Offset of the byte pattern, anywhere in the scan window if nil
+	OID     string      `json:"oid,omitempty"`      // Dotted OID whose DER encoding is present in the scan window
+	MinSize int         `json:"min_size,omitempty"` // Minimum size of the data in bytes
+	MaxSize int         `json:"max_size,omitempty"` // Maximum size of the data in bytes
+	All     []Condition `json:"all,omitempty"`      // Conditions that must all match
+	Any     []Condition `json:"any,omitempty"`      // Conditions of which at least one must match
+	Not     *Condition  `json:"not,omitempty"`      // Condition that must not match
+}
+
+// RuleSet holds compiled detection rules, it is safe for concurrent use
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// compiledRule is a rule with its compiled condition
+type compiledRule struct {
+	name      string
+	condition *matcher
+}
+
+// matcher is a compiled Condition
+type matcher struct {
+	pattern []byte
+	mask    []byte // 0x00 for wildcard bytes of the pattern
+	offset  int    // -1 to search the pattern in the scan window
+	oid     []byte // DER encoding of the OID
+	minSize int
+	maxSize int
+	all     []*matcher
+	any     []*matcher
+	not     *matcher
+}
+
+// CompileRules validates and compiles the rules. Rules are matched in the given order
+func CompileRules(rules []Rule) (*RuleSet, error) {
+	set := &RuleSet{rules: make([]compiledRule, 0, len(rules))}
+
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("%w: missing name", ErrInvalidRule)
+		}
+
+		m, err := compileCondition(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidRule, rule.Name, err)
+		}
+
+		set.rules = append(set.rules, compiledRule{name: rule.Name, condition: m})
+	}
+
+	return set, nil
+}
+
+// LoadRules compiles the rules of a JSON array, e.g.
+//
+//	[{"name": "Acme container", "condition": {"all": [
+//	    {"bytes": "41 43 4d 45 ?? 01", "offset": 0},
+//	    {"any": [{"oid": "1.3.6.1.4.1.99999.1"}, {"max_size": 4096}]}
+//	]}}]
+func LoadRules(r io.Reader) (*RuleSet, error) {
+	var rules []Rule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to decode detection rules: %w", err)
+	}
+
+	return CompileRules(rules)
+}
+
+// Match returns the names of all rules matching the data
+func (s *RuleSet) Match(data []byte) []string {
+	var names []string
+
+	for _, rule := range s.rules {
+		if rule.condition.match(data) {
+			names = append(names, rule.name)
+		}
+	}
+
+	return names
+}
+
+// first returns the name of the first rule matching the data
+func (s *RuleSet) first(data []byte) (string, bool) {
+	for _, rule := range s.rules {
+		if rule.condition.match(data) {
+			return rule.name, true
+		}
+	}
+
+	return "", false
+}
+
+// annotate reports the first rule matching the data in a DetectAny result. Data of unknown format
+// matching a rule is reported as FamilyCustom
+func (s *RuleSet) annotate(data []byte, result AnyResult, err error) (AnyResult, error) {
+	if err != nil && !errors.Is(err, ErrUnknownFormat) {
+		return result, err
+	}
+
+	name, ok := s.first(data)
+	if !ok {
+		return result, err
+	}
+
+	if err != nil {
+		return AnyResult{Family: FamilyCustom, Kind: KindUnknown, Confidence: ConfidenceMedium, Rule: name}, nil
+	}
+
+	result.Rule = name
+
+	return result, nil
+}
+
+// compileCondition validates and compiles the condition and its nested conditions
+func compileCondition(c Condition) (*matcher, error) {
+	m := &matcher{offset: -1, minSize: c.MinSize, maxSize: c.MaxSize}
+	empty := c.MinSize == 0 && c.MaxSize == 0
+
+	if c.MinSize < 0 || c.MaxSize < 0 || c.MaxSize > 0 && c.MaxSize < c.MinSize {
+		return nil, fmt.Errorf("invalid size range %d-%d", c.MinSize, c.MaxSize)
+	}
+
+	if c.Bytes != "" {
+		pattern, mask, err := parseBytePattern(c.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		m.pattern, m.mask, empty = pattern, mask, false
+	}
+
+	if c.Offset != nil {
+		if c.Bytes == "" || *c.Offset < 0 {
+			return nil, fmt.Errorf("offset %d requires a byte pattern and must not be negative", *c.Offset)
+		}
+
+		m.offset = *c.Offset
+	}
+
+	if c.OID != "" {
+		oid, err := parseDottedOID(c.OID)
+		if err == nil {
+			m.oid, err = asn1.Marshal(oid)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("malformed OID %q", c.OID)
+		}
+
+		empty = false
+	}
+
+	var err error
+
+	if m.all, err = compileConditions(c.All); err != nil {
+		return nil, err
+	}
+
+	if m.any, err = compileConditions(c.Any); err != nil {
+		return nil, err
+	}
+
+	if len(m.all) > 0 || len(m.any) > 0 {
+		empty = false
+	}
+
+	if c.Not != nil {
+		if m.not, err = compileCondition(*c.Not); err != nil {
+			return nil, err
+		}
+
+		empty = false
+	}
+
+	if empty {
+		return nil, errors.New("empty condition")
+	}
+
+	return m, nil
+}
+
+// compileConditions compiles the nested conditions of a combination
+func compileConditions(conditions []Condition) ([]*matcher, error) {
+	var matchers []*matcher
+
+	for _, c := range conditions {
+		m, err := compileCondition(c)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
+
+// parseBytePattern parses a hex byte pattern with "??" wildcards, whitespace is ignored
+func parseBytePattern(s string) ([]byte, []byte, error) {
+	digits := strings.Join(strings.Fields(s), "")
+	if len(digits)%2 != 0 {
+		return nil, nil, fmt.Errorf("odd length byte pattern %q", s)
+	}
+
+	pattern := make([]byte, len(digits)/2)
+	mask := make([]byte, len(pattern))
+
+	for i := range pattern {
+		pair := digits[2*i : 2*i+2]
+		if pair == "??" {
+			continue
+		}
+
+		b, err := hex.DecodeString(pair)
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed byte pattern %q", s)
+		}
+
+		pattern[i], mask[i] = b[0], 0xff
+	}
+
+	return pattern, mask, nil
+}
+
+// match checks if the data matches all fields of the condition
+func (m *matcher) match(data []byte) bool {
+	if len(data) < m.minSize || m.maxSize > 0 && len(data) > m.maxSize {
+		return false
+	}
+
+	if m.pattern != nil && !m.matchPattern(data) {
+		return false
+	}
+
+	if m.oid != nil && !bytes.Contains(scanWindow(data), m.oid) {
+		return false
+	}
+
+	for _, nested := range m.all {
+		if !nested.match(data) {
+			return false
+		}
+	}
+
+	if m.any != nil && !m.matchAny(data) {
+		return false
+	}
+
+	return m.not == nil || !m.not.match(data)
+}
+
+// matchAny checks if the data matches at least one of the alternative conditions
+func (m *matcher) matchAny(data []byte) bool {
+	for _, nested := range m.any {
+		if nested.match(data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPattern checks if the byte pattern occurs at the offset, or anywhere in the scan window
+func (m *matcher) matchPattern(data []byte) bool {
+	if m.offset >= 0 {
+		return m.offset <= len(data)-len(m.pattern) && m.matchPatternAt(data, m.offset)
+	}
+
+	window := scanWindow(data)
+	for i := 0; i <= len(window)-len(m.pattern); i++ {
+		if m.matchPatternAt(window, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPatternAt checks if the byte pattern occurs at the position
+func (m *matcher) matchPatternAt(data []byte, pos int) bool {
+	for i, b := range m.pattern {
+		if data[pos+i]&m.mask[i] != b {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,141 @@
+package cmsdetector
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// testRules provides detection rules for an in-house container format and its signed variant
+const testRules = `[
+	{"name": "Acme container", "condition": {"all": [
+		{"bytes": "41 43 4d 45 ?? 01", "offset": 0},
+		{"min_size": 8, "max_size": 64}
+	]}},
+	{"name": "Acme v1 or v2", "condition": {"any": [
+		{"bytes": "41434d45??01", "offset": 0},
+		{"bytes": "41434d45??02", "offset": 0}
+	]}},
+	{"name": "Not Acme", "condition": {"not": {"bytes": "41434d45"}}},
+	{"name": "SHA-256 signed", "condition": {"oid": "2.16.840.1.101.3.4.2.1"}}
+]`
+
+// loadTestRules loads the test detection rules
+func loadTestRules(t *testing.T) *RuleSet {
+	t.Helper()
+
+	rules, err := LoadRules(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+
+	return rules
+}
+
+// TestRuleSetMatch tests the rules matching the data
+func TestRuleSetMatch(t *testing.T) {
+	rules := loadTestRules(t)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{name: "Acme v1", data: []byte("ACME\x00\x01 container"), expected: []string{"Acme container", "Acme v1 or v2"}},
+		{name: "Acme v2", data: []byte("ACME\xff\x02 container"), expected: []string{"Acme v1 or v2"}},
+		{name: "Acme v1 too large", data: append([]byte("ACME\x00\x01"), make([]byte, 64)...), expected: []string{"Acme v1 or v2"}},
+		{name: "Acme v1 too small", data: []byte("ACME\x00\x01"), expected: []string{"Acme v1 or v2"}},
+		{name: "Acme at other offset", data: []byte("XACME\x00\x01 container"), expected: nil},
+		{name: "Other data", data: []byte("other data"), expected: []string{"Not Acme"}},
+		{name: "SignedData", data: cmstest.SignedData(t), expected: []string{"Not Acme", "SHA-256 signed"}},
+		{name: "Empty", data: nil, expected: []string{"Not Acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := rules.Match(tt.data); !reflect.DeepEqual(got, tt.expected) {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+				}
+			},
+		)
+	}
+}
+
+// TestLoadRulesInvalid tests that invalid rules are rejected
+func TestLoadRulesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{name: "Missing name", rules: `[{"condition": {"bytes": "00"}}]`},
+		{name: "Empty condition", rules: `[{"name": "Empty", "condition": {}}]`},
+		{name: "Empty nested condition", rules: `[{"name": "Empty", "condition": {"any": [{}]}}]`},
+		{name: "Odd length pattern", rules: `[{"name": "Odd", "condition": {"bytes": "414"}}]`},
+		{name: "Malformed pattern", rules: `[{"name": "Malformed", "condition": {"bytes": "4G"}}]`},
+		{name: "Negative offset", rules: `[{"name": "Offset", "condition": {"bytes": "41", "offset": -1}}]`},
+		{name: "Offset without pattern", rules: `[{"name": "Offset", "condition": {"offset": 0, "min_size": 1}}]`},
+		{name: "Malformed OID", rules: `[{"name": "OID", "condition": {"oid": "1.x"}}]`},
+		{name: "Invalid size range", rules: `[{"name": "Size", "condition": {"min_size": 10, "max_size": 5}}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if _, err := LoadRules(strings.NewReader(tt.rules)); !errors.Is(err, ErrInvalidRule) {
+					t.Errorf("Expected ErrInvalidRule, got %v", err)
+				}
+			},
+		)
+	}
+
+	if _, err := LoadRules(strings.NewReader(`{"name": "Not an array"}`)); err == nil {
+		t.Error("Expected an error for malformed JSON")
+	}
+}
+
+// TestDetectorRules tests that DetectAny reports the first matching rule
+func TestDetectorRules(t *testing.T) {
+	detector := NewDetector(WithRules(loadTestRules(t)))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected AnyResult
+	}{
+		{
+			name:     "In-house format",
+			data:     []byte("ACME\x00\x01 container"),
+			expected: AnyResult{Family: FamilyCustom, Kind: KindUnknown, Confidence: ConfidenceMedium, Rule: "Acme container"},
+		},
+		{
+			name:     "Known format",
+			data:     cmstest.SignedData(t),
+			expected: AnyResult{Family: FamilyCMS, Kind: KindSignedData, Confidence: ConfidenceHigh, Rule: "Not Acme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := detector.DetectAny(tt.data)
+				if err != nil {
+					t.Fatalf("DetectAny returned an error: %v", err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+
+	// Without a matching rule unknown data remains unknown
+	never, _ := CompileRules([]Rule{{Name: "Never", Condition: Condition{MinSize: 1 << 30}}})
+	if _, err := NewDetector(WithRules(never)).DetectAny([]byte("ACME\x00\x01 container")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
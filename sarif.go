@@ -0,0 +1,197 @@
+package cmsdetector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Rule IDs of the findings reported by WriteSARIF
+const (
+	SARIFRuleWeakAlgorithm         = "weak-algorithm"
+	SARIFRuleUnencryptedPrivateKey = "unencrypted-private-key"
+	SARIFRuleUnknownFormat         = "unknown-format"
+)
+
+// SARIF schema and version written by WriteSARIF
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// privateKeyKinds lists the kinds of private keys whose encryption is reported by the Scanner
+var privateKeyKinds = map[Kind]bool{
+	KindPrivateKey:        true,
+	KindOpenSSHPrivateKey: true,
+	KindPuTTYPrivateKey:   true,
+}
+
+// sarifRules describes the rules of the findings
+var sarifRules = []sarifRule{
+	{
+		ID:                   SARIFRuleWeakAlgorithm,
+		ShortDescription:     sarifMessage{Text: "Deprecated algorithm or weak parameter"},
+		DefaultConfiguration: sarifConfiguration{Level: "warning"},
+	},
+	{
+		ID:                   SARIFRuleUnencryptedPrivateKey,
+		ShortDescription:     sarifMessage{Text: "Private key stored without encryption"},
+		DefaultConfiguration: sarifConfiguration{Level: "error"},
+	},
+	{
+		ID:                   SARIFRuleUnknownFormat,
+		ShortDescription:     sarifMessage{Text: "File in no recognized format"},
+		DefaultConfiguration: sarifConfiguration{Level: "note"},
+	},
+}
+
+// sarifLog provides the JSON structure of a SARIF 2.1.0 log
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun provides the JSON structure of a SARIF run
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool provides the JSON structure of the SARIF tool description
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver provides the JSON structure of the SARIF tool component
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule provides the JSON structure of a SARIF reporting descriptor
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+// sarifConfiguration provides the JSON structure of a SARIF reporting configuration
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+// sarifMessage provides the JSON structure of a SARIF message
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult provides the JSON structure of a SARIF result
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+// sarifLocation provides the JSON structure of a SARIF location
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation provides the JSON structure of a SARIF physical location
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+// sarifArtifactLocation provides the JSON structure of a SARIF artifact location
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// WriteSARIF writes the findings of the scanned files as a SARIF 2.1.0 log for code scanning
+// and vulnerability dashboards: weak algorithms, unencrypted private keys and unknown formats.
+// Files unpacked from containers are reported with their paths in the container. File paths are
+// reported as relative URIs
+func WriteSARIF(w io.Writer, files []ScannedFile) error {
+	results := []sarifResult{}
+
+	for _, file := range files {
+		results = appendSARIFResults(results, file)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "cmsdetector",
+						InformationURI: "https://github.com/lEx0/cmsdetector",
+						Rules:          sarifRules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(log)
+}
+
+// appendSARIFResults appends the findings of the file, of every block of PEM bundles and of the
+// files unpacked from it
+func appendSARIFResults(results []sarifResult, file ScannedFile) []sarifResult {
+	switch {
+	case len(file.PEMBlocks) > 0:
+		for _, block := range file.PEMBlocks {
+			prefix := fmt.Sprintf("PEM block %d: ", block.Index)
+			results = appendKeyResults(results, file.Path, prefix, block.Result.Kind, block.Encrypted, block.Weak)
+		}
+	case file.Err == nil:
+		results = appendKeyResults(results, file.Path, "", file.Result.Kind, file.Encrypted, file.Weak)
+	}
+
+	// Unpacked containers are described by their contents
+	if errors.Is(file.Err, ErrUnknownFormat) && file.Container == "" {
+		results = append(results, newSARIFResult(SARIFRuleUnknownFormat, "note", file.Path, file.Err.Error()))
+	}
+
+	for _, child := range file.Children {
+		results = appendSARIFResults(results, child)
+	}
+
+	return results
+}
+
+// appendKeyResults appends the weak algorithms and unencrypted private keys of a file or PEM block,
+// with the messages prefixed
+func appendKeyResults(results []sarifResult, path, prefix string, kind Kind, encrypted bool, weak []Finding) []sarifResult {
+	for _, finding := range weak {
+		results = append(results, newSARIFResult(SARIFRuleWeakAlgorithm, "warning", path, prefix+finding.String()))
+	}
+
+	if !encrypted && privateKeyKinds[kind] {
+		results = append(results, newSARIFResult(SARIFRuleUnencryptedPrivateKey, "error", path, prefix+kind.String()+" is not encrypted"))
+	}
+
+	return results
+}
+
+// newSARIFResult creates a result of the rule for the file
+func newSARIFResult(ruleID, level, path, message string) sarifResult {
+	return sarifResult{
+		RuleID:  ruleID,
+		Level:   level,
+		Message: sarifMessage{Text: message},
+		Locations: []sarifLocation{
+			{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: path}}},
+		},
+	}
+}
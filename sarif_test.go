@@ -0,0 +1,170 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestWriteSARIF tests the findings written for scanned files
+func TestWriteSARIF(t *testing.T) {
+	weak := cmstest.EncryptedData(t, cmstest.WithContentEncryptionAlgorithm(cmstest.DESEDE3CBCOID))
+
+	fsys := fstest.MapFS{
+		"keys/id_ed25519":      {Data: createOpenSSHPrivateKey("none")},
+		"keys/id_encrypted":    {Data: createOpenSSHPrivateKey("aes256-ctr")},
+		"archive/weak.p7":      {Data: weak},
+		"archive/weak.pem":     {Data: pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: weak})},
+		"archive/strong.p7":    {Data: cmstest.EncryptedData(t)},
+		"archive/notes.txt":    {Data: []byte("Hello, world")},
+		"keys/id_ed25519.pub":  {Data: []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGNvbnRlbnQ= user@host")},
+		"keys/.gitkeep":        {Data: []byte{}},
+		"archive/signed.p7s":   {Data: cmstest.SignedData(t)},
+		"archive/detached.p7s": {Data: cmstest.SignedData(t, cmstest.WithDetached())},
+	}
+
+	report, err := (&Scanner{Exclude: []string{".gitkeep"}}).Scan(fsys, ".")
+	if err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, report.Files); err != nil {
+		t.Fatalf("WriteSARIF returned an error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to decode SARIF log: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != 3 {
+		t.Fatalf("Unexpected SARIF log: %s", buf.String())
+	}
+
+	var got []string
+	for _, result := range log.Runs[0].Results {
+		got = append(got, result.RuleID+" "+result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+
+	expected := []string{
+		"unknown-format archive/notes.txt",
+		"weak-algorithm archive/weak.p7",
+		"weak-algorithm archive/weak.pem",
+		"unencrypted-private-key keys/id_ed25519",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// createPrivateKeyPEM creates an unencrypted PKCS#8 private key in a PRIVATE KEY block
+func createPrivateKeyPEM(t *testing.T) *pem.Block {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}
+}
+
+// sarifFindings writes the files as a SARIF log and returns the rule and location of its results
+func sarifFindings(t *testing.T, files []ScannedFile) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, files); err != nil {
+		t.Fatalf("WriteSARIF returned an error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Failed to decode SARIF log: %v", err)
+	}
+
+	var got []string
+	for _, result := range log.Runs[0].Results {
+		got = append(got, result.RuleID+" "+result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+
+	return got
+}
+
+// TestWriteSARIFUnpacked tests that the findings of unpacked files are reported with their paths
+// in the container, and the container itself is not reported as unknown
+func TestWriteSARIFUnpacked(t *testing.T) {
+	fsys := fstest.MapFS{
+		"backup.zip": {
+			Data: createZIP(
+				t, []archiveMember{
+					{name: "keys/server.key", data: pem.EncodeToMemory(createPrivateKeyPEM(t))},
+					{name: "notes.txt", data: []byte("Hello, world")},
+				},
+			),
+		},
+	}
+
+	report, err := (&Scanner{Unpack: true}).Scan(fsys, ".")
+	if err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	expected := []string{
+		"unencrypted-private-key backup.zip/keys/server.key",
+		"unknown-format backup.zip/notes.txt",
+	}
+
+	if got := sarifFindings(t, report.Files); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// TestWriteSARIFPEMBundle tests that the findings of every block of PEM bundles are reported
+func TestWriteSARIFPEMBundle(t *testing.T) {
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cmstest.Certificate(t, "Test")})
+	bundle := append(certificate, pem.EncodeToMemory(createPrivateKeyPEM(t))...)
+
+	report, err := (&Scanner{}).Scan(fstest.MapFS{"server.pem": {Data: bundle}}, ".")
+	if err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	expected := []string{"unencrypted-private-key server.pem"}
+	if got := sarifFindings(t, report.Files); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	expectedCounts := map[Kind]int{KindCertificate: 1, KindPrivateKey: 1}
+	if !reflect.DeepEqual(report.Counts, expectedCounts) {
+		t.Errorf("Expected counts %v, got %v", expectedCounts, report.Counts)
+	}
+}
+
+// TestWriteSARIFNoFindings tests that an empty result list is written without findings
+func TestWriteSARIFNoFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, nil); err != nil {
+		t.Fatalf("WriteSARIF returned an error: %v", err)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("Expected an empty results array, got %s", buf.String())
+	}
+}
@@ -0,0 +1,368 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// encryptedKinds lists the kinds whose contents cannot be read without a key or password
+var encryptedKinds = map[Kind]bool{
+	KindEnvelopedData:          true,
+	KindSignedAndEnvelopedData: true,
+	KindEncryptedData:          true,
+	KindEncryptedPKCS12:        true,
+	KindEncryptedPrivateKey:    true,
+	KindJWE:                    true,
+	KindCOSEEncrypt:            true,
+	KindCOSEEncrypt0:           true,
+}
+
+// Scanner classifies the files of a file system, e.g. to build an inventory of key material.
+// Patterns use the path.Match syntax and are matched against the path relative to the scanned
+// directory when they contain a slash, and against the file name otherwise. Concurrent scans call
+// the hooks concurrently
+type Scanner struct {
+	Include []string // Patterns of the files to classify, all files when empty
+	Exclude []string // Patterns of the files and directories to skip
+	Hooks   Hooks    // Progress and detection callbacks
+	Unpack  bool     // Classify the files in archives, e-mail messages and PDF documents up to Limits.MaxUnpackDepth
+}
+
+// Hooks receive progress and detection events of a Scanner or Detector. Unset hooks are skipped,
+// hooks are called synchronously from the scanning or detecting goroutine
+type Hooks struct {
+	OnFileStart      func(path string)      // Called before an included file is read, not called by a Detector
+	OnResult         func(file ScannedFile) // Called after a file is classified or fails
+	OnHeuristicFired func(file ScannedFile) // Called for matches with less than ConfidenceHigh
+}
+
+// ScannedFile contains the classification of a single file
+type ScannedFile struct {
+	Path        string // Slash-separated path in the file system
+	Size        int64
+	Result      AnyResult
+	ContentType asn1.ObjectIdentifier // Content type of CMS/PKCS structures
+	Encrypted   bool                  // Indicates if a key or password is needed to read the contents
+	Weak        []Finding             // Deprecated algorithms and weak parameters of CMS/PKCS structures and encrypted PKCS#8 keys
+	PDF         []PDFSignature        // Signatures of PDF documents, which are reported with an UnknownFormatError
+	PEMBlocks   []ScannedPEMBlock     // Blocks of PEM bundles, e.g. a certificate chain and its key, the first block is reported in Result
+	Err         error                 // Classification error, e.g. an UnknownFormatError
+	Container   string                // Container format the children were unpacked from, e.g. ContainerArchive
+	Children    []ScannedFile         // Files unpacked from the container, their paths are prefixed with its path
+	UnpackErr   error                 // Error unpacking the container, e.g. ErrUnpackDepth
+}
+
+// ScannedPEMBlock contains the classification of a block of a PEM bundle
+type ScannedPEMBlock struct {
+	PEMBlock
+	Encrypted bool      // Indicates if a key or password is needed to read the contents
+	Weak      []Finding // Deprecated algorithms and weak parameters of CMS/PKCS structures and encrypted PKCS#8 keys
+}
+
+// ScanReport aggregates the classifications of the scanned files
+type ScanReport struct {
+	Files     []ScannedFile // All classified files in lexical order, unpacked files are their children
+	Counts    map[Kind]int  // Number of recognized files per kind, including unpacked files and every block of PEM bundles
+	Encrypted []string      // Paths of encrypted containers and of PEM bundles with an encrypted block
+	Unknown   []string      // Paths of files in no recognized format
+	Failed    []string      // Paths of files that could not be read or exceed MaxInputSize
+}
+
+// Scan walks the directory tree rooted at root and classifies every included file with DetectAny
+func (s *Scanner) Scan(fsys fs.FS, root string) (*ScanReport, error) {
+	for _, pattern := range append(append([]string(nil), s.Include...), s.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	report := &ScanReport{Counts: make(map[Kind]int)}
+
+	err := fs.WalkDir(
+		fsys, root, func(name string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable directories are reported instead of aborting the scan
+				if name == root {
+					return err
+				}
+
+				file := ScannedFile{Path: name, Err: err}
+				s.Hooks.result(file)
+				report.add(file)
+
+				return nil
+			}
+
+			relative := relativePath(root, name)
+
+			if entry.IsDir() {
+				if name != root && s.matches(s.Exclude, relative) {
+					return fs.SkipDir
+				}
+
+				return nil
+			}
+
+			if !entry.Type().IsRegular() || s.matches(s.Exclude, relative) {
+				return nil
+			}
+
+			if len(s.Include) > 0 && !s.matches(s.Include, relative) {
+				return nil
+			}
+
+			report.add(s.scanFile(fsys, name))
+
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// matches checks if the relative path matches any of the patterns
+func (s *Scanner) matches(patterns []string, relative string) bool {
+	for _, pattern := range patterns {
+		name := relative
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relative)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scanFile reads and classifies a single file, reporting it to the hooks
+func (s *Scanner) scanFile(fsys fs.FS, name string) ScannedFile {
+	if s.Hooks.OnFileStart != nil {
+		s.Hooks.OnFileStart(name)
+	}
+
+	data, err := readFileLimited(fsys, name)
+	if err != nil {
+		return s.reportFile(ScannedFile{Path: name, Err: err})
+	}
+
+	return s.reportFile(s.classify(name, data))
+}
+
+// Classify classifies data read from another source than a file system, e.g. standard input,
+// like a scanned file with the given name, reporting it to the hooks
+func (s *Scanner) Classify(name string, data []byte) ScannedFile {
+	if s.Hooks.OnFileStart != nil {
+		s.Hooks.OnFileStart(name)
+	}
+
+	return s.reportFile(s.classify(name, data))
+}
+
+// classify classifies the data and, if Unpack is set, the files unpacked from it
+func (s *Scanner) classify(name string, data []byte) ScannedFile {
+	file := classifyData(name, data)
+	if s.Unpack {
+		unpackFile(&file, data, 1)
+	}
+
+	return file
+}
+
+// reportFile reports a classified file to the hooks
+func (s *Scanner) reportFile(file ScannedFile) ScannedFile {
+	s.Hooks.detection(file)
+
+	return file
+}
+
+// enabled checks if any hook receiving detections is set
+func (h Hooks) enabled() bool {
+	return h.OnResult != nil || h.OnHeuristicFired != nil
+}
+
+// detection calls the OnHeuristicFired hook for matches with less than ConfidenceHigh and the
+// OnResult hook, if they are set
+func (h Hooks) detection(file ScannedFile) {
+	if file.Err == nil && file.Result.Confidence < ConfidenceHigh && h.OnHeuristicFired != nil {
+		h.OnHeuristicFired(file)
+	}
+
+	h.result(file)
+}
+
+// result calls the OnResult hook if it is set
+func (h Hooks) result(file ScannedFile) {
+	if h.OnResult != nil {
+		h.OnResult(file)
+	}
+}
+
+// classifyData classifies the contents of a file
+func classifyData(name string, data []byte) ScannedFile {
+	file := ScannedFile{Path: name, Size: int64(len(data))}
+	file.Result, file.Err = DetectAny(data)
+
+	if file.Err == nil {
+		file.Encrypted, file.ContentType, file.Weak = inspectResult(file.Result, data)
+
+		// Files of a single block are described by the result
+		if file.Result.PEMType != "" {
+			if blocks, err := DetectPEMBlocks(data); err == nil && len(blocks) > 1 {
+				file.PEMBlocks = scanPEMBlocks(data, blocks)
+			}
+		}
+	}
+
+	if signatures, err := DetectPDFSignatures(data); err == nil {
+		file.PDF = signatures
+	}
+
+	return file
+}
+
+// inspectResult returns whether the classified data is encrypted, and the content type and weak
+// algorithms and parameters of CMS/PKCS structures and encrypted PKCS#8 keys
+func inspectResult(result AnyResult, data []byte) (encrypted bool, contentType asn1.ObjectIdentifier, weak []Finding) {
+	encrypted = encryptedKinds[result.Kind]
+
+	// Encryption of SSH keys is indicated by their cipher name
+	if result.Family == FamilySSH {
+		if key, err := DetectSSHKey(data); err == nil {
+			encrypted = key.Encrypted
+		}
+	}
+
+	if result.Family == FamilyCMS || result.Kind == KindEncryptedPrivateKey {
+		detection, findings, err := inspectCMS(data, result.PEMType)
+		if err == nil {
+			contentType = detection.ContentType
+
+			// Encryption of CMS/PKCS structures is indicated by their contents, not their kind
+			if result.Family == FamilyCMS {
+				encrypted = detection.IsEncrypted
+			}
+		}
+
+		weak = findings
+	}
+
+	return encrypted, contentType, weak
+}
+
+// scanPEMBlocks inspects the classified blocks of PEM encoded data, which are decoded in the same
+// order as by DetectPEMBlocks
+func scanPEMBlocks(data []byte, blocks []PEMBlock) []ScannedPEMBlock {
+	scanned := make([]ScannedPEMBlock, len(blocks))
+
+	rest := data
+	for i, block := range blocks {
+		decoded, next := pem.Decode(rest)
+
+		scanned[i].PEMBlock = block
+		scanned[i].Encrypted, _, scanned[i].Weak = inspectResult(block.Result, pem.EncodeToMemory(decoded))
+
+		rest = next
+	}
+
+	return scanned
+}
+
+// inspectCMS returns the detection result and the weak algorithms and parameters of a CMS/PKCS
+// structure or encrypted PKCS#8 key, decoding PEM first. The error is the one of the detection
+func inspectCMS(data []byte, pemType string) (DetectionResult, []Finding, error) {
+	if pemType != "" {
+		block, _ := pem.Decode(bytes.TrimSpace(data))
+		if block == nil {
+			return DetectionResult{}, nil, ErrNotPEM
+		}
+
+		data = block.Bytes
+	}
+
+	result, detectErr := Detect(data)
+
+	report, err := InspectAlgorithms(data)
+	if err != nil {
+		return result, nil, detectErr
+	}
+
+	return result, report.Weak, detectErr
+}
+
+// readFileLimited reads the file, rejecting files larger than MaxInputSize without reading them
+func readFileLimited(fsys fs.FS, name string) ([]byte, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := CurrentLimits().MaxInputSize; limit > 0 && info.Size() > int64(limit) {
+		return nil, ErrInputTooLarge
+	}
+
+	return readAll(newSizeLimitedReader(file))
+}
+
+// add records the file in the report
+func (r *ScanReport) add(file ScannedFile) {
+	r.Files = append(r.Files, file)
+	r.count(file)
+}
+
+// count records the file and the files unpacked from it in the aggregates of the report
+func (r *ScanReport) count(file ScannedFile) {
+	switch {
+	case errors.Is(file.Err, ErrUnknownFormat):
+		r.Unknown = append(r.Unknown, file.Path)
+	case file.Err != nil:
+		r.Failed = append(r.Failed, file.Path)
+	default:
+		if len(file.PEMBlocks) == 0 {
+			r.Counts[file.Result.Kind]++
+		}
+
+		// Bundles are counted by their recognized blocks, the first of which is reported in Result
+		encrypted := file.Encrypted
+		for _, block := range file.PEMBlocks {
+			if block.Result.Kind != KindUnknown {
+				r.Counts[block.Result.Kind]++
+			}
+
+			encrypted = encrypted || block.Encrypted
+		}
+
+		if encrypted {
+			r.Encrypted = append(r.Encrypted, file.Path)
+		}
+	}
+
+	for _, child := range file.Children {
+		r.count(child)
+	}
+}
+
+// relativePath returns the path of name relative to the scanned root
+func relativePath(root, name string) string {
+	if root == "." || name == root {
+		return name
+	}
+
+	return name[len(root)+1:]
+}
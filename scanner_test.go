@@ -0,0 +1,267 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// createScanFS creates a file system with key material, documents and a skipped directory
+func createScanFS(t *testing.T) fstest.MapFS {
+	t.Helper()
+
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	return fstest.MapFS{
+		"keys/user.p7s":        {Data: createSignedData(t, sha256OID, rsaOID)},
+		"keys/backup.p7m":      {Data: createEnvelopedData(t, rsaOID, aesOID)},
+		"keys/id_ed25519":      {Data: createOpenSSHPrivateKey("aes256-ctr")},
+		"keys/id_ed25519.pub":  {Data: []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGNvbnRlbnQ= user@host")},
+		"docs/readme.txt":      {Data: []byte("Hello, world")},
+		"docs/report.pdf":      {Data: []byte("%PDF-1.7")},
+		".git/objects/ab/cdef": {Data: createSignedData(t, sha256OID, rsaOID)},
+	}
+}
+
+// TestScanner tests the aggregate report of a scan
+func TestScanner(t *testing.T) {
+	fsys := createScanFS(t)
+
+	tests := []struct {
+		name              string
+		scanner           Scanner
+		root              string
+		expectedFiles     int
+		expectedCounts    map[Kind]int
+		expectedEncrypted []string
+		expectedUnknown   []string
+	}{
+		{
+			name:          "All files except excluded directory",
+			scanner:       Scanner{Exclude: []string{".git"}},
+			root:          ".",
+			expectedFiles: 6,
+			expectedCounts: map[Kind]int{
+				KindSignedData:        1,
+				KindEnvelopedData:     1,
+				KindOpenSSHPrivateKey: 1,
+				KindSSHPublicKey:      1,
+			},
+			expectedEncrypted: []string{"keys/backup.p7m", "keys/id_ed25519"},
+			expectedUnknown:   []string{"docs/readme.txt", "docs/report.pdf"},
+		},
+		{
+			name:              "Included file names",
+			scanner:           Scanner{Include: []string{"*.p7?"}},
+			root:              ".",
+			expectedFiles:     2,
+			expectedCounts:    map[Kind]int{KindSignedData: 1, KindEnvelopedData: 1},
+			expectedEncrypted: []string{"keys/backup.p7m"},
+		},
+		{
+			name:              "Subdirectory with excluded file names",
+			scanner:           Scanner{Exclude: []string{"id_*"}},
+			root:              "keys",
+			expectedFiles:     2,
+			expectedCounts:    map[Kind]int{KindSignedData: 1, KindEnvelopedData: 1},
+			expectedEncrypted: []string{"keys/backup.p7m"},
+		},
+		{
+			name:           "Path pattern",
+			scanner:        Scanner{Include: []string{"keys/*.p7s"}},
+			root:           ".",
+			expectedFiles:  1,
+			expectedCounts: map[Kind]int{KindSignedData: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := tt.scanner.Scan(fsys, tt.root)
+				if err != nil {
+					t.Fatalf("Scan returned an error: %v", err)
+				}
+
+				if len(report.Files) != tt.expectedFiles {
+					t.Errorf("Expected %d files, got %d", tt.expectedFiles, len(report.Files))
+				}
+
+				if len(report.Counts) != len(tt.expectedCounts) {
+					t.Errorf("Expected counts %v, got %v", tt.expectedCounts, report.Counts)
+				}
+
+				for kind, count := range tt.expectedCounts {
+					if report.Counts[kind] != count {
+						t.Errorf("Expected %d files of kind %v, got %d", count, kind, report.Counts[kind])
+					}
+				}
+
+				if !equalStrings(report.Encrypted, tt.expectedEncrypted) {
+					t.Errorf("Expected encrypted %v, got %v", tt.expectedEncrypted, report.Encrypted)
+				}
+
+				if !equalStrings(report.Unknown, tt.expectedUnknown) {
+					t.Errorf("Expected unknown %v, got %v", tt.expectedUnknown, report.Unknown)
+				}
+			},
+		)
+	}
+}
+
+// TestScannerMaxInputSize tests that files larger than MaxInputSize are reported as failed
+func TestScannerMaxInputSize(t *testing.T) {
+	fsys := fstest.MapFS{
+		"large.bin": {Data: make([]byte, 128)},
+		"small.bin": {Data: []byte("small")},
+	}
+
+	withLimits(t, Limits{MaxInputSize: 64})
+
+	report, err := (&Scanner{}).Scan(fsys, ".")
+	if err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	if !equalStrings(report.Failed, []string{"large.bin"}) {
+		t.Errorf("Expected failed %v, got %v", []string{"large.bin"}, report.Failed)
+	}
+
+	if !errors.Is(report.Files[0].Err, ErrInputTooLarge) {
+		t.Errorf("Expected error %v, got %v", ErrInputTooLarge, report.Files[0].Err)
+	}
+}
+
+// TestScannerInvalidPattern tests rejection of malformed patterns
+func TestScannerInvalidPattern(t *testing.T) {
+	if _, err := (&Scanner{Include: []string{"[a-"}}).Scan(fstest.MapFS{}, "."); err == nil {
+		t.Error("Expected error for invalid pattern, got nil")
+	}
+}
+
+// TestScannerHooks tests the order of the events reported to the hooks
+func TestScannerHooks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"keystore.bks": {Data: createBKS()},
+		"readme.txt":   {Data: []byte("Hello, world")},
+	}
+
+	var events []string
+
+	scanner := Scanner{
+		Hooks: Hooks{
+			OnFileStart: func(path string) {
+				events = append(events, "start "+path)
+			},
+			OnResult: func(file ScannedFile) {
+				events = append(events, "result "+file.Path)
+			},
+			OnHeuristicFired: func(file ScannedFile) {
+				events = append(events, "heuristic "+file.Result.Kind.String())
+			},
+		},
+	}
+
+	if _, err := scanner.Scan(fsys, "."); err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	expected := []string{
+		"start keystore.bks",
+		"heuristic BouncyCastle KeyStore",
+		"result keystore.bks",
+		"start readme.txt",
+		"result readme.txt",
+	}
+
+	if !equalStrings(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+// TestScannerClassify tests classification of data that is not read from a file system
+func TestScannerClassify(t *testing.T) {
+	var events []string
+
+	scanner := Scanner{
+		Hooks: Hooks{
+			OnFileStart: func(path string) {
+				events = append(events, "start "+path)
+			},
+			OnResult: func(file ScannedFile) {
+				events = append(events, "result "+file.Path)
+			},
+		},
+	}
+
+	file := scanner.Classify("-", createOpenSSHPrivateKey("aes256-ctr"))
+	if file.Err != nil {
+		t.Fatalf("Classify returned an error: %v", file.Err)
+	}
+
+	if file.Result.Kind != KindOpenSSHPrivateKey || !file.Encrypted {
+		t.Errorf("Expected encrypted %v, got %v (encrypted %v)", KindOpenSSHPrivateKey, file.Result.Kind, file.Encrypted)
+	}
+
+	expected := []string{"start -", "result -"}
+	if !equalStrings(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+
+	signed := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: cmstest.SignedData(t)})
+	if file := scanner.Classify("signed.pem", signed); !file.ContentType.Equal(PKCS7SignedDataOID) || file.PEMBlocks != nil {
+		t.Errorf("Expected content type %v of a single block, got %v and blocks %+v", PKCS7SignedDataOID, file.ContentType, file.PEMBlocks)
+	}
+
+	bundle := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cmstest.Certificate(t, "Test")}), signed...)
+	if file := scanner.Classify("bundle.pem", bundle); len(file.PEMBlocks) != 2 || file.PEMBlocks[1].Result.Kind != KindSignedData {
+		t.Errorf("Expected signed data in the second block, got %+v", file.PEMBlocks)
+	}
+
+	withPBEThresholds(t, PBEThresholds{MinIterations: 10000})
+
+	key := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: createEncryptedPrivateKey(t, 2048)})
+	if file := scanner.Classify("key.pem", key); len(file.Weak) != 1 || file.Weak[0].Reason != "low iteration count: 2048" {
+		t.Errorf("Expected a low iteration count of the encrypted key, got %+v", file.Weak)
+	}
+
+	document := createPDF(createPDFSignatureDictionary("Sig", PDFSubFilterCAdESDetached, cmstest.SignedData(t)))
+	if file := scanner.Classify("signed.pdf", document); len(file.PDF) != 1 || !file.PDF[0].IsPAdES() {
+		t.Errorf("Expected a PAdES signature, got %+v", file.PDF)
+	}
+}
+
+// TestScannerEncrypted tests that encryption of CMS/PKCS structures is reported by their contents
+func TestScannerEncrypted(t *testing.T) {
+	tests := []struct {
+		name              string
+		data              []byte
+		expectedEncrypted bool
+	}{
+		{name: "EnvelopedData", data: cmstest.EnvelopedData(t), expectedEncrypted: true},
+		{name: "AuthEnvelopedData", data: createAuthEnvelopedData(t), expectedEncrypted: true},
+		{name: "SignedData", data: cmstest.SignedData(t), expectedEncrypted: false},
+		{name: "PFX with certificates only", data: createCertificatePFX(t), expectedEncrypted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				file := (&Scanner{}).Classify("-", tt.data)
+				if file.Err != nil {
+					t.Fatalf("Classify returned an error: %v", file.Err)
+				}
+
+				if file.Encrypted != tt.expectedEncrypted {
+					t.Errorf("Expected encrypted %v, got %v (%v)", tt.expectedEncrypted, file.Encrypted, file.Result.Kind)
+				}
+			},
+		)
+	}
+}
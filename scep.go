@@ -0,0 +1,145 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// SCEP signed attribute OIDs of the id-VeriSign arc (RFC 8894, section 3.2.1)
+var (
+	SCEPMessageTypeAttributeOID    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 2}
+	SCEPPKIStatusAttributeOID      = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 3}
+	SCEPFailInfoAttributeOID       = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 4}
+	SCEPSenderNonceAttributeOID    = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 5}
+	SCEPRecipientNonceAttributeOID = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 6}
+	SCEPTransactionIDAttributeOID  = asn1.ObjectIdentifier{2, 16, 840, 1, 113733, 1, 9, 7}
+)
+
+// PayloadSCEP is reported for SignedData carrying a SCEP pkiMessage
+const PayloadSCEP = "SCEP pkiMessage"
+
+// SCEPMessageType is the type of a SCEP pkiMessage (RFC 8894, section 3.2.1.2)
+type SCEPMessageType int
+
+// SCEP message types
+const (
+	SCEPCertRep    SCEPMessageType = 3
+	SCEPRenewalReq SCEPMessageType = 17
+	SCEPPKCSReq    SCEPMessageType = 19
+	SCEPCertPoll   SCEPMessageType = 20
+	SCEPGetCert    SCEPMessageType = 21
+	SCEPGetCRL     SCEPMessageType = 22
+)
+
+// SCEP pkiStatus values of CertRep messages (RFC 8894, section 3.2.1.3)
+const (
+	SCEPStatusSuccess = "0"
+	SCEPStatusFailure = "2"
+	SCEPStatusPending = "3"
+)
+
+// scepMessageTypeNames maps SCEP message types to their names in RFC 8894
+var scepMessageTypeNames = map[SCEPMessageType]string{
+	SCEPCertRep:    "CertRep",
+	SCEPRenewalReq: "RenewalReq",
+	SCEPPKCSReq:    "PKCSReq",
+	SCEPCertPoll:   "CertPoll",
+	SCEPGetCert:    "GetCert",
+	SCEPGetCRL:     "GetCRL",
+}
+
+// ErrNotSCEP is returned when the data is not a SCEP pkiMessage
+var ErrNotSCEP = errors.New("not a SCEP pkiMessage")
+
+// SCEPMessage describes the SCEP attributes of a pkiMessage
+type SCEPMessage struct {
+	MessageType    SCEPMessageType
+	TransactionID  string
+	SenderNonce    []byte
+	RecipientNonce []byte // Set in CertRep messages
+	PKIStatus      string // SCEPStatusSuccess, SCEPStatusFailure or SCEPStatusPending in CertRep messages
+	FailInfo       string // Failure reason of failed CertRep messages, e.g. "2" for badTime
+}
+
+// String returns the name of the message type, e.g. "PKCSReq"
+func (t SCEPMessageType) String() string {
+	if name, ok := scepMessageTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SCEPMessageType(%d)", int(t))
+}
+
+// DetectSCEP detects a SCEP pkiMessage, SignedData whose signer carries the messageType and
+// transactionID attributes, and reports its message type, transaction and nonces. The enveloped
+// pkcsPKIEnvelope is not decrypted
+func DetectSCEP(data []byte) (message *SCEPMessage, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if errors.Is(err, ErrNotSignedData) {
+		return nil, ErrNotSCEP
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if message, ok := scepMessage(sd); ok {
+		return message, nil
+	}
+
+	return nil, ErrNotSCEP
+}
+
+// scepMessage returns the SCEP attributes of the first signer carrying them
+func scepMessage(sd *signedData) (*SCEPMessage, bool) {
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.SignedAttrs)
+		if err != nil {
+			continue
+		}
+
+		var messageType, transactionID string
+		if !printableAttribute(attrs, SCEPMessageTypeAttributeOID, &messageType) ||
+			!printableAttribute(attrs, SCEPTransactionIDAttributeOID, &transactionID) {
+			continue
+		}
+
+		code, err := strconv.Atoi(messageType)
+		if err != nil {
+			continue
+		}
+
+		message := &SCEPMessage{MessageType: SCEPMessageType(code), TransactionID: transactionID}
+		printableAttribute(attrs, SCEPPKIStatusAttributeOID, &message.PKIStatus)
+		printableAttribute(attrs, SCEPFailInfoAttributeOID, &message.FailInfo)
+		octetStringAttribute(attrs, SCEPSenderNonceAttributeOID, &message.SenderNonce)
+		octetStringAttribute(attrs, SCEPRecipientNonceAttributeOID, &message.RecipientNonce)
+
+		return message, true
+	}
+
+	return nil, false
+}
+
+// printableAttribute decodes the first value of a PrintableString attribute
+func printableAttribute(attrs []attribute, oid asn1.ObjectIdentifier, value *string) bool {
+	values := attributeValues(attrs, oid)
+	if len(values) == 0 {
+		return false
+	}
+
+	_, err := asn1.Unmarshal(values[0].FullBytes, value)
+
+	return err == nil
+}
+
+// octetStringAttribute decodes the first value of an OCTET STRING attribute
+func octetStringAttribute(attrs []attribute, oid asn1.ObjectIdentifier, value *[]byte) {
+	if values := attributeValues(attrs, oid); len(values) > 0 {
+		_, _ = asn1.Unmarshal(values[0].FullBytes, value)
+	}
+}
@@ -0,0 +1,129 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestDetectSCEP tests detection of SCEP pkiMessages and their attributes
+func TestDetectSCEP(t *testing.T) {
+	senderNonce := bytes.Repeat([]byte{0x01}, 16)
+	recipientNonce := bytes.Repeat([]byte{0x02}, 16)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected *SCEPMessage
+		err      error
+	}{
+		{
+			name: "PKCSReq",
+			data: createSignedDataWithAttributes(
+				t,
+				createAttribute(t, SCEPMessageTypeAttributeOID, "19"),
+				createAttribute(t, SCEPSenderNonceAttributeOID, senderNonce),
+				createAttribute(t, SCEPTransactionIDAttributeOID, "2F3C88114C283E9A"),
+			),
+			expected: &SCEPMessage{MessageType: SCEPPKCSReq, TransactionID: "2F3C88114C283E9A", SenderNonce: senderNonce},
+		},
+		{
+			name: "Failed CertRep",
+			data: createSignedDataWithAttributes(
+				t,
+				createAttribute(t, SCEPMessageTypeAttributeOID, "3"),
+				createAttribute(t, SCEPPKIStatusAttributeOID, SCEPStatusFailure),
+				createAttribute(t, SCEPFailInfoAttributeOID, "2"),
+				createAttribute(t, SCEPSenderNonceAttributeOID, senderNonce),
+				createAttribute(t, SCEPRecipientNonceAttributeOID, recipientNonce),
+				createAttribute(t, SCEPTransactionIDAttributeOID, "1"),
+			),
+			expected: &SCEPMessage{
+				MessageType:    SCEPCertRep,
+				TransactionID:  "1",
+				SenderNonce:    senderNonce,
+				RecipientNonce: recipientNonce,
+				PKIStatus:      SCEPStatusFailure,
+				FailInfo:       "2",
+			},
+		},
+		{
+			name: "Missing transaction ID",
+			data: createSignedDataWithAttributes(t, createAttribute(t, SCEPMessageTypeAttributeOID, "19")),
+			err:  ErrNotSCEP,
+		},
+		{
+			name: "Non-numeric message type",
+			data: createSignedDataWithAttributes(
+				t,
+				createAttribute(t, SCEPMessageTypeAttributeOID, "PKCSReq"),
+				createAttribute(t, SCEPTransactionIDAttributeOID, "1"),
+			),
+			err: ErrNotSCEP,
+		},
+		{
+			name: "Plain SignedData",
+			data: createSignedDataWithAttributes(t, createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID)),
+			err:  ErrNotSCEP,
+		},
+		{
+			name: "EnvelopedData",
+			data: createTestData(t, PKCS7EnvelopedDataOID),
+			err:  ErrNotSCEP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				message, err := DetectSCEP(tt.data)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Expected error %v, got %v", tt.err, err)
+				}
+
+				if tt.expected == nil {
+					return
+				}
+
+				if message.MessageType != tt.expected.MessageType || message.TransactionID != tt.expected.TransactionID {
+					t.Errorf("Expected %v %q, got %v %q", tt.expected.MessageType, tt.expected.TransactionID, message.MessageType, message.TransactionID)
+				}
+
+				if !bytes.Equal(message.SenderNonce, tt.expected.SenderNonce) || !bytes.Equal(message.RecipientNonce, tt.expected.RecipientNonce) {
+					t.Errorf("Expected nonces %x/%x, got %x/%x", tt.expected.SenderNonce, tt.expected.RecipientNonce, message.SenderNonce, message.RecipientNonce)
+				}
+
+				if message.PKIStatus != tt.expected.PKIStatus || message.FailInfo != tt.expected.FailInfo {
+					t.Errorf("Expected status %q/%q, got %q/%q", tt.expected.PKIStatus, tt.expected.FailInfo, message.PKIStatus, message.FailInfo)
+				}
+
+				result, err := Detect(tt.data)
+				if err != nil || result.Payload != PayloadSCEP {
+					t.Errorf("Expected payload %q, got %q (%v)", PayloadSCEP, result.Payload, err)
+				}
+			},
+		)
+	}
+}
+
+// TestSCEPMessageTypeString tests the names of SCEP message types
+func TestSCEPMessageTypeString(t *testing.T) {
+	tests := []struct {
+		messageType SCEPMessageType
+		expected    string
+	}{
+		{SCEPCertRep, "CertRep"},
+		{SCEPRenewalReq, "RenewalReq"},
+		{SCEPPKCSReq, "PKCSReq"},
+		{SCEPCertPoll, "CertPoll"},
+		{SCEPGetCert, "GetCert"},
+		{SCEPGetCRL, "GetCRL"},
+		{SCEPMessageType(99), "SCEPMessageType(99)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.messageType.String(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// SignatureScheme is the family of the signature algorithm of SignedData, which determines the
+// backend able to verify it
+type SignatureScheme int
+
+// Signature schemes reported by SignatureFamily
+const (
+	SignatureSchemeUnknown     SignatureScheme = iota // No signers, or an algorithm of no listed family
+	SignatureSchemeRSA                                // RSA PKCS#1 v1.5 or RSASSA-PSS
+	SignatureSchemeECDSA                              // ECDSA over any curve
+	SignatureSchemeEd25519                            // Ed25519
+	SignatureSchemeGOST2001                           // GOST R 34.10-2001
+	SignatureSchemeGOST2012256                        // GOST R 34.10-2012 with a 256 bit key
+	SignatureSchemeGOST2012512                        // GOST R 34.10-2012 with a 512 bit key
+	SignatureSchemeGOST34310                          // GOST 34.310-2004 of Kazakhstan
+	SignatureSchemeMixed                              // Signers of different families
+)
+
+// String returns a human-readable name of the signature scheme
+func (s SignatureScheme) String() string {
+	switch s {
+	case SignatureSchemeUnknown:
+		return "unknown"
+	case SignatureSchemeRSA:
+		return "RSA"
+	case SignatureSchemeECDSA:
+		return "ECDSA"
+	case SignatureSchemeEd25519:
+		return "Ed25519"
+	case SignatureSchemeGOST2001:
+		return "GOST R 34.10-2001"
+	case SignatureSchemeGOST2012256:
+		return "GOST R 34.10-2012 (256 bit)"
+	case SignatureSchemeGOST2012512:
+		return "GOST R 34.10-2012 (512 bit)"
+	case SignatureSchemeGOST34310:
+		return "GOST 34.310-2004"
+	case SignatureSchemeMixed:
+		return "mixed"
+	default:
+		return fmt.Sprintf("SignatureScheme(%d)", int(s))
+	}
+}
+
+// IsGOST checks if the scheme is one of the GOST families
+func (s SignatureScheme) IsGOST() bool {
+	switch s {
+	case SignatureSchemeGOST2001, SignatureSchemeGOST2012256, SignatureSchemeGOST2012512, SignatureSchemeGOST34310:
+		return true
+	default:
+		return false
+	}
+}
+
+// signatureSchemes maps signature algorithms to their family. Signers may give the key algorithm
+// instead of the signature algorithm, as RSA and CryptoPro signers commonly do
+var signatureSchemes = map[string]SignatureScheme{
+	"1.2.840.113549.1.1.1":  SignatureSchemeRSA,         // RSA
+	"1.2.840.113549.1.1.2":  SignatureSchemeRSA,         // MD2 with RSA
+	"1.2.840.113549.1.1.3":  SignatureSchemeRSA,         // MD4 with RSA
+	"1.2.840.113549.1.1.4":  SignatureSchemeRSA,         // MD5 with RSA
+	"1.2.840.113549.1.1.5":  SignatureSchemeRSA,         // SHA-1 with RSA
+	"1.2.840.113549.1.1.10": SignatureSchemeRSA,         // RSASSA-PSS
+	"1.2.840.113549.1.1.11": SignatureSchemeRSA,         // SHA-256 with RSA
+	"1.2.840.113549.1.1.12": SignatureSchemeRSA,         // SHA-384 with RSA
+	"1.2.840.113549.1.1.13": SignatureSchemeRSA,         // SHA-512 with RSA
+	"1.2.840.113549.1.1.14": SignatureSchemeRSA,         // SHA-224 with RSA
+	"1.2.840.113549.1.1.15": SignatureSchemeRSA,         // SHA-512/224 with RSA
+	"1.2.840.113549.1.1.16": SignatureSchemeRSA,         // SHA-512/256 with RSA
+	"1.2.840.10045.2.1":     SignatureSchemeECDSA,       // EC public key
+	"1.2.840.10045.4.1":     SignatureSchemeECDSA,       // ECDSA with SHA-1
+	"1.2.840.10045.4.3.1":   SignatureSchemeECDSA,       // ECDSA with SHA-224
+	"1.2.840.10045.4.3.2":   SignatureSchemeECDSA,       // ECDSA with SHA-256
+	"1.2.840.10045.4.3.3":   SignatureSchemeECDSA,       // ECDSA with SHA-384
+	"1.2.840.10045.4.3.4":   SignatureSchemeECDSA,       // ECDSA with SHA-512
+	"1.3.101.112":           SignatureSchemeEd25519,     // Ed25519
+	"1.2.643.2.2.3":         SignatureSchemeGOST2001,    // GOST R 34.11-94 with GOST R 34.10-2001
+	"1.2.643.2.2.19":        SignatureSchemeGOST2001,    // GOST R 34.10-2001
+	"1.2.643.7.1.1.1.1":     SignatureSchemeGOST2012256, // GOST R 34.10-2012 (256 bit)
+	"1.2.643.7.1.1.3.2":     SignatureSchemeGOST2012256, // GOST R 34.10-2012 with GOST R 34.11-2012 (256 bit)
+	"1.2.643.7.1.1.1.2":     SignatureSchemeGOST2012512, // GOST R 34.10-2012 (512 bit)
+	"1.2.643.7.1.1.3.3":     SignatureSchemeGOST2012512, // GOST R 34.10-2012 with GOST R 34.11-2012 (512 bit)
+	"1.2.398.3.10.1.1.1.1":  SignatureSchemeGOST34310,   // GOST 34.310-2004
+	"1.2.398.3.10.1.1.1.2":  SignatureSchemeGOST34310,   // GOST 34.310-2004 with GOST 34.311-95
+}
+
+// signatureSchemeOf returns the family of the signature algorithm
+func signatureSchemeOf(oid asn1.ObjectIdentifier) SignatureScheme {
+	return signatureSchemes[oid.String()]
+}
+
+// SignatureFamily reports the family of the signature algorithm shared by all signers of the
+// SignedData, SignatureSchemeMixed if the signers differ and SignatureSchemeUnknown if there are no
+// signers. Data other than SignedData is rejected with ErrNotSignedData
+func SignatureFamily(data []byte) (scheme SignatureScheme, err error) {
+	defer recoverPanic(&err)
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return SignatureSchemeUnknown, err
+	}
+
+	for i, si := range sd.SignerInfos {
+		signer := signatureSchemeOf(si.SignatureAlgorithm.Algorithm)
+
+		switch {
+		case i == 0:
+			scheme = signer
+		case signer != scheme:
+			return SignatureSchemeMixed, nil
+		}
+	}
+
+	return scheme, nil
+}
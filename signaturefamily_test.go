@@ -0,0 +1,125 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+// TestSignatureFamily tests classification of the signature algorithms of SignedData
+func TestSignatureFamily(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	streebog256OID := asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2}
+
+	signer := func(signatureOID asn1.ObjectIdentifier) signerInfo {
+		return createSignerInfo(t, sha256OID, signatureOID, nil, nil)
+	}
+
+	tests := []struct {
+		name     string
+		signers  []signerInfo
+		expected SignatureScheme
+	}{
+		{
+			name:     "RSA key algorithm",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1})},
+			expected: SignatureSchemeRSA,
+		},
+		{
+			name:     "RSASSA-PSS",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10})},
+			expected: SignatureSchemeRSA,
+		},
+		{
+			name:     "ECDSA with SHA-256",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2})},
+			expected: SignatureSchemeECDSA,
+		},
+		{
+			name:     "Ed25519",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 3, 101, 112})},
+			expected: SignatureSchemeEd25519,
+		},
+		{
+			name:     "GOST R 34.10-2001",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 643, 2, 2, 3})},
+			expected: SignatureSchemeGOST2001,
+		},
+		{
+			name:     "GOST R 34.10-2012 256 bit key algorithm",
+			signers:  []signerInfo{createSignerInfo(t, streebog256OID, asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}, nil, nil)},
+			expected: SignatureSchemeGOST2012256,
+		},
+		{
+			name:     "GOST R 34.10-2012 512 bit",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 3})},
+			expected: SignatureSchemeGOST2012512,
+		},
+		{
+			name:     "GOST 34.310-2004",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 398, 3, 10, 1, 1, 1, 2})},
+			expected: SignatureSchemeGOST34310,
+		},
+		{
+			name: "Co-signers of one family",
+			signers: []signerInfo{
+				signer(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}),
+				signer(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}),
+			},
+			expected: SignatureSchemeRSA,
+		},
+		{
+			name: "Co-signers of different families",
+			signers: []signerInfo{
+				signer(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}),
+				signer(asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}),
+			},
+			expected: SignatureSchemeMixed,
+		},
+		{
+			name:     "Unknown algorithm",
+			signers:  []signerInfo{signer(asn1.ObjectIdentifier{1, 2, 3, 4})},
+			expected: SignatureSchemeUnknown,
+		},
+		{
+			name:     "No signers",
+			expected: SignatureSchemeUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				scheme, err := SignatureFamily(createSignedDataWithSigners(t, tt.signers))
+				if err != nil {
+					t.Fatalf("SignatureFamily returned an error: %v", err)
+				}
+
+				if scheme != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, scheme)
+				}
+			},
+		)
+	}
+}
+
+// TestSignatureFamilyInvalidData tests SignatureFamily with data other than SignedData
+func TestSignatureFamilyInvalidData(t *testing.T) {
+	if _, err := SignatureFamily(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+
+	if _, err := SignatureFamily([]byte{0x01, 0x02, 0x03}); err == nil {
+		t.Error("Expected error for invalid data, got nil")
+	}
+}
+
+// TestSignatureSchemeIsGOST tests the GOST families of signature schemes
+func TestSignatureSchemeIsGOST(t *testing.T) {
+	for scheme := SignatureSchemeUnknown; scheme <= SignatureSchemeMixed; scheme++ {
+		expected := scheme >= SignatureSchemeGOST2001 && scheme <= SignatureSchemeGOST34310
+		if scheme.IsGOST() != expected {
+			t.Errorf("Expected IsGOST %v for %v, got %v", expected, scheme, scheme.IsGOST())
+		}
+	}
+}
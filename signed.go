@@ -0,0 +1,144 @@
+package cmsdetector
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PKCS#9 signed attribute OIDs (RFC 5652, section 11)
+var (
+	ContentTypeAttributeOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	MessageDigestAttributeOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	SigningTimeAttributeOID   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	CounterSignatureOID       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 6}
+)
+
+// SignatureCounts contains the number of signatures of each kind found in SignedData
+type SignatureCounts struct {
+	Signers           int // Parallel SignerInfos (co-signatures)
+	CounterSignatures int // counterSignature attributes, including nested ones
+}
+
+// ErrNotSignedData is returned when the data is not PKCS#7 signed data
+var ErrNotSignedData = errors.New("not a PKCS#7 signed data")
+
+// loadSignedData parses the data as ContentInfo wrapping SignedData
+func loadSignedData(data []byte) (*signedData, error) {
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	contentInfo, err := parseContentInfo(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	if !contentInfo.ContentType.Equal(PKCS7SignedDataOID) {
+		return nil, ErrNotSignedData
+	}
+
+	sd, err := parseSignedData(contentInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed data: %w", err)
+	}
+
+	return sd, nil
+}
+
+// SigningTimes returns the signingTime attribute values of all signers of the SignedData
+func SigningTimes(data []byte) ([]time.Time, error) {
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var times []time.Time
+
+	for _, si := range sd.SignerInfos {
+		attrs, err := parseAttributes(si.SignedAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signed attributes: %w", err)
+		}
+
+		for _, value := range attributeValues(attrs, SigningTimeAttributeOID) {
+			var signingTime time.Time
+			if _, err := asn1.Unmarshal(value.FullBytes, &signingTime); err != nil {
+				return nil, fmt.Errorf("failed to parse signing time: %w", err)
+			}
+
+			times = append(times, signingTime)
+		}
+	}
+
+	return times, nil
+}
+
+// IsMultiSigned checks if the SignedData carries more than one signature of any kind
+func (c SignatureCounts) IsMultiSigned() bool {
+	return c.Signers+c.CounterSignatures > 1
+}
+
+// CountSignatures counts the parallel signers and countersignatures of the SignedData
+func CountSignatures(data []byte) (SignatureCounts, error) {
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return SignatureCounts{}, err
+	}
+
+	counts := SignatureCounts{Signers: len(sd.SignerInfos)}
+
+	for _, si := range sd.SignerInfos {
+		counts.CounterSignatures += countCounterSignatures(si, 0)
+	}
+
+	return counts, nil
+}
+
+// countCounterSignatures counts the countersignatures of the signer, following nested ones
+func countCounterSignatures(si signerInfo, depth int) int {
+	if exceedsNestingDepth(depth) {
+		return 0
+	}
+
+	attrs, err := parseAttributes(si.UnsignedAttrs)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+
+	for _, value := range attributeValues(attrs, CounterSignatureOID) {
+		count++
+
+		var counterSigner signerInfo
+		if _, err := asn1.Unmarshal(value.FullBytes, &counterSigner); err == nil {
+			count += countCounterSignatures(counterSigner, depth+1)
+		}
+	}
+
+	return count
+}
+
+// IsSignedBy checks if any signer of the SignedData references the certificate, either by
+// issuer and serial number or by subject key identifier. The signature itself is not verified.
+func IsSignedBy(data []byte, cert *x509.Certificate) (bool, error) {
+	if cert == nil {
+		return false, errors.New("certificate is nil")
+	}
+
+	sd, err := loadSignedData(data)
+	if err != nil {
+		return false, err
+	}
+
+	for _, si := range sd.SignerInfos {
+		if matchesCertificate(si.SID, cert) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,208 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSigningTimes tests extraction of signingTime attributes from SignedData
+func TestSigningTimes(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	first := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	second := time.Date(2051, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	signers := []signerInfo{
+		createSignerInfo(
+			t, sha256OID, rsaOID,
+			[]attribute{
+				createAttribute(t, ContentTypeAttributeOID, PKCS7DataOID),
+				createAttribute(t, SigningTimeAttributeOID, first),
+			},
+			nil,
+		),
+		createSignerInfo(t, sha256OID, rsaOID, []attribute{createAttribute(t, SigningTimeAttributeOID, second)}, nil),
+		createSignerInfo(t, sha256OID, rsaOID, nil, nil),
+	}
+
+	times, err := SigningTimes(createSignedDataWithSigners(t, signers))
+	if err != nil {
+		t.Fatalf("SigningTimes returned an error: %v", err)
+	}
+
+	if len(times) != 2 {
+		t.Fatalf("Expected 2 signing times, got %d", len(times))
+	}
+
+	// DER encoding sorts the SET OF SignerInfo, so the order of signers is not preserved
+	if times[0].After(times[1]) {
+		times[0], times[1] = times[1], times[0]
+	}
+
+	if !times[0].Equal(first) || !times[1].Equal(second) {
+		t.Errorf("Expected signing times %v and %v, got %v", first, second, times)
+	}
+}
+
+// TestSigningTimesInvalidData tests SigningTimes with data other than SignedData
+func TestSigningTimesInvalidData(t *testing.T) {
+	if _, err := SigningTimes(createTestData(t, PKCS7DataOID)); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+
+	if _, err := SigningTimes([]byte{0x01, 0x02, 0x03}); err == nil {
+		t.Error("Expected error for invalid data, got nil")
+	}
+}
+
+// TestCountSignatures tests counting of co-signatures and countersignatures
+func TestCountSignatures(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+	nested := createSignerInfo(t, sha256OID, rsaOID, nil, nil)
+	counterSigner := createSignerInfo(t, sha256OID, rsaOID, nil, []attribute{createAttribute(t, CounterSignatureOID, nested)})
+	signer := createSignerInfo(t, sha256OID, rsaOID, nil, []attribute{createAttribute(t, CounterSignatureOID, counterSigner)})
+
+	tests := []struct {
+		name          string
+		signers       []signerInfo
+		expected      SignatureCounts
+		expectedMulti bool
+	}{
+		{
+			name:          "Single signer",
+			signers:       []signerInfo{createSignerInfo(t, sha256OID, rsaOID, nil, nil)},
+			expected:      SignatureCounts{Signers: 1},
+			expectedMulti: false,
+		},
+		{
+			name: "Co-signed",
+			signers: []signerInfo{
+				createSignerInfo(t, sha256OID, rsaOID, nil, nil),
+				createSignerInfo(t, sha256OID, rsaOID, nil, nil),
+			},
+			expected:      SignatureCounts{Signers: 2},
+			expectedMulti: true,
+		},
+		{
+			name:          "Nested countersignatures",
+			signers:       []signerInfo{signer},
+			expected:      SignatureCounts{Signers: 1, CounterSignatures: 2},
+			expectedMulti: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				counts, err := CountSignatures(createSignedDataWithSigners(t, tt.signers))
+				if err != nil {
+					t.Fatalf("CountSignatures returned an error: %v", err)
+				}
+
+				if counts != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, counts)
+				}
+
+				if counts.IsMultiSigned() != tt.expectedMulti {
+					t.Errorf("Expected IsMultiSigned %v, got %v", tt.expectedMulti, counts.IsMultiSigned())
+				}
+			},
+		)
+	}
+}
+
+// TestIsSignedBy tests matching of signers against certificates
+func TestIsSignedBy(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	ecdsaOID := asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(createCertificate(t, "Signer", key))
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	other, err := x509.ParseCertificate(createCertificate(t, "Other", key))
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	issuerAndSerial, err := asn1.Marshal(
+		issuerAndSerialNumber{
+			Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+			SerialNumber: cert.SerialNumber,
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal issuer and serial number: %v", err)
+	}
+
+	byIssuer := createSignerInfo(t, sha256OID, ecdsaOID, nil, nil)
+	byIssuer.SID = asn1.RawValue{FullBytes: issuerAndSerial}
+
+	bySKI := createSignerInfo(t, sha256OID, ecdsaOID, nil, nil)
+	bySKI.SID = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: cert.SubjectKeyId}
+
+	tests := []struct {
+		name     string
+		signer   signerInfo
+		cert     *x509.Certificate
+		expected bool
+	}{
+		{
+			name:     "Issuer and serial number",
+			signer:   byIssuer,
+			cert:     cert,
+			expected: true,
+		},
+		{
+			name:     "Subject key identifier",
+			signer:   bySKI,
+			cert:     cert,
+			expected: true,
+		},
+		{
+			name:     "Different issuer",
+			signer:   byIssuer,
+			cert:     other,
+			expected: false,
+		},
+		{
+			name:     "Different subject key identifier",
+			signer:   bySKI,
+			cert:     other,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				signed, err := IsSignedBy(createSignedDataWithSigners(t, []signerInfo{tt.signer}), tt.cert)
+				if err != nil {
+					t.Fatalf("IsSignedBy returned an error: %v", err)
+				}
+
+				if signed != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, signed)
+				}
+			},
+		)
+	}
+
+	if _, err := IsSignedBy(createTestData(t, PKCS7DataOID), cert); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+}
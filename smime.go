@@ -0,0 +1,195 @@
+package cmsdetector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+)
+
+// S/MIME media types (RFC 8551, section 3.2)
+const (
+	MediaTypePKCS7MIME       = "application/pkcs7-mime"
+	MediaTypePKCS7Signature  = "application/pkcs7-signature"
+	mediaTypeXPKCS7MIME      = "application/x-pkcs7-mime"
+	mediaTypeXPKCS7Signature = "application/x-pkcs7-signature"
+	mediaTypeMultipartSigned = "multipart/signed"
+)
+
+// maxMultipartParts limits the number of inspected parts of a multipart/signed message
+const maxMultipartParts = 16
+
+// ErrNotSMIME is returned when the data is not an S/MIME entity
+var ErrNotSMIME = errors.New("not an S/MIME entity")
+
+// SMIMEResult contains the result of S/MIME detection
+type SMIMEResult struct {
+	MediaType string          // Media type of the CMS part, e.g. application/pkcs7-mime
+	SMIMEType string          // Value of the smime-type parameter (enveloped-data, signed-data, certs-only...), if any
+	Detached  bool            // Indicates a multipart/signed message with a detached signature
+	Inner     DetectionResult // Detection result of the unwrapped CMS structure
+}
+
+// DetectSMIME unwraps a MIME entity carrying CMS (application/pkcs7-mime, application/pkcs7-signature
+// or multipart/signed) and detects the type of the inner CMS structure
+func DetectSMIME(data []byte) (SMIMEResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return SMIMEResult{}, err
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return SMIMEResult{}, fmt.Errorf("failed to parse MIME header: %w", err)
+	}
+
+	body, err := readAll(reader.R)
+	if err != nil {
+		return SMIMEResult{}, fmt.Errorf("failed to read MIME body: %w", err)
+	}
+
+	return detectSMIMEEntity(header, body)
+}
+
+// detectSMIMEEntity detects the CMS structure of a MIME entity with the given header and raw body
+func detectSMIMEEntity(header textproto.MIMEHeader, body []byte) (SMIMEResult, error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return SMIMEResult{}, ErrNotSMIME
+	}
+
+	if mediaType == mediaTypeMultipartSigned {
+		return detectMultipartSigned(params["boundary"], body)
+	}
+
+	if !isSMIMEMediaType(mediaType) {
+		return SMIMEResult{}, ErrNotSMIME
+	}
+
+	decoded, err := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return SMIMEResult{}, err
+	}
+
+	inner, err := Detect(decoded)
+	if err != nil {
+		return SMIMEResult{}, err
+	}
+
+	return SMIMEResult{
+		MediaType: normalizeSMIMEMediaType(mediaType),
+		SMIMEType: strings.ToLower(params["smime-type"]),
+		Inner:     inner,
+	}, nil
+}
+
+// detectMultipartSigned detects the detached signature part of a multipart/signed message
+func detectMultipartSigned(boundary string, body []byte) (SMIMEResult, error) {
+	if boundary == "" {
+		return SMIMEResult{}, ErrNotSMIME
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	for i := 0; i < maxMultipartParts; i++ {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			break
+		}
+
+		mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil || !isSMIMEMediaType(mediaType) {
+			continue
+		}
+
+		partBody, err := readAll(part)
+		if err != nil {
+			return SMIMEResult{}, fmt.Errorf("failed to read signature part: %w", err)
+		}
+
+		result, err := detectSMIMEEntity(part.Header, partBody)
+		if err != nil {
+			return SMIMEResult{}, err
+		}
+
+		result.Detached = true
+
+		return result, nil
+	}
+
+	return SMIMEResult{}, ErrNotSMIME
+}
+
+// isSMIMEMediaType checks if the media type carries CMS, including legacy x- types
+func isSMIMEMediaType(mediaType string) bool {
+	switch mediaType {
+	case MediaTypePKCS7MIME, MediaTypePKCS7Signature, mediaTypeXPKCS7MIME, mediaTypeXPKCS7Signature:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeSMIMEMediaType maps legacy x- media types to their registered names
+func normalizeSMIMEMediaType(mediaType string) string {
+	switch mediaType {
+	case mediaTypeXPKCS7MIME:
+		return MediaTypePKCS7MIME
+	case mediaTypeXPKCS7Signature:
+		return MediaTypePKCS7Signature
+	default:
+		return mediaType
+	}
+}
+
+// decodeTransferEncoding decodes the MIME body according to its Content-Transfer-Encoding
+func decodeTransferEncoding(encoding string, body []byte) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// Line breaks and other whitespace are not part of the base64 alphabet
+		scratch := getScratch()
+		defer putScratch(scratch)
+
+		cleaned := *scratch
+		for _, c := range body {
+			if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+				cleaned = append(cleaned, c)
+			}
+		}
+
+		*scratch = cleaned
+
+		size := base64.StdEncoding.DecodedLen(len(cleaned))
+		if err := checkAllocation(size); err != nil {
+			return nil, err
+		}
+
+		decoded := make([]byte, size)
+
+		n, err := base64.StdEncoding.Decode(decoded, cleaned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 body: %w", err)
+		}
+
+		return decoded[:n], nil
+	case "quoted-printable":
+		decoded, err := readAll(quotedprintable.NewReader(bytes.NewReader(body)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode quoted-printable body: %w", err)
+		}
+
+		return decoded, nil
+	case "", "7bit", "8bit", "binary":
+		return body, nil
+	default:
+		return nil, fmt.Errorf("unsupported transfer encoding: %s", encoding)
+	}
+}
@@ -0,0 +1,147 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+)
+
+// wrapBase64 encodes the data as base64 with 76 character lines
+func wrapBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+
+	lines = append(lines, encoded)
+
+	return strings.Join(lines, "\r\n")
+}
+
+// encodeQuotedPrintable encodes binary data with the quoted-printable transfer encoding
+func encodeQuotedPrintable(t *testing.T, data []byte) string {
+	t.Helper()
+
+	var b strings.Builder
+
+	w := quotedprintable.NewWriter(&b)
+	w.Binary = true
+
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to encode quoted-printable: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to encode quoted-printable: %v", err)
+	}
+
+	return b.String()
+}
+
+// TestDetectSMIME tests detection of MIME-wrapped CMS
+func TestDetectSMIME(t *testing.T) {
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	signed := createSignedData(t, sha256OID, rsaOID)
+	enveloped := createEnvelopedData(t, rsaOID, aesOID)
+
+	tests := []struct {
+		name              string
+		message           string
+		expectedMediaType string
+		expectedSMIMEType string
+		expectedDetached  bool
+		expectedType      string
+	}{
+		{
+			name: "Enveloped data",
+			message: "Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name=smime.p7m\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(enveloped),
+			expectedMediaType: MediaTypePKCS7MIME,
+			expectedSMIMEType: "enveloped-data",
+			expectedType:      "PKCS#7 Enveloped Data",
+		},
+		{
+			name: "Legacy signed data",
+			message: "Content-Type: application/x-pkcs7-mime; smime-type=Signed-Data\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(signed),
+			expectedMediaType: MediaTypePKCS7MIME,
+			expectedSMIMEType: "signed-data",
+			expectedType:      "PKCS#7 Signed Data",
+		},
+		{
+			name: "Binary signature",
+			message: "Content-Type: application/pkcs7-signature\r\n" +
+				"Content-Transfer-Encoding: binary\r\n\r\n" + string(signed),
+			expectedMediaType: MediaTypePKCS7Signature,
+			expectedType:      "PKCS#7 Signed Data",
+		},
+		{
+			name: "Quoted-printable signature",
+			message: "Content-Type: application/pkcs7-signature\r\n" +
+				"Content-Transfer-Encoding: quoted-printable\r\n\r\n" + encodeQuotedPrintable(t, signed),
+			expectedMediaType: MediaTypePKCS7Signature,
+			expectedType:      "PKCS#7 Signed Data",
+		},
+		{
+			name: "Multipart signed",
+			message: "MIME-Version: 1.0\r\n" +
+				"Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; " +
+				"micalg=sha-256; boundary=\"boundary42\"\r\n\r\n" +
+				"--boundary42\r\nContent-Type: text/plain\r\n\r\nHello\r\n" +
+				"--boundary42\r\nContent-Type: application/pkcs7-signature; name=smime.p7s\r\n" +
+				"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(signed) + "\r\n" +
+				"--boundary42--\r\n",
+			expectedMediaType: MediaTypePKCS7Signature,
+			expectedDetached:  true,
+			expectedType:      "PKCS#7 Signed Data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectSMIME([]byte(tt.message))
+				if err != nil {
+					t.Fatalf("DetectSMIME returned an error: %v", err)
+				}
+
+				if result.MediaType != tt.expectedMediaType {
+					t.Errorf("Expected media type %s, got %s", tt.expectedMediaType, result.MediaType)
+				}
+
+				if result.SMIMEType != tt.expectedSMIMEType {
+					t.Errorf("Expected smime-type %q, got %q", tt.expectedSMIMEType, result.SMIMEType)
+				}
+
+				if result.Detached != tt.expectedDetached {
+					t.Errorf("Expected detached %v, got %v", tt.expectedDetached, result.Detached)
+				}
+
+				if result.Inner.Type != tt.expectedType {
+					t.Errorf("Expected inner type %s, got %s", tt.expectedType, result.Inner.Type)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectSMIMEInvalidData tests DetectSMIME with entities that are not S/MIME
+func TestDetectSMIMEInvalidData(t *testing.T) {
+	if _, err := DetectSMIME([]byte("Content-Type: text/plain\r\n\r\nHello")); !errors.Is(err, ErrNotSMIME) {
+		t.Errorf("Expected ErrNotSMIME, got %v", err)
+	}
+
+	message := "Content-Type: application/pkcs7-mime\r\nContent-Transfer-Encoding: base64\r\n\r\n!!!"
+	if _, err := DetectSMIME([]byte(message)); err == nil {
+		t.Error("Expected error for invalid base64, got nil")
+	}
+}
@@ -0,0 +1,181 @@
+package cmsdetector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+)
+
+const (
+	// pemTypeOpenSSHPrivateKey is the PEM block type of OpenSSH private keys
+	pemTypeOpenSSHPrivateKey = "OPENSSH PRIVATE KEY"
+
+	// openSSHKeyMagic starts the body of an OpenSSH private key (PROTOCOL.key)
+	openSSHKeyMagic = "openssh-key-v1\x00"
+
+	// puttyKeyPrefix starts the first line of a PuTTY private key file
+	puttyKeyPrefix = "PuTTY-User-Key-File-"
+
+	// sshCipherNone is the cipher name of unencrypted keys in OpenSSH and PuTTY formats
+	sshCipherNone = "none"
+)
+
+// sshKeyTypePrefixes contains the prefixes of SSH public key algorithm names
+var sshKeyTypePrefixes = []string{"ssh-", "ecdsa-sha2-", "sk-ssh-", "sk-ecdsa-sha2-"}
+
+// ErrNotSSHKey is returned when the data is not a supported SSH key
+var ErrNotSSHKey = errors.New("not a supported SSH key")
+
+// SSHKeyResult describes a detected SSH key file
+type SSHKeyResult struct {
+	Kind      Kind   // KindOpenSSHPrivateKey, KindSSHPublicKey or KindPuTTYPrivateKey
+	Algorithm string // SSH key algorithm, e.g. "ssh-ed25519"
+	Encrypted bool   // Indicates if a passphrase is required to use the key
+	Comment   string // Key comment, if stored unencrypted
+}
+
+// DetectSSHKey detects OpenSSH private keys, authorized_keys-format public keys and PuTTY .ppk files
+func DetectSSHKey(data []byte) (SSHKeyResult, error) {
+	if err := checkInputSize(data); err != nil {
+		return SSHKeyResult{}, err
+	}
+
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN "+pemTypeOpenSSHPrivateKey)):
+		return parseOpenSSHPrivateKey(trimmed)
+	case bytes.HasPrefix(trimmed, []byte(puttyKeyPrefix)):
+		return parsePuTTYKey(trimmed)
+	}
+
+	return parseAuthorizedKey(trimmed)
+}
+
+// parseOpenSSHPrivateKey reads the cipher and key type of an OpenSSH private key
+func parseOpenSSHPrivateKey(data []byte) (SSHKeyResult, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != pemTypeOpenSSHPrivateKey || !bytes.HasPrefix(block.Bytes, []byte(openSSHKeyMagic)) {
+		return SSHKeyResult{}, ErrNotSSHKey
+	}
+
+	r := &binaryReader{data: block.Bytes[len(openSSHKeyMagic):]}
+	cipherName := string(r.readBytes())
+	r.readBytes() // KDF name
+	r.readBytes() // KDF options
+
+	if keys := r.readUint32(); keys == 0 {
+		return SSHKeyResult{}, ErrNotSSHKey
+	}
+
+	publicKey := r.readBytes()
+	if r.err != nil {
+		return SSHKeyResult{}, ErrNotSSHKey
+	}
+
+	return SSHKeyResult{
+		Kind:      KindOpenSSHPrivateKey,
+		Algorithm: sshKeyType(publicKey),
+		Encrypted: cipherName != sshCipherNone,
+	}, nil
+}
+
+// parsePuTTYKey reads the header lines of a PuTTY private key file
+func parsePuTTYKey(data []byte) (SSHKeyResult, error) {
+	result := SSHKeyResult{Kind: KindPuTTYPrivateKey}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		name, value, ok := cutHeader(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(name, puttyKeyPrefix):
+			result.Algorithm = value
+		case name == "Encryption":
+			result.Encrypted = value != sshCipherNone
+		case name == "Comment":
+			result.Comment = value
+		}
+	}
+
+	if result.Algorithm == "" {
+		return SSHKeyResult{}, ErrNotSSHKey
+	}
+
+	return result, nil
+}
+
+// cutHeader splits a "Name: value" line
+func cutHeader(line string) (string, string, bool) {
+	i := strings.Index(line, ": ")
+	if i < 0 {
+		return "", "", false
+	}
+
+	return line[:i], strings.TrimSpace(line[i+2:]), true
+}
+
+// parseAuthorizedKey parses the first key of an authorized_keys file or a .pub file
+func parseAuthorizedKey(data []byte) (SSHKeyResult, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		// The key type may be preceded by options
+		for i := 0; i+1 < len(fields); i++ {
+			if !isSSHKeyType(fields[i]) {
+				continue
+			}
+
+			blob, err := base64.StdEncoding.DecodeString(fields[i+1])
+			if err != nil || sshKeyType(blob) != fields[i] {
+				return SSHKeyResult{}, ErrNotSSHKey
+			}
+
+			return SSHKeyResult{
+				Kind:      KindSSHPublicKey,
+				Algorithm: fields[i],
+				Comment:   strings.Join(fields[i+2:], " "),
+			}, nil
+		}
+
+		return SSHKeyResult{}, ErrNotSSHKey
+	}
+
+	return SSHKeyResult{}, ErrNotSSHKey
+}
+
+// isSSHKeyType checks if the name looks like an SSH public key algorithm
+func isSSHKeyType(name string) bool {
+	for _, prefix := range sshKeyTypePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sshKeyType returns the key type stored at the start of an SSH public key blob
+func sshKeyType(blob []byte) string {
+	r := &binaryReader{data: blob}
+
+	keyType := r.readBytes()
+	if r.err != nil {
+		return ""
+	}
+
+	return string(keyType)
+}
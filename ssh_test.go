@@ -0,0 +1,111 @@
+package cmsdetector
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+// createSSHPublicKeyBlob creates an ssh-ed25519 public key in SSH wire format
+func createSSHPublicKeyBlob() []byte {
+	var w binaryWriter
+
+	w.writeBytes([]byte("ssh-ed25519"))
+	w.writeBytes(make([]byte, 32))
+
+	return w.Bytes()
+}
+
+// createOpenSSHPrivateKey creates a PEM encoded OpenSSH private key using the given cipher
+func createOpenSSHPrivateKey(cipherName string) []byte {
+	var w binaryWriter
+
+	w.WriteString(openSSHKeyMagic)
+	w.writeBytes([]byte(cipherName))
+
+	if cipherName == sshCipherNone {
+		w.writeBytes([]byte("none"))
+		w.writeBytes(nil)
+	} else {
+		w.writeBytes([]byte("bcrypt"))
+		w.writeBytes(make([]byte, 24))
+	}
+
+	w.writeUint32(1)
+	w.writeBytes(createSSHPublicKeyBlob())
+	w.writeBytes(make([]byte, 64))
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeOpenSSHPrivateKey, Bytes: w.Bytes()})
+}
+
+// TestDetectSSHKey tests detection of OpenSSH, authorized_keys and PuTTY key formats
+func TestDetectSSHKey(t *testing.T) {
+	publicKey := base64.StdEncoding.EncodeToString(createSSHPublicKeyBlob())
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expected    SSHKeyResult
+		expectedErr error
+	}{
+		{
+			name:     "OpenSSH private key",
+			data:     createOpenSSHPrivateKey(sshCipherNone),
+			expected: SSHKeyResult{Kind: KindOpenSSHPrivateKey, Algorithm: "ssh-ed25519"},
+		},
+		{
+			name:     "Encrypted OpenSSH private key",
+			data:     createOpenSSHPrivateKey("aes256-ctr"),
+			expected: SSHKeyResult{Kind: KindOpenSSHPrivateKey, Algorithm: "ssh-ed25519", Encrypted: true},
+		},
+		{
+			name:     "Public key",
+			data:     []byte("ssh-ed25519 " + publicKey + " user@host\n"),
+			expected: SSHKeyResult{Kind: KindSSHPublicKey, Algorithm: "ssh-ed25519", Comment: "user@host"},
+		},
+		{
+			name:     "authorized_keys with options",
+			data:     []byte("# keys\n\nfrom=\"10.0.0.1\",no-pty ssh-ed25519 " + publicKey + "\n"),
+			expected: SSHKeyResult{Kind: KindSSHPublicKey, Algorithm: "ssh-ed25519"},
+		},
+		{
+			name:        "Mismatched public key type",
+			data:        []byte("ssh-rsa " + publicKey),
+			expectedErr: ErrNotSSHKey,
+		},
+		{
+			name: "PuTTY key",
+			data: []byte(
+				"PuTTY-User-Key-File-3: ssh-rsa\r\nEncryption: aes256-cbc\r\nComment: rsa-key-20240101\r\n" +
+					"Public-Lines: 1\r\nAAAAB3NzaC1yc2E=\r\n",
+			),
+			expected: SSHKeyResult{Kind: KindPuTTYPrivateKey, Algorithm: "ssh-rsa", Encrypted: true, Comment: "rsa-key-20240101"},
+		},
+		{
+			name:     "Unencrypted PuTTY key",
+			data:     []byte("PuTTY-User-Key-File-2: ssh-ed25519\nEncryption: none\nComment: key\n"),
+			expected: SSHKeyResult{Kind: KindPuTTYPrivateKey, Algorithm: "ssh-ed25519", Comment: "key"},
+		},
+		{
+			name:        "PKCS#7 Signed Data",
+			data:        createTestData(t, PKCS7SignedDataOID),
+			expectedErr: ErrNotSSHKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectSSHKey(tt.data)
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,378 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Strictness selects how strictly a Detector enforces the encoding rules of CMS/PKCS structures
+type Strictness int
+
+// Strictness levels of a Detector. The zero value is StrictnessStandard
+const (
+	StrictnessStandard Strictness = iota // DER as accepted by Detect, trailing data is ignored
+	StrictnessLenient                    // Also accepts BER and non-minimal INTEGER and BOOLEAN encodings
+	StrictnessStrict                     // Rejects structures Lint finds not to be canonical DER per RFC 5652
+)
+
+// String returns the name of the strictness level
+func (s Strictness) String() string {
+	switch s {
+	case StrictnessStandard:
+		return "standard"
+	case StrictnessLenient:
+		return "lenient"
+	case StrictnessStrict:
+		return "strict"
+	default:
+		return fmt.Sprintf("Strictness(%d)", int(s))
+	}
+}
+
+// ErrNonConforming is returned by a strict Detector, wrapped in NonConformingError, for structures
+// that are not canonical DER or violate RFC 5652
+var ErrNonConforming = errors.New("structure does not conform to DER and RFC 5652")
+
+// NonConformingError is returned by a strict Detector with the diagnostics causing the rejection
+type NonConformingError struct {
+	Diagnostics []Diagnostic
+}
+
+// Error implements the error interface
+func (e *NonConformingError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrNonConforming, e.Diagnostics[0])
+}
+
+// Unwrap allows errors.Is(err, ErrNonConforming)
+func (e *NonConformingError) Unwrap() error {
+	return ErrNonConforming
+}
+
+// strictWarnings lists the rules of warnings rejected by a strict Detector besides all errors.
+// Unlisted digest algorithms and PKCS #7 v1.5 version numbers are tolerated
+var strictWarnings = map[string]bool{
+	RuleBEREncoding:        true,
+	RuleNonCanonicalLength: true,
+	RuleUnsortedSet:        true,
+	RuleTrailingData:       true,
+}
+
+// Detector detects CMS/PKCS data with a selectable strictness, e.g. for gateways enforcing a policy
+// on accepted encodings, and user-defined rules. A Detector is created by NewDetector and cannot be
+// changed afterwards, the zero value behaves like the package-level functions. A Detector is safe
+// for concurrent use, concurrent calls call the hooks concurrently
+type Detector struct {
+	strictness Strictness
+	rules      *RuleSet // User-defined rules applied by DetectAny
+	ruleSource *RuleSet // RuleSet the rules were copied from, identifying them in cache keys
+	cache      *Cache   // Results of inputs seen before, nil to classify every input
+	hooks      Hooks    // Detection callbacks, called with a ScannedFile without a path
+}
+
+// DetectorOption configures a Detector created by NewDetector
+type DetectorOption func(*Detector)
+
+// NewDetector creates a Detector with the options, which are copied so that the Detector does not
+// change afterwards. Detectors with other settings may share the Cache and RuleSet
+func NewDetector(opts ...DetectorOption) *Detector {
+	d := &Detector{}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// WithStrictness sets the strictness level, StrictnessStandard by default
+func WithStrictness(strictness Strictness) DetectorOption {
+	return func(d *Detector) {
+		d.strictness = strictness
+	}
+}
+
+// WithRules sets the user-defined rules applied by DetectAny
+func WithRules(rules *RuleSet) DetectorOption {
+	return func(d *Detector) {
+		d.rules, d.ruleSource = nil, rules
+		if rules != nil {
+			d.rules = &RuleSet{rules: append([]compiledRule(nil), rules.rules...)}
+		}
+	}
+}
+
+// WithCache sets the cache of results of inputs seen before, by default every input is classified
+func WithCache(cache *Cache) DetectorOption {
+	return func(d *Detector) {
+		d.cache = cache
+	}
+}
+
+// WithHooks sets the detection callbacks, e.g. LogHooks
+func WithHooks(hooks Hooks) DetectorOption {
+	return func(d *Detector) {
+		d.hooks = hooks
+	}
+}
+
+// Detect determines the type of CMS/PKCS data like the package-level Detect, applying the strictness level
+func (d *Detector) Detect(data []byte) (result DetectionResult, err error) {
+	defer recoverPanic(&err)
+
+	if d.cache == nil || checkInputSize(data) != nil {
+		result, err = d.detect(data)
+	} else {
+		key := d.cacheKey(cacheDetect, data)
+
+		entry, ok := d.cache.get(key)
+		if !ok {
+			entry = cacheEntry{key: key}
+			entry.detection, entry.err = d.detect(data)
+			d.cache.add(entry)
+		}
+
+		result, err = entry.detection, entry.err
+	}
+
+	recordDetection(len(data), result.Kind, result.Kind == KindEncryptedPKCS12, err)
+
+	if d.hooks.enabled() {
+		file := ScannedFile{Size: int64(len(data)), Err: err}
+		if err == nil {
+			file.Result = AnyResult{Family: FamilyCMS, Kind: result.Kind, Confidence: ConfidenceHigh, NeedsPassword: result.NeedsPassword}
+			file.ContentType, file.Encrypted = result.ContentType, result.IsEncrypted
+
+			// Encrypted PKCS#12 containers are only recognized by heuristics
+			if result.Kind == KindEncryptedPKCS12 {
+				file.Result.Confidence = ConfidenceLow
+			}
+		}
+
+		d.hooks.detection(file)
+	}
+
+	return result, err
+}
+
+// detect determines the type of CMS/PKCS data without reporting metrics
+func (d *Detector) detect(data []byte) (DetectionResult, error) {
+	if d.strictness == StrictnessStrict {
+		if err := checkConformance(data); err != nil {
+			return DetectionResult{}, err
+		}
+	}
+
+	result, err := detect(data)
+
+	// BER structures fail to parse, or match the encrypted PKCS#12 heuristics at best
+	if d.strictness != StrictnessLenient || err == nil && result.Kind != KindEncryptedPKCS12 {
+		return result, err
+	}
+
+	if der, ok := berToDER(data); ok {
+		if converted, convertedErr := detect(der); convertedErr == nil && converted.Kind != KindEncryptedPKCS12 {
+			return converted, nil
+		}
+	}
+
+	return result, err
+}
+
+// DetectAny tries every format family supported by the package like the package-level DetectAny.
+// The strictness level applies to CMS/PKCS structures, also when PEM encoded. The first matching
+// user-defined rule is reported in AnyResult.Rule, data of unknown format matching a rule is
+// reported as FamilyCustom
+func (d *Detector) DetectAny(data []byte) (result AnyResult, err error) {
+	defer recoverPanic(&err)
+
+	if d.cache == nil || checkInputSize(data) != nil {
+		result, err = d.annotatedDetectAny(data)
+	} else {
+		key := d.cacheKey(cacheDetectAny, data)
+
+		entry, ok := d.cache.get(key)
+		if !ok {
+			entry = cacheEntry{key: key}
+			entry.any, entry.err = d.annotatedDetectAny(data)
+			d.cache.add(entry)
+		}
+
+		result, err = entry.any, entry.err
+	}
+
+	recordDetection(len(data), result.Kind, result.Confidence < ConfidenceHigh, err)
+
+	if d.hooks.enabled() {
+		d.hooks.detection(ScannedFile{Size: int64(len(data)), Result: result, Encrypted: err == nil && encryptedKinds[result.Kind], Err: err})
+	}
+
+	return result, err
+}
+
+// annotatedDetectAny returns the best match for the data with the first matching user-defined rule
+func (d *Detector) annotatedDetectAny(data []byte) (AnyResult, error) {
+	result, err := d.detectAny(data)
+	if d.rules != nil {
+		result, err = d.rules.annotate(data, result, err)
+	}
+
+	return result, err
+}
+
+// detectAny returns the best match for the data without reporting metrics
+func (d *Detector) detectAny(data []byte) (AnyResult, error) {
+	result, err := detectAny(data)
+
+	if d.strictness == StrictnessLenient && (err != nil || result.Confidence < ConfidenceHigh) {
+		if der, ok := berToDER(data); ok {
+			if converted, convertedErr := detectAny(der); convertedErr == nil && converted.Confidence == ConfidenceHigh {
+				return converted, nil
+			}
+		}
+	}
+
+	if err != nil || d.strictness != StrictnessStrict || result.Family != FamilyCMS {
+		return result, err
+	}
+
+	der := data
+	if result.PEMType != "" {
+		if block, _ := pem.Decode(data); block != nil {
+			der = block.Bytes
+		}
+	}
+
+	if err := checkConformance(der); err != nil {
+		return AnyResult{}, err
+	}
+
+	return result, nil
+}
+
+// checkConformance returns a NonConformingError if Lint reports an error or an encoding warning
+func checkConformance(data []byte) error {
+	if err := checkInputSize(data); err != nil {
+		return err
+	}
+
+	var rejected []Diagnostic
+
+	for _, d := range Lint(data) {
+		if d.Severity == SeverityError || strictWarnings[d.Rule] {
+			rejected = append(rejected, d)
+		}
+	}
+
+	if len(rejected) > 0 {
+		return &NonConformingError{Diagnostics: rejected}
+	}
+
+	return nil
+}
+
+// ToDER re-encodes a BER encoded structure in DER for parsers built on encoding/asn1, which
+// reject indefinite lengths and constructed strings. Data following the structure is dropped
+func ToDER(data []byte) (der []byte, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return nil, err
+	}
+
+	l := &linter{data: data}
+
+	root, lintErr := l.parse(0, len(data), 0)
+	if lintErr != nil {
+		return nil, fmt.Errorf("invalid BER encoding at offset %d: %s", lintErr.offset, lintErr.message)
+	}
+
+	return encodeDER(root)
+}
+
+// berToDER re-encodes the BER element at the start of data in DER: definite minimal lengths,
+// primitive strings and minimal INTEGER and BOOLEAN encodings. SET OF elements are not sorted
+func berToDER(data []byte) ([]byte, bool) {
+	l := &linter{data: data}
+
+	root, err := l.parse(0, len(data), 0)
+	if err != nil {
+		return nil, false
+	}
+
+	der, encodeErr := encodeDER(root)
+
+	return der, encodeErr == nil
+}
+
+// encodeDER returns the DER encoding of the element, built in a scratch buffer and failing with
+// ErrInputTooLarge if it exceeds the maximum allocation
+func encodeDER(root *berElement) ([]byte, error) {
+	scratch := getScratch()
+	defer putScratch(scratch)
+
+	*scratch = appendDER(*scratch, root)
+	if err := checkAllocation(len(*scratch)); err != nil {
+		return nil, err
+	}
+
+	return append(make([]byte, 0, len(*scratch)), *scratch...), nil
+}
+
+// appendDER appends the DER encoding of the element
+func appendDER(b []byte, e *berElement) []byte {
+	identifier, contents := e.identifier, e.contents
+
+	switch {
+	case e.constructed && e.class == asn1.ClassUniversal && e.tag != asn1.TagBitString && constructedStringTags[e.tag] != "":
+		// Strings are the concatenation of their segments
+		identifier = []byte{identifier[0] &^ 0x20}
+
+		scratch := getScratch()
+		defer putScratch(scratch)
+
+		*scratch = appendSegments(*scratch, e)
+		contents = *scratch
+	case e.constructed:
+		scratch := getScratch()
+		defer putScratch(scratch)
+
+		for _, child := range e.children {
+			*scratch = appendDER(*scratch, child)
+		}
+
+		contents = *scratch
+	case e.class == asn1.ClassUniversal && e.tag == asn1.TagInteger:
+		contents = minimalInteger(contents)
+	case e.class == asn1.ClassUniversal && e.tag == asn1.TagBoolean && len(contents) == 1 && contents[0] != 0:
+		contents = []byte{0xff}
+	}
+
+	b = append(b, identifier...)
+	b = appendDERLength(b, len(contents))
+
+	return append(b, contents...)
+}
+
+// appendSegments appends the contents of the primitive segments of a constructed string
+func appendSegments(b []byte, e *berElement) []byte {
+	if !e.constructed {
+		return append(b, e.contents...)
+	}
+
+	for _, child := range e.children {
+		b = appendSegments(b, child)
+	}
+
+	return b
+}
+
+// minimalInteger removes redundant leading octets of the two's complement encoding of an INTEGER
+func minimalInteger(contents []byte) []byte {
+	for len(contents) > 1 &&
+		(contents[0] == 0x00 && contents[1]&0x80 == 0 || contents[0] == 0xff && contents[1]&0x80 != 0) {
+		contents = contents[1:]
+	}
+
+	return contents
+}
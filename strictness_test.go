@@ -0,0 +1,397 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// TestNewDetector tests that the options are copied, so a Detector does not change once created
+func TestNewDetector(t *testing.T) {
+	rules := loadTestRules(t)
+	detector := NewDetector(WithStrictness(StrictnessStrict), WithRules(rules), WithCache(NewCache(8)))
+
+	if detector.strictness != StrictnessStrict || detector.cache == nil {
+		t.Errorf("Expected strict detector with a cache, got %+v", detector)
+	}
+
+	// Changing the rule set the detector was created with does not change the detector
+	rules.rules[0] = compiledRule{name: "Changed", condition: rules.rules[0].condition}
+
+	result, err := detector.DetectAny([]byte("ACME\x00\x01 container"))
+	if err != nil {
+		t.Fatalf("DetectAny returned an error: %v", err)
+	}
+
+	if result.Rule != "Acme container" {
+		t.Errorf("Expected rule %q, got %q", "Acme container", result.Rule)
+	}
+}
+
+// TestDetectorStrictness tests Detect with every strictness level
+func TestDetectorStrictness(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		strictness Strictness
+		expected   Kind
+		expectErr  bool
+	}{
+		{name: "DER standard", data: signed, strictness: StrictnessStandard, expected: KindSignedData},
+		{name: "DER lenient", data: signed, strictness: StrictnessLenient, expected: KindSignedData},
+		{name: "DER strict", data: signed, strictness: StrictnessStrict, expected: KindSignedData},
+		{name: "BER standard", data: toIndefiniteLength(t, signed), strictness: StrictnessStandard, expectErr: true},
+		{name: "BER lenient", data: toIndefiniteLength(t, signed), strictness: StrictnessLenient, expected: KindSignedData},
+		{name: "BER strict", data: toIndefiniteLength(t, signed), strictness: StrictnessStrict, expectErr: true},
+		{name: "Long form length lenient", data: toLongFormLength(t, signed), strictness: StrictnessLenient, expected: KindSignedData},
+		{name: "Trailing data standard", data: append(signed[:len(signed):len(signed)], 0x00), strictness: StrictnessStandard, expected: KindSignedData},
+		{name: "Trailing data strict", data: append(signed[:len(signed):len(signed)], 0x00), strictness: StrictnessStrict, expectErr: true},
+		{
+			name:       "Version mismatch standard",
+			data:       createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			strictness: StrictnessStandard,
+			expected:   KindSignedData,
+		},
+		{
+			name:       "Version mismatch strict",
+			data:       createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID),
+			strictness: StrictnessStrict,
+			expectErr:  true,
+		},
+		{name: "PKCS #7 v1.5 version strict", data: createWindowsCatalog(t), strictness: StrictnessStrict, expected: KindWindowsCatalog},
+		{name: "PFX strict", data: cmstest.PFX(t), strictness: StrictnessStrict, expected: KindEncryptedPKCS12},
+		{name: "Garbage lenient", data: []byte{0x30, 0x80, 0x01}, strictness: StrictnessLenient, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				detector := NewDetector(WithStrictness(tt.strictness))
+
+				result, err := detector.Detect(tt.data)
+				if tt.expectErr {
+					if err == nil {
+						t.Fatalf("Expected an error, got %v", result.Kind)
+					}
+
+					if tt.strictness == StrictnessStrict && !errors.Is(err, ErrNonConforming) {
+						t.Errorf("Expected ErrNonConforming, got %v", err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("Detect returned an error: %v", err)
+				}
+
+				if result.Kind != tt.expected {
+					t.Errorf("Expected kind %v, got %v", tt.expected, result.Kind)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectorDetectAny tests DetectAny with every strictness level
+func TestDetectorDetectAny(t *testing.T) {
+	mismatch := createSignedData(t, cmstest.SHA256OID, cmstest.SHA256WithRSAOID)
+	armored := pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: mismatch})
+
+	tests := []struct {
+		name       string
+		data       []byte
+		strictness Strictness
+		expected   Kind
+		expectErr  bool
+	}{
+		{name: "BER lenient", data: toIndefiniteLength(t, cmstest.SignedData(t)), strictness: StrictnessLenient, expected: KindSignedData},
+		{name: "PEM standard", data: armored, strictness: StrictnessStandard, expected: KindSignedData},
+		{name: "PEM strict", data: armored, strictness: StrictnessStrict, expectErr: true},
+		{name: "Certificate strict", data: cmstest.Certificate(t, "Strict"), strictness: StrictnessStrict, expected: KindCertificate},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				detector := NewDetector(WithStrictness(tt.strictness))
+
+				result, err := detector.DetectAny(tt.data)
+				if tt.expectErr {
+					var nonConforming *NonConformingError
+					if !errors.As(err, &nonConforming) || len(nonConforming.Diagnostics) == 0 {
+						t.Errorf("Expected NonConformingError, got %v", err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("DetectAny returned an error: %v", err)
+				}
+
+				if result.Kind != tt.expected {
+					t.Errorf("Expected kind %v, got %v", tt.expected, result.Kind)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectorHooks tests the detection events reported by a Detector
+func TestDetectorHooks(t *testing.T) {
+	signed := cmstest.SignedData(t)
+
+	var results, heuristics []ScannedFile
+
+	detector := NewDetector(
+		WithCache(NewCache(8)),
+		WithHooks(
+			Hooks{
+				OnResult:         func(file ScannedFile) { results = append(results, file) },
+				OnHeuristicFired: func(file ScannedFile) { heuristics = append(heuristics, file) },
+			},
+		),
+	)
+
+	_, _ = detector.Detect(signed)
+	_, _ = detector.Detect(signed)
+	_, _ = detector.DetectAny(createBKS())
+	_, _ = detector.DetectAny([]byte("Hello, world"))
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+
+	for i, file := range results[:2] {
+		if file.Result.Kind != KindSignedData || file.Result.Confidence != ConfidenceHigh || !file.ContentType.Equal(PKCS7SignedDataOID) {
+			t.Errorf("Expected result %d of SignedData, got %+v", i, file)
+		}
+	}
+
+	if results[2].Result.Kind != KindBKS || results[2].Size != int64(len(createBKS())) {
+		t.Errorf("Expected BKS result, got %+v", results[2])
+	}
+
+	if !errors.Is(results[3].Err, ErrUnknownFormat) {
+		t.Errorf("Expected %v, got %v", ErrUnknownFormat, results[3].Err)
+	}
+
+	if len(heuristics) != 1 || heuristics[0].Result.Kind != KindBKS {
+		t.Errorf("Expected a heuristic match of BKS, got %+v", heuristics)
+	}
+}
+
+// TestBERToDER tests the re-encoding of BER in DER
+func TestBERToDER(t *testing.T) {
+	tests := []struct {
+		name     string
+		ber      []byte
+		expected []byte
+	}{
+		{
+			name:     "Indefinite length",
+			ber:      []byte{0x30, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00},
+			expected: []byte{0x30, 0x03, 0x02, 0x01, 0x01},
+		},
+		{
+			name:     "Long form length",
+			ber:      []byte{0x04, 0x82, 0x00, 0x01, 0x41},
+			expected: []byte{0x04, 0x01, 0x41},
+		},
+		{
+			name:     "Constructed OCTET STRING",
+			ber:      []byte{0x24, 0x80, 0x04, 0x01, 0x41, 0x04, 0x01, 0x42, 0x00, 0x00},
+			expected: []byte{0x04, 0x02, 0x41, 0x42},
+		},
+		{
+			name:     "Non-minimal INTEGER",
+			ber:      []byte{0x02, 0x03, 0x00, 0x00, 0x01},
+			expected: []byte{0x02, 0x01, 0x01},
+		},
+		{
+			name:     "Negative non-minimal INTEGER",
+			ber:      []byte{0x02, 0x02, 0xff, 0x80},
+			expected: []byte{0x02, 0x01, 0x80},
+		},
+		{
+			name:     "BOOLEAN true",
+			ber:      []byte{0x01, 0x01, 0x01},
+			expected: []byte{0x01, 0x01, 0xff},
+		},
+		{
+			name:     "High tag number",
+			ber:      []byte{0xbf, 0x81, 0x00, 0x80, 0x05, 0x00, 0x00, 0x00},
+			expected: []byte{0xbf, 0x81, 0x00, 0x02, 0x05, 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				der, ok := berToDER(tt.ber)
+				if !ok {
+					t.Fatal("Expected BER to be re-encoded")
+				}
+
+				if !bytes.Equal(der, tt.expected) {
+					t.Errorf("Expected %x, got %x", tt.expected, der)
+				}
+			},
+		)
+	}
+}
+
+// TestToDER tests the exported re-encoding of BER in DER and its errors
+func TestToDER(t *testing.T) {
+	tests := []struct {
+		name      string
+		ber       []byte
+		expected  []byte
+		expectErr bool
+	}{
+		{
+			name:     "Indefinite length with trailing data",
+			ber:      []byte{0x30, 0x80, 0x02, 0x01, 0x01, 0x00, 0x00, 0x0a},
+			expected: []byte{0x30, 0x03, 0x02, 0x01, 0x01},
+		},
+		{
+			name:      "Missing end-of-contents",
+			ber:       []byte{0x30, 0x80, 0x02, 0x01, 0x01},
+			expectErr: true,
+		},
+		{
+			name:      "Empty",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				der, err := ToDER(tt.ber)
+				if (err != nil) != tt.expectErr {
+					t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+				}
+
+				if !bytes.Equal(der, tt.expected) {
+					t.Errorf("Expected %x, got %x", tt.expected, der)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectorConcurrentUse tests that a Detector sharing a cache and rules, the inspection functions
+// and the OID registry can be used from concurrent goroutines, run with -race
+func TestDetectorConcurrentUse(t *testing.T) {
+	registered := asn1.ObjectIdentifier{1, 2, 398, 3, 10, 99, 2}
+	unregisterOIDs(t, registered.String())
+
+	err := RegisterOID(registered, OIDInfo{Name: "Local Signed Document", Category: OIDCategoryContentType, Kind: KindSignedData})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	offset := 0
+
+	rules, err := CompileRules([]Rule{{Name: "Greeting", Condition: Condition{Bytes: "48 65 6c 6c 6f", Offset: &offset}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	signed := cmstest.SignedData(t)
+	inputs := [][]byte{
+		signed,
+		cmstest.EnvelopedData(t),
+		cmstest.PFX(t),
+		pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: signed}),
+		toIndefiniteLength(t, signed),
+		createContentInfo(t, registered, []byte{0x01}),
+		[]byte("Hello, world"),
+	}
+
+	detector := NewDetector(WithStrictness(StrictnessLenient), WithRules(rules), WithCache(NewCache(2*len(inputs))))
+
+	type outcome struct {
+		detection DetectionResult
+		any       AnyResult
+		layer     *Layer
+		err       string
+	}
+
+	run := func(detector *Detector, data []byte) outcome {
+		var o outcome
+
+		detection, detectErr := detector.Detect(data)
+		anyResult, anyErr := detector.DetectAny(data)
+		layer, layerErr := InspectLayers(data)
+		_, _ = InspectAlgorithms(data)
+
+		o.detection, o.any, o.layer = detection, anyResult, layer
+		o.err = fmt.Sprint(detectErr, anyErr, layerErr)
+
+		return o
+	}
+
+	// Results of sequential calls without the cache
+	expected := make([]outcome, len(inputs))
+	originals := make([][]byte, len(inputs))
+
+	for i, data := range inputs {
+		originals[i] = append([]byte(nil), data...)
+		expected[i] = run(NewDetector(WithStrictness(detector.strictness), WithRules(rules)), data)
+	}
+
+	const goroutines = 8
+
+	others := make([]asn1.ObjectIdentifier, goroutines)
+	for g := range others {
+		others[g] = asn1.ObjectIdentifier{1, 2, 398, 3, 10, 98, g}
+		unregisterOIDs(t, others[g].String())
+	}
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			// Registrations and limit changes of other goroutines must not disturb detection
+			for i := 0; i < 20; i++ {
+				n := (g + i) % len(inputs)
+
+				if got := run(detector, inputs[n]); !reflect.DeepEqual(got, expected[n]) {
+					t.Errorf("Expected %+v, got %+v", expected[n], got)
+				}
+
+				_ = RegisterOID(others[g], OIDInfo{Name: "Other", Category: OIDCategoryContentType})
+				_, _ = LookupOID(registered)
+				SetLimits(CurrentLimits())
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	for i, data := range inputs {
+		if !bytes.Equal(data, originals[i]) {
+			t.Errorf("Expected input %d not to be modified", i)
+		}
+	}
+
+	if stats := detector.cache.Stats(); stats.Hits == 0 {
+		t.Errorf("Expected cache hits, got %+v", stats)
+	}
+}
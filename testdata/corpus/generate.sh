@@ -0,0 +1,29 @@
+#!/bin/sh
+# Regenerates the corpus of real-world structures used as fuzzing seeds and by the
+# differential tests. Requires OpenSSL 3 and OpenSSH.
+set -e
+
+cd "$(dirname "$0")"
+
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+openssl req -x509 -newkey ec -pkeyopt ec_paramgen_curve:P-256 -nodes -keyout "$work/ec.key" -out "$work/ec.pem" -subj "/CN=Corpus EC" -days 3650
+openssl req -x509 -newkey rsa:2048 -nodes -keyout "$work/rsa.key" -out "$work/rsa.pem" -subj "/CN=Corpus RSA" -days 3650
+printf 'corpus content\n' > "$work/content.txt"
+
+openssl x509 -in "$work/ec.pem" -outform DER -out certificate.cer
+openssl req -new -key "$work/ec.key" -subj "/CN=Corpus CSR" -outform DER -out request.csr
+openssl pkcs8 -topk8 -nocrypt -in "$work/ec.key" -outform DER -out private-key.p8
+openssl pkcs8 -topk8 -in "$work/ec.key" -v2 aes-256-cbc -passout pass:corpus -outform DER -out encrypted-key.p8
+openssl cms -sign -in "$work/content.txt" -signer "$work/ec.pem" -inkey "$work/ec.key" -nodetach -outform DER -out signed.p7s
+openssl cms -sign -in "$work/content.txt" -signer "$work/ec.pem" -inkey "$work/ec.key" -outform DER -out detached.p7s
+openssl cms -encrypt -in "$work/content.txt" -aes256 -outform DER -out enveloped.p7m "$work/rsa.pem"
+openssl cms -EncryptedData_encrypt -in "$work/content.txt" -aes128 -secretkey 000102030405060708090a0b0c0d0e0f -outform DER -out encrypted.p7e
+openssl cms -digest_create -in "$work/content.txt" -outform DER -out digested.p7
+openssl cms -data_create -in "$work/content.txt" -outform DER -out data.p7
+openssl crl2pkcs7 -nocrl -certfile "$work/ec.pem" -outform DER -out certificates.p7b
+openssl pkcs12 -export -in "$work/ec.pem" -inkey "$work/ec.key" -passout pass:corpus -out keystore.p12
+
+rm -f id_ed25519 id_ed25519.pub
+ssh-keygen -q -t ed25519 -N corpus -C corpus -f id_ed25519
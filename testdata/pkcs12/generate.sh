@@ -0,0 +1,20 @@
+#!/bin/sh
+# Regenerates the PKCS#12 containers used by the certificate preview tests. Requires OpenSSL 3
+# with the legacy provider for RC2 encryption.
+set -e
+
+cd "$(dirname "$0")"
+
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+
+openssl req -x509 -newkey ec -pkeyopt ec_paramgen_curve:P-256 -nodes -keyout "$work/key.pem" -out "$work/cert.pem" \
+	-utf8 -subj "/CN=Иванов И.И." -days 3650
+
+# Empty password with the legacy RC2-40 certificate encryption, the default of OpenSSL 1.1 and Windows
+openssl pkcs12 -export -legacy -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass: -out empty-rc2.p12
+openssl pkcs12 -export -certpbe PBE-SHA1-3DES -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass: -out empty-3des.p12
+openssl pkcs12 -export -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass: -out empty-aes.p12
+openssl pkcs12 -export -keypbe NONE -certpbe NONE -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass:secret -out unencrypted.p12
+openssl pkcs12 -export -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass:secret -out password.p12
+openssl pkcs12 -export -nomac -in "$work/cert.pem" -inkey "$work/key.pem" -name "Иванов И.И." -passout pass:secret -out nomac.p12
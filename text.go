@@ -0,0 +1,186 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformedText is returned by UnmarshalText for text that is not a valid encoding of a result
+var ErrMalformedText = errors.New("malformed detection result text")
+
+// String returns the text encoding of the result, e.g. for logs
+func (r DetectionResult) String() string {
+	text, err := r.MarshalText()
+	if err != nil {
+		return fmt.Sprintf("kind=%q", r.Kind.String())
+	}
+
+	return string(text)
+}
+
+// MarshalText encodes the result as space-separated key=value pairs in logfmt style, e.g.
+// kind="PKCS#7 Signed Data" content_type=1.2.840.113549.1.7.2 content_size=1024. Empty fields are
+// omitted, as is the type when it is the name of the kind, and values with spaces, quotes or equal
+// signs are quoted as Go string literals. The header is hex encoded
+func (r DetectionResult) MarshalText() ([]byte, error) {
+	kind, err := r.Kind.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	b := appendTextField(nil, "kind", string(kind))
+
+	if r.Type != r.Kind.String() || r.Kind == KindUnknown {
+		b = appendTextField(b, "type", r.Type)
+	}
+
+	if len(r.ContentType) > 0 {
+		b = appendTextField(b, "content_type", r.ContentType.String())
+	}
+
+	if len(r.InnerContentType) > 0 {
+		b = appendTextField(b, "inner_content_type", r.InnerContentType.String())
+	}
+
+	b = appendTextField(b, "inner_type", r.InnerType)
+
+	if r.IsEncrypted {
+		b = appendTextField(b, "encrypted", "true")
+	}
+
+	if r.NeedsPassword {
+		b = appendTextField(b, "needs_password", "true")
+	}
+
+	b = appendTextField(b, "provider", r.Provider)
+	b = appendTextField(b, "payload", r.Payload)
+
+	if r.ContentSize != 0 {
+		b = appendTextField(b, "content_size", strconv.FormatInt(r.ContentSize, 10))
+	}
+
+	return appendTextField(b, "header", hex.EncodeToString(r.Header)), nil
+}
+
+// UnmarshalText decodes the text encoding of MarshalText. Unknown keys are ignored, so that
+// fields added later can be read by older versions
+func (r *DetectionResult) UnmarshalText(text []byte) error {
+	result := DetectionResult{}
+	hasType := false
+
+	err := readTextFields(
+		text, func(key, value string) (err error) {
+			switch key {
+			case "kind":
+				err = result.Kind.UnmarshalText([]byte(value))
+			case "type":
+				result.Type, hasType = value, true
+			case "content_type":
+				result.ContentType, err = parseDottedOID(value)
+			case "inner_content_type":
+				result.InnerContentType, err = parseDottedOID(value)
+			case "inner_type":
+				result.InnerType = value
+			case "encrypted":
+				result.IsEncrypted, err = strconv.ParseBool(value)
+			case "needs_password":
+				result.NeedsPassword, err = strconv.ParseBool(value)
+			case "provider":
+				result.Provider = value
+			case "payload":
+				result.Payload = value
+			case "content_size":
+				result.ContentSize, err = strconv.ParseInt(value, 10, 64)
+			case "header":
+				result.Header, err = hex.DecodeString(value)
+			}
+
+			if err != nil {
+				return fmt.Errorf("%w: invalid %s: %v", ErrMalformedText, key, err)
+			}
+
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if !hasType && result.Kind != KindUnknown {
+		result.Type = result.Kind.String()
+	}
+
+	*r = result
+
+	return nil
+}
+
+// appendTextField appends a key=value pair, empty values are omitted
+func appendTextField(b []byte, key, value string) []byte {
+	if value == "" {
+		return b
+	}
+
+	if len(b) > 0 {
+		b = append(b, ' ')
+	}
+
+	b = append(b, key...)
+	b = append(b, '=')
+
+	if quoted := strconv.Quote(value); quoted[1:len(quoted)-1] != value || strings.ContainsAny(value, " =") {
+		return append(b, quoted...)
+	}
+
+	return append(b, value...)
+}
+
+// readTextFields calls fn for every key=value pair of the text
+func readTextFields(text []byte, fn func(key, value string) error) error {
+	for {
+		text = bytes.TrimLeft(text, " ")
+		if len(text) == 0 {
+			return nil
+		}
+
+		eq := bytes.IndexByte(text, '=')
+		if eq <= 0 || bytes.ContainsAny(text[:eq], " \"") {
+			return fmt.Errorf("%w: missing key", ErrMalformedText)
+		}
+
+		key, rest := string(text[:eq]), string(text[eq+1:])
+
+		var value string
+
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return fmt.Errorf("%w: invalid quoted value of %s", ErrMalformedText, key)
+			}
+
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+
+			if rest != "" && rest[0] != ' ' {
+				return fmt.Errorf("%w: missing space after %s", ErrMalformedText, key)
+			}
+		} else {
+			end := strings.IndexByte(rest, ' ')
+			if end < 0 {
+				end = len(rest)
+			}
+
+			value, rest = rest[:end], rest[end:]
+		}
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+
+		text = []byte(rest)
+	}
+}
@@ -0,0 +1,136 @@
+package cmsdetector
+
+import (
+	"encoding/asn1"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestKindText tests the text encoding of kinds
+func TestKindText(t *testing.T) {
+	for kind, name := range kindNames {
+		text, err := kind.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText returned an error for %v: %v", kind, err)
+		}
+
+		if string(text) != name {
+			t.Errorf("Expected %q, got %q", name, text)
+		}
+
+		var decoded Kind
+		if err := decoded.UnmarshalText(text); err != nil || decoded != kind {
+			t.Errorf("Expected %v, got %v (error %v)", kind, decoded, err)
+		}
+	}
+
+	var kind Kind
+	if err := kind.UnmarshalText([]byte("pkcs#7 signed data")); err != nil || kind != KindSignedData {
+		t.Errorf("Expected %v ignoring case, got %v (error %v)", KindSignedData, kind, err)
+	}
+
+	if err := kind.UnmarshalText([]byte("Signed Data")); err == nil {
+		t.Error("Expected an error for an unknown name, got nil")
+	}
+
+	if _, err := Kind(-1).MarshalText(); err == nil {
+		t.Error("Expected an error for an undefined kind, got nil")
+	}
+}
+
+// TestDetectionResultText tests the text encoding of results and its round trip
+func TestDetectionResultText(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   DetectionResult
+		expected string
+	}{
+		{
+			name:     "Zero result",
+			result:   DetectionResult{},
+			expected: "kind=Unknown",
+		},
+		{
+			name: "Signed data",
+			result: DetectionResult{
+				Type:             "PKCS#7 Signed Data",
+				Kind:             KindSignedData,
+				ContentType:      PKCS7SignedDataOID,
+				InnerContentType: PKCS7DataOID,
+				InnerType:        "PKCS#7 Data",
+				ContentSize:      13,
+				Header:           []byte{0x30, 0x80},
+			},
+			expected: `kind="PKCS#7 Signed Data" content_type=1.2.840.113549.1.7.2 inner_content_type=1.2.840.113549.1.7.1 inner_type="PKCS#7 Data" content_size=13 header=3080`,
+		},
+		{
+			name: "Unknown content type",
+			result: DetectionResult{
+				Type:        "Time-Stamp Token Info",
+				ContentType: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4},
+				IsEncrypted: true,
+				Provider:    ProviderCryptoPro,
+				Payload:     `say "hi"`,
+			},
+			expected: `kind=Unknown type="Time-Stamp Token Info" content_type=1.2.840.113549.1.9.16.1.4 encrypted=true provider="CryptoPro CSP" payload="say \"hi\""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				text, err := tt.result.MarshalText()
+				if err != nil {
+					t.Fatalf("MarshalText returned an error: %v", err)
+				}
+
+				if string(text) != tt.expected {
+					t.Errorf("Expected %s, got %s", tt.expected, text)
+				}
+
+				if tt.result.String() != tt.expected {
+					t.Errorf("Expected String to return %s, got %s", tt.expected, tt.result.String())
+				}
+
+				var decoded DetectionResult
+				if err := decoded.UnmarshalText(text); err != nil {
+					t.Fatalf("UnmarshalText returned an error: %v", err)
+				}
+
+				if !reflect.DeepEqual(decoded, tt.result) {
+					t.Errorf("Expected %+v, got %+v", tt.result, decoded)
+				}
+			},
+		)
+	}
+}
+
+// TestDetectionResultUnmarshalTextErrors tests that malformed text is rejected
+func TestDetectionResultUnmarshalTextErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "Missing key", text: "=Unknown"},
+		{name: "Missing equal sign", text: "kind"},
+		{name: "Unterminated quote", text: `kind="PKCS#7 Data`},
+		{name: "Text after quote", text: `kind="PKCS#7 Data"x`},
+		{name: "Unknown kind", text: "kind=Other"},
+		{name: "Invalid OID", text: "content_type=1"},
+		{name: "Invalid bool", text: "encrypted=maybe"},
+		{name: "Invalid size", text: "content_size=large"},
+		{name: "Invalid header", text: "header=xyz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				err := new(DetectionResult).UnmarshalText([]byte(tt.text))
+				if !errors.Is(err, ErrMalformedText) {
+					t.Errorf("Expected %v, got %v", ErrMalformedText, err)
+				}
+			},
+		)
+	}
+}
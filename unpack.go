@@ -0,0 +1,101 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Container formats unpacked by Scanner when Unpack is set
+const (
+	ContainerArchive = "archive" // ZIP, TAR or gzip compressed TAR archive
+	ContainerEmail   = "email"   // MIME message, e.g. an .eml file, with its attachments
+	ContainerPDF     = "pdf"     // PDF document with its signatures
+)
+
+// ErrUnpackDepth is reported for containers nested deeper than Limits.MaxUnpackDepth, which are
+// classified without their contents
+var ErrUnpackDepth = errors.New("container nested deeper than the maximum unpack depth")
+
+// unpackFile classifies the files in the container file at the given nesting depth, starting at 1,
+// and adds them as its children
+func unpackFile(file *ScannedFile, data []byte, depth int) {
+	container, walk := containerWalker(data)
+	if walk == nil {
+		return
+	}
+
+	file.Container = container
+
+	if limit := CurrentLimits().MaxUnpackDepth; limit > 0 && depth > limit {
+		file.UnpackErr = ErrUnpackDepth
+
+		return
+	}
+
+	err := walk(
+		func(entry containerEntry) {
+			name := file.Path + "/" + entry.name
+			if entry.err != nil {
+				file.Children = append(file.Children, ScannedFile{Path: name, Size: entry.size, Err: entry.err})
+
+				return
+			}
+
+			child := classifyData(name, entry.data)
+			unpackFile(&child, entry.data, depth+1)
+
+			file.Children = append(file.Children, child)
+		},
+	)
+
+	// Gzip compressed data other than TAR archives is no container
+	if errors.Is(err, ErrNotArchive) {
+		file.Container = ""
+
+		return
+	}
+
+	file.UnpackErr = err
+}
+
+// containerWalker returns the container format of the data and a function walking its files,
+// or a nil function for data that is not a container
+func containerWalker(data []byte) (string, func(func(containerEntry)) error) {
+	switch {
+	case bytes.HasPrefix(bytes.TrimPrefix(data, utf8BOM), pdfHeader):
+		return ContainerPDF, func(visit func(containerEntry)) error {
+			return walkPDF(data, visit)
+		}
+	case isEmail(data):
+		return ContainerEmail, func(visit func(containerEntry)) error {
+			return walkEmail(data, visit)
+		}
+	}
+
+	if !hasArchiveMagic(data) {
+		return "", nil
+	}
+
+	return ContainerArchive, func(visit func(containerEntry)) error {
+		return walkArchive(bytes.NewReader(data), int64(len(data)), visit)
+	}
+}
+
+// walkPDF calls visit for the CMS structure of every signature of a PDF document
+func walkPDF(data []byte, visit func(containerEntry)) error {
+	signatures, err := DetectPDFSignatures(data)
+	if errors.Is(err, ErrNotSignedPDF) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for i, signature := range signatures {
+		visit(containerEntry{name: fmt.Sprintf("signature%d", i+1), size: int64(len(signature.Data)), data: signature.Data})
+	}
+
+	return nil
+}
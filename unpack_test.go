@@ -0,0 +1,176 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// describeTree returns a line per file of the tree with its kind, container and unpack error
+func describeTree(file ScannedFile) []string {
+	line := fmt.Sprintf("%s: %v", file.Path, file.Result.Kind)
+	if file.Container != "" {
+		line += " [" + file.Container + "]"
+	}
+
+	if file.UnpackErr != nil {
+		line += " " + file.UnpackErr.Error()
+	}
+
+	lines := []string{line}
+	for _, child := range file.Children {
+		lines = append(lines, describeTree(child)...)
+	}
+
+	return lines
+}
+
+// createEmail creates a multipart message with a text body and a base64 encoded attachment
+func createEmail(filename string, attachment []byte) []byte {
+	return []byte(
+		"From: alice@example.com\r\nMIME-Version: 1.0\r\n" +
+			"Content-Type: multipart/mixed; boundary=\"frontier\"\r\n\r\n" +
+			"--frontier\r\nContent-Type: text/plain\r\n\r\nSee the attachment\r\n" +
+			"--frontier\r\nContent-Type: application/octet-stream\r\n" +
+			"Content-Disposition: attachment; filename=\"" + filename + "\"\r\n" +
+			"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(attachment) + "\r\n" +
+			"--frontier--\r\n",
+	)
+}
+
+// TestScannerUnpack tests classification of files nested in archives, e-mail messages and PDF documents
+func TestScannerUnpack(t *testing.T) {
+	signed := cmstest.SignedData(t)
+	pfx := cmstest.PFX(t)
+
+	inner := createZIP(t, []archiveMember{{name: "keys/user.p12", data: pfx}})
+	document := createPDF(createPDFSignatureDictionary("Sig", PDFSubFilterCAdESDetached, signed))
+
+	backup := createTAR(
+		t, []archiveMember{
+			{name: "inner.zip", data: inner},
+			{name: "mail.eml", data: createEmail("contract.p7m", signed)},
+			{name: "report.pdf", data: document},
+			{name: "notes.txt", data: []byte("Hello, world")},
+		}, true,
+	)
+
+	var forwarded bytes.Buffer
+	forwarded.WriteString("MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=\"outer\"\r\n\r\n")
+	forwarded.WriteString("--outer\r\nContent-Type: message/rfc822\r\n\r\n")
+	forwarded.Write(createEmail("id.p12", pfx))
+	forwarded.WriteString("\r\n--outer--\r\n")
+
+	var compressed bytes.Buffer
+	compressor := gzip.NewWriter(&compressed)
+	_, _ = compressor.Write(signed)
+	_ = compressor.Close()
+
+	tests := []struct {
+		name     string
+		file     string
+		data     []byte
+		limits   Limits
+		expected []string
+	}{
+		{
+			name:   "Nested containers",
+			file:   "backup.tar.gz",
+			data:   backup,
+			limits: DefaultLimits(),
+			expected: []string{
+				"backup.tar.gz: Unknown [archive]",
+				"backup.tar.gz/inner.zip: Unknown [archive]",
+				"backup.tar.gz/inner.zip/keys/user.p12: PKCS#12",
+				"backup.tar.gz/mail.eml: Unknown [email]",
+				"backup.tar.gz/mail.eml/contract.p7m: PKCS#7 Signed Data",
+				"backup.tar.gz/report.pdf: Unknown [pdf]",
+				"backup.tar.gz/report.pdf/signature1: PKCS#7 Signed Data",
+				"backup.tar.gz/notes.txt: Unknown",
+			},
+		},
+		{
+			name:   "Depth limit",
+			file:   "backup.tar.gz",
+			data:   backup,
+			limits: Limits{MaxUnpackDepth: 1},
+			expected: []string{
+				"backup.tar.gz: Unknown [archive]",
+				"backup.tar.gz/inner.zip: Unknown [archive] " + ErrUnpackDepth.Error(),
+				"backup.tar.gz/mail.eml: Unknown [email] " + ErrUnpackDepth.Error(),
+				"backup.tar.gz/report.pdf: Unknown [pdf] " + ErrUnpackDepth.Error(),
+				"backup.tar.gz/notes.txt: Unknown",
+			},
+		},
+		{
+			name:   "Forwarded message",
+			file:   "forward.eml",
+			data:   forwarded.Bytes(),
+			limits: DefaultLimits(),
+			expected: []string{
+				"forward.eml: Unknown [email]",
+				"forward.eml/part2: Unknown [email]",
+				"forward.eml/part2/id.p12: PKCS#12",
+			},
+		},
+		{
+			name:     "Gzip compressed CMS",
+			file:     "signed.p7s.gz",
+			data:     compressed.Bytes(),
+			limits:   DefaultLimits(),
+			expected: []string{"signed.p7s.gz: Unknown"},
+		},
+		{
+			name:     "Plain file",
+			file:     "signed.p7s",
+			data:     signed,
+			limits:   DefaultLimits(),
+			expected: []string{"signed.p7s: PKCS#7 Signed Data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withLimits(t, tt.limits)
+
+				file := (&Scanner{Unpack: true}).Classify(tt.file, tt.data)
+				if !equalStrings(describeTree(file), tt.expected) {
+					t.Errorf("Expected tree %q, got %q", tt.expected, describeTree(file))
+				}
+			},
+		)
+	}
+
+	if file := (&Scanner{}).Classify("backup.tar.gz", backup); file.Container != "" || len(file.Children) != 0 {
+		t.Errorf("Expected no children without Unpack, got %d in %q", len(file.Children), file.Container)
+	}
+}
+
+// TestScannerUnpackReport tests that the aggregates of a scan include unpacked files
+func TestScannerUnpackReport(t *testing.T) {
+	truncated := createTAR(t, []archiveMember{{name: "large.bin", data: make([]byte, 600)}}, false)[:700]
+
+	fsys := fstest.MapFS{
+		"backup.zip": {Data: createZIP(t, []archiveMember{{name: "id.p12", data: cmstest.PFX(t)}, {name: "broken.tar", data: truncated}})},
+	}
+
+	report, err := (&Scanner{Unpack: true}).Scan(fsys, ".")
+	if err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+
+	if len(report.Files) != 1 || report.Counts[KindPKCS12] != 1 {
+		t.Errorf("Expected 1 file with a PKCS#12 child, got %d files and counts %v", len(report.Files), report.Counts)
+	}
+
+	children := report.Files[0].Children
+	if len(children) != 2 || children[1].UnpackErr == nil || errors.Is(children[1].UnpackErr, ErrUnpackDepth) {
+		t.Errorf("Expected an unpack error of the truncated archive, got %+v", children)
+	}
+}
@@ -0,0 +1,596 @@
+// Package verify validates CMS (PKCS#7) SignedData signatures using the algorithms
+// available in the Go standard library: RSA (PKCS#1 v1.5 and PSS), ECDSA and Ed25519
+// with SHA-2 digests. Signatures made with GOST or other national algorithms are reported
+// with ErrUnsupportedAlgorithm, as they require an external crypto provider.
+//
+// Only the signatures are verified; certificate trust and revocation are left to the caller,
+// who can use the returned chain material with x509.Certificate.Verify.
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+
+	// Register SHA-2 implementations for crypto.Hash
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// maxChainLength limits the length of the certificate chains built from embedded certificates
+const maxChainLength = 10
+
+// Errors returned for individual signers
+var (
+	ErrUnsupportedAlgorithm       = errors.New("unsupported algorithm")
+	ErrSignerCertificateNotFound  = errors.New("signer certificate not found")
+	ErrMessageDigestMismatch      = errors.New("message digest mismatch")
+	ErrContentTypeMismatch        = errors.New("content type attribute mismatch")
+	ErrMissingSignedAttribute     = errors.New("missing mandatory signed attribute")
+	ErrInvalidSignature           = errors.New("invalid signature")
+	ErrDetachedContentUnavailable = errors.New("signed content is not attached")
+)
+
+// OIDs of the algorithms supported by the package
+var (
+	oidSHA224          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRSASSAPSS       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidSHA224WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 14}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+	oidRussianArc      = asn1.ObjectIdentifier{1, 2, 643}
+	oidKazakhstanArc   = asn1.ObjectIdentifier{1, 2, 398}
+)
+
+// signedData provides the ASN.1 structure of CMS SignedData (RFC 5652, section 5.1)
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+// encapsulatedContentInfo provides the ASN.1 structure of the signed content
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo provides the ASN.1 structure of CMS SignerInfo (RFC 5652, section 5.3)
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// issuerAndSerialNumber provides the ASN.1 structure identifying a certificate by issuer and serial number
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// attribute provides the ASN.1 structure of a CMS Attribute
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// pssParameters provides the ASN.1 structure of RSASSA-PSS parameters (RFC 4055, section 3.1)
+type pssParameters struct {
+	Hash pkix.AlgorithmIdentifier `asn1:"explicit,optional,tag:0"`
+}
+
+// SignerResult contains the verification result of a single signer
+type SignerResult struct {
+	Certificate        *x509.Certificate   // Signer certificate, nil if not embedded
+	Chain              []*x509.Certificate // Chain from the signer certificate built from embedded certificates
+	DigestAlgorithm    asn1.ObjectIdentifier
+	SignatureAlgorithm asn1.ObjectIdentifier
+	Err                error // Nil if the signature is valid
+}
+
+// Valid checks if the signature of the signer was verified successfully
+func (r SignerResult) Valid() bool {
+	return r.Err == nil
+}
+
+// Result contains the verification results of all signers of a SignedData
+type Result struct {
+	ContentType  asn1.ObjectIdentifier
+	Certificates []*x509.Certificate // All certificates embedded in the SignedData
+	Signers      []SignerResult
+}
+
+// Valid checks if there is at least one signer and all signatures are valid
+func (r *Result) Valid() bool {
+	if len(r.Signers) == 0 {
+		return false
+	}
+
+	for _, signer := range r.Signers {
+		if !signer.Valid() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Verify verifies the signatures of an attached CMS SignedData. An error is returned if the
+// structure can't be parsed, and ErrDetachedContentUnavailable if the content is not attached.
+// Signature failures, e.g. ErrInvalidSignature and ErrMessageDigestMismatch, are reported as the
+// Err of the signer in the result
+func Verify(data []byte) (*Result, error) {
+	sd, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sd.EncapContentInfo.EContent.Bytes) == 0 {
+		return nil, ErrDetachedContentUnavailable
+	}
+
+	var content []byte
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent.Bytes, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse encapsulated content: %w", err)
+	}
+
+	return verifySignedData(sd, bytes.NewReader(content), content)
+}
+
+// VerifyDetached verifies the signatures of a detached CMS SignedData over the content read
+// from the reader. The content is streamed once through all required digest functions,
+// so large files are never loaded into memory.
+func VerifyDetached(data []byte, content io.Reader) (*Result, error) {
+	sd, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifySignedData(sd, content, nil)
+}
+
+// parse unmarshals ContentInfo wrapping SignedData
+func parse(data []byte) (*signedData, error) {
+	var contentInfo cmsdetector.ContentInfo
+	if _, err := asn1.Unmarshal(data, &contentInfo); err != nil {
+		return nil, fmt.Errorf("failed to parse ASN.1 structure: %w", err)
+	}
+
+	if !contentInfo.ContentType.Equal(cmsdetector.PKCS7SignedDataOID) {
+		return nil, cmsdetector.ErrNotSignedData
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(contentInfo.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("failed to parse signed data: %w", err)
+	}
+
+	return &sd, nil
+}
+
+// verifySignedData verifies all signers, computing the content digests from the reader in a single pass.
+// The full content is only needed for signers without signed attributes using Ed25519 and may be nil.
+func verifySignedData(sd *signedData, content io.Reader, fullContent []byte) (*Result, error) {
+	result := &Result{
+		ContentType:  sd.EncapContentInfo.EContentType,
+		Certificates: parseCertificates(sd.Certificates),
+	}
+
+	var hashes []crypto.Hash
+
+	for _, si := range sd.SignerInfos {
+		if digestHash, err := hashForOID(si.DigestAlgorithm.Algorithm); err == nil {
+			hashes = append(hashes, digestHash)
+		}
+	}
+
+	digests, err := computeDigests(content, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signed content: %w", err)
+	}
+
+	for _, si := range sd.SignerInfos {
+		signer := SignerResult{
+			DigestAlgorithm:    si.DigestAlgorithm.Algorithm,
+			SignatureAlgorithm: si.SignatureAlgorithm.Algorithm,
+		}
+
+		signer.Certificate = findCertificate(si.SID, result.Certificates)
+		if signer.Certificate != nil {
+			signer.Chain = buildChain(signer.Certificate, result.Certificates)
+		}
+
+		signer.Err = verifySigner(si, signer.Certificate, sd.EncapContentInfo.EContentType, digests, fullContent)
+		result.Signers = append(result.Signers, signer)
+	}
+
+	return result, nil
+}
+
+// computeDigests hashes the content with all given hash functions in a single pass
+func computeDigests(content io.Reader, hashes []crypto.Hash) (map[crypto.Hash][]byte, error) {
+	hashers := make(map[crypto.Hash]hash.Hash)
+	writers := make([]io.Writer, 0, len(hashes))
+
+	for _, h := range hashes {
+		if _, ok := hashers[h]; ok {
+			continue
+		}
+
+		hasher := h.New()
+		hashers[h] = hasher
+		writers = append(writers, hasher)
+	}
+
+	if _, err := io.Copy(io.MultiWriter(writers...), content); err != nil {
+		return nil, err
+	}
+
+	digests := make(map[crypto.Hash][]byte, len(hashers))
+	for h, hasher := range hashers {
+		digests[h] = hasher.Sum(nil)
+	}
+
+	return digests, nil
+}
+
+// verifySigner verifies the signature of a single signer
+func verifySigner(
+	si signerInfo,
+	cert *x509.Certificate,
+	contentType asn1.ObjectIdentifier,
+	digests map[crypto.Hash][]byte,
+	fullContent []byte,
+) error {
+	if err := checkSupported(si); err != nil {
+		return err
+	}
+
+	if cert == nil {
+		return ErrSignerCertificateNotFound
+	}
+
+	digestHash, err := hashForOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	digest := digests[digestHash]
+
+	// Without signed attributes the signature covers the content itself
+	if len(si.SignedAttrs.Bytes) == 0 {
+		if _, ok := cert.PublicKey.(ed25519.PublicKey); ok {
+			if fullContent == nil {
+				return fmt.Errorf("%w: Ed25519 without signed attributes requires the full content", ErrUnsupportedAlgorithm)
+			}
+
+			return checkSignature(si, cert, digestHash, fullContent, nil)
+		}
+
+		return checkSignature(si, cert, digestHash, nil, digest)
+	}
+
+	if err := checkSignedAttributes(si.SignedAttrs, contentType, digest); err != nil {
+		return err
+	}
+
+	// Signed attributes are signed with their universal SET tag instead of the implicit [0]
+	signed := make([]byte, len(si.SignedAttrs.FullBytes))
+	copy(signed, si.SignedAttrs.FullBytes)
+	signed[0] = 0x31
+
+	hasher := digestHash.New()
+	hasher.Write(signed)
+
+	return checkSignature(si, cert, digestHash, signed, hasher.Sum(nil))
+}
+
+// checkSignedAttributes checks the mandatory contentType and messageDigest signed attributes
+func checkSignedAttributes(raw asn1.RawValue, contentType asn1.ObjectIdentifier, digest []byte) error {
+	var foundDigest, foundContentType bool
+
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			attr attribute
+			err  error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fmt.Errorf("failed to parse signed attributes: %w", err)
+		}
+
+		switch {
+		case attr.Type.Equal(cmsdetector.MessageDigestAttributeOID):
+			var value []byte
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err != nil {
+				return fmt.Errorf("failed to parse message digest: %w", err)
+			}
+
+			if !bytes.Equal(value, digest) {
+				return ErrMessageDigestMismatch
+			}
+
+			foundDigest = true
+		case attr.Type.Equal(cmsdetector.ContentTypeAttributeOID):
+			var value asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &value); err != nil {
+				return fmt.Errorf("failed to parse content type: %w", err)
+			}
+
+			if !value.Equal(contentType) {
+				return ErrContentTypeMismatch
+			}
+
+			foundContentType = true
+		}
+	}
+
+	if !foundDigest || !foundContentType {
+		return ErrMissingSignedAttribute
+	}
+
+	return nil
+}
+
+// checkSignature verifies the signature over the message (Ed25519) or its digest (RSA, ECDSA)
+func checkSignature(si signerInfo, cert *x509.Certificate, digestHash crypto.Hash, message, digest []byte) error {
+	sigAlg := si.SignatureAlgorithm.Algorithm
+
+	// The bare key algorithms rsaEncryption and id-ecPublicKey take the hash of the digest algorithm
+	if signatureHash, ok := signatureHashFor(sigAlg); ok && signatureHash != digestHash {
+		return fmt.Errorf(
+			"%w: signature algorithm %s uses %s, digest algorithm %s",
+			ErrUnsupportedAlgorithm,
+			cmsdetector.GetAlgorithmName(sigAlg),
+			signatureHash,
+			digestHash,
+		)
+	}
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if sigAlg.Equal(oidRSASSAPSS) {
+			pssHash, err := pssHashFor(si.SignatureAlgorithm, digestHash)
+			if err != nil {
+				return err
+			}
+
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: pssHash}
+			if err := rsa.VerifyPSS(pub, pssHash, digest, si.Signature, opts); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+			}
+
+			return nil
+		}
+
+		if !isOneOf(sigAlg, oidRSAEncryption, oidSHA224WithRSA, oidSHA256WithRSA, oidSHA384WithRSA, oidSHA512WithRSA) {
+			return fmt.Errorf("%w: signature algorithm %s for RSA key", ErrUnsupportedAlgorithm, sigAlg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(pub, digestHash, digest, si.Signature); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+
+		return nil
+	case *ecdsa.PublicKey:
+		if !isOneOf(sigAlg, oidECPublicKey, oidECDSAWithSHA224, oidECDSAWithSHA256, oidECDSAWithSHA384, oidECDSAWithSHA512) {
+			return fmt.Errorf("%w: signature algorithm %s for ECDSA key", ErrUnsupportedAlgorithm, sigAlg)
+		}
+
+		if !ecdsa.VerifyASN1(pub, digest, si.Signature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	case ed25519.PublicKey:
+		if !sigAlg.Equal(oidEd25519) {
+			return fmt.Errorf("%w: signature algorithm %s for Ed25519 key", ErrUnsupportedAlgorithm, sigAlg)
+		}
+
+		if !ed25519.Verify(pub, message, si.Signature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: public key algorithm %s", ErrUnsupportedAlgorithm, cert.PublicKeyAlgorithm)
+	}
+}
+
+// checkSupported returns a descriptive error for algorithms that need an external provider
+func checkSupported(si signerInfo) error {
+	for _, oid := range []asn1.ObjectIdentifier{si.DigestAlgorithm.Algorithm, si.SignatureAlgorithm.Algorithm} {
+		if hasPrefix(oid, oidRussianArc) || hasPrefix(oid, oidKazakhstanArc) {
+			return fmt.Errorf(
+				"%w: GOST algorithm %s (%s) requires an external provider",
+				ErrUnsupportedAlgorithm,
+				oid,
+				cmsdetector.GetAlgorithmName(oid),
+			)
+		}
+	}
+
+	return nil
+}
+
+// hashForOID returns the SHA-2 hash function identified by the digest algorithm OID
+func hashForOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA224):
+		return crypto.SHA224, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("%w: digest algorithm %s (%s)", ErrUnsupportedAlgorithm, oid, cmsdetector.GetAlgorithmName(oid))
+	}
+}
+
+// signatureHashFor returns the hash function named by a signature algorithm OID, such as
+// sha256WithRSAEncryption or ecdsa-with-SHA384
+func signatureHashFor(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case isOneOf(oid, oidSHA224WithRSA, oidECDSAWithSHA224):
+		return crypto.SHA224, true
+	case isOneOf(oid, oidSHA256WithRSA, oidECDSAWithSHA256):
+		return crypto.SHA256, true
+	case isOneOf(oid, oidSHA384WithRSA, oidECDSAWithSHA384):
+		return crypto.SHA384, true
+	case isOneOf(oid, oidSHA512WithRSA, oidECDSAWithSHA512):
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// pssHashFor returns the hash function from RSASSA-PSS parameters, which must match the digest algorithm
+func pssHashFor(alg pkix.AlgorithmIdentifier, digestHash crypto.Hash) (crypto.Hash, error) {
+	var params pssParameters
+	if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+		return 0, fmt.Errorf("failed to parse RSASSA-PSS parameters: %w", err)
+	}
+
+	// The default hash of RSASSA-PSS is SHA-1, which is not supported
+	pssHash, err := hashForOID(params.Hash.Algorithm)
+	if err != nil {
+		return 0, err
+	}
+
+	if pssHash != digestHash {
+		return 0, fmt.Errorf("%w: RSASSA-PSS hash differs from digest algorithm", ErrUnsupportedAlgorithm)
+	}
+
+	return pssHash, nil
+}
+
+// parseCertificates parses the X.509 certificates of a CertificateSet, skipping other alternatives
+func parseCertificates(raw asn1.RawValue) []*x509.Certificate {
+	var certs []*x509.Certificate
+
+	for rest := raw.Bytes; len(rest) > 0; {
+		var (
+			choice asn1.RawValue
+			err    error
+		)
+
+		if rest, err = asn1.Unmarshal(rest, &choice); err != nil {
+			break
+		}
+
+		if choice.Class != asn1.ClassUniversal || choice.Tag != asn1.TagSequence {
+			continue
+		}
+
+		if cert, err := x509.ParseCertificate(choice.FullBytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+
+	return certs
+}
+
+// findCertificate returns the certificate referenced by the SignerIdentifier
+func findCertificate(sid asn1.RawValue, certs []*x509.Certificate) *x509.Certificate {
+	var ias issuerAndSerialNumber
+
+	byIssuer := sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence
+	if byIssuer {
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil || ias.SerialNumber == nil {
+			return nil
+		}
+	}
+
+	for _, cert := range certs {
+		switch {
+		case byIssuer:
+			if bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return cert
+			}
+		case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+			// subjectKeyIdentifier is implicitly tagged, so the content is the key identifier itself
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(sid.Bytes, cert.SubjectKeyId) {
+				return cert
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildChain follows issuer links between the embedded certificates, starting from the signer
+func buildChain(leaf *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+
+	for current := leaf; len(chain) < maxChainLength; {
+		if bytes.Equal(current.RawIssuer, current.RawSubject) {
+			break
+		}
+
+		var issuer *x509.Certificate
+
+		for _, candidate := range certs {
+			if bytes.Equal(candidate.RawSubject, current.RawIssuer) && current.CheckSignatureFrom(candidate) == nil {
+				issuer = candidate
+				break
+			}
+		}
+
+		if issuer == nil {
+			break
+		}
+
+		chain = append(chain, issuer)
+		current = issuer
+	}
+
+	return chain
+}
+
+// isOneOf checks if the OID equals any of the candidates
+func isOneOf(oid asn1.ObjectIdentifier, candidates ...asn1.ObjectIdentifier) bool {
+	for _, candidate := range candidates {
+		if oid.Equal(candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasPrefix checks if the OID lies under the given arc
+func hasPrefix(oid, arc asn1.ObjectIdentifier) bool {
+	return len(oid) >= len(arc) && oid[:len(arc)].Equal(arc)
+}
@@ -0,0 +1,445 @@
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lEx0/cmsdetector"
+)
+
+// testSigner describes a signer used to build test SignedData
+type testSigner struct {
+	key          crypto.Signer
+	cert         *x509.Certificate
+	digestOID    asn1.ObjectIdentifier
+	signatureOID asn1.ObjectIdentifier
+	signatureArg asn1.RawValue
+	hash         crypto.Hash
+	noAttributes bool
+	tamper       bool
+	corrupt      bool
+}
+
+// createCertificate creates a self-signed certificate for the given key
+func createCertificate(t *testing.T, commonName string, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+
+	return cert
+}
+
+// mustMarshal marshals the value or fails the test
+func mustMarshal(t *testing.T, value interface{}) []byte {
+	t.Helper()
+
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	return der
+}
+
+// createAttribute creates an attribute with a single value
+func createAttribute(t *testing.T, attrType asn1.ObjectIdentifier, value interface{}) attribute {
+	t.Helper()
+
+	return attribute{
+		Type:   attrType,
+		Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: mustMarshal(t, value)},
+	}
+}
+
+// createSignerInfo signs the content with the test signer
+func createSignerInfo(t *testing.T, signer testSigner, content []byte) signerInfo {
+	t.Helper()
+
+	si := signerInfo{
+		Version: 1,
+		SID: asn1.RawValue{
+			FullBytes: mustMarshal(
+				t,
+				issuerAndSerialNumber{
+					Issuer:       asn1.RawValue{FullBytes: signer.cert.RawIssuer},
+					SerialNumber: signer.cert.SerialNumber,
+				},
+			),
+		},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: signer.digestOID},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: signer.signatureOID, Parameters: signer.signatureArg},
+	}
+
+	message := content
+
+	if !signer.noAttributes {
+		h := signer.hash.New()
+		h.Write(content)
+		digest := h.Sum(nil)
+
+		if signer.tamper {
+			digest[0] ^= 0xFF
+		}
+
+		var attrs []byte
+		attrs = append(attrs, mustMarshal(t, createAttribute(t, cmsdetector.ContentTypeAttributeOID, cmsdetector.PKCS7DataOID))...)
+		attrs = append(attrs, mustMarshal(t, createAttribute(t, cmsdetector.MessageDigestAttributeOID, digest))...)
+
+		si.SignedAttrs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs}
+		message = mustMarshal(t, asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	}
+
+	var (
+		signature []byte
+		err       error
+	)
+
+	switch {
+	case signer.signatureOID.Equal(oidEd25519):
+		signature, err = signer.key.Sign(rand.Reader, message, crypto.Hash(0))
+	case signer.signatureOID.Equal(oidRSASSAPSS):
+		h := signer.hash.New()
+		h.Write(message)
+		signature, err = signer.key.Sign(rand.Reader, h.Sum(nil), &rsa.PSSOptions{SaltLength: 32, Hash: signer.hash})
+	case signer.hash != 0:
+		h := signer.hash.New()
+		h.Write(message)
+		signature, err = signer.key.Sign(rand.Reader, h.Sum(nil), signer.hash)
+	default:
+		signature = []byte{0x01}
+	}
+
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	if signer.corrupt {
+		signature[len(signature)-1] ^= 0xFF
+	}
+
+	si.Signature = signature
+
+	return si
+}
+
+// createSignedData creates ContentInfo wrapping SignedData with the given signers
+func createSignedData(t *testing.T, content []byte, detached bool, certs []*x509.Certificate, signers ...testSigner) []byte {
+	t.Helper()
+
+	sd := signedData{
+		Version:          1,
+		EncapContentInfo: encapsulatedContentInfo{EContentType: cmsdetector.PKCS7DataOID},
+	}
+
+	if !detached {
+		sd.EncapContentInfo.EContent = asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      mustMarshal(t, content),
+		}
+	}
+
+	var certSet []byte
+	for _, cert := range certs {
+		certSet = append(certSet, cert.Raw...)
+	}
+
+	if len(certSet) > 0 {
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certSet}
+	}
+
+	for _, signer := range signers {
+		sd.DigestAlgorithms = append(sd.DigestAlgorithms, pkix.AlgorithmIdentifier{Algorithm: signer.digestOID})
+		sd.SignerInfos = append(sd.SignerInfos, createSignerInfo(t, signer, content))
+	}
+
+	return mustMarshal(
+		t,
+		cmsdetector.ContentInfo{
+			ContentType: cmsdetector.PKCS7SignedDataOID,
+			Content: asn1.RawValue{
+				Class:      asn1.ClassContextSpecific,
+				Tag:        0,
+				IsCompound: true,
+				Bytes:      mustMarshal(t, sd),
+			},
+		},
+	)
+}
+
+// testSigners creates signers for all supported key types
+func testSigners(t *testing.T) map[string]testSigner {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	pssParams := mustMarshal(
+		t,
+		pssParameters{Hash: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}},
+	)
+
+	rsaCert := createCertificate(t, "RSA Signer", rsaKey)
+
+	return map[string]testSigner{
+		"RSA": {
+			key: rsaKey, cert: rsaCert, hash: crypto.SHA256,
+			digestOID: oidSHA256, signatureOID: oidSHA256WithRSA,
+		},
+		"RSA-PSS": {
+			key: rsaKey, cert: rsaCert, hash: crypto.SHA256,
+			digestOID: oidSHA256, signatureOID: oidRSASSAPSS, signatureArg: asn1.RawValue{FullBytes: pssParams},
+		},
+		"ECDSA": {
+			key: ecKey, cert: createCertificate(t, "ECDSA Signer", ecKey), hash: crypto.SHA384,
+			digestOID: oidSHA384, signatureOID: oidECDSAWithSHA384,
+		},
+		"Ed25519": {
+			key: edKey, cert: createCertificate(t, "Ed25519 Signer", edKey), hash: crypto.SHA512,
+			digestOID: oidSHA512, signatureOID: oidEd25519,
+		},
+	}
+}
+
+// TestVerify tests verification of attached SignedData
+func TestVerify(t *testing.T) {
+	content := []byte("signed document")
+	signers := testSigners(t)
+
+	withoutAttributes := signers["RSA"]
+	withoutAttributes.noAttributes = true
+
+	edWithoutAttributes := signers["Ed25519"]
+	edWithoutAttributes.noAttributes = true
+
+	tampered := signers["ECDSA"]
+	tampered.tamper = true
+
+	rsaMismatch := signers["RSA"]
+	rsaMismatch.digestOID, rsaMismatch.hash = oidSHA384, crypto.SHA384
+
+	corruptRSA := signers["RSA"]
+	corruptRSA.corrupt = true
+
+	corruptEd25519 := signers["Ed25519"]
+	corruptEd25519.corrupt = true
+
+	ecdsaMismatch := signers["ECDSA"]
+	ecdsaMismatch.signatureOID = oidECDSAWithSHA256
+
+	bareRSA := signers["RSA"]
+	bareRSA.digestOID, bareRSA.hash, bareRSA.signatureOID = oidSHA384, crypto.SHA384, oidRSAEncryption
+
+	gost := signers["RSA"]
+	gost.digestOID = cmsdetector.GOSTR34112012256OID
+	gost.signatureOID = cmsdetector.GOSTR34102012256SignatureOID
+	gost.hash = 0
+	gost.noAttributes = true
+
+	tests := []struct {
+		name        string
+		signer      testSigner
+		certs       []*x509.Certificate
+		expectedErr error
+	}{
+		{name: "RSA", signer: signers["RSA"]},
+		{name: "RSA-PSS", signer: signers["RSA-PSS"]},
+		{name: "ECDSA", signer: signers["ECDSA"]},
+		{name: "Ed25519", signer: signers["Ed25519"]},
+		{name: "RSA without signed attributes", signer: withoutAttributes},
+		{name: "Ed25519 without signed attributes", signer: edWithoutAttributes},
+		{name: "Message digest mismatch", signer: tampered, expectedErr: ErrMessageDigestMismatch},
+		{name: "Invalid RSA signature", signer: corruptRSA, expectedErr: ErrInvalidSignature},
+		{name: "Invalid Ed25519 signature", signer: corruptEd25519, expectedErr: ErrInvalidSignature},
+		{name: "RSA signature hash differs from digest", signer: rsaMismatch, expectedErr: ErrUnsupportedAlgorithm},
+		{name: "ECDSA signature hash differs from digest", signer: ecdsaMismatch, expectedErr: ErrUnsupportedAlgorithm},
+		{name: "RSA key algorithm", signer: bareRSA},
+		{name: "GOST", signer: gost, expectedErr: ErrUnsupportedAlgorithm},
+		{
+			name:        "Missing certificate",
+			signer:      signers["RSA"],
+			certs:       []*x509.Certificate{},
+			expectedErr: ErrSignerCertificateNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				certs := tt.certs
+				if certs == nil {
+					certs = []*x509.Certificate{tt.signer.cert}
+				}
+
+				result, err := Verify(createSignedData(t, content, false, certs, tt.signer))
+				if err != nil {
+					t.Fatalf("Verify returned an error: %v", err)
+				}
+
+				if len(result.Signers) != 1 {
+					t.Fatalf("Expected 1 signer, got %d", len(result.Signers))
+				}
+
+				signer := result.Signers[0]
+				if !errors.Is(signer.Err, tt.expectedErr) || (tt.expectedErr == nil && signer.Err != nil) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, signer.Err)
+				}
+
+				if result.Valid() != (tt.expectedErr == nil) {
+					t.Errorf("Expected Valid %v, got %v", tt.expectedErr == nil, result.Valid())
+				}
+
+				if tt.expectedErr == nil && (len(signer.Chain) != 1 || signer.Chain[0] != signer.Certificate) {
+					t.Errorf("Expected chain with the signer certificate, got %v", signer.Chain)
+				}
+			},
+		)
+	}
+}
+
+// TestVerifyMultipleSigners tests verification of SignedData with parallel signers
+func TestVerifyMultipleSigners(t *testing.T) {
+	content := []byte("co-signed document")
+	signers := testSigners(t)
+
+	data := createSignedData(
+		t, content, false,
+		[]*x509.Certificate{signers["RSA"].cert, signers["ECDSA"].cert},
+		signers["RSA"], signers["ECDSA"],
+	)
+
+	result, err := Verify(data)
+	if err != nil {
+		t.Fatalf("Verify returned an error: %v", err)
+	}
+
+	if len(result.Signers) != 2 || !result.Valid() {
+		t.Errorf("Expected 2 valid signers, got %+v", result.Signers)
+	}
+
+	if len(result.Certificates) != 2 {
+		t.Errorf("Expected 2 certificates, got %d", len(result.Certificates))
+	}
+}
+
+// TestVerifyErrors tests Verify with data that can't be verified
+func TestVerifyErrors(t *testing.T) {
+	signer := testSigners(t)["RSA"]
+
+	detached := createSignedData(t, []byte("content"), true, []*x509.Certificate{signer.cert}, signer)
+	if _, err := Verify(detached); !errors.Is(err, ErrDetachedContentUnavailable) {
+		t.Errorf("Expected ErrDetachedContentUnavailable, got %v", err)
+	}
+
+	data := mustMarshal(t, cmsdetector.ContentInfo{ContentType: cmsdetector.PKCS7DataOID})
+	if _, err := Verify(data); !errors.Is(err, cmsdetector.ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData, got %v", err)
+	}
+
+	if _, err := Verify([]byte{0x01, 0x02}); err == nil {
+		t.Error("Expected error for invalid data, got nil")
+	}
+}
+
+// TestVerifyDetached tests verification of detached SignedData with streamed content
+func TestVerifyDetached(t *testing.T) {
+	content := bytes.Repeat([]byte("large detached document "), 10000)
+	signers := testSigners(t)
+
+	edWithoutAttributes := signers["Ed25519"]
+	edWithoutAttributes.noAttributes = true
+
+	tests := []struct {
+		name        string
+		signer      testSigner
+		content     []byte
+		expectedErr error
+	}{
+		{name: "RSA", signer: signers["RSA"], content: content},
+		{name: "ECDSA", signer: signers["ECDSA"], content: content},
+		{name: "Ed25519", signer: signers["Ed25519"], content: content},
+		{name: "Modified content", signer: signers["RSA"], content: content[1:], expectedErr: ErrMessageDigestMismatch},
+		{
+			name:        "Ed25519 without signed attributes",
+			signer:      edWithoutAttributes,
+			content:     content,
+			expectedErr: ErrUnsupportedAlgorithm,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				data := createSignedData(t, content, true, []*x509.Certificate{tt.signer.cert}, tt.signer)
+
+				result, err := VerifyDetached(data, bytes.NewReader(tt.content))
+				if err != nil {
+					t.Fatalf("VerifyDetached returned an error: %v", err)
+				}
+
+				signer := result.Signers[0]
+				if !errors.Is(signer.Err, tt.expectedErr) || (tt.expectedErr == nil && signer.Err != nil) {
+					t.Errorf("Expected error %v, got %v", tt.expectedErr, signer.Err)
+				}
+			},
+		)
+	}
+}
+
+// failingReader is an io.Reader that always fails
+type failingReader struct{}
+
+// Read implements io.Reader
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+// TestVerifyDetachedReadError tests VerifyDetached with a failing content reader
+func TestVerifyDetachedReadError(t *testing.T) {
+	signer := testSigners(t)["RSA"]
+	data := createSignedData(t, []byte("content"), true, []*x509.Certificate{signer.cert}, signer)
+
+	if _, err := VerifyDetached(data, failingReader{}); err == nil {
+		t.Error("Expected error for failing reader, got nil")
+	}
+}
@@ -0,0 +1,227 @@
+package cmsdetector
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"sync"
+)
+
+// minRSAKeyBits is the minimum RSA modulus size considered secure
+const minRSAKeyBits = 2048
+
+// PBEThresholds are the weakest parameters of password-based key derivations that InspectAlgorithms,
+// and so CheckPolicy with ForbidWeakAlgs and Scanner, accept without a finding. A zero field
+// disables the check
+type PBEThresholds struct {
+	MinIterations int // Minimum iteration count, also of the integrity MAC of PKCS#12 containers
+	MinSaltLength int // Minimum salt length in bytes
+}
+
+// pbeThresholds holds the thresholds in effect, guarded by pbeThresholdsMu
+var (
+	pbeThresholdsMu sync.RWMutex
+	pbeThresholds   = DefaultPBEThresholds()
+)
+
+// DefaultPBEThresholds returns the thresholds in effect unless changed with SetPBEThresholds: the
+// minimum iteration count of NIST SP 800-132 and the minimum salt length of RFC 8018
+func DefaultPBEThresholds() PBEThresholds {
+	return PBEThresholds{
+		MinIterations: 1000,
+		MinSaltLength: 8,
+	}
+}
+
+// SetPBEThresholds replaces the thresholds applied by InspectAlgorithms, e.g. to enforce an internal
+// key hygiene policy. It is safe for concurrent use
+func SetPBEThresholds(t PBEThresholds) {
+	pbeThresholdsMu.Lock()
+	defer pbeThresholdsMu.Unlock()
+
+	pbeThresholds = t
+}
+
+// CurrentPBEThresholds returns the thresholds in effect
+func CurrentPBEThresholds() PBEThresholds {
+	pbeThresholdsMu.RLock()
+	defer pbeThresholdsMu.RUnlock()
+
+	return pbeThresholds
+}
+
+// Weakness reasons reported in findings
+const (
+	reasonWeakDigest     = "weak digest algorithm"
+	reasonWeakEncryption = "weak encryption algorithm"
+)
+
+// weakAlgorithms maps OIDs of deprecated algorithms to the reason they are considered weak
+var weakAlgorithms = map[string]string{
+	"1.2.840.113549.2.5":        reasonWeakDigest,     // MD5
+	"1.3.14.3.2.26":             reasonWeakDigest,     // SHA-1
+	"1.2.840.113549.1.1.4":      reasonWeakDigest,     // MD5 with RSA
+	"1.2.840.113549.1.1.5":      reasonWeakDigest,     // SHA-1 with RSA
+	"1.2.840.10040.4.3":         reasonWeakDigest,     // SHA-1 with DSA
+	"1.2.840.10045.4.1":         reasonWeakDigest,     // ECDSA with SHA-1
+	"1.2.840.113549.2.7":        reasonWeakDigest,     // HMAC with SHA-1
+	"1.3.14.3.2.7":              reasonWeakEncryption, // DES-CBC
+	"1.2.840.113549.3.7":        reasonWeakEncryption, // Triple-DES-CBC
+	"1.2.840.113549.3.2":        reasonWeakEncryption, // RC2-CBC
+	"1.2.840.113549.3.4":        reasonWeakEncryption, // RC4
+	"1.2.840.113549.1.9.16.3.6": reasonWeakEncryption, // Triple-DES Key Wrap
+	"1.2.840.113549.1.5.3":      reasonWeakEncryption, // PBE with MD5 and DES-CBC
+	"1.2.840.113549.1.5.10":     reasonWeakEncryption, // PBE with SHA-1 and DES-CBC
+	"1.2.840.113549.1.12.1.1":   reasonWeakEncryption, // PBE with SHA-1 and 128 bit RC4
+	"1.2.840.113549.1.12.1.2":   reasonWeakEncryption, // PBE with SHA-1 and 40 bit RC4
+	"1.2.840.113549.1.12.1.3":   reasonWeakEncryption, // PBE with SHA-1 and 3-key Triple-DES-CBC
+	"1.2.840.113549.1.12.1.4":   reasonWeakEncryption, // PBE with SHA-1 and 2-key Triple-DES-CBC
+	"1.2.840.113549.1.12.1.5":   reasonWeakEncryption, // PBE with SHA-1 and 128 bit RC2-CBC
+	"1.2.840.113549.1.12.1.6":   reasonWeakEncryption, // PBE with SHA-1 and 40 bit RC2-CBC
+}
+
+// Password-based encryption OIDs (RFC 8018, RFC 7292)
+var (
+	pbkdf2OID      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	pbes1Arc       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5}
+	pkcs12PBEArc   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1}
+	x509CertBagOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+)
+
+// rsaEncryptionOID identifies RSA public keys (RFC 8017)
+var rsaEncryptionOID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+// Finding describes a weakness found in a CMS/PKCS structure
+type Finding struct {
+	Algorithm Algorithm // Algorithm the finding relates to, if any
+	Reason    string
+}
+
+// String returns the reason followed by the algorithm name, if any
+func (f Finding) String() string {
+	if f.Algorithm.Name == "" {
+		return f.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", f.Reason, f.Algorithm.Name)
+}
+
+// pbeParams provides the ASN.1 structure of PBES1 and PKCS#12 PBE parameters
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// pbkdf2Params provides the ASN.1 structure of PBKDF2 parameters (RFC 8018, appendix A.2)
+type pbkdf2Params struct {
+	Salt           asn1.RawValue
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// certBag provides the ASN.1 structure of a PKCS#12 CertBag
+type certBag struct {
+	CertID    asn1.ObjectIdentifier
+	CertValue []byte `asn1:"tag:0,explicit"`
+}
+
+// addFinding adds the finding to the report unless it is already there
+func (r *AlgorithmReport) addFinding(oid asn1.ObjectIdentifier, reason string) {
+	for _, finding := range r.Weak {
+		if finding.Reason == reason && finding.Algorithm.OID.Equal(oid) {
+			return
+		}
+	}
+
+	finding := Finding{Reason: reason}
+	if len(oid) > 0 {
+		finding.Algorithm = Algorithm{OID: oid, Name: GetAlgorithmName(oid)}
+	}
+
+	r.Weak = append(r.Weak, finding)
+}
+
+// flagWeakAlgorithms adds findings for all deprecated algorithms in the report
+func (r *AlgorithmReport) flagWeakAlgorithms() {
+	for _, oid := range r.all() {
+		if reason, ok := weakAlgorithms[oid.String()]; ok {
+			r.addFinding(oid, reason)
+		}
+	}
+}
+
+// checkIterations adds a finding if the key derivation iteration count is below the threshold
+func (r *AlgorithmReport) checkIterations(oid asn1.ObjectIdentifier, iterations int) {
+	if iterations < CurrentPBEThresholds().MinIterations {
+		r.addFinding(oid, fmt.Sprintf("low iteration count: %d", iterations))
+	}
+}
+
+// checkSalt adds a finding if the key derivation salt is shorter than the threshold
+func (r *AlgorithmReport) checkSalt(oid asn1.ObjectIdentifier, salt []byte) {
+	if len(salt) < CurrentPBEThresholds().MinSaltLength {
+		r.addFinding(oid, fmt.Sprintf("short salt: %d bytes", len(salt)))
+	}
+}
+
+// checkPBEParameters adds findings for weak password-based encryption parameters
+func (r *AlgorithmReport) checkPBEParameters(alg pkix.AlgorithmIdentifier) {
+	switch {
+	case alg.Algorithm.Equal(pbes2OID):
+		var params pbes2Params
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return
+		}
+
+		if !params.KeyDerivationFunc.Algorithm.Equal(pbkdf2OID) {
+			return
+		}
+
+		var kdfParams pbkdf2Params
+		if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+			return
+		}
+
+		r.checkIterations(pbkdf2OID, kdfParams.IterationCount)
+
+		// The salt may also be an AlgorithmIdentifier of another source, which is not checked
+		if kdfParams.Salt.Class == asn1.ClassUniversal && kdfParams.Salt.Tag == asn1.TagOctetString {
+			r.checkSalt(pbkdf2OID, kdfParams.Salt.Bytes)
+		}
+	case hasOIDPrefix(alg.Algorithm, pbes1Arc), hasOIDPrefix(alg.Algorithm, pkcs12PBEArc):
+		var params pbeParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return
+		}
+
+		r.checkIterations(alg.Algorithm, params.Iterations)
+		r.checkSalt(alg.Algorithm, params.Salt)
+	}
+}
+
+// checkCertificate adds a finding if the certificate carries a short RSA key
+func (r *AlgorithmReport) checkCertificate(cert *x509.Certificate) {
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return
+	}
+
+	if bits := key.N.BitLen(); bits < minRSAKeyBits {
+		r.addFinding(rsaEncryptionOID, fmt.Sprintf("RSA key too short: %d bits (%s)", bits, cert.Subject.String()))
+	}
+}
+
+// checkCertBag adds findings for the certificate stored in an unencrypted PKCS#12 CertBag
+func (r *AlgorithmReport) checkCertBag(bag safeBag) {
+	var cb certBag
+	if _, err := asn1.Unmarshal(bag.Value.Bytes, &cb); err != nil || !cb.CertID.Equal(x509CertBagOID) {
+		return
+	}
+
+	if cert, err := x509.ParseCertificate(cb.CertValue); err == nil {
+		r.checkCertificate(cert)
+	}
+}
@@ -0,0 +1,261 @@
+package cmsdetector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/lEx0/cmsdetector/cmstest"
+)
+
+// findingReasons returns the reasons of the given findings
+func findingReasons(findings []Finding) []string {
+	reasons := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		reasons = append(reasons, finding.Reason)
+	}
+
+	return reasons
+}
+
+// TestWeakFindings tests flagging of weak algorithms and parameters
+func TestWeakFindings(t *testing.T) {
+	sha1OID := asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	sha256OID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	rsaOID := asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	aesOID := asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	desEDE3OID := asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	pbeParameters, err := asn1.Marshal(pbeParams{Salt: make([]byte, 8), Iterations: 100})
+	if err != nil {
+		t.Fatalf("Failed to marshal PBE parameters: %v", err)
+	}
+
+	keyInfo := encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3},
+			Parameters: asn1.RawValue{FullBytes: pbeParameters},
+		},
+		EncryptedData: []byte{0x01},
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected []string
+	}{
+		{
+			name:     "SHA-1 digest",
+			data:     createSignedData(t, sha1OID, rsaOID),
+			expected: []string{reasonWeakDigest},
+		},
+		{
+			name:     "Triple-DES encryption",
+			data:     createEnvelopedData(t, rsaOID, desEDE3OID),
+			expected: []string{reasonWeakEncryption},
+		},
+		{
+			name:     "Short RSA key",
+			data:     createSignedDataWithCertificates(t, sha256OID, rsaOID, createCertificate(t, "Weak", rsaKey)),
+			expected: []string{"RSA key too short: 1024 bits (CN=Weak)"},
+		},
+		{
+			name:     "EC key",
+			data:     createSignedDataWithCertificates(t, sha256OID, rsaOID, createCertificate(t, "EC", ecKey)),
+			expected: []string{},
+		},
+		{
+			name: "Low PBE iteration count",
+			data: createPFX(t, []safeBag{createSafeBag(t, PKCS12ShroudedKeyBagOID, keyInfo)}, nil),
+			expected: []string{
+				"low iteration count: 100",
+				reasonWeakEncryption,
+			},
+		},
+		{
+			name:     "Strong algorithms",
+			data:     createEnvelopedData(t, rsaOID, aesOID),
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				report, err := InspectAlgorithms(tt.data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				if reasons := findingReasons(report.Weak); !equalStrings(reasons, tt.expected) {
+					t.Errorf("Expected findings %v, got %v", tt.expected, reasons)
+				}
+			},
+		)
+	}
+}
+
+// createEncryptedPrivateKey creates an encrypted PKCS#8 key using PBES2 with PBKDF2, an 8 byte salt
+// and the iteration count
+func createEncryptedPrivateKey(t *testing.T, iterations int) []byte {
+	t.Helper()
+
+	marshal := func(value interface{}) asn1.RawValue {
+		encoded, err := asn1.Marshal(value)
+		if err != nil {
+			t.Fatalf("Failed to marshal %T: %v", value, err)
+		}
+
+		return asn1.RawValue{FullBytes: encoded}
+	}
+
+	kdf := pbkdf2Params{Salt: asn1.RawValue{Tag: asn1.TagOctetString, Bytes: make([]byte, 8)}, IterationCount: iterations}
+	scheme := pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: pbkdf2OID, Parameters: marshal(kdf)},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: cmstest.AES256CBCOID, Parameters: marshal(make([]byte, 16))},
+	}
+
+	return marshal(
+		encryptedPrivateKeyInfo{
+			Algorithm:     pkix.AlgorithmIdentifier{Algorithm: pbes2OID, Parameters: marshal(scheme)},
+			EncryptedData: make([]byte, 32),
+		},
+	).FullBytes
+}
+
+// withPBEThresholds applies the PBE thresholds for the duration of the test
+func withPBEThresholds(t *testing.T, thresholds PBEThresholds) {
+	t.Helper()
+
+	previous := CurrentPBEThresholds()
+	SetPBEThresholds(thresholds)
+
+	t.Cleanup(
+		func() {
+			SetPBEThresholds(previous)
+		},
+	)
+}
+
+// TestPBEThresholds tests flagging of key derivations below configured iteration counts and salt lengths
+func TestPBEThresholds(t *testing.T) {
+	shortSalt, err := asn1.Marshal(pbeParams{Salt: make([]byte, 4), Iterations: 2048})
+	if err != nil {
+		t.Fatalf("Failed to marshal PBE parameters: %v", err)
+	}
+
+	encryptedKey, err := asn1.Marshal(
+		encryptedPrivateKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3},
+				Parameters: asn1.RawValue{FullBytes: shortSalt},
+			},
+			EncryptedData: make([]byte, 16),
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to marshal encrypted private key: %v", err)
+	}
+
+	// cmstest containers use PBES2 with 8 byte salts and an HMAC with SHA-256 integrity MAC
+	pfx := cmstest.PFX(t, cmstest.WithIterations(2048))
+
+	tests := []struct {
+		name       string
+		thresholds PBEThresholds
+		data       []byte
+		expected   []string
+	}{
+		{
+			name:       "PKCS#12 meeting the defaults",
+			thresholds: DefaultPBEThresholds(),
+			data:       pfx,
+			expected:   []string{},
+		},
+		{
+			name:       "PKCS#12 below the iteration count",
+			thresholds: PBEThresholds{MinIterations: 10000},
+			data:       pfx,
+			expected:   []string{"low iteration count: 2048", "low iteration count: 2048"},
+		},
+		{
+			name:       "PKCS#12 below the salt length",
+			thresholds: PBEThresholds{MinSaltLength: 16},
+			data:       pfx,
+			expected:   []string{"short salt: 8 bytes", "short salt: 8 bytes"},
+		},
+		{
+			name:       "PKCS#8 below the default salt length",
+			thresholds: DefaultPBEThresholds(),
+			data:       encryptedKey,
+			expected:   []string{"short salt: 4 bytes", reasonWeakEncryption},
+		},
+		{
+			name:       "Checks disabled",
+			thresholds: PBEThresholds{},
+			data:       encryptedKey,
+			expected:   []string{reasonWeakEncryption},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				withPBEThresholds(t, tt.thresholds)
+
+				report, err := InspectAlgorithms(tt.data)
+				if err != nil {
+					t.Fatalf("InspectAlgorithms returned an error: %v", err)
+				}
+
+				if reasons := findingReasons(report.Weak); !equalStrings(reasons, tt.expected) {
+					t.Errorf("Expected findings %v, got %v", tt.expected, reasons)
+				}
+			},
+		)
+	}
+}
+
+// TestFindingString tests the description of findings
+func TestFindingString(t *testing.T) {
+	tests := []struct {
+		name     string
+		finding  Finding
+		expected string
+	}{
+		{
+			name:     "With algorithm",
+			finding:  Finding{Algorithm: Algorithm{OID: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}, Name: "SHA-1"}, Reason: reasonWeakDigest},
+			expected: "weak digest algorithm: SHA-1",
+		},
+		{
+			name:     "Without algorithm",
+			finding:  Finding{Reason: "RSA key too short: 1024 bits"},
+			expected: "RSA key too short: 1024 bits",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				if got := tt.finding.String(); got != tt.expected {
+					t.Errorf("Expected %q, got %q", tt.expected, got)
+				}
+			},
+		)
+	}
+}
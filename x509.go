@@ -0,0 +1,60 @@
+package cmsdetector
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+)
+
+// signedEnvelope provides the ASN.1 structure shared by certificates, CRLs and certification requests
+type signedEnvelope struct {
+	TBS                asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+// tbsCertificate provides the leading fields of TBSCertificate (RFC 5280, section 4.1)
+type tbsCertificate struct {
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           asn1.RawValue
+	Subject            asn1.RawValue
+	PublicKey          subjectPublicKeyInfo
+}
+
+// certificationRequestInfo provides the ASN.1 structure of CertificationRequestInfo (RFC 2986, section 4.1)
+type certificationRequestInfo struct {
+	Version    int
+	Subject    asn1.RawValue
+	PublicKey  subjectPublicKeyInfo
+	Attributes asn1.RawValue `asn1:"tag:0"`
+}
+
+// subjectPublicKeyInfo provides the ASN.1 structure of SubjectPublicKeyInfo
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// detectX509 detects DER encoded certificates and certification requests by their structure,
+// so certificates with algorithms unsupported by crypto/x509 (e.g. GOST) are recognized as well
+func detectX509(der []byte) (Kind, bool) {
+	var envelope signedEnvelope
+	if rest, err := asn1.Unmarshal(der, &envelope); err != nil || len(rest) > 0 {
+		return KindUnknown, false
+	}
+
+	var tbs tbsCertificate
+	if _, err := asn1.Unmarshal(envelope.TBS.FullBytes, &tbs); err == nil {
+		return KindCertificate, true
+	}
+
+	var cri certificationRequestInfo
+	if _, err := asn1.Unmarshal(envelope.TBS.FullBytes, &cri); err == nil {
+		return KindCertificateRequest, true
+	}
+
+	return KindUnknown, false
+}
@@ -0,0 +1,100 @@
+package cmsdetector
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// xmldsigNamespace is the namespace of XML Signature elements (W3C XMLDSig)
+const xmldsigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+
+// xadesNamespaces lists the namespaces of the XAdES QualifyingProperties element (ETSI EN 319 132-1)
+var xadesNamespaces = map[string]bool{
+	"http://uri.etsi.org/01903/v1.1.1#": true,
+	"http://uri.etsi.org/01903/v1.3.2#": true,
+}
+
+// utf8BOM is the byte order mark some tools write at the start of UTF-8 XML documents
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ErrNotXMLSignature is returned when the data is not an XML document with an XML signature
+var ErrNotXMLSignature = errors.New("not an XML signature document")
+
+// XMLSignatureResult describes a detected XML signature document
+type XMLSignatureResult struct {
+	Kind       Kind // KindXMLSignature, or KindXAdES when XAdES qualifying properties are present
+	Signatures int  // Number of ds:Signature elements
+	Enveloped  bool // The first signature is nested in the signed document instead of being its root
+}
+
+// DetectXMLSignature detects XML documents carrying XMLDSig signatures and tells XAdES signatures
+// apart by their qualifying properties. Elements are matched by namespace, whatever the prefix.
+// Only the document structure is classified, signatures are neither canonicalized nor verified
+func DetectXMLSignature(data []byte) (result XMLSignatureResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := checkInputSize(data); err != nil {
+		return XMLSignatureResult{}, err
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return XMLSignatureResult{}, ErrNotXMLSignature
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+
+	// Element names are ASCII, so documents declaring other encodings are classified as is
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	depth := 0
+	xades := false
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return XMLSignatureResult{}, ErrNotXMLSignature
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			depth++
+			if exceedsNestingDepth(depth) {
+				return XMLSignatureResult{}, fmt.Errorf("XML nesting deeper than %d levels", CurrentLimits().MaxNestingDepth)
+			}
+
+			switch {
+			case element.Name.Space == xmldsigNamespace && element.Name.Local == "Signature":
+				if result.Signatures == 0 {
+					result.Enveloped = depth > 1
+				}
+
+				result.Signatures++
+			case xadesNamespaces[element.Name.Space] && element.Name.Local == "QualifyingProperties":
+				xades = true
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	if result.Signatures == 0 {
+		return XMLSignatureResult{}, ErrNotXMLSignature
+	}
+
+	result.Kind = KindXMLSignature
+	if xades {
+		result.Kind = KindXAdES
+	}
+
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package cmsdetector
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestDetectXMLSignature tests detection of XMLDSig and XAdES documents
+func TestDetectXMLSignature(t *testing.T) {
+	signature := `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#">` +
+		`<ds:SignedInfo/><ds:SignatureValue>c2ln</ds:SignatureValue></ds:Signature>`
+	xades := `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:SignedInfo/>` +
+		`<ds:Object><xades:QualifyingProperties xmlns:xades="http://uri.etsi.org/01903/v1.3.2#">` +
+		`<xades:SignedProperties/></xades:QualifyingProperties></ds:Object></ds:Signature>`
+
+	tests := []struct {
+		name        string
+		data        string
+		expected    XMLSignatureResult
+		expectedErr error
+	}{
+		{
+			name:     "Enveloped XMLDSig",
+			data:     `<?xml version="1.0" encoding="UTF-8"?><invoice><amount>10</amount>` + signature + `</invoice>`,
+			expected: XMLSignatureResult{Kind: KindXMLSignature, Signatures: 1, Enveloped: true},
+		},
+		{
+			name:     "Enveloping XMLDSig",
+			data:     signature,
+			expected: XMLSignatureResult{Kind: KindXMLSignature, Signatures: 1},
+		},
+		{
+			name:     "XAdES",
+			data:     "\xEF\xBB\xBF\n" + xades,
+			expected: XMLSignatureResult{Kind: KindXAdES, Signatures: 1},
+		},
+		{
+			name: "Default namespace and countersignature",
+			data: `<doc><Signature xmlns="http://www.w3.org/2000/09/xmldsig#"><SignedInfo/></Signature>` +
+				`<sig:Signature xmlns:sig="http://www.w3.org/2000/09/xmldsig#"/></doc>`,
+			expected: XMLSignatureResult{Kind: KindXMLSignature, Signatures: 2, Enveloped: true},
+		},
+		{
+			name:     "Windows-1251 declaration",
+			data:     `<?xml version="1.0" encoding="windows-1251"?><doc>` + signature + `</doc>`,
+			expected: XMLSignatureResult{Kind: KindXMLSignature, Signatures: 1, Enveloped: true},
+		},
+		{
+			name:        "Signature element in another namespace",
+			data:        `<doc><Signature>text</Signature></doc>`,
+			expectedErr: ErrNotXMLSignature,
+		},
+		{
+			name:        "Plain XML",
+			data:        `<?xml version="1.0"?><doc><item/></doc>`,
+			expectedErr: ErrNotXMLSignature,
+		},
+		{
+			name:        "Malformed XML",
+			data:        `<doc>` + signature + `</invoice>`,
+			expectedErr: ErrNotXMLSignature,
+		},
+		{
+			name:        "Not XML",
+			data:        `{"signature":"<ds:Signature>"}`,
+			expectedErr: ErrNotXMLSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				result, err := DetectXMLSignature([]byte(tt.data))
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+				}
+
+				if result != tt.expected {
+					t.Errorf("Expected %+v, got %+v", tt.expected, result)
+				}
+			},
+		)
+	}
+}